@@ -9,10 +9,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/andmang/agent-sdk-go/pkg/agent"
 	"github.com/andmang/agent-sdk-go/pkg/config"
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/llm/anthropic"
 	"github.com/andmang/agent-sdk-go/pkg/llm/gemini"
 	"github.com/andmang/agent-sdk-go/pkg/llm/ollama"
@@ -1433,26 +1435,82 @@ func listProviders() {
 }
 
 func listModels() {
-	fmt.Println("Popular Models by Provider:")
+	fmt.Println("Known Models by Provider:")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("OpenAI:")
-	fmt.Println("  - gpt-4o-mini (fast, cost-effective)")
-	fmt.Println("  - gpt-4o (most capable)")
-	fmt.Println("  - gpt-3.5-turbo (legacy)")
-	fmt.Println()
-	fmt.Println("Anthropic:")
-	fmt.Println("  - claude-3-5-sonnet-20241022 (most capable)")
-	fmt.Println("  - claude-3-haiku-20240307 (fast)")
-	fmt.Println()
-	fmt.Println("Vertex AI:")
-	fmt.Println("  - gemini-1.5-pro (most capable)")
-	fmt.Println("  - gemini-1.5-flash (fast)")
-	fmt.Println("  - gemini-2.0-flash-exp (experimental)")
+
+	providerNames := map[string]string{
+		"openai":    "OpenAI",
+		"anthropic": "Anthropic",
+		"gemini":    "Google Gemini",
+		"local":     "Local (Ollama, vLLM)",
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	provider := "-"
+	for _, model := range llm.DefaultModelRegistry().Models() {
+		if model.Provider != provider {
+			if provider != "-" {
+				fmt.Fprintln(w)
+			}
+			provider = model.Provider
+			name, ok := providerNames[provider]
+			if !ok {
+				name = provider
+			}
+			fmt.Fprintf(w, "%s:\n", name)
+			fmt.Fprintln(w, "  MODEL\tCONTEXT\tOUTPUT\tFEATURES\tPRICE (IN/OUT PER 1M)")
+		}
+
+		price := "-"
+		if model.Price != nil {
+			price = fmt.Sprintf("$%g / $%g", model.Price.InputPerMillion, model.Price.OutputPerMillion)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", model.Name, formatTokens(model.ContextWindow),
+			formatTokens(model.MaxOutputTokens), modelFeatures(model), price)
+	}
+	w.Flush()
+
 	fmt.Println()
-	fmt.Println("Ollama (local):")
-	fmt.Println("  - llama3.2:latest")
-	fmt.Println("  - mistral:latest")
-	fmt.Println("  - codellama:latest")
+	fmt.Println("Names match by prefix, e.g. gpt-4o also covers gpt-4o-2024-08-06.")
+	fmt.Println("Register fine-tuned or self-hosted models with llm.RegisterModel.")
+}
+
+// modelFeatures lists the optional features supported by a model
+func modelFeatures(model llm.ModelInfo) string {
+	var features []string
+	if model.SupportsTools {
+		features = append(features, "tools")
+	}
+	if model.SupportsVision {
+		features = append(features, "vision")
+	}
+	if model.SupportsReasoning {
+		features = append(features, "reasoning")
+	}
+	if model.SupportsStructuredOutput {
+		features = append(features, "json")
+	}
+	if model.SupportsStreaming {
+		features = append(features, "streaming")
+	}
+	if len(features) == 0 {
+		return "-"
+	}
+	return strings.Join(features, ",")
+}
+
+// formatTokens formats a token count such as 128000 as "128K"
+func formatTokens(tokens int) string {
+	switch {
+	case tokens <= 0:
+		return "-"
+	case tokens >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(tokens)/1000000)
+	case tokens >= 1000:
+		return fmt.Sprintf("%dK", tokens/1000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
 }
 
 func listTools() {
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
-	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/llm/azureopenai"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 )
@@ -118,18 +117,18 @@ func main() {
 
 	// Test 2: Chat completion
 	logger.Info(ctx, "Testing chat completion...", nil)
-	messages := []llm.Message{
+	messages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a helpful programming assistant.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "What's the best way to handle errors in Go?",
 		},
 	}
 
-	resp, err = client.Chat(ctx, messages, nil)
+	resp, err = client.ChatMessages(ctx, messages)
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
@@ -138,20 +137,18 @@ func main() {
 
 	// Test 3: Multi-turn conversation with Chat method
 	logger.Info(ctx, "Testing multi-turn conversation...", nil)
-	multiTurnMessages := []llm.Message{
+	multiTurnMessages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a senior Go programmer who provides concise code examples.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "Show me how to implement a simple HTTP server in Go.",
 		},
 	}
 
-	resp, err = client.Chat(ctx, multiTurnMessages, &llm.GenerateParams{
-		Temperature: 0.5,
-	})
+	resp, err = client.ChatMessages(ctx, multiTurnMessages, interfaces.WithTemperature(0.5))
 	if err != nil {
 		logger.Error(ctx, "Failed multi-turn chat", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
@@ -4,7 +4,7 @@ import (
 	"context"
 	"os"
 
-	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 )
@@ -42,18 +42,18 @@ func main() {
 	logger.Info(ctx, "Generated text", map[string]interface{}{"text": resp})
 
 	// Test chat
-	messages := []llm.Message{
+	messages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a helpful programming assistant.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "What's the best way to handle errors in Go?",
 		},
 	}
 
-	resp, err = client.Chat(ctx, messages, nil)
+	resp, err = client.ChatMessages(ctx, messages)
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
@@ -61,20 +61,18 @@ func main() {
 	logger.Info(ctx, "Chat response", map[string]interface{}{"text": resp})
 
 	// Example of multi-turn conversation with Chat method
-	multiTurnMessages := []llm.Message{
+	multiTurnMessages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a senior Go programmer who provides concise code examples.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "Show me how to implement a simple HTTP server in Go.",
 		},
 	}
 
-	resp, err = client.Chat(ctx, multiTurnMessages, &llm.GenerateParams{
-		Temperature: 0.5,
-	})
+	resp, err = client.ChatMessages(ctx, multiTurnMessages, interfaces.WithTemperature(0.5))
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
@@ -82,21 +80,19 @@ func main() {
 	logger.Info(ctx, "First response", map[string]interface{}{"text": resp})
 
 	// Add the assistant's response to continue the conversation
-	multiTurnMessages = append(multiTurnMessages, llm.Message{
-		Role:    "assistant",
+	multiTurnMessages = append(multiTurnMessages, interfaces.Message{
+		Role:    interfaces.MessageRoleAssistant,
 		Content: resp,
 	})
 
 	// Add a follow-up question
-	multiTurnMessages = append(multiTurnMessages, llm.Message{
-		Role:    "user",
+	multiTurnMessages = append(multiTurnMessages, interfaces.Message{
+		Role:    interfaces.MessageRoleUser,
 		Content: "How would I add middleware for logging requests?",
 	})
 
 	// Get the next response
-	resp, err = client.Chat(ctx, multiTurnMessages, &llm.GenerateParams{
-		Temperature: 0.5,
-	})
+	resp, err = client.ChatMessages(ctx, multiTurnMessages, interfaces.WithTemperature(0.5))
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 )
@@ -91,24 +91,22 @@ func main() {
 
 	// Demonstrate reasoning with Chat method
 	fmt.Println("\n=== Reasoning with Chat API ===")
-	messages := []llm.Message{
+	messages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a helpful assistant who explains concepts clearly.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "Explain the concept of recursion in programming.",
 		},
 	}
 
-	resp, err = client.Chat(
+	resp, err = client.ChatMessages(
 		ctx,
 		messages,
-		&llm.GenerateParams{
-			Temperature: 0.3,
-			Reasoning:   "comprehensive",
-		},
+		interfaces.WithTemperature(0.3),
+		openai.WithReasoning("comprehensive"),
 	)
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
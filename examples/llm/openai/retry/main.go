@@ -5,7 +5,7 @@ import (
 	"os"
 	"time"
 
-	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/andmang/agent-sdk-go/pkg/retry"
@@ -52,20 +52,18 @@ func main() {
 
 	// Example 2: Chat with retry and custom parameters
 	logger.Info(ctx, "Example 2: Chat with retry and custom parameters", nil)
-	messages := []llm.Message{
+	messages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a helpful assistant who provides detailed explanations.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "Explain the concept of exponential backoff in retry mechanisms.",
 		},
 	}
 
-	resp, err = client.Chat(ctx, messages, &llm.GenerateParams{
-		Temperature: 0.5,
-	})
+	resp, err = client.ChatMessages(ctx, messages, interfaces.WithTemperature(0.5))
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/llm/vllm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/andmang/agent-sdk-go/pkg/retry"
@@ -57,20 +57,18 @@ func main() {
 
 	// Test 2: Chat conversation
 	fmt.Println("=== Test 2: Chat Conversation ===")
-	messages := []llm.Message{
+	messages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a helpful programming assistant.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "What's the best way to handle errors in Go?",
 		},
 	}
 
-	resp, err = client.Chat(ctx, messages, &llm.GenerateParams{
-		Temperature: 0.5,
-	})
+	resp, err = client.ChatMessages(ctx, messages, interfaces.WithTemperature(0.5))
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 	} else {
@@ -79,20 +77,18 @@ func main() {
 
 	// Test 3: Multi-turn conversation
 	fmt.Println("=== Test 3: Multi-turn Conversation ===")
-	multiTurnMessages := []llm.Message{
+	multiTurnMessages := []interfaces.Message{
 		{
-			Role:    "system",
+			Role:    interfaces.MessageRoleSystem,
 			Content: "You are a senior Go programmer who provides concise code examples.",
 		},
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleUser,
 			Content: "Show me how to implement a simple HTTP server in Go.",
 		},
 	}
 
-	resp, err = client.Chat(ctx, multiTurnMessages, &llm.GenerateParams{
-		Temperature: 0.3,
-	})
+	resp, err = client.ChatMessages(ctx, multiTurnMessages, interfaces.WithTemperature(0.3))
 	if err != nil {
 		logger.Error(ctx, "Failed to chat", map[string]interface{}{"error": err.Error()})
 	} else {
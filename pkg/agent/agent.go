@@ -8,14 +8,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andmang/agent-sdk-go/pkg/contextwindow"
 	"github.com/andmang/agent-sdk-go/pkg/executionplan"
 	"github.com/andmang/agent-sdk-go/pkg/grpc/client"
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/andmang/agent-sdk-go/pkg/mcp"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 	"github.com/andmang/agent-sdk-go/pkg/tools"
+	"github.com/andmang/agent-sdk-go/pkg/toolselection"
 	"github.com/andmang/agent-sdk-go/pkg/tracing"
 )
 
@@ -67,7 +70,21 @@ type Agent struct {
 	mcpServers           []interfaces.MCPServer   // MCP servers for the agent
 	lazyMCPConfigs       []LazyMCPConfig          // Lazy MCP server configurations
 	maxIterations        int                      // Maximum number of tool-calling iterations (default: 2)
+	maxParallelToolCalls int                      // Maximum number of tool calls of one turn run concurrently (default: sequential)
 	streamConfig         *interfaces.StreamConfig // Streaming configuration for the agent
+	priceTable           *llm.PriceTable          // Model prices used to compute the cost of a run
+	contextWindow        *contextwindow.Manager   // Fits conversation history to the model's context window
+	toolSelector         *toolselection.Selector  // Selects the tools given to the LLM in a run
+	toolChoice           *interfaces.ToolChoice   // Whether and which tools the LLM must call (default: auto)
+	hooks                []Hooks                  // Hooks and middleware run at the steps of local runs
+	approvalPolicies     []ApprovalPolicy         // Policies selecting the tool calls that require human approval
+	approvalStore        interfaces.ApprovalStore // Store for tool calls waiting for approval
+	approvalHandler      func(context.Context, interfaces.ToolApproval)
+	approvalTimeout      time.Duration              // How long a tool call waits for approval (negative: no limit)
+	approvals            approvalWaiters            // Tool calls of this process waiting for approval
+	checkpointStore      interfaces.CheckpointStore // Store for the state of runs, to resume them
+	budget               *RunBudget                 // Limits on the resources of each run
+	reflection           *ReflectionConfig          // Critique and revision of the answers
 
 	// Remote agent fields
 	isRemote      bool                      // Whether this is a remote agent
@@ -209,6 +226,14 @@ func WithMaxIterations(maxIterations int) Option {
 	}
 }
 
+// WithParallelToolCalls lets the agent execute up to maxParallel tool calls
+// requested in the same LLM turn concurrently
+func WithParallelToolCalls(maxParallel int) Option {
+	return func(a *Agent) {
+		a.maxParallelToolCalls = maxParallel
+	}
+}
+
 // WithStreamConfig sets the streaming configuration for the agent
 func WithStreamConfig(config *interfaces.StreamConfig) Option {
 	return func(a *Agent) {
@@ -216,6 +241,30 @@ func WithStreamConfig(config *interfaces.StreamConfig) Option {
 	}
 }
 
+// WithPriceTable sets the model price table used to compute the cost of a run
+func WithPriceTable(prices *llm.PriceTable) Option {
+	return func(a *Agent) {
+		a.priceTable = prices
+	}
+}
+
+// WithContextWindow fits the conversation history sent to the LLM into the
+// model's context window with the given manager. The memory itself keeps the
+// full history.
+func WithContextWindow(manager *contextwindow.Manager) Option {
+	return func(a *Agent) {
+		a.contextWindow = manager
+	}
+}
+
+// WithToolChoice forces or forbids tool use on every run of the agent. Use
+// WithRunToolChoice to override it for a single run.
+func WithToolChoice(choice interfaces.ToolChoice) Option {
+	return func(a *Agent) {
+		a.toolChoice = &choice
+	}
+}
+
 // WithURL creates a remote agent that communicates via gRPC
 func WithURL(url string) Option {
 	return func(a *Agent) {
@@ -293,6 +342,10 @@ func validateLocalAgent(agent *Agent) (*Agent, error) {
 		return nil, fmt.Errorf("LLM is required for local agents")
 	}
 
+	if err := agent.validateModel(); err != nil {
+		return nil, fmt.Errorf("model validation failed: %w", err)
+	}
+
 	// Validate sub-agents if present
 	if len(agent.subAgents) > 0 {
 		// Check for circular dependencies
@@ -309,10 +362,20 @@ func validateLocalAgent(agent *Agent) (*Agent, error) {
 	// Configure sub-agent tools with logger and tracer
 	agent.configureSubAgentTools()
 
+	// Route memory writes through the hooks
+	agent.hookMemory()
+
+	if agent.approvalStore == nil {
+		agent.approvalStore = NewInMemoryApprovalStore()
+	}
+	if agent.approvalTimeout == 0 {
+		agent.approvalTimeout = DefaultApprovalTimeout
+	}
+
 	// Initialize execution plan components
 	agent.planStore = executionplan.NewStore()
 	agent.planGenerator = executionplan.NewGenerator(agent.llm, agent.tools, agent.systemPrompt)
-	agent.planExecutor = executionplan.NewExecutor(agent.tools)
+	agent.planExecutor = executionplan.NewExecutor(agent.hookTools(agent.tools))
 
 	return agent, nil
 }
@@ -420,20 +483,49 @@ func CreateAgentForTask(taskName string, agentConfigs AgentConfigs, taskConfigs
 	return NewAgentFromConfig(agentName, agentConfigs, variables, options...)
 }
 
-// Run runs the agent with the given input
+// Run runs the agent with the given input. Use RunDetailed to get a trace of
+// the run.
 func (a *Agent) Run(ctx context.Context, input string) (string, error) {
-	// If custom run function is set, use it instead
+	result, err := a.RunDetailed(ctx, input)
+	return result.Output, err
+}
+
+// RunWithParts runs the agent with multimodal input, such as a question with
+// screenshots or scanned pages. The text of the parts is the input used by
+// guardrails and execution plans, and all parts are stored in memory and sent
+// to the LLM.
+func (a *Agent) RunWithParts(ctx context.Context, parts ...interfaces.ContentPart) (string, error) {
 	if a.customRunFunc != nil {
-		return a.customRunFunc(ctx, input, a)
+		return "", fmt.Errorf("multimodal input is not supported with a custom run function")
 	}
-
-	// If this is a remote agent, delegate to remote execution
 	if a.isRemote {
-		return a.runRemote(ctx, input)
+		return "", fmt.Errorf("multimodal input is not supported by remote agents")
+	}
+	return a.runLocal(ctx, interfaces.ContentText(parts), parts)
+}
+
+// RunWithUsage executes the agent and returns the token usage of the run,
+// including the usage of every tool-loop iteration and of any sub-agents.
+// The usage is priced with the agent's price table when one is configured.
+func (a *Agent) RunWithUsage(ctx context.Context, input string) (string, llm.UsageSummary, error) {
+	result, err := a.RunDetailed(ctx, input)
+	return result.Output, result.Usage, err
+}
+
+// summarizeUsage totals the usage collected in the context and logs it
+func (a *Agent) summarizeUsage(ctx context.Context) llm.UsageSummary {
+	summary := llm.Summarize(llm.GetUsageFromContext(ctx), a.priceTable)
+	if a.logger != nil && len(summary.Records) > 0 {
+		a.logger.Debug(ctx, "Agent run token usage", map[string]interface{}{
+			"agent":         a.name,
+			"llm_calls":     len(summary.Records),
+			"input_tokens":  summary.Total.InputTokens,
+			"output_tokens": summary.Total.OutputTokens,
+			"total_tokens":  summary.Total.TotalTokens,
+			"cost":          summary.Cost,
+		})
 	}
-
-	// Local agent execution
-	return a.runLocal(ctx, input)
+	return summary
 }
 
 // RunWithAuth executes the agent with an explicit auth token
@@ -444,7 +536,7 @@ func (a *Agent) RunWithAuth(ctx context.Context, input string, authToken string)
 	}
 
 	// For local agents, the auth token isn't used but we maintain compatibility
-	return a.runLocal(ctx, input)
+	return a.runLocal(ctx, input, nil)
 }
 
 // RunStreamWithAuth executes the agent with streaming response and explicit auth token
@@ -458,20 +550,6 @@ func (a *Agent) RunStreamWithAuth(ctx context.Context, input string, authToken s
 	return a.RunStream(ctx, input)
 }
 
-// runRemote executes a remote agent via gRPC
-func (a *Agent) runRemote(ctx context.Context, input string) (string, error) {
-	if a.remoteClient == nil {
-		return "", fmt.Errorf("remote client not initialized")
-	}
-
-	// If orgID is set on the agent, add it to the context
-	if a.orgID != "" {
-		ctx = multitenancy.WithOrgID(ctx, a.orgID)
-	}
-
-	return a.remoteClient.Run(ctx, input)
-}
-
 // runRemoteWithAuth executes a remote agent via gRPC with explicit auth token
 func (a *Agent) runRemoteWithAuth(ctx context.Context, input string, authToken string) (string, error) {
 	if a.remoteClient == nil {
@@ -500,8 +578,9 @@ func (a *Agent) runRemoteStreamWithAuth(ctx context.Context, input string, authT
 	return a.remoteClient.RunStreamWithAuth(ctx, input, authToken)
 }
 
-// runLocal executes a local agent
-func (a *Agent) runLocal(ctx context.Context, input string) (string, error) {
+// runLocal executes a local agent. parts holds the content of multimodal
+// input, input its text.
+func (a *Agent) runLocal(ctx context.Context, input string, parts []interfaces.ContentPart) (result string, err error) {
 	// Inject agent name into context for tracing span naming
 	ctx = tracing.WithAgentName(ctx, a.name)
 
@@ -510,6 +589,9 @@ func (a *Agent) runLocal(ctx context.Context, input string) (string, error) {
 		ctx = multitenancy.WithOrgID(ctx, a.orgID)
 	}
 
+	// Track the resources of the run if it has a budget
+	ctx = a.startBudget(ctx)
+
 	// Start tracing if available
 	var span interfaces.Span
 	if a.tracer != nil {
@@ -517,11 +599,18 @@ func (a *Agent) runLocal(ctx context.Context, input string) (string, error) {
 		defer span.End()
 	}
 
+	defer func() {
+		if err != nil {
+			a.notifyError(ctx, err)
+		}
+	}()
+
 	// Add user message to memory
 	if a.memory != nil {
 		if err := a.memory.AddMessage(ctx, interfaces.Message{
 			Role:    interfaces.MessageRoleUser,
 			Content: input,
+			Parts:   parts,
 		}); err != nil {
 			return "", fmt.Errorf("failed to add user message to memory: %w", err)
 		}
@@ -559,6 +648,31 @@ func (a *Agent) runLocal(ctx context.Context, input string) (string, error) {
 		return response, nil
 	}
 
+	allTools := a.runTools(ctx)
+
+	// If tools are available and plan approval is required, generate an execution plan
+	if (len(allTools) > 0) && a.requirePlanApproval {
+		a.planGenerator = executionplan.NewGenerator(a.llm, allTools, a.systemPrompt)
+		return a.runWithExecutionPlan(ctx, input)
+	}
+
+	// Checkpoint the run if a store is configured
+	if a.checkpointStore != nil {
+		var run *checkpointRun
+		ctx, run, err = a.startConversationRun(ctx, input, parts)
+		if err != nil {
+			return "", err
+		}
+		defer func() { run.finish(ctx, result, err) }()
+	}
+
+	// Otherwise, run without an execution plan
+	return a.runWithoutExecutionPlanWithTools(ctx, input, parts, allTools)
+}
+
+// runTools returns the tools of a run: the agent's tools and those of its MCP
+// servers
+func (a *Agent) runTools(ctx context.Context) []interfaces.Tool {
 	allTools := a.tools
 
 	// Add MCP tools if available
@@ -577,14 +691,7 @@ func (a *Agent) runLocal(ctx context.Context, input string) (string, error) {
 		lazyMCPTools := a.createLazyMCPTools()
 		allTools = append(allTools, lazyMCPTools...)
 	}
-	// If tools are available and plan approval is required, generate an execution plan
-	if (len(allTools) > 0) && a.requirePlanApproval {
-		a.planGenerator = executionplan.NewGenerator(a.llm, allTools, a.systemPrompt)
-		return a.runWithExecutionPlan(ctx, input)
-	}
-
-	// Otherwise, run without an execution plan
-	return a.runWithoutExecutionPlanWithTools(ctx, input, allTools)
+	return allTools
 }
 
 // collectMCPTools collects tools from all MCP servers
@@ -640,11 +747,30 @@ func (a *Agent) createLazyMCPTools() []interfaces.Tool {
 	return lazyTools
 }
 
+// llmMemory returns the memory passed to the LLM, which holds the history
+// fitted to the context window if the agent has a context window manager
+func (a *Agent) llmMemory(ctx context.Context, tools []interfaces.Tool) interfaces.Memory {
+	var memory interfaces.Memory = a.memory
+	if run := a.currentRun(ctx); run != nil {
+		memory = run.memory
+	}
+	if memory == nil || a.contextWindow == nil {
+		return memory
+	}
+	return a.contextWindow.Wrap(memory, a.systemPrompt, tools)
+}
+
 // runWithoutExecutionPlanWithTools runs the agent without an execution plan but with the specified tools
-func (a *Agent) runWithoutExecutionPlanWithTools(ctx context.Context, input string, tools []interfaces.Tool) (string, error) {
+func (a *Agent) runWithoutExecutionPlanWithTools(ctx context.Context, input string, parts []interfaces.ContentPart, tools []interfaces.Tool) (string, error) {
 	// Use input directly as prompt - let LLM providers handle message history via Memory
 	prompt := input
 
+	// Give the LLM only the tools relevant to the input if the agent selects tools
+	selection := a.selectTools(ctx, input, tools)
+	if selection != nil {
+		tools = selection.tools()
+	}
+
 	// Generate response with tools if available
 	var response string
 	var err error
@@ -656,8 +782,8 @@ func (a *Agent) runWithoutExecutionPlanWithTools(ctx context.Context, input stri
 	}
 
 	// Add response format as a generate option if available
-	if a.responseFormat != nil {
-		generateOptions = append(generateOptions, openai.WithResponseFormat(*a.responseFormat))
+	if responseFormat := a.responseFormatFor(ctx); responseFormat != nil {
+		generateOptions = append(generateOptions, openai.WithResponseFormat(*responseFormat))
 	}
 
 	if a.llmConfig != nil {
@@ -667,23 +793,86 @@ func (a *Agent) runWithoutExecutionPlanWithTools(ctx context.Context, input stri
 	}
 
 	// Add max iterations option
-	generateOptions = append(generateOptions, interfaces.WithMaxIterations(a.maxIterations))
+	generateOptions = append(generateOptions, interfaces.WithMaxIterations(a.iterationsLeft(ctx)))
+
+	if a.maxParallelToolCalls > 1 {
+		generateOptions = append(generateOptions, interfaces.WithParallelToolCalls(a.maxParallelToolCalls))
+	}
+
+	if toolChoice := a.toolChoiceFor(ctx, tools); toolChoice != nil {
+		generateOptions = append(generateOptions, interfaces.WithToolChoice(*toolChoice))
+	}
 
 	// Always pass memory to LLM - let providers handle message history conversion natively
-	if a.memory != nil {
-		generateOptions = append(generateOptions, interfaces.WithMemory(a.memory))
+	if memory := a.llmMemory(ctx, tools); memory != nil {
+		generateOptions = append(generateOptions, interfaces.WithMemory(memory))
 	}
 
-	if len(tools) > 0 {
-		response, err = a.llm.GenerateWithTools(ctx, prompt, tools, generateOptions...)
-	} else {
-		response, err = a.llm.Generate(ctx, prompt, generateOptions...)
+	// The text parts are already the prompt; send the media along with it
+	for _, part := range parts {
+		if part.Type != interfaces.ContentPartText {
+			generateOptions = append(generateOptions, interfaces.WithContentParts(part))
+		}
+	}
+
+	// Keep the results of the tool calls for a call that continues the work
+	// of one that was stopped
+	ctx, results := withToolResults(ctx)
+	iterations := a.iterationsLeft(ctx)
+	overBudget := false
+	for {
+		// The call is cancelled if the run exceeds its budget, or to give the
+		// LLM the tools it requested
+		budgetCtx, stopBudget := startBudgetCall(ctx)
+		llmCtx, stopSelection := selection.startCall(budgetCtx)
+		firstTurn := len(llm.GetToolTurnsFromContext(ctx))
+		call := &LLMCall{Prompt: prompt, Tools: tools, Options: generateOptions}
+		response, err = a.callLLM(llmCtx, call, func(ctx context.Context, call *LLMCall) (string, error) {
+			if len(call.Tools) > 0 {
+				return a.llm.GenerateWithTools(ctx, call.Prompt, a.hookTools(call.Tools), call.Options...)
+			}
+			return a.llm.Generate(ctx, call.Prompt, call.Options...)
+		})
+		exceeded := budgetExceeded(budgetCtx)
+		added := selection.addedTools(llmCtx)
+		stopSelection()
+		stopBudget()
+
+		// Answer with what the run has gathered if it exceeded its budget
+		if err != nil && exceeded != nil {
+			response, err = a.answerOverBudget(ctx, input, results, exceeded)
+			overBudget = true
+			break
+		}
+		if err == nil || len(added) == 0 {
+			break
+		}
+
+		// Continue with the tools the LLM requested and the iterations left
+		iterations -= len(llm.GetToolTurnsFromContext(ctx)) - firstTurn
+		prompt, err = a.continueWithTools(ctx, input, added, results)
+		if err != nil {
+			break
+		}
+		tools = selection.tools()
+		generateOptions = append(generateOptions, interfaces.WithMaxIterations(max(iterations, 1)))
+		if memory := a.llmMemory(ctx, tools); memory != nil {
+			generateOptions = append(generateOptions, interfaces.WithMemory(memory))
+		}
 	}
 
 	if err != nil {
 		return "", fmt.Errorf("failed to generate response: %w", err)
 	}
 
+	// Have the answer critiqued and revised, unless the run is out of budget
+	if a.reflection != nil && !overBudget {
+		response, err = a.reflect(ctx, input, response, results, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	// Apply guardrails to output if available
 	if a.guardrails != nil {
 		guardedResponse, err := a.guardrails.ProcessOutput(ctx, response)
@@ -693,6 +882,11 @@ func (a *Agent) runWithoutExecutionPlanWithTools(ctx context.Context, input stri
 		response = guardedResponse
 	}
 
+	response, err = a.finalResponse(ctx, response)
+	if err != nil {
+		return "", err
+	}
+
 	// Add agent message to memory
 	if a.memory != nil {
 		if err := a.memory.AddMessage(ctx, interfaces.Message{
@@ -750,8 +944,41 @@ func (a *Agent) approvePlan(ctx context.Context, plan *executionplan.ExecutionPl
 		}
 	}
 
+	return a.runPlan(ctx, plan, 0, nil)
+}
+
+// runPlan executes an approved plan from the given step and adds its result
+// to memory. With a checkpoint store, the progress of the plan is saved after
+// each step.
+func (a *Agent) runPlan(ctx context.Context, plan *executionplan.ExecutionPlan, start int, previousResults []string) (result string, err error) {
+	var onStep executionplan.StepHandler
+	if a.checkpointStore != nil {
+		run := a.currentRun(ctx)
+		if run == nil {
+			state := interfaces.RunCheckpoint{Input: plan.Description, Plan: &interfaces.PlanProgress{
+				TaskID:      plan.TaskID,
+				Description: plan.Description,
+			}}
+			for _, step := range plan.Steps {
+				state.Plan.Steps = append(state.Plan.Steps, interfaces.PlanStepSpec{
+					ToolName:    step.ToolName,
+					Input:       step.Input,
+					Description: step.Description,
+				})
+			}
+			ctx, run, err = a.startRun(ctx, state, nil)
+			if err != nil {
+				return "", err
+			}
+			defer func() { run.finish(ctx, result, err) }()
+		}
+		onStep = func(step int, result string) error {
+			return run.finishPlanStep(ctx, step, result)
+		}
+	}
+
 	// Execute the plan
-	result, err := a.planExecutor.ExecutePlan(ctx, plan)
+	result, err = a.planExecutor.ExecutePlanFrom(ctx, plan, start, previousResults, onStep)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute plan: %w", err)
 	}
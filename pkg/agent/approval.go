@@ -0,0 +1,448 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+// errStoppedAwaitingApproval is the error of a tool call of a checkpointed run
+// that stopped while the call was waiting for approval
+var errStoppedAwaitingApproval = errors.New("the run stopped while the call was waiting for approval")
+
+// ApprovalPolicy decides whether a tool call must be approved by a human
+// before it runs
+type ApprovalPolicy func(ctx context.Context, tool interfaces.Tool, arguments string) bool
+
+// RequireApprovalFor returns a policy that requires approval for every call of
+// the named tools
+func RequireApprovalFor(toolNames ...string) ApprovalPolicy {
+	return func(ctx context.Context, tool interfaces.Tool, arguments string) bool {
+		return slices.Contains(toolNames, tool.Name())
+	}
+}
+
+// WithApprovalPolicy requires human approval for the tool calls that any of
+// the policies match. Tools that implement interfaces.ApprovalTool decide for
+// themselves, with or without a policy.
+//
+// A call that requires approval is saved in the approval store, and the run
+// waits until ResolveApproval is called with a decision. RunStream sends an
+// approval request event when the run starts waiting. With a checkpoint
+// store, a run that stops while a call waits for approval can be resumed with
+// ResumeRun once the call is decided, by this process or another one.
+func WithApprovalPolicy(policies ...ApprovalPolicy) Option {
+	return func(a *Agent) {
+		a.approvalPolicies = append(a.approvalPolicies, policies...)
+	}
+}
+
+// WithApprovalStore sets the store that persists tool calls waiting for
+// approval (default: in memory)
+func WithApprovalStore(store interfaces.ApprovalStore) Option {
+	return func(a *Agent) {
+		a.approvalStore = store
+	}
+}
+
+// WithApprovalHandler sets a function that is called when a tool call starts
+// waiting for approval, for example to notify an operator. It is called for
+// runs started with Run, which send no events, as well as RunStream.
+func WithApprovalHandler(handler func(ctx context.Context, approval interfaces.ToolApproval)) Option {
+	return func(a *Agent) {
+		a.approvalHandler = handler
+	}
+}
+
+// DefaultApprovalTimeout is how long a tool call waits for approval unless
+// WithApprovalTimeout sets another timeout
+const DefaultApprovalTimeout = 24 * time.Hour
+
+// WithApprovalTimeout sets how long a tool call waits for a decision before it
+// expires and is reported to the LLM as not approved (default:
+// DefaultApprovalTimeout). A negative timeout waits until the context of the
+// run is done.
+func WithApprovalTimeout(timeout time.Duration) Option {
+	return func(a *Agent) {
+		a.approvalTimeout = timeout
+	}
+}
+
+// ResolveApproval approves, edits or rejects a tool call waiting for approval.
+// The run that made the call resumes: an approved call runs, with the
+// arguments of the decision if they are set, and a rejected call is reported
+// to the LLM with the reason. If the call was made by a checkpointed run that
+// is no longer waiting in this process, for example after a restart, the
+// decision is saved and applied when the run is resumed with ResumeRun.
+// Remote agents send the decision to the remote service.
+func (a *Agent) ResolveApproval(ctx context.Context, approvalID string, decision interfaces.ApprovalDecision) error {
+	if a.isRemote {
+		if a.remoteClient == nil {
+			return fmt.Errorf("remote client not initialized")
+		}
+		return a.remoteClient.ResolveToolApproval(ctx, approvalID, decision)
+	}
+
+	approval, err := a.approvalStore.GetApproval(ctx, approvalID)
+	if err != nil {
+		return err
+	}
+	if orgID, _ := multitenancy.GetOrgID(ctx); orgID != "" && orgID != approval.OrgID {
+		return fmt.Errorf("approval %s: %w", approvalID, interfaces.ErrApprovalNotFound)
+	}
+	if approval.Expired(time.Now()) {
+		a.expireApproval(ctx, *approval)
+		approval.Status = interfaces.ApprovalStatusExpired
+	}
+	if approval.Status != interfaces.ApprovalStatusPending {
+		return fmt.Errorf("approval %s is %s: %w", approvalID, approval.Status, interfaces.ErrApprovalNotFound)
+	}
+
+	decisions, ok := a.approvals.take(approvalID)
+	if !ok && (approval.RunID == "" || a.checkpointStore == nil) {
+		return fmt.Errorf("approval %s is not awaited by a run of this agent: %w", approvalID, interfaces.ErrApprovalNotFound)
+	}
+	if ok {
+		decisions <- decision
+	}
+
+	approval.Status = interfaces.ApprovalStatusRejected
+	if decision.Approved {
+		approval.Status = interfaces.ApprovalStatusApproved
+	}
+	approval.Decision = &decision
+	approval.DecidedAt = time.Now()
+	if err := a.approvalStore.SaveApproval(ctx, *approval); err != nil {
+		return fmt.Errorf("failed to save decision: %w", err)
+	}
+	return nil
+}
+
+// PendingApprovals returns the tool calls waiting for approval, limited to the
+// organization of the context if it has one. Approvals that expired are saved
+// as expired and left out.
+func (a *Agent) PendingApprovals(ctx context.Context) ([]interfaces.ToolApproval, error) {
+	if a.isRemote {
+		if a.remoteClient == nil {
+			return nil, fmt.Errorf("remote client not initialized")
+		}
+		return a.remoteClient.ListToolApprovals(ctx)
+	}
+
+	approvals, err := a.approvalStore.ListApprovals(ctx, interfaces.ApprovalStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	orgID, _ := multitenancy.GetOrgID(ctx)
+	now := time.Now()
+	var filtered []interfaces.ToolApproval
+	for _, approval := range approvals {
+		if approval.Expired(now) {
+			a.expireApproval(ctx, approval)
+			continue
+		}
+		if orgID == "" || approval.OrgID == orgID {
+			filtered = append(filtered, approval)
+		}
+	}
+	return filtered, nil
+}
+
+// approvalMiddleware returns the tool middleware that holds calls requiring
+// approval, or nil if no tool can require it
+func (a *Agent) approvalMiddleware(tools []interfaces.Tool) ToolMiddleware {
+	needed := len(a.approvalPolicies) > 0
+	for _, tool := range tools {
+		if _, ok := tool.(interfaces.ApprovalTool); ok {
+			needed = true
+		}
+	}
+	if !needed {
+		return nil
+	}
+	return a.awaitApproval
+}
+
+// requiresApproval reports whether a tool call must be approved
+func (a *Agent) requiresApproval(ctx context.Context, call *ToolCall) bool {
+	if tool, ok := call.Tool.(interfaces.ApprovalTool); ok && tool.RequiresApproval(ctx, call.Arguments) {
+		return true
+	}
+	for _, policy := range a.approvalPolicies {
+		if policy(ctx, call.Tool, call.Arguments) {
+			return true
+		}
+	}
+	return false
+}
+
+// awaitApproval is the tool middleware that saves calls requiring approval and
+// waits for their decision. Arguments edited by a human go through the
+// approval policy again, and wait for an approval of their own if they
+// require one.
+func (a *Agent) awaitApproval(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+	if !a.requiresApproval(ctx, call) {
+		return next(ctx, call)
+	}
+
+	// A call made again by a resumed run gets the approval it waited for
+	run := a.currentRun(ctx)
+	key := interfaces.IdempotencyKey(ctx)
+	approval, err := a.resumedApproval(ctx, run, key)
+	if err != nil {
+		return "", err
+	}
+	resumed := approval != nil
+	for {
+		if approval == nil {
+			approval = a.newApproval(ctx, run, call)
+			resumed = false
+		}
+
+		decision, err := a.approvalDecision(ctx, run, key, approval, resumed)
+		if err != nil {
+			return "", err
+		}
+		if !decision.Approved {
+			if decision.Reason != "" {
+				return "", fmt.Errorf("the call was rejected by a human: %s", decision.Reason)
+			}
+			return "", errors.New("the call was rejected by a human")
+		}
+
+		// The call is made with the arguments that were approved
+		call.Arguments = approval.Arguments
+		if decision.Arguments == "" || decision.Arguments == approval.Arguments {
+			return next(ctx, call)
+		}
+		call.Arguments = decision.Arguments
+		if !a.requiresApproval(ctx, call) {
+			return next(ctx, call)
+		}
+		approval = nil
+	}
+}
+
+// newApproval returns the pending approval of a tool call
+func (a *Agent) newApproval(ctx context.Context, run *checkpointRun, call *ToolCall) *interfaces.ToolApproval {
+	approval := &interfaces.ToolApproval{
+		ID:        uuid.New().String(),
+		AgentName: a.name,
+		ToolName:  call.Tool.Name(),
+		Arguments: call.Arguments,
+		Status:    interfaces.ApprovalStatusPending,
+		CreatedAt: time.Now(),
+	}
+	approval.OrgID, _ = multitenancy.GetOrgID(ctx)
+	approval.ConversationID, _ = memory.GetConversationID(ctx)
+	if run != nil {
+		approval.RunID = run.state.RunID
+	}
+	if a.approvalTimeout > 0 {
+		approval.ExpiresAt = approval.CreatedAt.Add(a.approvalTimeout)
+	}
+	return approval
+}
+
+// approvalDecision returns the decision on an approval, waiting for it unless
+// it was made while the run was not running
+func (a *Agent) approvalDecision(ctx context.Context, run *checkpointRun, key string, approval *interfaces.ToolApproval, resumed bool) (interfaces.ApprovalDecision, error) {
+	switch {
+	case approval.Status == interfaces.ApprovalStatusExpired || approval.Expired(time.Now()):
+		a.expireApproval(ctx, *approval)
+		return interfaces.ApprovalDecision{}, errors.New("the call was not approved in time")
+	case approval.Status != interfaces.ApprovalStatusPending && approval.Decision != nil:
+		return *approval.Decision, nil
+	default:
+		return a.waitForDecision(ctx, run, key, approval, resumed)
+	}
+}
+
+// resumedApproval returns the approval that the tool call with the
+// idempotency key waited for before its run was resumed, or nil
+func (a *Agent) resumedApproval(ctx context.Context, run *checkpointRun, key string) (*interfaces.ToolApproval, error) {
+	if run == nil || key == "" {
+		return nil, nil
+	}
+	approvalID := run.approvalFor(key)
+	if approvalID == "" {
+		return nil, nil
+	}
+	approval, err := a.approvalStore.GetApproval(ctx, approvalID)
+	if errors.Is(err, interfaces.ErrApprovalNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	return approval, nil
+}
+
+// waitForDecision saves a pending approval, unless it was saved before the run
+// was resumed, and waits for its decision
+func (a *Agent) waitForDecision(ctx context.Context, run *checkpointRun, key string, approval *interfaces.ToolApproval, resumed bool) (interfaces.ApprovalDecision, error) {
+	decisions := a.approvals.add(approval.ID)
+	defer a.approvals.take(approval.ID)
+	if !resumed {
+		if err := a.approvalStore.SaveApproval(ctx, *approval); err != nil {
+			return interfaces.ApprovalDecision{}, fmt.Errorf("failed to save approval: %w", err)
+		}
+	}
+	// A checkpointed run waits for the same approval when it is resumed
+	if run != nil && key != "" {
+		if err := run.waitForApproval(ctx, key, approval.ID); err != nil {
+			return interfaces.ApprovalDecision{}, err
+		}
+	}
+
+	a.logger.Info(ctx, "Tool call is waiting for approval", map[string]interface{}{
+		"approval_id": approval.ID,
+		"tool_name":   approval.ToolName,
+	})
+	if a.approvalHandler != nil {
+		a.approvalHandler(ctx, *approval)
+	}
+	if notify, ok := ctx.Value(approvalNotifierKey{}).(func(interfaces.ToolApproval)); ok {
+		notify(*approval)
+	}
+
+	// Time spent waiting for a human does not count against the time limit
+	// of the run
+	defer pauseBudgetClock(ctx)()
+
+	waitCtx := ctx
+	if !approval.ExpiresAt.IsZero() {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(ctx, approval.ExpiresAt)
+		defer cancel()
+	}
+
+	select {
+	case decision := <-decisions:
+		return decision, nil
+	case <-waitCtx.Done():
+		if _, waiting := a.approvals.take(approval.ID); waiting {
+			// The approval of a checkpointed run stays pending, to be
+			// decided before the run is resumed
+			if run != nil && ctx.Err() != nil && !approval.Expired(time.Now()) {
+				return interfaces.ApprovalDecision{}, fmt.Errorf("%w: %w", errStoppedAwaitingApproval, ctx.Err())
+			}
+			a.expireApproval(ctx, *approval)
+			return interfaces.ApprovalDecision{}, fmt.Errorf("the call was not approved in time: %w", waitCtx.Err())
+		}
+		// A decision was made as the wait ended
+		return <-decisions, nil
+	}
+}
+
+// expireApproval saves a pending approval as expired
+func (a *Agent) expireApproval(ctx context.Context, approval interfaces.ToolApproval) {
+	if approval.Status != interfaces.ApprovalStatusPending {
+		return
+	}
+	approval.Status = interfaces.ApprovalStatusExpired
+	approval.DecidedAt = time.Now()
+	if err := a.approvalStore.SaveApproval(context.WithoutCancel(ctx), approval); err != nil {
+		a.logger.Warn(ctx, "Failed to save expired approval", map[string]interface{}{
+			"approval_id": approval.ID,
+			"error":       err.Error(),
+		})
+	}
+}
+
+// approvalNotifierKey is the context key of the function that sends approval
+// request events of a stream
+type approvalNotifierKey struct{}
+
+// withApprovalNotifier returns a context whose tool calls report approval
+// requests to notify
+func withApprovalNotifier(ctx context.Context, notify func(interfaces.ToolApproval)) context.Context {
+	return context.WithValue(ctx, approvalNotifierKey{}, notify)
+}
+
+// approvalWaiters holds the decision channels of the tool calls waiting for
+// approval in this process
+type approvalWaiters struct {
+	mu      sync.Mutex
+	waiting map[string]chan interfaces.ApprovalDecision
+}
+
+// add registers a tool call waiting for approval
+func (w *approvalWaiters) add(id string) <-chan interfaces.ApprovalDecision {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.waiting == nil {
+		w.waiting = make(map[string]chan interfaces.ApprovalDecision)
+	}
+	decisions := make(chan interfaces.ApprovalDecision, 1)
+	w.waiting[id] = decisions
+	return decisions
+}
+
+// take removes a waiting tool call and returns its decision channel
+func (w *approvalWaiters) take(id string) (chan<- interfaces.ApprovalDecision, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	decisions, ok := w.waiting[id]
+	delete(w.waiting, id)
+	return decisions, ok
+}
+
+// InMemoryApprovalStore keeps approvals in memory. Approvals are lost when
+// the process exits; use a persistent store to keep an audit trail.
+type InMemoryApprovalStore struct {
+	mu        sync.RWMutex
+	approvals map[string]interfaces.ToolApproval
+}
+
+// NewInMemoryApprovalStore creates an empty in-memory approval store
+func NewInMemoryApprovalStore() *InMemoryApprovalStore {
+	return &InMemoryApprovalStore{approvals: make(map[string]interfaces.ToolApproval)}
+}
+
+// SaveApproval creates or updates an approval
+func (s *InMemoryApprovalStore) SaveApproval(ctx context.Context, approval interfaces.ToolApproval) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.approvals[approval.ID] = approval
+	return nil
+}
+
+// GetApproval returns an approval by ID
+func (s *InMemoryApprovalStore) GetApproval(ctx context.Context, id string) (*interfaces.ToolApproval, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	approval, ok := s.approvals[id]
+	if !ok {
+		return nil, fmt.Errorf("approval %s: %w", id, interfaces.ErrApprovalNotFound)
+	}
+	return &approval, nil
+}
+
+// ListApprovals returns the approvals with the given status, oldest first
+func (s *InMemoryApprovalStore) ListApprovals(ctx context.Context, status interfaces.ApprovalStatus) ([]interfaces.ToolApproval, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var approvals []interfaces.ToolApproval
+	for _, approval := range s.approvals {
+		if status == "" || approval.Status == status {
+			approvals = append(approvals, approval)
+		}
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+	return approvals, nil
+}
+
+var _ interfaces.ApprovalStore = (*InMemoryApprovalStore)(nil)
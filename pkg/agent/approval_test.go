@@ -0,0 +1,504 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/checkpoint"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+// thresholdTool requires approval for amounts above a limit
+type thresholdTool struct {
+	mockTool
+}
+
+func (t *thresholdTool) RequiresApproval(ctx context.Context, args string) bool {
+	return strings.Contains(args, "large")
+}
+
+// runAsync runs the agent in the background and returns its result channel
+func runAsync(ctx context.Context, agent *Agent, input string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := agent.Run(ctx, input)
+		done <- err
+	}()
+	return done
+}
+
+// awaitRequest waits for a tool call to be reported as waiting for approval
+func awaitRequest(t *testing.T, requests <-chan interfaces.ToolApproval) interfaces.ToolApproval {
+	t.Helper()
+	select {
+	case approval := <-requests:
+		return approval
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an approval request")
+		return interfaces.ToolApproval{}
+	}
+}
+
+func TestApproveWithEditedArguments(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"100"}`), mock.Text("done")))
+	requests := make(chan interfaces.ToolApproval, 1)
+	store := NewInMemoryApprovalStore()
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(&mockTool{name: "transfer"}, &mockTool{name: "lookup"}),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(RequireApprovalFor("transfer")),
+		WithApprovalStore(store),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	done := runAsync(ctx, agent, "Transfer 100")
+	approval := awaitRequest(t, requests)
+	if approval.ToolName != "transfer" || approval.Arguments != `{"input":"100"}` || approval.OrgID != "test-org" || approval.ConversationID != "test-conversation" {
+		t.Errorf("Unexpected approval request: %+v", approval)
+	}
+
+	pending, err := agent.PendingApprovals(ctx)
+	if err != nil {
+		t.Fatalf("PendingApprovals failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != approval.ID {
+		t.Fatalf("Expected the call to be pending, got %+v", pending)
+	}
+	if others, _ := agent.PendingApprovals(multitenancy.WithOrgID(context.Background(), "other-org")); len(others) != 0 {
+		t.Errorf("Expected no pending approvals for another organization, got %+v", others)
+	}
+
+	if err := agent.ResolveApproval(ctx, approval.ID, interfaces.ApprovalDecision{Approved: true, Arguments: `{"input":"50"}`}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+
+	// The policy still requires approval of the edited call
+	edited := awaitRequest(t, requests)
+	if edited.ID == approval.ID || edited.Arguments != `{"input":"50"}` {
+		t.Fatalf("Expected a new approval of the edited call, got %+v", edited)
+	}
+	if err := agent.ResolveApproval(ctx, edited.ID, interfaces.ApprovalDecision{Approved: true}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result := model.LastCall().ToolResults[0].Content; result != `tool transfer executed with: {"input":"50"}` {
+		t.Errorf("Expected the tool to run with the edited arguments, got %q", result)
+	}
+	saved, err := store.GetApproval(ctx, approval.ID)
+	if err != nil {
+		t.Fatalf("GetApproval failed: %v", err)
+	}
+	if saved.Status != interfaces.ApprovalStatusApproved || saved.Decision == nil || saved.DecidedAt.IsZero() {
+		t.Errorf("Expected the decision to be saved, got %+v", saved)
+	}
+	if err := agent.ResolveApproval(ctx, approval.ID, interfaces.ApprovalDecision{Approved: true}); !errors.Is(err, interfaces.ErrApprovalNotFound) {
+		t.Errorf("Expected a second decision to fail, got %v", err)
+	}
+}
+
+func TestEditedArgumentsApprovalPolicy(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"large 100"}`), mock.Text("done")))
+	requests := make(chan interfaces.ToolApproval, 1)
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(&thresholdTool{mockTool{name: "transfer"}}),
+		WithRequirePlanApproval(false),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	done := runAsync(ctx, agent, "Transfer 100")
+	approval := awaitRequest(t, requests)
+
+	// Edited arguments that require approval wait for it
+	if err := agent.ResolveApproval(ctx, approval.ID, interfaces.ApprovalDecision{Approved: true, Arguments: `{"input":"large 500"}`}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	edited := awaitRequest(t, requests)
+	if edited.Arguments != `{"input":"large 500"}` {
+		t.Fatalf("Expected the edited call to wait for approval, got %+v", edited)
+	}
+
+	// Edited arguments that do not require approval run
+	if err := agent.ResolveApproval(ctx, edited.ID, interfaces.ApprovalDecision{Approved: true, Arguments: `{"input":"small 50"}`}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result := model.LastCall().ToolResults[0].Content; result != `tool transfer executed with: {"input":"small 50"}` {
+		t.Errorf("Expected the tool to run with the edited arguments, got %q", result)
+	}
+}
+
+func TestApprovalsWithoutOrganization(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"100"}`), mock.Text("done")))
+	requests := make(chan interfaces.ToolApproval, 1)
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(&mockTool{name: "transfer"}),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(RequireApprovalFor("transfer")),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	done := runAsync(context.Background(), agent, "Transfer 100")
+	approval := awaitRequest(t, requests)
+
+	// An organization does not see or decide calls made without one
+	orgCtx := multitenancy.WithOrgID(context.Background(), "test-org")
+	if pending, _ := agent.PendingApprovals(orgCtx); len(pending) != 0 {
+		t.Errorf("Expected no pending approvals for the organization, got %+v", pending)
+	}
+	if err := agent.ResolveApproval(orgCtx, approval.ID, interfaces.ApprovalDecision{Approved: true}); !errors.Is(err, interfaces.ErrApprovalNotFound) {
+		t.Errorf("Expected the organization not to find the approval, got %v", err)
+	}
+
+	if err := agent.ResolveApproval(context.Background(), approval.ID, interfaces.ApprovalDecision{Approved: true}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
+
+func TestRejectApproval(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("delete", `{"input":"a"}`), mock.Text("done")))
+	var deleted bool
+	deleteTool := &mockTool{name: "delete", runFunc: func(ctx context.Context, input string) (string, error) {
+		deleted = true
+		return "deleted", nil
+	}}
+	requests := make(chan interfaces.ToolApproval, 1)
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(deleteTool),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(func(ctx context.Context, tool interfaces.Tool, arguments string) bool {
+			return tool.Name() == "delete"
+		}),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	done := runAsync(ctx, agent, "Delete it")
+	approval := awaitRequest(t, requests)
+
+	if err := agent.ResolveApproval(multitenancy.WithOrgID(ctx, "other-org"), approval.ID, interfaces.ApprovalDecision{}); !errors.Is(err, interfaces.ErrApprovalNotFound) {
+		t.Errorf("Expected a decision from another organization to fail, got %v", err)
+	}
+	if err := agent.ResolveApproval(ctx, approval.ID, interfaces.ApprovalDecision{Reason: "not today"}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	result := model.LastCall().ToolResults[0].Content
+	if deleted || !strings.Contains(result, "rejected") || !strings.Contains(result, "not today") {
+		t.Errorf("Expected the call to be rejected with the reason, got %q (deleted: %v)", result, deleted)
+	}
+}
+
+func TestApprovalWaitNotBudgeted(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"100"}`), mock.Text("done")))
+	requests := make(chan interfaces.ToolApproval, 1)
+	var transfers int
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(countingTool("transfer", &transfers)),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(RequireApprovalFor("transfer")),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+		WithBudget(RunBudget{MaxDuration: 100 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	done := make(chan *RunResult, 1)
+	go func() {
+		result, err := agent.RunDetailed(ctx, "Transfer 100")
+		if err != nil {
+			t.Errorf("RunDetailed failed: %v", err)
+		}
+		done <- result
+	}()
+	approval := awaitRequest(t, requests)
+
+	// The human takes longer than the time limit of the run
+	time.Sleep(300 * time.Millisecond)
+	if err := agent.ResolveApproval(ctx, approval.ID, interfaces.ApprovalDecision{Approved: true}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	result := <-done
+	if result == nil {
+		t.FailNow()
+	}
+	if transfers != 1 || result.Output != "done" || result.BudgetExceeded != "" {
+		t.Errorf("Expected the approved call to run within the budget, got %q after %d transfers (%q)", result.Output, transfers, result.BudgetExceeded)
+	}
+}
+
+func TestApprovalToolAndTimeout(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.ToolCalls(
+			interfaces.ToolCall{ID: "1", Name: "pay", Arguments: `{"input":"small"}`},
+			interfaces.ToolCall{ID: "2", Name: "pay", Arguments: `{"input":"large"}`},
+		),
+		mock.Text("done"),
+	))
+	store := NewInMemoryApprovalStore()
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(&thresholdTool{mockTool{name: "pay"}}),
+		WithRequirePlanApproval(false),
+		WithApprovalStore(store),
+		WithApprovalTimeout(20*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	if _, err := agent.Run(ctx, "Pay both"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := model.LastCall().ToolResults
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 tool results, got %d", len(results))
+	}
+	if results[0].Content != `tool pay executed with: {"input":"small"}` {
+		t.Errorf("Expected the small payment to run without approval, got %q", results[0].Content)
+	}
+	if !strings.Contains(results[1].Content, "not approved in time") {
+		t.Errorf("Expected the large payment to expire, got %q", results[1].Content)
+	}
+
+	approvals, err := store.ListApprovals(ctx, "")
+	if err != nil {
+		t.Fatalf("ListApprovals failed: %v", err)
+	}
+	if len(approvals) != 1 || approvals[0].Status != interfaces.ApprovalStatusExpired {
+		t.Errorf("Expected one expired approval, got %+v", approvals)
+	}
+}
+
+func TestApprovalRunStream(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"100"}`), mock.Text("done")))
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(&mockTool{name: "transfer"}),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(RequireApprovalFor("transfer")),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	events, err := agent.RunStream(ctx, "Transfer 100")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+
+	var requested bool
+	var toolResult string
+	for event := range events {
+		switch event.Type {
+		case interfaces.AgentEventApprovalRequest:
+			requested = true
+			if event.Approval == nil || event.Approval.ToolName != "transfer" {
+				t.Fatalf("Expected the approval in the event, got %+v", event.Approval)
+			}
+			if err := agent.ResolveApproval(ctx, event.Approval.ID, interfaces.ApprovalDecision{Approved: true}); err != nil {
+				t.Fatalf("ResolveApproval failed: %v", err)
+			}
+		case interfaces.AgentEventToolResult:
+			toolResult = event.ToolCall.Result
+		case interfaces.AgentEventError:
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+
+	if !requested {
+		t.Error("Expected an approval request event")
+	}
+	if toolResult != `tool transfer executed with: {"input":"100"}` {
+		t.Errorf("Expected the approved call to run, got %q", toolResult)
+	}
+}
+
+func TestResolveApprovalAfterRestart(t *testing.T) {
+	checkpoints := checkpoint.NewInMemoryStore()
+	approvals := NewInMemoryApprovalStore()
+	var transferred []string
+	transfer := &mockTool{name: "transfer", runFunc: func(ctx context.Context, input string) (string, error) {
+		transferred = append(transferred, input)
+		return "transferred", nil
+	}}
+	requests := make(chan interfaces.ToolApproval, 1)
+
+	// The first process stops while the call waits for approval
+	first, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"100"}`)))),
+		WithTools(transfer),
+		WithName("payments"),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(RequireApprovalFor("transfer")),
+		WithApprovalStore(approvals),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+		WithCheckpointStore(checkpoints),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ctx, stop := context.WithCancel(WithRunID(hookTestContext(), "run-1"))
+	done := runAsync(ctx, first, "Transfer 100")
+	approval := awaitRequest(t, requests)
+	stop()
+	if err := <-done; err == nil {
+		t.Fatal("Expected the stopped run to fail")
+	}
+
+	saved, err := checkpoints.GetCheckpoint(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if len(saved.PendingToolCalls) != 1 || saved.PendingToolCalls[0].ApprovalID != approval.ID {
+		t.Fatalf("Expected the call to wait for the approval in the checkpoint, got %+v", saved.PendingToolCalls)
+	}
+	if stored, _ := approvals.GetApproval(context.Background(), approval.ID); stored.Status != interfaces.ApprovalStatusPending || stored.RunID != "run-1" {
+		t.Fatalf("Expected the approval of the run to stay pending, got %+v", stored)
+	}
+
+	// A new process decides the call and resumes the run
+	model := mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"100"}`), mock.Text("done")))
+	second, err := NewAgent(
+		WithLLM(model),
+		WithTools(transfer),
+		WithName("payments"),
+		WithRequirePlanApproval(false),
+		WithApprovalPolicy(RequireApprovalFor("transfer")),
+		WithApprovalStore(approvals),
+		WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+		WithCheckpointStore(checkpoints),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ctx = hookTestContext()
+	if err := second.ResolveApproval(ctx, approval.ID, interfaces.ApprovalDecision{Approved: true, Arguments: `{"input":"50"}`}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	type result struct {
+		response string
+		err      error
+	}
+	resumed := make(chan result, 1)
+	go func() {
+		response, err := second.ResumeRun(ctx, "run-1")
+		resumed <- result{response, err}
+	}()
+
+	// The edited call waits for an approval of its own
+	edited := awaitRequest(t, requests)
+	if edited.ID == approval.ID || edited.Arguments != `{"input":"50"}` || edited.RunID != "run-1" {
+		t.Fatalf("Expected a new approval of the edited call, got %+v", edited)
+	}
+	if err := second.ResolveApproval(ctx, edited.ID, interfaces.ApprovalDecision{Approved: true}); err != nil {
+		t.Fatalf("ResolveApproval failed: %v", err)
+	}
+	res := <-resumed
+	response, err := res.response, res.err
+	if err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	if response != "done" || len(transferred) != 1 || transferred[0] != `{"input":"50"}` {
+		t.Errorf("Expected the call to run once with the decision, got %q after %v", response, transferred)
+	}
+	if stored, _ := approvals.GetApproval(ctx, approval.ID); stored.Status != interfaces.ApprovalStatusApproved {
+		t.Errorf("Expected the approval to be approved, got %s", stored.Status)
+	}
+}
+
+func TestAbandonedApprovalExpires(t *testing.T) {
+	approvals := NewInMemoryApprovalStore()
+	agent, err := NewAgent(
+		WithLLM(mock.New()),
+		WithApprovalStore(approvals),
+		WithCheckpointStore(checkpoint.NewInMemoryStore()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	// The run that made the call is gone and the approval was never decided
+	ctx := context.Background()
+	abandoned := interfaces.ToolApproval{
+		ID:        "approval-1",
+		RunID:     "run-1",
+		ToolName:  "transfer",
+		Status:    interfaces.ApprovalStatusPending,
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	if err := approvals.SaveApproval(ctx, abandoned); err != nil {
+		t.Fatalf("SaveApproval failed: %v", err)
+	}
+
+	if pending, err := agent.PendingApprovals(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("Expected no pending approvals, got %+v (%v)", pending, err)
+	}
+	if err := agent.ResolveApproval(ctx, abandoned.ID, interfaces.ApprovalDecision{Approved: true}); !errors.Is(err, interfaces.ErrApprovalNotFound) {
+		t.Errorf("Expected an expired approval not to be decided, got %v", err)
+	}
+	if stored, _ := approvals.GetApproval(ctx, abandoned.ID); stored.Status != interfaces.ApprovalStatusExpired {
+		t.Errorf("Expected the approval to be expired, got %s", stored.Status)
+	}
+}
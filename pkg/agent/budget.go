@@ -0,0 +1,349 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// RunBudget limits the resources a run may use. Zero fields are not limited.
+// A budget covers the run and the sub-agents it calls, which share it through
+// the context.
+type RunBudget struct {
+	MaxTokens       int            // Total tokens of the LLM calls
+	MaxCost         float64        // Cost in USD, priced with the price table of the agent
+	MaxToolCalls    int            // Calls of any tool
+	MaxCallsPerTool map[string]int // Calls of each named tool
+	MaxDuration     time.Duration  // Wall-clock time of the run, not counting waits for approval
+}
+
+// BudgetResource is a resource limited by a run budget
+type BudgetResource string
+
+const (
+	BudgetTokens    BudgetResource = "tokens"
+	BudgetCost      BudgetResource = "cost"
+	BudgetToolCalls BudgetResource = "tool_calls"
+	BudgetDuration  BudgetResource = "duration"
+)
+
+// BudgetExceededError reports a limit of a run budget that was reached
+type BudgetExceededError struct {
+	Resource BudgetResource
+	Tool     string // Tool whose own call limit was reached, if any
+	Reason   string // Description of the limit for the LLM and the caller
+}
+
+// Error returns the reason of the error
+func (e *BudgetExceededError) Error() string {
+	return "run budget exceeded: " + e.Reason
+}
+
+// WithBudget limits the resources of every run of the agent. When a limit
+// other than a per-tool limit is reached, the agent stops calling tools and
+// answers with what it has gathered so far, explaining that the answer may be
+// incomplete. A tool that reached its own limit is refused, and the LLM may
+// go on with other tools.
+func WithBudget(budget RunBudget) Option {
+	return func(a *Agent) {
+		a.budget = &budget
+	}
+}
+
+// runBudgetKey is the context key for the budget of a run
+type runBudgetKey struct{}
+
+// budgetTrackerKey is the context key for the budget tracker of a run
+type budgetTrackerKey struct{}
+
+// budgetCallKey is the context key for the LLM call of a run with a budget
+type budgetCallKey struct{}
+
+// WithRunBudget sets a budget for the run with the returned context, in
+// addition to the agent's WithBudget. The budget also covers the sub-agents
+// called during the run.
+func WithRunBudget(ctx context.Context, budget RunBudget) context.Context {
+	return context.WithValue(ctx, runBudgetKey{}, &budget)
+}
+
+// budgetTracker counts the resources used against a budget. Trackers of
+// nested runs point to the trackers of the runs they are nested in.
+type budgetTracker struct {
+	budget RunBudget
+	parent *budgetTracker
+	usage  func() []llm.UsageRecord // Usage of the run and its sub-agents
+	prices *llm.PriceTable
+
+	mu           sync.Mutex
+	toolCalls    int
+	callsPerTool map[string]int
+	deadline     time.Time // End of the time limit, moved back by pauses of the clock
+	paused       int       // Tool calls waiting for a human
+	pausedAt     time.Time // When the clock was paused
+}
+
+// startBudget starts tracking the budgets of a run: the budget set for it
+// with WithRunBudget, and the agent's own
+func (a *Agent) startBudget(ctx context.Context) context.Context {
+	if budget, ok := ctx.Value(runBudgetKey{}).(*RunBudget); ok && budget != nil {
+		// The budget is set once, so that sub-agents share it instead of
+		// starting their own
+		ctx = context.WithValue(ctx, runBudgetKey{}, (*RunBudget)(nil))
+		ctx = a.trackBudget(ctx, *budget)
+	}
+	if a.budget != nil {
+		ctx = a.trackBudget(ctx, *a.budget)
+	}
+	return ctx
+}
+
+// trackBudget adds a tracker of the budget to the context
+func (a *Agent) trackBudget(ctx context.Context, budget RunBudget) context.Context {
+	scope := llm.WithUsageCollection(ctx)
+	parent, _ := ctx.Value(budgetTrackerKey{}).(*budgetTracker)
+	tracker := &budgetTracker{
+		budget:       budget,
+		parent:       parent,
+		usage:        func() []llm.UsageRecord { return llm.GetUsageFromContext(scope) },
+		prices:       a.priceTable,
+		callsPerTool: make(map[string]int),
+	}
+	if budget.MaxDuration > 0 {
+		tracker.deadline = time.Now().Add(budget.MaxDuration)
+	}
+	return context.WithValue(scope, budgetTrackerKey{}, tracker)
+}
+
+// budgetTrackerFromContext returns the tracker of the innermost budget of the
+// run, if it has one
+func budgetTrackerFromContext(ctx context.Context) *budgetTracker {
+	tracker, _ := ctx.Value(budgetTrackerKey{}).(*budgetTracker)
+	return tracker
+}
+
+// exceeded returns the limit on tokens, cost or time that the run has
+// reached, checking the budgets of the runs it is nested in as well
+func (t *budgetTracker) exceeded() *BudgetExceededError {
+	for tracker := t; tracker != nil; tracker = tracker.parent {
+		budget := tracker.budget
+		if budget.MaxDuration > 0 && tracker.timeLeft() <= 0 {
+			return tracker.durationExceeded()
+		}
+		if budget.MaxTokens <= 0 && budget.MaxCost <= 0 {
+			continue
+		}
+		summary := llm.Summarize(tracker.usage(), tracker.prices)
+		if budget.MaxTokens > 0 && summary.Total.TotalTokens >= budget.MaxTokens {
+			return &BudgetExceededError{
+				Resource: BudgetTokens,
+				Reason:   fmt.Sprintf("the token budget of %d tokens was used up (%d tokens)", budget.MaxTokens, summary.Total.TotalTokens),
+			}
+		}
+		if budget.MaxCost > 0 && summary.Cost >= budget.MaxCost {
+			return &BudgetExceededError{
+				Resource: BudgetCost,
+				Reason:   fmt.Sprintf("the cost budget of $%.4f was used up ($%.4f)", budget.MaxCost, summary.Cost),
+			}
+		}
+	}
+	return nil
+}
+
+// timeLeft returns the time left until the time limit of the budget. It does
+// not run down while the clock is paused.
+func (t *budgetTracker) timeLeft() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.paused > 0 {
+		return t.deadline.Sub(t.pausedAt)
+	}
+	return time.Until(t.deadline)
+}
+
+// durationExceeded returns the error of the time limit of the budget
+func (t *budgetTracker) durationExceeded() *BudgetExceededError {
+	return &BudgetExceededError{
+		Resource: BudgetDuration,
+		Reason:   fmt.Sprintf("the time limit of %s was reached", t.budget.MaxDuration),
+	}
+}
+
+// pauseBudgetClock stops the clocks of the budgets of the run and the runs it
+// is nested in, so that time spent waiting for a human does not count against
+// their time limits, and returns a function that restarts them
+func pauseBudgetClock(ctx context.Context) func() {
+	tracker := budgetTrackerFromContext(ctx)
+	if tracker == nil {
+		return func() {}
+	}
+
+	now := time.Now()
+	for t := tracker; t != nil; t = t.parent {
+		t.mu.Lock()
+		if t.paused == 0 {
+			t.pausedAt = now
+		}
+		t.paused++
+		t.mu.Unlock()
+	}
+	return func() {
+		now := time.Now()
+		for t := tracker; t != nil; t = t.parent {
+			t.mu.Lock()
+			t.paused--
+			if t.paused == 0 {
+				t.deadline = t.deadline.Add(now.Sub(t.pausedAt))
+			}
+			t.mu.Unlock()
+		}
+	}
+}
+
+// startToolCall counts a call of the tool against the budgets, or returns the
+// limit that prevents it
+func (t *budgetTracker) startToolCall(name string) *BudgetExceededError {
+	if exceeded := t.exceeded(); exceeded != nil {
+		return exceeded
+	}
+
+	// Trackers are locked from the innermost, so that concurrent calls
+	// cannot both take the last call of a budget
+	var chain []*budgetTracker
+	for tracker := t; tracker != nil; tracker = tracker.parent {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		chain = append(chain, tracker)
+	}
+	for _, tracker := range chain {
+		if limit := tracker.budget.MaxToolCalls; limit > 0 && tracker.toolCalls >= limit {
+			return &BudgetExceededError{
+				Resource: BudgetToolCalls,
+				Reason:   fmt.Sprintf("the limit of %d tool calls was reached", limit),
+			}
+		}
+		if limit, ok := tracker.budget.MaxCallsPerTool[name]; ok && tracker.callsPerTool[name] >= limit {
+			return &BudgetExceededError{
+				Resource: BudgetToolCalls,
+				Tool:     name,
+				Reason:   fmt.Sprintf("the limit of %d calls of the tool %s was reached", limit, name),
+			}
+		}
+	}
+	for _, tracker := range chain {
+		tracker.toolCalls++
+		tracker.callsPerTool[name]++
+	}
+	return nil
+}
+
+// budgetCall is an LLM call of a run with a budget, which is cancelled when
+// the budget is exceeded
+type budgetCall struct {
+	cancel context.CancelCauseFunc
+}
+
+// startBudgetCall returns the context of an LLM call of the run, which is
+// cancelled when the budget of the run is exceeded, and a function that
+// releases it
+func startBudgetCall(ctx context.Context) (context.Context, func()) {
+	tracker := budgetTrackerFromContext(ctx)
+	if tracker == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	call := &budgetCall{cancel: cancel}
+	stop := func() { cancel(nil) }
+	if tracker.budget.MaxDuration > 0 {
+		// The time limit moves back while the clock is paused, so the time
+		// left is checked again when the timer fires
+		done := make(chan struct{})
+		go func() {
+			timer := time.NewTimer(tracker.timeLeft())
+			defer timer.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+					left := tracker.timeLeft()
+					if left <= 0 {
+						cancel(tracker.durationExceeded())
+						return
+					}
+					timer.Reset(left)
+				}
+			}
+		}()
+		stop = func() {
+			close(done)
+			cancel(nil)
+		}
+	}
+	return context.WithValue(ctx, budgetCallKey{}, call), stop
+}
+
+// budgetExceeded returns the budget limit that cancelled the context, if any
+func budgetExceeded(ctx context.Context) *BudgetExceededError {
+	var exceeded *BudgetExceededError
+	if errors.As(context.Cause(ctx), &exceeded) {
+		return exceeded
+	}
+	return nil
+}
+
+// enforceBudget is the tool middleware refusing the tool calls that the
+// budget of the run does not allow. Reaching a limit other than a per-tool
+// limit cancels the LLM call, which ends the tool loop.
+func enforceBudget(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+	tracker := budgetTrackerFromContext(ctx)
+	if tracker == nil {
+		return next(ctx, call)
+	}
+
+	if exceeded := tracker.startToolCall(call.Tool.Name()); exceeded != nil {
+		if budget, ok := ctx.Value(budgetCallKey{}).(*budgetCall); ok && exceeded.Tool == "" {
+			budget.cancel(exceeded)
+		}
+		return "", exceeded
+	}
+	return next(ctx, call)
+}
+
+// answerOverBudget asks the LLM for a final answer without tools once the run
+// has exceeded its budget, from the results of the tool calls made so far
+func (a *Agent) answerOverBudget(ctx context.Context, input string, results *toolResults, exceeded *BudgetExceededError) (string, error) {
+	// The run may have been stopped by the budget of the run it is nested in
+	if ctx.Err() != nil {
+		ctx = context.WithoutCancel(ctx)
+	}
+	if a.logger != nil {
+		a.logger.Warn(ctx, "Run budget exceeded, answering with the results so far", map[string]interface{}{
+			"agent":  a.name,
+			"reason": exceeded.Reason,
+		})
+	}
+	if trace := runTraceFromContext(ctx); trace != nil {
+		trace.mu.Lock()
+		trace.budgetExceeded = exceeded.Reason
+		trace.mu.Unlock()
+	}
+
+	prompt := fmt.Sprintf(`%s
+
+Work on this request was stopped because %s. Answer the request with the information gathered so far, and say that the answer may be incomplete and why.
+
+Results of the tool calls made so far:
+%s`, input, exceeded.Reason, results)
+
+	call := &LLMCall{Prompt: prompt, Options: a.answerOptions(ctx)}
+	return a.callLLM(ctx, call, func(ctx context.Context, call *LLMCall) (string, error) {
+		return a.llm.Generate(ctx, call.Prompt, call.Options...)
+	})
+}
@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+)
+
+// countingTool returns a tool counting its calls
+func countingTool(name string, calls *int) *mockTool {
+	return &mockTool{name: name, runFunc: func(ctx context.Context, input string) (string, error) {
+		*calls++
+		return name + " result", nil
+	}}
+}
+
+func TestBudgetTokensOfSubAgents(t *testing.T) {
+	research, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.Text("the research found 42").WithUsage(llm.TokenUsage{InputTokens: 150, OutputTokens: 50})))),
+		WithName("Research"),
+		WithDescription("Researches questions"),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create sub-agent: %v", err)
+	}
+
+	var lookups int
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("Research_agent", `{"query":"find the answer"}`).WithUsage(llm.TokenUsage{InputTokens: 10, OutputTokens: 5}),
+		mock.ToolCall("lookup", `{"input":"more"}`).WithUsage(llm.TokenUsage{InputTokens: 10, OutputTokens: 5}),
+		mock.Text("The answer is 42, but the search was cut short"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithName("Assistant"),
+		WithTools(countingTool("lookup", &lookups)),
+		WithAgents(research),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(5),
+		WithBudget(RunBudget{MaxTokens: 100}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	result, err := agent.RunDetailed(context.Background(), "What is the answer?")
+	if err != nil {
+		t.Fatalf("Expected an answer within the budget, got %v", err)
+	}
+	if result.Output != "The answer is 42, but the search was cut short" || lookups != 0 {
+		t.Errorf("Expected an answer without the lookup, got %q after %d lookups", result.Output, lookups)
+	}
+	if !strings.Contains(result.BudgetExceeded, "token budget of 100 tokens") {
+		t.Errorf("Expected the token budget to be reported, got %q", result.BudgetExceeded)
+	}
+
+	final := model.LastCall()
+	if final.Method != "Generate" || !strings.Contains(final.Prompt, "What is the answer?") ||
+		!strings.Contains(final.Prompt, "the research found 42") || !strings.Contains(final.Prompt, result.BudgetExceeded) {
+		t.Errorf("Expected a final answer from the results so far, got %s: %q", final.Method, final.Prompt)
+	}
+}
+
+func TestBudgetToolCalls(t *testing.T) {
+	var lookups, searches int
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("lookup", `{"input":"a"}`),
+		mock.ToolCall("lookup", `{"input":"b"}`),
+		mock.ToolCall("search", `{"input":"c"}`),
+		mock.ToolCall("search", `{"input":"d"}`),
+		mock.Text("answered with the results so far"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(countingTool("lookup", &lookups), countingTool("search", &searches)),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(5),
+		WithBudget(RunBudget{MaxToolCalls: 2, MaxCallsPerTool: map[string]int{"lookup": 1}}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	result, err := agent.RunDetailed(context.Background(), "Look it up")
+	if err != nil {
+		t.Fatalf("RunDetailed failed: %v", err)
+	}
+	if lookups != 1 || searches != 1 {
+		t.Errorf("Expected one lookup and one search, got %d and %d", lookups, searches)
+	}
+
+	// The LLM is told about the tool limit and goes on with another tool
+	calls := model.Calls()
+	if len(calls) != 5 || !strings.Contains(calls[2].ToolResults[0].Content, "limit of 1 calls of the tool lookup") {
+		t.Fatalf("Expected the refused lookup to be reported, got %+v", calls)
+	}
+
+	// The limit of calls of all tools ends the run
+	if result.Output != "answered with the results so far" || !strings.Contains(result.BudgetExceeded, "limit of 2 tool calls") {
+		t.Errorf("Expected an answer after the tool call limit, got %q (%q)", result.Output, result.BudgetExceeded)
+	}
+}
+
+func TestBudgetDuration(t *testing.T) {
+	slow := &mockTool{name: "slow", runFunc: func(ctx context.Context, input string) (string, error) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(5 * time.Second):
+			return "too late", nil
+		}
+	}}
+	agent, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("slow", `{}`), mock.Text("no time to finish")))),
+		WithTools(slow),
+		WithRequirePlanApproval(false),
+		WithBudget(RunBudget{MaxDuration: 50 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	start := time.Now()
+	result, err := agent.RunDetailed(context.Background(), "Do the slow thing")
+	if err != nil {
+		t.Fatalf("RunDetailed failed: %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expected the slow tool to be stopped, the run took %s", time.Since(start))
+	}
+	if result.Output != "no time to finish" || !strings.Contains(result.BudgetExceeded, "time limit of 50ms") {
+		t.Errorf("Expected an answer after the time limit, got %q (%q)", result.Output, result.BudgetExceeded)
+	}
+}
+
+func TestRunBudgetSharedWithSubAgents(t *testing.T) {
+	var lookups int
+	lookup := countingTool("lookup", &lookups)
+	research, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("lookup", `{"input":"sub"}`), mock.Text("researched")))),
+		WithName("Research"),
+		WithDescription("Researches questions"),
+		WithTools(lookup),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create sub-agent: %v", err)
+	}
+
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("Research_agent", `{"query":"research"}`),
+		mock.ToolCall("lookup", `{"input":"parent"}`),
+		mock.Text("answered"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithName("Assistant"),
+		WithTools(lookup),
+		WithAgents(research),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(5),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	// The call of the sub-agent and its lookup use up the budget of the run
+	ctx := WithRunBudget(context.Background(), RunBudget{MaxToolCalls: 2})
+	result, err := agent.RunDetailed(ctx, "Research it")
+	if err != nil {
+		t.Fatalf("RunDetailed failed: %v", err)
+	}
+	if lookups != 1 || result.Output != "answered" || !strings.Contains(result.BudgetExceeded, "limit of 2 tool calls") {
+		t.Errorf("Expected the budget to cover the sub-agent, got %d lookups and %q (%q)", lookups, result.Output, result.BudgetExceeded)
+	}
+
+	// Without a budget the next run is not limited
+	model.Script(
+		mock.ToolCall("lookup", `{"input":"1"}`),
+		mock.ToolCall("lookup", `{"input":"2"}`),
+		mock.ToolCall("lookup", `{"input":"3"}`),
+		mock.Text("unlimited"),
+	)
+	if output, err := agent.Run(context.Background(), "Again"); err != nil || output != "unlimited" || lookups != 4 {
+		t.Errorf("Expected an unlimited run, got %q after %d lookups (%v)", output, lookups, err)
+	}
+}
+
+func TestBudgetStream(t *testing.T) {
+	var lookups int
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("lookup", `{"input":"a"}`),
+		mock.ToolCall("lookup", `{"input":"b"}`),
+		mock.Text("answered with the results so far"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(countingTool("lookup", &lookups)),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(5),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := WithRunBudget(context.Background(), RunBudget{MaxToolCalls: 1})
+	events, err := agent.RunStream(ctx, "Look it up")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var content strings.Builder
+	for event := range events {
+		switch event.Type {
+		case interfaces.AgentEventContent:
+			content.WriteString(event.Content)
+		case interfaces.AgentEventError:
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+
+	if lookups != 1 || content.String() != "answered with the results so far" {
+		t.Errorf("Expected an answer after one lookup, got %q after %d lookups", content.String(), lookups)
+	}
+	final := model.LastCall()
+	if final.Method != "Generate" || !strings.Contains(final.Prompt, "limit of 1 tool calls") ||
+		!strings.Contains(final.Prompt, "lookup result") {
+		t.Errorf("Expected a final answer from the results so far, got %s: %q", final.Method, final.Prompt)
+	}
+}
@@ -0,0 +1,551 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/andmang/agent-sdk-go/pkg/executionplan"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+	"github.com/andmang/agent-sdk-go/pkg/tracing"
+)
+
+// WithCheckpointStore saves the state of each run to the store after every
+// LLM turn and tool result, and after every step of an execution plan, so
+// that a run interrupted by a crash can be continued with ResumeRun. Runs
+// started with Run, RunWithParts and RunStream, and plans executed on
+// approval, are checkpointed. A run in progress holds a claim on its run ID
+// in the store, so that it is not resumed twice at the same time.
+//
+// Tool calls of a checkpointed run carry an idempotency key, read with
+// interfaces.IdempotencyKey, that stays the same when the call is made again
+// after the run is resumed.
+func WithCheckpointStore(store interfaces.CheckpointStore) Option {
+	return func(a *Agent) {
+		a.checkpointStore = store
+	}
+}
+
+// runClaimTTL is how long the claim of a run in progress lasts unless it is
+// renewed. The claim of a run whose process stopped expires after it, and the
+// run can then be resumed.
+const runClaimTTL = time.Minute
+
+// runIDKey is the context key of the ID a run is checkpointed under
+type runIDKey struct{}
+
+// WithRunID returns a context whose run is checkpointed under the given ID, to
+// resume it later with ResumeRun. Runs without one get a generated ID, which
+// UnfinishedRuns reports.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// ResumeRun continues a checkpointed run from its last checkpoint and returns
+// its response. The LLM is given the conversation of the run as it was saved,
+// including the results of the tool calls that finished, and an execution
+// plan continues with the first step that did not finish. The response of a
+// run that already completed is returned as is. A run that is in progress,
+// or whose process stopped less than a minute ago, is claimed by it, and
+// ResumeRun returns interfaces.ErrRunClaimed.
+func (a *Agent) ResumeRun(ctx context.Context, runID string) (result string, err error) {
+	if a.isRemote {
+		return "", fmt.Errorf("resuming runs is not supported by remote agents")
+	}
+	if a.checkpointStore == nil {
+		return "", fmt.Errorf("no checkpoint store configured")
+	}
+
+	// Claim the run before reading its state, so that it is not resumed
+	// twice and the state is not changed by the run that held it
+	owner := uuid.New().String()
+	if err := a.checkpointStore.ClaimRun(ctx, runID, owner, runClaimTTL); err != nil {
+		return "", err
+	}
+	claimed := false
+	defer func() {
+		if !claimed {
+			a.releaseRun(ctx, runID, owner)
+		}
+	}()
+
+	state, err := a.checkpointStore.GetCheckpoint(ctx, runID)
+	if err != nil {
+		return "", err
+	}
+	if state.AgentName != a.name {
+		return "", fmt.Errorf("run %s belongs to agent %q", runID, state.AgentName)
+	}
+	if state.Status == interfaces.RunStatusCompleted {
+		return state.Response, nil
+	}
+
+	// Restore the context of the run
+	ctx = tracing.WithAgentName(ctx, a.name)
+	if state.OrgID != "" {
+		ctx = multitenancy.WithOrgID(ctx, state.OrgID)
+	}
+	if state.ConversationID != "" {
+		ctx = memory.WithConversationID(ctx, state.ConversationID)
+	}
+	ctx = a.startBudget(ctx)
+
+	var span interfaces.Span
+	if a.tracer != nil {
+		ctx, span = a.tracer.StartSpan(ctx, "agent.ResumeRun")
+		defer span.End()
+	}
+
+	defer func() {
+		if err != nil {
+			a.notifyError(ctx, err)
+		}
+	}()
+
+	// Calls that were running when the run stopped are made again by the LLM
+	// or the plan, in the iteration that was interrupted. Calls that were
+	// waiting for approval wait for the same approval, or get its decision.
+	if len(state.PendingToolCalls) > 0 && state.Iteration > 0 {
+		state.Iteration--
+	}
+	approvals := make(map[string]string)
+	for _, pending := range state.PendingToolCalls {
+		if pending.ApprovalID != "" {
+			approvals[pending.IdempotencyKey] = pending.ApprovalID
+		}
+	}
+	state.PendingToolCalls = nil
+	state.Status = interfaces.RunStatusRunning
+	state.Error = ""
+
+	// The conversation that came before the run is read from memory
+	var history []interfaces.Message
+	if a.memory != nil && state.MemoryOffset > 0 {
+		messages, err := a.memory.GetMessages(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the conversation: %w", err)
+		}
+		history = messages[:min(state.MemoryOffset, len(messages))]
+	}
+
+	run := a.newCheckpointRun(*state, history, owner)
+	run.approvals = approvals
+	ctx = context.WithValue(ctx, checkpointRunKey{}, run)
+	if err := run.save(ctx); err != nil {
+		return "", err
+	}
+	claimed = true
+	run.renewClaim(ctx)
+	defer func() { run.finish(ctx, result, err) }()
+
+	a.logger.Info(ctx, "Resuming run", map[string]interface{}{
+		"run_id":    runID,
+		"iteration": state.Iteration,
+	})
+
+	if state.Plan != nil {
+		plan := &executionplan.ExecutionPlan{
+			TaskID:       state.Plan.TaskID,
+			Description:  state.Plan.Description,
+			UserApproved: true,
+			Status:       executionplan.StatusApproved,
+		}
+		for _, step := range state.Plan.Steps {
+			plan.Steps = append(plan.Steps, executionplan.ExecutionStep{
+				ToolName:    step.ToolName,
+				Input:       step.Input,
+				Description: step.Description,
+			})
+		}
+		return a.runPlan(ctx, plan, state.Plan.NextStep, state.Plan.Results)
+	}
+
+	return a.runWithoutExecutionPlanWithTools(ctx, state.Input, nil, a.runTools(ctx))
+}
+
+// UnfinishedRuns returns the checkpointed runs of the agent that can be
+// resumed, limited to the organization of the context if it has one
+func (a *Agent) UnfinishedRuns(ctx context.Context) ([]interfaces.RunCheckpoint, error) {
+	if a.checkpointStore == nil {
+		return nil, fmt.Errorf("no checkpoint store configured")
+	}
+
+	checkpoints, err := a.checkpointStore.ListCheckpoints(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	orgID, _ := multitenancy.GetOrgID(ctx)
+
+	var unfinished []interfaces.RunCheckpoint
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Status == interfaces.RunStatusCompleted || checkpoint.AgentName != a.name {
+			continue
+		}
+		if orgID != "" && checkpoint.OrgID != "" && checkpoint.OrgID != orgID {
+			continue
+		}
+		unfinished = append(unfinished, checkpoint)
+	}
+	return unfinished, nil
+}
+
+// startRun claims the run ID of the context, saves the first checkpoint of a
+// run and returns a context carrying it. The caller fills in the input and
+// the messages or plan of the state, and gives the conversation of memory
+// that came before the run.
+func (a *Agent) startRun(ctx context.Context, state interfaces.RunCheckpoint, history []interfaces.Message) (context.Context, *checkpointRun, error) {
+	state.RunID, _ = ctx.Value(runIDKey{}).(string)
+	if state.RunID == "" {
+		state.RunID = uuid.New().String()
+	}
+	state.AgentName = a.name
+	state.OrgID, _ = multitenancy.GetOrgID(ctx)
+	state.ConversationID, _ = memory.GetConversationID(ctx)
+	state.Status = interfaces.RunStatusRunning
+	state.CreatedAt = time.Now()
+
+	owner := uuid.New().String()
+	if err := a.checkpointStore.ClaimRun(ctx, state.RunID, owner, runClaimTTL); err != nil {
+		return ctx, nil, err
+	}
+	run := a.newCheckpointRun(state, history, owner)
+	if err := run.save(ctx); err != nil {
+		a.releaseRun(ctx, state.RunID, owner)
+		return ctx, nil, err
+	}
+	run.renewClaim(ctx)
+	return context.WithValue(ctx, checkpointRunKey{}, run), run, nil
+}
+
+// startConversationRun starts a run of the LLM with the conversation of the
+// agent's memory, or the input alone if it has none. The checkpoints of the
+// run hold its own messages, and the number of messages in memory before it.
+func (a *Agent) startConversationRun(ctx context.Context, input string, parts []interfaces.ContentPart) (context.Context, *checkpointRun, error) {
+	state := interfaces.RunCheckpoint{Input: input}
+	var history []interfaces.Message
+	if a.memory != nil {
+		messages, err := a.memory.GetMessages(ctx)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("failed to read the conversation: %w", err)
+		}
+		history = messages
+		state.MemoryOffset = len(messages)
+	} else {
+		state.Messages = []interfaces.Message{{Role: interfaces.MessageRoleUser, Content: input, Parts: parts}}
+	}
+	return a.startRun(ctx, state, history)
+}
+
+// releaseRun releases the claim of the owner on a run
+func (a *Agent) releaseRun(ctx context.Context, runID, owner string) {
+	if err := a.checkpointStore.ReleaseRun(context.WithoutCancel(ctx), runID, owner); err != nil {
+		a.logger.Warn(ctx, "Failed to release the run", map[string]interface{}{
+			"run_id": runID,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// checkpointRunKey is the context key of the checkpointed run in progress
+type checkpointRunKey struct{}
+
+// currentRun returns the checkpointed run of the agent in progress, or nil.
+// Runs of other agents, such as the parent of a sub-agent, are ignored.
+func (a *Agent) currentRun(ctx context.Context) *checkpointRun {
+	if run, ok := ctx.Value(checkpointRunKey{}).(*checkpointRun); ok && run.agent == a {
+		return run
+	}
+	return nil
+}
+
+// iterationsLeft returns the tool-calling iterations left to the run in
+// progress
+func (a *Agent) iterationsLeft(ctx context.Context) int {
+	run := a.currentRun(ctx)
+	if run == nil {
+		return a.maxIterations
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return max(a.maxIterations-run.state.Iteration, 1)
+}
+
+// checkpointToolCall is the tool middleware that records the tool calls of a
+// checkpointed run and gives them their idempotency key
+func (a *Agent) checkpointToolCall(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+	run := a.currentRun(ctx)
+	if run == nil {
+		return next(ctx, call)
+	}
+
+	key, err := run.startToolCall(ctx, call.Tool.Name(), call.Arguments)
+	if err != nil {
+		return "", err
+	}
+	result, err := next(interfaces.WithIdempotencyKey(ctx, key), call)
+	// A call that was still waiting for approval is made again when the run
+	// is resumed
+	if !errors.Is(err, errStoppedAwaitingApproval) {
+		run.finishToolCall(key)
+	}
+	return result, err
+}
+
+// checkpointRun is the state of a checkpointed run in progress
+type checkpointRun struct {
+	agent  *Agent
+	memory *checkpointMemory // Memory given to the LLM
+	owner  string            // Owner of the claim on the run
+	// stopRenewal stops the renewal of the claim on the run
+	stopRenewal func()
+
+	mu    sync.Mutex
+	state interfaces.RunCheckpoint
+	// resultsWritten is set when tool results were written since the last
+	// iteration started, so that the next tool call starts a new one
+	resultsWritten bool
+	// approvals are the approvals that the tool calls of the run waited for
+	// when it was resumed, by idempotency key
+	approvals map[string]string
+}
+
+// newCheckpointRun returns a run continuing from the given state, after the
+// conversation of memory that came before it, claimed by the owner
+func (a *Agent) newCheckpointRun(state interfaces.RunCheckpoint, history []interfaces.Message, owner string) *checkpointRun {
+	run := &checkpointRun{agent: a, state: state, owner: owner, resultsWritten: true, stopRenewal: func() {}}
+	if run.state.CompletedToolCalls == nil {
+		run.state.CompletedToolCalls = make(map[string]int)
+	}
+	run.memory = &checkpointMemory{
+		run:        run,
+		memory:     a.memory,
+		transcript: llm.NewTranscript(append(slices.Clone(history), state.Messages...)),
+	}
+	return run
+}
+
+// renewClaim renews the claim on the run until the run finishes
+func (r *checkpointRun) renewClaim(ctx context.Context) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.stopRenewal = cancel
+	go func() {
+		ticker := time.NewTicker(runClaimTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.agent.checkpointStore.ClaimRun(ctx, r.state.RunID, r.owner, runClaimTTL); err != nil && ctx.Err() == nil {
+					r.agent.logger.Warn(ctx, "Failed to renew the claim on the run", map[string]interface{}{
+						"run_id": r.state.RunID,
+						"error":  err.Error(),
+					})
+				}
+			}
+		}
+	}()
+}
+
+// save saves the state of the run
+func (r *checkpointRun) save(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.saveLocked(ctx)
+}
+
+// saveLocked saves the state of the run with its lock held
+func (r *checkpointRun) saveLocked(ctx context.Context) error {
+	r.state.UpdatedAt = time.Now()
+	if err := r.agent.checkpointStore.SaveCheckpoint(ctx, r.state); err != nil {
+		return fmt.Errorf("failed to save checkpoint of run %s: %w", r.state.RunID, err)
+	}
+	return nil
+}
+
+// finish saves the outcome of the run and releases its claim. A run that
+// failed can be resumed.
+func (r *checkpointRun) finish(ctx context.Context, response string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopRenewal()
+	defer r.agent.releaseRun(ctx, r.state.RunID, r.owner)
+
+	// Calls waiting for approval are kept, so that the resumed run waits for
+	// the same approvals
+	r.state.PendingToolCalls = slices.DeleteFunc(r.state.PendingToolCalls, func(pending interfaces.PendingToolCall) bool {
+		return pending.ApprovalID == ""
+	})
+	if err != nil {
+		r.state.Status = interfaces.RunStatusFailed
+		r.state.Error = err.Error()
+	} else {
+		r.state.Status = interfaces.RunStatusCompleted
+		r.state.Response = response
+	}
+	if saveErr := r.saveLocked(context.WithoutCancel(ctx)); saveErr != nil {
+		r.agent.logger.Warn(ctx, "Failed to save the outcome of the run", map[string]interface{}{
+			"run_id": r.state.RunID,
+			"error":  saveErr.Error(),
+		})
+	}
+}
+
+// addMessage adds a message of the conversation and saves the run
+func (r *checkpointRun) addMessage(ctx context.Context, message interfaces.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Messages = append(r.state.Messages, message)
+	if message.Role == interfaces.MessageRoleTool {
+		r.resultsWritten = true
+	}
+	return r.saveLocked(ctx)
+}
+
+// clearMessages removes the messages of the conversation and saves the run
+func (r *checkpointRun) clearMessages(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Messages = nil
+	r.state.MemoryOffset = 0
+	return r.saveLocked(ctx)
+}
+
+// startToolCall records a tool call as pending, saves the run and returns the
+// idempotency key of the call. The key is derived from the run, the tool and
+// its arguments, and the number of identical calls made before it, so a call
+// made again after the run is resumed gets the same key.
+func (r *checkpointRun) startToolCall(ctx context.Context, toolName, arguments string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The first call after the results of the previous iteration were
+	// written starts a new one
+	if len(r.state.PendingToolCalls) == 0 && r.resultsWritten {
+		r.state.Iteration++
+		r.resultsWritten = false
+	}
+
+	callKey := toolCallKey(toolName, arguments)
+	occurrence := r.state.CompletedToolCalls[callKey]
+	for _, pending := range r.state.PendingToolCalls {
+		if toolCallKey(pending.ToolName, pending.Arguments) == callKey {
+			occurrence++
+		}
+	}
+
+	key := fmt.Sprintf("%s-%s-%d", r.state.RunID, callKey, occurrence)
+	r.state.PendingToolCalls = append(r.state.PendingToolCalls, interfaces.PendingToolCall{
+		ToolName:       toolName,
+		Arguments:      arguments,
+		IdempotencyKey: key,
+		StartedAt:      time.Now(),
+	})
+	return key, r.saveLocked(ctx)
+}
+
+// finishToolCall records that a tool call finished. The run is saved with the
+// result of the call, when it is written to memory or as a plan step.
+func (r *checkpointRun) finishToolCall(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := slices.IndexFunc(r.state.PendingToolCalls, func(pending interfaces.PendingToolCall) bool {
+		return pending.IdempotencyKey == key
+	})
+	if index < 0 {
+		return
+	}
+	pending := r.state.PendingToolCalls[index]
+	r.state.PendingToolCalls = slices.Delete(r.state.PendingToolCalls, index, index+1)
+	r.state.CompletedToolCalls[toolCallKey(pending.ToolName, pending.Arguments)]++
+}
+
+// approvalFor returns the approval that the tool call with the idempotency key
+// waited for before the run was resumed, if any
+func (r *checkpointRun) approvalFor(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.approvals[key]
+}
+
+// waitForApproval records that the pending tool call with the idempotency key
+// waits for the approval, and saves the run
+func (r *checkpointRun) waitForApproval(ctx context.Context, key, approvalID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := slices.IndexFunc(r.state.PendingToolCalls, func(pending interfaces.PendingToolCall) bool {
+		return pending.IdempotencyKey == key
+	})
+	if index < 0 {
+		return nil
+	}
+	r.state.PendingToolCalls[index].ApprovalID = approvalID
+	return r.saveLocked(ctx)
+}
+
+// finishPlanStep records the result of a plan step and saves the run
+func (r *checkpointRun) finishPlanStep(ctx context.Context, step int, result string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Plan.NextStep = step + 1
+	r.state.Plan.Results = append(r.state.Plan.Results, result)
+	return r.saveLocked(ctx)
+}
+
+// toolCallKey identifies the calls of a tool with the same arguments
+func toolCallKey(toolName, arguments string) string {
+	sum := sha256.Sum256([]byte(toolName + "\x00" + arguments))
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkpointMemory is the memory given to the LLM during a checkpointed run.
+// It reads the conversation of the run, and writes messages to the run and to
+// the agent's memory.
+type checkpointMemory struct {
+	run        *checkpointRun
+	memory     interfaces.Memory // Memory of the agent; nil if it has none
+	transcript interfaces.Memory
+}
+
+// AddMessage adds a message to the agent's memory and the run
+func (m *checkpointMemory) AddMessage(ctx context.Context, message interfaces.Message) error {
+	if m.memory != nil {
+		if err := m.memory.AddMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+	if err := m.transcript.AddMessage(ctx, message); err != nil {
+		return err
+	}
+	return m.run.addMessage(ctx, message)
+}
+
+// GetMessages returns the conversation of the run
+func (m *checkpointMemory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	return m.transcript.GetMessages(ctx, options...)
+}
+
+// Clear clears the agent's memory and the conversation of the run
+func (m *checkpointMemory) Clear(ctx context.Context) error {
+	if m.memory != nil {
+		if err := m.memory.Clear(ctx); err != nil {
+			return err
+		}
+	}
+	if err := m.transcript.Clear(ctx); err != nil {
+		return err
+	}
+	return m.run.clearMessages(ctx)
+}
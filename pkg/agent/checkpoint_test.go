@@ -0,0 +1,428 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/checkpoint"
+	"github.com/andmang/agent-sdk-go/pkg/executionplan"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+)
+
+func TestResumeRunAfterFailure(t *testing.T) {
+	store := checkpoint.NewInMemoryStore()
+	var charges int
+	charge := &mockTool{name: "charge", runFunc: func(ctx context.Context, input string) (string, error) {
+		charges++
+		return "charged", nil
+	}}
+
+	// The first process fails after the tool call
+	first, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("charge", `{"input":"10"}`), mock.Error(errors.New("connection reset"))))),
+		WithTools(charge),
+		WithName("billing"),
+		WithRequirePlanApproval(false),
+		WithCheckpointStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ctx := WithRunID(hookTestContext(), "run-1")
+	if _, err := first.Run(ctx, "Charge 10"); err == nil {
+		t.Fatal("Expected the first run to fail")
+	}
+
+	saved, err := store.GetCheckpoint(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if saved.Status != interfaces.RunStatusFailed || saved.Iteration != 1 || len(saved.Messages) != 3 {
+		t.Fatalf("Unexpected checkpoint: status %s, iteration %d, %d messages", saved.Status, saved.Iteration, len(saved.Messages))
+	}
+	if saved.Messages[2].Role != interfaces.MessageRoleTool || saved.Messages[2].Content != "charged" {
+		t.Errorf("Expected the tool result in the checkpoint, got %+v", saved.Messages[2])
+	}
+
+	unfinished, err := first.UnfinishedRuns(ctx)
+	if err != nil || len(unfinished) != 1 || unfinished[0].RunID != "run-1" {
+		t.Errorf("Expected the run to be unfinished, got %+v (%v)", unfinished, err)
+	}
+
+	// A new process resumes the run
+	model := mock.New(mock.WithTurns(mock.Text("charged once")))
+	second, err := NewAgent(
+		WithLLM(model),
+		WithTools(charge),
+		WithName("billing"),
+		WithRequirePlanApproval(false),
+		WithCheckpointStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	response, err := second.ResumeRun(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	if response != "charged once" || charges != 1 {
+		t.Errorf("Expected the run to finish without charging again, got %q after %d charges", response, charges)
+	}
+
+	call := model.LastCall()
+	if call.Options.MaxIterations != 1 {
+		t.Errorf("Expected 1 iteration to be left, got %d", call.Options.MaxIterations)
+	}
+	messages, err := call.Options.Memory.GetMessages(context.Background())
+	if err != nil || len(messages) != 3 || messages[0].Content != "Charge 10" {
+		t.Errorf("Expected the LLM to be given the conversation of the run, got %+v (%v)", messages, err)
+	}
+
+	saved, _ = store.GetCheckpoint(ctx, "run-1")
+	if saved.Status != interfaces.RunStatusCompleted || saved.Response != "charged once" {
+		t.Errorf("Expected the run to be completed, got %s with %q", saved.Status, saved.Response)
+	}
+	if response, err := second.ResumeRun(context.Background(), "run-1"); err != nil || response != "charged once" || len(model.Calls()) != 1 {
+		t.Errorf("Expected a completed run to return its response, got %q (%v)", response, err)
+	}
+}
+
+func TestResumeRunWithMemory(t *testing.T) {
+	store := checkpoint.NewInMemoryStore()
+	mem := memory.NewConversationBuffer()
+	ctx := WithRunID(hookTestContext(), "run-4")
+
+	// The conversation before the run
+	for _, message := range []interfaces.Message{
+		{Role: interfaces.MessageRoleUser, Content: "Hello"},
+		{Role: interfaces.MessageRoleAssistant, Content: "Hi, how can I help?"},
+	} {
+		if err := mem.AddMessage(ctx, message); err != nil {
+			t.Fatalf("AddMessage failed: %v", err)
+		}
+	}
+
+	newAgent := func(model *mock.LLM) *Agent {
+		agent, err := NewAgent(
+			WithLLM(model),
+			WithTools(&mockTool{name: "charge"}),
+			WithName("billing"),
+			WithMemory(mem),
+			WithRequirePlanApproval(false),
+			WithCheckpointStore(store),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create agent: %v", err)
+		}
+		return agent
+	}
+	first := newAgent(mock.New(mock.WithTurns(mock.ToolCall("charge", `{"input":"10"}`), mock.Error(errors.New("connection reset")))))
+	if _, err := first.Run(ctx, "Charge 10"); err == nil {
+		t.Fatal("Expected the first run to fail")
+	}
+
+	// The checkpoint holds the messages of the run, not the conversation
+	saved, err := store.GetCheckpoint(ctx, "run-4")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if saved.MemoryOffset != 3 || len(saved.Messages) != 2 || len(saved.Messages[0].ToolCalls) != 1 || saved.Messages[1].Role != interfaces.MessageRoleTool {
+		t.Fatalf("Expected the offset of the run in memory and its messages, got %d and %+v", saved.MemoryOffset, saved.Messages)
+	}
+
+	// The resumed run is given the conversation followed by the run
+	model := mock.New(mock.WithTurns(mock.Text("charged once")))
+	if _, err := newAgent(model).ResumeRun(context.Background(), "run-4"); err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	messages, err := model.LastCall().Options.Memory.GetMessages(context.Background())
+	if err != nil || len(messages) != 5 || messages[0].Content != "Hello" || messages[2].Content != "Charge 10" || messages[4].Role != interfaces.MessageRoleTool {
+		t.Errorf("Expected the conversation and the messages of the run, got %+v (%v)", messages, err)
+	}
+}
+
+func TestResumeRunClaimed(t *testing.T) {
+	store := checkpoint.NewInMemoryStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	charge := &mockTool{name: "charge", runFunc: func(ctx context.Context, input string) (string, error) {
+		close(started)
+		<-release
+		return "charged", nil
+	}}
+
+	agent, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("charge", `{"input":"10"}`), mock.Text("done")))),
+		WithTools(charge),
+		WithRequirePlanApproval(false),
+		WithCheckpointStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ctx := WithRunID(hookTestContext(), "run-5")
+	done := runAsync(ctx, agent, "Charge 10")
+	<-started
+
+	// A run in progress is neither resumed nor started again
+	if _, err := agent.ResumeRun(ctx, "run-5"); !errors.Is(err, interfaces.ErrRunClaimed) {
+		t.Errorf("Expected ErrRunClaimed from ResumeRun, got %v", err)
+	}
+	if _, err := agent.Run(ctx, "Charge 10"); !errors.Is(err, interfaces.ErrRunClaimed) {
+		t.Errorf("Expected ErrRunClaimed from Run, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response, err := agent.ResumeRun(ctx, "run-5"); err != nil || response != "done" {
+		t.Errorf("Expected the finished run to be released, got %q (%v)", response, err)
+	}
+}
+
+func TestResumeStreamedRun(t *testing.T) {
+	store := checkpoint.NewInMemoryStore()
+	var charges int
+	newAgent := func(model *mock.LLM) *Agent {
+		agent, err := NewAgent(
+			WithLLM(model),
+			WithTools(countingTool("charge", &charges)),
+			WithName("billing"),
+			WithRequirePlanApproval(false),
+			WithCheckpointStore(store),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create agent: %v", err)
+		}
+		return agent
+	}
+
+	// The stream fails after the tool call
+	first := newAgent(mock.New(mock.WithTurns(mock.ToolCall("charge", `{"input":"10"}`), mock.Error(errors.New("connection reset")))))
+	ctx := WithRunID(hookTestContext(), "run-6")
+	events, err := first.RunStream(ctx, "Charge 10")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var failed bool
+	for event := range events {
+		if event.Type == interfaces.AgentEventError {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Fatal("Expected the stream to fail")
+	}
+
+	saved, err := store.GetCheckpoint(ctx, "run-6")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if saved.Status != interfaces.RunStatusFailed || len(saved.Messages) != 3 || saved.Messages[2].Content != "charge result" {
+		t.Fatalf("Expected the tool result in the checkpoint, got %s with %+v", saved.Status, saved.Messages)
+	}
+
+	// The run is resumed without calling the tool again
+	response, err := newAgent(mock.New(mock.WithTurns(mock.Text("charged once")))).ResumeRun(context.Background(), "run-6")
+	if err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	if response != "charged once" || charges != 1 {
+		t.Errorf("Expected the run to finish without charging again, got %q after %d charges", response, charges)
+	}
+}
+
+func TestResumeRunWithoutStreaming(t *testing.T) {
+	// An OpenAI server that asks for a tool call, fails, and then answers
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, body)
+		n := len(requests)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch n {
+		case 1:
+			_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"finish_reason":"tool_calls",
+				"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"charge","arguments":"{\"input\":\"10\"}"}}]}}]}`))
+		case 2:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"connection reset upstream","type":"invalid_request_error"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"3","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"finish_reason":"stop",
+				"message":{"role":"assistant","content":"charged once"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	store := checkpoint.NewInMemoryStore()
+	var charges int
+	newAgent := func() *Agent {
+		agent, err := NewAgent(
+			WithLLM(openai.NewClient("test-key", openai.WithModel("gpt-4o-mini"), openai.WithBaseURL(server.URL))),
+			WithTools(countingTool("charge", &charges)),
+			WithName("billing"),
+			WithRequirePlanApproval(false),
+			WithCheckpointStore(store),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create agent: %v", err)
+		}
+		return agent
+	}
+
+	ctx := WithRunID(hookTestContext(), "run-openai")
+	if _, err := newAgent().Run(ctx, "Charge 10"); err == nil {
+		t.Fatal("Expected the first run to fail")
+	}
+	saved, err := store.GetCheckpoint(ctx, "run-openai")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if len(saved.Messages) != 3 || len(saved.Messages[1].ToolCalls) != 1 || saved.Messages[2].Content != "charge result" {
+		t.Fatalf("Expected the tool call and its result in the checkpoint, got %+v", saved.Messages)
+	}
+
+	// A new process resumes the run without calling the tool again
+	response, err := newAgent().ResumeRun(context.Background(), "run-openai")
+	if err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	if response != "charged once" || charges != 1 {
+		t.Errorf("Expected the run to finish without charging again, got %q after %d charges", response, charges)
+	}
+
+	// The resumed request replays the tool call and its result
+	mu.Lock()
+	defer mu.Unlock()
+	messages, _ := requests[len(requests)-1]["messages"].([]interface{})
+	var replayed bool
+	for _, message := range messages {
+		if message, ok := message.(map[string]interface{}); ok && message["role"] == "tool" && message["content"] == "charge result" {
+			replayed = true
+		}
+	}
+	if !replayed {
+		t.Errorf("Expected the tool result in the resumed request, got %v", messages)
+	}
+}
+
+func TestResumeRunIdempotencyKey(t *testing.T) {
+	store := checkpoint.NewInMemoryStore()
+	var keys []string
+	var snapshot *interfaces.RunCheckpoint
+	transfer := &mockTool{name: "transfer", runFunc: func(ctx context.Context, input string) (string, error) {
+		keys = append(keys, interfaces.IdempotencyKey(ctx))
+		if snapshot == nil {
+			// The state the run would be resumed from if the process died now
+			snapshot, _ = store.GetCheckpoint(ctx, "run-2")
+		}
+		return "transferred", nil
+	}}
+
+	first, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"a"}`), mock.Text("done")))),
+		WithTools(transfer),
+		WithRequirePlanApproval(false),
+		WithCheckpointStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	ctx := WithRunID(hookTestContext(), "run-2")
+	if _, err := first.Run(ctx, "Transfer"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if snapshot == nil || len(snapshot.PendingToolCalls) != 1 || snapshot.PendingToolCalls[0].IdempotencyKey != keys[0] {
+		t.Fatalf("Expected the call to be pending with its key, got %+v", snapshot)
+	}
+	if err := store.SaveCheckpoint(ctx, *snapshot); err != nil {
+		t.Fatalf("SaveCheckpoint failed: %v", err)
+	}
+
+	// The LLM makes the interrupted call again
+	second, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"input":"a"}`), mock.Text("done")))),
+		WithTools(transfer),
+		WithRequirePlanApproval(false),
+		WithCheckpointStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	if _, err := second.ResumeRun(ctx, "run-2"); err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[1] != keys[0] {
+		t.Errorf("Expected the call to get the same idempotency key, got %v", keys)
+	}
+}
+
+func TestResumePlan(t *testing.T) {
+	store := checkpoint.NewInMemoryStore()
+	var fetches, uploads int
+	fetch := &mockTool{name: "fetch", runFunc: func(ctx context.Context, input string) (string, error) {
+		fetches++
+		return "fetched", nil
+	}}
+	upload := &mockTool{name: "upload", runFunc: func(ctx context.Context, input string) (string, error) {
+		uploads++
+		if uploads == 1 {
+			return "", errors.New("storage unavailable")
+		}
+		return "uploaded", nil
+	}}
+
+	agent, err := NewAgent(
+		WithLLM(mock.New()),
+		WithTools(fetch, upload),
+		WithCheckpointStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	plan := executionplan.NewExecutionPlan("Copy the report", []executionplan.ExecutionStep{
+		{ToolName: "fetch", Input: `{"input":"report"}`, Description: "Fetch the report"},
+		{ToolName: "upload", Input: `{"input":"report"}`, Description: "Upload the report"},
+	})
+	ctx := WithRunID(hookTestContext(), "run-3")
+	if _, err := agent.ApproveExecutionPlan(ctx, plan); err == nil {
+		t.Fatal("Expected the plan to fail")
+	}
+
+	saved, err := store.GetCheckpoint(ctx, "run-3")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if saved.Plan == nil || saved.Plan.NextStep != 1 || len(saved.Plan.Results) != 1 {
+		t.Fatalf("Expected the plan to stop at the second step, got %+v", saved.Plan)
+	}
+
+	result, err := agent.ResumeRun(ctx, "run-3")
+	if err != nil {
+		t.Fatalf("ResumeRun failed: %v", err)
+	}
+	if fetches != 1 || uploads != 2 {
+		t.Errorf("Expected only the failed step to run again, got %d fetches and %d uploads", fetches, uploads)
+	}
+	if !strings.Contains(result, "Step 1 (Fetch the report): fetched") || !strings.Contains(result, "Step 2 (Upload the report): uploaded") {
+		t.Errorf("Expected the results of both steps, got %q", result)
+	}
+}
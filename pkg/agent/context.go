@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/big"
 	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 )
 
 // ContextKey is a type for context keys to avoid collisions
@@ -124,3 +126,44 @@ func generateInvocationID() string {
 func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, timeout)
 }
+
+// toolChoiceKey is the context key for the tool choice of a run
+type toolChoiceKey struct{}
+
+// WithRunToolChoice sets the tool choice for runs with the returned context,
+// overriding the agent's WithToolChoice. For example, an extraction run can
+// force a call to one tool:
+//
+//	ctx = agent.WithRunToolChoice(ctx, interfaces.ToolChoiceFunction("extract_invoice"))
+func WithRunToolChoice(ctx context.Context, choice interfaces.ToolChoice) context.Context {
+	return context.WithValue(ctx, toolChoiceKey{}, &choice)
+}
+
+// toolChoiceFor returns the tool choice for a run with the given tools,
+// preferring one set for the run over the agent's own. There is none if no
+// tools are offered, or if the named tool is not among them, as for the tools
+// of a sub-agent called during the run.
+func (a *Agent) toolChoiceFor(ctx context.Context, tools []interfaces.Tool) *interfaces.ToolChoice {
+	choice := a.toolChoice
+	if runChoice, ok := ctx.Value(toolChoiceKey{}).(*interfaces.ToolChoice); ok {
+		choice = runChoice
+	}
+	if choice == nil || len(tools) == 0 {
+		return nil
+	}
+	if choice.Mode == interfaces.ToolChoiceModeTool {
+		for _, tool := range tools {
+			if tool.Name() == choice.Name {
+				return choice
+			}
+		}
+		if a.logger != nil {
+			a.logger.Warn(ctx, "Ignoring tool choice for a tool the agent does not have", map[string]interface{}{
+				"agent": a.name,
+				"tool":  choice.Name,
+			})
+		}
+		return nil
+	}
+	return choice
+}
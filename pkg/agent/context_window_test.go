@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/contextwindow"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+func TestWithContextWindow(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	mem := memory.NewConversationBuffer()
+	for i := 0; i < 80; i++ {
+		if err := mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: strings.Repeat("history ", 50)}); err != nil {
+			t.Fatalf("Failed to add message: %v", err)
+		}
+	}
+
+	var history []interfaces.Message
+	agent, err := NewAgent(
+		WithLLM(&mockLLM{generateFunc: func(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+			var params interfaces.GenerateOptions
+			for _, option := range options {
+				option(&params)
+			}
+			var err error
+			history, err = params.Memory.GetMessages(ctx)
+			return "ok", err
+		}}),
+		WithMemory(mem),
+		WithContextWindow(contextwindow.NewManager("gpt-4")),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	if _, err := agent.Run(ctx, "What did we talk about?"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(history) == 0 || len(history) >= 81 {
+		t.Fatalf("Expected the history to be trimmed, got %d messages", len(history))
+	}
+	if history[len(history)-1].Content != "What did we talk about?" {
+		t.Errorf("Expected the current input to be kept, got %q", history[len(history)-1].Content)
+	}
+
+	all, err := mem.GetMessages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(all) != 82 {
+		t.Errorf("Expected memory to keep the full history, got %d messages", len(all))
+	}
+}
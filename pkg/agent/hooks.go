@@ -0,0 +1,293 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// LLMCall is a request of the agent to its LLM. Middleware may change it
+// before passing it on.
+type LLMCall struct {
+	Prompt  string
+	Tools   []interfaces.Tool // Tools offered to the LLM; empty for calls without tools
+	Options []interfaces.GenerateOption
+	Stream  bool // Whether the call was made by RunStream
+}
+
+// LLMHandler performs an LLM call and returns the response
+type LLMHandler func(ctx context.Context, call *LLMCall) (string, error)
+
+// LLMMiddleware wraps the LLM calls of an agent. It can inspect or change the
+// call before invoking next, inspect or replace the response after it, or
+// return without invoking next to answer in place of the LLM.
+//
+// With RunStream, next forwards the stream of the LLM to the caller and
+// returns the full response once it has ended, so a replaced response is
+// stored in memory but cannot change the content already streamed. A response
+// returned without invoking next is streamed as a single content event.
+type LLMMiddleware func(ctx context.Context, call *LLMCall, next LLMHandler) (string, error)
+
+// ToolCall is a call of a tool requested by the LLM. Middleware may change its
+// arguments before passing it on.
+type ToolCall struct {
+	Tool      interfaces.Tool
+	Arguments string
+}
+
+// ToolHandler executes a tool call and returns its result
+type ToolHandler func(ctx context.Context, call *ToolCall) (string, error)
+
+// ToolMiddleware wraps the tool calls of an agent. It can change the arguments
+// before invoking next, replace the result after it, or return without
+// invoking next to skip the tool, for example to deny the call or to return a
+// cached result. An error is reported to the LLM as the result of the call.
+type ToolMiddleware func(ctx context.Context, call *ToolCall, next ToolHandler) (string, error)
+
+// Hooks are extension points of a local agent run. Every field is optional.
+// Hooks registered with WithHooks compose in order: the middleware of the
+// first hooks is the outermost, and callbacks are called in registration
+// order.
+type Hooks struct {
+	// WrapLLMCall wraps each call of the agent to its LLM. The tool loop of a
+	// call with tools runs inside the LLM, so it is one call.
+	WrapLLMCall LLMMiddleware
+
+	// WrapToolCall wraps each tool call, including the steps of execution plans
+	WrapToolCall ToolMiddleware
+
+	// OnMemoryWrite is called before a message is added to the agent's
+	// memory, including the tool calls stored by the LLM. It may change the
+	// message; an error cancels the write.
+	OnMemoryWrite func(ctx context.Context, message *interfaces.Message) error
+
+	// OnError is called with the error a run fails with
+	OnError func(ctx context.Context, err error)
+
+	// OnFinalResponse is called with the answer of the LLM after the output
+	// guardrails and before it is stored in memory. It may replace the
+	// answer; an error fails the run. With RunStream, the answer has already
+	// been streamed, and the replacement is only stored in memory.
+	OnFinalResponse func(ctx context.Context, response string) (string, error)
+}
+
+// WithHooks registers hooks that run at the steps of the agent. It may be
+// given several times; all hooks are kept.
+func WithHooks(hooks ...Hooks) Option {
+	return func(a *Agent) {
+		a.hooks = append(a.hooks, hooks...)
+	}
+}
+
+// callLLM performs an LLM call through the LLM middleware of the agent. The
+// call is traced if the run is.
+func (a *Agent) callLLM(ctx context.Context, call *LLMCall, handler LLMHandler) (string, error) {
+	llmHandler := handler
+	handler = func(ctx context.Context, call *LLMCall) (string, error) {
+		return traceLLMCall(ctx, call, llmHandler)
+	}
+	for i := len(a.hooks) - 1; i >= 0; i-- {
+		if middleware := a.hooks[i].WrapLLMCall; middleware != nil {
+			next := handler
+			handler = func(ctx context.Context, call *LLMCall) (string, error) {
+				return middleware(ctx, call, next)
+			}
+		}
+	}
+	return handler(ctx, call)
+}
+
+// hookTools wraps the tools so that their calls are traced, checked against
+// the budget of the run, collected for the run and go through the tool
+// middleware of the agent, followed by the checkpointing and the approval of
+// calls
+func (a *Agent) hookTools(tools []interfaces.Tool) []interfaces.Tool {
+	middleware := []ToolMiddleware{traceToolCall, enforceBudget, collectToolResult}
+	for _, hooks := range a.hooks {
+		if hooks.WrapToolCall != nil {
+			middleware = append(middleware, hooks.WrapToolCall)
+		}
+	}
+	if a.checkpointStore != nil {
+		middleware = append(middleware, a.checkpointToolCall)
+	}
+	if approval := a.approvalMiddleware(tools); approval != nil {
+		middleware = append(middleware, approval)
+	}
+	if len(tools) == 0 {
+		return tools
+	}
+
+	hooked := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		hooked[i] = &hookedTool{Tool: tool, agent: a, middleware: middleware}
+	}
+	return hooked
+}
+
+// toolResultsKey is the context key for the results of the tool calls of a run
+type toolResultsKey struct{}
+
+// toolResults keeps the results of the tool calls of a run, for an LLM call
+// that has to continue the work of one that was stopped
+type toolResults struct {
+	mu      sync.Mutex
+	results []string
+}
+
+// withToolResults adds a collection of the tool results of the run to the
+// context
+func withToolResults(ctx context.Context) (context.Context, *toolResults) {
+	results := &toolResults{}
+	return context.WithValue(ctx, toolResultsKey{}, results), results
+}
+
+// String lists the results of the tool calls for a prompt
+func (r *toolResults) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.results) == 0 {
+		return "No tools were called."
+	}
+	return strings.Join(r.results, "\n")
+}
+
+// collectToolResult is the tool middleware keeping the results of the tool
+// calls of the run
+func collectToolResult(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+	results, ok := ctx.Value(toolResultsKey{}).(*toolResults)
+	if !ok {
+		return next(ctx, call)
+	}
+
+	result, err := next(ctx, call)
+	content := result
+	if err != nil {
+		content = "Error: " + err.Error()
+	}
+	results.mu.Lock()
+	results.results = append(results.results, fmt.Sprintf("- %s(%s): %s", call.Tool.Name(), call.Arguments, content))
+	results.mu.Unlock()
+	return result, err
+}
+
+// hookMemory wraps the memory of the agent so that writes go through the
+// OnMemoryWrite hooks, if there are any
+func (a *Agent) hookMemory() {
+	if a.memory == nil {
+		return
+	}
+	var callbacks []func(context.Context, *interfaces.Message) error
+	for _, hooks := range a.hooks {
+		if hooks.OnMemoryWrite != nil {
+			callbacks = append(callbacks, hooks.OnMemoryWrite)
+		}
+	}
+	if len(callbacks) > 0 {
+		a.memory = &hookedMemory{Memory: a.memory, callbacks: callbacks}
+	}
+}
+
+// finalResponse passes the answer of the LLM through the OnFinalResponse hooks
+func (a *Agent) finalResponse(ctx context.Context, response string) (string, error) {
+	for _, hooks := range a.hooks {
+		if hooks.OnFinalResponse == nil {
+			continue
+		}
+		var err error
+		if response, err = hooks.OnFinalResponse(ctx, response); err != nil {
+			return "", err
+		}
+	}
+	return response, nil
+}
+
+// notifyError calls the OnError hooks
+func (a *Agent) notifyError(ctx context.Context, err error) {
+	for _, hooks := range a.hooks {
+		if hooks.OnError != nil {
+			hooks.OnError(ctx, err)
+		}
+	}
+}
+
+// hookedTool is a tool whose calls go through tool middleware
+type hookedTool struct {
+	interfaces.Tool
+	agent      *Agent
+	middleware []ToolMiddleware
+}
+
+// Execute runs the tool through the middleware
+func (t *hookedTool) Execute(ctx context.Context, args string) (string, error) {
+	return t.call(ctx, args, func(ctx context.Context, call *ToolCall) (string, error) {
+		return call.Tool.Execute(ctx, call.Arguments)
+	})
+}
+
+// Run runs the tool with the given input through the middleware
+func (t *hookedTool) Run(ctx context.Context, input string) (string, error) {
+	return t.call(ctx, input, func(ctx context.Context, call *ToolCall) (string, error) {
+		return call.Tool.Run(ctx, call.Arguments)
+	})
+}
+
+// call passes a call of the tool through the middleware to handler
+func (t *hookedTool) call(ctx context.Context, args string, handler ToolHandler) (string, error) {
+	for i := len(t.middleware) - 1; i >= 0; i-- {
+		middleware, next := t.middleware[i], handler
+		handler = func(ctx context.Context, call *ToolCall) (string, error) {
+			return middleware(ctx, call, next)
+		}
+	}
+	return handler(ctx, &ToolCall{Tool: t.Tool, Arguments: args})
+}
+
+// DisplayName returns the display name of the wrapped tool, if it has one
+func (t *hookedTool) DisplayName() string {
+	if tool, ok := t.Tool.(interfaces.ToolWithDisplayName); ok {
+		return tool.DisplayName()
+	}
+	return ""
+}
+
+// Internal reports whether the wrapped tool is internal
+func (t *hookedTool) Internal() bool {
+	if tool, ok := t.Tool.(interfaces.InternalTool); ok {
+		return tool.Internal()
+	}
+	return false
+}
+
+// LookupTool returns the tool with the given name provided by the wrapped
+// tool, if it provides tools, wrapped with the hooks of the agent
+func (t *hookedTool) LookupTool(name string) interfaces.Tool {
+	lookup, ok := t.Tool.(interfaces.ToolLookup)
+	if !ok {
+		return nil
+	}
+	tool := lookup.LookupTool(name)
+	if tool == nil {
+		return nil
+	}
+	return t.agent.hookTools([]interfaces.Tool{tool})[0]
+}
+
+// hookedMemory is a memory whose writes go through OnMemoryWrite hooks
+type hookedMemory struct {
+	interfaces.Memory
+	callbacks []func(context.Context, *interfaces.Message) error
+}
+
+// AddMessage passes the message through the hooks and stores it
+func (m *hookedMemory) AddMessage(ctx context.Context, message interfaces.Message) error {
+	for _, callback := range m.callbacks {
+		if err := callback(ctx, &message); err != nil {
+			return err
+		}
+	}
+	return m.Memory.AddMessage(ctx, message)
+}
@@ -0,0 +1,367 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+// recordingHooks returns hooks that record the steps they see under a name
+func recordingHooks(name string, steps *[]string) Hooks {
+	return Hooks{
+		WrapLLMCall: func(ctx context.Context, call *LLMCall, next LLMHandler) (string, error) {
+			*steps = append(*steps, name+" before llm")
+			response, err := next(ctx, call)
+			*steps = append(*steps, name+" after llm")
+			return response, err
+		},
+		WrapToolCall: func(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+			*steps = append(*steps, name+" before "+call.Tool.Name())
+			result, err := next(ctx, call)
+			*steps = append(*steps, name+" after "+call.Tool.Name())
+			return result, err
+		},
+		OnFinalResponse: func(ctx context.Context, response string) (string, error) {
+			*steps = append(*steps, name+" final")
+			return response, nil
+		},
+	}
+}
+
+func hookTestContext() context.Context {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	return memory.WithConversationID(ctx, "test-conversation")
+}
+
+func TestHooksOrder(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("lookup", `{"input":"a"}`), mock.Text("done")))
+	tool := &mockTool{name: "lookup"}
+
+	var steps []string
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tool),
+		WithRequirePlanApproval(false),
+		WithHooks(recordingHooks("first", &steps)),
+		WithHooks(recordingHooks("second", &steps)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	if _, err := agent.Run(hookTestContext(), "Look it up"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	expected := []string{
+		"first before llm", "second before llm",
+		"first before lookup", "second before lookup", "second after lookup", "first after lookup",
+		"second after llm", "first after llm",
+		"first final", "second final",
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Expected steps %v, got %v", expected, steps)
+	}
+}
+
+func TestToolMiddleware(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.ToolCalls(
+			interfaces.ToolCall{ID: "1", Name: "lookup", Arguments: `{"input":"a"}`},
+			interfaces.ToolCall{ID: "2", Name: "delete", Arguments: `{"input":"b"}`},
+		),
+		mock.Text("done"),
+	))
+	var deleted bool
+	lookup := &mockTool{name: "lookup"}
+	deleteTool := &mockTool{name: "delete", runFunc: func(ctx context.Context, input string) (string, error) {
+		deleted = true
+		return "deleted", nil
+	}}
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(lookup, deleteTool),
+		WithRequirePlanApproval(false),
+		WithHooks(Hooks{
+			WrapToolCall: func(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+				if call.Tool.Name() == "delete" {
+					return "", errors.New("delete is not allowed")
+				}
+				call.Arguments = `{"input":"changed"}`
+				result, err := next(ctx, call)
+				return strings.ToUpper(result), err
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	if _, err := agent.Run(hookTestContext(), "Look it up and delete it"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := model.LastCall().ToolResults
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 tool results, got %d", len(results))
+	}
+	if results[0].Content != `TOOL LOOKUP EXECUTED WITH: {"INPUT":"CHANGED"}` {
+		t.Errorf("Expected the changed arguments and replaced result, got %q", results[0].Content)
+	}
+	if deleted || !strings.Contains(results[1].Content, "delete is not allowed") {
+		t.Errorf("Expected the delete call to be denied, got %q (deleted: %v)", results[1].Content, deleted)
+	}
+}
+
+func TestToolMiddlewareRun(t *testing.T) {
+	var calls []string
+	agent, err := NewAgent(
+		WithLLM(mock.New()),
+		WithTools(&mockTool{name: "lookup"}),
+		WithRequirePlanApproval(false),
+		WithHooks(Hooks{
+			WrapToolCall: func(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+				calls = append(calls, call.Tool.Name())
+				call.Arguments = "changed"
+				return next(ctx, call)
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	tools := agent.hookTools(agent.tools)
+	result, err := tools[0].Run(context.Background(), "original")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result != "tool lookup executed with: changed" {
+		t.Errorf("Expected Run to go through the middleware, got %q", result)
+	}
+	if len(calls) != 1 || calls[0] != "lookup" {
+		t.Errorf("Expected the middleware to see one lookup call, got %v", calls)
+	}
+}
+
+func TestLLMMiddlewareShortCircuit(t *testing.T) {
+	model := mock.New()
+	mem := memory.NewConversationBuffer()
+
+	var written []interfaces.Message
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithMemory(mem),
+		WithHooks(Hooks{
+			WrapLLMCall: func(ctx context.Context, call *LLMCall, next LLMHandler) (string, error) {
+				return "cached answer", nil
+			},
+			OnMemoryWrite: func(ctx context.Context, message *interfaces.Message) error {
+				message.Content = strings.ReplaceAll(message.Content, "secret", "[redacted]")
+				written = append(written, *message)
+				return nil
+			},
+			OnFinalResponse: func(ctx context.Context, response string) (string, error) {
+				return response + " (from cache)", nil
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	response, err := agent.Run(ctx, "What is the secret?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != "cached answer (from cache)" {
+		t.Errorf("Expected the cached answer, got %q", response)
+	}
+	if len(model.Calls()) != 0 {
+		t.Errorf("Expected the LLM not to be called, got %d calls", len(model.Calls()))
+	}
+
+	messages, err := mem.GetMessages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(written) != 2 || len(messages) != 2 {
+		t.Fatalf("Expected 2 messages to be written, got %d hooked and %d stored", len(written), len(messages))
+	}
+	if messages[0].Content != "What is the [redacted]?" || messages[1].Content != "cached answer (from cache)" {
+		t.Errorf("Unexpected messages in memory: %+v", messages)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Error(errors.New("provider down"))))
+
+	var reported []error
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithHooks(Hooks{OnError: func(ctx context.Context, err error) {
+			reported = append(reported, err)
+		}}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	_, err = agent.Run(hookTestContext(), "Hello")
+	if err == nil {
+		t.Fatal("Expected Run to fail")
+	}
+	if len(reported) != 1 || reported[0] != err {
+		t.Errorf("Expected the run error to be reported once, got %v", reported)
+	}
+
+	events, err := agent.RunStream(hookTestContext(), "Hello")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	for range events {
+	}
+	if len(reported) != 2 {
+		t.Errorf("Expected the stream error to be reported once, got %v", reported)
+	}
+}
+
+func TestRunStreamRunsEachToolOnce(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.ToolCalls(
+			interfaces.ToolCall{ID: "call-1", Name: "lookup", Arguments: `{"input":"a"}`},
+			interfaces.ToolCall{ID: "call-2", Name: "lookup", Arguments: `{"input":"b"}`},
+		),
+		mock.ToolCall("search", `{"input":"c"}`),
+		mock.Text("done"),
+	))
+	executions := map[string]int{}
+	tools := make([]interfaces.Tool, 0, 2)
+	for _, name := range []string{"lookup", "search"} {
+		name := name
+		tools = append(tools, &mockTool{name: name, runFunc: func(ctx context.Context, input string) (string, error) {
+			executions[name]++
+			return "found", nil
+		}})
+	}
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tools...),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	events, err := agent.RunStream(hookTestContext(), "Look it up")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var executing, toolResults int
+	for event := range events {
+		switch event.Type {
+		case interfaces.AgentEventToolCall:
+			if event.ToolCall.Status == "executing" {
+				executing++
+			}
+		case interfaces.AgentEventToolResult:
+			toolResults++
+		case interfaces.AgentEventError:
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+
+	expected := map[string]int{"lookup": 2, "search": 1}
+	if !reflect.DeepEqual(executions, expected) {
+		t.Errorf("Expected each tool call to run once, got %v", executions)
+	}
+	if executing != 3 || toolResults != 3 {
+		t.Errorf("Expected 3 executing and 3 tool result events, got %d and %d", executing, toolResults)
+	}
+}
+
+func TestHooksRunStream(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.ToolCall("lookup", `{"input":"a"}`), mock.Text("done")))
+	var executions int
+	tool := &mockTool{name: "lookup", runFunc: func(ctx context.Context, input string) (string, error) {
+		executions++
+		return "found", nil
+	}}
+	mem := memory.NewConversationBuffer()
+
+	var steps []string
+	var streamCall bool
+	hooks := recordingHooks("hooks", &steps)
+	wrapLLMCall := hooks.WrapLLMCall
+	hooks.WrapLLMCall = func(ctx context.Context, call *LLMCall, next LLMHandler) (string, error) {
+		streamCall = call.Stream
+		return wrapLLMCall(ctx, call, next)
+	}
+	hooks.OnFinalResponse = func(ctx context.Context, response string) (string, error) {
+		return response + "!", nil
+	}
+
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tool),
+		WithMemory(mem),
+		WithRequirePlanApproval(false),
+		WithHooks(hooks),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	events, err := agent.RunStream(ctx, "Look it up")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var content strings.Builder
+	var toolResult string
+	for event := range events {
+		switch event.Type {
+		case interfaces.AgentEventContent:
+			content.WriteString(event.Content)
+		case interfaces.AgentEventToolResult:
+			toolResult = event.ToolCall.Result
+		case interfaces.AgentEventError:
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+
+	if !streamCall {
+		t.Error("Expected the LLM call to be marked as streaming")
+	}
+	if executions != 1 {
+		t.Errorf("Expected the tool to run once, ran %d times", executions)
+	}
+	expected := []string{"hooks before llm", "hooks before lookup", "hooks after lookup", "hooks after llm"}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Expected steps %v, got %v", expected, steps)
+	}
+	if toolResult != "found" {
+		t.Errorf("Expected the tool result event to hold the result, got %q", toolResult)
+	}
+	if !strings.Contains(content.String(), "done") {
+		t.Errorf("Expected the streamed answer, got %q", content.String())
+	}
+
+	messages, err := mem.GetMessages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if last := messages[len(messages)-1]; last.Content != "done!" {
+		t.Errorf("Expected the final response hook to change the stored answer, got %q", last.Content)
+	}
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// namedLLM is a mock LLM that reports its model name
+type namedLLM struct {
+	mockLLM
+	model string
+}
+
+func (m *namedLLM) GetModel() string {
+	return m.model
+}
+
+func TestModelValidation(t *testing.T) {
+	tool := &mockTool{name: "lookup", description: "Looks things up"}
+	format := interfaces.ResponseFormat{Type: interfaces.ResponseFormatJSON, Name: "answer"}
+
+	tests := []struct {
+		name    string
+		model   string
+		options []Option
+		wantErr string
+	}{
+		{"supported", "gpt-4o-mini", []Option{WithTools(tool), WithResponseFormat(format)}, ""},
+		{"unknown model", "my-model", []Option{WithTools(tool), WithResponseFormat(format)}, ""},
+		{"no structured output", "gpt-3.5-turbo", []Option{WithResponseFormat(format)}, "structured output"},
+		{"no reasoning", "gpt-4o", []Option{WithLLMConfig(interfaces.LLMConfig{EnableReasoning: true})}, "reasoning"},
+		{"no tools", "o1-mini", []Option{WithTools(tool)}, "tool calling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]Option{WithLLM(&namedLLM{model: tt.model})}, tt.options...)
+			_, err := NewAgent(options...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, llm.ErrUnsupportedFeature) {
+				t.Fatalf("Expected ErrUnsupportedFeature, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error to mention %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+func TestRunWithParts(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	var prompt string
+	var params interfaces.GenerateOptions
+	mem := memory.NewConversationBuffer()
+	agent, err := NewAgent(
+		WithLLM(&mockLLM{generateFunc: func(ctx context.Context, p string, options ...interfaces.GenerateOption) (string, error) {
+			prompt = p
+			for _, option := range options {
+				option(&params)
+			}
+			return "A scanned invoice", nil
+		}}),
+		WithMemory(mem),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	image := interfaces.ImagePart([]byte("png"), "image/png")
+	result, err := agent.RunWithParts(ctx, interfaces.TextPart("What is this?"), image)
+	if err != nil {
+		t.Fatalf("RunWithParts failed: %v", err)
+	}
+	if result != "A scanned invoice" {
+		t.Errorf("Unexpected result: %q", result)
+	}
+
+	if prompt != "What is this?" {
+		t.Errorf("Expected the text parts as prompt, got %q", prompt)
+	}
+	if len(params.ContentParts) != 1 || params.ContentParts[0].Type != interfaces.ContentPartImage {
+		t.Errorf("Expected the image to be sent with the prompt, got %+v", params.ContentParts)
+	}
+
+	messages, err := mem.GetMessages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages in memory, got %d", len(messages))
+	}
+	if messages[0].Content != "What is this?" || len(messages[0].Parts) != 2 {
+		t.Errorf("Expected the user message to keep its parts, got %+v", messages[0])
+	}
+}
+
+func TestRunWithPartsCustomFunction(t *testing.T) {
+	agent, err := NewAgent(
+		WithLLM(&mockLLM{}),
+		WithCustomRunFunction(func(ctx context.Context, input string, agent *Agent) (string, error) {
+			return input, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	if _, err := agent.RunWithParts(context.Background(), interfaces.TextPart("hi")); err == nil {
+		t.Error("Expected an error for a custom run function")
+	}
+}
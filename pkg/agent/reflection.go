@@ -0,0 +1,227 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
+)
+
+// DefaultReflectionRounds is the number of rounds of critique and revision
+// when the reflection config does not set one
+const DefaultReflectionRounds = 2
+
+// reflectionApproved is the reply of the critic to an answer without problems
+const reflectionApproved = "APPROVED"
+
+// ReflectionConfig configures the critique and revision of the answers of an
+// agent
+type ReflectionConfig struct {
+	Critic    interfaces.LLM // LLM that critiques the answers; defaults to the LLM of the agent
+	MaxRounds int            // Rounds of critique and revision; defaults to DefaultReflectionRounds
+}
+
+// WithReflection has a critic check the answer of each run against the system
+// prompt, the response format and the input before it is returned. In each
+// round, the critic reviews the answer and, if it finds problems, the agent
+// revises the answer. RunStream reports the critiques as thinking events.
+func WithReflection(config ReflectionConfig) Option {
+	return func(a *Agent) {
+		if config.MaxRounds <= 0 {
+			config.MaxRounds = DefaultReflectionRounds
+		}
+		a.reflection = &config
+	}
+}
+
+// critique is the review of an answer by the critic
+type critique struct {
+	approved bool
+	problems string
+}
+
+// reflect critiques and revises the answer to the input. notify, if not nil,
+// is called with each step of the reflection. The answer is kept if the
+// critique or the revision fails, unless the run was cancelled.
+func (a *Agent) reflect(ctx context.Context, input, answer string, results *toolResults, notify func(string)) (string, error) {
+	var span interfaces.Span
+	if a.tracer != nil {
+		ctx, span = a.tracer.StartSpan(ctx, "agent.Reflect")
+		defer span.End()
+	}
+	step := func(round int, event, message string) {
+		if span != nil {
+			span.SetAttribute("reflection.rounds", round)
+			span.AddEvent("reflection."+event, map[string]interface{}{
+				"round":   round,
+				"message": message,
+			})
+		}
+		if notify != nil {
+			notify(message)
+		}
+	}
+
+	for round := 1; round <= a.reflection.MaxRounds; round++ {
+		review, err := a.critique(ctx, input, answer, results)
+		if err != nil {
+			return a.stopReflection(ctx, span, answer, "critique", err)
+		}
+		if review.approved {
+			step(round, "approved", fmt.Sprintf("Critique %d: the answer was approved", round))
+			if span != nil {
+				span.SetAttribute("reflection.approved", true)
+			}
+			return answer, nil
+		}
+		step(round, "critique", fmt.Sprintf("Critique %d: the answer has problems\n%s", round, review.problems))
+
+		revised, err := a.revise(ctx, input, answer, review.problems, results)
+		if err != nil {
+			return a.stopReflection(ctx, span, answer, "revision", err)
+		}
+		answer = revised
+		step(round, "revision", fmt.Sprintf("Revision %d: revised the answer", round))
+	}
+	if span != nil {
+		span.SetAttribute("reflection.approved", false)
+	}
+	return answer, nil
+}
+
+// stopReflection ends a reflection that failed with the answer so far
+func (a *Agent) stopReflection(ctx context.Context, span interfaces.Span, answer, step string, err error) (string, error) {
+	if span != nil {
+		span.RecordError(err)
+	}
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("reflection %s failed: %w", step, err)
+	}
+	if a.logger != nil {
+		a.logger.Warn(ctx, "Reflection failed, keeping the answer", map[string]interface{}{
+			"agent": a.name,
+			"step":  step,
+			"error": err.Error(),
+		})
+	}
+	return answer, nil
+}
+
+// critique has the critic review the answer. An answer that does not match
+// the JSON schema of the response format is never approved.
+func (a *Agent) critique(ctx context.Context, input, answer string, results *toolResults) (critique, error) {
+	critic := a.reflection.Critic
+	if critic == nil {
+		critic = a.llm
+	}
+
+	format := a.responseFormatFor(ctx)
+	formatDescription := "Any format."
+	var schemaErr error
+	if format != nil && format.Schema != nil {
+		schema, err := json.Marshal(format.Schema)
+		if err != nil {
+			return critique{}, fmt.Errorf("failed to encode the response schema: %w", err)
+		}
+		formatDescription = fmt.Sprintf("Only a JSON object matching the schema %s:\n%s", format.Name, schema)
+		schemaErr = structuredoutput.Validate(format.Schema, []byte(structuredoutput.ExtractJSON(answer)))
+	}
+	systemPrompt := a.systemPrompt
+	if systemPrompt == "" {
+		systemPrompt = "None."
+	}
+
+	prompt := fmt.Sprintf(`Review the answer of an assistant to a request. Check that the answer:
+- follows the instructions of the system prompt,
+- answers the request fully and correctly, consistently with the results of the tool calls,
+- has the required response format.
+
+System prompt:
+%s
+
+Required response format:
+%s
+
+Request:
+%s
+
+Results of the tool calls:
+%s
+
+Answer:
+%s
+
+If the answer has no problems, reply with %s and nothing else. Otherwise, list its problems and how to fix them, one per line.`,
+		systemPrompt, formatDescription, input, results, answer, reflectionApproved)
+
+	budgetCtx, stopBudget := startBudgetCall(ctx)
+	defer stopBudget()
+	call := &LLMCall{Prompt: prompt, Options: []interfaces.GenerateOption{
+		openai.WithSystemMessage("You review the answers of an assistant for problems. You do not answer the requests yourself."),
+	}}
+	reply, err := a.callLLM(budgetCtx, call, func(ctx context.Context, call *LLMCall) (string, error) {
+		return critic.Generate(ctx, call.Prompt, call.Options...)
+	})
+	if err != nil {
+		return critique{}, err
+	}
+
+	reply = strings.TrimSpace(reply)
+	review := critique{approved: strings.HasPrefix(strings.ToUpper(reply), reflectionApproved)}
+	if !review.approved {
+		review.problems = reply
+	}
+	if schemaErr != nil {
+		review.approved = false
+		review.problems = strings.TrimSpace(fmt.Sprintf("- The answer does not match the JSON schema: %v\n%s", schemaErr, review.problems))
+	}
+	return review, nil
+}
+
+// revise has the LLM of the agent rewrite the answer to fix the problems the
+// critic found
+func (a *Agent) revise(ctx context.Context, input, answer, problems string, results *toolResults) (string, error) {
+	prompt := fmt.Sprintf(`%s
+
+Your answer to this request was reviewed, and problems were found in it.
+
+Your answer:
+%s
+
+Problems found:
+%s
+
+Results of the tool calls made:
+%s
+
+Write a revised answer to the request that fixes these problems. Reply with the revised answer only.`, input, answer, problems, results)
+
+	budgetCtx, stopBudget := startBudgetCall(ctx)
+	defer stopBudget()
+	call := &LLMCall{Prompt: prompt, Options: a.answerOptions(ctx)}
+	return a.callLLM(budgetCtx, call, func(ctx context.Context, call *LLMCall) (string, error) {
+		return a.llm.Generate(ctx, call.Prompt, call.Options...)
+	})
+}
+
+// answerOptions returns the options of an LLM call that answers the input
+// without tools, outside of the tool loop
+func (a *Agent) answerOptions(ctx context.Context) []interfaces.GenerateOption {
+	options := []interfaces.GenerateOption{}
+	if a.systemPrompt != "" {
+		options = append(options, openai.WithSystemMessage(a.systemPrompt))
+	}
+	if responseFormat := a.responseFormatFor(ctx); responseFormat != nil {
+		options = append(options, openai.WithResponseFormat(*responseFormat))
+	}
+	if a.llmConfig != nil {
+		options = append(options, func(options *interfaces.GenerateOptions) {
+			options.LLMConfig = a.llmConfig
+		})
+	}
+	return options
+}
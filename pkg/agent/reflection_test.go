@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+)
+
+// recordingTracer records the spans started and the events added to them
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) StartSpan(ctx context.Context, name string) (context.Context, interfaces.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordingSpan{name: name, attributes: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (t *recordingTracer) StartTraceSession(ctx context.Context, contextID string) (context.Context, interfaces.Span) {
+	return t.StartSpan(ctx, contextID)
+}
+
+func (t *recordingTracer) span(name string) *recordingSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, span := range t.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+type recordingSpan struct {
+	name       string
+	events     []string
+	attributes map[string]interface{}
+}
+
+func (s *recordingSpan) End()                  {}
+func (s *recordingSpan) RecordError(err error) {}
+func (s *recordingSpan) AddEvent(name string, attributes map[string]interface{}) {
+	s.events = append(s.events, name)
+}
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func TestReflectionRevisesAnswer(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.Text("It is 20 degrees"),
+		mock.Text("- The answer does not say where"),
+		mock.Text("It is 20 degrees in Paris"),
+		mock.Text("APPROVED"),
+	))
+	tracer := &recordingTracer{}
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithSystemPrompt("Always name the city."),
+		WithTracer(tracer),
+		WithReflection(ReflectionConfig{MaxRounds: 3}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	response, err := agent.Run(context.Background(), "How warm is it in Paris?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != "It is 20 degrees in Paris" {
+		t.Errorf("Expected the revised answer, got %q", response)
+	}
+
+	calls := model.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("Expected a draft, two critiques and a revision, got %d calls", len(calls))
+	}
+	critique := calls[1].Prompt
+	if !strings.Contains(critique, "Always name the city.") || !strings.Contains(critique, "How warm is it in Paris?") ||
+		!strings.Contains(critique, "It is 20 degrees") {
+		t.Errorf("Expected the critique to check the draft against the system prompt and the input, got %q", critique)
+	}
+	revision := calls[2]
+	if !strings.Contains(revision.Prompt, "The answer does not say where") || revision.Options.SystemMessage != "Always name the city." {
+		t.Errorf("Expected the revision to fix the problems found, got %q", revision.Prompt)
+	}
+
+	span := tracer.span("agent.Reflect")
+	if span == nil {
+		t.Fatal("Expected a span for the reflection")
+	}
+	if strings.Join(span.events, ",") != "reflection.critique,reflection.revision,reflection.approved" {
+		t.Errorf("Expected the critique trail in the span, got %v", span.events)
+	}
+	if span.attributes["reflection.rounds"] != 2 || span.attributes["reflection.approved"] != true {
+		t.Errorf("Expected 2 rounds ending in approval, got %v", span.attributes)
+	}
+}
+
+func TestReflectionCritic(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Text("Paris"), mock.Text("Paris, France")))
+	critic := mock.New(mock.WithTurns(mock.Text("Name the country as well"), mock.Text("approved")))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithReflection(ReflectionConfig{Critic: critic}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	response, err := agent.Run(context.Background(), "Where is the Louvre?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != "Paris, France" {
+		t.Errorf("Expected the revised answer, got %q", response)
+	}
+	if len(model.Calls()) != 2 || len(critic.Calls()) != 2 {
+		t.Errorf("Expected the critic to review both answers, got %d agent and %d critic calls", len(model.Calls()), len(critic.Calls()))
+	}
+}
+
+func TestReflectionMaxRounds(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.Text("draft"),
+		mock.Text("Too short"),
+		mock.Text("revised"),
+	))
+	agent, err := NewAgent(WithLLM(model), WithReflection(ReflectionConfig{MaxRounds: 1}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	response, err := agent.Run(context.Background(), "Write a story")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != "revised" || len(model.Calls()) != 3 {
+		t.Errorf("Expected one round of critique and revision, got %q after %d calls", response, len(model.Calls()))
+	}
+}
+
+func TestReflectionStructuredOutput(t *testing.T) {
+	format := interfaces.ResponseFormat{
+		Type: interfaces.ResponseFormatJSON,
+		Name: "Location",
+		Schema: interfaces.JSONSchema{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+			"required":   []string{"city"},
+		},
+	}
+	model := mock.New(mock.WithTurns(
+		mock.Text(`{"town": "Paris"}`),
+		mock.Text("APPROVED"),
+		mock.Text(`{"city": "Paris"}`),
+		mock.Text("APPROVED"),
+	))
+	agent, err := NewAgent(WithLLM(model), WithResponseFormat(format), WithReflection(ReflectionConfig{}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	response, err := agent.Run(context.Background(), "Where is the Louvre?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if response != `{"city": "Paris"}` {
+		t.Errorf("Expected the answer to be revised to match the schema, got %q", response)
+	}
+
+	calls := model.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("Expected 4 calls, got %d", len(calls))
+	}
+	if !strings.Contains(calls[1].Prompt, `"required":["city"]`) {
+		t.Errorf("Expected the critic to be given the schema, got %q", calls[1].Prompt)
+	}
+	revision := calls[2]
+	if !strings.Contains(revision.Prompt, "does not match the JSON schema") || revision.Options.ResponseFormat == nil {
+		t.Errorf("Expected the revision to fix the schema with the response format, got %q", revision.Prompt)
+	}
+}
+
+func TestReflectionRunStream(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.Text("draft"),
+		mock.Text("Too short"),
+		mock.Text("revised"),
+		mock.Text("APPROVED"),
+	))
+	agent, err := NewAgent(WithLLM(model), WithReflection(ReflectionConfig{}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	events, err := agent.RunStream(context.Background(), "Write a story")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var content strings.Builder
+	var thinking []string
+	for event := range events {
+		switch event.Type {
+		case interfaces.AgentEventContent:
+			content.WriteString(event.Content)
+		case interfaces.AgentEventThinking:
+			thinking = append(thinking, event.ThinkingStep)
+		case interfaces.AgentEventError:
+			t.Fatalf("Unexpected error event: %v", event.Error)
+		}
+	}
+
+	if content.String() != "revised" {
+		t.Errorf("Expected only the revised answer as content, got %q", content.String())
+	}
+	if len(thinking) != 3 || !strings.Contains(thinking[0], "Too short") ||
+		!strings.HasPrefix(thinking[1], "Revision 1") || !strings.Contains(thinking[2], "approved") {
+		t.Errorf("Expected the critique trail as thinking events, got %q", thinking)
+	}
+}
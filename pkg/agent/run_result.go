@@ -0,0 +1,275 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/grpc/pb"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+// RunStepType is the kind of a step of a run
+type RunStepType string
+
+const (
+	// RunStepLLM is a turn of the LLM
+	RunStepLLM RunStepType = "llm"
+	// RunStepTool is a tool call, requested by the LLM or made by an execution plan
+	RunStepTool RunStepType = "tool"
+)
+
+// RunStep is a step of a run
+type RunStep struct {
+	Type RunStepType `json:"type"`
+	// Iteration is the 1-based LLM turn of the step, or 0 for the steps of
+	// an execution plan
+	Iteration  int           `json:"iteration"`
+	ToolName   string        `json:"tool_name,omitempty"`
+	Arguments  string        `json:"arguments,omitempty"` // Arguments the tool was called with
+	Result     string        `json:"result,omitempty"`    // Output of the tool
+	Error      string        `json:"error,omitempty"`
+	StartTime  time.Time     `json:"start_time"`
+	DurationMs int64         `json:"duration_ms"` // Duration in milliseconds for JSON
+	Duration   time.Duration `json:"-"`
+}
+
+// RunResult is the response of a run with a trace of how it was produced
+type RunResult struct {
+	Output string    `json:"output"`
+	Steps  []RunStep `json:"steps"` // LLM turns and tool calls, in the order they started
+	// Iterations is the number of LLM turns of the run
+	Iterations int `json:"iterations"`
+	// Truncated reports that the tool loop reached the maximum number of
+	// iterations, so that the LLM had to answer without finishing its work
+	Truncated bool `json:"truncated"`
+	// BudgetExceeded is the reason the run stopped early because it exceeded
+	// its budget, in which case the output is answered from the results
+	// gathered until then
+	BudgetExceeded string           `json:"budget_exceeded,omitempty"`
+	Usage          llm.UsageSummary `json:"usage"`
+	StartTime      time.Time        `json:"start_time"`
+	DurationMs     int64            `json:"duration_ms"` // Duration in milliseconds for JSON
+	Duration       time.Duration    `json:"-"`
+}
+
+// RunDetailed executes the agent and returns its response with a trace of
+// the run: the LLM turns and tool calls it took, their arguments, results and
+// latency, the token usage, and whether the tool loop was cut short by the
+// maximum number of iterations. The result of a failed run is returned along
+// with the error and traces the steps taken until it failed.
+func (a *Agent) RunDetailed(ctx context.Context, input string) (*RunResult, error) {
+	// If this is a remote agent, the remote agent traces the run
+	if a.isRemote && a.customRunFunc == nil {
+		return a.runRemoteDetailed(ctx, input)
+	}
+
+	ctx = llm.WithUsageCollection(ctx)
+	ctx = llm.WithToolTurnRecording(ctx)
+	trace := &runTrace{}
+	ctx = context.WithValue(ctx, runTraceKey{}, trace)
+
+	start := time.Now()
+	var output string
+	var err error
+	if a.customRunFunc != nil {
+		output, err = a.customRunFunc(ctx, input, a)
+	} else {
+		output, err = a.runLocal(ctx, input, nil)
+	}
+	return trace.result(output, a.summarizeUsage(ctx), start, time.Since(start)), err
+}
+
+// runRemoteDetailed executes a remote agent via gRPC and converts its trace
+func (a *Agent) runRemoteDetailed(ctx context.Context, input string) (*RunResult, error) {
+	if a.remoteClient == nil {
+		return &RunResult{}, fmt.Errorf("remote client not initialized")
+	}
+
+	// If orgID is set on the agent, add it to the context
+	if a.orgID != "" {
+		ctx = multitenancy.WithOrgID(ctx, a.orgID)
+	}
+
+	response, err := a.remoteClient.RunDetailed(ctx, input)
+	if err != nil {
+		return &RunResult{}, err
+	}
+	result := convertPbRunTrace(response.Trace)
+	result.Output = response.Output
+	return result, nil
+}
+
+// runTraceKey is the context key for the trace of a run
+type runTraceKey struct{}
+
+// runTrace collects the steps of a run
+type runTrace struct {
+	mu         sync.Mutex
+	steps      []RunStep
+	iterations int
+	truncated  bool
+	// budgetExceeded is the reason the run exceeded its budget, if it did
+	budgetExceeded string
+	// turnOffset maps the tool turns of the current LLM call to the
+	// iterations of the run
+	turnOffset int
+}
+
+// runTraceFromContext returns the trace of the run, if it is traced
+func runTraceFromContext(ctx context.Context) *runTrace {
+	trace, _ := ctx.Value(runTraceKey{}).(*runTrace)
+	return trace
+}
+
+// traceLLMCall performs an LLM call and records its turns in the trace of the
+// run. The tool loop runs inside the LLM, so its turns are delimited by the
+// tool turns recorded in the context.
+func traceLLMCall(ctx context.Context, call *LLMCall, next LLMHandler) (string, error) {
+	trace := runTraceFromContext(ctx)
+	if trace == nil {
+		return next(ctx, call)
+	}
+
+	firstTurn := len(llm.GetToolTurnsFromContext(ctx))
+	trace.mu.Lock()
+	iteration := trace.iterations
+	trace.turnOffset = iteration - firstTurn
+	trace.mu.Unlock()
+
+	start := time.Now()
+	response, err := next(ctx, call)
+	end := time.Now()
+	turns := llm.GetToolTurnsFromContext(ctx)[firstTurn:]
+
+	var options interfaces.GenerateOptions
+	for _, option := range call.Options {
+		option(&options)
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	for _, turn := range turns {
+		iteration++
+		trace.steps = append(trace.steps, newRunStep(RunStep{Type: RunStepLLM, Iteration: iteration, StartTime: start}, turn.StartTime))
+		start = turn.EndTime
+	}
+	iteration++
+	step := RunStep{Type: RunStepLLM, Iteration: iteration, StartTime: start}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	trace.steps = append(trace.steps, newRunStep(step, end))
+	trace.iterations = iteration
+	if len(call.Tools) > 0 && options.MaxIterations > 0 && len(turns) >= options.MaxIterations {
+		trace.truncated = true
+	}
+	return response, err
+}
+
+// traceToolCall is the tool middleware recording tool calls in the trace of
+// the run
+func traceToolCall(ctx context.Context, call *ToolCall, next ToolHandler) (string, error) {
+	trace := runTraceFromContext(ctx)
+	if trace == nil {
+		return next(ctx, call)
+	}
+
+	start := time.Now()
+	result, err := next(ctx, call)
+	step := RunStep{
+		Type:      RunStepTool,
+		ToolName:  call.Tool.Name(),
+		Arguments: call.Arguments,
+		Result:    result,
+		StartTime: start,
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if turn := llm.ToolTurnFromContext(ctx); turn > 0 {
+		step.Iteration = turn + trace.turnOffset
+	}
+	trace.steps = append(trace.steps, newRunStep(step, time.Now()))
+	return result, err
+}
+
+// result returns the result of the traced run
+func (t *runTrace) result(output string, usage llm.UsageSummary, start time.Time, duration time.Duration) *RunResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	steps := make([]RunStep, len(t.steps))
+	copy(steps, t.steps)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].StartTime.Before(steps[j].StartTime)
+	})
+	return &RunResult{
+		Output:         output,
+		Steps:          steps,
+		Iterations:     t.iterations,
+		Truncated:      t.truncated,
+		Usage:          usage,
+		BudgetExceeded: t.budgetExceeded,
+		StartTime:      start,
+		DurationMs:     duration.Milliseconds(),
+		Duration:       duration,
+	}
+}
+
+// newRunStep returns the step ending at the given time
+func newRunStep(step RunStep, end time.Time) RunStep {
+	step.Duration = end.Sub(step.StartTime)
+	step.DurationMs = step.Duration.Milliseconds()
+	return step
+}
+
+// convertPbRunTrace converts the trace of a remote run
+func convertPbRunTrace(trace *pb.RunTrace) *RunResult {
+	if trace == nil {
+		return &RunResult{}
+	}
+
+	result := &RunResult{
+		Iterations:     int(trace.Iterations),
+		Truncated:      trace.Truncated,
+		BudgetExceeded: trace.BudgetExceeded,
+		StartTime:      time.UnixMilli(trace.StartTime),
+		DurationMs:     trace.DurationMs,
+		Duration:       time.Duration(trace.DurationMs) * time.Millisecond,
+	}
+	if usage := trace.Usage; usage != nil {
+		result.Usage = llm.UsageSummary{
+			Total: llm.TokenUsage{
+				InputTokens:         int(usage.InputTokens),
+				OutputTokens:        int(usage.OutputTokens),
+				CachedInputTokens:   int(usage.CachedInputTokens),
+				CacheCreationTokens: int(usage.CacheCreationTokens),
+				ReasoningTokens:     int(usage.ReasoningTokens),
+				TotalTokens:         int(usage.TotalTokens),
+			},
+			Cost: trace.Cost,
+		}
+	}
+	for _, step := range trace.Steps {
+		result.Steps = append(result.Steps, RunStep{
+			Type:       RunStepType(step.Type),
+			Iteration:  int(step.Iteration),
+			ToolName:   step.ToolName,
+			Arguments:  step.Arguments,
+			Result:     step.Result,
+			Error:      step.Error,
+			StartTime:  time.UnixMilli(step.StartTime),
+			DurationMs: step.DurationMs,
+			Duration:   time.Duration(step.DurationMs) * time.Millisecond,
+		})
+	}
+	return result
+}
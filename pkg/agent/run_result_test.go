@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/grpc/pb"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+)
+
+func TestRunDetailed(t *testing.T) {
+	lookup := &mockTool{name: "lookup"}
+	failing := &mockTool{name: "failing", runFunc: func(ctx context.Context, input string) (string, error) {
+		return "", errors.New("unavailable")
+	}}
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("lookup", `{"input":"a"}`).WithUsage(llm.TokenUsage{InputTokens: 10, OutputTokens: 2}),
+		mock.ToolCalls(
+			interfaces.ToolCall{Name: "lookup", Arguments: `{"input":"b"}`},
+			interfaces.ToolCall{Name: "failing", Arguments: `{"input":"c"}`},
+		).WithUsage(llm.TokenUsage{InputTokens: 20, OutputTokens: 3}),
+		mock.Text("done").WithUsage(llm.TokenUsage{InputTokens: 30, OutputTokens: 4}),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(lookup, failing),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(3),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	result, err := agent.RunDetailed(context.Background(), "Look it up")
+	if err != nil {
+		t.Fatalf("RunDetailed failed: %v", err)
+	}
+	if result.Output != "done" || result.Iterations != 3 || result.Truncated {
+		t.Errorf("Unexpected result: output %q, %d iterations, truncated %v", result.Output, result.Iterations, result.Truncated)
+	}
+	if result.Usage.Total.TotalTokens != 69 || len(result.Usage.Records) != 3 {
+		t.Errorf("Expected the usage of the 3 turns, got %+v", result.Usage)
+	}
+
+	expected := []RunStep{
+		{Type: RunStepLLM, Iteration: 1},
+		{Type: RunStepTool, Iteration: 1, ToolName: "lookup", Arguments: `{"input":"a"}`, Result: `tool lookup executed with: {"input":"a"}`},
+		{Type: RunStepLLM, Iteration: 2},
+		{Type: RunStepTool, Iteration: 2, ToolName: "lookup", Arguments: `{"input":"b"}`, Result: `tool lookup executed with: {"input":"b"}`},
+		{Type: RunStepTool, Iteration: 2, ToolName: "failing", Arguments: `{"input":"c"}`, Error: "unavailable"},
+		{Type: RunStepLLM, Iteration: 3},
+	}
+	if len(result.Steps) != len(expected) {
+		t.Fatalf("Expected %d steps, got %+v", len(expected), result.Steps)
+	}
+	for i, step := range result.Steps {
+		want := expected[i]
+		if step.Type != want.Type || step.Iteration != want.Iteration || step.ToolName != want.ToolName ||
+			step.Arguments != want.Arguments || step.Result != want.Result || step.Error != want.Error {
+			t.Errorf("Step %d: expected %+v, got %+v", i, want, step)
+		}
+		if step.StartTime.Before(result.StartTime) || step.Duration < 0 {
+			t.Errorf("Step %d has invalid timing: %+v", i, step)
+		}
+	}
+
+	// Run returns the output of the same run
+	model.Script(mock.Text("again"))
+	if output, err := agent.Run(context.Background(), "Again"); err != nil || output != "again" {
+		t.Errorf("Expected Run to return the output, got %q (%v)", output, err)
+	}
+}
+
+func TestRunDetailedTruncated(t *testing.T) {
+	agent, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("lookup", `{"input":"a"}`), mock.Text("partial answer")))),
+		WithTools(&mockTool{name: "lookup"}),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(1),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	result, err := agent.RunDetailed(context.Background(), "Look it up")
+	if err != nil {
+		t.Fatalf("RunDetailed failed: %v", err)
+	}
+	if !result.Truncated || result.Iterations != 2 || result.Output != "partial answer" {
+		t.Errorf("Expected a truncated run, got %+v", result)
+	}
+}
+
+func TestRunDetailedFailure(t *testing.T) {
+	agent, err := NewAgent(
+		WithLLM(mock.New(mock.WithTurns(mock.ToolCall("lookup", `{"input":"a"}`), mock.Error(errors.New("rate limited"))))),
+		WithTools(&mockTool{name: "lookup"}),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	result, err := agent.RunDetailed(context.Background(), "Look it up")
+	if err == nil {
+		t.Fatal("Expected the run to fail")
+	}
+	if result == nil || len(result.Steps) != 3 || result.Iterations != 2 {
+		t.Fatalf("Expected the steps taken before the failure, got %+v", result)
+	}
+	if last := result.Steps[2]; last.Type != RunStepLLM || last.Error == "" {
+		t.Errorf("Expected the failed LLM turn, got %+v", last)
+	}
+}
+
+func TestConvertPbRunTrace(t *testing.T) {
+	start := time.UnixMilli(time.Now().UnixMilli())
+	result := convertPbRunTrace(&pb.RunTrace{
+		Steps: []*pb.RunStep{
+			{Type: "llm", Iteration: 1, StartTime: start.UnixMilli(), DurationMs: 120},
+			{Type: "tool", Iteration: 1, ToolName: "lookup", Arguments: "{}", Result: "found", StartTime: start.UnixMilli(), DurationMs: 5},
+		},
+		Iterations: 2,
+		Truncated:  true,
+		Usage:      &pb.TokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		Cost:       0.01,
+		StartTime:  start.UnixMilli(),
+		DurationMs: 300,
+	})
+
+	if result.Iterations != 2 || !result.Truncated || !result.StartTime.Equal(start) || result.Duration != 300*time.Millisecond {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.Usage.Total.TotalTokens != 15 || result.Usage.Cost != 0.01 {
+		t.Errorf("Unexpected usage: %+v", result.Usage)
+	}
+	if len(result.Steps) != 2 || result.Steps[1].ToolName != "lookup" || result.Steps[1].Result != "found" || result.Steps[0].Duration != 120*time.Millisecond {
+		t.Errorf("Unexpected steps: %+v", result.Steps)
+	}
+}
@@ -2,11 +2,13 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 	"github.com/andmang/agent-sdk-go/pkg/tracing"
 )
@@ -48,11 +50,29 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 		// Inject agent name into context for tracing span naming
 		ctx = tracing.WithAgentName(ctx, a.name)
 
+		// Collect token usage so it can be reported with the completion event
+		ctx = llm.WithUsageCollection(ctx)
+
+		// Report tool calls waiting for approval to the caller
+		ctx = withApprovalNotifier(ctx, func(approval interfaces.ToolApproval) {
+			select {
+			case eventChan <- interfaces.AgentStreamEvent{
+				Type:      interfaces.AgentEventApprovalRequest,
+				Approval:  &approval,
+				Timestamp: time.Now(),
+			}:
+			case <-ctx.Done():
+			}
+		})
+
 		// If orgID is set on the agent, add it to the context
 		if a.orgID != "" {
 			ctx = multitenancy.WithOrgID(ctx, a.orgID)
 		}
 
+		// Track the resources of the run if it has a budget
+		ctx = a.startBudget(ctx)
+
 		// Start tracing if available
 		var span interfaces.Span
 		if a.tracer != nil {
@@ -66,11 +86,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 				Role:    "user",
 				Content: input,
 			}); err != nil {
-				eventChan <- interfaces.AgentStreamEvent{
-					Type:      interfaces.AgentEventError,
-					Error:     fmt.Errorf("failed to add user message to memory: %w", err),
-					Timestamp: time.Now(),
-				}
+				a.sendError(ctx, eventChan, fmt.Errorf("failed to add user message to memory: %w", err))
 				return
 			}
 		}
@@ -80,11 +96,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 		if a.guardrails != nil {
 			guardedInput, err := a.guardrails.ProcessInput(ctx, input)
 			if err != nil {
-				eventChan <- interfaces.AgentStreamEvent{
-					Type:      interfaces.AgentEventError,
-					Error:     fmt.Errorf("guardrails error: %w", err),
-					Timestamp: time.Now(),
-				}
+				a.sendError(ctx, eventChan, fmt.Errorf("guardrails error: %w", err))
 				return
 			}
 			processedInput = guardedInput
@@ -96,11 +108,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 			// For now, plan actions are not streamed - fall back to regular handling
 			result, err := a.handlePlanAction(ctx, taskID, action, planInput)
 			if err != nil {
-				eventChan <- interfaces.AgentStreamEvent{
-					Type:      interfaces.AgentEventError,
-					Error:     err,
-					Timestamp: time.Now(),
-				}
+				a.sendError(ctx, eventChan, err)
 			} else {
 				eventChan <- interfaces.AgentStreamEvent{
 					Type:      interfaces.AgentEventContent,
@@ -121,11 +129,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 					Role:    "assistant",
 					Content: response,
 				}); err != nil {
-					eventChan <- interfaces.AgentStreamEvent{
-						Type:      interfaces.AgentEventError,
-						Error:     fmt.Errorf("failed to add role response to memory: %w", err),
-						Timestamp: time.Now(),
-					}
+					a.sendError(ctx, eventChan, fmt.Errorf("failed to add role response to memory: %w", err))
 					return
 				}
 			}
@@ -162,11 +166,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 			// For now, fall back to non-streaming execution plan generation
 			result, err := a.runWithExecutionPlan(ctx, processedInput)
 			if err != nil {
-				eventChan <- interfaces.AgentStreamEvent{
-					Type:      interfaces.AgentEventError,
-					Error:     err,
-					Timestamp: time.Now(),
-				}
+				a.sendError(ctx, eventChan, err)
 			} else {
 				eventChan <- interfaces.AgentStreamEvent{
 					Type:      interfaces.AgentEventContent,
@@ -181,27 +181,45 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 			return
 		}
 
-		// Run with streaming
-		if err := a.runStreamingGeneration(ctx, processedInput, allTools, streamingLLM, eventChan); err != nil {
-			eventChan <- interfaces.AgentStreamEvent{
-				Type:      interfaces.AgentEventError,
-				Error:     err,
-				Timestamp: time.Now(),
+		// Checkpoint the run if a store is configured
+		var run *checkpointRun
+		if a.checkpointStore != nil {
+			var err error
+			ctx, run, err = a.startConversationRun(ctx, processedInput, nil)
+			if err != nil {
+				a.sendError(ctx, eventChan, err)
+				return
 			}
 		}
+
+		// Run with streaming
+		response, err := a.runStreamingGeneration(ctx, processedInput, allTools, streamingLLM, eventChan)
+		if run != nil {
+			run.finish(ctx, response, err)
+		}
+		if err != nil {
+			a.sendError(ctx, eventChan, err)
+		}
 	}()
 
 	return eventChan, nil
 }
 
-// runStreamingGeneration handles the core streaming generation logic
+// runStreamingGeneration handles the core streaming generation logic and
+// returns the response
 func (a *Agent) runStreamingGeneration(
 	ctx context.Context,
 	input string,
 	tools []interfaces.Tool,
 	streamingLLM interfaces.StreamingLLM,
 	eventChan chan<- interfaces.AgentStreamEvent,
-) error {
+) (string, error) {
+	// Give the LLM only the tools relevant to the input if the agent selects tools
+	selection := a.selectTools(ctx, input, tools)
+	if selection != nil {
+		tools = selection.tools()
+	}
+
 	// Prepare generation options
 	options := []interfaces.GenerateOption{}
 
@@ -220,20 +238,30 @@ func (a *Agent) runStreamingGeneration(
 	}
 
 	// Add response format if available
-	if a.responseFormat != nil {
+	if responseFormat := a.responseFormatFor(ctx); responseFormat != nil {
 		options = append(options, func(opts *interfaces.GenerateOptions) {
-			opts.ResponseFormat = a.responseFormat
+			opts.ResponseFormat = responseFormat
 		})
 	}
 
-	// Add max iterations if available
+	// Add max iterations if available, less those of a checkpointed run
 	if a.maxIterations > 0 {
-		options = append(options, interfaces.WithMaxIterations(a.maxIterations))
+		options = append(options, interfaces.WithMaxIterations(a.iterationsLeft(ctx)))
+	}
+
+	// Add parallel tool calls if enabled
+	if a.maxParallelToolCalls > 1 {
+		options = append(options, interfaces.WithParallelToolCalls(a.maxParallelToolCalls))
+	}
+
+	// Add tool choice if set for the agent or the run
+	if toolChoice := a.toolChoiceFor(ctx, tools); toolChoice != nil {
+		options = append(options, interfaces.WithToolChoice(*toolChoice))
 	}
 
-	// Add memory if available
-	if a.memory != nil {
-		options = append(options, interfaces.WithMemory(a.memory))
+	// Add memory if available, or the conversation of a checkpointed run
+	if memory := a.llmMemory(ctx, tools); memory != nil {
+		options = append(options, interfaces.WithMemory(memory))
 	}
 
 	// Add stream config if available
@@ -241,18 +269,156 @@ func (a *Agent) runStreamingGeneration(
 		options = append(options, interfaces.WithStreamConfig(*a.streamConfig))
 	}
 
-	// Start LLM streaming
+	// Keep the results of the tool calls for reflection and for a call that
+	// continues the work of one that was stopped
+	ctx, results := withToolResults(ctx)
+
+	// With reflection, the content of the draft is held back until the
+	// answer has been critiqued and revised
+	out := eventChan
+	release := func() {}
+	if a.reflection != nil {
+		out, release = holdContent(eventChan)
+	}
+
+	// Call the LLM through the middleware, which may answer without streaming
+	streamed := false
+	overBudget := false
+	prompt := input
+	var response string
+	var err error
+	for {
+		// The call is cancelled if the run exceeds its budget, or to give the
+		// LLM the tools it requested
+		budgetCtx, stopBudget := startBudgetCall(ctx)
+		llmCtx, stopSelection := selection.startCall(budgetCtx)
+		call := &LLMCall{Prompt: prompt, Tools: tools, Options: options, Stream: true}
+		response, err = a.callLLM(llmCtx, call, func(ctx context.Context, call *LLMCall) (string, error) {
+			streamed = true
+			return a.forwardLLMStream(ctx, streamingLLM, call, out)
+		})
+		exceeded := budgetExceeded(budgetCtx)
+		added := selection.addedTools(llmCtx)
+		stopSelection()
+		stopBudget()
+
+		// Answer with what the run has gathered if it exceeded its budget.
+		// The stream may have ended without an error when it was cancelled.
+		if exceeded != nil {
+			response, err = a.answerOverBudget(ctx, input, results, exceeded)
+			streamed = false
+			overBudget = true
+			break
+		}
+		if len(added) == 0 {
+			break
+		}
+
+		// Continue with the tools the LLM requested
+		prompt, err = a.continueWithTools(ctx, input, added, results)
+		if err != nil {
+			break
+		}
+		tools = selection.tools()
+		if memory := a.llmMemory(ctx, tools); memory != nil {
+			options = append(options, interfaces.WithMemory(memory))
+		}
+	}
+	release()
+	if err != nil && errors.Is(err, errStreamNotStarted) {
+		return "", err
+	}
+	if a.reflection != nil && err == nil && !overBudget {
+		response, err = a.reflect(ctx, input, response, results, func(step string) {
+			eventChan <- interfaces.AgentStreamEvent{
+				Type:         interfaces.AgentEventThinking,
+				ThinkingStep: step,
+				Timestamp:    time.Now(),
+			}
+		})
+		// The answer is sent as a whole once reflected on
+		streamed = false
+	}
+	if !streamed && err == nil && response != "" {
+		eventChan <- interfaces.AgentStreamEvent{
+			Type:      interfaces.AgentEventContent,
+			Content:   response,
+			Timestamp: time.Now(),
+		}
+	}
+
+	if err == nil {
+		response, err = a.finalResponse(ctx, response)
+	}
+
+	// Add the response to memory if available and no error occurred
+	if a.memory != nil && err == nil && response != "" {
+		if err := a.memory.AddMessage(ctx, interfaces.Message{
+			Role:    "assistant",
+			Content: response,
+		}); err != nil {
+			// Warning: Failed to add assistant response to memory
+			fmt.Printf("Warning: Failed to add assistant response to memory: %v\n", err)
+		}
+	}
+
+	// Send completion event
+	eventChan <- interfaces.AgentStreamEvent{
+		Type:      interfaces.AgentEventComplete,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"total_content_length": len(response),
+			"had_error":            err != nil,
+			"usage":                a.summarizeUsage(ctx),
+		},
+	}
+
+	return response, err
+}
+
+// holdContent returns a channel that forwards the events sent to it to
+// eventChan, except content, and a function that closes it once the events
+// have been forwarded
+func holdContent(eventChan chan<- interfaces.AgentStreamEvent) (chan<- interfaces.AgentStreamEvent, func()) {
+	held := make(chan interfaces.AgentStreamEvent, cap(eventChan))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range held {
+			if event.Type != interfaces.AgentEventContent {
+				eventChan <- event
+			}
+		}
+	}()
+	return held, func() {
+		close(held)
+		<-done
+	}
+}
+
+// errStreamNotStarted marks errors of LLM streams that could not be started
+var errStreamNotStarted = errors.New("failed to start LLM streaming")
+
+// forwardLLMStream streams an LLM call, forwards its events as agent events and
+// returns the streamed content once the stream has ended
+func (a *Agent) forwardLLMStream(
+	ctx context.Context,
+	streamingLLM interfaces.StreamingLLM,
+	call *LLMCall,
+	eventChan chan<- interfaces.AgentStreamEvent,
+) (string, error) {
 	var llmEventChan <-chan interfaces.StreamEvent
 	var err error
 
+	tools := a.hookTools(call.Tools)
 	if len(tools) > 0 {
-		llmEventChan, err = streamingLLM.GenerateWithToolsStream(ctx, input, tools, options...)
+		llmEventChan, err = streamingLLM.GenerateWithToolsStream(ctx, call.Prompt, tools, call.Options...)
 	} else {
-		llmEventChan, err = streamingLLM.GenerateStream(ctx, input, options...)
+		llmEventChan, err = streamingLLM.GenerateStream(ctx, call.Prompt, call.Options...)
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to start LLM streaming: %w", err)
+		return "", fmt.Errorf("%w: %w", errStreamNotStarted, err)
 	}
 
 	// Track accumulated content for memory
@@ -263,10 +429,9 @@ func (a *Agent) runStreamingGeneration(
 	for llmEvent := range llmEventChan {
 		agentEvent := a.convertLLMEventToAgentEvent(llmEvent)
 
-		// Handle tool calls specially
+		// Announce tool calls with their display name
 		if llmEvent.Type == interfaces.StreamEventToolUse && llmEvent.ToolCall != nil {
-			// Execute tool and send progress events
-			a.handleToolCallStreaming(ctx, llmEvent.ToolCall, tools, eventChan)
+			a.handleToolCallStreaming(llmEvent.ToolCall, tools, eventChan)
 		}
 
 		// Accumulate content for memory
@@ -274,8 +439,12 @@ func (a *Agent) runStreamingGeneration(
 			accumulatedContent.WriteString(llmEvent.Content)
 		}
 
-		// Track errors
+		// Track errors, except those of a call stopped to give the LLM more
+		// tools or because the run exceeded its budget
 		if llmEvent.Error != nil {
+			if errors.Is(context.Cause(ctx), errToolsAdded) || budgetExceeded(ctx) != nil {
+				continue
+			}
 			finalError = llmEvent.Error
 		}
 
@@ -283,28 +452,7 @@ func (a *Agent) runStreamingGeneration(
 		eventChan <- agentEvent
 	}
 
-	// Add accumulated content to memory if available and no error occurred
-	if a.memory != nil && finalError == nil && accumulatedContent.Len() > 0 {
-		if err := a.memory.AddMessage(ctx, interfaces.Message{
-			Role:    "assistant",
-			Content: accumulatedContent.String(),
-		}); err != nil {
-			// Warning: Failed to add assistant response to memory
-			fmt.Printf("Warning: Failed to add assistant response to memory: %v\n", err)
-		}
-	}
-
-	// Send completion event
-	eventChan <- interfaces.AgentStreamEvent{
-		Type:      interfaces.AgentEventComplete,
-		Timestamp: time.Now(),
-		Metadata: map[string]interface{}{
-			"total_content_length": accumulatedContent.Len(),
-			"had_error":            finalError != nil,
-		},
-	}
-
-	return finalError
+	return accumulatedContent.String(), finalError
 }
 
 // convertLLMEventToAgentEvent converts LLM events to agent events
@@ -346,11 +494,17 @@ func (a *Agent) convertLLMEventToAgentEvent(llmEvent interfaces.StreamEvent) int
 	case interfaces.StreamEventToolResult:
 		agentEvent.Type = interfaces.AgentEventToolResult
 		if llmEvent.ToolCall != nil {
+			// Providers send the result as content or as result metadata
+			result := llmEvent.Content
+			if metadataResult, ok := llmEvent.Metadata["result"].(string); ok && result == "" {
+				result = metadataResult
+			}
 			agentEvent.ToolCall = &interfaces.ToolCallEvent{
-				ID:     llmEvent.ToolCall.ID,
-				Name:   llmEvent.ToolCall.Name,
-				Result: "", // LLM StreamEvent ToolCall doesn't have Result field
-				Status: "completed",
+				ID:        llmEvent.ToolCall.ID,
+				Name:      llmEvent.ToolCall.Name,
+				Arguments: llmEvent.ToolCall.Arguments,
+				Result:    result,
+				Status:    "completed",
 			}
 		}
 
@@ -371,21 +525,16 @@ func (a *Agent) convertLLMEventToAgentEvent(llmEvent interfaces.StreamEvent) int
 	return agentEvent
 }
 
-// handleToolCallStreaming executes a tool call and sends progress events
+// handleToolCallStreaming sends the executing event of a tool call with the
+// display name of the tool. It does not run the tool: the LLM's tool loop runs
+// it, through the agent's hooks, and streams its result.
 func (a *Agent) handleToolCallStreaming(
-	ctx context.Context,
 	toolCall *interfaces.ToolCall,
 	tools []interfaces.Tool,
 	eventChan chan<- interfaces.AgentStreamEvent,
 ) {
-	// Find the requested tool first to get its display name and internal flag
-	var selectedTool interfaces.Tool
-	for _, tool := range tools {
-		if tool.Name() == toolCall.Name {
-			selectedTool = tool
-			break
-		}
-	}
+	// Find the requested tool to get its display name and internal flag
+	selectedTool := llm.FindTool(tools, toolCall.Name)
 
 	// Prepare tool call event with display name and internal flag
 	var displayName string
@@ -409,7 +558,6 @@ func (a *Agent) handleToolCallStreaming(
 		displayName = toolCall.Name
 	}
 
-	// Send tool execution start event
 	eventChan <- interfaces.AgentStreamEvent{
 		Type: interfaces.AgentEventToolCall,
 		ToolCall: &interfaces.ToolCallEvent{
@@ -422,53 +570,17 @@ func (a *Agent) handleToolCallStreaming(
 		},
 		Timestamp: time.Now(),
 	}
+}
 
-	if selectedTool == nil {
-		// Send tool result event with error instead of error event
-		errorMessage := fmt.Sprintf("Error: tool not found: %s", toolCall.Name)
-		eventChan <- interfaces.AgentStreamEvent{
-			Type: interfaces.AgentEventToolResult,
-			ToolCall: &interfaces.ToolCallEvent{
-				ID:          toolCall.ID,
-				Name:        toolCall.Name,
-				DisplayName: displayName,
-				Internal:    internal,
-				Arguments:   toolCall.Arguments,
-				Result:      errorMessage,
-				Status:      "error",
-			},
-			Error:     fmt.Errorf("tool not found: %s", toolCall.Name),
-			Timestamp: time.Now(),
-		}
-		return
-	}
-
-	// Execute the tool
-	toolResult, err := selectedTool.Execute(ctx, toolCall.Arguments)
-
-	// Send tool result event
-	resultEvent := interfaces.AgentStreamEvent{
-		Type: interfaces.AgentEventToolResult,
-		ToolCall: &interfaces.ToolCallEvent{
-			ID:          toolCall.ID,
-			Name:        toolCall.Name,
-			DisplayName: displayName,
-			Internal:    internal,
-			Arguments:   toolCall.Arguments,
-			Result:      toolResult,
-		},
+// sendError reports the error a stream fails with to the OnError hooks and
+// sends it as an error event
+func (a *Agent) sendError(ctx context.Context, eventChan chan<- interfaces.AgentStreamEvent, err error) {
+	a.notifyError(ctx, err)
+	eventChan <- interfaces.AgentStreamEvent{
+		Type:      interfaces.AgentEventError,
+		Error:     err,
 		Timestamp: time.Now(),
 	}
-
-	if err != nil {
-		resultEvent.Error = err
-		resultEvent.ToolCall.Status = "error"
-		resultEvent.ToolCall.Result = fmt.Sprintf("Error: %v", err)
-	} else {
-		resultEvent.ToolCall.Status = "completed"
-	}
-
-	eventChan <- resultEvent
 }
 
 // runRemoteStream handles streaming for remote agents
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestToolChoice(t *testing.T) {
+	var choice *interfaces.ToolChoice
+	llm := &mockLLM{generateFunc: func(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+		var params interfaces.GenerateOptions
+		for _, option := range options {
+			option(&params)
+		}
+		choice = params.ToolChoice
+		return "ok", nil
+	}}
+	tool := &mockTool{name: "extract_invoice", description: "Extracts invoice fields"}
+
+	agent, err := NewAgent(
+		WithLLM(llm),
+		WithTools(tool),
+		WithRequirePlanApproval(false),
+		WithToolChoice(interfaces.ToolChoiceRequired),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected *interfaces.ToolChoice
+	}{
+		{"agent default", context.Background(), &interfaces.ToolChoiceRequired},
+		{"run override", WithRunToolChoice(context.Background(), interfaces.ToolChoiceFunction("extract_invoice")),
+			&interfaces.ToolChoice{Mode: interfaces.ToolChoiceModeTool, Name: "extract_invoice"}},
+		{"unknown tool", WithRunToolChoice(context.Background(), interfaces.ToolChoiceFunction("missing")), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice = nil
+			if _, err := agent.Run(tt.ctx, "Extract the invoice"); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if tt.expected == nil {
+				if choice != nil {
+					t.Errorf("Expected no tool choice, got %+v", *choice)
+				}
+				return
+			}
+			if choice == nil || *choice != *tt.expected {
+				t.Errorf("Expected tool choice %+v, got %+v", *tt.expected, choice)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/toolselection"
+)
+
+// RequestToolsName is the name of the tool the LLM is given to ask for tools
+// that were not selected for the run
+const RequestToolsName = "request_tools"
+
+// toolSelectionHistory is the number of recent messages of the conversation
+// that the tools are selected for, along with the input
+const toolSelectionHistory = 4
+
+// errToolsAdded is the cause of the cancellation of an LLM call after the LLM
+// was given more tools and the tool calls of its turn have finished
+var errToolsAdded = errors.New("tools were added to the run")
+
+// WithToolSelector sends the LLM only the tools that the selector finds
+// relevant to the input and the recent conversation, instead of every tool of
+// the agent and its MCP servers. The LLM is also given a request_tools tool
+// to ask for tools it was not given, which are added for the rest of the run.
+// A tool of the agent that the LLM calls by name without having been given it
+// is added and run as well.
+// Tools are selected the same way in Run and RunStream; execution plans are
+// given all tools.
+func WithToolSelector(selector *toolselection.Selector) Option {
+	return func(a *Agent) {
+		a.toolSelector = selector
+	}
+}
+
+// toolSelection is the set of tools given to the LLM in a run
+type toolSelection struct {
+	selector *toolselection.Selector
+	all      []interfaces.Tool
+
+	mu    sync.Mutex
+	given map[string]bool
+	added []string // Tools requested during the current LLM call
+}
+
+// selectTools selects the tools of the run that are given to the LLM. It
+// returns nil if the LLM is given all of them.
+func (a *Agent) selectTools(ctx context.Context, input string, tools []interfaces.Tool) *toolSelection {
+	if a.toolSelector == nil || len(tools) == 0 {
+		return nil
+	}
+
+	selected, err := a.toolSelector.Select(ctx, a.toolSelectionQuery(ctx, input), tools)
+	if err != nil {
+		if a.logger != nil {
+			a.logger.Warn(ctx, "Tool selection failed, giving the LLM all tools", map[string]interface{}{
+				"agent": a.name,
+				"error": err.Error(),
+			})
+		}
+		return nil
+	}
+	if len(selected) == len(tools) {
+		return nil
+	}
+
+	selection := &toolSelection{selector: a.toolSelector, all: tools, given: make(map[string]bool)}
+	for _, tool := range selected {
+		selection.given[tool.Name()] = true
+	}
+	// A tool forced by the tool choice is always given
+	if choice := a.toolChoiceFor(ctx, tools); choice != nil && choice.Mode == interfaces.ToolChoiceModeTool {
+		selection.given[choice.Name] = true
+	}
+	if a.logger != nil {
+		a.logger.Debug(ctx, "Selected tools for the run", map[string]interface{}{
+			"agent":    a.name,
+			"selected": len(selection.given),
+			"tools":    len(tools),
+		})
+	}
+	return selection
+}
+
+// toolSelectionQuery returns the text the tools are selected for: the input
+// and the recent messages of the conversation
+func (a *Agent) toolSelectionQuery(ctx context.Context, input string) string {
+	if a.memory == nil {
+		return input
+	}
+	messages, err := a.memory.GetMessages(ctx,
+		interfaces.WithLimit(toolSelectionHistory),
+		interfaces.WithRoles(string(interfaces.MessageRoleUser), string(interfaces.MessageRoleAssistant)),
+	)
+	if err != nil {
+		return input
+	}
+
+	var query []string
+	for _, message := range messages {
+		if message.Content != "" && message.Content != input {
+			query = append(query, message.Content)
+		}
+	}
+	return strings.Join(append(query, input), "\n")
+}
+
+// tools returns the tools given to the LLM, in the order of the tools of the
+// run, with the tool to request others
+func (s *toolSelection) tools() []interfaces.Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tools []interfaces.Tool
+	for _, tool := range s.all {
+		if s.given[tool.Name()] {
+			tools = append(tools, tool)
+		}
+	}
+	if len(tools) < len(s.all) {
+		tools = append(tools, &requestToolsTool{selection: s})
+	}
+	return tools
+}
+
+// startCall returns the context of an LLM call, and a function that releases
+// it. The tools of an LLM call cannot change, so once the LLM has requested
+// more tools, the call is cancelled at the end of the turn, after the other
+// tool calls of the turn have finished, to be made again with the new tools.
+func (s *toolSelection) startCall(ctx context.Context) (context.Context, func()) {
+	if s == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	s.mu.Lock()
+	s.added = nil
+	s.mu.Unlock()
+	ctx = llm.WithToolTurnEnd(ctx, func() {
+		s.mu.Lock()
+		added := len(s.added) > 0
+		s.mu.Unlock()
+		if added {
+			cancel(errToolsAdded)
+		}
+	})
+	return ctx, func() { cancel(nil) }
+}
+
+// addedTools returns the tools added during the LLM call of the context, if
+// the call was cancelled to give them to the LLM
+func (s *toolSelection) addedTools(ctx context.Context) []string {
+	if s == nil || !errors.Is(context.Cause(ctx), errToolsAdded) {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.added
+}
+
+// requestToolsTool is the tool the LLM calls to ask for tools it was not
+// given. The tools found are given to the LLM once the tool calls of its turn
+// have finished.
+type requestToolsTool struct {
+	selection *toolSelection
+}
+
+// Name returns the name of the tool
+func (t *requestToolsTool) Name() string {
+	return RequestToolsName
+}
+
+// Description returns the description of the tool
+func (t *requestToolsTool) Description() string {
+	return "Request tools that you were not given. Describe what the tools should do, or name them if you know their names. The tools found are available once the tool calls of this turn have finished."
+}
+
+// Parameters returns the parameters of the tool
+func (t *requestToolsTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{
+		"query": {
+			Type:        "string",
+			Description: "What the tools should do, or their names",
+			Required:    true,
+		},
+	}
+}
+
+// Run requests tools for the input
+func (t *requestToolsTool) Run(ctx context.Context, input string) (string, error) {
+	s := t.selection
+	s.mu.Lock()
+	var candidates []interfaces.Tool
+	for _, tool := range s.all {
+		if !s.given[tool.Name()] {
+			candidates = append(candidates, tool)
+		}
+	}
+	s.mu.Unlock()
+
+	found, err := s.selector.Search(ctx, input, candidates, s.selector.TopK())
+	if err != nil {
+		return "", fmt.Errorf("failed to find tools: %w", err)
+	}
+	if len(found) == 0 {
+		return "No other tools are available.", nil
+	}
+
+	var names []string
+	s.mu.Lock()
+	for _, tool := range found {
+		s.given[tool.Name()] = true
+		s.added = append(s.added, tool.Name())
+		names = append(names, tool.Name())
+	}
+	s.mu.Unlock()
+	return "Added the tools " + strings.Join(names, ", "), nil
+}
+
+// LookupTool returns the tool of the run with the given name, which the LLM
+// calls without having been given it, and gives it to the LLM from its next
+// call
+func (t *requestToolsTool) LookupTool(name string) interfaces.Tool {
+	s := t.selection
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tool := range s.all {
+		if tool.Name() == name {
+			s.given[name] = true
+			return tool
+		}
+	}
+	return nil
+}
+
+// Execute requests tools for the query of the arguments
+func (t *requestToolsTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if params.Query == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	return t.Run(ctx, params.Query)
+}
+
+// continueWithTools prepares the LLM call that continues the work on the input
+// with the tools the LLM requested and returns its prompt. The LLM is told
+// about the tools with a system message in the memory of the run, which holds
+// the tool calls made so far. Without memory, the prompt tells it and lists
+// the results of the calls.
+func (a *Agent) continueWithTools(ctx context.Context, input string, added []string, results *toolResults) (string, error) {
+	notice := fmt.Sprintf("You requested more tools, and the tools %s are now available. Continue working on the request.", strings.Join(added, ", "))
+	memory := a.llmMemory(ctx, nil)
+	if memory == nil {
+		return fmt.Sprintf(`%s
+
+%s
+
+Results of the tool calls made so far:
+%s`, input, notice, results), nil
+	}
+	if err := memory.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleSystem, Content: notice}); err != nil {
+		return "", fmt.Errorf("failed to add the requested tools to memory: %w", err)
+	}
+	return input, nil
+}
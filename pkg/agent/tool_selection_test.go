@@ -0,0 +1,346 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/toolselection"
+)
+
+// keywordEmbedder embeds texts by counting the keywords they contain
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embedding := make([]float32, len(e.keywords))
+	for i, keyword := range e.keywords {
+		embedding[i] = float32(strings.Count(strings.ToLower(text), keyword))
+	}
+	return embedding, nil
+}
+
+func (e *keywordEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = e.Embed(ctx, text)
+	}
+	return embeddings, nil
+}
+
+func (e *keywordEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	var dot, norm1, norm2 float64
+	for i := range vec1 {
+		dot += float64(vec1[i] * vec2[i])
+		norm1 += float64(vec1[i] * vec1[i])
+		norm2 += float64(vec2[i] * vec2[i])
+	}
+	if norm1 == 0 || norm2 == 0 {
+		return 0, nil
+	}
+	return float32(dot / math.Sqrt(norm1*norm2)), nil
+}
+
+func selectionTestTools() []interfaces.Tool {
+	return []interfaces.Tool{
+		&mockTool{name: "get_weather", description: "Get the weather forecast"},
+		&mockTool{name: "send_email", description: "Send an email"},
+		&mockTool{name: "create_event", description: "Create a calendar event"},
+		&mockTool{name: "query_db", description: "Query the database"},
+	}
+}
+
+func toolNames(tools []interfaces.Tool) string {
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func newTestSelector(topK int) *toolselection.Selector {
+	embedder := &keywordEmbedder{keywords: []string{"weather", "email", "calendar", "database"}}
+	return toolselection.NewSelector(embedder, toolselection.WithTopK(topK), toolselection.WithPinnedTools("query_db"))
+}
+
+func TestToolSelection(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Text("sunny"), mock.Text("saved")))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithMemory(memory.NewConversationBuffer()),
+		WithTools(selectionTestTools()...),
+		WithToolSelector(newTestSelector(2)),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	if _, err := agent.Run(ctx, "What is the weather in Paris?"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if names := toolNames(model.LastCall().Tools); !strings.HasPrefix(names, "get_weather,") || !strings.HasSuffix(names, ",query_db,"+RequestToolsName) {
+		t.Errorf("Expected the weather tool and the pinned tool, got %s", names)
+	}
+
+	// The tools are selected for the conversation as well as the input
+	if _, err := agent.Run(ctx, "Can you email it to me?"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if names := toolNames(model.LastCall().Tools); names != "get_weather,send_email,query_db,"+RequestToolsName {
+		t.Errorf("Expected the weather and email tools and the pinned tool, got %s", names)
+	}
+}
+
+func TestToolSelectionExpansion(t *testing.T) {
+	var sent string
+	tools := selectionTestTools()
+	tools[1] = &mockTool{name: "send_email", description: "Send an email", runFunc: func(ctx context.Context, input string) (string, error) {
+		sent = input
+		return "sent", nil
+	}}
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall(RequestToolsName, `{"query":"a tool to send an email"}`),
+		mock.ToolCall("send_email", `{"to":"bob"}`),
+		mock.Text("The forecast was sent to Bob"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tools...),
+		WithToolSelector(newTestSelector(1)),
+		WithRequirePlanApproval(false),
+		WithMaxIterations(3),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	result, err := agent.RunDetailed(context.Background(), "Get the weather forecast and mail it to Bob")
+	if err != nil {
+		t.Fatalf("RunDetailed failed: %v", err)
+	}
+	if result.Output != "The forecast was sent to Bob" || sent != `{"to":"bob"}` {
+		t.Errorf("Expected the requested tool to be called, got %q after sending %q", result.Output, sent)
+	}
+
+	calls := model.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 LLM turns, got %d", len(calls))
+	}
+	if names := toolNames(calls[0].Tools); names != "get_weather,query_db,"+RequestToolsName {
+		t.Errorf("Expected the selected tools first, got %s", names)
+	}
+	restarted := calls[1]
+	if names := toolNames(restarted.Tools); names != "get_weather,send_email,query_db,"+RequestToolsName {
+		t.Errorf("Expected the requested tool to be added, got %s", names)
+	}
+	if !strings.Contains(restarted.Prompt, "the tools send_email are now available") ||
+		!strings.Contains(restarted.Prompt, "Added the tools send_email") {
+		t.Errorf("Expected the prompt to continue the work, got %q", restarted.Prompt)
+	}
+	if restarted.Options.MaxIterations != 2 {
+		t.Errorf("Expected the iterations left, got %d", restarted.Options.MaxIterations)
+	}
+}
+
+func TestToolSelectionUnselectedToolCalledByName(t *testing.T) {
+	var sent string
+	tools := selectionTestTools()
+	tools[1] = &mockTool{name: "send_email", description: "Send an email", runFunc: func(ctx context.Context, input string) (string, error) {
+		sent = input
+		return "sent", nil
+	}}
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("send_email", `{"to":"bob"}`),
+		mock.Text("The forecast was sent to Bob"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tools...),
+		WithToolSelector(newTestSelector(1)),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	output, err := agent.Run(context.Background(), "Get the weather forecast and mail it to Bob")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if names := toolNames(model.Calls()[0].Tools); names != "get_weather,query_db,"+RequestToolsName {
+		t.Fatalf("Expected send_email not to be selected, got %s", names)
+	}
+	if output != "The forecast was sent to Bob" || sent != `{"to":"bob"}` {
+		t.Errorf("Expected the unselected tool to run, got %q after sending %q", output, sent)
+	}
+	if results := model.LastCall().ToolResults; len(results) != 1 || results[0].Content != "sent" {
+		t.Errorf("Expected the result of the tool, got %+v", results)
+	}
+}
+
+func TestToolSelectionFinishesTurn(t *testing.T) {
+	var forecasts int
+	tools := selectionTestTools()
+	tools[0] = countingTool("get_weather", &forecasts)
+	model := mock.New(mock.WithTurns(
+		mock.ToolCalls(
+			interfaces.ToolCall{Name: RequestToolsName, Arguments: `{"query":"a tool to send an email"}`},
+			interfaces.ToolCall{Name: "get_weather", Arguments: `{"input":"Paris"}`},
+		),
+		mock.ToolCall("send_email", `{"to":"bob"}`),
+		mock.Text("The forecast was sent to Bob"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tools...),
+		WithToolSelector(newTestSelector(1)),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	if _, err := agent.Run(context.Background(), "Get the weather forecast and mail it to Bob"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The other call of the turn runs before the LLM is given the new tools
+	calls := model.Calls()
+	if forecasts != 1 || len(calls) != 3 {
+		t.Fatalf("Expected the forecast to run once and 3 LLM turns, got %d and %d", forecasts, len(calls))
+	}
+	restarted := calls[1]
+	if names := toolNames(restarted.Tools); names != "get_weather,send_email,query_db,"+RequestToolsName {
+		t.Errorf("Expected the requested tool to be added, got %s", names)
+	}
+	if !strings.Contains(restarted.Prompt, "get_weather result") {
+		t.Errorf("Expected the result of the forecast in the prompt, got %q", restarted.Prompt)
+	}
+}
+
+func TestToolSelectionExpansionWithMemory(t *testing.T) {
+	mem := memory.NewConversationBuffer()
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall(RequestToolsName, `{"query":"a tool to send an email"}`),
+		mock.ToolCall("send_email", `{"to":"bob"}`),
+		mock.Text("The forecast was sent to Bob"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithMemory(mem),
+		WithTools(selectionTestTools()...),
+		WithToolSelector(newTestSelector(1)),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	ctx := hookTestContext()
+	input := "Get the weather forecast and mail it to Bob"
+	if _, err := agent.Run(ctx, input); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if restarted := model.Calls()[1]; restarted.Prompt != input {
+		t.Errorf("Expected the input as the prompt with memory, got %q", restarted.Prompt)
+	}
+
+	// The LLM is told about the tools in memory, after the call that requested them
+	messages, err := mem.GetMessages(ctx)
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	var roles []string
+	for _, message := range messages {
+		roles = append(roles, string(message.Role))
+	}
+	expected := "user,assistant,tool,system,assistant,tool,assistant"
+	if strings.Join(roles, ",") != expected {
+		t.Fatalf("Expected messages %s, got %s", expected, strings.Join(roles, ","))
+	}
+	if notice := messages[3].Content; !strings.Contains(notice, "the tools send_email are now available") {
+		t.Errorf("Expected the added tools in memory, got %q", notice)
+	}
+}
+
+func TestToolSelectionFewTools(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Text("done")))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(selectionTestTools()[:2]...),
+		WithToolSelector(newTestSelector(2)),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	if _, err := agent.Run(context.Background(), "Send an email"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if names := toolNames(model.LastCall().Tools); names != "get_weather,send_email" {
+		t.Errorf("Expected all tools without the request tool, got %s", names)
+	}
+}
+
+func TestToolSelectionStream(t *testing.T) {
+	var sent string
+	tools := selectionTestTools()
+	tools[1] = &mockTool{name: "send_email", description: "Send an email", runFunc: func(ctx context.Context, input string) (string, error) {
+		sent = input
+		return "sent", nil
+	}}
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall(RequestToolsName, `{"query":"a tool to send an email"}`),
+		mock.ToolCall("send_email", `{"to":"bob"}`),
+		mock.Text("The forecast was sent to Bob"),
+	))
+	agent, err := NewAgent(
+		WithLLM(model),
+		WithTools(tools...),
+		WithToolSelector(newTestSelector(1)),
+		WithRequirePlanApproval(false),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	events, err := agent.RunStream(context.Background(), "Get the weather forecast and mail it to Bob")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var content strings.Builder
+	for event := range events {
+		switch event.Type {
+		case interfaces.AgentEventContent:
+			content.WriteString(event.Content)
+		case interfaces.AgentEventError:
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+	if !strings.Contains(content.String(), "The forecast was sent to Bob") || sent != `{"to":"bob"}` {
+		t.Errorf("Expected the requested tool to be called, got %q after sending %q", content.String(), sent)
+	}
+
+	calls := model.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 LLM turns, got %d", len(calls))
+	}
+	if names := toolNames(calls[0].Tools); names != "get_weather,query_db,"+RequestToolsName {
+		t.Errorf("Expected the selected tools first, got %s", names)
+	}
+	restarted := calls[1]
+	if names := toolNames(restarted.Tools); names != "get_weather,send_email,query_db,"+RequestToolsName {
+		t.Errorf("Expected the requested tool to be added, got %s", names)
+	}
+	if !strings.Contains(restarted.Prompt, "the tools send_email are now available") {
+		t.Errorf("Expected the prompt to continue the work, got %q", restarted.Prompt)
+	}
+}
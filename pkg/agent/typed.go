@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
+)
+
+// DefaultRepairAttempts is the number of times RunTyped asks the model to fix
+// a response that does not match the schema
+const DefaultRepairAttempts = 2
+
+// responseFormatKey is the context key for a per-run response format
+type responseFormatKey struct{}
+
+// typedRunConfig holds the options of a typed run
+type typedRunConfig struct {
+	repairAttempts int
+}
+
+// TypedRunOption configures RunTyped
+type TypedRunOption func(*typedRunConfig)
+
+// WithRepairAttempts sets how many times the model is re-prompted with the
+// validation errors before RunTyped gives up. 0 disables repairs.
+func WithRepairAttempts(attempts int) TypedRunOption {
+	return func(c *typedRunConfig) {
+		if attempts >= 0 {
+			c.repairAttempts = attempts
+		}
+	}
+}
+
+// StructuredOutputError is returned by RunTyped when no response matched the schema
+type StructuredOutputError struct {
+	Attempts int    // Number of responses received, including repairs
+	Response string // The last raw response
+	Err      error  // Why the last response was rejected, e.g. a *structuredoutput.ValidationError
+}
+
+// Error implements the error interface
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("no valid structured output after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
+
+// RunTyped runs the agent and returns its response decoded into T, which must
+// be a struct. The JSON schema of T is sent to the LLM as the response format,
+// taking precedence over the agent's own response format for this run. The
+// response is validated against the schema; if it does not match, the model is
+// shown the validation errors and asked to correct it, up to the number of
+// repair attempts.
+func RunTyped[T any](ctx context.Context, a *Agent, input string, options ...TypedRunOption) (T, error) {
+	var result T
+
+	config := typedRunConfig{repairAttempts: DefaultRepairAttempts}
+	for _, option := range options {
+		option(&config)
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return result, fmt.Errorf("RunTyped requires a struct type, got %s", t)
+	}
+	format := structuredoutput.NewResponseFormat(result)
+	ctx = context.WithValue(ctx, responseFormatKey{}, format)
+
+	prompt := input
+	for attempt := 1; ; attempt++ {
+		response, err := a.Run(ctx, prompt)
+		if err != nil {
+			return result, err
+		}
+
+		parseErr := decodeTyped(format.Schema, response, &result)
+		if parseErr == nil {
+			return result, nil
+		}
+		if attempt > config.repairAttempts {
+			return result, &StructuredOutputError{Attempts: attempt, Response: response, Err: parseErr}
+		}
+
+		if a.logger != nil {
+			a.logger.Warn(ctx, "Structured output did not match the schema, asking the model to repair it", map[string]interface{}{
+				"agent":   a.name,
+				"schema":  format.Name,
+				"attempt": attempt,
+				"error":   parseErr.Error(),
+			})
+		}
+		prompt = repairPrompt(response, parseErr)
+	}
+}
+
+// decodeTyped validates the JSON in a response against the schema and decodes it
+func decodeTyped(schema interfaces.JSONSchema, response string, v interface{}) error {
+	data := []byte(structuredoutput.ExtractJSON(response))
+	if err := structuredoutput.Validate(schema, data); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// repairPrompt asks the model to correct a response that was rejected
+func repairPrompt(response string, err error) string {
+	var sb strings.Builder
+	sb.WriteString("Your previous response did not match the required JSON schema.\n\nPrevious response:\n")
+	sb.WriteString(response)
+	sb.WriteString("\n\nErrors:\n")
+
+	var validationErr *structuredoutput.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, issue := range validationErr.Issues {
+			sb.WriteString("- " + issue + "\n")
+		}
+	} else {
+		sb.WriteString("- " + err.Error() + "\n")
+	}
+
+	sb.WriteString("\nRespond with only the corrected JSON object, without any other text.")
+	return sb.String()
+}
+
+// responseFormatFor returns the response format for a run, preferring one
+// set for the run over the agent's own
+func (a *Agent) responseFormatFor(ctx context.Context) *interfaces.ResponseFormat {
+	if format, ok := ctx.Value(responseFormatKey{}).(*interfaces.ResponseFormat); ok && format != nil {
+		return format
+	}
+	return a.responseFormat
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
+)
+
+type typedInvoice struct {
+	Number string  `json:"number"`
+	Total  float64 `json:"total"`
+	Lines  []struct {
+		Item     string `json:"item"`
+		Quantity int    `json:"quantity"`
+	} `json:"lines"`
+	Notes string `json:"notes,omitempty"`
+}
+
+func TestRunTyped(t *testing.T) {
+	var format *interfaces.ResponseFormat
+	agent, err := NewAgent(WithLLM(&mockLLM{generateFunc: func(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+		var params interfaces.GenerateOptions
+		for _, option := range options {
+			option(&params)
+		}
+		format = params.ResponseFormat
+		return "Here is the invoice:\n```json\n{\"number\": \"INV-1\", \"total\": 12.5, \"lines\": [{\"item\": \"pen\", \"quantity\": 5}]}\n```", nil
+	}}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	invoice, err := RunTyped[typedInvoice](context.Background(), agent, "Extract the invoice")
+	if err != nil {
+		t.Fatalf("RunTyped failed: %v", err)
+	}
+	if invoice.Number != "INV-1" || invoice.Total != 12.5 || len(invoice.Lines) != 1 || invoice.Lines[0].Quantity != 5 {
+		t.Errorf("Unexpected invoice: %+v", invoice)
+	}
+	if format == nil || format.Name != "typedInvoice" {
+		t.Fatalf("Expected the schema of typedInvoice to be sent, got %+v", format)
+	}
+}
+
+func TestRunTypedRepairsInvalidResponse(t *testing.T) {
+	var prompts []string
+	agent, err := NewAgent(WithLLM(&mockLLM{generateFunc: func(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+		prompts = append(prompts, prompt)
+		if len(prompts) == 1 {
+			return `{"number": "INV-1", "total": "12.50", "lines": []}`, nil
+		}
+		return `{"number": "INV-1", "total": 12.5, "lines": []}`, nil
+	}}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	invoice, err := RunTyped[typedInvoice](context.Background(), agent, "Extract the invoice")
+	if err != nil {
+		t.Fatalf("RunTyped failed: %v", err)
+	}
+	if invoice.Total != 12.5 {
+		t.Errorf("Expected the repaired total, got %v", invoice.Total)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(prompts))
+	}
+	if !strings.Contains(prompts[1], "$.total: expected number, got string") {
+		t.Errorf("Expected the repair prompt to contain the validation error, got %q", prompts[1])
+	}
+}
+
+func TestRunTypedGivesUp(t *testing.T) {
+	calls := 0
+	agent, err := NewAgent(WithLLM(&mockLLM{generateFunc: func(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+		calls++
+		return "I cannot find an invoice", nil
+	}}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	_, err = RunTyped[typedInvoice](context.Background(), agent, "Extract the invoice", WithRepairAttempts(1))
+	var outputErr *StructuredOutputError
+	if !errors.As(err, &outputErr) {
+		t.Fatalf("Expected a StructuredOutputError, got %v", err)
+	}
+	if outputErr.Attempts != 2 || calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d (%d calls)", outputErr.Attempts, calls)
+	}
+	if outputErr.Response != "I cannot find an invoice" {
+		t.Errorf("Unexpected last response: %q", outputErr.Response)
+	}
+}
+
+func TestRunTypedMissingFields(t *testing.T) {
+	agent, err := NewAgent(WithLLM(&mockLLM{generateFunc: func(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+		return `{"number": "INV-1"}`, nil
+	}}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	_, err = RunTyped[typedInvoice](context.Background(), agent, "Extract the invoice", WithRepairAttempts(0))
+	var validationErr *structuredoutput.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	if len(validationErr.Issues) != 2 {
+		t.Errorf("Expected issues for total and lines, got %v", validationErr.Issues)
+	}
+}
+
+func TestRunTypedRequiresStruct(t *testing.T) {
+	agent, err := NewAgent(WithLLM(&mockLLM{}))
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	if _, err := RunTyped[[]string](context.Background(), agent, "List things"); err == nil {
+		t.Error("Expected an error for a non-struct type")
+	}
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// usageLLM reports fixed usage for every call and runs each tool it is given once
+type usageLLM struct {
+	model string
+	usage llm.TokenUsage
+}
+
+func (m *usageLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	llm.AddUsageToContext(ctx, llm.UsageRecord{Provider: m.Name(), Model: m.model, Usage: m.usage})
+	return "done", nil
+}
+
+func (m *usageLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	for i, tool := range tools {
+		llm.AddUsageToContext(ctx, llm.UsageRecord{Provider: m.Name(), Model: m.model, Iteration: i + 1, Usage: m.usage})
+		if _, err := tool.Execute(ctx, `{"query": "sub task"}`); err != nil {
+			return "", err
+		}
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{Provider: m.Name(), Model: m.model, Iteration: len(tools) + 1, Usage: m.usage})
+	return "done with tools", nil
+}
+
+func (m *usageLLM) Name() string {
+	return "usage-llm"
+}
+
+func (m *usageLLM) SupportsStreaming() bool {
+	return false
+}
+
+func TestRunWithUsage(t *testing.T) {
+	subAgent, err := NewAgent(
+		WithName("Helper"),
+		WithDescription("Helps"),
+		WithLLM(&usageLLM{model: "small-model", usage: llm.TokenUsage{InputTokens: 100, OutputTokens: 10}}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create sub-agent: %v", err)
+	}
+
+	mainAgent, err := NewAgent(
+		WithName("Main"),
+		WithLLM(&usageLLM{model: "big-model", usage: llm.TokenUsage{InputTokens: 1000, OutputTokens: 200}}),
+		WithAgents(subAgent),
+		WithRequirePlanApproval(false),
+		WithPriceTable(llm.NewPriceTable(map[string]llm.ModelPrice{
+			"big-model": {InputPerMillion: 1000, OutputPerMillion: 5000},
+		})),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create main agent: %v", err)
+	}
+
+	result, summary, err := mainAgent.RunWithUsage(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("RunWithUsage failed: %v", err)
+	}
+	if result != "done with tools" {
+		t.Errorf("Unexpected result: %q", result)
+	}
+
+	// Two main-agent iterations plus one sub-agent call
+	if len(summary.Records) != 3 {
+		t.Fatalf("Expected 3 usage records, got %d: %+v", len(summary.Records), summary.Records)
+	}
+	if summary.Total.InputTokens != 2100 || summary.Total.OutputTokens != 410 || summary.Total.TotalTokens != 2510 {
+		t.Errorf("Unexpected total usage: %+v", summary.Total)
+	}
+
+	// Only the main model is priced: 2000 input and 400 output tokens
+	expectedCost := 2000*1000/1e6 + 400*5000/1e6
+	if summary.Cost < expectedCost-1e-9 || summary.Cost > expectedCost+1e-9 {
+		t.Errorf("Expected cost %f, got %f", expectedCost, summary.Cost)
+	}
+	if len(summary.UnpricedModels) != 1 || summary.UnpricedModels[0] != "small-model" {
+		t.Errorf("Expected small-model to be unpriced, got %v", summary.UnpricedModels)
+	}
+}
@@ -2,6 +2,8 @@ package agent
 
 import (
 	"fmt"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 )
 
 // validateSubAgents checks for circular dependencies in sub-agents
@@ -43,6 +45,37 @@ func (a *Agent) checkCircularDependency(visited, recursionStack map[string]bool)
 	return nil
 }
 
+// validateModel checks the agent's configuration against the capabilities of
+// its model, so that unsupported features fail when the agent is created
+// instead of on the first request. Models that are not in the model registry
+// are not checked.
+func (a *Agent) validateModel() error {
+	model := llm.ModelName(a.llm)
+	if model == "" {
+		return nil
+	}
+	info, ok := llm.LookupModel(model)
+	if !ok {
+		return nil
+	}
+
+	requirements := llm.ModelRequirements{
+		StructuredOutput: a.responseFormat != nil,
+		Reasoning:        a.llmConfig != nil && a.llmConfig.EnableReasoning,
+	}
+	if len(a.tools) > 0 || len(a.subAgents) > 0 || len(a.mcpServers) > 0 || len(a.lazyMCPConfigs) > 0 {
+		// Clients with a ReAct fallback can call tools without native support
+		if m, ok := a.llm.(interface{ ToolCallingMode() llm.ToolCallingMode }); !ok || m.ToolCallingMode() == llm.ToolCallingNative {
+			requirements.Tools = true
+		}
+	}
+
+	if err := info.Check(requirements); err != nil {
+		return fmt.Errorf("%w (register the model's capabilities with llm.RegisterModel if this is wrong)", err)
+	}
+	return nil
+}
+
 // getUniqueID returns a unique identifier for the agent
 func (a *Agent) getUniqueID() string {
 	if a.name != "" {
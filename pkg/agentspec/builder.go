@@ -0,0 +1,359 @@
+package agentspec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/agent"
+	"github.com/andmang/agent-sdk-go/pkg/guardrails"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/logging"
+	"github.com/andmang/agent-sdk-go/pkg/mcp"
+)
+
+// Builder builds agents from specs
+type Builder struct {
+	registry *Registry
+	options  []agent.Option
+	logger   logging.Logger
+}
+
+// BuilderOption configures a Builder
+type BuilderOption func(*Builder)
+
+// WithRegistry sets the registry of the factories of LLMs, tools and memory
+// backends. It defaults to NewRegistry().
+func WithRegistry(registry *Registry) BuilderOption {
+	return func(b *Builder) {
+		b.registry = registry
+	}
+}
+
+// WithAgentOptions adds options to every agent built, including sub-agents.
+// They are applied after the options of the spec, so they override them.
+func WithAgentOptions(options ...agent.Option) BuilderOption {
+	return func(b *Builder) {
+		b.options = append(b.options, options...)
+	}
+}
+
+// WithLogger sets the logger of the guardrails of the agents
+func WithLogger(logger logging.Logger) BuilderOption {
+	return func(b *Builder) {
+		b.logger = logger
+	}
+}
+
+// NewBuilder creates a new builder
+func NewBuilder(options ...BuilderOption) *Builder {
+	b := &Builder{}
+	for _, option := range options {
+		option(b)
+	}
+	if b.registry == nil {
+		b.registry = NewRegistry()
+	}
+	if b.logger == nil {
+		b.logger = logging.New()
+	}
+	return b
+}
+
+// LoadFile reads and validates a spec and the specs of its sub-agents. The
+// LLM providers, tool and memory types must be registered.
+func (b *Builder) LoadFile(path string) (*Spec, error) {
+	spec, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if issues := b.resolve(spec, nil); len(issues) > 0 {
+		return nil, &ValidationError{Issues: issues}
+	}
+	return spec, nil
+}
+
+// BuildFile loads the spec at path and builds its agent
+func (b *Builder) BuildFile(ctx context.Context, path string) (*agent.Agent, error) {
+	spec, err := b.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return b.build(ctx, spec)
+}
+
+// Build builds the agent of a spec, loading the specs of its sub-agents
+func (b *Builder) Build(ctx context.Context, spec *Spec) (*agent.Agent, error) {
+	if issues := b.resolve(spec, nil); len(issues) > 0 {
+		return nil, &ValidationError{Issues: issues}
+	}
+	return b.build(ctx, spec)
+}
+
+// resolve checks the factory names of the spec and loads the specs of its
+// sub-agents. The stack holds the specs that refer to the spec, to detect
+// cycles.
+func (b *Builder) resolve(spec *Spec, stack []string) []Issue {
+	issues := b.checkFactories(spec)
+
+	file, err := filepath.Abs(spec.file)
+	if err != nil {
+		file = spec.file
+	}
+	stack = append(stack, file)
+
+	spec.subAgents = nil
+	for i, subAgent := range spec.SubAgents {
+		path := fmt.Sprintf("sub_agents[%d]", i)
+		if !filepath.IsAbs(subAgent) {
+			subAgent = filepath.Join(filepath.Dir(spec.file), subAgent)
+		}
+		if absolute, err := filepath.Abs(subAgent); err == nil {
+			subAgent = absolute
+		}
+		if slices.Contains(stack, subAgent) {
+			issues = append(issues, spec.issue(path, "the sub-agent refers back to "+subAgent))
+			continue
+		}
+
+		subSpec, err := LoadFile(subAgent)
+		if err != nil {
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				issues = append(issues, validationErr.Issues...)
+			} else {
+				issues = append(issues, spec.issue(path, err.Error()))
+			}
+			continue
+		}
+		issues = append(issues, b.resolve(subSpec, stack)...)
+		spec.subAgents = append(spec.subAgents, subSpec)
+	}
+	return issues
+}
+
+// checkFactories checks that the factories the spec names are registered
+func (b *Builder) checkFactories(spec *Spec) []Issue {
+	var issues []Issue
+	check := func(path, kind, name string, registered bool) {
+		if !registered {
+			message := fmt.Sprintf("unknown %s %q, expected one of %s", kind, name, strings.Join(b.registry.names(kind), ", "))
+			issues = append(issues, spec.issue(path, message))
+		}
+	}
+
+	_, ok := b.registry.llm(spec.LLM.Provider)
+	check("llm.provider", "llm", spec.LLM.Provider, ok)
+	for i, tool := range spec.Tools {
+		_, ok := b.registry.tool(tool.Type)
+		check(fmt.Sprintf("tools[%d].type", i), "tool", tool.Type, ok)
+	}
+	if spec.Memory != nil {
+		_, ok := b.registry.memoryFactory(spec.Memory.Type)
+		check("memory.type", "memory", spec.Memory.Type, ok)
+	}
+	return issues
+}
+
+// build builds the agent of a resolved spec
+func (b *Builder) build(ctx context.Context, spec *Spec) (*agent.Agent, error) {
+	llmFactory, _ := b.registry.llm(spec.LLM.Provider)
+	llm, err := llmFactory(ctx, spec.LLM)
+	if err != nil {
+		return nil, spec.errorAt("llm", fmt.Errorf("failed to create LLM: %w", err))
+	}
+
+	options := []agent.Option{agent.WithName(spec.Name), agent.WithLLM(llm)}
+	if spec.Description != "" {
+		options = append(options, agent.WithDescription(spec.Description))
+	}
+	switch {
+	case spec.SystemPrompt != "":
+		options = append(options, agent.WithSystemPrompt(spec.SystemPrompt))
+	case spec.Role != "" || spec.Goal != "" || spec.Backstory != "":
+		prompt := agent.FormatSystemPromptFromConfig(agent.AgentConfig{Role: spec.Role, Goal: spec.Goal, Backstory: spec.Backstory}, nil)
+		options = append(options, agent.WithSystemPrompt(prompt))
+	}
+	if config, ok := llmConfig(spec.LLM); ok {
+		options = append(options, agent.WithLLMConfig(config))
+	}
+
+	tools := make([]interfaces.Tool, 0, len(spec.Tools))
+	for i, toolSpec := range spec.Tools {
+		factory, _ := b.registry.tool(toolSpec.Type)
+		tool, err := factory(ctx, toolSpec.Config)
+		if err != nil {
+			return nil, spec.errorAt(fmt.Sprintf("tools[%d]", i), fmt.Errorf("failed to create %s tool: %w", toolSpec.Type, err))
+		}
+		tools = append(tools, tool)
+	}
+	if len(tools) > 0 {
+		options = append(options, agent.WithTools(tools...))
+	}
+
+	mcpOptions, err := b.mcpServers(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, mcpOptions...)
+
+	if spec.Memory != nil {
+		factory, _ := b.registry.memoryFactory(spec.Memory.Type)
+		mem, err := factory(ctx, spec.Memory.Config, llm)
+		if err != nil {
+			return nil, spec.errorAt("memory", fmt.Errorf("failed to create %s memory: %w", spec.Memory.Type, err))
+		}
+		options = append(options, agent.WithMemory(mem))
+	}
+
+	if len(spec.Guardrails) > 0 {
+		options = append(options, agent.WithGuardrails(b.guardrails(spec)))
+	}
+
+	limits := spec.Limits
+	if limits.MaxIterations > 0 {
+		options = append(options, agent.WithMaxIterations(limits.MaxIterations))
+	}
+	if limits.MaxParallelToolCalls > 0 {
+		options = append(options, agent.WithParallelToolCalls(limits.MaxParallelToolCalls))
+	}
+	budget := agent.RunBudget{
+		MaxTokens:       limits.MaxTokens,
+		MaxCost:         limits.MaxCost,
+		MaxToolCalls:    limits.MaxToolCalls,
+		MaxCallsPerTool: limits.MaxCallsPerTool,
+		MaxDuration:     time.Duration(limits.MaxDuration),
+	}
+	if budget.MaxTokens > 0 || budget.MaxCost > 0 || budget.MaxToolCalls > 0 || len(budget.MaxCallsPerTool) > 0 || budget.MaxDuration > 0 {
+		options = append(options, agent.WithBudget(budget))
+	}
+
+	if spec.ResponseFormat != nil {
+		format, err := agent.ConvertYAMLSchemaToResponseFormat(spec.ResponseFormat)
+		if err != nil {
+			return nil, spec.errorAt("response_format", err)
+		}
+		options = append(options, agent.WithResponseFormat(*format))
+	}
+	if spec.RequirePlanApproval != nil {
+		options = append(options, agent.WithRequirePlanApproval(*spec.RequirePlanApproval))
+	}
+
+	// The sub-agents are added after the tools, which WithTools replaces
+	if len(spec.subAgents) > 0 {
+		subAgents := make([]*agent.Agent, 0, len(spec.subAgents))
+		for _, subSpec := range spec.subAgents {
+			subAgent, err := b.build(ctx, subSpec)
+			if err != nil {
+				return nil, err
+			}
+			subAgents = append(subAgents, subAgent)
+		}
+		options = append(options, agent.WithAgents(subAgents...))
+	}
+
+	a, err := agent.NewAgent(append(options, b.options...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent %s: %w", spec.Name, err)
+	}
+	return a, nil
+}
+
+// llmConfig returns the sampling configuration of the LLM, if any is set
+func llmConfig(spec LLMSpec) (interfaces.LLMConfig, bool) {
+	config := interfaces.LLMConfig{
+		Temperature:      spec.Temperature,
+		TopP:             spec.TopP,
+		TopK:             spec.TopK,
+		FrequencyPenalty: spec.FrequencyPenalty,
+		PresencePenalty:  spec.PresencePenalty,
+		StopSequences:    spec.StopSequences,
+		Reasoning:        spec.Reasoning,
+	}
+	set := config.Temperature != 0 || config.TopP != 0 || config.TopK != 0 || config.FrequencyPenalty != 0 ||
+		config.PresencePenalty != 0 || len(config.StopSequences) > 0 || config.Reasoning != ""
+	return config, set
+}
+
+// mcpServers returns the options that give the agent the tools of its MCP
+// servers. The servers whose tools are listed are started lazily; the others
+// are connected now.
+func (b *Builder) mcpServers(ctx context.Context, spec *Spec) ([]agent.Option, error) {
+	var servers []interfaces.MCPServer
+	var lazy []agent.LazyMCPConfig
+	for i, server := range spec.MCPServers {
+		if len(server.Tools) > 0 {
+			config := agent.LazyMCPConfig{
+				Name:    server.Name,
+				Type:    server.Type,
+				Command: server.Command,
+				Args:    server.Args,
+				Env:     server.Env,
+				URL:     server.URL,
+			}
+			for _, tool := range server.Tools {
+				toolConfig := agent.LazyMCPToolConfig{Name: tool.Name, Description: tool.Description}
+				if tool.Schema != nil {
+					toolConfig.Schema = tool.Schema
+				}
+				config.Tools = append(config.Tools, toolConfig)
+			}
+			lazy = append(lazy, config)
+			continue
+		}
+
+		var connected interfaces.MCPServer
+		var err error
+		if server.Type == "stdio" {
+			connected, err = mcp.NewStdioServer(ctx, mcp.StdioServerConfig{
+				Command: server.Command,
+				Args:    server.Args,
+				Env:     server.Env,
+			})
+		} else {
+			connected, err = mcp.NewHTTPServer(ctx, mcp.HTTPServerConfig{
+				BaseURL:      server.URL,
+				Token:        server.Token,
+				ProtocolType: mcp.ServerProtocolType(server.Protocol),
+			})
+		}
+		if err != nil {
+			return nil, spec.errorAt(fmt.Sprintf("mcp_servers[%d]", i), fmt.Errorf("failed to connect to MCP server %s: %w", server.Name, err))
+		}
+		servers = append(servers, connected)
+	}
+
+	var options []agent.Option
+	if len(servers) > 0 {
+		options = append(options, agent.WithMCPServers(servers))
+	}
+	if len(lazy) > 0 {
+		options = append(options, agent.WithLazyMCPConfigs(lazy))
+	}
+	return options, nil
+}
+
+// guardrails returns the pipeline of the guardrails of the spec
+func (b *Builder) guardrails(spec *Spec) *guardrails.Pipeline {
+	var pipeline []guardrails.Guardrail
+	for _, g := range spec.Guardrails {
+		action := guardrails.Action(g.Action)
+		switch guardrails.GuardrailType(g.Type) {
+		case guardrails.ContentFilterGuardrail:
+			pipeline = append(pipeline, guardrails.NewContentFilter(g.BlockedWords, action))
+		case guardrails.PiiFilterGuardrail:
+			pipeline = append(pipeline, guardrails.NewPiiFilter(action))
+		case guardrails.TokenLimitGuardrail:
+			pipeline = append(pipeline, guardrails.NewTokenLimit(g.MaxTokens, nil, action, g.TruncateMode))
+		case guardrails.ToolRestrictionGuardrail:
+			pipeline = append(pipeline, guardrails.NewToolRestriction(g.AllowedTools, action))
+		case guardrails.RateLimitGuardrail:
+			pipeline = append(pipeline, guardrails.NewRateLimit(g.RequestsPerMinute, action))
+		}
+	}
+	return guardrails.NewPipeline(pipeline, b.logger)
+}
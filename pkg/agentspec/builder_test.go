@@ -0,0 +1,213 @@
+package agentspec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoTool returns its input, prefixed with the configured prefix
+type echoTool struct {
+	prefix string
+}
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Echo the input" }
+func (t *echoTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{}
+}
+func (t *echoTool) Run(ctx context.Context, input string) (string, error) {
+	return t.prefix + input, nil
+}
+func (t *echoTool) Execute(ctx context.Context, args string) (string, error) {
+	return t.Run(ctx, args)
+}
+
+// testRegistry returns a registry with a mock LLM provider that creates the
+// model named in the spec, and an echo tool
+func testRegistry(models map[string]*mock.LLM) *Registry {
+	registry := NewRegistry()
+	registry.RegisterLLM("mock", func(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+		model, ok := models[spec.Model]
+		if !ok {
+			return nil, errors.New("unknown model " + spec.Model)
+		}
+		return model, nil
+	})
+	registry.RegisterTool("echo", func(ctx context.Context, config Config) (interfaces.Tool, error) {
+		var params struct {
+			Prefix string `yaml:"prefix"`
+		}
+		if err := config.Decode(&params); err != nil {
+			return nil, err
+		}
+		return &echoTool{prefix: params.Prefix}, nil
+	})
+	return registry
+}
+
+func writeSpecs(t *testing.T, specs map[string]string) string {
+	dir := t.TempDir()
+	for name, spec := range specs {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(spec), 0o600))
+	}
+	return dir
+}
+
+func TestBuildFile(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"assistant.yaml": `name: assistant
+system_prompt: You are a helpful assistant.
+llm:
+  provider: mock
+  model: main
+tools:
+  - type: echo
+    config:
+      prefix: "echo: "
+memory:
+  type: conversation_buffer
+guardrails:
+  - type: content_filter
+    action: block
+    blocked_words: [password]
+sub_agents:
+  - agents/researcher.yaml
+limits:
+  max_iterations: 3
+require_plan_approval: false
+`,
+		"agents/researcher.yaml": `name: researcher
+description: Researches topics
+role: Researcher
+goal: Find facts
+backstory: You are thorough.
+llm:
+  provider: mock
+  model: research
+`,
+	})
+
+	main := mock.New(mock.WithTurns(
+		mock.ToolCall("researcher_agent", `{"query":"Find the capital of France"}`),
+		mock.ToolCall("echo", `Paris`),
+		mock.Text("The capital is Paris"),
+	))
+	research := mock.New(mock.WithTurns(mock.Text("Paris")))
+	builder := NewBuilder(WithRegistry(testRegistry(map[string]*mock.LLM{"main": main, "research": research})))
+
+	a, err := builder.BuildFile(context.Background(), filepath.Join(dir, "assistant.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "assistant", a.GetName())
+	assert.Equal(t, "You are a helpful assistant.", a.GetSystemPrompt())
+	assert.NotNil(t, a.GetMemory())
+
+	subAgent, ok := a.GetSubAgent("researcher")
+	require.True(t, ok)
+	assert.Equal(t, "Researches topics", subAgent.GetDescription())
+	assert.Contains(t, subAgent.GetSystemPrompt(), "Researcher")
+
+	var names []string
+	for _, tool := range a.GetTools() {
+		names = append(names, tool.Name())
+	}
+	assert.Equal(t, []string{"echo", "researcher_agent"}, names)
+
+	ctx := memory.WithConversationID(multitenancy.WithOrgID(context.Background(), "test-org"), "test-conversation")
+	result, err := a.RunDetailed(ctx, "What is the capital of France?")
+	require.NoError(t, err)
+	assert.Equal(t, "The capital is Paris", result.Output)
+	assert.Equal(t, "Find the capital of France", research.LastCall().Prompt)
+	assert.Equal(t, 3, main.LastCall().Options.MaxIterations)
+
+	// The guardrails of the spec check the input
+	_, err = a.Run(ctx, "What is my password?")
+	assert.ErrorContains(t, err, "blocked by content_filter guardrail")
+}
+
+func TestBuilderIssues(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"assistant.yaml": `name: assistant
+llm:
+  provider: mock
+tools:
+  - type: search
+sub_agents:
+  - writer.yaml
+  - missing.yaml
+`,
+		"writer.yaml": `name: writer
+llm:
+  provider: mock
+memory:
+  type: vector
+`,
+	})
+	builder := NewBuilder(WithRegistry(testRegistry(nil)))
+
+	_, err := builder.LoadFile(filepath.Join(dir, "assistant.yaml"))
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Issues, 3)
+
+	assert.Equal(t, filepath.Join(dir, "assistant.yaml"), validationErr.Issues[0].File)
+	assert.Equal(t, 5, validationErr.Issues[0].Line)
+	assert.Equal(t, "tools[0].type", validationErr.Issues[0].Path)
+	assert.Contains(t, validationErr.Issues[0].Message, `unknown tool "search", expected one of calculator, echo, websearch`)
+
+	// The issues of a sub-agent are located in its spec
+	assert.Equal(t, filepath.Join(dir, "writer.yaml"), validationErr.Issues[1].File)
+	assert.Equal(t, "memory.type", validationErr.Issues[1].Path)
+	assert.Equal(t, 5, validationErr.Issues[1].Line)
+
+	assert.Equal(t, "sub_agents[1]", validationErr.Issues[2].Path)
+	assert.Equal(t, 8, validationErr.Issues[2].Line)
+	assert.Contains(t, validationErr.Issues[2].Message, "failed to read agent spec")
+}
+
+func TestBuilderSubAgentCycle(t *testing.T) {
+	dir := writeSpecs(t, map[string]string{
+		"a.yaml": "name: a\nllm:\n  provider: mock\nsub_agents: [b.yaml]\n",
+		"b.yaml": "name: b\nllm:\n  provider: mock\nsub_agents: [a.yaml]\n",
+	})
+	builder := NewBuilder(WithRegistry(testRegistry(nil)))
+
+	_, err := builder.LoadFile(filepath.Join(dir, "a.yaml"))
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Issues, 1)
+	assert.Equal(t, filepath.Join(dir, "b.yaml"), validationErr.Issues[0].File)
+	assert.Equal(t, "sub_agents[0]", validationErr.Issues[0].Path)
+	assert.Contains(t, validationErr.Issues[0].Message, "refers back to")
+}
+
+func TestBuildFactoryError(t *testing.T) {
+	spec, err := Parse([]byte(`name: assistant
+llm:
+  provider: mock
+  model: main
+tools:
+  - type: echo
+    config:
+      prefix: "> "
+  - type: echo
+    config:
+      suffix: "!"
+`), "assistant.yaml")
+	require.NoError(t, err)
+
+	builder := NewBuilder(WithRegistry(testRegistry(map[string]*mock.LLM{"main": mock.New()})))
+	_, err = builder.Build(context.Background(), spec)
+	assert.EqualError(t, err, "assistant.yaml:9:5: tools[1]: failed to create echo tool: invalid config: suffix: unknown field")
+}
@@ -0,0 +1,299 @@
+package agentspec
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a problem found in a spec, located in its file
+type Issue struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // Path of the value in the spec, e.g. "tools[1].type"
+	Message string
+}
+
+// String formats the issue as file:line:column: path: message
+func (i Issue) String() string {
+	if location := i.location(); location != "" {
+		return location + ": " + i.Message
+	}
+	return i.Message
+}
+
+// location formats the location of the issue as file:line:column: path
+func (i Issue) location() string {
+	var location []string
+	if i.File != "" {
+		location = append(location, i.File)
+	}
+	if i.Line > 0 {
+		location = append(location, strconv.Itoa(i.Line), strconv.Itoa(i.Column))
+	}
+	result := strings.Join(location, ":")
+	switch {
+	case result == "":
+		return i.Path
+	case i.Path != "":
+		return result + ": " + i.Path
+	}
+	return result
+}
+
+// ValidationError lists the issues that make a spec invalid
+type ValidationError struct {
+	Issues []Issue
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issues[i] = issue.String()
+	}
+	return "invalid agent spec: " + strings.Join(issues, "; ")
+}
+
+// LoadFile reads and validates a spec from a YAML file. The specs of its
+// sub-agents are not loaded; Builder.LoadFile loads them.
+func LoadFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - Loading the spec at the given path is the purpose of this function
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent spec: %w", err)
+	}
+	return Parse(data, path)
+}
+
+// Parse reads and validates a spec from YAML. The file name is used in the
+// locations of issues and to resolve the paths of sub-agents.
+func Parse(data []byte, file string) (*Spec, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, &ValidationError{Issues: []Issue{{File: file, Message: err.Error()}}}
+	}
+	if len(document.Content) == 0 {
+		return nil, &ValidationError{Issues: []Issue{{File: file, Message: "the spec is empty"}}}
+	}
+	root := document.Content[0]
+
+	issues := interpolate(root, "")
+	issues = append(issues, checkNode(root, reflect.TypeOf(Spec{}), "")...)
+	if len(issues) > 0 {
+		return nil, newValidationError(file, issues)
+	}
+
+	spec := &Spec{file: file, nodes: make(map[string]*yaml.Node)}
+	if err := root.Decode(spec); err != nil {
+		return nil, &ValidationError{Issues: []Issue{{File: file, Message: err.Error()}}}
+	}
+	indexNodes(root, "", spec.nodes)
+	if issues := spec.validate(); len(issues) > 0 {
+		return nil, &ValidationError{Issues: issues}
+	}
+	return spec, nil
+}
+
+// newValidationError returns the error for issues of a file
+func newValidationError(file string, issues []Issue) *ValidationError {
+	for i := range issues {
+		issues[i].File = file
+	}
+	return &ValidationError{Issues: issues}
+}
+
+// interpolate replaces the references to environment variables in the
+// scalar values of the node
+func interpolate(node *yaml.Node, path string) []Issue {
+	var issues []Issue
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		value, err := expandEnv(node.Value)
+		if err != nil {
+			return []Issue{issueAt(node, path, err.Error())}
+		}
+		node.Value = value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			issues = append(issues, interpolate(node.Content[i+1], joinPath(path, node.Content[i].Value))...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			issues = append(issues, interpolate(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return issues
+}
+
+// expandEnv replaces ${NAME} and ${NAME:-default} with the values of
+// environment variables, and $$ with $
+func expandEnv(value string) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			result.WriteByte(value[i])
+			continue
+		}
+		switch value[i+1] {
+		case '$':
+			result.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated reference %q", value[i:])
+			}
+			reference := value[i+2 : i+end]
+			name, fallback, hasFallback := strings.Cut(reference, ":-")
+			if name == "" {
+				return "", fmt.Errorf("empty environment variable reference")
+			}
+			variable, ok := os.LookupEnv(name)
+			switch {
+			case ok && variable != "":
+				result.WriteString(variable)
+			case hasFallback:
+				result.WriteString(fallback)
+			case !ok:
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			i += end
+		default:
+			result.WriteByte('$')
+		}
+	}
+	return result.String(), nil
+}
+
+// checkNode checks that the node can be decoded into the type, reporting
+// unknown fields and values of the wrong kind where they are
+func checkNode(node *yaml.Node, t reflect.Type, path string) []Issue {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return nil
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()) {
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			return []Issue{issueAt(node, path, err.Error())}
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return checkNode(node, t.Elem(), path)
+	case reflect.Interface:
+		return nil
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return []Issue{issueAt(node, path, "expected a mapping")}
+		}
+		fields := yamlFields(t)
+		var issues []Issue
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				issues = append(issues, issueAt(key, joinPath(path, key.Value), "unknown field"))
+				continue
+			}
+			issues = append(issues, checkNode(value, field.Type, joinPath(path, key.Value))...)
+		}
+		return issues
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return []Issue{issueAt(node, path, "expected a mapping")}
+		}
+		var issues []Issue
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			issues = append(issues, checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+		return issues
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return []Issue{issueAt(node, path, "expected a list")}
+		}
+		var issues []Issue
+		for i, item := range node.Content {
+			issues = append(issues, checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return issues
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return []Issue{issueAt(node, path, "expected "+kindName(t))}
+	}
+	if err := node.Decode(reflect.New(t).Interface()); err != nil {
+		return []Issue{issueAt(node, path, fmt.Sprintf("expected %s, got %q", kindName(t), node.Value))}
+	}
+	return nil
+}
+
+// indexNodes records the nodes of the spec by their path
+func indexNodes(node *yaml.Node, path string, nodes map[string]*yaml.Node) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	nodes[path] = node
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			indexNodes(node.Content[i+1], joinPath(path, node.Content[i].Value), nodes)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			indexNodes(item, fmt.Sprintf("%s[%d]", path, i), nodes)
+		}
+	}
+}
+
+// yamlFields returns the fields of a struct by their yaml name
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if field.IsExported() && name != "" && name != "-" {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// kindName describes the values of a type in issues
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	default:
+		return "a string"
+	}
+}
+
+// issueAt returns an issue located at the node
+func issueAt(node *yaml.Node, path, message string) Issue {
+	return Issue{Line: node.Line, Column: node.Column, Path: path, Message: message}
+}
+
+// joinPath returns the path of a field of the value at path
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
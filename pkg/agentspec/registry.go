@@ -0,0 +1,293 @@
+package agentspec
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/anthropic"
+	"github.com/andmang/agent-sdk-go/pkg/llm/azureopenai"
+	"github.com/andmang/agent-sdk-go/pkg/llm/gemini"
+	"github.com/andmang/agent-sdk-go/pkg/llm/ollama"
+	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
+	"github.com/andmang/agent-sdk-go/pkg/llm/vllm"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/tools/calculator"
+	"github.com/andmang/agent-sdk-go/pkg/tools/websearch"
+	"google.golang.org/genai"
+)
+
+// LLMFactory creates the LLM of a spec
+type LLMFactory func(ctx context.Context, spec LLMSpec) (interfaces.LLM, error)
+
+// ToolFactory creates a tool from its configuration
+type ToolFactory func(ctx context.Context, config Config) (interfaces.Tool, error)
+
+// MemoryFactory creates a memory backend from its configuration. The LLM of
+// the agent is given for backends that summarize the conversation.
+type MemoryFactory func(ctx context.Context, config Config, llm interfaces.LLM) (interfaces.Memory, error)
+
+// Registry holds the named factories that create the LLMs, tools and memory
+// backends of specs
+type Registry struct {
+	mu     sync.RWMutex
+	llms   map[string]LLMFactory
+	tools  map[string]ToolFactory
+	memory map[string]MemoryFactory
+}
+
+// NewRegistry creates a registry with the built-in factories:
+//   - LLM providers: openai, anthropic, azureopenai, gemini, ollama and vllm
+//   - Tools: calculator and websearch
+//   - Memory: conversation_buffer, conversation_summary and redis
+func NewRegistry() *Registry {
+	r := &Registry{
+		llms:   make(map[string]LLMFactory),
+		tools:  make(map[string]ToolFactory),
+		memory: make(map[string]MemoryFactory),
+	}
+
+	r.RegisterLLM("openai", newOpenAI)
+	r.RegisterLLM("anthropic", newAnthropic)
+	r.RegisterLLM("azureopenai", newAzureOpenAI)
+	r.RegisterLLM("gemini", newGemini)
+	r.RegisterLLM("ollama", newOllama)
+	r.RegisterLLM("vllm", newVLLM)
+
+	r.RegisterTool("calculator", newCalculator)
+	r.RegisterTool("websearch", newWebSearch)
+
+	r.RegisterMemory("conversation_buffer", newConversationBuffer)
+	r.RegisterMemory("conversation_summary", newConversationSummary)
+	r.RegisterMemory("redis", newRedisMemory)
+	return r
+}
+
+// RegisterLLM registers an LLM factory for the provider name, replacing any
+// factory registered for it
+func (r *Registry) RegisterLLM(provider string, factory LLMFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.llms[provider] = factory
+}
+
+// RegisterTool registers a tool factory for the type name, replacing any
+// factory registered for it
+func (r *Registry) RegisterTool(toolType string, factory ToolFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[toolType] = factory
+}
+
+// RegisterMemory registers a memory factory for the type name, replacing any
+// factory registered for it
+func (r *Registry) RegisterMemory(memoryType string, factory MemoryFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.memory[memoryType] = factory
+}
+
+// llm returns the LLM factory of the provider
+func (r *Registry) llm(provider string) (LLMFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.llms[provider]
+	return factory, ok
+}
+
+// tool returns the tool factory of the type
+func (r *Registry) tool(toolType string) (ToolFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.tools[toolType]
+	return factory, ok
+}
+
+// memoryFactory returns the memory factory of the type
+func (r *Registry) memoryFactory(memoryType string) (MemoryFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.memory[memoryType]
+	return factory, ok
+}
+
+// names returns the registered names of the factories of a kind, for issues
+func (r *Registry) names(kind string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	switch kind {
+	case "llm":
+		for name := range r.llms {
+			names = append(names, name)
+		}
+	case "tool":
+		for name := range r.tools {
+			names = append(names, name)
+		}
+	case "memory":
+		for name := range r.memory {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func newOpenAI(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+	options := []openai.Option{}
+	if spec.Model != "" {
+		options = append(options, openai.WithModel(spec.Model))
+	}
+	if spec.BaseURL != "" {
+		options = append(options, openai.WithBaseURL(spec.BaseURL))
+	}
+	return openai.NewClient(spec.APIKey, options...), nil
+}
+
+func newAnthropic(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+	options := []anthropic.Option{}
+	if spec.Model != "" {
+		options = append(options, anthropic.WithModel(spec.Model))
+	}
+	if spec.BaseURL != "" {
+		options = append(options, anthropic.WithBaseURL(spec.BaseURL))
+	}
+	return anthropic.NewClient(spec.APIKey, options...), nil
+}
+
+func newAzureOpenAI(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+	if spec.BaseURL == "" || spec.Deployment == "" {
+		return nil, fmt.Errorf("azureopenai requires base_url and deployment")
+	}
+	options := []azureopenai.Option{}
+	if spec.Model != "" {
+		options = append(options, azureopenai.WithModel(spec.Model))
+	}
+	if spec.APIVersion != "" {
+		options = append(options, azureopenai.WithAPIVersion(spec.APIVersion))
+	}
+	return azureopenai.NewClient(spec.APIKey, spec.BaseURL, spec.Deployment, options...), nil
+}
+
+func newGemini(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+	options := []gemini.Option{}
+	if spec.Model != "" {
+		options = append(options, gemini.WithModel(spec.Model))
+	}
+	if spec.APIKey != "" {
+		options = append(options, gemini.WithAPIKey(spec.APIKey))
+	}
+	if spec.BaseURL != "" {
+		options = append(options, gemini.WithBaseURL(spec.BaseURL))
+	}
+	if spec.ProjectID != "" {
+		options = append(options, gemini.WithBackend(genai.BackendVertexAI), gemini.WithProjectID(spec.ProjectID))
+		if spec.Location != "" {
+			options = append(options, gemini.WithLocation(spec.Location))
+		}
+	}
+	return gemini.NewClient(ctx, options...)
+}
+
+func newOllama(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+	options := []ollama.Option{}
+	if spec.Model != "" {
+		options = append(options, ollama.WithModel(spec.Model))
+	}
+	if spec.BaseURL != "" {
+		options = append(options, ollama.WithBaseURL(spec.BaseURL))
+	}
+	return ollama.NewClient(options...), nil
+}
+
+func newVLLM(ctx context.Context, spec LLMSpec) (interfaces.LLM, error) {
+	options := []vllm.Option{}
+	if spec.Model != "" {
+		options = append(options, vllm.WithModel(spec.Model))
+	}
+	if spec.BaseURL != "" {
+		options = append(options, vllm.WithBaseURL(spec.BaseURL))
+	}
+	return vllm.NewClient(options...), nil
+}
+
+func newCalculator(ctx context.Context, config Config) (interfaces.Tool, error) {
+	if err := config.Decode(&struct{}{}); err != nil {
+		return nil, err
+	}
+	return calculator.New(), nil
+}
+
+func newWebSearch(ctx context.Context, config Config) (interfaces.Tool, error) {
+	var params struct {
+		APIKey   string `yaml:"api_key"`
+		EngineID string `yaml:"engine_id"`
+	}
+	if err := config.Decode(&params); err != nil {
+		return nil, err
+	}
+	if params.APIKey == "" || params.EngineID == "" {
+		return nil, fmt.Errorf("websearch requires api_key and engine_id")
+	}
+	return websearch.New(params.APIKey, params.EngineID), nil
+}
+
+func newConversationBuffer(ctx context.Context, config Config, llm interfaces.LLM) (interfaces.Memory, error) {
+	var params struct {
+		MaxSize int `yaml:"max_size"`
+	}
+	if err := config.Decode(&params); err != nil {
+		return nil, err
+	}
+	options := []memory.Option{}
+	if params.MaxSize > 0 {
+		options = append(options, memory.WithMaxSize(params.MaxSize))
+	}
+	return memory.NewConversationBuffer(options...), nil
+}
+
+func newConversationSummary(ctx context.Context, config Config, llm interfaces.LLM) (interfaces.Memory, error) {
+	var params struct {
+		MaxBufferSize int `yaml:"max_buffer_size"`
+		SummaryLength int `yaml:"summary_length"`
+	}
+	if err := config.Decode(&params); err != nil {
+		return nil, err
+	}
+	options := []memory.SummaryOption{}
+	if params.MaxBufferSize > 0 {
+		options = append(options, memory.WithMaxBufferSize(params.MaxBufferSize))
+	}
+	if params.SummaryLength > 0 {
+		options = append(options, memory.WithSummaryLength(params.SummaryLength))
+	}
+	return memory.NewConversationSummary(llm, options...), nil
+}
+
+func newRedisMemory(ctx context.Context, config Config, llm interfaces.LLM) (interfaces.Memory, error) {
+	var params struct {
+		URL       string   `yaml:"url"`
+		Password  string   `yaml:"password"`
+		DB        int      `yaml:"db"`
+		TTL       Duration `yaml:"ttl"`
+		KeyPrefix string   `yaml:"key_prefix"`
+	}
+	if err := config.Decode(&params); err != nil {
+		return nil, err
+	}
+	if params.URL == "" {
+		return nil, fmt.Errorf("redis requires url")
+	}
+	options := []memory.RedisOption{}
+	if params.TTL > 0 {
+		options = append(options, memory.WithTTL(time.Duration(params.TTL)))
+	}
+	if params.KeyPrefix != "" {
+		options = append(options, memory.WithKeyPrefix(params.KeyPrefix))
+	}
+	return memory.NewRedisMemoryFromConfig(memory.RedisConfig{URL: params.URL, Password: params.Password, DB: params.DB}, options...)
+}
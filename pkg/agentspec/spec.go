@@ -0,0 +1,173 @@
+// Package agentspec defines agents declaratively in YAML. A spec describes
+// the LLM, tools, MCP servers, memory, guardrails, sub-agents and limits of an
+// agent, and a Builder turns it into a configured *agent.Agent. Tools, memory
+// backends and LLM providers are created by named factories of a Registry.
+//
+// Values may reference environment variables as ${NAME}, or ${NAME:-default}
+// with a default, so that secrets are kept out of the spec. Write $$ for a
+// literal dollar sign.
+package agentspec
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/agent"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the definition of an agent
+type Spec struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+
+	// SystemPrompt is the system prompt of the agent. Alternatively, the
+	// prompt is formatted from a role, goal and backstory.
+	SystemPrompt string `yaml:"system_prompt,omitempty"`
+	Role         string `yaml:"role,omitempty"`
+	Goal         string `yaml:"goal,omitempty"`
+	Backstory    string `yaml:"backstory,omitempty"`
+
+	LLM        LLMSpec         `yaml:"llm"`
+	Tools      []ToolSpec      `yaml:"tools,omitempty"`
+	MCPServers []MCPServerSpec `yaml:"mcp_servers,omitempty"`
+	Memory     *MemorySpec     `yaml:"memory,omitempty"`
+	Guardrails []GuardrailSpec `yaml:"guardrails,omitempty"`
+
+	// SubAgents are the paths of the specs of the sub-agents, relative to
+	// this spec
+	SubAgents []string `yaml:"sub_agents,omitempty"`
+
+	Limits              LimitsSpec                  `yaml:"limits,omitempty"`
+	ResponseFormat      *agent.ResponseFormatConfig `yaml:"response_format,omitempty"`
+	RequirePlanApproval *bool                       `yaml:"require_plan_approval,omitempty"`
+
+	file      string                // File the spec was loaded from, if any
+	nodes     map[string]*yaml.Node // Nodes of the spec by path, for error locations
+	subAgents []*Spec               // Loaded specs of the sub-agents
+}
+
+// LLMSpec configures the LLM of an agent
+type LLMSpec struct {
+	Provider string `yaml:"provider"` // Name of an LLM factory, e.g. openai or anthropic
+	Model    string `yaml:"model,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty"`
+	BaseURL  string `yaml:"base_url,omitempty"`
+
+	// Deployment and APIVersion configure Azure OpenAI
+	Deployment string `yaml:"deployment,omitempty"`
+	APIVersion string `yaml:"api_version,omitempty"`
+	// ProjectID and Location select Vertex AI for Gemini
+	ProjectID string `yaml:"project_id,omitempty"`
+	Location  string `yaml:"location,omitempty"`
+
+	Temperature      float64  `yaml:"temperature,omitempty"`
+	TopP             float64  `yaml:"top_p,omitempty"`
+	TopK             int      `yaml:"top_k,omitempty"`
+	FrequencyPenalty float64  `yaml:"frequency_penalty,omitempty"`
+	PresencePenalty  float64  `yaml:"presence_penalty,omitempty"`
+	StopSequences    []string `yaml:"stop_sequences,omitempty"`
+	Reasoning        string   `yaml:"reasoning,omitempty"`
+}
+
+// ToolSpec is a tool created by a named tool factory
+type ToolSpec struct {
+	Type   string `yaml:"type"`
+	Config Config `yaml:"config,omitempty"`
+}
+
+// MemorySpec is a memory backend created by a named memory factory
+type MemorySpec struct {
+	Type   string `yaml:"type"`
+	Config Config `yaml:"config,omitempty"`
+}
+
+// MCPServerSpec is an MCP server whose tools are given to the agent. A server
+// whose tools are listed is started when one of them is first called;
+// otherwise it is connected when the agent is built.
+type MCPServerSpec struct {
+	Name     string        `yaml:"name"`
+	Type     string        `yaml:"type"` // stdio or http
+	Command  string        `yaml:"command,omitempty"`
+	Args     []string      `yaml:"args,omitempty"`
+	Env      []string      `yaml:"env,omitempty"`
+	URL      string        `yaml:"url,omitempty"`
+	Protocol string        `yaml:"protocol,omitempty"` // streamable or sse, for http servers
+	Token    string        `yaml:"token,omitempty"`
+	Tools    []MCPToolSpec `yaml:"tools,omitempty"`
+}
+
+// MCPToolSpec is a tool of an MCP server
+type MCPToolSpec struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description,omitempty"`
+	Schema      map[string]interface{} `yaml:"schema,omitempty"`
+}
+
+// GuardrailSpec is a guardrail applied to the input and output of the agent
+type GuardrailSpec struct {
+	Type              string   `yaml:"type"`   // content_filter, pii_filter, token_limit, tool_restriction or rate_limit
+	Action            string   `yaml:"action"` // block, redact or warn
+	BlockedWords      []string `yaml:"blocked_words,omitempty"`
+	MaxTokens         int      `yaml:"max_tokens,omitempty"`
+	TruncateMode      string   `yaml:"truncate_mode,omitempty"`
+	AllowedTools      []string `yaml:"allowed_tools,omitempty"`
+	RequestsPerMinute int      `yaml:"requests_per_minute,omitempty"`
+}
+
+// LimitsSpec limits the runs of the agent
+type LimitsSpec struct {
+	MaxIterations        int            `yaml:"max_iterations,omitempty"`
+	MaxParallelToolCalls int            `yaml:"max_parallel_tool_calls,omitempty"`
+	MaxTokens            int            `yaml:"max_tokens,omitempty"`
+	MaxCost              float64        `yaml:"max_cost,omitempty"`
+	MaxToolCalls         int            `yaml:"max_tool_calls,omitempty"`
+	MaxCallsPerTool      map[string]int `yaml:"max_calls_per_tool,omitempty"`
+	MaxDuration          Duration       `yaml:"max_duration,omitempty"`
+}
+
+// Config is the configuration of a tool or memory factory
+type Config map[string]interface{}
+
+// Decode decodes the configuration into the struct pointed to by target,
+// using its yaml tags. Unknown fields are an error.
+func (c Config) Decode(target interface{}) error {
+	t := reflect.TypeOf(target)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return fmt.Errorf("config must be decoded into a pointer")
+	}
+	data, err := yaml.Marshal(map[string]interface{}(c))
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to decode config: %w", err)
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+	if issues := checkNode(node.Content[0], t.Elem(), ""); len(issues) > 0 {
+		return fmt.Errorf("invalid config: %s: %s", issues[0].Path, issues[0].Message)
+	}
+	return node.Content[0].Decode(target)
+}
+
+// Duration is a duration written as a string such as "90s" or "5m"
+type Duration time.Duration
+
+// UnmarshalYAML parses the duration
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	duration, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", node.Value)
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalYAML writes the duration as a string
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
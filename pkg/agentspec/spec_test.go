@@ -0,0 +1,188 @@
+package agentspec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `name: assistant
+description: Answers questions
+system_prompt: You are a helpful assistant.
+llm:
+  provider: openai
+  model: ${TEST_MODEL:-gpt-4o}
+  api_key: ${TEST_API_KEY}
+  temperature: 0.2
+tools:
+  - type: calculator
+  - type: websearch
+    config:
+      api_key: ${TEST_API_KEY}
+      engine_id: engine
+mcp_servers:
+  - name: files
+    type: stdio
+    command: mcp-files
+    tools:
+      - name: read_file
+        description: Read a file
+memory:
+  type: conversation_buffer
+  config:
+    max_size: 20
+guardrails:
+  - type: content_filter
+    action: block
+    blocked_words: [password]
+sub_agents:
+  - researcher.yaml
+limits:
+  max_iterations: 4
+  max_cost: 0.5
+  max_calls_per_tool:
+    websearch: 2
+  max_duration: 90s
+require_plan_approval: false
+`
+
+func TestParse(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "secret")
+
+	spec, err := Parse([]byte(testSpec), "assistant.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "assistant", spec.Name)
+	assert.Equal(t, "gpt-4o", spec.LLM.Model)
+	assert.Equal(t, "secret", spec.LLM.APIKey)
+	assert.Equal(t, 0.2, spec.LLM.Temperature)
+	require.Len(t, spec.Tools, 2)
+	assert.Equal(t, "secret", spec.Tools[1].Config["api_key"])
+	require.Len(t, spec.MCPServers, 1)
+	assert.Equal(t, "read_file", spec.MCPServers[0].Tools[0].Name)
+	assert.Equal(t, "conversation_buffer", spec.Memory.Type)
+	assert.Equal(t, []string{"password"}, spec.Guardrails[0].BlockedWords)
+	assert.Equal(t, []string{"researcher.yaml"}, spec.SubAgents)
+	assert.Equal(t, 4, spec.Limits.MaxIterations)
+	assert.Equal(t, map[string]int{"websearch": 2}, spec.Limits.MaxCallsPerTool)
+	assert.Equal(t, Duration(90*time.Second), spec.Limits.MaxDuration)
+	require.NotNil(t, spec.RequirePlanApproval)
+	assert.False(t, *spec.RequirePlanApproval)
+}
+
+func TestParseInterpolation(t *testing.T) {
+	t.Setenv("TEST_MODEL", "claude")
+	t.Setenv("TEST_EMPTY", "")
+
+	spec, err := Parse([]byte(`name: assistant
+system_prompt: Prices are in $$ and ${TEST_EMPTY:-euros}
+llm:
+  provider: anthropic
+  model: ${TEST_MODEL:-other}
+  api_key: key-${TEST_EMPTY}
+`), "")
+	require.NoError(t, err)
+	assert.Equal(t, "claude", spec.LLM.Model)
+	assert.Equal(t, "Prices are in $ and euros", spec.SystemPrompt)
+	assert.Equal(t, "key-", spec.LLM.APIKey)
+}
+
+func TestParseIssues(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		issue string
+	}{
+		{
+			name:  "unknown field",
+			spec:  "name: assistant\nllm:\n  provider: openai\n  modle: gpt-4o\n",
+			issue: "spec.yaml:4:3: llm.modle: unknown field",
+		},
+		{
+			name:  "wrong kind",
+			spec:  "name: assistant\nllm:\n  provider: openai\nlimits:\n  max_iterations: many\n",
+			issue: `spec.yaml:5:19: limits.max_iterations: expected an integer, got "many"`,
+		},
+		{
+			name:  "expected a list",
+			spec:  "name: assistant\nllm:\n  provider: openai\ntools: calculator\n",
+			issue: "spec.yaml:4:8: tools: expected a list",
+		},
+		{
+			name:  "invalid duration",
+			spec:  "name: assistant\nllm:\n  provider: openai\nlimits:\n  max_duration: soon\n",
+			issue: `spec.yaml:5:17: limits.max_duration: invalid duration "soon"`,
+		},
+		{
+			name:  "unset variable",
+			spec:  "name: assistant\nllm:\n  provider: openai\n  api_key: ${TEST_UNSET_KEY}\n",
+			issue: "spec.yaml:4:12: llm.api_key: environment variable TEST_UNSET_KEY is not set",
+		},
+		{
+			name:  "missing field",
+			spec:  "name: assistant\nllm:\n  model: gpt-4o\n",
+			issue: "spec.yaml:3:3: llm.provider: is required",
+		},
+		{
+			name:  "missing name",
+			spec:  "llm:\n  provider: openai\n",
+			issue: "spec.yaml:1:1: name: is required",
+		},
+		{
+			name:  "invalid action",
+			spec:  "name: assistant\nllm:\n  provider: openai\nguardrails:\n  - type: pii_filter\n    action: drop\n",
+			issue: "spec.yaml:6:13: guardrails[0].action: must be one of block, redact, warn",
+		},
+		{
+			name:  "missing command",
+			spec:  "name: assistant\nllm:\n  provider: openai\nmcp_servers:\n  - name: files\n    type: stdio\n",
+			issue: "spec.yaml:5:5: mcp_servers[0].command: is required for a stdio server",
+		},
+		{
+			name:  "negative limit",
+			spec:  "name: assistant\nllm:\n  provider: openai\nlimits:\n  max_tool_calls: -1\n",
+			issue: "spec.yaml:5:19: limits.max_tool_calls: cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.spec), "spec.yaml")
+			require.Error(t, err)
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			require.Len(t, validationErr.Issues, 1)
+			assert.Equal(t, tt.issue, validationErr.Issues[0].String())
+		})
+	}
+}
+
+func TestParseReportsAllIssues(t *testing.T) {
+	_, err := Parse([]byte("name: my assistant\nllm:\n  provider: openai\ntools:\n  - config: {}\n"), "spec.yaml")
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Issues, 2)
+	assert.Equal(t, "spec.yaml:1:7: name: may only contain letters, digits, underscores and hyphens", validationErr.Issues[0].String())
+	assert.Equal(t, "spec.yaml:5:5: tools[0].type: is required", validationErr.Issues[1].String())
+}
+
+func TestConfigDecode(t *testing.T) {
+	var params struct {
+		URL string   `yaml:"url"`
+		DB  int      `yaml:"db"`
+		TTL Duration `yaml:"ttl"`
+	}
+
+	require.NoError(t, Config{"url": "localhost:6379", "db": 2, "ttl": "1h"}.Decode(&params))
+	assert.Equal(t, "localhost:6379", params.URL)
+	assert.Equal(t, 2, params.DB)
+	assert.Equal(t, Duration(time.Hour), params.TTL)
+
+	err := Config{"url": "localhost:6379", "database": 2}.Decode(&params)
+	assert.EqualError(t, err, "invalid config: database: unknown field")
+}
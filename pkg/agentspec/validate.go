@@ -0,0 +1,170 @@
+package agentspec
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// namePattern matches the names of agents, which are used in the names of
+// the tools that call them as sub-agents
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+var (
+	mcpServerTypes = []string{"stdio", "http"}
+	mcpProtocols   = []string{"streamable", "sse"}
+	guardrailTypes = []string{"content_filter", "pii_filter", "token_limit", "tool_restriction", "rate_limit"}
+	actions        = []string{"block", "redact", "warn"}
+	truncateModes  = []string{"start", "middle", "end"}
+)
+
+// validate checks the values of the spec, which has the expected structure
+func (s *Spec) validate() []Issue {
+	var issues []Issue
+	report := func(path, format string, args ...interface{}) {
+		issues = append(issues, s.issue(path, fmt.Sprintf(format, args...)))
+	}
+
+	switch {
+	case s.Name == "":
+		report("name", "is required")
+	case !namePattern.MatchString(s.Name):
+		report("name", "may only contain letters, digits, underscores and hyphens")
+	}
+	if s.SystemPrompt != "" && (s.Role != "" || s.Goal != "" || s.Backstory != "") {
+		report("system_prompt", "cannot be set with role, goal or backstory")
+	}
+	if s.LLM.Provider == "" {
+		report("llm.provider", "is required")
+	}
+
+	for i, tool := range s.Tools {
+		if tool.Type == "" {
+			report(fmt.Sprintf("tools[%d].type", i), "is required")
+		}
+	}
+	if s.Memory != nil && s.Memory.Type == "" {
+		report("memory.type", "is required")
+	}
+
+	names := make(map[string]bool)
+	for i, server := range s.MCPServers {
+		path := fmt.Sprintf("mcp_servers[%d]", i)
+		switch {
+		case server.Name == "":
+			report(path+".name", "is required")
+		case names[server.Name]:
+			report(path+".name", "duplicate MCP server %q", server.Name)
+		}
+		names[server.Name] = true
+		if !slices.Contains(mcpServerTypes, server.Type) {
+			report(path+".type", "must be one of %s", strings.Join(mcpServerTypes, ", "))
+		}
+		if server.Type == "stdio" && server.Command == "" {
+			report(path+".command", "is required for a stdio server")
+		}
+		if server.Type == "http" && server.URL == "" {
+			report(path+".url", "is required for an http server")
+		}
+		if server.Protocol != "" && !slices.Contains(mcpProtocols, server.Protocol) {
+			report(path+".protocol", "must be one of %s", strings.Join(mcpProtocols, ", "))
+		}
+		// The servers whose tools are listed are started lazily, without
+		// authentication
+		if server.Token != "" && len(server.Tools) > 0 {
+			report(path+".token", "is not supported for a server whose tools are listed")
+		}
+		for j, tool := range server.Tools {
+			if tool.Name == "" {
+				report(fmt.Sprintf("%s.tools[%d].name", path, j), "is required")
+			}
+		}
+	}
+
+	for i, guardrail := range s.Guardrails {
+		path := fmt.Sprintf("guardrails[%d]", i)
+		if !slices.Contains(guardrailTypes, guardrail.Type) {
+			report(path+".type", "must be one of %s", strings.Join(guardrailTypes, ", "))
+		}
+		if !slices.Contains(actions, guardrail.Action) {
+			report(path+".action", "must be one of %s", strings.Join(actions, ", "))
+		}
+		switch guardrail.Type {
+		case "content_filter":
+			if len(guardrail.BlockedWords) == 0 {
+				report(path+".blocked_words", "is required for a content_filter guardrail")
+			}
+		case "token_limit":
+			if guardrail.MaxTokens <= 0 {
+				report(path+".max_tokens", "must be positive for a token_limit guardrail")
+			}
+			if guardrail.TruncateMode != "" && !slices.Contains(truncateModes, guardrail.TruncateMode) {
+				report(path+".truncate_mode", "must be one of %s", strings.Join(truncateModes, ", "))
+			}
+		case "tool_restriction":
+			if len(guardrail.AllowedTools) == 0 {
+				report(path+".allowed_tools", "is required for a tool_restriction guardrail")
+			}
+		case "rate_limit":
+			if guardrail.RequestsPerMinute <= 0 {
+				report(path+".requests_per_minute", "must be positive for a rate_limit guardrail")
+			}
+		}
+	}
+
+	for i, subAgent := range s.SubAgents {
+		if subAgent == "" {
+			report(fmt.Sprintf("sub_agents[%d]", i), "must be the path of a spec")
+		}
+	}
+
+	limits := map[string]float64{
+		"max_iterations":          float64(s.Limits.MaxIterations),
+		"max_parallel_tool_calls": float64(s.Limits.MaxParallelToolCalls),
+		"max_tokens":              float64(s.Limits.MaxTokens),
+		"max_cost":                s.Limits.MaxCost,
+		"max_tool_calls":          float64(s.Limits.MaxToolCalls),
+		"max_duration":            float64(s.Limits.MaxDuration),
+	}
+	for tool, calls := range s.Limits.MaxCallsPerTool {
+		limits["max_calls_per_tool."+tool] = float64(calls)
+	}
+	for _, name := range slices.Sorted(maps.Keys(limits)) {
+		if limits[name] < 0 {
+			report("limits."+name, "cannot be negative")
+		}
+	}
+
+	return issues
+}
+
+// issue returns an issue located at the value at path. A value that is
+// missing from the spec is located at the closest value that contains it.
+func (s *Spec) issue(path, message string) Issue {
+	issue := Issue{File: s.file, Path: path, Message: message}
+	for p := path; ; p = parentPath(p) {
+		if node, ok := s.nodes[p]; ok {
+			issue.Line, issue.Column = node.Line, node.Column
+			break
+		}
+		if p == "" {
+			break
+		}
+	}
+	return issue
+}
+
+// errorAt returns an error of the value at path, located in the spec
+func (s *Spec) errorAt(path string, err error) error {
+	return fmt.Errorf("%s: %w", s.issue(path, "").location(), err)
+}
+
+// parentPath returns the path of the value that contains the value at path
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+// Package checkpoint provides stores for the state of agent runs, used with
+// agent.WithCheckpointStore to resume runs that were interrupted.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// InMemoryStore keeps checkpoints in memory. It lets runs resume after an
+// error in the same process; use a persistent store to resume them after the
+// process exits.
+type InMemoryStore struct {
+	mu          sync.RWMutex
+	checkpoints map[string][]byte
+	claims      map[string]runClaim
+}
+
+// runClaim is the claim of an owner on a run
+type runClaim struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// NewInMemoryStore creates an empty in-memory checkpoint store
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		checkpoints: make(map[string][]byte),
+		claims:      make(map[string]runClaim),
+	}
+}
+
+// SaveCheckpoint creates or replaces the checkpoint of a run
+func (s *InMemoryStore) SaveCheckpoint(ctx context.Context, checkpoint interfaces.RunCheckpoint) error {
+	// Checkpoints are stored encoded, so that the caller can keep changing
+	// the state it saved
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[checkpoint.RunID] = data
+	return nil
+}
+
+// GetCheckpoint returns the checkpoint of a run
+func (s *InMemoryStore) GetCheckpoint(ctx context.Context, runID string) (*interfaces.RunCheckpoint, error) {
+	s.mu.RLock()
+	data, ok := s.checkpoints[runID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("run %s: %w", runID, interfaces.ErrCheckpointNotFound)
+	}
+	return decode(data)
+}
+
+// ListCheckpoints returns the checkpoints with the given status, oldest first
+func (s *InMemoryStore) ListCheckpoints(ctx context.Context, status interfaces.RunStatus) ([]interfaces.RunCheckpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var checkpoints []interfaces.RunCheckpoint
+	for _, data := range s.checkpoints {
+		checkpoint, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		if status == "" || checkpoint.Status == status {
+			checkpoints = append(checkpoints, *checkpoint)
+		}
+	}
+	sortByCreation(checkpoints)
+	return checkpoints, nil
+}
+
+// DeleteCheckpoint removes the checkpoint of a run
+func (s *InMemoryStore) DeleteCheckpoint(ctx context.Context, runID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkpoints, runID)
+	delete(s.claims, runID)
+	return nil
+}
+
+// ClaimRun claims a run for the owner, or renews its claim
+func (s *InMemoryStore) ClaimRun(ctx context.Context, runID, owner string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if claim, ok := s.claims[runID]; ok && claim.owner != owner && now.Before(claim.expiresAt) {
+		return fmt.Errorf("run %s: %w", runID, interfaces.ErrRunClaimed)
+	}
+	s.claims[runID] = runClaim{owner: owner, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// ReleaseRun releases the claim of the owner on a run
+func (s *InMemoryStore) ReleaseRun(ctx context.Context, runID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if claim, ok := s.claims[runID]; ok && claim.owner == owner {
+		delete(s.claims, runID)
+	}
+	return nil
+}
+
+// decode decodes a stored checkpoint
+func decode(data []byte) (*interfaces.RunCheckpoint, error) {
+	var checkpoint interfaces.RunCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// sortByCreation sorts checkpoints oldest first
+func sortByCreation(checkpoints []interfaces.RunCheckpoint) {
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+}
+
+var _ interfaces.CheckpointStore = (*InMemoryStore)(nil)
@@ -0,0 +1,191 @@
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// testStore checks that a store saves, lists and deletes checkpoints
+func testStore(t *testing.T, store interfaces.CheckpointStore) {
+	ctx := context.Background()
+	start := time.Now().Truncate(time.Millisecond)
+
+	first := interfaces.RunCheckpoint{
+		RunID:     "run-1",
+		AgentName: "billing",
+		Input:     "Charge 10",
+		Status:    interfaces.RunStatusRunning,
+		Messages: []interfaces.Message{
+			{Role: interfaces.MessageRoleUser, Content: "Charge 10"},
+			{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "call_1", Name: "charge", Arguments: `{"amount":10}`}}},
+		},
+		PendingToolCalls:   []interfaces.PendingToolCall{{ToolName: "charge", Arguments: `{"amount":10}`, IdempotencyKey: "run-1-abc-0", StartedAt: start}},
+		CompletedToolCalls: map[string]int{"def": 1},
+		Iteration:          1,
+		CreatedAt:          start,
+		UpdatedAt:          start,
+	}
+	second := interfaces.RunCheckpoint{
+		RunID:     "run-2",
+		Status:    interfaces.RunStatusCompleted,
+		Response:  "done",
+		Plan:      &interfaces.PlanProgress{TaskID: "task-1", Steps: []interfaces.PlanStepSpec{{ToolName: "fetch"}}, NextStep: 1},
+		CreatedAt: start.Add(time.Second),
+	}
+	for _, checkpoint := range []interfaces.RunCheckpoint{second, first} {
+		if err := store.SaveCheckpoint(ctx, checkpoint); err != nil {
+			t.Fatalf("SaveCheckpoint failed: %v", err)
+		}
+	}
+
+	// Changing the saved state does not change the checkpoint
+	first.Messages[0].Content = "changed"
+
+	got, err := store.GetCheckpoint(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("GetCheckpoint failed: %v", err)
+	}
+	if got.Messages[0].Content != "Charge 10" || got.Messages[1].ToolCalls[0].Name != "charge" {
+		t.Errorf("Unexpected messages: %+v", got.Messages)
+	}
+	if len(got.PendingToolCalls) != 1 || got.PendingToolCalls[0].IdempotencyKey != "run-1-abc-0" || got.CompletedToolCalls["def"] != 1 || got.Iteration != 1 {
+		t.Errorf("Unexpected tool call state: %+v", got)
+	}
+
+	all, err := store.ListCheckpoints(ctx, "")
+	if err != nil {
+		t.Fatalf("ListCheckpoints failed: %v", err)
+	}
+	if len(all) != 2 || all[0].RunID != "run-1" || all[1].Plan == nil || all[1].Plan.NextStep != 1 {
+		t.Errorf("Expected both checkpoints, oldest first, got %+v", all)
+	}
+	running, err := store.ListCheckpoints(ctx, interfaces.RunStatusRunning)
+	if err != nil || len(running) != 1 || running[0].RunID != "run-1" {
+		t.Errorf("Expected the running checkpoint, got %+v (%v)", running, err)
+	}
+
+	if err := store.DeleteCheckpoint(ctx, "run-1"); err != nil {
+		t.Fatalf("DeleteCheckpoint failed: %v", err)
+	}
+	if _, err := store.GetCheckpoint(ctx, "run-1"); !errors.Is(err, interfaces.ErrCheckpointNotFound) {
+		t.Errorf("Expected ErrCheckpointNotFound, got %v", err)
+	}
+}
+
+// testClaims checks that a store lets one owner at a time claim a run
+func testClaims(t *testing.T, store interfaces.CheckpointStore) {
+	ctx := context.Background()
+	if err := store.ClaimRun(ctx, "run-3", "first", time.Minute); err != nil {
+		t.Fatalf("ClaimRun failed: %v", err)
+	}
+	if err := store.ClaimRun(ctx, "run-3", "second", time.Minute); !errors.Is(err, interfaces.ErrRunClaimed) {
+		t.Errorf("Expected ErrRunClaimed for another owner, got %v", err)
+	}
+	if err := store.ClaimRun(ctx, "run-3", "first", time.Minute); err != nil {
+		t.Errorf("Expected the owner to renew its claim, got %v", err)
+	}
+
+	// Only the owner releases its claim
+	if err := store.ReleaseRun(ctx, "run-3", "second"); err != nil {
+		t.Fatalf("ReleaseRun failed: %v", err)
+	}
+	if err := store.ClaimRun(ctx, "run-3", "second", time.Minute); !errors.Is(err, interfaces.ErrRunClaimed) {
+		t.Errorf("Expected the claim to be kept, got %v", err)
+	}
+	if err := store.ReleaseRun(ctx, "run-3", "first"); err != nil {
+		t.Fatalf("ReleaseRun failed: %v", err)
+	}
+	if err := store.ClaimRun(ctx, "run-3", "second", time.Minute); err != nil {
+		t.Errorf("Expected the released run to be claimed, got %v", err)
+	}
+	if err := store.ReleaseRun(ctx, "run-3", "second"); err != nil {
+		t.Fatalf("ReleaseRun failed: %v", err)
+	}
+}
+
+func TestInMemoryStore(t *testing.T) {
+	store := NewInMemoryStore()
+	testStore(t, store)
+	testClaims(t, store)
+
+	// An expired claim is taken over
+	ctx := context.Background()
+	if err := store.ClaimRun(ctx, "run-4", "first", time.Millisecond); err != nil {
+		t.Fatalf("ClaimRun failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := store.ClaimRun(ctx, "run-4", "second", time.Minute); err != nil {
+		t.Errorf("Expected the expired claim to be taken over, got %v", err)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := NewRedisStore(client, WithKeyPrefix("test:"), WithTTL(time.Hour))
+	testStore(t, store)
+	testClaims(t, store)
+
+	// An expired claim is taken over
+	ctx := context.Background()
+	if err := store.ClaimRun(ctx, "run-4", "first", time.Minute); err != nil {
+		t.Fatalf("ClaimRun failed: %v", err)
+	}
+	server.FastForward(2 * time.Minute)
+	if err := store.ClaimRun(ctx, "run-4", "second", time.Minute); err != nil {
+		t.Errorf("Expected the expired claim to be taken over, got %v", err)
+	}
+
+	// Expired checkpoints are not listed
+	server.FastForward(2 * time.Hour)
+	checkpoints, err := store.ListCheckpoints(ctx, "")
+	if err != nil || len(checkpoints) != 0 {
+		t.Errorf("Expected no checkpoints after they expired, got %+v (%v)", checkpoints, err)
+	}
+	if members, _ := client.SMembers(ctx, "test:runs").Result(); len(members) != 0 {
+		t.Errorf("Expected expired runs to be removed from the index, got %v", members)
+	}
+}
+
+func TestPostgresStore(t *testing.T) {
+	if _, err := NewPostgresStore(nil, WithTableName("checkpoints; DROP TABLE users")); err == nil {
+		t.Error("Expected an invalid table name to be rejected")
+	}
+
+	dbURL := os.Getenv("POSTGRES_URL")
+	if dbURL == "" {
+		t.Skip("POSTGRES_URL environment variable not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	store, err := NewPostgresStore(db, WithTableName("test_agent_run_checkpoints"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.CreateTable(ctx); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	defer func() {
+		_, _ = db.ExecContext(ctx, "DROP TABLE test_agent_run_checkpoints, test_agent_run_checkpoints_claims")
+	}()
+
+	testStore(t, store)
+	testClaims(t, store)
+}
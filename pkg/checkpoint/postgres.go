@@ -0,0 +1,199 @@
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// tableNamePattern matches the table names accepted by the Postgres store,
+// which are inserted into its queries
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PostgresStore keeps checkpoints in a Postgres table, one row per run, and
+// the claims on runs in a second table named after it with a "_claims"
+// suffix. The caller opens the database with a Postgres driver such as
+// github.com/lib/pq.
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+// PostgresOption represents an option for configuring the Postgres store
+type PostgresOption func(*PostgresStore)
+
+// WithTableName sets the table of the checkpoints (default: "agent_run_checkpoints")
+func WithTableName(table string) PostgresOption {
+	return func(s *PostgresStore) {
+		s.table = table
+	}
+}
+
+// NewPostgresStore creates a checkpoint store backed by Postgres. Call
+// CreateTable to create its table if it does not exist.
+func NewPostgresStore(db *sql.DB, options ...PostgresOption) (*PostgresStore, error) {
+	store := &PostgresStore{
+		db:    db,
+		table: "agent_run_checkpoints",
+	}
+	for _, option := range options {
+		option(store)
+	}
+	if !tableNamePattern.MatchString(store.table) {
+		return nil, fmt.Errorf("invalid table name: %q", store.table)
+	}
+	return store, nil
+}
+
+// claimsTable returns the table of the claims on runs
+func (s *PostgresStore) claimsTable() string {
+	return s.table + "_claims"
+}
+
+// CreateTable creates the tables of the checkpoints and the claims on runs if
+// they do not exist
+func (s *PostgresStore) CreateTable(ctx context.Context) error {
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		run_id TEXT PRIMARY KEY,
+		agent_name TEXT NOT NULL DEFAULT '',
+		org_id TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		data JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`, s.table))
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint table: %w", err)
+	}
+
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		run_id TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`, s.claimsTable()))
+	if err != nil {
+		return fmt.Errorf("failed to create claim table: %w", err)
+	}
+	return nil
+}
+
+// SaveCheckpoint creates or replaces the checkpoint of a run
+func (s *PostgresStore) SaveCheckpoint(ctx context.Context, checkpoint interfaces.RunCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (run_id, agent_name, org_id, status, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (run_id) DO UPDATE SET
+			agent_name = EXCLUDED.agent_name,
+			org_id = EXCLUDED.org_id,
+			status = EXCLUDED.status,
+			data = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at`, s.table),
+		checkpoint.RunID, checkpoint.AgentName, checkpoint.OrgID, string(checkpoint.Status),
+		data, checkpoint.CreatedAt, checkpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint to Postgres: %w", err)
+	}
+	return nil
+}
+
+// GetCheckpoint returns the checkpoint of a run
+func (s *PostgresStore) GetCheckpoint(ctx context.Context, runID string) (*interfaces.RunCheckpoint, error) {
+	var data []byte
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT data FROM %s WHERE run_id = $1`, s.table), runID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("run %s: %w", runID, interfaces.ErrCheckpointNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint from Postgres: %w", err)
+	}
+	return decode(data)
+}
+
+// ListCheckpoints returns the checkpoints with the given status, oldest first
+func (s *PostgresStore) ListCheckpoints(ctx context.Context, status interfaces.RunStatus) ([]interfaces.RunCheckpoint, error) {
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT data FROM %s WHERE $1 = '' OR status = $1 ORDER BY created_at`, s.table), string(status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints from Postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []interfaces.RunCheckpoint
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan checkpoint: %w", err)
+		}
+		checkpoint, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints from Postgres: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// DeleteCheckpoint removes the checkpoint of a run
+func (s *PostgresStore) DeleteCheckpoint(ctx context.Context, runID string) error {
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE run_id = $1`, s.table), runID); err != nil {
+		return fmt.Errorf("failed to delete checkpoint from Postgres: %w", err)
+	}
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE run_id = $1`, s.claimsTable()), runID); err != nil {
+		return fmt.Errorf("failed to delete claim from Postgres: %w", err)
+	}
+	return nil
+}
+
+// ClaimRun claims a run for the owner, or renews its claim
+func (s *PostgresStore) ClaimRun(ctx context.Context, runID, owner string, ttl time.Duration) error {
+	now := time.Now()
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s AS claim (run_id, owner, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (run_id) DO UPDATE SET
+			owner = EXCLUDED.owner,
+			expires_at = EXCLUDED.expires_at
+		WHERE claim.owner = EXCLUDED.owner OR claim.expires_at < $4`, s.claimsTable()),
+		runID, owner, now.Add(ttl), now)
+	if err != nil {
+		return fmt.Errorf("failed to claim run in Postgres: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to claim run in Postgres: %w", err)
+	}
+	if claimed == 0 {
+		return fmt.Errorf("run %s: %w", runID, interfaces.ErrRunClaimed)
+	}
+	return nil
+}
+
+// ReleaseRun releases the claim of the owner on a run
+func (s *PostgresStore) ReleaseRun(ctx context.Context, runID, owner string) error {
+	// #nosec G201 - the table name is validated in NewPostgresStore
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE run_id = $1 AND owner = $2`, s.claimsTable()), runID, owner); err != nil {
+		return fmt.Errorf("failed to release run in Postgres: %w", err)
+	}
+	return nil
+}
+
+var _ interfaces.CheckpointStore = (*PostgresStore)(nil)
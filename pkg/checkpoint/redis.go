@@ -0,0 +1,191 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// claimScript sets the claim of an owner on a run unless another owner holds
+// it
+var claimScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner and owner ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// releaseScript deletes the claim on a run if the owner holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisStore keeps checkpoints in Redis, one key per run, with an index set
+// of the run IDs for listing. Claims on runs are keys that expire.
+type RedisStore struct {
+	client    *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// RedisOption represents an option for configuring the Redis store
+type RedisOption func(*RedisStore)
+
+// WithKeyPrefix sets the prefix of the Redis keys (default: "agent:checkpoint:")
+func WithKeyPrefix(prefix string) RedisOption {
+	return func(s *RedisStore) {
+		s.keyPrefix = prefix
+	}
+}
+
+// WithTTL sets how long checkpoints are kept after their last update
+// (default: 7 days; 0 keeps them until they are deleted)
+func WithTTL(ttl time.Duration) RedisOption {
+	return func(s *RedisStore) {
+		s.ttl = ttl
+	}
+}
+
+// NewRedisStore creates a checkpoint store backed by Redis
+func NewRedisStore(client *redis.Client, options ...RedisOption) *RedisStore {
+	store := &RedisStore{
+		client:    client,
+		keyPrefix: "agent:checkpoint:",
+		ttl:       7 * 24 * time.Hour,
+	}
+	for _, option := range options {
+		option(store)
+	}
+	return store
+}
+
+// key returns the key of the checkpoint of a run
+func (s *RedisStore) key(runID string) string {
+	return s.keyPrefix + "run:" + runID
+}
+
+// claimKey returns the key of the claim on a run
+func (s *RedisStore) claimKey(runID string) string {
+	return s.keyPrefix + "claim:" + runID
+}
+
+// indexKey returns the key of the set of run IDs
+func (s *RedisStore) indexKey() string {
+	return s.keyPrefix + "runs"
+}
+
+// SaveCheckpoint creates or replaces the checkpoint of a run
+func (s *RedisStore) SaveCheckpoint(ctx context.Context, checkpoint interfaces.RunCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.key(checkpoint.RunID), data, s.ttl)
+	pipe.SAdd(ctx, s.indexKey(), checkpoint.RunID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save checkpoint to Redis: %w", err)
+	}
+	return nil
+}
+
+// GetCheckpoint returns the checkpoint of a run
+func (s *RedisStore) GetCheckpoint(ctx context.Context, runID string) (*interfaces.RunCheckpoint, error) {
+	data, err := s.client.Get(ctx, s.key(runID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("run %s: %w", runID, interfaces.ErrCheckpointNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint from Redis: %w", err)
+	}
+	return decode(data)
+}
+
+// ListCheckpoints returns the checkpoints with the given status, oldest first.
+// Runs whose checkpoint expired are removed from the index.
+func (s *RedisStore) ListCheckpoints(ctx context.Context, status interfaces.RunStatus) ([]interfaces.RunCheckpoint, error) {
+	runIDs, err := s.client.SMembers(ctx, s.indexKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints from Redis: %w", err)
+	}
+	if len(runIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(runIDs))
+	for i, runID := range runIDs {
+		keys[i] = s.key(runID)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoints from Redis: %w", err)
+	}
+
+	var checkpoints []interfaces.RunCheckpoint
+	var expired []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, runIDs[i])
+			continue
+		}
+		checkpoint, err := decode([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		if status == "" || checkpoint.Status == status {
+			checkpoints = append(checkpoints, *checkpoint)
+		}
+	}
+	if len(expired) > 0 {
+		s.client.SRem(ctx, s.indexKey(), expired...)
+	}
+
+	sortByCreation(checkpoints)
+	return checkpoints, nil
+}
+
+// DeleteCheckpoint removes the checkpoint of a run
+func (s *RedisStore) DeleteCheckpoint(ctx context.Context, runID string) error {
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, s.key(runID), s.claimKey(runID))
+	pipe.SRem(ctx, s.indexKey(), runID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete checkpoint from Redis: %w", err)
+	}
+	return nil
+}
+
+// ClaimRun claims a run for the owner, or renews its claim
+func (s *RedisStore) ClaimRun(ctx context.Context, runID, owner string, ttl time.Duration) error {
+	claimed, err := claimScript.Run(ctx, s.client, []string{s.claimKey(runID)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to claim run in Redis: %w", err)
+	}
+	if claimed == 0 {
+		return fmt.Errorf("run %s: %w", runID, interfaces.ErrRunClaimed)
+	}
+	return nil
+}
+
+// ReleaseRun releases the claim of the owner on a run
+func (s *RedisStore) ReleaseRun(ctx context.Context, runID, owner string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{s.claimKey(runID)}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release run in Redis: %w", err)
+	}
+	return nil
+}
+
+var _ interfaces.CheckpointStore = (*RedisStore)(nil)
@@ -0,0 +1,131 @@
+package contextwindow
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+const (
+	// messageOverheadTokens covers the role and separators of each message
+	messageOverheadTokens = 4
+	// mediaPartTokens is the estimate for an image, file or audio part
+	mediaPartTokens = 1000
+)
+
+// TokenCounter counts the tokens in a text. It has the same method as
+// guardrails.TokenCounter, so exact tokenizers can be used for both.
+type TokenCounter interface {
+	CountTokens(text string) (int, error)
+}
+
+// EstimateCounter estimates token counts the way BPE tokenizers split text:
+// words are split into chunks of a few characters, and punctuation and CJK
+// characters count as a token each. It needs no tokenizer files and is
+// usually within 10-15% of the real count.
+type EstimateCounter struct {
+	charsPerToken float64
+}
+
+// NewTokenCounter returns an estimating counter tuned for the tokenizer of
+// the model's family
+func NewTokenCounter(model string) *EstimateCounter {
+	name := strings.ToLower(model)
+	charsPerToken := 4.0
+	switch {
+	case strings.Contains(name, "claude"):
+		charsPerToken = 3.5
+	case strings.Contains(name, "llama"), strings.Contains(name, "mistral"), strings.Contains(name, "mixtral"):
+		charsPerToken = 3.7
+	}
+	return &EstimateCounter{charsPerToken: charsPerToken}
+}
+
+// CountTokens estimates the number of tokens in text
+func (c *EstimateCounter) CountTokens(text string) (int, error) {
+	tokens := 0
+	run := 0
+	flush := func() {
+		if run > 0 {
+			tokens += int(math.Ceil(float64(run) / c.charsPerToken))
+			run = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			run++
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// CountMessage counts the tokens of a message, including its tool calls and
+// an estimate for media parts
+func CountMessage(counter TokenCounter, message interfaces.Message) (int, error) {
+	total := messageOverheadTokens
+
+	text := message.Content
+	if len(message.Parts) > 0 {
+		text = ""
+		for _, part := range message.Parts {
+			if part.Type == interfaces.ContentPartText {
+				text += part.Text + "\n"
+			} else {
+				total += mediaPartTokens
+			}
+		}
+	}
+	for _, call := range message.ToolCalls {
+		text += "\n" + call.Name + " " + call.Arguments
+	}
+
+	tokens, err := counter.CountTokens(text)
+	if err != nil {
+		return 0, err
+	}
+	return total + tokens, nil
+}
+
+// CountMessages counts the tokens of a list of messages
+func CountMessages(counter TokenCounter, messages []interfaces.Message) (int, error) {
+	total := 0
+	for _, message := range messages {
+		tokens, err := CountMessage(counter, message)
+		if err != nil {
+			return 0, err
+		}
+		total += tokens
+	}
+	return total, nil
+}
+
+// CountTools counts the tokens of tool definitions sent with a request
+func CountTools(counter TokenCounter, tools []interfaces.Tool) (int, error) {
+	total := 0
+	for _, tool := range tools {
+		parameters, err := json.Marshal(tool.Parameters())
+		if err != nil {
+			parameters = nil
+		}
+		tokens, err := counter.CountTokens(tool.Name() + "\n" + tool.Description() + "\n" + string(parameters))
+		if err != nil {
+			return 0, err
+		}
+		total += tokens + messageOverheadTokens
+	}
+	return total, nil
+}
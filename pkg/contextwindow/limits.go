@@ -0,0 +1,67 @@
+package contextwindow
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// DefaultContextWindow is used for models that are not in the table
+const DefaultContextWindow = 8192
+
+var contextWindowsMu sync.RWMutex
+
+// contextWindows maps model name prefixes registered with
+// RegisterContextWindow to context window sizes in tokens. They take
+// precedence over the model registry of the llm package.
+var contextWindows = map[string]int{}
+
+// ContextWindow returns the context window of a model in tokens, from the
+// sizes registered with RegisterContextWindow or else from llm.LookupModel.
+// Provider prefixes such as "models/" or "us.anthropic." are ignored. The
+// second result is false if the model is unknown, in which case
+// DefaultContextWindow is returned.
+func ContextWindow(model string) (int, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if size, ok := lookupContextWindow(name); ok {
+		return size, true
+	}
+	// Bedrock and Vertex names such as "anthropic.claude-3-5-sonnet"
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if size, ok := lookupContextWindow(name[i+1:]); ok {
+			return size, true
+		}
+	}
+	if info, ok := llm.LookupModel(model); ok && info.ContextWindow > 0 {
+		return info.ContextWindow, true
+	}
+	return DefaultContextWindow, false
+}
+
+// RegisterContextWindow sets the context window for model names starting with prefix
+func RegisterContextWindow(prefix string, tokens int) {
+	contextWindowsMu.Lock()
+	defer contextWindowsMu.Unlock()
+	contextWindows[strings.ToLower(prefix)] = tokens
+}
+
+func lookupContextWindow(name string) (int, bool) {
+	contextWindowsMu.RLock()
+	defer contextWindowsMu.RUnlock()
+
+	best := ""
+	for prefix := range contextWindows {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return 0, false
+	}
+	return contextWindows[best], true
+}
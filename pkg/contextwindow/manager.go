@@ -0,0 +1,154 @@
+package contextwindow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/logging"
+)
+
+// DefaultReservedOutputTokens is the number of tokens left for the response
+// by default, capped at a quarter of the context window
+const DefaultReservedOutputTokens = 4096
+
+// Manager fits conversation history into a model's context window. Before
+// each LLM call, the system prompt, tool definitions and reserved output
+// tokens are subtracted from the window, and the history is fitted into what
+// is left with a Strategy.
+type Manager struct {
+	maxTokens      int
+	reservedOutput int
+	counter        TokenCounter
+	strategy       Strategy
+	logger         logging.Logger
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithMaxTokens sets the context window size, overriding the size known for the model
+func WithMaxTokens(tokens int) Option {
+	return func(m *Manager) {
+		m.maxTokens = tokens
+	}
+}
+
+// WithReservedOutputTokens sets the number of tokens left for the response
+func WithReservedOutputTokens(tokens int) Option {
+	return func(m *Manager) {
+		m.reservedOutput = tokens
+	}
+}
+
+// WithTokenCounter sets the token counter, e.g. an exact tokenizer for the model
+func WithTokenCounter(counter TokenCounter) Option {
+	return func(m *Manager) {
+		m.counter = counter
+	}
+}
+
+// WithStrategy sets how history is fitted into the budget. The default is
+// DropToolResults.
+func WithStrategy(strategy Strategy) Option {
+	return func(m *Manager) {
+		m.strategy = strategy
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger logging.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager creates a context window manager for a model, using the
+// model's context window size and a token counter for its family
+func NewManager(model string, options ...Option) *Manager {
+	maxTokens, _ := ContextWindow(model)
+	m := &Manager{
+		maxTokens:      maxTokens,
+		reservedOutput: -1,
+		counter:        NewTokenCounter(model),
+		strategy:       DropToolResults(),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	if m.reservedOutput < 0 {
+		m.reservedOutput = min(DefaultReservedOutputTokens, m.maxTokens/4)
+	}
+	return m
+}
+
+// Budget returns the number of tokens left for the conversation history
+// after the system prompt, the tool definitions and the reserved output
+func (m *Manager) Budget(systemMessage string, tools []interfaces.Tool) (int, error) {
+	systemTokens, err := m.counter.CountTokens(systemMessage)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count system prompt tokens: %w", err)
+	}
+	toolTokens, err := CountTools(m.counter, tools)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tool tokens: %w", err)
+	}
+	return m.maxTokens - m.reservedOutput - systemTokens - toolTokens, nil
+}
+
+// Fit returns the history fitted into the budget left by the system prompt
+// and tools. Messages that already fit are returned unchanged.
+func (m *Manager) Fit(ctx context.Context, messages []interfaces.Message, systemMessage string, tools []interfaces.Tool) ([]interfaces.Message, error) {
+	budget, err := m.Budget(systemMessage, tools)
+	if err != nil {
+		return nil, err
+	}
+	used, err := CountMessages(m.counter, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count message tokens: %w", err)
+	}
+	if used <= budget {
+		return messages, nil
+	}
+
+	fitted, err := m.strategy.Fit(ctx, messages, budget, m.counter)
+	if err != nil {
+		return nil, err
+	}
+	if m.logger != nil {
+		fittedTokens, _ := CountMessages(m.counter, fitted)
+		m.logger.Debug(ctx, "Fitted conversation history to the context window", map[string]interface{}{
+			"budget":          budget,
+			"tokens_before":   used,
+			"tokens_after":    fittedTokens,
+			"messages_before": len(messages),
+			"messages_after":  len(fitted),
+		})
+	}
+	return fitted, nil
+}
+
+// Wrap returns a memory whose GetMessages fits the history of the wrapped
+// memory for a request with the given system prompt and tools. Pass it to an
+// LLM with interfaces.WithMemory; messages are still added to and cleared
+// from the wrapped memory, which keeps the full history.
+func (m *Manager) Wrap(memory interfaces.Memory, systemMessage string, tools []interfaces.Tool) interfaces.Memory {
+	return &fittedMemory{Memory: memory, manager: m, systemMessage: systemMessage, tools: tools}
+}
+
+// fittedMemory is a memory whose history is fitted to the context window
+type fittedMemory struct {
+	interfaces.Memory
+	manager       *Manager
+	systemMessage string
+	tools         []interfaces.Tool
+}
+
+// GetMessages returns the fitted history
+func (f *fittedMemory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	messages, err := f.Memory.GetMessages(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.manager.Fit(ctx, messages, f.systemMessage, f.tools)
+}
@@ -0,0 +1,136 @@
+package contextwindow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+type testTool struct{}
+
+func (testTool) Name() string        { return "search" }
+func (testTool) Description() string { return "Search the web for a query" }
+func (testTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{"query": {Type: "string", Description: "The query", Required: true}}
+}
+func (testTool) Run(ctx context.Context, input string) (string, error) { return "", nil }
+func (testTool) Execute(ctx context.Context, args string) (string, error) {
+	return "", nil
+}
+
+func TestEstimateCounter(t *testing.T) {
+	counter := NewTokenCounter("gpt-4o")
+
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"Hello world", 4},
+		{"Hello, world!", 6},
+		{"internationalization", 5},
+		{"你好世界", 4},
+	}
+	for _, tt := range tests {
+		tokens, err := counter.CountTokens(tt.text)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, tokens, tt.text)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected int
+		known    bool
+	}{
+		{"gpt-4o-mini", 128000, true},
+		{"gpt-4", 8192, true},
+		{"gpt-4.1-nano", 1047576, true},
+		{"claude-3-5-sonnet-20241022", 200000, true},
+		{"us.anthropic.claude-3-5-sonnet-20240620-v1:0", 200000, true},
+		{"models/gemini-1.5-pro-002", 2097152, true},
+		{"gemini-2.5-flash", 1048576, true},
+		{"llama3.1:8b", 131072, true},
+		{"unknown-model", DefaultContextWindow, false},
+	}
+	for _, tt := range tests {
+		size, known := ContextWindow(tt.model)
+		assert.Equal(t, tt.expected, size, tt.model)
+		assert.Equal(t, tt.known, known, tt.model)
+	}
+
+	RegisterContextWindow("my-finetune", 32000)
+	size, known := ContextWindow("my-finetune-v2")
+	assert.True(t, known)
+	assert.Equal(t, 32000, size)
+}
+
+func TestManagerBudget(t *testing.T) {
+	manager := NewManager("gpt-4")
+	budget, err := manager.Budget("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8192-2048, budget, "output reserve is capped at a quarter of the window")
+
+	withTools, err := manager.Budget("You are a helpful assistant.", []interfaces.Tool{testTool{}})
+	require.NoError(t, err)
+	assert.Less(t, withTools, budget)
+
+	manager = NewManager("gpt-4", WithMaxTokens(1000), WithReservedOutputTokens(100))
+	budget, err = manager.Budget("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 900, budget)
+}
+
+func TestManagerFit(t *testing.T) {
+	manager := NewManager("gpt-4", WithMaxTokens(200), WithReservedOutputTokens(0), WithStrategy(TruncateOldest()))
+
+	short := []interfaces.Message{{Role: interfaces.MessageRoleUser, Content: "Hi"}}
+	fitted, err := manager.Fit(context.Background(), short, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, short, fitted)
+
+	var long []interfaces.Message
+	for i := 0; i < 20; i++ {
+		long = append(long, interfaces.Message{Role: interfaces.MessageRoleUser, Content: strings.Repeat("word ", 20)})
+	}
+	long = append(long, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "latest question"})
+
+	fitted, err = manager.Fit(context.Background(), long, "", nil)
+	require.NoError(t, err)
+	tokens, err := CountMessages(manager.counter, fitted)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, tokens, 200)
+	assert.Equal(t, "latest question", fitted[len(fitted)-1].Content)
+}
+
+func TestWrap(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	mem := memory.NewConversationBuffer()
+	for i := 0; i < 50; i++ {
+		require.NoError(t, mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: strings.Repeat("word ", 20)}))
+	}
+
+	wrapped := NewManager("gpt-4", WithMaxTokens(300), WithReservedOutputTokens(0)).Wrap(mem, "", nil)
+	fitted, err := wrapped.GetMessages(ctx)
+	require.NoError(t, err)
+	assert.Less(t, len(fitted), 50)
+
+	all, err := mem.GetMessages(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 50, "the wrapped memory keeps the full history")
+
+	require.NoError(t, wrapped.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "new"}))
+	all, err = mem.GetMessages(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 51)
+}
@@ -0,0 +1,234 @@
+package contextwindow
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// omittedToolResult replaces the content of dropped tool results
+const omittedToolResult = "[tool result omitted to fit the context window]"
+
+// Strategy fits conversation history into a token budget. The last message,
+// normally the current user input, must always be kept.
+type Strategy interface {
+	Fit(ctx context.Context, messages []interfaces.Message, budget int, counter TokenCounter) ([]interfaces.Message, error)
+}
+
+// StrategyFunc adapts a function to the Strategy interface
+type StrategyFunc func(ctx context.Context, messages []interfaces.Message, budget int, counter TokenCounter) ([]interfaces.Message, error)
+
+// Fit calls f
+func (f StrategyFunc) Fit(ctx context.Context, messages []interfaces.Message, budget int, counter TokenCounter) ([]interfaces.Message, error) {
+	return f(ctx, messages, budget, counter)
+}
+
+// TruncateOldest drops the oldest messages until the history fits. System
+// messages in the history, such as summaries, are kept, and the history never
+// starts with tool results whose tool call was dropped.
+func TruncateOldest() Strategy {
+	return StrategyFunc(truncateOldest)
+}
+
+func truncateOldest(_ context.Context, messages []interfaces.Message, budget int, counter TokenCounter) ([]interfaces.Message, error) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	var pinned []interfaces.Message
+	var rest []interfaces.Message
+	used := 0
+	for i, message := range messages {
+		if message.Role == interfaces.MessageRoleSystem && i < len(messages)-1 {
+			tokens, err := CountMessage(counter, message)
+			if err != nil {
+				return nil, err
+			}
+			pinned = append(pinned, message)
+			used += tokens
+			continue
+		}
+		rest = append(rest, message)
+	}
+
+	// Keep the longest suffix that fits, but always the last message
+	start := len(rest) - 1
+	lastTokens, err := CountMessage(counter, rest[start])
+	if err != nil {
+		return nil, err
+	}
+	used += lastTokens
+	for start > 0 {
+		tokens, err := CountMessage(counter, rest[start-1])
+		if err != nil {
+			return nil, err
+		}
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		start--
+	}
+	for start < len(rest)-1 && rest[start].Role == interfaces.MessageRoleTool {
+		start++
+	}
+
+	return append(pinned, rest[start:]...), nil
+}
+
+// DropToolResults replaces the content of the oldest tool results with a short
+// note until the history fits, keeping the tool calls themselves. If that is
+// not enough, the oldest messages are dropped as with TruncateOldest.
+func DropToolResults() Strategy {
+	return StrategyFunc(func(ctx context.Context, messages []interfaces.Message, budget int, counter TokenCounter) ([]interfaces.Message, error) {
+		used, err := CountMessages(counter, messages)
+		if err != nil {
+			return nil, err
+		}
+
+		fitted := make([]interfaces.Message, len(messages))
+		copy(fitted, messages)
+		for i := 0; i < len(fitted)-1 && used > budget; i++ {
+			if fitted[i].Role != interfaces.MessageRoleTool || fitted[i].Content == omittedToolResult {
+				continue
+			}
+			before, err := CountMessage(counter, fitted[i])
+			if err != nil {
+				return nil, err
+			}
+			fitted[i].Content = omittedToolResult
+			fitted[i].Parts = nil
+			after, err := CountMessage(counter, fitted[i])
+			if err != nil {
+				return nil, err
+			}
+			used -= before - after
+		}
+
+		if used <= budget {
+			return fitted, nil
+		}
+		return truncateOldest(ctx, fitted, budget, counter)
+	})
+}
+
+// Summarizer summarizes the older part of a conversation with an LLM
+type Summarizer struct {
+	llm      interfaces.LLM
+	fraction float64
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// Summarize returns a strategy that replaces the older messages that do not
+// fit with a summary written by the LLM, sent as a system message. Roughly
+// fraction of the budget (0.25 if not in (0, 1)) is left for the summary.
+// Summaries are cached, so the same history is only summarized once.
+func Summarize(llm interfaces.LLM, fraction float64) *Summarizer {
+	if fraction <= 0 || fraction >= 1 {
+		fraction = 0.25
+	}
+	return &Summarizer{llm: llm, fraction: fraction, cache: make(map[string]string)}
+}
+
+// Fit implements Strategy
+func (s *Summarizer) Fit(ctx context.Context, messages []interfaces.Message, budget int, counter TokenCounter) ([]interfaces.Message, error) {
+	used, err := CountMessages(counter, messages)
+	if err != nil {
+		return nil, err
+	}
+	if used <= budget || len(messages) < 2 {
+		return truncateOldest(ctx, messages, budget, counter)
+	}
+
+	// Keep the most recent messages that fit in the rest of the budget
+	recent, err := truncateOldest(ctx, messages, budget-int(float64(budget)*s.fraction), counter)
+	if err != nil {
+		return nil, err
+	}
+	older := olderMessages(messages, recent)
+	if len(older) == 0 {
+		return recent, nil
+	}
+
+	summary, err := s.summarize(ctx, older, int(float64(budget)*s.fraction))
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize conversation history: %w", err)
+	}
+
+	fitted := append([]interfaces.Message{{
+		Role:    interfaces.MessageRoleSystem,
+		Content: "Summary of the earlier conversation:\n" + summary,
+	}}, withoutSystemMessages(recent)...)
+	return truncateOldest(ctx, fitted, budget, counter)
+}
+
+// summarize returns the cached summary of the messages or asks the LLM for one
+func (s *Summarizer) summarize(ctx context.Context, messages []interfaces.Message, maxTokens int) (string, error) {
+	var transcript strings.Builder
+	for _, message := range messages {
+		content := message.Content
+		for _, call := range message.ToolCalls {
+			content += fmt.Sprintf("\n[called %s with %s]", call.Name, call.Arguments)
+		}
+		transcript.WriteString(fmt.Sprintf("%s: %s\n", message.Role, content))
+	}
+
+	hash := sha256.Sum256([]byte(transcript.String()))
+	key := hex.EncodeToString(hash[:])
+	s.mu.Lock()
+	summary, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return summary, nil
+	}
+
+	// Roughly 0.75 words per token
+	prompt := fmt.Sprintf("Summarize the following conversation in at most %d words. Keep names, numbers, decisions, "+
+		"open questions and the results of tool calls that may be needed later.\n\n%s", maxTokens*3/4, transcript.String())
+	summary, err := s.llm.Generate(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.cache[key] = summary
+	s.mu.Unlock()
+	return summary, nil
+}
+
+// olderMessages returns the messages that were dropped from recent, along
+// with the system messages of the history so that they are summarized too
+func olderMessages(messages, recent []interfaces.Message) []interfaces.Message {
+	dropped := len(withoutSystemMessages(messages)) - len(withoutSystemMessages(recent))
+	if dropped == 0 {
+		return nil
+	}
+
+	var older []interfaces.Message
+	for _, message := range messages {
+		if message.Role == interfaces.MessageRoleSystem {
+			older = append(older, message)
+		} else if dropped > 0 {
+			older = append(older, message)
+			dropped--
+		}
+	}
+	return older
+}
+
+func withoutSystemMessages(messages []interfaces.Message) []interfaces.Message {
+	var result []interfaces.Message
+	for _, message := range messages {
+		if message.Role != interfaces.MessageRoleSystem {
+			result = append(result, message)
+		}
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package contextwindow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// wordCounter counts one token per word, so budgets are easy to reason about
+type wordCounter struct{}
+
+func (wordCounter) CountTokens(text string) (int, error) {
+	return len(strings.Fields(text)), nil
+}
+
+type summaryLLM struct {
+	calls int
+}
+
+func (s *summaryLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	s.calls++
+	return "the user asked about the weather", nil
+}
+
+func (s *summaryLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return s.Generate(ctx, prompt, options...)
+}
+
+func (s *summaryLLM) Name() string            { return "summary" }
+func (s *summaryLLM) SupportsStreaming() bool { return false }
+
+func words(n int) string {
+	return strings.TrimSpace(strings.Repeat("w ", n))
+}
+
+func conversation() []interfaces.Message {
+	return []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: words(6)},
+		{Role: interfaces.MessageRoleUser, Content: words(16)},
+		{Role: interfaces.MessageRoleAssistant, Content: words(6), ToolCalls: []interfaces.ToolCall{{ID: "1", Name: "weather", Arguments: "{}"}}},
+		{Role: interfaces.MessageRoleTool, Content: words(96), ToolCallID: "1"},
+		{Role: interfaces.MessageRoleAssistant, Content: words(16)},
+		{Role: interfaces.MessageRoleUser, Content: words(6)},
+	}
+}
+
+func TestTruncateOldest(t *testing.T) {
+	// Each message costs 4 tokens of overhead: 10, 20, 12, 100, 20 and 10 tokens
+	fitted, err := TruncateOldest().Fit(context.Background(), conversation(), 45, wordCounter{})
+	require.NoError(t, err)
+	require.Len(t, fitted, 3)
+	assert.Equal(t, interfaces.MessageRoleSystem, fitted[0].Role, "system messages are kept")
+	assert.Equal(t, conversation()[4:], fitted[1:])
+
+	// A budget that would start the history with a tool result drops it too
+	fitted, err = TruncateOldest().Fit(context.Background(), conversation(), 145, wordCounter{})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces.MessageRoleAssistant, fitted[1].Role)
+
+	// The last message is kept even if it alone exceeds the budget
+	fitted, err = TruncateOldest().Fit(context.Background(), conversation(), 0, wordCounter{})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces.MessageRoleUser, fitted[len(fitted)-1].Role)
+}
+
+func TestDropToolResults(t *testing.T) {
+	fitted, err := DropToolResults().Fit(context.Background(), conversation(), 100, wordCounter{})
+	require.NoError(t, err)
+	require.Len(t, fitted, 6, "dropping the tool result is enough")
+	assert.Equal(t, omittedToolResult, fitted[3].Content)
+	assert.Equal(t, "1", fitted[3].ToolCallID)
+	assert.Equal(t, words(96), conversation()[3].Content, "the input is not modified")
+
+	fitted, err = DropToolResults().Fit(context.Background(), conversation(), 45, wordCounter{})
+	require.NoError(t, err)
+	assert.Len(t, fitted, 3, "falls back to truncation")
+}
+
+func TestSummarize(t *testing.T) {
+	llm := &summaryLLM{}
+	strategy := Summarize(llm, 0.25)
+
+	fitted, err := strategy.Fit(context.Background(), conversation(), 80, wordCounter{})
+	require.NoError(t, err)
+	require.NotEmpty(t, fitted)
+	assert.Equal(t, interfaces.MessageRoleSystem, fitted[0].Role)
+	assert.Contains(t, fitted[0].Content, "the user asked about the weather")
+	assert.Equal(t, conversation()[5], fitted[len(fitted)-1])
+	assert.Equal(t, 1, llm.calls)
+
+	_, err = strategy.Fit(context.Background(), conversation(), 80, wordCounter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, llm.calls, "the summary is cached")
+
+	fitted, err = strategy.Fit(context.Background(), conversation(), 1000, wordCounter{})
+	require.NoError(t, err)
+	assert.Equal(t, conversation(), fitted)
+	assert.Equal(t, 1, llm.calls)
+}
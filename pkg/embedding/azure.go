@@ -0,0 +1,32 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go/option"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used unless one is
+// set with option.WithQuery("api-version", ...)
+const DefaultAzureAPIVersion = "2024-08-01-preview"
+
+// NewAzureOpenAIEmbedder creates an embedder for an Azure OpenAI embedding
+// deployment. baseURL is the resource endpoint, such as
+// "https://your-resource.openai.azure.com". config.Model is sent as the model
+// name and defaults to the deployment name. Request options such as
+// option.WithHTTPClient are applied after the defaults.
+func NewAzureOpenAIEmbedder(apiKey, baseURL, deployment string, config EmbeddingConfig, opts ...option.RequestOption) *OpenAIEmbedder {
+	if config.Model == "" {
+		config.Model = deployment
+	}
+
+	deploymentURL := fmt.Sprintf("%s/openai/deployments/%s", strings.TrimSuffix(baseURL, "/"), deployment)
+	options := []option.RequestOption{
+		option.WithAPIKey(apiKey),
+		option.WithHeader("api-key", apiKey),
+		option.WithBaseURL(deploymentURL),
+		option.WithQuery("api-version", DefaultAzureAPIVersion),
+	}
+	return newOpenAIEmbedder(config, openAIMaxBatchSize, append(options, opts...)...)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
@@ -35,6 +36,16 @@ type EmbeddingConfig struct {
 
 	// UserID is an optional identifier for tracking embedding usage
 	UserID string
+
+	// BatchSize is the maximum number of texts sent in one request by
+	// EmbedBatch. Larger batches are split into several requests. Zero uses
+	// the provider's limit.
+	BatchSize int
+
+	// TaskType describes what the embeddings are used for, such as
+	// "RETRIEVAL_DOCUMENT" or "RETRIEVAL_QUERY"
+	// Only supported by Gemini
+	TaskType string
 }
 
 // DefaultEmbeddingConfig returns a default configuration for embedding generation
@@ -72,22 +83,21 @@ type Client interface {
 	CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error)
 }
 
-// OpenAIEmbedder implements embedding generation using OpenAI API
+// openAIMaxBatchSize is the maximum number of inputs of an OpenAI embeddings request
+const openAIMaxBatchSize = 2048
+
+// OpenAIEmbedder implements embedding generation using OpenAI API and
+// OpenAI-compatible APIs such as Azure OpenAI and vLLM
 type OpenAIEmbedder struct {
-	client openai.Client
-	model  string
-	config EmbeddingConfig
+	client       openai.Client
+	model        string
+	config       EmbeddingConfig
+	maxBatchSize int
 }
 
 // NewOpenAIEmbedder creates a new OpenAIEmbedder instance with default configuration
 func NewOpenAIEmbedder(apiKey, model string) *OpenAIEmbedder {
-	config := DefaultEmbeddingConfig(model)
-
-	return &OpenAIEmbedder{
-		client: openai.NewClient(option.WithAPIKey(apiKey)),
-		model:  config.Model,
-		config: config,
-	}
+	return newOpenAIEmbedder(DefaultEmbeddingConfig(model), openAIMaxBatchSize, option.WithAPIKey(apiKey))
 }
 
 // NewOpenAIEmbedderWithConfig creates a new OpenAIEmbedder with custom configuration
@@ -97,10 +107,16 @@ func NewOpenAIEmbedderWithConfig(apiKey string, config EmbeddingConfig) *OpenAIE
 		config.Model = "text-embedding-3-small" // Default model if not specified
 	}
 
+	return newOpenAIEmbedder(config, openAIMaxBatchSize, option.WithAPIKey(apiKey))
+}
+
+// newOpenAIEmbedder creates an OpenAIEmbedder for any OpenAI-compatible API
+func newOpenAIEmbedder(config EmbeddingConfig, maxBatchSize int, opts ...option.RequestOption) *OpenAIEmbedder {
 	return &OpenAIEmbedder{
-		client: openai.NewClient(option.WithAPIKey(apiKey)),
-		model:  config.Model,
-		config: config,
+		client:       openai.NewClient(opts...),
+		model:        config.Model,
+		config:       config,
+		maxBatchSize: maxBatchSize,
 	}
 }
 
@@ -152,12 +168,16 @@ func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	return e.EmbedBatchWithConfig(ctx, texts, e.config)
 }
 
-// EmbedBatchWithConfig generates embeddings for multiple texts with custom configuration
+// EmbedBatchWithConfig generates embeddings for multiple texts with custom
+// configuration, in requests of at most config.BatchSize texts
 func (e *OpenAIEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
-	if len(texts) == 0 {
-		return [][]float32{}, nil
-	}
+	return embedInBatches(ctx, texts, batchSize(config, e.maxBatchSize), func(ctx context.Context, texts []string) ([][]float32, error) {
+		return e.embedBatch(ctx, texts, config)
+	})
+}
 
+// embedBatch generates embeddings for texts in a single request
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
 	req := openai.EmbeddingNewParams{
 		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
 		Model: openai.EmbeddingModel(config.Model),
@@ -204,16 +224,21 @@ func (e *OpenAIEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []strin
 
 // CalculateSimilarity calculates the similarity between two embeddings
 func (e *OpenAIEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
-	if len(vec1) != len(vec2) {
-		return 0, errors.New("embedding vectors must have the same dimensions")
-	}
-
 	if metric == "" {
 		metric = e.config.SimilarityMetric
 	}
+	return calculateSimilarity(vec1, vec2, metric)
+}
+
+// calculateSimilarity calculates the similarity between two embeddings using
+// the given metric
+func calculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	if len(vec1) != len(vec2) {
+		return 0, errors.New("embedding vectors must have the same dimensions")
+	}
 
 	switch metric {
-	case "cosine":
+	case "cosine", "":
 		return cosineSimilarity(vec1, vec2), nil
 	case "euclidean":
 		return euclideanDistance(vec1, vec2), nil
@@ -224,6 +249,41 @@ func (e *OpenAIEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string
 	}
 }
 
+// batchSize returns the number of texts to send per request: the configured
+// batch size, capped at the provider's limit
+func batchSize(config EmbeddingConfig, maxBatchSize int) int {
+	if config.BatchSize > 0 && (maxBatchSize <= 0 || config.BatchSize < maxBatchSize) {
+		return config.BatchSize
+	}
+	return maxBatchSize
+}
+
+// embedInBatches splits texts into batches of at most size texts, embeds each
+// batch with embed and returns the embeddings in the order of texts. A size of
+// zero or less sends all texts in one batch.
+func embedInBatches(ctx context.Context, texts []string, size int, embed func(ctx context.Context, texts []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	if size <= 0 {
+		size = len(texts)
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		batch, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed texts %d to %d: %w", start, end-1, err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batch))
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
 // cosineSimilarity calculates the cosine similarity between two vectors
 func cosineSimilarity(vec1, vec2 []float32) float32 {
 	var dotProd, mag1, mag2 float32
@@ -271,3 +331,12 @@ func dotProduct(vec1, vec2 []float32) float32 {
 func (e *OpenAIEmbedder) GetConfig() EmbeddingConfig {
 	return e.config
 }
+
+var (
+	_ Client              = (*OpenAIEmbedder)(nil)
+	_ Client              = (*OllamaEmbedder)(nil)
+	_ Client              = (*GeminiEmbedder)(nil)
+	_ interfaces.Embedder = (*OpenAIEmbedder)(nil)
+	_ interfaces.Embedder = (*OllamaEmbedder)(nil)
+	_ interfaces.Embedder = (*GeminiEmbedder)(nil)
+)
@@ -0,0 +1,199 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestEmbedInBatches(t *testing.T) {
+	var sizes []int
+	embed := func(ctx context.Context, texts []string) ([][]float32, error) {
+		sizes = append(sizes, len(texts))
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float32{float32(len(text))}
+		}
+		return embeddings, nil
+	}
+
+	embeddings, err := embedInBatches(context.Background(), []string{"a", "bb", "ccc", "dddd", "eeeee"}, 2, embed)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+	assert.Equal(t, [][]float32{{1}, {2}, {3}, {4}, {5}}, embeddings)
+
+	embeddings, err = embedInBatches(context.Background(), nil, 2, embed)
+	require.NoError(t, err)
+	assert.Empty(t, embeddings)
+
+	assert.Equal(t, 10, batchSize(EmbeddingConfig{BatchSize: 10}, 100))
+	assert.Equal(t, 100, batchSize(EmbeddingConfig{BatchSize: 1000}, 100))
+	assert.Equal(t, 100, batchSize(EmbeddingConfig{}, 100))
+}
+
+func TestOllamaEmbedder(t *testing.T) {
+	var requests []ollamaEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embed", r.URL.Path)
+		var req ollamaEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		if req.Input[0] == "fail" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+			return
+		}
+		embeddings := make([][]float32, len(req.Input))
+		for i := range req.Input {
+			embeddings[i] = []float32{float32(len(requests)), float32(i)}
+		}
+		_ = json.NewEncoder(w).Encode(ollamaEmbedResponse{Model: req.Model, Embeddings: embeddings})
+	}))
+	defer server.Close()
+
+	config := DefaultEmbeddingConfig("nomic-embed-text")
+	config.Dimensions = 256
+	config.BatchSize = 2
+	embedder := NewOllamaEmbedder(server.URL, config)
+
+	embeddings, err := embedder.EmbedBatch(context.Background(), []string{"one", "two", "three"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {1, 1}, {2, 0}}, embeddings)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "nomic-embed-text", requests[0].Model)
+	assert.Equal(t, 256, requests[0].Dimensions)
+	require.NotNil(t, requests[0].Truncate)
+	assert.True(t, *requests[0].Truncate)
+
+	config.Truncation = "none"
+	_, err = embedder.EmbedWithConfig(context.Background(), "four", config)
+	require.NoError(t, err)
+	assert.False(t, *requests[2].Truncate)
+
+	_, err = embedder.Embed(context.Background(), "fail")
+	assert.True(t, errors.Is(err, interfaces.ErrInvalidRequest), err)
+}
+
+// openAIEmbeddingHandler answers OpenAI-style embedding requests and records them
+func openAIEmbeddingHandler(t *testing.T, requests *[]*http.Request, bodies *[]map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		*requests = append(*requests, r)
+		*bodies = append(*bodies, body)
+
+		inputs, _ := body["input"].([]interface{})
+		if inputs == nil {
+			inputs = []interface{}{body["input"]}
+		}
+		data := make([]map[string]interface{}, len(inputs))
+		for i := range inputs {
+			// Answer out of order to check that embeddings are sorted by index
+			index := len(inputs) - 1 - i
+			data[i] = map[string]interface{}{"object": "embedding", "index": index, "embedding": []float64{float64(index), 0.5}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": data, "model": body["model"]})
+	}
+}
+
+func TestVLLMEmbedder(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-should-not-be-sent")
+
+	var requests []*http.Request
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(openAIEmbeddingHandler(t, &requests, &bodies))
+	defer server.Close()
+
+	embedder := NewVLLMEmbedder(server.URL, EmbeddingConfig{Model: "BAAI/bge-m3", Dimensions: 512, BatchSize: 2})
+	embeddings, err := embedder.EmbedBatch(context.Background(), []string{"one", "two", "three"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0, 0.5}, {1, 0.5}, {0, 0.5}}, embeddings)
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/v1/embeddings", requests[0].URL.Path)
+	assert.NotContains(t, requests[0].Header.Get("Authorization"), "sk-should-not-be-sent")
+	assert.Equal(t, "BAAI/bge-m3", bodies[0]["model"])
+	assert.Equal(t, float64(512), bodies[0]["dimensions"])
+}
+
+func TestAzureOpenAIEmbedder(t *testing.T) {
+	var requests []*http.Request
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(openAIEmbeddingHandler(t, &requests, &bodies))
+	defer server.Close()
+
+	embedder := NewAzureOpenAIEmbedder("azure-key", server.URL+"/", "embeddings-large", EmbeddingConfig{Dimensions: 1024})
+	embedding, err := embedder.Embed(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0, 0.5}, embedding)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/openai/deployments/embeddings-large/embeddings", requests[0].URL.Path)
+	assert.Equal(t, DefaultAzureAPIVersion, requests[0].URL.Query().Get("api-version"))
+	assert.Equal(t, "azure-key", requests[0].Header.Get("api-key"))
+	assert.Equal(t, "embeddings-large", bodies[0]["model"])
+	assert.Equal(t, float64(1024), bodies[0]["dimensions"])
+}
+
+func TestGeminiEmbedder(t *testing.T) {
+	var paths []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+
+		requests, _ := body["requests"].([]interface{})
+		embeddings := make([]map[string]interface{}, len(requests))
+		for i := range requests {
+			embeddings[i] = map[string]interface{}{"values": []float32{float32(i), 1}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}))
+	defer server.Close()
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	require.NoError(t, err)
+
+	embedder := NewGeminiEmbedderWithClient(client, EmbeddingConfig{Dimensions: 768, TaskType: "RETRIEVAL_DOCUMENT", BatchSize: 2})
+	embeddings, err := embedder.EmbedBatch(context.Background(), []string{"one", "two", "three"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0, 1}, {1, 1}, {0, 1}}, embeddings)
+
+	require.Len(t, paths, 2)
+	assert.True(t, strings.HasSuffix(paths[0], "models/gemini-embedding-001:batchEmbedContents"), paths[0])
+	request := bodies[0]["requests"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(768), request["outputDimensionality"])
+	assert.Equal(t, "RETRIEVAL_DOCUMENT", request["taskType"])
+}
+
+func TestCalculateSimilarity(t *testing.T) {
+	embedder := NewOllamaEmbedder("", DefaultEmbeddingConfig("nomic-embed-text"))
+
+	similarity, err := embedder.CalculateSimilarity([]float32{1, 0}, []float32{1, 0}, "")
+	require.NoError(t, err)
+	assert.InDelta(t, 1, similarity, 1e-6)
+
+	_, err = embedder.CalculateSimilarity([]float32{1, 0}, []float32{1}, "cosine")
+	assert.Error(t, err)
+	_, err = embedder.CalculateSimilarity([]float32{1}, []float32{1}, "manhattan")
+	assert.Error(t, err)
+}
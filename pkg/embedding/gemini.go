@@ -0,0 +1,127 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// geminiMaxBatchSize is the maximum number of texts of a Gemini API
+// embedding request
+const geminiMaxBatchSize = 100
+
+// GeminiEmbedder implements embedding generation using the Gemini API or
+// Vertex AI, for models such as "gemini-embedding-001" or "text-embedding-005"
+type GeminiEmbedder struct {
+	client *genai.Client
+	config EmbeddingConfig
+}
+
+// NewGeminiEmbedder creates an embedder that uses the Gemini API with an API key
+func NewGeminiEmbedder(ctx context.Context, apiKey string, config EmbeddingConfig) (*GeminiEmbedder, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key is required for Gemini API backend")
+	}
+
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	return NewGeminiEmbedderWithClient(client, config), nil
+}
+
+// NewGeminiEmbedderWithClient creates an embedder that uses an existing genai
+// client, for example one configured for Vertex AI with a project, location
+// and credentials. Some Vertex AI models, such as gemini-embedding-001, only
+// accept one text per request and need a BatchSize of 1.
+func NewGeminiEmbedderWithClient(client *genai.Client, config EmbeddingConfig) *GeminiEmbedder {
+	if config.Model == "" {
+		config.Model = "gemini-embedding-001"
+	}
+
+	return &GeminiEmbedder{
+		client: client,
+		config: config,
+	}
+}
+
+// Embed generates an embedding with the default configuration
+func (e *GeminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedWithConfig(ctx, text, e.config)
+}
+
+// EmbedWithConfig generates an embedding with custom configuration
+func (e *GeminiEmbedder) EmbedWithConfig(ctx context.Context, text string, config EmbeddingConfig) ([]float32, error) {
+	embeddings, err := e.embedBatch(ctx, []string{text}, config)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts with the default configuration
+func (e *GeminiEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.EmbedBatchWithConfig(ctx, texts, e.config)
+}
+
+// EmbedBatchWithConfig generates embeddings for multiple texts with custom
+// configuration, in requests of at most config.BatchSize texts
+func (e *GeminiEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	return embedInBatches(ctx, texts, batchSize(config, geminiMaxBatchSize), func(ctx context.Context, texts []string) ([][]float32, error) {
+		return e.embedBatch(ctx, texts, config)
+	})
+}
+
+// embedBatch generates embeddings for texts in a single request
+func (e *GeminiEmbedder) embedBatch(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+
+	embedConfig := &genai.EmbedContentConfig{
+		TaskType: config.TaskType,
+	}
+	if config.Dimensions > 0 {
+		dimensions := int32(config.Dimensions)
+		embedConfig.OutputDimensionality = &dimensions
+	}
+	// Only Vertex AI can truncate; the Gemini API rejects the option
+	if e.client.ClientConfig().Backend == genai.BackendVertexAI {
+		embedConfig.AutoTruncate = config.Truncation == "truncate"
+	}
+
+	resp, err := e.client.Models.EmbedContent(ctx, config.Model, contents, embedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	embeddings := make([][]float32, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+		embeddings[i] = embedding.Values
+	}
+	return embeddings, nil
+}
+
+// CalculateSimilarity calculates the similarity between two embeddings
+func (e *GeminiEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	if metric == "" {
+		metric = e.config.SimilarityMetric
+	}
+	return calculateSimilarity(vec1, vec2, metric)
+}
+
+// GetConfig returns the current configuration
+func (e *GeminiEmbedder) GetConfig() EmbeddingConfig {
+	return e.config
+}
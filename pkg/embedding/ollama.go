@@ -0,0 +1,144 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// ollamaMaxBatchSize bounds the size of Ollama embedding requests, which the
+// server does not limit itself
+const ollamaMaxBatchSize = 256
+
+// OllamaEmbedder implements embedding generation using Ollama's /api/embed
+// endpoint, for models such as "nomic-embed-text" or "mxbai-embed-large"
+type OllamaEmbedder struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	config     EmbeddingConfig
+}
+
+// ollamaEmbedRequest is the body of an /api/embed request
+type ollamaEmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Truncate   *bool    `json:"truncate,omitempty"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// ollamaEmbedResponse is the body of an /api/embed response
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// NewOllamaEmbedder creates an embedder for an Ollama server. baseURL
+// defaults to "http://localhost:11434".
+func NewOllamaEmbedder(baseURL string, config EmbeddingConfig) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if config.Model == "" {
+		config.Model = "nomic-embed-text"
+	}
+
+	return &OllamaEmbedder{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{},
+		config:     config,
+	}
+}
+
+// Embed generates an embedding with the default configuration
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedWithConfig(ctx, text, e.config)
+}
+
+// EmbedWithConfig generates an embedding with custom configuration
+func (e *OllamaEmbedder) EmbedWithConfig(ctx context.Context, text string, config EmbeddingConfig) ([]float32, error) {
+	embeddings, err := e.embedBatch(ctx, []string{text}, config)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts with the default configuration
+func (e *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.EmbedBatchWithConfig(ctx, texts, e.config)
+}
+
+// EmbedBatchWithConfig generates embeddings for multiple texts with custom
+// configuration, in requests of at most config.BatchSize texts
+func (e *OllamaEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	return embedInBatches(ctx, texts, batchSize(config, ollamaMaxBatchSize), func(ctx context.Context, texts []string) ([][]float32, error) {
+		return e.embedBatch(ctx, texts, config)
+	})
+}
+
+// embedBatch generates embeddings for texts in a single request
+func (e *OllamaEmbedder) embedBatch(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	req := ollamaEmbedRequest{
+		Model:      config.Model,
+		Input:      texts,
+		Dimensions: config.Dimensions,
+	}
+	if config.Truncation != "" {
+		truncate := config.Truncation != "none"
+		req.Truncate = &truncate
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.BaseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", llm.ClassifyError("ollama", err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed: %w", llm.NewHTTPError("ollama", resp.StatusCode, resp.Header, respBody))
+	}
+
+	var embedResp ollamaEmbedResponse
+	if err := json.Unmarshal(respBody, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Embeddings))
+	}
+
+	return embedResp.Embeddings, nil
+}
+
+// CalculateSimilarity calculates the similarity between two embeddings
+func (e *OllamaEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	if metric == "" {
+		metric = e.config.SimilarityMetric
+	}
+	return calculateSimilarity(vec1, vec2, metric)
+}
+
+// GetConfig returns the current configuration
+func (e *OllamaEmbedder) GetConfig() EmbeddingConfig {
+	return e.config
+}
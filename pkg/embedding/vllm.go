@@ -0,0 +1,29 @@
+package embedding
+
+import (
+	"strings"
+
+	"github.com/openai/openai-go/option"
+)
+
+// vllmMaxBatchSize bounds the size of vLLM embedding requests, which the
+// server does not limit itself
+const vllmMaxBatchSize = 256
+
+// NewVLLMEmbedder creates an embedder for a vLLM server running an embedding
+// model, such as "BAAI/bge-m3", through its OpenAI-compatible API. baseURL is
+// the server address, such as "http://localhost:8000". Servers started with
+// --api-key need option.WithAPIKey. Truncation is not sent, as older servers
+// reject it; vLLM rejects texts longer than the model's maximum length.
+func NewVLLMEmbedder(baseURL string, config EmbeddingConfig, opts ...option.RequestOption) *OpenAIEmbedder {
+	if baseURL == "" {
+		baseURL = "http://localhost:8000"
+	}
+
+	options := []option.RequestOption{
+		option.WithBaseURL(strings.TrimSuffix(baseURL, "/") + "/v1/"),
+		// Do not send an OPENAI_API_KEY from the environment to the server
+		option.WithAPIKey(""),
+	}
+	return newOpenAIEmbedder(config, vllmMaxBatchSize, append(options, opts...)...)
+}
@@ -25,18 +25,36 @@ func NewExecutor(tools []interfaces.Tool) *Executor {
 	}
 }
 
+// StepHandler is called with the index and result of each step of a plan once
+// it has run. An error stops the plan.
+type StepHandler func(step int, result string) error
+
 // ExecutePlan executes an approved execution plan
 func (e *Executor) ExecutePlan(ctx context.Context, plan *ExecutionPlan) (string, error) {
+	return e.ExecutePlanFrom(ctx, plan, 0, nil, nil)
+}
+
+// ExecutePlanFrom executes an approved execution plan from the given step,
+// for example to resume a plan that was interrupted. The results of the steps
+// before it are given as previous results, formatted as the results of
+// ExecutePlan. onStep is optional.
+func (e *Executor) ExecutePlanFrom(ctx context.Context, plan *ExecutionPlan, start int, previousResults []string, onStep StepHandler) (string, error) {
 	if !plan.UserApproved {
 		return "", fmt.Errorf("execution plan has not been approved by the user")
 	}
+	if start < 0 || start > len(plan.Steps) {
+		return "", fmt.Errorf("invalid start step %d for a plan of %d steps", start, len(plan.Steps))
+	}
 
 	// Update status to executing
 	plan.Status = StatusExecuting
 
 	// Execute each step in the plan
 	results := make([]string, 0, len(plan.Steps))
-	for i, step := range plan.Steps {
+	results = append(results, previousResults...)
+	for i := start; i < len(plan.Steps); i++ {
+		step := plan.Steps[i]
+
 		// Get the tool
 		tool, ok := e.tools[step.ToolName]
 		if !ok {
@@ -53,7 +71,14 @@ func (e *Executor) ExecutePlan(ctx context.Context, plan *ExecutionPlan) (string
 		}
 
 		// Add the result to the list of results
-		results = append(results, fmt.Sprintf("Step %d (%s): %s", i+1, step.Description, result))
+		results = append(results, formatStepResult(i, step, result))
+
+		if onStep != nil {
+			if err := onStep(i, results[len(results)-1]); err != nil {
+				plan.Status = StatusFailed
+				return "", fmt.Errorf("failed after step %d: %w", i+1, err)
+			}
+		}
 	}
 
 	// Update status to completed
@@ -63,6 +88,12 @@ func (e *Executor) ExecutePlan(ctx context.Context, plan *ExecutionPlan) (string
 	return fmt.Sprintf("Execution plan completed successfully!\n\n%s", strings.Join(results, "\n\n")), nil
 }
 
+// formatStepResult formats the result of a step as it appears in the result
+// of a plan
+func formatStepResult(index int, step ExecutionStep, result string) string {
+	return fmt.Sprintf("Step %d (%s): %s", index+1, step.Description, result)
+}
+
 // CancelPlan cancels an execution plan
 func (e *Executor) CancelPlan(plan *ExecutionPlan) {
 	plan.Status = StatusCancelled
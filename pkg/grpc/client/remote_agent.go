@@ -141,9 +141,19 @@ func (r *RemoteAgentClient) Disconnect() error {
 
 // Run executes the remote agent with the given input
 func (r *RemoteAgentClient) Run(ctx context.Context, input string) (string, error) {
-	if err := r.ensureConnected(); err != nil {
+	resp, err := r.RunDetailed(ctx, input)
+	if err != nil {
 		return "", err
 	}
+	return resp.Output, nil
+}
+
+// RunDetailed executes the remote agent and returns its response with the
+// trace of the run
+func (r *RemoteAgentClient) RunDetailed(ctx context.Context, input string) (*pb.RunResponse, error) {
+	if err := r.ensureConnected(); err != nil {
+		return nil, err
+	}
 
 	// Create request
 	req := &pb.RunRequest{
@@ -180,13 +190,13 @@ func (r *RemoteAgentClient) Run(ctx context.Context, input string) (string, erro
 		}
 
 		if resp.Error != "" {
-			return "", fmt.Errorf("remote agent error: %s", resp.Error)
+			return nil, fmt.Errorf("remote agent error: %s", resp.Error)
 		}
 
-		return resp.Output, nil
+		return resp, nil
 	}
 
-	return "", fmt.Errorf("failed after %d attempts, last error: %w", r.retryCount, lastErr)
+	return nil, fmt.Errorf("failed after %d attempts, last error: %w", r.retryCount, lastErr)
 }
 
 // RunWithAuth executes the remote agent with explicit auth token
@@ -338,6 +348,70 @@ func (r *RemoteAgentClient) ApproveExecutionPlan(ctx context.Context, planID str
 	return r.client.ApproveExecutionPlan(ctx, req)
 }
 
+// ResolveToolApproval approves, edits or rejects a tool call of the remote
+// agent that is waiting for approval
+func (r *RemoteAgentClient) ResolveToolApproval(ctx context.Context, approvalID string, decision interfaces.ApprovalDecision) error {
+	if err := r.ensureConnected(); err != nil {
+		return err
+	}
+
+	req := &pb.ResolveToolApprovalRequest{
+		ApprovalId: approvalID,
+		Approved:   decision.Approved,
+		Arguments:  decision.Arguments,
+		Reason:     decision.Reason,
+	}
+
+	// Add org_id from context if available
+	if orgID, _ := multitenancy.GetOrgID(ctx); orgID != "" {
+		req.OrgId = orgID
+	}
+
+	ctx, cancel := r.withTimeoutIfSet(ctx)
+	defer cancel()
+
+	resp, err := r.client.ResolveToolApproval(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to resolve approval: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("remote agent error: %s", resp.Error)
+	}
+	return nil
+}
+
+// ListToolApprovals returns the tool calls of the remote agent that are
+// waiting for approval
+func (r *RemoteAgentClient) ListToolApprovals(ctx context.Context) ([]interfaces.ToolApproval, error) {
+	if err := r.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	req := &pb.ListToolApprovalsRequest{}
+
+	// Add org_id from context if available
+	if orgID, _ := multitenancy.GetOrgID(ctx); orgID != "" {
+		req.OrgId = orgID
+	}
+
+	ctx, cancel := r.withTimeoutIfSet(ctx)
+	defer cancel()
+
+	resp, err := r.client.ListToolApprovals(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("remote agent error: %s", resp.Error)
+	}
+
+	approvals := make([]interfaces.ToolApproval, 0, len(resp.Approvals))
+	for _, approval := range resp.Approvals {
+		approvals = append(approvals, convertPbToToolApproval(approval))
+	}
+	return approvals, nil
+}
+
 // ensureConnected ensures that the client is connected to the remote service
 func (r *RemoteAgentClient) ensureConnected() error {
 	if r.conn == nil || r.client == nil {
@@ -736,6 +810,12 @@ func convertPbToStreamEvent(resp *pb.RunStreamResponse) interfaces.AgentStreamEv
 		event.Type = interfaces.AgentEventError
 	case pb.EventType_EVENT_TYPE_COMPLETE:
 		event.Type = interfaces.AgentEventComplete
+	case pb.EventType_EVENT_TYPE_APPROVAL_REQUEST:
+		event.Type = interfaces.AgentEventApprovalRequest
+		if resp.Approval != nil {
+			approval := convertPbToToolApproval(resp.Approval)
+			event.Approval = &approval
+		}
 	default:
 		event.Type = interfaces.AgentEventContent
 	}
@@ -747,3 +827,16 @@ func convertPbToStreamEvent(resp *pb.RunStreamResponse) interfaces.AgentStreamEv
 
 	return event
 }
+
+// convertPbToToolApproval converts a protobuf ToolApproval
+func convertPbToToolApproval(approval *pb.ToolApproval) interfaces.ToolApproval {
+	return interfaces.ToolApproval{
+		ID:             approval.Id,
+		AgentName:      approval.AgentName,
+		ConversationID: approval.ConversationId,
+		ToolName:       approval.ToolName,
+		Arguments:      approval.Arguments,
+		Status:         interfaces.ApprovalStatus(approval.Status),
+		CreatedAt:      time.UnixMilli(approval.CreatedAt),
+	}
+}
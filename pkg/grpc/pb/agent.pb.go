@@ -7,12 +7,11 @@
 package pb
 
 import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
-
-	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
-	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 )
 
 const (
@@ -26,15 +25,16 @@ const (
 type EventType int32
 
 const (
-	EventType_EVENT_TYPE_UNSPECIFIED   EventType = 0
-	EventType_EVENT_TYPE_MESSAGE_START EventType = 1
-	EventType_EVENT_TYPE_CONTENT       EventType = 2
-	EventType_EVENT_TYPE_THINKING      EventType = 3
-	EventType_EVENT_TYPE_TOOL_CALL     EventType = 4
-	EventType_EVENT_TYPE_TOOL_RESULT   EventType = 5
-	EventType_EVENT_TYPE_ERROR         EventType = 6
-	EventType_EVENT_TYPE_COMPLETE      EventType = 7
-	EventType_EVENT_TYPE_MESSAGE_STOP  EventType = 8
+	EventType_EVENT_TYPE_UNSPECIFIED      EventType = 0
+	EventType_EVENT_TYPE_MESSAGE_START    EventType = 1
+	EventType_EVENT_TYPE_CONTENT          EventType = 2
+	EventType_EVENT_TYPE_THINKING         EventType = 3
+	EventType_EVENT_TYPE_TOOL_CALL        EventType = 4
+	EventType_EVENT_TYPE_TOOL_RESULT      EventType = 5
+	EventType_EVENT_TYPE_ERROR            EventType = 6
+	EventType_EVENT_TYPE_COMPLETE         EventType = 7
+	EventType_EVENT_TYPE_MESSAGE_STOP     EventType = 8
+	EventType_EVENT_TYPE_APPROVAL_REQUEST EventType = 9
 )
 
 // Enum value maps for EventType.
@@ -49,17 +49,19 @@ var (
 		6: "EVENT_TYPE_ERROR",
 		7: "EVENT_TYPE_COMPLETE",
 		8: "EVENT_TYPE_MESSAGE_STOP",
+		9: "EVENT_TYPE_APPROVAL_REQUEST",
 	}
 	EventType_value = map[string]int32{
-		"EVENT_TYPE_UNSPECIFIED":   0,
-		"EVENT_TYPE_MESSAGE_START": 1,
-		"EVENT_TYPE_CONTENT":       2,
-		"EVENT_TYPE_THINKING":      3,
-		"EVENT_TYPE_TOOL_CALL":     4,
-		"EVENT_TYPE_TOOL_RESULT":   5,
-		"EVENT_TYPE_ERROR":         6,
-		"EVENT_TYPE_COMPLETE":      7,
-		"EVENT_TYPE_MESSAGE_STOP":  8,
+		"EVENT_TYPE_UNSPECIFIED":      0,
+		"EVENT_TYPE_MESSAGE_START":    1,
+		"EVENT_TYPE_CONTENT":          2,
+		"EVENT_TYPE_THINKING":         3,
+		"EVENT_TYPE_TOOL_CALL":        4,
+		"EVENT_TYPE_TOOL_RESULT":      5,
+		"EVENT_TYPE_ERROR":            6,
+		"EVENT_TYPE_COMPLETE":         7,
+		"EVENT_TYPE_MESSAGE_STOP":     8,
+		"EVENT_TYPE_APPROVAL_REQUEST": 9,
 	}
 )
 
@@ -139,7 +141,7 @@ func (x HealthResponse_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use HealthResponse_Status.Descriptor instead.
 func (HealthResponse_Status) EnumDescriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{9, 0}
+	return file_agent_proto_rawDescGZIP(), []int{12, 0}
 }
 
 // RunRequest contains the input for agent execution
@@ -225,6 +227,7 @@ type RunResponse struct {
 	Output        string                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
 	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	Metadata      map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Trace         *RunTrace              `protobuf:"bytes,4,opt,name=trace,proto3" json:"trace,omitempty"` // How the output was produced
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -280,6 +283,300 @@ func (x *RunResponse) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *RunResponse) GetTrace() *RunTrace {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+// RunTrace describes the steps of a run
+type RunTrace struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Steps          []*RunStep             `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	Iterations     int32                  `protobuf:"varint,2,opt,name=iterations,proto3" json:"iterations,omitempty"` // Number of LLM turns
+	Truncated      bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"`   // Whether the tool loop reached the maximum number of iterations
+	Usage          *TokenUsage            `protobuf:"bytes,4,opt,name=usage,proto3" json:"usage,omitempty"`
+	Cost           float64                `protobuf:"fixed64,5,opt,name=cost,proto3" json:"cost,omitempty"`                           // Cost in USD, if the agent has a price table
+	StartTime      int64                  `protobuf:"varint,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix timestamp in milliseconds
+	DurationMs     int64                  `protobuf:"varint,7,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	BudgetExceeded string                 `protobuf:"bytes,8,opt,name=budget_exceeded,json=budgetExceeded,proto3" json:"budget_exceeded,omitempty"` // Why the run stopped early, if it exceeded its budget
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunTrace) Reset() {
+	*x = RunTrace{}
+	mi := &file_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunTrace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunTrace) ProtoMessage() {}
+
+func (x *RunTrace) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunTrace.ProtoReflect.Descriptor instead.
+func (*RunTrace) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RunTrace) GetSteps() []*RunStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *RunTrace) GetIterations() int32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+func (x *RunTrace) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *RunTrace) GetUsage() *TokenUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *RunTrace) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *RunTrace) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *RunTrace) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *RunTrace) GetBudgetExceeded() string {
+	if x != nil {
+		return x.BudgetExceeded
+	}
+	return ""
+}
+
+// RunStep is an LLM turn or a tool call of a run
+type RunStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`            // "llm" or "tool"
+	Iteration     int32                  `protobuf:"varint,2,opt,name=iteration,proto3" json:"iteration,omitempty"` // 1-based LLM turn, 0 for the steps of an execution plan
+	ToolName      string                 `protobuf:"bytes,3,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	Arguments     string                 `protobuf:"bytes,4,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	Result        string                 `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	StartTime     int64                  `protobuf:"varint,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix timestamp in milliseconds
+	DurationMs    int64                  `protobuf:"varint,8,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunStep) Reset() {
+	*x = RunStep{}
+	mi := &file_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStep) ProtoMessage() {}
+
+func (x *RunStep) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStep.ProtoReflect.Descriptor instead.
+func (*RunStep) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RunStep) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RunStep) GetIteration() int32 {
+	if x != nil {
+		return x.Iteration
+	}
+	return 0
+}
+
+func (x *RunStep) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *RunStep) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+func (x *RunStep) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *RunStep) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *RunStep) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *RunStep) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+// TokenUsage counts the tokens used by a run
+type TokenUsage struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	InputTokens         int64                  `protobuf:"varint,1,opt,name=input_tokens,json=inputTokens,proto3" json:"input_tokens,omitempty"`
+	OutputTokens        int64                  `protobuf:"varint,2,opt,name=output_tokens,json=outputTokens,proto3" json:"output_tokens,omitempty"`
+	CachedInputTokens   int64                  `protobuf:"varint,3,opt,name=cached_input_tokens,json=cachedInputTokens,proto3" json:"cached_input_tokens,omitempty"`
+	CacheCreationTokens int64                  `protobuf:"varint,4,opt,name=cache_creation_tokens,json=cacheCreationTokens,proto3" json:"cache_creation_tokens,omitempty"`
+	ReasoningTokens     int64                  `protobuf:"varint,5,opt,name=reasoning_tokens,json=reasoningTokens,proto3" json:"reasoning_tokens,omitempty"`
+	TotalTokens         int64                  `protobuf:"varint,6,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *TokenUsage) Reset() {
+	*x = TokenUsage{}
+	mi := &file_agent_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenUsage) ProtoMessage() {}
+
+func (x *TokenUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenUsage.ProtoReflect.Descriptor instead.
+func (*TokenUsage) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TokenUsage) GetInputTokens() int64 {
+	if x != nil {
+		return x.InputTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetOutputTokens() int64 {
+	if x != nil {
+		return x.OutputTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetCachedInputTokens() int64 {
+	if x != nil {
+		return x.CachedInputTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetCacheCreationTokens() int64 {
+	if x != nil {
+		return x.CacheCreationTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetReasoningTokens() int64 {
+	if x != nil {
+		return x.ReasoningTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetTotalTokens() int64 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
 // RunStreamResponse for streaming responses
 type RunStreamResponse struct {
 	state   protoimpl.MessageState `protogen:"open.v1"`
@@ -292,13 +589,14 @@ type RunStreamResponse struct {
 	Thinking      string            `protobuf:"bytes,6,opt,name=thinking,proto3" json:"thinking,omitempty"`
 	Metadata      map[string]string `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	Timestamp     int64             `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix timestamp in milliseconds
+	Approval      *ToolApproval     `protobuf:"bytes,9,opt,name=approval,proto3" json:"approval,omitempty"`    // Set for approval request events
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RunStreamResponse) Reset() {
 	*x = RunStreamResponse{}
-	mi := &file_agent_proto_msgTypes[2]
+	mi := &file_agent_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -310,7 +608,7 @@ func (x *RunStreamResponse) String() string {
 func (*RunStreamResponse) ProtoMessage() {}
 
 func (x *RunStreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[2]
+	mi := &file_agent_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -323,7 +621,7 @@ func (x *RunStreamResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunStreamResponse.ProtoReflect.Descriptor instead.
 func (*RunStreamResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{2}
+	return file_agent_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *RunStreamResponse) GetChunk() string {
@@ -382,6 +680,13 @@ func (x *RunStreamResponse) GetTimestamp() int64 {
 	return 0
 }
 
+func (x *RunStreamResponse) GetApproval() *ToolApproval {
+	if x != nil {
+		return x.Approval
+	}
+	return nil
+}
+
 // ToolCall message for tool execution information
 type ToolCall struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -398,7 +703,7 @@ type ToolCall struct {
 
 func (x *ToolCall) Reset() {
 	*x = ToolCall{}
-	mi := &file_agent_proto_msgTypes[3]
+	mi := &file_agent_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -410,7 +715,7 @@ func (x *ToolCall) String() string {
 func (*ToolCall) ProtoMessage() {}
 
 func (x *ToolCall) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[3]
+	mi := &file_agent_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -423,7 +728,7 @@ func (x *ToolCall) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
 func (*ToolCall) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{3}
+	return file_agent_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ToolCall) GetId() string {
@@ -484,7 +789,7 @@ type MetadataRequest struct {
 
 func (x *MetadataRequest) Reset() {
 	*x = MetadataRequest{}
-	mi := &file_agent_proto_msgTypes[4]
+	mi := &file_agent_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -496,7 +801,7 @@ func (x *MetadataRequest) String() string {
 func (*MetadataRequest) ProtoMessage() {}
 
 func (x *MetadataRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[4]
+	mi := &file_agent_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -509,7 +814,7 @@ func (x *MetadataRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetadataRequest.ProtoReflect.Descriptor instead.
 func (*MetadataRequest) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{4}
+	return file_agent_proto_rawDescGZIP(), []int{7}
 }
 
 // MetadataResponse contains agent metadata
@@ -526,7 +831,7 @@ type MetadataResponse struct {
 
 func (x *MetadataResponse) Reset() {
 	*x = MetadataResponse{}
-	mi := &file_agent_proto_msgTypes[5]
+	mi := &file_agent_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -538,7 +843,7 @@ func (x *MetadataResponse) String() string {
 func (*MetadataResponse) ProtoMessage() {}
 
 func (x *MetadataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[5]
+	mi := &file_agent_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -551,7 +856,7 @@ func (x *MetadataResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetadataResponse.ProtoReflect.Descriptor instead.
 func (*MetadataResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{5}
+	return file_agent_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *MetadataResponse) GetName() string {
@@ -598,7 +903,7 @@ type CapabilitiesRequest struct {
 
 func (x *CapabilitiesRequest) Reset() {
 	*x = CapabilitiesRequest{}
-	mi := &file_agent_proto_msgTypes[6]
+	mi := &file_agent_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -610,7 +915,7 @@ func (x *CapabilitiesRequest) String() string {
 func (*CapabilitiesRequest) ProtoMessage() {}
 
 func (x *CapabilitiesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[6]
+	mi := &file_agent_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -623,7 +928,7 @@ func (x *CapabilitiesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CapabilitiesRequest.ProtoReflect.Descriptor instead.
 func (*CapabilitiesRequest) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{6}
+	return file_agent_proto_rawDescGZIP(), []int{9}
 }
 
 // CapabilitiesResponse contains agent capabilities
@@ -640,7 +945,7 @@ type CapabilitiesResponse struct {
 
 func (x *CapabilitiesResponse) Reset() {
 	*x = CapabilitiesResponse{}
-	mi := &file_agent_proto_msgTypes[7]
+	mi := &file_agent_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -652,7 +957,7 @@ func (x *CapabilitiesResponse) String() string {
 func (*CapabilitiesResponse) ProtoMessage() {}
 
 func (x *CapabilitiesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[7]
+	mi := &file_agent_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -665,7 +970,7 @@ func (x *CapabilitiesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CapabilitiesResponse.ProtoReflect.Descriptor instead.
 func (*CapabilitiesResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{7}
+	return file_agent_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *CapabilitiesResponse) GetTools() []string {
@@ -712,7 +1017,7 @@ type HealthRequest struct {
 
 func (x *HealthRequest) Reset() {
 	*x = HealthRequest{}
-	mi := &file_agent_proto_msgTypes[8]
+	mi := &file_agent_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -724,7 +1029,7 @@ func (x *HealthRequest) String() string {
 func (*HealthRequest) ProtoMessage() {}
 
 func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[8]
+	mi := &file_agent_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -737,7 +1042,7 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
 func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{8}
+	return file_agent_proto_rawDescGZIP(), []int{11}
 }
 
 // HealthResponse contains health status
@@ -751,7 +1056,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_agent_proto_msgTypes[9]
+	mi := &file_agent_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -763,7 +1068,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[9]
+	mi := &file_agent_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -776,7 +1081,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{9}
+	return file_agent_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *HealthResponse) GetStatus() HealthResponse_Status {
@@ -802,7 +1107,7 @@ type ReadinessRequest struct {
 
 func (x *ReadinessRequest) Reset() {
 	*x = ReadinessRequest{}
-	mi := &file_agent_proto_msgTypes[10]
+	mi := &file_agent_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -814,7 +1119,7 @@ func (x *ReadinessRequest) String() string {
 func (*ReadinessRequest) ProtoMessage() {}
 
 func (x *ReadinessRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[10]
+	mi := &file_agent_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -827,7 +1132,7 @@ func (x *ReadinessRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadinessRequest.ProtoReflect.Descriptor instead.
 func (*ReadinessRequest) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{10}
+	return file_agent_proto_rawDescGZIP(), []int{13}
 }
 
 // ReadinessResponse contains readiness status
@@ -841,7 +1146,7 @@ type ReadinessResponse struct {
 
 func (x *ReadinessResponse) Reset() {
 	*x = ReadinessResponse{}
-	mi := &file_agent_proto_msgTypes[11]
+	mi := &file_agent_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -853,7 +1158,7 @@ func (x *ReadinessResponse) String() string {
 func (*ReadinessResponse) ProtoMessage() {}
 
 func (x *ReadinessResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[11]
+	mi := &file_agent_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -866,7 +1171,7 @@ func (x *ReadinessResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadinessResponse.ProtoReflect.Descriptor instead.
 func (*ReadinessResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{11}
+	return file_agent_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ReadinessResponse) GetReady() bool {
@@ -896,7 +1201,7 @@ type PlanRequest struct {
 
 func (x *PlanRequest) Reset() {
 	*x = PlanRequest{}
-	mi := &file_agent_proto_msgTypes[12]
+	mi := &file_agent_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -908,7 +1213,7 @@ func (x *PlanRequest) String() string {
 func (*PlanRequest) ProtoMessage() {}
 
 func (x *PlanRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[12]
+	mi := &file_agent_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -921,7 +1226,7 @@ func (x *PlanRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlanRequest.ProtoReflect.Descriptor instead.
 func (*PlanRequest) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{12}
+	return file_agent_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *PlanRequest) GetInput() string {
@@ -965,7 +1270,7 @@ type PlanResponse struct {
 
 func (x *PlanResponse) Reset() {
 	*x = PlanResponse{}
-	mi := &file_agent_proto_msgTypes[13]
+	mi := &file_agent_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -977,7 +1282,7 @@ func (x *PlanResponse) String() string {
 func (*PlanResponse) ProtoMessage() {}
 
 func (x *PlanResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[13]
+	mi := &file_agent_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -990,7 +1295,7 @@ func (x *PlanResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlanResponse.ProtoReflect.Descriptor instead.
 func (*PlanResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{13}
+	return file_agent_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *PlanResponse) GetPlanId() string {
@@ -1034,7 +1339,7 @@ type PlanStep struct {
 
 func (x *PlanStep) Reset() {
 	*x = PlanStep{}
-	mi := &file_agent_proto_msgTypes[14]
+	mi := &file_agent_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1046,7 +1351,7 @@ func (x *PlanStep) String() string {
 func (*PlanStep) ProtoMessage() {}
 
 func (x *PlanStep) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[14]
+	mi := &file_agent_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1059,7 +1364,7 @@ func (x *PlanStep) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
 func (*PlanStep) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{14}
+	return file_agent_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *PlanStep) GetId() string {
@@ -1102,7 +1407,7 @@ type ApprovalRequest struct {
 
 func (x *ApprovalRequest) Reset() {
 	*x = ApprovalRequest{}
-	mi := &file_agent_proto_msgTypes[15]
+	mi := &file_agent_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1114,7 +1419,7 @@ func (x *ApprovalRequest) String() string {
 func (*ApprovalRequest) ProtoMessage() {}
 
 func (x *ApprovalRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[15]
+	mi := &file_agent_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1127,7 +1432,7 @@ func (x *ApprovalRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApprovalRequest.ProtoReflect.Descriptor instead.
 func (*ApprovalRequest) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{15}
+	return file_agent_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *ApprovalRequest) GetPlanId() string {
@@ -1162,7 +1467,7 @@ type ApprovalResponse struct {
 
 func (x *ApprovalResponse) Reset() {
 	*x = ApprovalResponse{}
-	mi := &file_agent_proto_msgTypes[16]
+	mi := &file_agent_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1174,7 +1479,7 @@ func (x *ApprovalResponse) String() string {
 func (*ApprovalResponse) ProtoMessage() {}
 
 func (x *ApprovalResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_proto_msgTypes[16]
+	mi := &file_agent_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1187,7 +1492,7 @@ func (x *ApprovalResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApprovalResponse.ProtoReflect.Descriptor instead.
 func (*ApprovalResponse) Descriptor() ([]byte, []int) {
-	return file_agent_proto_rawDescGZIP(), []int{16}
+	return file_agent_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ApprovalResponse) GetResult() string {
@@ -1204,6 +1509,319 @@ func (x *ApprovalResponse) GetError() string {
 	return ""
 }
 
+// ToolApproval is a tool call waiting for a human decision
+type ToolApproval struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AgentName      string                 `protobuf:"bytes,2,opt,name=agent_name,json=agentName,proto3" json:"agent_name,omitempty"`
+	ConversationId string                 `protobuf:"bytes,3,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ToolName       string                 `protobuf:"bytes,4,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	Arguments      string                 `protobuf:"bytes,5,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	Status         string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`                         // "pending", "approved", "rejected", "expired"
+	CreatedAt      int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix timestamp in milliseconds
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ToolApproval) Reset() {
+	*x = ToolApproval{}
+	mi := &file_agent_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolApproval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolApproval) ProtoMessage() {}
+
+func (x *ToolApproval) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolApproval.ProtoReflect.Descriptor instead.
+func (*ToolApproval) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ToolApproval) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ToolApproval) GetAgentName() string {
+	if x != nil {
+		return x.AgentName
+	}
+	return ""
+}
+
+func (x *ToolApproval) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ToolApproval) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *ToolApproval) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+func (x *ToolApproval) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ToolApproval) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// ResolveToolApprovalRequest approves, edits or rejects a tool call
+type ResolveToolApprovalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApprovalId    string                 `protobuf:"bytes,1,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	Approved      bool                   `protobuf:"varint,2,opt,name=approved,proto3" json:"approved,omitempty"`
+	Arguments     string                 `protobuf:"bytes,3,opt,name=arguments,proto3" json:"arguments,omitempty"` // Replaces the arguments of an approved call when set
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`       // Reported to the agent when the call is rejected
+	OrgId         string                 `protobuf:"bytes,5,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveToolApprovalRequest) Reset() {
+	*x = ResolveToolApprovalRequest{}
+	mi := &file_agent_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveToolApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveToolApprovalRequest) ProtoMessage() {}
+
+func (x *ResolveToolApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveToolApprovalRequest.ProtoReflect.Descriptor instead.
+func (*ResolveToolApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ResolveToolApprovalRequest) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *ResolveToolApprovalRequest) GetApproved() bool {
+	if x != nil {
+		return x.Approved
+	}
+	return false
+}
+
+func (x *ResolveToolApprovalRequest) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+func (x *ResolveToolApprovalRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ResolveToolApprovalRequest) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+// ResolveToolApprovalResponse contains the result of a decision
+type ResolveToolApprovalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveToolApprovalResponse) Reset() {
+	*x = ResolveToolApprovalResponse{}
+	mi := &file_agent_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveToolApprovalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveToolApprovalResponse) ProtoMessage() {}
+
+func (x *ResolveToolApprovalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveToolApprovalResponse.ProtoReflect.Descriptor instead.
+func (*ResolveToolApprovalResponse) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ResolveToolApprovalResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ListToolApprovalsRequest for listing tool calls waiting for approval
+type ListToolApprovalsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrgId         string                 `protobuf:"bytes,1,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolApprovalsRequest) Reset() {
+	*x = ListToolApprovalsRequest{}
+	mi := &file_agent_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolApprovalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolApprovalsRequest) ProtoMessage() {}
+
+func (x *ListToolApprovalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolApprovalsRequest.ProtoReflect.Descriptor instead.
+func (*ListToolApprovalsRequest) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListToolApprovalsRequest) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+// ListToolApprovalsResponse contains the tool calls waiting for approval
+type ListToolApprovalsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Approvals     []*ToolApproval        `protobuf:"bytes,1,rep,name=approvals,proto3" json:"approvals,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolApprovalsResponse) Reset() {
+	*x = ListToolApprovalsResponse{}
+	mi := &file_agent_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolApprovalsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolApprovalsResponse) ProtoMessage() {}
+
+func (x *ListToolApprovalsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolApprovalsResponse.ProtoReflect.Descriptor instead.
+func (*ListToolApprovalsResponse) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListToolApprovalsResponse) GetApprovals() []*ToolApproval {
+	if x != nil {
+		return x.Approvals
+	}
+	return nil
+}
+
+func (x *ListToolApprovalsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_agent_proto protoreflect.FileDescriptor
 
 const file_agent_proto_rawDesc = "" +
@@ -1218,14 +1836,47 @@ const file_agent_proto_rawDesc = "" +
 	"\x0fconversation_id\x18\x05 \x01(\tR\x0econversationId\x1a:\n" +
 	"\fContextEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb6\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdd\x01\n" +
 	"\vRunResponse\x12\x16\n" +
 	"\x06output\x18\x01 \x01(\tR\x06output\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12<\n" +
-	"\bmetadata\x18\x03 \x03(\v2 .agent.RunResponse.MetadataEntryR\bmetadata\x1a;\n" +
+	"\bmetadata\x18\x03 \x03(\v2 .agent.RunResponse.MetadataEntryR\bmetadata\x12%\n" +
+	"\x05trace\x18\x04 \x01(\v2\x0f.agent.RunTraceR\x05trace\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf4\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x94\x02\n" +
+	"\bRunTrace\x12$\n" +
+	"\x05steps\x18\x01 \x03(\v2\x0e.agent.RunStepR\x05steps\x12\x1e\n" +
+	"\n" +
+	"iterations\x18\x02 \x01(\x05R\n" +
+	"iterations\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\x12'\n" +
+	"\x05usage\x18\x04 \x01(\v2\x11.agent.TokenUsageR\x05usage\x12\x12\n" +
+	"\x04cost\x18\x05 \x01(\x01R\x04cost\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x06 \x01(\x03R\tstartTime\x12\x1f\n" +
+	"\vduration_ms\x18\a \x01(\x03R\n" +
+	"durationMs\x12'\n" +
+	"\x0fbudget_exceeded\x18\b \x01(\tR\x0ebudgetExceeded\"\xe4\x01\n" +
+	"\aRunStep\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1c\n" +
+	"\titeration\x18\x02 \x01(\x05R\titeration\x12\x1b\n" +
+	"\ttool_name\x18\x03 \x01(\tR\btoolName\x12\x1c\n" +
+	"\targuments\x18\x04 \x01(\tR\targuments\x12\x16\n" +
+	"\x06result\x18\x05 \x01(\tR\x06result\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\a \x01(\x03R\tstartTime\x12\x1f\n" +
+	"\vduration_ms\x18\b \x01(\x03R\n" +
+	"durationMs\"\x86\x02\n" +
+	"\n" +
+	"TokenUsage\x12!\n" +
+	"\finput_tokens\x18\x01 \x01(\x03R\vinputTokens\x12#\n" +
+	"\routput_tokens\x18\x02 \x01(\x03R\foutputTokens\x12.\n" +
+	"\x13cached_input_tokens\x18\x03 \x01(\x03R\x11cachedInputTokens\x122\n" +
+	"\x15cache_creation_tokens\x18\x04 \x01(\x03R\x13cacheCreationTokens\x12)\n" +
+	"\x10reasoning_tokens\x18\x05 \x01(\x03R\x0freasoningTokens\x12!\n" +
+	"\ftotal_tokens\x18\x06 \x01(\x03R\vtotalTokens\"\xa5\x03\n" +
 	"\x11RunStreamResponse\x12\x14\n" +
 	"\x05chunk\x18\x01 \x01(\tR\x05chunk\x12\x19\n" +
 	"\bis_final\x18\x02 \x01(\bR\aisFinal\x12\x14\n" +
@@ -1235,7 +1886,8 @@ const file_agent_proto_rawDesc = "" +
 	"\ttool_call\x18\x05 \x01(\v2\x0f.agent.ToolCallR\btoolCall\x12\x1a\n" +
 	"\bthinking\x18\x06 \x01(\tR\bthinking\x12B\n" +
 	"\bmetadata\x18\a \x03(\v2&.agent.RunStreamResponse.MetadataEntryR\bmetadata\x12\x1c\n" +
-	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x1a;\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12/\n" +
+	"\bapproval\x18\t \x01(\v2\x13.agent.ToolApprovalR\bapproval\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbb\x01\n" +
@@ -1309,7 +1961,31 @@ const file_agent_proto_rawDesc = "" +
 	"\rmodifications\x18\x03 \x01(\tR\rmodifications\"@\n" +
 	"\x10ApprovalResponse\x12\x16\n" +
 	"\x06result\x18\x01 \x01(\tR\x06result\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error*\xf8\x01\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xd8\x01\n" +
+	"\fToolApproval\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"agent_name\x18\x02 \x01(\tR\tagentName\x12'\n" +
+	"\x0fconversation_id\x18\x03 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\ttool_name\x18\x04 \x01(\tR\btoolName\x12\x1c\n" +
+	"\targuments\x18\x05 \x01(\tR\targuments\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\"\xa6\x01\n" +
+	"\x1aResolveToolApprovalRequest\x12\x1f\n" +
+	"\vapproval_id\x18\x01 \x01(\tR\n" +
+	"approvalId\x12\x1a\n" +
+	"\bapproved\x18\x02 \x01(\bR\bapproved\x12\x1c\n" +
+	"\targuments\x18\x03 \x01(\tR\targuments\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x15\n" +
+	"\x06org_id\x18\x05 \x01(\tR\x05orgId\"3\n" +
+	"\x1bResolveToolApprovalResponse\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"1\n" +
+	"\x18ListToolApprovalsRequest\x12\x15\n" +
+	"\x06org_id\x18\x01 \x01(\tR\x05orgId\"d\n" +
+	"\x19ListToolApprovalsResponse\x121\n" +
+	"\tapprovals\x18\x01 \x03(\v2\x13.agent.ToolApprovalR\tapprovals\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error*\x99\x02\n" +
 	"\tEventType\x12\x1a\n" +
 	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
 	"\x18EVENT_TYPE_MESSAGE_START\x10\x01\x12\x16\n" +
@@ -1319,7 +1995,8 @@ const file_agent_proto_rawDesc = "" +
 	"\x16EVENT_TYPE_TOOL_RESULT\x10\x05\x12\x14\n" +
 	"\x10EVENT_TYPE_ERROR\x10\x06\x12\x17\n" +
 	"\x13EVENT_TYPE_COMPLETE\x10\a\x12\x1b\n" +
-	"\x17EVENT_TYPE_MESSAGE_STOP\x10\b2\x82\x04\n" +
+	"\x17EVENT_TYPE_MESSAGE_STOP\x10\b\x12\x1f\n" +
+	"\x1bEVENT_TYPE_APPROVAL_REQUEST\x10\t2\xb8\x05\n" +
 	"\fAgentService\x12,\n" +
 	"\x03Run\x12\x11.agent.RunRequest\x1a\x12.agent.RunResponse\x12:\n" +
 	"\tRunStream\x12\x11.agent.RunRequest\x1a\x18.agent.RunStreamResponse0\x01\x12>\n" +
@@ -1328,7 +2005,9 @@ const file_agent_proto_rawDesc = "" +
 	"\x06Health\x12\x14.agent.HealthRequest\x1a\x15.agent.HealthResponse\x12:\n" +
 	"\x05Ready\x12\x17.agent.ReadinessRequest\x1a\x18.agent.ReadinessResponse\x12@\n" +
 	"\x15GenerateExecutionPlan\x12\x12.agent.PlanRequest\x1a\x13.agent.PlanResponse\x12G\n" +
-	"\x14ApproveExecutionPlan\x12\x16.agent.ApprovalRequest\x1a\x17.agent.ApprovalResponseB/Z-github.com/andmang/agent-sdk-go/pkg/grpc/pbb\x06proto3"
+	"\x14ApproveExecutionPlan\x12\x16.agent.ApprovalRequest\x1a\x17.agent.ApprovalResponse\x12\\\n" +
+	"\x13ResolveToolApproval\x12!.agent.ResolveToolApprovalRequest\x1a\".agent.ResolveToolApprovalResponse\x12V\n" +
+	"\x11ListToolApprovals\x12\x1f.agent.ListToolApprovalsRequest\x1a .agent.ListToolApprovalsResponseB-Z+github.com/andmang/agent-sdk-go/pkg/grpc/pbb\x06proto3"
 
 var (
 	file_agent_proto_rawDescOnce sync.Once
@@ -1337,73 +2016,89 @@ var (
 
 func file_agent_proto_rawDescGZIP() []byte {
 	file_agent_proto_rawDescOnce.Do(func() {
-		// #nosec G103 - unsafe usage is safe in protobuf-generated code
 		file_agent_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)))
 	})
 	return file_agent_proto_rawDescData
 }
 
 var file_agent_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_agent_proto_goTypes = []any{
-	(EventType)(0),               // 0: agent.EventType
-	(HealthResponse_Status)(0),   // 1: agent.HealthResponse.Status
-	(*RunRequest)(nil),           // 2: agent.RunRequest
-	(*RunResponse)(nil),          // 3: agent.RunResponse
-	(*RunStreamResponse)(nil),    // 4: agent.RunStreamResponse
-	(*ToolCall)(nil),             // 5: agent.ToolCall
-	(*MetadataRequest)(nil),      // 6: agent.MetadataRequest
-	(*MetadataResponse)(nil),     // 7: agent.MetadataResponse
-	(*CapabilitiesRequest)(nil),  // 8: agent.CapabilitiesRequest
-	(*CapabilitiesResponse)(nil), // 9: agent.CapabilitiesResponse
-	(*HealthRequest)(nil),        // 10: agent.HealthRequest
-	(*HealthResponse)(nil),       // 11: agent.HealthResponse
-	(*ReadinessRequest)(nil),     // 12: agent.ReadinessRequest
-	(*ReadinessResponse)(nil),    // 13: agent.ReadinessResponse
-	(*PlanRequest)(nil),          // 14: agent.PlanRequest
-	(*PlanResponse)(nil),         // 15: agent.PlanResponse
-	(*PlanStep)(nil),             // 16: agent.PlanStep
-	(*ApprovalRequest)(nil),      // 17: agent.ApprovalRequest
-	(*ApprovalResponse)(nil),     // 18: agent.ApprovalResponse
-	nil,                          // 19: agent.RunRequest.ContextEntry
-	nil,                          // 20: agent.RunResponse.MetadataEntry
-	nil,                          // 21: agent.RunStreamResponse.MetadataEntry
-	nil,                          // 22: agent.MetadataResponse.PropertiesEntry
-	nil,                          // 23: agent.PlanRequest.ContextEntry
-	nil,                          // 24: agent.PlanStep.ParametersEntry
+	(EventType)(0),                      // 0: agent.EventType
+	(HealthResponse_Status)(0),          // 1: agent.HealthResponse.Status
+	(*RunRequest)(nil),                  // 2: agent.RunRequest
+	(*RunResponse)(nil),                 // 3: agent.RunResponse
+	(*RunTrace)(nil),                    // 4: agent.RunTrace
+	(*RunStep)(nil),                     // 5: agent.RunStep
+	(*TokenUsage)(nil),                  // 6: agent.TokenUsage
+	(*RunStreamResponse)(nil),           // 7: agent.RunStreamResponse
+	(*ToolCall)(nil),                    // 8: agent.ToolCall
+	(*MetadataRequest)(nil),             // 9: agent.MetadataRequest
+	(*MetadataResponse)(nil),            // 10: agent.MetadataResponse
+	(*CapabilitiesRequest)(nil),         // 11: agent.CapabilitiesRequest
+	(*CapabilitiesResponse)(nil),        // 12: agent.CapabilitiesResponse
+	(*HealthRequest)(nil),               // 13: agent.HealthRequest
+	(*HealthResponse)(nil),              // 14: agent.HealthResponse
+	(*ReadinessRequest)(nil),            // 15: agent.ReadinessRequest
+	(*ReadinessResponse)(nil),           // 16: agent.ReadinessResponse
+	(*PlanRequest)(nil),                 // 17: agent.PlanRequest
+	(*PlanResponse)(nil),                // 18: agent.PlanResponse
+	(*PlanStep)(nil),                    // 19: agent.PlanStep
+	(*ApprovalRequest)(nil),             // 20: agent.ApprovalRequest
+	(*ApprovalResponse)(nil),            // 21: agent.ApprovalResponse
+	(*ToolApproval)(nil),                // 22: agent.ToolApproval
+	(*ResolveToolApprovalRequest)(nil),  // 23: agent.ResolveToolApprovalRequest
+	(*ResolveToolApprovalResponse)(nil), // 24: agent.ResolveToolApprovalResponse
+	(*ListToolApprovalsRequest)(nil),    // 25: agent.ListToolApprovalsRequest
+	(*ListToolApprovalsResponse)(nil),   // 26: agent.ListToolApprovalsResponse
+	nil,                                 // 27: agent.RunRequest.ContextEntry
+	nil,                                 // 28: agent.RunResponse.MetadataEntry
+	nil,                                 // 29: agent.RunStreamResponse.MetadataEntry
+	nil,                                 // 30: agent.MetadataResponse.PropertiesEntry
+	nil,                                 // 31: agent.PlanRequest.ContextEntry
+	nil,                                 // 32: agent.PlanStep.ParametersEntry
 }
 var file_agent_proto_depIdxs = []int32{
-	19, // 0: agent.RunRequest.context:type_name -> agent.RunRequest.ContextEntry
-	20, // 1: agent.RunResponse.metadata:type_name -> agent.RunResponse.MetadataEntry
-	0,  // 2: agent.RunStreamResponse.event_type:type_name -> agent.EventType
-	5,  // 3: agent.RunStreamResponse.tool_call:type_name -> agent.ToolCall
-	21, // 4: agent.RunStreamResponse.metadata:type_name -> agent.RunStreamResponse.MetadataEntry
-	22, // 5: agent.MetadataResponse.properties:type_name -> agent.MetadataResponse.PropertiesEntry
-	1,  // 6: agent.HealthResponse.status:type_name -> agent.HealthResponse.Status
-	23, // 7: agent.PlanRequest.context:type_name -> agent.PlanRequest.ContextEntry
-	16, // 8: agent.PlanResponse.steps:type_name -> agent.PlanStep
-	24, // 9: agent.PlanStep.parameters:type_name -> agent.PlanStep.ParametersEntry
-	2,  // 10: agent.AgentService.Run:input_type -> agent.RunRequest
-	2,  // 11: agent.AgentService.RunStream:input_type -> agent.RunRequest
-	6,  // 12: agent.AgentService.GetMetadata:input_type -> agent.MetadataRequest
-	8,  // 13: agent.AgentService.GetCapabilities:input_type -> agent.CapabilitiesRequest
-	10, // 14: agent.AgentService.Health:input_type -> agent.HealthRequest
-	12, // 15: agent.AgentService.Ready:input_type -> agent.ReadinessRequest
-	14, // 16: agent.AgentService.GenerateExecutionPlan:input_type -> agent.PlanRequest
-	17, // 17: agent.AgentService.ApproveExecutionPlan:input_type -> agent.ApprovalRequest
-	3,  // 18: agent.AgentService.Run:output_type -> agent.RunResponse
-	4,  // 19: agent.AgentService.RunStream:output_type -> agent.RunStreamResponse
-	7,  // 20: agent.AgentService.GetMetadata:output_type -> agent.MetadataResponse
-	9,  // 21: agent.AgentService.GetCapabilities:output_type -> agent.CapabilitiesResponse
-	11, // 22: agent.AgentService.Health:output_type -> agent.HealthResponse
-	13, // 23: agent.AgentService.Ready:output_type -> agent.ReadinessResponse
-	15, // 24: agent.AgentService.GenerateExecutionPlan:output_type -> agent.PlanResponse
-	18, // 25: agent.AgentService.ApproveExecutionPlan:output_type -> agent.ApprovalResponse
-	18, // [18:26] is the sub-list for method output_type
-	10, // [10:18] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	27, // 0: agent.RunRequest.context:type_name -> agent.RunRequest.ContextEntry
+	28, // 1: agent.RunResponse.metadata:type_name -> agent.RunResponse.MetadataEntry
+	4,  // 2: agent.RunResponse.trace:type_name -> agent.RunTrace
+	5,  // 3: agent.RunTrace.steps:type_name -> agent.RunStep
+	6,  // 4: agent.RunTrace.usage:type_name -> agent.TokenUsage
+	0,  // 5: agent.RunStreamResponse.event_type:type_name -> agent.EventType
+	8,  // 6: agent.RunStreamResponse.tool_call:type_name -> agent.ToolCall
+	29, // 7: agent.RunStreamResponse.metadata:type_name -> agent.RunStreamResponse.MetadataEntry
+	22, // 8: agent.RunStreamResponse.approval:type_name -> agent.ToolApproval
+	30, // 9: agent.MetadataResponse.properties:type_name -> agent.MetadataResponse.PropertiesEntry
+	1,  // 10: agent.HealthResponse.status:type_name -> agent.HealthResponse.Status
+	31, // 11: agent.PlanRequest.context:type_name -> agent.PlanRequest.ContextEntry
+	19, // 12: agent.PlanResponse.steps:type_name -> agent.PlanStep
+	32, // 13: agent.PlanStep.parameters:type_name -> agent.PlanStep.ParametersEntry
+	22, // 14: agent.ListToolApprovalsResponse.approvals:type_name -> agent.ToolApproval
+	2,  // 15: agent.AgentService.Run:input_type -> agent.RunRequest
+	2,  // 16: agent.AgentService.RunStream:input_type -> agent.RunRequest
+	9,  // 17: agent.AgentService.GetMetadata:input_type -> agent.MetadataRequest
+	11, // 18: agent.AgentService.GetCapabilities:input_type -> agent.CapabilitiesRequest
+	13, // 19: agent.AgentService.Health:input_type -> agent.HealthRequest
+	15, // 20: agent.AgentService.Ready:input_type -> agent.ReadinessRequest
+	17, // 21: agent.AgentService.GenerateExecutionPlan:input_type -> agent.PlanRequest
+	20, // 22: agent.AgentService.ApproveExecutionPlan:input_type -> agent.ApprovalRequest
+	23, // 23: agent.AgentService.ResolveToolApproval:input_type -> agent.ResolveToolApprovalRequest
+	25, // 24: agent.AgentService.ListToolApprovals:input_type -> agent.ListToolApprovalsRequest
+	3,  // 25: agent.AgentService.Run:output_type -> agent.RunResponse
+	7,  // 26: agent.AgentService.RunStream:output_type -> agent.RunStreamResponse
+	10, // 27: agent.AgentService.GetMetadata:output_type -> agent.MetadataResponse
+	12, // 28: agent.AgentService.GetCapabilities:output_type -> agent.CapabilitiesResponse
+	14, // 29: agent.AgentService.Health:output_type -> agent.HealthResponse
+	16, // 30: agent.AgentService.Ready:output_type -> agent.ReadinessResponse
+	18, // 31: agent.AgentService.GenerateExecutionPlan:output_type -> agent.PlanResponse
+	21, // 32: agent.AgentService.ApproveExecutionPlan:output_type -> agent.ApprovalResponse
+	24, // 33: agent.AgentService.ResolveToolApproval:output_type -> agent.ResolveToolApprovalResponse
+	26, // 34: agent.AgentService.ListToolApprovals:output_type -> agent.ListToolApprovalsResponse
+	25, // [25:35] is the sub-list for method output_type
+	15, // [15:25] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_agent_proto_init() }
@@ -1415,10 +2110,9 @@ func file_agent_proto_init() {
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			// #nosec G103 - unsafe usage is safe in protobuf-generated code
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   23,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
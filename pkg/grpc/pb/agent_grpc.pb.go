@@ -27,6 +27,8 @@ const (
 	AgentService_Ready_FullMethodName                 = "/agent.AgentService/Ready"
 	AgentService_GenerateExecutionPlan_FullMethodName = "/agent.AgentService/GenerateExecutionPlan"
 	AgentService_ApproveExecutionPlan_FullMethodName  = "/agent.AgentService/ApproveExecutionPlan"
+	AgentService_ResolveToolApproval_FullMethodName   = "/agent.AgentService/ResolveToolApproval"
+	AgentService_ListToolApprovals_FullMethodName     = "/agent.AgentService/ListToolApprovals"
 )
 
 // AgentServiceClient is the client API for AgentService service.
@@ -51,6 +53,10 @@ type AgentServiceClient interface {
 	GenerateExecutionPlan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanResponse, error)
 	// Approve execution plan (if supported)
 	ApproveExecutionPlan(ctx context.Context, in *ApprovalRequest, opts ...grpc.CallOption) (*ApprovalResponse, error)
+	// Approve, edit or reject a tool call waiting for approval
+	ResolveToolApproval(ctx context.Context, in *ResolveToolApprovalRequest, opts ...grpc.CallOption) (*ResolveToolApprovalResponse, error)
+	// List the tool calls waiting for approval
+	ListToolApprovals(ctx context.Context, in *ListToolApprovalsRequest, opts ...grpc.CallOption) (*ListToolApprovalsResponse, error)
 }
 
 type agentServiceClient struct {
@@ -150,6 +156,26 @@ func (c *agentServiceClient) ApproveExecutionPlan(ctx context.Context, in *Appro
 	return out, nil
 }
 
+func (c *agentServiceClient) ResolveToolApproval(ctx context.Context, in *ResolveToolApprovalRequest, opts ...grpc.CallOption) (*ResolveToolApprovalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolveToolApprovalResponse)
+	err := c.cc.Invoke(ctx, AgentService_ResolveToolApproval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) ListToolApprovals(ctx context.Context, in *ListToolApprovalsRequest, opts ...grpc.CallOption) (*ListToolApprovalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToolApprovalsResponse)
+	err := c.cc.Invoke(ctx, AgentService_ListToolApprovals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServiceServer is the server API for AgentService service.
 // All implementations must embed UnimplementedAgentServiceServer
 // for forward compatibility.
@@ -172,6 +198,10 @@ type AgentServiceServer interface {
 	GenerateExecutionPlan(context.Context, *PlanRequest) (*PlanResponse, error)
 	// Approve execution plan (if supported)
 	ApproveExecutionPlan(context.Context, *ApprovalRequest) (*ApprovalResponse, error)
+	// Approve, edit or reject a tool call waiting for approval
+	ResolveToolApproval(context.Context, *ResolveToolApprovalRequest) (*ResolveToolApprovalResponse, error)
+	// List the tool calls waiting for approval
+	ListToolApprovals(context.Context, *ListToolApprovalsRequest) (*ListToolApprovalsResponse, error)
 	mustEmbedUnimplementedAgentServiceServer()
 }
 
@@ -206,6 +236,12 @@ func (UnimplementedAgentServiceServer) GenerateExecutionPlan(context.Context, *P
 func (UnimplementedAgentServiceServer) ApproveExecutionPlan(context.Context, *ApprovalRequest) (*ApprovalResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ApproveExecutionPlan not implemented")
 }
+func (UnimplementedAgentServiceServer) ResolveToolApproval(context.Context, *ResolveToolApprovalRequest) (*ResolveToolApprovalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveToolApproval not implemented")
+}
+func (UnimplementedAgentServiceServer) ListToolApprovals(context.Context, *ListToolApprovalsRequest) (*ListToolApprovalsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListToolApprovals not implemented")
+}
 func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
 func (UnimplementedAgentServiceServer) testEmbeddedByValue()                      {}
 
@@ -364,6 +400,42 @@ func _AgentService_ApproveExecutionPlan_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_ResolveToolApproval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveToolApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ResolveToolApproval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ResolveToolApproval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ResolveToolApproval(ctx, req.(*ResolveToolApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_ListToolApprovals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToolApprovalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ListToolApprovals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ListToolApprovals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ListToolApprovals(ctx, req.(*ListToolApprovalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -399,6 +471,14 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ApproveExecutionPlan",
 			Handler:    _AgentService_ApproveExecutionPlan_Handler,
 		},
+		{
+			MethodName: "ResolveToolApproval",
+			Handler:    _AgentService_ResolveToolApproval_Handler,
+		},
+		{
+			MethodName: "ListToolApprovals",
+			Handler:    _AgentService_ListToolApprovals_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -77,20 +77,22 @@ func (s *AgentServer) Run(ctx context.Context, req *pb.RunRequest) (*pb.RunRespo
 	}
 
 	// Execute the agent
-	result, err := s.agent.Run(ctx, req.Input)
+	result, err := s.agent.RunDetailed(ctx, req.Input)
 	if err != nil {
 		return &pb.RunResponse{
 			Output: "",
 			Error:  err.Error(),
+			Trace:  convertRunResult(result),
 		}, nil
 	}
 
 	return &pb.RunResponse{
-		Output: result,
+		Output: result.Output,
 		Error:  "",
 		Metadata: map[string]string{
 			"agent_name": s.agent.GetName(),
 		},
+		Trace: convertRunResult(result),
 	}, nil
 }
 
@@ -193,6 +195,11 @@ func (s *AgentServer) RunStream(req *pb.RunRequest, stream pb.AgentService_RunSt
 			}
 		}
 
+		// Add the tool call waiting for approval if present
+		if event.Approval != nil {
+			response.Approval = convertToolApproval(*event.Approval)
+		}
+
 		// Add thinking if present
 		if event.ThinkingStep != "" {
 			response.Thinking = event.ThinkingStep
@@ -242,6 +249,8 @@ func (s *AgentServer) convertEventType(eventType interfaces.AgentEventType) pb.E
 		return pb.EventType_EVENT_TYPE_ERROR
 	case interfaces.AgentEventComplete:
 		return pb.EventType_EVENT_TYPE_COMPLETE
+	case interfaces.AgentEventApprovalRequest:
+		return pb.EventType_EVENT_TYPE_APPROVAL_REQUEST
 	default:
 		return pb.EventType_EVENT_TYPE_CONTENT
 	}
@@ -418,6 +427,97 @@ func (s *AgentServer) ApproveExecutionPlan(ctx context.Context, req *pb.Approval
 	}, nil
 }
 
+// ResolveToolApproval approves, edits or rejects a tool call waiting for approval
+func (s *AgentServer) ResolveToolApproval(ctx context.Context, req *pb.ResolveToolApprovalRequest) (*pb.ResolveToolApprovalResponse, error) {
+	// Approvals are only visible to the organization of the run that made them
+	if req.OrgId == "" {
+		return nil, status.Error(codes.Unauthenticated, "org_id is required")
+	}
+	ctx = multitenancy.WithOrgID(ctx, req.OrgId)
+
+	err := s.agent.ResolveApproval(ctx, req.ApprovalId, interfaces.ApprovalDecision{
+		Approved:  req.Approved,
+		Arguments: req.Arguments,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		return &pb.ResolveToolApprovalResponse{
+			Error: fmt.Sprintf("Failed to resolve approval: %v", err),
+		}, nil
+	}
+
+	return &pb.ResolveToolApprovalResponse{}, nil
+}
+
+// ListToolApprovals returns the tool calls waiting for approval
+func (s *AgentServer) ListToolApprovals(ctx context.Context, req *pb.ListToolApprovalsRequest) (*pb.ListToolApprovalsResponse, error) {
+	// Approvals are only visible to the organization of the run that made them
+	if req.OrgId == "" {
+		return nil, status.Error(codes.Unauthenticated, "org_id is required")
+	}
+	ctx = multitenancy.WithOrgID(ctx, req.OrgId)
+
+	approvals, err := s.agent.PendingApprovals(ctx)
+	if err != nil {
+		return &pb.ListToolApprovalsResponse{
+			Error: fmt.Sprintf("Failed to list approvals: %v", err),
+		}, nil
+	}
+
+	response := &pb.ListToolApprovalsResponse{}
+	for _, approval := range approvals {
+		response.Approvals = append(response.Approvals, convertToolApproval(approval))
+	}
+	return response, nil
+}
+
+// convertToolApproval converts a tool approval to its protobuf message
+func convertToolApproval(approval interfaces.ToolApproval) *pb.ToolApproval {
+	return &pb.ToolApproval{
+		Id:             approval.ID,
+		AgentName:      approval.AgentName,
+		ConversationId: approval.ConversationID,
+		ToolName:       approval.ToolName,
+		Arguments:      approval.Arguments,
+		Status:         string(approval.Status),
+		CreatedAt:      approval.CreatedAt.UnixMilli(),
+	}
+}
+
+// convertRunResult converts the trace of a run to its protobuf message
+func convertRunResult(result *agent.RunResult) *pb.RunTrace {
+	usage := result.Usage.Total
+	trace := &pb.RunTrace{
+		Iterations:     int32(result.Iterations),
+		Truncated:      result.Truncated,
+		BudgetExceeded: result.BudgetExceeded,
+		Usage: &pb.TokenUsage{
+			InputTokens:         int64(usage.InputTokens),
+			OutputTokens:        int64(usage.OutputTokens),
+			CachedInputTokens:   int64(usage.CachedInputTokens),
+			CacheCreationTokens: int64(usage.CacheCreationTokens),
+			ReasoningTokens:     int64(usage.ReasoningTokens),
+			TotalTokens:         int64(usage.TotalTokens),
+		},
+		Cost:       result.Usage.Cost,
+		StartTime:  result.StartTime.UnixMilli(),
+		DurationMs: result.DurationMs,
+	}
+	for _, step := range result.Steps {
+		trace.Steps = append(trace.Steps, &pb.RunStep{
+			Type:       string(step.Type),
+			Iteration:  int32(step.Iteration),
+			ToolName:   step.ToolName,
+			Arguments:  step.Arguments,
+			Result:     step.Result,
+			Error:      step.Error,
+			StartTime:  step.StartTime.UnixMilli(),
+			DurationMs: step.DurationMs,
+		})
+	}
+	return trace
+}
+
 // Start starts the gRPC server on the specified port
 func (s *AgentServer) Start(port int) error {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
@@ -148,6 +148,17 @@ func (p *Pipeline) ProcessResponse(ctx context.Context, response string) (string
 	return processedResponse, nil
 }
 
+// ProcessInput processes the input of an agent, so that the pipeline can be
+// given to agent.WithGuardrails
+func (p *Pipeline) ProcessInput(ctx context.Context, input string) (string, error) {
+	return p.ProcessRequest(ctx, input)
+}
+
+// ProcessOutput processes the output of an agent
+func (p *Pipeline) ProcessOutput(ctx context.Context, output string) (string, error) {
+	return p.ProcessResponse(ctx, output)
+}
+
 // AddGuardrail adds a guardrail to the pipeline
 func (p *Pipeline) AddGuardrail(guardrail Guardrail) {
 	p.guardrails = append(p.guardrails, guardrail)
@@ -0,0 +1,76 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrApprovalNotFound is returned when a decision is made on a tool call that
+// is not waiting for approval
+var ErrApprovalNotFound = errors.New("approval not found")
+
+// ApprovalStatus is the state of a tool call that requires approval
+type ApprovalStatus string
+
+const (
+	// ApprovalStatusPending means the tool call waits for a decision
+	ApprovalStatusPending ApprovalStatus = "pending"
+	// ApprovalStatusApproved means the tool call was approved, possibly with
+	// edited arguments
+	ApprovalStatusApproved ApprovalStatus = "approved"
+	// ApprovalStatusRejected means the tool call was rejected
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+	// ApprovalStatusExpired means no decision was made before the approval
+	// expired, or before a run that is not checkpointed ended
+	ApprovalStatusExpired ApprovalStatus = "expired"
+)
+
+// ToolApproval is a tool call that requires human approval before it runs
+type ToolApproval struct {
+	ID             string            `json:"id"`
+	AgentName      string            `json:"agent_name,omitempty"`
+	OrgID          string            `json:"org_id,omitempty"`
+	ConversationID string            `json:"conversation_id,omitempty"`
+	RunID          string            `json:"run_id,omitempty"` // Checkpointed run that made the call, if any
+	ToolName       string            `json:"tool_name"`
+	Arguments      string            `json:"arguments"`
+	Status         ApprovalStatus    `json:"status"`
+	Decision       *ApprovalDecision `json:"decision,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	DecidedAt      time.Time         `json:"decided_at,omitempty"`
+	ExpiresAt      time.Time         `json:"expires_at,omitempty"` // When a pending call stops waiting (zero = never)
+}
+
+// Expired reports whether a pending approval can no longer be decided
+func (a ToolApproval) Expired(now time.Time) bool {
+	return a.Status == ApprovalStatusPending && !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt)
+}
+
+// ApprovalDecision is a human decision on a tool call
+type ApprovalDecision struct {
+	Approved  bool   `json:"approved"`
+	Arguments string `json:"arguments,omitempty"` // Replaces the arguments of an approved call when set
+	Reason    string `json:"reason,omitempty"`    // Reported to the LLM when the call is rejected
+}
+
+// ApprovalStore persists tool calls that require approval
+type ApprovalStore interface {
+	// SaveApproval creates or updates an approval
+	SaveApproval(ctx context.Context, approval ToolApproval) error
+
+	// GetApproval returns an approval by ID, or ErrApprovalNotFound
+	GetApproval(ctx context.Context, id string) (*ToolApproval, error)
+
+	// ListApprovals returns the approvals with the given status, oldest first.
+	// An empty status returns all approvals.
+	ListApprovals(ctx context.Context, status ApprovalStatus) ([]ToolApproval, error)
+}
+
+// ApprovalTool is an optional interface that tools can implement to require
+// human approval before they run
+type ApprovalTool interface {
+	// RequiresApproval reports whether a call with the given arguments must
+	// be approved
+	RequiresApproval(ctx context.Context, args string) bool
+}
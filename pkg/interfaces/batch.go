@@ -0,0 +1,79 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBatchInProgress is returned when the results of a batch that has not
+// finished are requested
+var ErrBatchInProgress = errors.New("batch is still in progress")
+
+// BatchStatus is the state of a batch
+type BatchStatus string
+
+const (
+	// BatchStatusInProgress means the batch is being validated or processed
+	BatchStatusInProgress BatchStatus = "in_progress"
+	// BatchStatusCompleted means every request was processed
+	BatchStatusCompleted BatchStatus = "completed"
+	// BatchStatusFailed means the batch was rejected and has no results
+	BatchStatusFailed BatchStatus = "failed"
+	// BatchStatusCanceled means the batch was canceled; requests that
+	// finished before have results
+	BatchStatusCanceled BatchStatus = "canceled"
+	// BatchStatusExpired means the batch did not finish in time; requests that
+	// finished before have results
+	BatchStatusExpired BatchStatus = "expired"
+)
+
+// Done reports whether the batch has stopped processing
+func (s BatchStatus) Done() bool {
+	return s != BatchStatusInProgress && s != ""
+}
+
+// BatchRequest is one generate request of a batch. CustomID identifies its
+// result and must be unique within the batch.
+type BatchRequest struct {
+	CustomID string
+	Prompt   string
+	Options  []GenerateOption
+}
+
+// Batch describes a batch submitted to a provider
+type Batch struct {
+	ID             string
+	Status         BatchStatus
+	ProviderStatus string // Status as reported by the provider
+	Total          int    // Number of requests
+	Succeeded      int    // Requests that finished successfully
+	Failed         int    // Requests that failed, were canceled or expired
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// BatchResult is the outcome of one request of a batch
+type BatchResult struct {
+	CustomID string
+	Content  string // Generated text; JSON for requests with a response format
+	Err      error  // Set if the request failed
+}
+
+// BatchLLM is implemented by LLMs whose provider has a batch API, which
+// processes many requests asynchronously at a lower price and with separate
+// rate limits
+type BatchLLM interface {
+	// SubmitBatch submits generate requests for asynchronous processing
+	SubmitBatch(ctx context.Context, requests []BatchRequest) (*Batch, error)
+
+	// GetBatch returns the current state of a batch
+	GetBatch(ctx context.Context, batchID string) (*Batch, error)
+
+	// GetBatchResults returns the results of a finished batch keyed by custom
+	// ID. It returns ErrBatchInProgress if the batch has not finished.
+	GetBatchResults(ctx context.Context, batchID string) (map[string]BatchResult, error)
+
+	// CancelBatch asks the provider to stop processing a batch
+	CancelBatch(ctx context.Context, batchID string) (*Batch, error)
+}
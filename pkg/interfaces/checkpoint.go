@@ -0,0 +1,127 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCheckpointNotFound is returned when a run has no checkpoint
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// ErrRunClaimed is returned when a run is claimed by another run in progress,
+// in this process or another one
+var ErrRunClaimed = errors.New("run is claimed by another run in progress")
+
+// RunStatus is the state of a checkpointed run
+type RunStatus string
+
+const (
+	// RunStatusRunning means the run has not finished, either because it is
+	// still running or because its process stopped; it can be resumed
+	RunStatusRunning RunStatus = "running"
+	// RunStatusCompleted means the run finished with a response
+	RunStatusCompleted RunStatus = "completed"
+	// RunStatusFailed means the run finished with an error; it can be resumed
+	RunStatusFailed RunStatus = "failed"
+)
+
+// RunCheckpoint is the saved state of an agent run, from which the run can be
+// resumed
+type RunCheckpoint struct {
+	RunID          string    `json:"run_id"`
+	AgentName      string    `json:"agent_name,omitempty"`
+	OrgID          string    `json:"org_id,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	Input          string    `json:"input"`
+	Status         RunStatus `json:"status"`
+
+	// MemoryOffset is the number of messages of the conversation in the
+	// agent's memory that came before the run
+	MemoryOffset int `json:"memory_offset,omitempty"`
+	// Messages are the messages of the run so far, including its tool calls
+	// and results, which follow the conversation in memory. Runs of agents
+	// without memory start with the input.
+	Messages []Message `json:"messages,omitempty"`
+	// PendingToolCalls are the tool calls that started but have not finished
+	PendingToolCalls []PendingToolCall `json:"pending_tool_calls,omitempty"`
+	// CompletedToolCalls counts the finished tool calls by tool and
+	// arguments, from which the idempotency keys of later calls are derived
+	CompletedToolCalls map[string]int `json:"completed_tool_calls,omitempty"`
+	// Iteration is the number of tool-calling iterations of the LLM so far
+	Iteration int `json:"iteration"`
+	// Plan is the progress of the execution plan the run executes, if any
+	Plan *PlanProgress `json:"plan,omitempty"`
+
+	Response  string    `json:"response,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PendingToolCall is a tool call that started but has not finished
+type PendingToolCall struct {
+	ToolName       string    `json:"tool_name"`
+	Arguments      string    `json:"arguments"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	ApprovalID     string    `json:"approval_id,omitempty"` // Approval the call waits for, if any
+	StartedAt      time.Time `json:"started_at"`
+}
+
+// PlanProgress is the progress of an execution plan
+type PlanProgress struct {
+	TaskID      string         `json:"task_id"`
+	Description string         `json:"description,omitempty"`
+	Steps       []PlanStepSpec `json:"steps"`
+	NextStep    int            `json:"next_step"` // Index of the first step that has not finished
+	Results     []string       `json:"results,omitempty"`
+}
+
+// PlanStepSpec is a step of an execution plan
+type PlanStepSpec struct {
+	ToolName    string `json:"tool_name"`
+	Input       string `json:"input"`
+	Description string `json:"description,omitempty"`
+}
+
+// CheckpointStore persists the state of agent runs
+type CheckpointStore interface {
+	// SaveCheckpoint creates or replaces the checkpoint of a run
+	SaveCheckpoint(ctx context.Context, checkpoint RunCheckpoint) error
+
+	// GetCheckpoint returns the checkpoint of a run, or ErrCheckpointNotFound
+	GetCheckpoint(ctx context.Context, runID string) (*RunCheckpoint, error)
+
+	// ListCheckpoints returns the checkpoints with the given status, oldest
+	// first. An empty status returns all checkpoints.
+	ListCheckpoints(ctx context.Context, status RunStatus) ([]RunCheckpoint, error)
+
+	// DeleteCheckpoint removes the checkpoint of a run
+	DeleteCheckpoint(ctx context.Context, runID string) error
+
+	// ClaimRun claims a run for the owner until the claim expires after ttl,
+	// or renews the claim the owner holds. It returns ErrRunClaimed if
+	// another owner holds a claim that has not expired.
+	ClaimRun(ctx context.Context, runID, owner string, ttl time.Duration) error
+
+	// ReleaseRun releases the claim of the owner on a run, if it holds it
+	ReleaseRun(ctx context.Context, runID, owner string) error
+}
+
+// idempotencyKeyKey is the context key of the idempotency key of a tool call
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a context carrying the idempotency key of a tool
+// call
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key of the tool call being executed,
+// or an empty string. Checkpointed runs give a call the same key when it is
+// made again after the run is resumed, so that tools with side effects can
+// skip work they already did.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
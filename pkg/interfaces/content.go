@@ -0,0 +1,113 @@
+package interfaces
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ContentPartType is the kind of content held by a ContentPart
+type ContentPartType string
+
+const (
+	// ContentPartText is plain text
+	ContentPartText ContentPartType = "text"
+	// ContentPartImage is an image, such as a screenshot or a scanned page
+	ContentPartImage ContentPartType = "image"
+	// ContentPartFile is a document, such as a PDF
+	ContentPartFile ContentPartType = "file"
+	// ContentPartAudio is an audio recording
+	ContentPartAudio ContentPartType = "audio"
+)
+
+// ContentPart is one part of a multimodal message. Media is given either
+// inline as Data or as a URL that the provider fetches itself.
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	URL      string          `json:"url,omitempty"`       // URL of the media, if not inline
+	Data     []byte          `json:"data,omitempty"`      // Inline media
+	MIMEType string          `json:"mime_type,omitempty"` // e.g. "image/png" or "application/pdf"
+	Filename string          `json:"filename,omitempty"`  // Name of a file, if known
+	Detail   string          `json:"detail,omitempty"`    // Image detail level where supported ("low", "high", "auto")
+}
+
+// TextPart creates a text content part
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// ImagePart creates an image content part from inline image data
+func ImagePart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: ContentPartImage, Data: data, MIMEType: mimeType}
+}
+
+// ImageURLPart creates an image content part from an image URL
+func ImageURLPart(imageURL string) ContentPart {
+	return ContentPart{Type: ContentPartImage, URL: imageURL}
+}
+
+// FilePart creates a file content part, such as a PDF, from inline data
+func FilePart(data []byte, mimeType, filename string) ContentPart {
+	return ContentPart{Type: ContentPartFile, Data: data, MIMEType: mimeType, Filename: filename}
+}
+
+// FileURLPart creates a file content part from a file URL
+func FileURLPart(fileURL, mimeType string) ContentPart {
+	return ContentPart{Type: ContentPartFile, URL: fileURL, MIMEType: mimeType}
+}
+
+// AudioPart creates an audio content part from inline audio data
+func AudioPart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: ContentPartAudio, Data: data, MIMEType: mimeType}
+}
+
+// MediaType returns the MIME type of the part. When it is not set, it is
+// guessed from the extension of the file name or URL.
+func (p ContentPart) MediaType() string {
+	if p.MIMEType != "" {
+		return p.MIMEType
+	}
+	name := p.Filename
+	if name == "" && p.URL != "" {
+		name = p.URL
+		if parsed, err := url.Parse(p.URL); err == nil {
+			name = parsed.Path
+		}
+	}
+	if ext := path.Ext(name); ext != "" {
+		if mimeType := mime.TypeByExtension(strings.ToLower(ext)); mimeType != "" {
+			// Drop parameters such as "; charset=utf-8"
+			mimeType, _, _ = strings.Cut(mimeType, ";")
+			return mimeType
+		}
+	}
+	return ""
+}
+
+// Base64 returns the inline data encoded as standard base64
+func (p ContentPart) Base64() string {
+	return base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// DataURL returns the inline data as a data URL, or the part's URL if the
+// media is not inline
+func (p ContentPart) DataURL() string {
+	if len(p.Data) == 0 {
+		return p.URL
+	}
+	return "data:" + p.MediaType() + ";base64," + p.Base64()
+}
+
+// ContentText returns the text of the text parts, separated by newlines
+func ContentText(parts []ContentPart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Type == ContentPartText && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
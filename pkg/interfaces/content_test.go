@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentPartMediaType(t *testing.T) {
+	assert.Equal(t, "image/png", ImagePart([]byte("png"), "image/png").MediaType())
+	assert.Equal(t, "image/jpeg", ImageURLPart("https://example.com/scan.JPG?size=large").MediaType())
+	assert.Equal(t, "application/pdf", FilePart([]byte("pdf"), "", "invoice.pdf").MediaType())
+	assert.Equal(t, "", ImageURLPart("https://example.com/image").MediaType())
+}
+
+func TestContentPartDataURL(t *testing.T) {
+	assert.Equal(t, "data:image/png;base64,cG5n", ImagePart([]byte("png"), "image/png").DataURL())
+	assert.Equal(t, "https://example.com/cat.jpg", ImageURLPart("https://example.com/cat.jpg").DataURL())
+}
+
+func TestContentText(t *testing.T) {
+	parts := []ContentPart{
+		TextPart("Compare these pages"),
+		ImagePart([]byte("png"), "image/png"),
+		TextPart("and list the differences"),
+	}
+	assert.Equal(t, "Compare these pages\nand list the differences", ContentText(parts))
+}
@@ -0,0 +1,106 @@
+package interfaces
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Classes of LLM provider failures. Provider clients return a *ProviderError
+// that matches one of them with errors.Is.
+var (
+	// ErrRateLimit means the provider throttled the request or a quota was hit
+	ErrRateLimit = errors.New("rate limit exceeded")
+	// ErrAuthentication means the credentials were missing, invalid or lack permission
+	ErrAuthentication = errors.New("authentication failed")
+	// ErrContextLength means the request does not fit in the model's context window
+	ErrContextLength = errors.New("context length exceeded")
+	// ErrContentPolicy means the provider refused the request or response on policy grounds
+	ErrContentPolicy = errors.New("content policy violation")
+	// ErrTransient means a temporary failure such as an overloaded server or a network error
+	ErrTransient = errors.New("transient provider error")
+	// ErrInvalidRequest means the provider rejected the request for any other reason
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// ProviderError is a classified failure returned by an LLM provider
+type ProviderError struct {
+	Provider   string        // Name of the provider, e.g. "openai"
+	Kind       error         // One of the error classes, e.g. ErrRateLimit
+	StatusCode int           // HTTP status code, 0 if the failure had none
+	Code       string        // Provider-specific error code or type, if any
+	Message    string        // Error message from the provider
+	RetryAfter time.Duration // Backoff requested by the provider, 0 if none
+	Err        error         // Underlying error, if any
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	var sb strings.Builder
+	if e.Provider != "" {
+		sb.WriteString(e.Provider)
+		sb.WriteString(": ")
+	}
+	if e.Kind != nil {
+		sb.WriteString(e.Kind.Error())
+	} else {
+		sb.WriteString("provider error")
+	}
+
+	var details []string
+	if e.StatusCode != 0 {
+		details = append(details, fmt.Sprintf("status %d", e.StatusCode))
+	}
+	if e.Code != "" {
+		details = append(details, "code "+e.Code)
+	}
+	if len(details) > 0 {
+		sb.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+
+	switch {
+	case e.Message != "":
+		sb.WriteString(": " + e.Message)
+	case e.Err != nil:
+		sb.WriteString(": " + e.Err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the error class and the underlying error, so that both
+// errors.Is(err, ErrRateLimit) and errors.As on the underlying error work
+func (e *ProviderError) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *ProviderError) Retryable() bool {
+	return e.Kind == ErrRateLimit || e.Kind == ErrTransient
+}
+
+// IsRetryableError reports whether err is a provider failure that may succeed
+// if the request is sent again, i.e. a rate limit or a transient error
+func IsRetryableError(err error) bool {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Retryable()
+	}
+	return errors.Is(err, ErrRateLimit) || errors.Is(err, ErrTransient)
+}
+
+// RetryAfterFromError returns the backoff the provider asked for, if any
+func RetryAfterFromError(err error) (time.Duration, bool) {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.RetryAfter > 0 {
+		return providerErr.RetryAfter, true
+	}
+	return 0, false
+}
@@ -1,6 +1,9 @@
 package interfaces
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // LLM represents a large language model provider
 type LLM interface {
@@ -17,6 +20,17 @@ type LLM interface {
 	SupportsStreaming() bool
 }
 
+// ChatLLM is an LLM that takes a whole conversation rather than a single
+// prompt, so that a transcript can be replayed exactly as it happened
+type ChatLLM interface {
+	LLM
+
+	// ChatMessages generates the next assistant message of a conversation.
+	// The messages may include assistant tool calls and tool results; leading
+	// system messages are used as the system message.
+	ChatMessages(ctx context.Context, messages []Message, options ...GenerateOption) (string, error)
+}
+
 // GenerateOption represents options for text generation
 type GenerateOption func(options *GenerateOptions)
 
@@ -29,6 +43,37 @@ type GenerateOptions struct {
 	MaxIterations  int             // Maximum number of tool-calling iterations (0 = use default)
 	Memory         Memory          // Optional memory for storing tool calls and results
 	StreamConfig   *StreamConfig   // Optional streaming configuration
+	// ContentParts are images, files or audio sent after the prompt text in the
+	// user message. With Memory, the user message is read from memory instead
+	// and must carry the parts itself.
+	ContentParts []ContentPart
+	// MaxParallelToolCalls is the maximum number of tool calls from one LLM turn
+	// executed concurrently (0 or 1 = run them one after another)
+	MaxParallelToolCalls int
+	// PromptCache configures prompt caching for providers that need explicit
+	// cache breakpoints (nil = the client's default, which is no caching)
+	PromptCache *PromptCacheConfig
+	// ToolChoice controls whether and which tools the model calls (nil = auto)
+	ToolChoice *ToolChoice
+}
+
+// PromptCacheConfig selects the parts of a request that are cached by
+// providers that need explicit cache breakpoints, such as Anthropic. Providers
+// that cache prompts automatically, such as OpenAI, ignore it.
+type PromptCacheConfig struct {
+	System   bool          // Cache the system prompt
+	Tools    bool          // Cache the tool definitions
+	Messages bool          // Cache the conversation up to the latest user message
+	TTL      time.Duration // How long cached prompts are kept (0 = provider default)
+}
+
+// DefaultPromptCacheConfig caches the system prompt, the tool definitions and
+// the conversation, so that each iteration of a tool loop and each turn of a
+// conversation reads the prompt of the previous one from the cache. Prompt
+// caching is off unless it is configured, for example with
+// WithPromptCache(DefaultPromptCacheConfig()).
+func DefaultPromptCacheConfig() PromptCacheConfig {
+	return PromptCacheConfig{System: true, Tools: true, Messages: true}
 }
 
 type LLMConfig struct {
@@ -40,6 +85,8 @@ type LLMConfig struct {
 	Reasoning        string   // Reasoning mode (minimal, low, medium, high) to control reasoning effort
 	EnableReasoning  bool     // Enable native reasoning tokens (Anthropic thinking/OpenAI o1)
 	ReasoningBudget  int      // Optional token budget for reasoning (Anthropic only)
+	TopK             int      // Sample from the top K tokens only (Anthropic, Gemini, Ollama and vLLM)
+	RepeatPenalty    float64  // Penalty for repeated tokens (Ollama and vLLM)
 }
 
 // WithMaxIterations creates a GenerateOption to set the maximum number of tool-calling iterations
@@ -56,6 +103,41 @@ func WithMemory(memory Memory) GenerateOption {
 	}
 }
 
+// WithParallelToolCalls creates a GenerateOption that executes the tool calls of
+// one LLM turn concurrently, running at most maxParallel of them at a time
+func WithParallelToolCalls(maxParallel int) GenerateOption {
+	return func(options *GenerateOptions) {
+		options.MaxParallelToolCalls = maxParallel
+	}
+}
+
+// WithContentParts creates a GenerateOption that sends images, files or audio
+// along with the prompt
+func WithContentParts(parts ...ContentPart) GenerateOption {
+	return func(options *GenerateOptions) {
+		options.ContentParts = append(options.ContentParts, parts...)
+	}
+}
+
+// WithPromptCache creates a GenerateOption to configure prompt caching
+func WithPromptCache(config PromptCacheConfig) GenerateOption {
+	return func(options *GenerateOptions) {
+		options.PromptCache = &config
+	}
+}
+
+// WithoutPromptCache creates a GenerateOption that turns prompt caching off
+func WithoutPromptCache() GenerateOption {
+	return WithPromptCache(PromptCacheConfig{})
+}
+
+// WithToolChoice creates a GenerateOption to force or forbid tool use
+func WithToolChoice(choice ToolChoice) GenerateOption {
+	return func(options *GenerateOptions) {
+		options.ToolChoice = &choice
+	}
+}
+
 // WithStreamConfig creates a GenerateOption to set the streaming configuration
 func WithStreamConfig(config StreamConfig) GenerateOption {
 	return func(options *GenerateOptions) {
@@ -120,6 +202,26 @@ func WithPresencePenalty(presencePenalty float64) GenerateOption {
 	}
 }
 
+// WithTopK creates a GenerateOption to set the top_k
+func WithTopK(topK int) GenerateOption {
+	return func(options *GenerateOptions) {
+		if options.LLMConfig == nil {
+			options.LLMConfig = &LLMConfig{}
+		}
+		options.LLMConfig.TopK = topK
+	}
+}
+
+// WithRepeatPenalty creates a GenerateOption to set the repeat penalty
+func WithRepeatPenalty(repeatPenalty float64) GenerateOption {
+	return func(options *GenerateOptions) {
+		if options.LLMConfig == nil {
+			options.LLMConfig = &LLMConfig{}
+		}
+		options.LLMConfig.RepeatPenalty = repeatPenalty
+	}
+}
+
 // WithStopSequences creates a GenerateOption to set the stop sequences
 func WithStopSequences(stopSequences []string) GenerateOption {
 	return func(options *GenerateOptions) {
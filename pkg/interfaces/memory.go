@@ -26,6 +26,11 @@ type Message struct {
 	// Content is the content of the message
 	Content string
 
+	// Parts holds the content of a multimodal message, such as text with
+	// images. When set, it is sent instead of Content, and Content holds the
+	// text of the parts.
+	Parts []ContentPart
+
 	// Metadata contains additional information about the message
 	Metadata map[string]interface{}
 
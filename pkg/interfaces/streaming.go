@@ -57,6 +57,7 @@ type AgentStreamEvent struct {
 	Content      string                 `json:"content,omitempty"`
 	ToolCall     *ToolCallEvent         `json:"tool_call,omitempty"`
 	ThinkingStep string                 `json:"thinking_step,omitempty"`
+	Approval     *ToolApproval          `json:"approval,omitempty"`
 	Error        error                  `json:"error,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	Timestamp    time.Time              `json:"timestamp"`
@@ -72,6 +73,10 @@ const (
 	AgentEventToolResult AgentEventType = "tool_result"
 	AgentEventError      AgentEventType = "error"
 	AgentEventComplete   AgentEventType = "complete"
+
+	// AgentEventApprovalRequest is sent when a tool call waits for approval.
+	// The run resumes once a decision is made on the approval.
+	AgentEventApprovalRequest AgentEventType = "approval_request"
 )
 
 // ToolCallEvent represents a tool call in streaming context
@@ -35,6 +35,14 @@ type InternalTool interface {
 	Internal() bool
 }
 
+// ToolLookup is an optional interface that tools can implement to provide
+// other tools by name, such as tools of the run that were not given to the LLM
+// but that it calls anyway
+type ToolLookup interface {
+	// LookupTool returns the tool with the given name, or nil if there is none
+	LookupTool(name string) Tool
+}
+
 // ParameterSpec defines the specification for a tool parameter
 type ParameterSpec struct {
 	// Type is the data type of the parameter (string, number, boolean, etc.)
@@ -0,0 +1,50 @@
+package interfaces
+
+// ToolChoiceMode is how the model may use the tools it is given
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceModeAuto lets the model decide whether to call tools
+	ToolChoiceModeAuto ToolChoiceMode = "auto"
+	// ToolChoiceModeNone forbids tool calls
+	ToolChoiceModeNone ToolChoiceMode = "none"
+	// ToolChoiceModeRequired makes the model call at least one tool
+	ToolChoiceModeRequired ToolChoiceMode = "required"
+	// ToolChoiceModeTool makes the model call the tool named in ToolChoice.Name
+	ToolChoiceModeTool ToolChoiceMode = "tool"
+)
+
+// ToolChoice controls whether and which tools the model calls
+type ToolChoice struct {
+	Mode ToolChoiceMode `json:"mode"`
+	Name string         `json:"name,omitempty"` // Tool to call with ToolChoiceModeTool
+}
+
+var (
+	// ToolChoiceAuto lets the model decide whether to call tools
+	ToolChoiceAuto = ToolChoice{Mode: ToolChoiceModeAuto}
+	// ToolChoiceNone forbids tool calls
+	ToolChoiceNone = ToolChoice{Mode: ToolChoiceModeNone}
+	// ToolChoiceRequired makes the model call at least one tool
+	ToolChoiceRequired = ToolChoice{Mode: ToolChoiceModeRequired}
+)
+
+// ToolChoiceFunction makes the model call the named tool
+func ToolChoiceFunction(name string) ToolChoice {
+	return ToolChoice{Mode: ToolChoiceModeTool, Name: name}
+}
+
+// ForTurn returns the tool choice for a turn of a tool-calling loop, where
+// turn 1 is the first request. Forcing a tool call only applies to the first
+// turn; later turns are auto so that the model can answer with the tool
+// results instead of calling tools until the iteration limit. Forbidding tool
+// calls applies to every turn. It returns nil if no choice was set.
+func (c *ToolChoice) ForTurn(turn int) *ToolChoice {
+	if c == nil {
+		return nil
+	}
+	if turn > 1 && (c.Mode == ToolChoiceModeRequired || c.Mode == ToolChoiceModeTool) {
+		return &ToolChoice{Mode: ToolChoiceModeAuto}
+	}
+	return c
+}
@@ -0,0 +1,30 @@
+package interfaces
+
+import "testing"
+
+func TestToolChoiceForTurn(t *testing.T) {
+	var unset *ToolChoice
+	if unset.ForTurn(1) != nil {
+		t.Error("Expected no choice when none is set")
+	}
+
+	tests := []struct {
+		choice ToolChoice
+		first  ToolChoiceMode
+		later  ToolChoiceMode
+	}{
+		{ToolChoiceAuto, ToolChoiceModeAuto, ToolChoiceModeAuto},
+		{ToolChoiceNone, ToolChoiceModeNone, ToolChoiceModeNone},
+		{ToolChoiceRequired, ToolChoiceModeRequired, ToolChoiceModeAuto},
+		{ToolChoiceFunction("extract_invoice"), ToolChoiceModeTool, ToolChoiceModeAuto},
+	}
+	for _, tt := range tests {
+		choice := tt.choice
+		if got := choice.ForTurn(1).Mode; got != tt.first {
+			t.Errorf("%v: expected %s on the first turn, got %s", tt.choice, tt.first, got)
+		}
+		if got := choice.ForTurn(2).Mode; got != tt.later {
+			t.Errorf("%v: expected %s on later turns, got %s", tt.choice, tt.later, got)
+		}
+	}
+}
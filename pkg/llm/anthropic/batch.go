@@ -0,0 +1,319 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+)
+
+// batchCustomIDPattern is the format of custom IDs accepted by the Message Batches API
+var batchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// batchErrorStatus maps the error types of batch results, which have no status
+// code, to the status the Messages API returns them with
+var batchErrorStatus = map[string]int{
+	"invalid_request_error": http.StatusBadRequest,
+	"authentication_error":  http.StatusUnauthorized,
+	"permission_error":      http.StatusForbidden,
+	"not_found_error":       http.StatusNotFound,
+	"request_too_large":     http.StatusRequestEntityTooLarge,
+	"rate_limit_error":      http.StatusTooManyRequests,
+	"api_error":             http.StatusInternalServerError,
+	"overloaded_error":      529,
+}
+
+// batchRequest is one request of a message batch
+type batchRequest struct {
+	CustomID string            `json:"custom_id"`
+	Params   CompletionRequest `json:"params"`
+}
+
+// messageBatch is a message batch as returned by the Message Batches API
+type messageBatch struct {
+	ID                string     `json:"id"`
+	ProcessingStatus  string     `json:"processing_status"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	CancelInitiatedAt *time.Time `json:"cancel_initiated_at"`
+	ResultsURL        string     `json:"results_url"`
+	RequestCounts     struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+}
+
+// batchResultLine is one line of the results of a message batch
+type batchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string              `json:"type"` // succeeded, errored, canceled or expired
+		Message *CompletionResponse `json:"message"`
+		Error   *struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+// SubmitBatch creates a message batch. Each request is built the way
+// Generate builds it, except that structured output requests are not
+// prefilled with "{", as their results could not be told apart; use
+// llm.UnmarshalBatchResult to decode them. Batches are not available on
+// Vertex AI.
+func (c *AnthropicClient) SubmitBatch(ctx context.Context, requests []interfaces.BatchRequest) (*interfaces.Batch, error) {
+	if err := c.checkBatchSupport(); err != nil {
+		return nil, err
+	}
+	if err := llm.ValidateBatchRequests(requests); err != nil {
+		return nil, err
+	}
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+	if _, err := multitenancy.GetOrgID(ctx); err != nil {
+		ctx = multitenancy.WithOrgID(ctx, "default")
+	}
+
+	body := struct {
+		Requests []batchRequest `json:"requests"`
+	}{Requests: make([]batchRequest, 0, len(requests))}
+	extendedCacheTTL := false
+	for _, request := range requests {
+		if !batchCustomIDPattern.MatchString(request.CustomID) {
+			return nil, fmt.Errorf("invalid custom ID %q: use 1 to 64 letters, digits, '-' or '_'", request.CustomID)
+		}
+
+		params := &interfaces.GenerateOptions{
+			LLMConfig: &interfaces.LLMConfig{
+				Temperature: 0.7, // Default temperature
+			},
+		}
+		for _, option := range request.Options {
+			option(params)
+		}
+
+		req, err := c.newCompletionRequest(ctx, request.Prompt, params, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request %s: %w", request.CustomID, err)
+		}
+		extendedCacheTTL = extendedCacheTTL || usesExtendedCacheTTL(&req)
+		body.Requests = append(body.Requests, batchRequest{CustomID: request.CustomID, Params: req})
+	}
+
+	var batch messageBatch
+	if err := c.batchRequest(ctx, http.MethodPost, c.BaseURL+"/v1/messages/batches", body, extendedCacheTTL, &batch); err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	c.logger.Info(ctx, "Submitted Anthropic message batch", map[string]interface{}{
+		"batch_id": batch.ID,
+		"requests": len(requests),
+		"model":    c.Model,
+	})
+	return convertBatch(&batch), nil
+}
+
+// GetBatch returns the current state of a message batch
+func (c *AnthropicClient) GetBatch(ctx context.Context, batchID string) (*interfaces.Batch, error) {
+	batch, err := c.getBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return convertBatch(batch), nil
+}
+
+// CancelBatch cancels a message batch. Requests that already finished keep
+// their results.
+func (c *AnthropicClient) CancelBatch(ctx context.Context, batchID string) (*interfaces.Batch, error) {
+	if err := c.checkBatchSupport(); err != nil {
+		return nil, err
+	}
+
+	var batch messageBatch
+	if err := c.batchRequest(ctx, http.MethodPost, c.BaseURL+"/v1/messages/batches/"+batchID+"/cancel", nil, false, &batch); err != nil {
+		return nil, fmt.Errorf("failed to cancel batch: %w", err)
+	}
+	return convertBatch(&batch), nil
+}
+
+// GetBatchResults downloads the results of a finished message batch. The
+// token usage of each successful request is recorded in the usage collector
+// of the context.
+func (c *AnthropicClient) GetBatchResults(ctx context.Context, batchID string) (map[string]interfaces.BatchResult, error) {
+	batch, err := c.getBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.ProcessingStatus != "ended" || batch.ResultsURL == "" {
+		return nil, fmt.Errorf("batch %s is %s: %w", batchID, batch.ProcessingStatus, interfaces.ErrBatchInProgress)
+	}
+
+	httpResp, err := c.sendBatchRequest(ctx, http.MethodGet, batch.ResultsURL, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download batch results: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	results := make(map[string]interfaces.BatchResult)
+	decoder := json.NewDecoder(httpResp.Body)
+	for {
+		var line batchResultLine
+		if err := decoder.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				return results, nil
+			}
+			return nil, fmt.Errorf("failed to decode batch results: %w", err)
+		}
+		results[line.CustomID] = c.batchResult(ctx, line)
+	}
+}
+
+// batchResult converts one line of the results of a message batch
+func (c *AnthropicClient) batchResult(ctx context.Context, line batchResultLine) interfaces.BatchResult {
+	result := interfaces.BatchResult{CustomID: line.CustomID}
+	switch line.Result.Type {
+	case "succeeded":
+		if line.Result.Message == nil {
+			result.Err = errors.New("batch result has no message")
+			break
+		}
+		c.recordUsage(ctx, line.Result.Message.Model, line.Result.Message.Usage, 0)
+
+		var contentText []string
+		for _, block := range line.Result.Message.Content {
+			if block.Type == "text" {
+				contentText = append(contentText, block.Text)
+			}
+		}
+		if len(contentText) == 0 {
+			result.Err = fmt.Errorf("no text content in response")
+			break
+		}
+		result.Content = strings.Join(contentText, "\n")
+	case "errored":
+		var code, message string
+		if line.Result.Error != nil {
+			code, message = line.Result.Error.Error.Type, line.Result.Error.Error.Message
+		}
+		result.Err = llm.NewProviderError(c.Name(), batchErrorStatus[code], code, message, nil, nil)
+	default:
+		result.Err = fmt.Errorf("request was %s before it was processed", line.Result.Type)
+	}
+	return result
+}
+
+// getBatch fetches a message batch
+func (c *AnthropicClient) getBatch(ctx context.Context, batchID string) (*messageBatch, error) {
+	if err := c.checkBatchSupport(); err != nil {
+		return nil, err
+	}
+
+	var batch messageBatch
+	if err := c.batchRequest(ctx, http.MethodGet, c.BaseURL+"/v1/messages/batches/"+batchID, nil, false, &batch); err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+	return &batch, nil
+}
+
+// checkBatchSupport returns an error when the client uses Vertex AI, which
+// has its own batch prediction API
+func (c *AnthropicClient) checkBatchSupport() error {
+	if c.VertexConfig != nil && c.VertexConfig.Enabled {
+		return errors.New("message batches are not supported with Vertex AI")
+	}
+	return nil
+}
+
+// batchRequest sends a Message Batches API request and decodes its response into out
+func (c *AnthropicClient) batchRequest(ctx context.Context, method, url string, body interface{}, extendedCacheTTL bool, out interface{}) error {
+	httpResp, err := c.sendBatchRequest(ctx, method, url, body, extendedCacheTTL)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if err := json.NewDecoder(httpResp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// sendBatchRequest sends a Message Batches API request and returns the
+// response if it succeeded
+func (c *AnthropicClient) sendBatchRequest(ctx context.Context, method, url string, body interface{}, extendedCacheTTL bool) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-API-Key", c.APIKey)
+	httpReq.Header.Set("Anthropic-Version", "2023-06-01")
+	if extendedCacheTTL {
+		httpReq.Header.Set("Anthropic-Beta", extendedCacheTTLBeta)
+	}
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", llm.ClassifyError(c.Name(), err))
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("error from Anthropic API: %w", llm.NewHTTPError(c.Name(), httpResp.StatusCode, httpResp.Header, respBody))
+	}
+	return httpResp, nil
+}
+
+// convertBatch converts a message batch to the provider-neutral description
+func convertBatch(batch *messageBatch) *interfaces.Batch {
+	counts := batch.RequestCounts
+	converted := &interfaces.Batch{
+		ID:             batch.ID,
+		ProviderStatus: batch.ProcessingStatus,
+		Total:          counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+		Succeeded:      counts.Succeeded,
+		Failed:         counts.Errored + counts.Canceled + counts.Expired,
+		CreatedAt:      batch.CreatedAt,
+		ExpiresAt:      batch.ExpiresAt,
+	}
+
+	switch {
+	case batch.ProcessingStatus != "ended":
+		// in_progress and canceling
+		converted.Status = interfaces.BatchStatusInProgress
+	case batch.CancelInitiatedAt != nil:
+		converted.Status = interfaces.BatchStatusCanceled
+	case counts.Expired > 0:
+		converted.Status = interfaces.BatchStatusExpired
+	default:
+		converted.Status = interfaces.BatchStatusCompleted
+	}
+	return converted
+}
+
+var _ interfaces.BatchLLM = (*AnthropicClient)(nil)
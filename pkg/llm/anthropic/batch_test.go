@@ -0,0 +1,127 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
+)
+
+func TestBatch(t *testing.T) {
+	var submitted []batchRequest
+	polls := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("X-API-Key"))
+		w.Header().Set("Content-Type", "application/json")
+		status := "in_progress"
+		if polls > 1 {
+			status = "ended"
+		}
+		batch := fmt.Sprintf(`{"id":"msgbatch_1","type":"message_batch","processing_status":%q,
+			"created_at":"2024-09-24T18:37:24Z","expires_at":"2024-09-25T18:37:24Z","results_url":%q,
+			"request_counts":{"processing":0,"succeeded":1,"errored":1,"canceled":0,"expired":0}}`,
+			status, server.URL+"/v1/messages/batches/msgbatch_1/results")
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+			var body struct {
+				Requests []batchRequest `json:"requests"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			submitted = body.Requests
+			_, _ = io.WriteString(w, batch)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_1":
+			polls++
+			_, _ = io.WriteString(w, batch)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_1/results":
+			_, _ = io.WriteString(w, `{"custom_id":"doc-1","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"{\"label\":\"invoice\"}"}],"usage":{"input_tokens":10,"output_tokens":5}}}}`+"\n")
+			_, _ = io.WriteString(w, `{"custom_id":"doc-2","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens is too large"}}}}`+"\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithModel("claude-sonnet-4-20250514"))
+
+	type label struct {
+		Label string `json:"label"`
+	}
+	requests := []interfaces.BatchRequest{
+		{CustomID: "doc-1", Prompt: "Classify document 1", Options: []interfaces.GenerateOption{
+			WithResponseFormat(*structuredoutput.NewResponseFormat(label{})),
+		}},
+		{CustomID: "doc-2", Prompt: "Classify document 2"},
+	}
+
+	ctx := llm.WithUsageCollection(context.Background())
+	results, err := llm.RunBatch(ctx, client, requests, time.Millisecond)
+	require.NoError(t, err)
+
+	require.Len(t, submitted, 2)
+	assert.Equal(t, "doc-1", submitted[0].CustomID)
+	assert.Equal(t, "claude-sonnet-4-20250514", submitted[0].Params.Model)
+	assert.Contains(t, submitted[0].Params.System, "valid JSON")
+	assert.Equal(t, "user", submitted[0].Params.Messages[len(submitted[0].Params.Messages)-1].Role, "batch requests are not prefilled")
+
+	require.Len(t, results, 2)
+	var decoded label
+	require.NoError(t, llm.UnmarshalBatchResult(results["doc-1"], &decoded))
+	assert.Equal(t, "invoice", decoded.Label)
+	assert.True(t, errors.Is(results["doc-2"].Err, interfaces.ErrInvalidRequest))
+	assert.Contains(t, results["doc-2"].Err.Error(), "max_tokens is too large")
+
+	usage := llm.GetUsageFromContext(ctx)
+	require.Len(t, usage, 1)
+	assert.Equal(t, 15, usage[0].Usage.TotalTokens)
+}
+
+func TestSubmitBatchValidation(t *testing.T) {
+	client := NewClient("test-key", WithModel("claude-sonnet-4-20250514"))
+	_, err := client.SubmitBatch(context.Background(), []interfaces.BatchRequest{{CustomID: "doc 1", Prompt: "Hello"}})
+	assert.ErrorContains(t, err, "invalid custom ID")
+
+	client.VertexConfig = &VertexConfig{Enabled: true}
+	_, err = client.SubmitBatch(context.Background(), []interfaces.BatchRequest{{CustomID: "doc-1", Prompt: "Hello"}})
+	assert.ErrorContains(t, err, "Vertex AI")
+}
+
+func TestConvertBatch(t *testing.T) {
+	canceled := time.Now()
+	tests := []struct {
+		name     string
+		batch    messageBatch
+		expected interfaces.BatchStatus
+	}{
+		{"in progress", messageBatch{ProcessingStatus: "in_progress"}, interfaces.BatchStatusInProgress},
+		{"canceling", messageBatch{ProcessingStatus: "canceling", CancelInitiatedAt: &canceled}, interfaces.BatchStatusInProgress},
+		{"canceled", messageBatch{ProcessingStatus: "ended", CancelInitiatedAt: &canceled}, interfaces.BatchStatusCanceled},
+		{"completed", messageBatch{ProcessingStatus: "ended"}, interfaces.BatchStatusCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertBatch(&tt.batch).Status)
+		})
+	}
+
+	expired := messageBatch{ProcessingStatus: "ended"}
+	expired.RequestCounts.Succeeded = 2
+	expired.RequestCounts.Expired = 1
+	batch := convertBatch(&expired)
+	assert.Equal(t, interfaces.BatchStatusExpired, batch.Status)
+	assert.Equal(t, 3, batch.Total)
+	assert.Equal(t, 1, batch.Failed)
+}
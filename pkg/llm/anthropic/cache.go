@@ -0,0 +1,111 @@
+package anthropic
+
+import (
+	"strings"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// extendedCacheTTLBeta is the beta that enables the 1 hour cache TTL
+const extendedCacheTTLBeta = "extended-cache-ttl-2025-04-11"
+
+// CacheControl marks the end of a prompt prefix to be cached
+type CacheControl struct {
+	Type string `json:"type"`          // Always "ephemeral"
+	TTL  string `json:"ttl,omitempty"` // "5m" (default) or "1h"
+}
+
+// WithPromptCache turns on prompt caching for every request of the client.
+// Requests that set interfaces.WithPromptCache or interfaces.WithoutPromptCache
+// override it.
+func WithPromptCache(config interfaces.PromptCacheConfig) Option {
+	return func(c *AnthropicClient) {
+		c.promptCache = &config
+	}
+}
+
+// applyPromptCache places cache breakpoints on the tool definitions, the
+// system prompt and the conversation up to the latest user message, as
+// configured by interfaces.WithPromptCache or the client's WithPromptCache.
+// Without either, no breakpoints are placed, since writing to the cache
+// costs more than an uncached prompt. Anthropic caches the prompt up to each
+// breakpoint, so the next iteration of a tool loop, which only appends
+// messages, reads everything before them from the cache. Prompts shorter than
+// the model's minimum cacheable length are not cached.
+//
+// The tools and messages of the request are copied before they are marked,
+// so the slices shared between iterations keep no stale breakpoints.
+func (c *AnthropicClient) applyPromptCache(req *CompletionRequest, params *interfaces.GenerateOptions) {
+	config := c.promptCache
+	if params != nil && params.PromptCache != nil {
+		config = params.PromptCache
+	}
+	if config == nil {
+		return
+	}
+
+	cacheControl := &CacheControl{Type: "ephemeral"}
+	if config.TTL >= time.Hour {
+		cacheControl.TTL = "1h"
+	}
+
+	if config.Tools && len(req.Tools) > 0 {
+		tools := append([]Tool(nil), req.Tools...)
+		tools[len(tools)-1].CacheControl = cacheControl
+		req.Tools = tools
+	}
+
+	if config.System && req.System != "" {
+		req.SystemBlocks = []InputBlock{{Type: "text", Text: req.System, CacheControl: cacheControl}}
+	}
+
+	if config.Messages {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role != "user" {
+				continue
+			}
+			messages := append([]Message(nil), req.Messages...)
+			messages[i] = withCacheControl(messages[i], cacheControl)
+			req.Messages = messages
+			break
+		}
+	}
+}
+
+// withCacheControl returns a copy of the message with a cache breakpoint on
+// its last content block
+func withCacheControl(msg Message, cacheControl *CacheControl) Message {
+	blocks := append([]InputBlock(nil), msg.Blocks...)
+	if len(blocks) == 0 {
+		if strings.TrimSpace(msg.Content) == "" {
+			return msg
+		}
+		blocks = []InputBlock{{Type: "text", Text: msg.Content}}
+	}
+	blocks[len(blocks)-1].CacheControl = cacheControl
+	msg.Blocks = blocks
+	return msg
+}
+
+// usesExtendedCacheTTL reports whether the request has a breakpoint with the 1 hour TTL
+func usesExtendedCacheTTL(req *CompletionRequest) bool {
+	for _, block := range req.SystemBlocks {
+		if block.CacheControl != nil && block.CacheControl.TTL == "1h" {
+			return true
+		}
+	}
+	for _, tool := range req.Tools {
+		if tool.CacheControl != nil && tool.CacheControl.TTL == "1h" {
+			return true
+		}
+	}
+	for _, msg := range req.Messages {
+		for _, block := range msg.Blocks {
+			if block.CacheControl != nil && block.CacheControl.TTL == "1h" {
+				return true
+			}
+		}
+	}
+	return false
+}
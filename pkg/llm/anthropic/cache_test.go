@@ -0,0 +1,157 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+func TestApplyPromptCache(t *testing.T) {
+	tools := []Tool{{Name: "search"}, {Name: "calculator"}}
+	messages := []Message{
+		{Role: "user", Content: "What is 2+2?"},
+		{Role: "assistant", Content: "Let me calculate"},
+		{Role: "user", Content: "Tool result for call_1: 4"},
+		{Role: "assistant", Content: "{"},
+	}
+	req := CompletionRequest{System: "You are helpful", Tools: tools, Messages: messages}
+
+	client := NewClient("test-key", WithPromptCache(interfaces.DefaultPromptCacheConfig()))
+	client.applyPromptCache(&req, &interfaces.GenerateOptions{})
+
+	ephemeral := &CacheControl{Type: "ephemeral"}
+	assert.Nil(t, req.Tools[0].CacheControl)
+	assert.Equal(t, ephemeral, req.Tools[1].CacheControl)
+	assert.Equal(t, []InputBlock{{Type: "text", Text: "You are helpful", CacheControl: ephemeral}}, req.SystemBlocks)
+
+	// The breakpoint goes on the latest user message, not on the prefill
+	assert.Empty(t, req.Messages[0].Blocks)
+	assert.Equal(t, []InputBlock{{Type: "text", Text: "Tool result for call_1: 4", CacheControl: ephemeral}}, req.Messages[2].Blocks)
+	assert.Empty(t, req.Messages[3].Blocks)
+
+	// The slices shared with later iterations are left unchanged
+	assert.Nil(t, tools[1].CacheControl)
+	assert.Empty(t, messages[2].Blocks)
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"type":          "text",
+		"text":          "You are helpful",
+		"cache_control": map[string]interface{}{"type": "ephemeral"},
+	}}, body["system"])
+
+	var decoded CompletionRequest
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "You are helpful", decoded.System)
+	assert.Equal(t, req.SystemBlocks, decoded.SystemBlocks)
+}
+
+func TestApplyPromptCacheConfig(t *testing.T) {
+	req := CompletionRequest{
+		System:   "You are helpful",
+		Tools:    []Tool{{Name: "search"}},
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}
+
+	// Caching is off unless it is configured
+	client := NewClient("test-key")
+	client.applyPromptCache(&req, &interfaces.GenerateOptions{})
+	assert.Nil(t, req.Tools[0].CacheControl)
+	assert.Empty(t, req.SystemBlocks)
+	assert.Empty(t, req.Messages[0].Blocks)
+
+	// A request can turn off the client's caching
+	client = NewClient("test-key", WithPromptCache(interfaces.DefaultPromptCacheConfig()))
+	client.applyPromptCache(&req, &interfaces.GenerateOptions{PromptCache: &interfaces.PromptCacheConfig{}})
+	assert.Nil(t, req.Tools[0].CacheControl)
+	assert.Empty(t, req.SystemBlocks)
+	assert.Empty(t, req.Messages[0].Blocks)
+	assert.False(t, usesExtendedCacheTTL(&req))
+
+	client.applyPromptCache(&req, &interfaces.GenerateOptions{PromptCache: &interfaces.PromptCacheConfig{Tools: true, TTL: time.Hour}})
+	assert.Equal(t, &CacheControl{Type: "ephemeral", TTL: "1h"}, req.Tools[0].CacheControl)
+	assert.Empty(t, req.SystemBlocks)
+	assert.True(t, usesExtendedCacheTTL(&req))
+}
+
+func TestGenerateWithToolsPromptCache(t *testing.T) {
+	var requests []map[string]interface{}
+	var betaHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		betaHeader = r.Header.Get("Anthropic-Beta")
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-sonnet-4-20250514",
+			"stop_reason": "end_turn",
+			"content":     []map[string]interface{}{{"type": "text", "text": "4"}},
+			"usage": map[string]interface{}{
+				"input_tokens":                10,
+				"output_tokens":               5,
+				"cache_read_input_tokens":     2000,
+				"cache_creation_input_tokens": 100,
+			},
+		}))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	ctx := llm.WithUsageCollection(context.Background())
+	response, err := client.GenerateWithTools(ctx, "What is 2+2?", []interfaces.Tool{&cacheTestTool{}},
+		interfaces.WithSystemMessage("You are a calculator"),
+		interfaces.WithPromptCache(interfaces.PromptCacheConfig{System: true, Tools: true, Messages: true, TTL: time.Hour}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "4", response)
+
+	require.Len(t, requests, 1)
+	body := requests[0]
+	oneHour := map[string]interface{}{"type": "ephemeral", "ttl": "1h"}
+	tools := body["tools"].([]interface{})
+	assert.Equal(t, oneHour, tools[0].(map[string]interface{})["cache_control"])
+	system := body["system"].([]interface{})
+	assert.Equal(t, oneHour, system[0].(map[string]interface{})["cache_control"])
+	messages := body["messages"].([]interface{})
+	content := messages[0].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, oneHour, content[0].(map[string]interface{})["cache_control"])
+	assert.Equal(t, extendedCacheTTLBeta, betaHeader)
+
+	usage := llm.GetUsageFromContext(ctx)
+	require.Len(t, usage, 1)
+	assert.Equal(t, 2000, usage[0].Usage.CachedInputTokens)
+	assert.Equal(t, 100, usage[0].Usage.CacheCreationTokens)
+	assert.Equal(t, 2110, usage[0].Usage.InputTokens)
+}
+
+// cacheTestTool is a tool that is never called
+type cacheTestTool struct{}
+
+func (t *cacheTestTool) Name() string        { return "calculator" }
+func (t *cacheTestTool) Description() string { return "Evaluates arithmetic expressions" }
+func (t *cacheTestTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{
+		"expression": {Type: "string", Description: "Expression to evaluate", Required: true},
+	}
+}
+func (t *cacheTestTool) Run(ctx context.Context, input string) (string, error) { return "", nil }
+func (t *cacheTestTool) Execute(ctx context.Context, args string) (string, error) {
+	return "", nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 	"github.com/andmang/agent-sdk-go/pkg/retry"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
 )
 
 // AnthropicClient implements the LLM interface for Anthropic
@@ -27,6 +28,7 @@ type AnthropicClient struct {
 	retryExecutor       *retry.Executor
 	vertexRetryExecutor *VertexRetryExecutor
 	VertexConfig        *VertexConfig
+	promptCache         *interfaces.PromptCacheConfig
 }
 
 // Option represents an option for configuring the Anthropic client
@@ -226,35 +228,67 @@ const (
 	ClaudeOpus41   = "claude-opus-4-1-20250805"   // Latest Opus 4.1
 )
 
-// SupportsThinking returns true if the model supports thinking tokens
+// SupportsThinking returns true if the model supports thinking tokens,
+// according to llm.LookupModel
 func SupportsThinking(model string) bool {
-	supportedModels := []string{
-		"claude-3-7-sonnet-20250219",
-		"claude-sonnet-4-20250514",
-		"claude-opus-4-20250514",
-		"claude-opus-4-1-20250805",
-		"claude-sonnet-4-5-20250929",
-		// Vertex AI format models
-		"claude-sonnet-4@20250514",
-		"claude-sonnet-4-v1@20250514",
-		"claude-sonnet-4-5@20250929",
-		"claude-opus-4@20250514",
-		"claude-opus-4-v1@20250514",
-		"claude-opus-4-1@20250805",
-	}
-
-	for _, supportedModel := range supportedModels {
-		if model == supportedModel {
-			return true
-		}
-	}
-	return false
+	info, ok := llm.LookupModel(model)
+	return ok && info.SupportsReasoning
 }
 
 // Message represents a message for Anthropic API
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Blocks holds the content of a multimodal message. When set, it is sent
+	// as the content instead of Content.
+	Blocks []InputBlock `json:"-"`
+}
+
+// InputBlock represents a content block of a request message
+type InputBlock struct {
+	Type         string        `json:"type"` // "text", "image" or "document"
+	Text         string        `json:"text,omitempty"`
+	Source       *BlockSource  `json:"source,omitempty"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// BlockSource represents the source of an image or document block
+type BlockSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// MarshalJSON sends Blocks as the message content when it is set
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) == 0 {
+		type plainMessage Message
+		return json.Marshal(plainMessage(m))
+	}
+	return json.Marshal(struct {
+		Role    string       `json:"role"`
+		Content []InputBlock `json:"content"`
+	}{Role: m.Role, Content: m.Blocks})
+}
+
+// UnmarshalJSON reads content given either as a string or as blocks
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Message{Role: raw.Role}
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] == '[' {
+		return json.Unmarshal(raw.Content, &m.Blocks)
+	}
+	return json.Unmarshal(raw.Content, &m.Content)
 }
 
 // ToolUse represents a tool call for Anthropic API
@@ -289,6 +323,49 @@ type CompletionRequest struct {
 	MetadataKey      string         `json:"metadata,omitempty"`
 	AnthropicVersion string         `json:"anthropic_version,omitempty"` // For Vertex AI
 	Thinking         *ReasoningSpec `json:"thinking,omitempty"`          // Keep "thinking" for API compatibility
+	// SystemBlocks holds the system prompt as content blocks, e.g. to mark it
+	// for caching. When set, it is sent as the system prompt instead of System.
+	SystemBlocks []InputBlock `json:"-"`
+}
+
+// MarshalJSON sends SystemBlocks as the system prompt when it is set
+func (r CompletionRequest) MarshalJSON() ([]byte, error) {
+	type plainRequest CompletionRequest
+	if len(r.SystemBlocks) == 0 {
+		return json.Marshal(plainRequest(r))
+	}
+	return json.Marshal(struct {
+		plainRequest
+		System []InputBlock `json:"system"`
+	}{plainRequest: plainRequest(r), System: r.SystemBlocks})
+}
+
+// UnmarshalJSON reads a system prompt given either as a string or as blocks
+func (r *CompletionRequest) UnmarshalJSON(data []byte) error {
+	type plainRequest CompletionRequest
+	var raw struct {
+		plainRequest
+		System json.RawMessage `json:"system"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = CompletionRequest(raw.plainRequest)
+	if len(raw.System) == 0 || string(raw.System) == "null" {
+		return nil
+	}
+	if raw.System[0] == '[' {
+		if err := json.Unmarshal(raw.System, &r.SystemBlocks); err != nil {
+			return err
+		}
+		var texts []string
+		for _, block := range r.SystemBlocks {
+			texts = append(texts, block.Text)
+		}
+		r.System = strings.Join(texts, "\n")
+		return nil
+	}
+	return json.Unmarshal(raw.System, &r.System)
 }
 
 // ReasoningSpec represents the reasoning configuration for Anthropic API
@@ -300,9 +377,10 @@ type ReasoningSpec struct {
 
 // Tool represents a tool definition for Anthropic API
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"input_schema"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"input_schema"`
+	CacheControl *CacheControl          `json:"cache_control,omitempty"`
 }
 
 // ContentBlock represents a content block in Anthropic API response
@@ -329,8 +407,24 @@ type CompletionResponse struct {
 
 // Usage represents token usage information
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// ToTokenUsage converts Anthropic usage to the provider-neutral format.
+// Anthropic reports cache reads and writes separately from input_tokens, so
+// they are added back to get the total number of prompt tokens.
+func (u Usage) ToTokenUsage() llm.TokenUsage {
+	input := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return llm.TokenUsage{
+		InputTokens:         input,
+		OutputTokens:        u.OutputTokens,
+		CachedInputTokens:   u.CacheReadInputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+		TotalTokens:         input + u.OutputTokens,
+	}
 }
 
 // WithReasoning creates a GenerateOption to set the reasoning mode
@@ -375,89 +469,12 @@ func (c *AnthropicClient) Generate(ctx context.Context, prompt string, options .
 		ctx = multitenancy.WithOrgID(ctx, defaultOrgID)
 	}
 
-	// Build messages with memory and current prompt
-	messages := c.buildMessagesWithMemory(ctx, prompt, params)
-
-	// Handle structured output if requested
-	if params.ResponseFormat != nil {
-		// Convert the schema to a string representation for the prompt
-		schemaJSON, err := json.MarshalIndent(params.ResponseFormat.Schema, "", "  ")
-		if err != nil {
-			return "", fmt.Errorf("failed to marshal response format schema: %w", err)
-		}
-
-		// Create an example JSON structure based on the schema
-		exampleJSON := createExampleFromSchema(params.ResponseFormat.Schema)
-		exampleStr, _ := json.MarshalIndent(exampleJSON, "", "  ")
-
-		// Enhance the user prompt with schema information and example
-		// Using best practices from Claude documentation for consistency
-		messages[0].Content = fmt.Sprintf(`%s
-
-You must respond with a valid JSON object that exactly follows this schema:
-%s
-
-Here is an example of the expected JSON structure:
-%s
-
-CRITICAL INSTRUCTIONS:
-- Output ONLY valid JSON, no additional text before or after
-- Follow the EXACT structure shown in the schema and example
-- Use the field names exactly as specified
-- Ensure all required fields are present
-- Pay special attention to array fields - they must be arrays of objects, not simple objects
-- If a field is defined as an array in the schema, it MUST be an array in your response
-- The JSON must be directly parsable and match the schema precisely`, prompt, string(schemaJSON), string(exampleStr))
-
-		// Add assistant message prefill to enforce JSON output
-		// This helps Claude start the response correctly as JSON
-		messages = append(messages, Message{
-			Role:    "assistant",
-			Content: "{",
-		})
-
-		c.logger.Debug(ctx, "Using structured output format with prefill", map[string]interface{}{
-			"schema_name": params.ResponseFormat.Name,
-		})
-	}
-
-	// Create request
-	req := CompletionRequest{
-		Model:       c.Model,
-		Messages:    messages,
-		MaxTokens:   2048,
-		Temperature: params.LLMConfig.Temperature,
-		TopP:        params.LLMConfig.TopP,
-	}
-
-	// Add system message if available
-	if params.SystemMessage != "" {
-		// If structured output is requested, enhance the system message
-		if params.ResponseFormat != nil {
-			req.System = params.SystemMessage + "\n\nYou must respond with valid JSON that matches the specified schema."
-		} else {
-			req.System = params.SystemMessage
-		}
-		c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": req.System})
-	} else if params.ResponseFormat != nil {
-		// If no system message but structured output is requested, add a system message for JSON
-		req.System = "You must respond with valid JSON that matches the specified schema."
-		c.logger.Debug(ctx, "Added system message for structured output", nil)
-	}
-
-	// Add reasoning parameter if available
-	if params.LLMConfig != nil && params.LLMConfig.Reasoning != "" {
-		c.logger.Debug(ctx, "Reasoning mode not supported in current API version", map[string]interface{}{"reasoning": params.LLMConfig.Reasoning})
-	}
-
-	if params.LLMConfig != nil {
-		if len(params.LLMConfig.StopSequences) > 0 {
-			req.StopSequences = params.LLMConfig.StopSequences
-		}
+	req, err := c.newCompletionRequest(ctx, prompt, params, true)
+	if err != nil {
+		return "", err
 	}
 
 	var resp CompletionResponse
-	var err error
 
 	operation := func() error {
 		var apiType string
@@ -515,6 +532,9 @@ CRITICAL INSTRUCTIONS:
 			httpReq.Header.Set("Content-Type", "application/json")
 			httpReq.Header.Set("X-API-Key", c.APIKey)
 			httpReq.Header.Set("Anthropic-Version", "2023-06-01")
+			if usesExtendedCacheTTL(&req) {
+				httpReq.Header.Set("Anthropic-Beta", extendedCacheTTLBeta)
+			}
 		}
 
 		// Send request
@@ -524,7 +544,7 @@ CRITICAL INSTRUCTIONS:
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to send request: %w", err)
+			return fmt.Errorf("failed to send request: %w", llm.ClassifyError(c.Name(), err))
 		}
 		defer func() {
 			if closeErr := httpResp.Body.Close(); closeErr != nil {
@@ -547,7 +567,7 @@ CRITICAL INSTRUCTIONS:
 				"response":    string(respBody),
 				"model":       c.Model,
 			})
-			return fmt.Errorf("error from Anthropic API: %s", string(respBody))
+			return fmt.Errorf("error from Anthropic API: %w", llm.NewHTTPError(c.Name(), httpResp.StatusCode, httpResp.Header, respBody))
 		}
 
 		// Unmarshal response
@@ -582,6 +602,8 @@ CRITICAL INSTRUCTIONS:
 		return "", err
 	}
 
+	c.recordUsage(ctx, resp.Model, resp.Usage, 0)
+
 	// Extract text from content blocks
 	var contentText []string
 	for _, block := range resp.Content {
@@ -614,242 +636,118 @@ CRITICAL INSTRUCTIONS:
 	return response, nil
 }
 
-// Chat uses the messages API to have a conversation with a model
-func (c *AnthropicClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
-	// Check if model is specified
-	if c.Model == "" {
-		return "", fmt.Errorf("model not specified: use WithModel option when creating the client")
-	}
+// newCompletionRequest builds the request of a Generate call, which is also
+// used for batch requests. With prefill, structured output requests end with
+// an assistant message holding "{", which the response then lacks.
+func (c *AnthropicClient) newCompletionRequest(ctx context.Context, prompt string, params *interfaces.GenerateOptions, prefill bool) (CompletionRequest, error) {
+	// Build messages with memory and current prompt
+	messages := c.buildMessagesWithMemory(ctx, prompt, params)
 
-	if params == nil {
-		params = llm.DefaultGenerateParams()
-	}
+	// Handle structured output if requested
+	if params.ResponseFormat != nil {
+		// Convert the schema to a string representation for the prompt
+		schemaJSON, err := json.MarshalIndent(params.ResponseFormat.Schema, "", "  ")
+		if err != nil {
+			return CompletionRequest{}, fmt.Errorf("failed to marshal response format schema: %w", err)
+		}
 
-	// Convert messages to the Anthropic Chat format
-	anthropicMessages := make([]Message, len(messages))
-	var systemMessage string
+		// Create an example JSON structure based on the schema
+		exampleJSON := createExampleFromSchema(params.ResponseFormat.Schema)
+		exampleStr, _ := json.MarshalIndent(exampleJSON, "", "  ")
 
-	for i, msg := range messages {
-		// Check if it's a system message
-		if msg.Role == "system" {
-			systemMessage = msg.Content
-			// Skip this message in the regular messages array
-			continue
-		}
+		// Enhance the user prompt with schema information and example
+		// Using best practices from Claude documentation for consistency
+		messages[0].Content = fmt.Sprintf(`%s
 
-		// Map role names (Anthropic uses "assistant" and "user")
-		role := msg.Role
-		switch role {
-		case "assistant", "user":
-			// These roles are the same in Anthropic
-		case "tool":
-			// Tool messages need special handling
-			// For simplicity, we'll convert them to assistant messages
-			role = "assistant"
-		}
+You must respond with a valid JSON object that exactly follows this schema:
+%s
 
-		anthropicMessages[i] = Message{
-			Role:    role,
-			Content: msg.Content,
-		}
-	}
+Here is an example of the expected JSON structure:
+%s
 
-	// Filter out any nil messages (from system messages being skipped) and messages with empty content
-	var filteredMessages []Message
-	for _, msg := range anthropicMessages {
-		if msg.Role != "" && strings.TrimSpace(msg.Content) != "" {
-			filteredMessages = append(filteredMessages, msg)
+CRITICAL INSTRUCTIONS:
+- Output ONLY valid JSON, no additional text before or after
+- Follow the EXACT structure shown in the schema and example
+- Use the field names exactly as specified
+- Ensure all required fields are present
+- Pay special attention to array fields - they must be arrays of objects, not simple objects
+- If a field is defined as an array in the schema, it MUST be an array in your response
+- The JSON must be directly parsable and match the schema precisely`, prompt, string(schemaJSON), string(exampleStr))
+
+		// Add assistant message prefill to enforce JSON output
+		// This helps Claude start the response correctly as JSON
+		if prefill {
+			messages = append(messages, Message{
+				Role:    "assistant",
+				Content: "{",
+			})
 		}
+
+		c.logger.Debug(ctx, "Using structured output format with prefill", map[string]interface{}{
+			"schema_name": params.ResponseFormat.Name,
+		})
 	}
 
-	// Create chat request
+	// Create request
 	req := CompletionRequest{
-		Model:         c.Model,
-		Messages:      filteredMessages,
-		MaxTokens:     2048,
-		Temperature:   params.Temperature,
-		TopP:          params.TopP,
-		StopSequences: params.StopSequences,
+		Model:       c.Model,
+		Messages:    messages,
+		MaxTokens:   2048,
+		Temperature: params.LLMConfig.Temperature,
+		TopP:        params.LLMConfig.TopP,
+		TopK:        params.LLMConfig.TopK,
 	}
 
 	// Add system message if available
-	if systemMessage != "" {
-		req.System = systemMessage
+	if params.SystemMessage != "" {
+		// If structured output is requested, enhance the system message
+		if params.ResponseFormat != nil {
+			req.System = params.SystemMessage + "\n\nYou must respond with valid JSON that matches the specified schema."
+		} else {
+			req.System = params.SystemMessage
+		}
+		c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": req.System})
+	} else if params.ResponseFormat != nil {
+		// If no system message but structured output is requested, add a system message for JSON
+		req.System = "You must respond with valid JSON that matches the specified schema."
+		c.logger.Debug(ctx, "Added system message for structured output", nil)
 	}
 
 	// Add reasoning parameter if available
-	if params.Reasoning != "" {
-		c.logger.Debug(ctx, "Reasoning mode not supported in current API version", map[string]interface{}{"reasoning": params.Reasoning})
+	if params.LLMConfig != nil && params.LLMConfig.Reasoning != "" {
+		c.logger.Debug(ctx, "Reasoning mode not supported in current API version", map[string]interface{}{"reasoning": params.LLMConfig.Reasoning})
 	}
 
-	var resp CompletionResponse
-	var err error
-
-	operation := func() error {
-		var apiType string
-		if c.VertexConfig != nil && c.VertexConfig.Enabled {
-			apiType = "Vertex AI"
-		} else {
-			apiType = "Anthropic API"
-		}
-
-		c.logger.Debug(ctx, "Executing "+apiType+" Chat request", map[string]interface{}{
-			"model":          c.Model,
-			"temperature":    req.Temperature,
-			"top_p":          req.TopP,
-			"stop_sequences": req.StopSequences,
-			"messages":       len(req.Messages),
-		})
-
-		var httpReq *http.Request
-
-		if c.VertexConfig != nil && c.VertexConfig.Enabled {
-			// Vertex AI mode
-			httpReq, err = c.VertexConfig.CreateVertexHTTPRequest(ctx, &req, "POST", "/v1/messages")
-			if err != nil {
-				return fmt.Errorf("failed to create Vertex AI chat request: %w", err)
-			}
-		} else {
-			// Standard Anthropic API mode
-			// Convert request to JSON
-			reqBody, err := json.Marshal(req)
-			if err != nil {
-				return fmt.Errorf("failed to marshal request: %w", err)
-			}
-
-			// Create HTTP request
-			httpReq, err = http.NewRequestWithContext(
-				ctx,
-				"POST",
-				c.BaseURL+"/v1/messages",
-				bytes.NewBuffer(reqBody),
-			)
-			if err != nil {
-				return fmt.Errorf("failed to create request: %w", err)
-			}
-
-			// Set headers for standard API
-			httpReq.Header.Set("Content-Type", "application/json")
-			httpReq.Header.Set("X-API-Key", c.APIKey)
-			httpReq.Header.Set("Anthropic-Version", "2023-06-01")
-		}
-
-		// Send request
-		httpResp, err := c.HTTPClient.Do(httpReq)
-		if err != nil {
-			c.logger.Error(ctx, "Error from Anthropic Chat API", map[string]interface{}{
-				"error": err.Error(),
-				"model": c.Model,
-			})
-			return fmt.Errorf("failed to send request: %w", err)
-		}
-		defer func() {
-			if closeErr := httpResp.Body.Close(); closeErr != nil {
-				c.logger.Warn(ctx, "Failed to close response body", map[string]interface{}{
-					"error": closeErr.Error(),
-				})
-			}
-		}()
-
-		// Read response body
-		respBody, err := io.ReadAll(httpResp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		// Check for error response
-		if httpResp.StatusCode != http.StatusOK {
-			c.logger.Error(ctx, "Error from Anthropic Chat API", map[string]interface{}{
-				"status_code": httpResp.StatusCode,
-				"response":    string(respBody),
-				"model":       c.Model,
-			})
-			return fmt.Errorf("error from Anthropic API: %s", string(respBody))
-		}
-
-		// Log raw response before unmarshaling for debugging
-		c.logger.Debug(ctx, "Raw streaming response before unmarshaling", map[string]interface{}{
-			"response_length": len(respBody),
-			"response_prefix": func() string {
-				if len(respBody) > 100 {
-					return string(respBody[:100])
-				}
-				return string(respBody)
-			}(),
-			"first_char": func() string {
-				if len(respBody) > 0 {
-					return fmt.Sprintf("'%c' (0x%02x)", respBody[0], respBody[0])
-				}
-				return "empty"
-			}(),
-		})
-
-		// Unmarshal response
-		err = json.Unmarshal(respBody, &resp)
-		if err != nil {
-			c.logger.Error(ctx, "Failed to unmarshal streaming response", map[string]interface{}{
-				"error":           err.Error(),
-				"response_length": len(respBody),
-				"response_sample": func() string {
-					if len(respBody) > 200 {
-						return string(respBody[:200])
-					}
-					return string(respBody)
-				}(),
-			})
-			return fmt.Errorf("failed to unmarshal response: %w", err)
+	if params.LLMConfig != nil {
+		if len(params.LLMConfig.StopSequences) > 0 {
+			req.StopSequences = params.LLMConfig.StopSequences
 		}
-
-		return nil
 	}
 
-	if c.vertexRetryExecutor != nil {
-		c.logger.Info(ctx, "Using Vertex retry mechanism with region rotation for Chat", map[string]interface{}{
-			"model":          c.Model,
-			"current_region": c.VertexConfig.GetCurrentRegion(),
-		})
-		err = c.vertexRetryExecutor.Execute(ctx, operation)
-	} else if c.retryExecutor != nil {
-		c.logger.Info(ctx, "Using standard retry mechanism for Anthropic Chat request", map[string]interface{}{
-			"model":                   c.Model,
-			"vertex_config_available": c.VertexConfig != nil,
-		})
-		err = c.retryExecutor.Execute(ctx, operation)
-	} else {
-		c.logger.Debug(ctx, "No retry mechanism configured for Chat request", map[string]interface{}{
-			"model": c.Model,
-		})
-		err = operation()
-	}
-
-	if err != nil {
-		return "", err
-	}
+	c.applyPromptCache(&req, params)
+	return req, nil
+}
 
-	// Extract text from content blocks
-	var contentText []string
-	for _, block := range resp.Content {
-		if block.Type == "text" {
-			contentText = append(contentText, block.Text)
-		}
+// Chat uses the messages API to have a conversation with a model.
+// It sends the messages with ChatMessages; DefaultGenerateParams are used if
+// params is nil.
+func (c *AnthropicClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
+	if params == nil {
+		params = llm.DefaultGenerateParams()
 	}
+	return c.ChatMessages(ctx, llm.ConvertMessages(messages), params.Options()...)
+}
 
-	if len(contentText) == 0 {
-		return "", fmt.Errorf("no text content in response")
+// ChatMessages generates the next assistant message of a conversation. The
+// messages are sent the way messages from memory are, so assistant tool calls
+// and tool results are kept; leading system messages are used as the system
+// message.
+func (c *AnthropicClient) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to chat: %w", err)
 	}
-
-	response := strings.Join(contentText, "\n")
-
-	c.logger.Debug(ctx, "Successfully received chat response from Anthropic", map[string]interface{}{
-		"model":           c.Model,
-		"response_length": len(response),
-		"response_preview": func() string {
-
-			return response
-		}(),
-	})
-
-	return response, nil
+	return c.Generate(ctx, prompt, options...)
 }
 
 // GenerateWithTools implements interfaces.LLM.GenerateWithTools
@@ -949,11 +847,9 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 			MaxTokens:   2048,
 			Temperature: params.LLMConfig.Temperature,
 			TopP:        params.LLMConfig.TopP,
+			TopK:        params.LLMConfig.TopK,
 			Tools:       anthropicTools,
-			// Auto use tools when needed
-			ToolChoice: map[string]string{
-				"type": "auto",
-			},
+			ToolChoice:  toolChoiceParam(params.ToolChoice.ForTurn(iteration + 1)),
 		}
 
 		// Add system message if available
@@ -976,6 +872,8 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 			c.logger.Debug(ctx, "Reasoning mode not supported in current API version", map[string]interface{}{"reasoning": params.LLMConfig.Reasoning})
 		}
 
+		c.applyPromptCache(&req, params)
+
 		// Send request
 		c.logger.Debug(ctx, "Sending request with tools to Anthropic", map[string]interface{}{
 			"model":         c.Model,
@@ -1007,7 +905,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 					"model":     c.Model,
 					"iteration": iteration + 1,
 				})
-				return fmt.Errorf("failed to send request (iteration %d): %w", iteration+1, err)
+				return fmt.Errorf("failed to send request (iteration %d): %w", iteration+1, llm.ClassifyError(c.Name(), err))
 			}
 			defer func() {
 				if closeErr := httpResp.Body.Close(); closeErr != nil {
@@ -1031,7 +929,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 					"model":       c.Model,
 					"iteration":   iteration + 1,
 				})
-				return fmt.Errorf("error from Anthropic API (iteration %d): %s", iteration+1, string(respBody))
+				return fmt.Errorf("error from Anthropic API (iteration %d): %w", iteration+1, llm.NewHTTPError(c.Name(), httpResp.StatusCode, httpResp.Header, respBody))
 			}
 
 			// Log raw response before unmarshaling for debugging
@@ -1088,6 +986,8 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 			return "", err
 		}
 
+		c.recordUsage(ctx, resp.Model, resp.Usage, iteration+1)
+
 		// Make sure content is not nil
 		if resp.Content == nil {
 			c.logger.Error(ctx, "No content in response", map[string]interface{}{"iteration": iteration + 1})
@@ -1162,7 +1062,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 
 			// If we have a ResponseFormat, extract JSON from the response
 			if params.ResponseFormat != nil {
-				extractedJSON := extractJSONFromResponse(response)
+				extractedJSON := structuredoutput.ExtractJSON(response)
 				if extractedJSON != response {
 					c.logger.Debug(ctx, "Extracted JSON from response", map[string]interface{}{
 						"original_length":  len(response),
@@ -1200,29 +1100,67 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 			})
 		}
 
-		// Process each tool call
-		var toolResults []ToolResult
-		for _, toolCall := range toolCalls {
+		// Resolve the requested tools and their arguments
+		toolNames := make([]string, len(toolCalls))
+		executions := make([]*llm.ToolExecution, len(toolCalls))
+		for i, toolCall := range toolCalls {
+			executions[i] = &llm.ToolExecution{}
+
 			// Get tool name - it could be in either Name or RecipientName field
-			toolName := ""
 			if toolCall.Name != "" {
-				toolName = toolCall.Name
+				toolNames[i] = toolCall.Name
 			} else if toolCall.RecipientName != "" {
-				toolName = toolCall.RecipientName
+				toolNames[i] = toolCall.RecipientName
 			} else {
-				c.logger.Error(ctx, "Tool call missing both Name and RecipientName", map[string]interface{}{"iteration": iteration + 1})
 				continue
 			}
 
-			// Find the requested tool
-			var selectedTool interfaces.Tool
-			for _, tool := range tools {
-				if tool.Name() == toolName {
-					selectedTool = tool
-					break
-				}
+			executions[i].Tool = llm.FindTool(tools, toolNames[i])
+			if executions[i].Tool == nil {
+				continue
 			}
 
+			// Get parameters - could be in either Input or Parameters field
+			var parameters map[string]interface{}
+			if len(toolCall.Input) > 0 {
+				parameters = toolCall.Input
+			} else if len(toolCall.Parameters) > 0 {
+				parameters = toolCall.Parameters
+			}
+
+			// Convert parameters to JSON string
+			toolCallJSON, err := json.Marshal(parameters)
+			if err != nil {
+				c.logger.Error(ctx, "Error marshalling parameters", map[string]interface{}{
+					"error":      err.Error(),
+					"parameters": parameters,
+					"iteration":  iteration + 1,
+				})
+				return "", fmt.Errorf("error marshalling parameters (iteration %d): %w", iteration+1, err)
+			}
+			executions[i].Arguments = string(toolCallJSON)
+
+			// Log parameters for debugging
+			c.logger.Debug(ctx, "Tool parameters", map[string]interface{}{
+				"toolName":   toolNames[i],
+				"parameters": executions[i].Arguments,
+				"iteration":  iteration + 1,
+			})
+		}
+
+		// Execute the tools, concurrently if enabled
+		llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+		// Process each tool call in the order the model requested them
+		var toolResults []ToolResult
+		for i, toolCall := range toolCalls {
+			toolName := toolNames[i]
+			if toolName == "" {
+				c.logger.Error(ctx, "Tool call missing both Name and RecipientName", map[string]interface{}{"iteration": iteration + 1})
+				continue
+			}
+
+			selectedTool := executions[i].Tool
 			if selectedTool == nil {
 				c.logger.Error(ctx, "Tool not found", map[string]interface{}{
 					"toolName":  toolName,
@@ -1270,41 +1208,15 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 				continue // Continue processing other tool calls
 			}
 
-			// Get parameters - could be in either Input or Parameters field
-			var parameters map[string]interface{}
-			if len(toolCall.Input) > 0 {
-				parameters = toolCall.Input
-			} else if len(toolCall.Parameters) > 0 {
-				parameters = toolCall.Parameters
-			}
-
-			// Convert parameters to JSON string
-			toolCallJSON, err := json.Marshal(parameters)
-			if err != nil {
-				c.logger.Error(ctx, "Error marshalling parameters", map[string]interface{}{
-					"error":      err.Error(),
-					"parameters": parameters,
-					"iteration":  iteration + 1,
-				})
-				return "", fmt.Errorf("error marshalling parameters (iteration %d): %w", iteration+1, err)
-			}
-
-			// Log parameters for debugging
-			c.logger.Debug(ctx, "Tool parameters", map[string]interface{}{
-				"toolName":   toolName,
-				"parameters": string(toolCallJSON),
-				"iteration":  iteration + 1,
-			})
-
-			// Execute the tool
-			c.logger.Info(ctx, "Executing tool", map[string]interface{}{
+			toolCallJSON := executions[i].Arguments
+			c.logger.Info(ctx, "Executed tool", map[string]interface{}{
 				"toolName":  selectedTool.Name(),
 				"iteration": iteration + 1,
 			})
-			toolResult, err := selectedTool.Execute(ctx, string(toolCallJSON))
+			toolResult, err := executions[i].Result, executions[i].Err
 
 			// Check for repetitive calls and add warning if needed
-			cacheKey := toolName + ":" + string(toolCallJSON)
+			cacheKey := toolName + ":" + toolCallJSON
 			toolCallHistory[cacheKey]++
 
 			if toolCallHistory[cacheKey] > 2 {
@@ -1331,7 +1243,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 						ToolCalls: []interfaces.ToolCall{{
 							ID:        toolCall.ID,
 							Name:      toolName,
-							Arguments: string(toolCallJSON),
+							Arguments: toolCallJSON,
 						}},
 					})
 					_ = params.Memory.AddMessage(ctx, interfaces.Message{
@@ -1350,7 +1262,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 						ToolCalls: []interfaces.ToolCall{{
 							ID:        toolCall.ID,
 							Name:      toolName,
-							Arguments: string(toolCallJSON),
+							Arguments: toolCallJSON,
 						}},
 					})
 					_ = params.Memory.AddMessage(ctx, interfaces.Message{
@@ -1415,6 +1327,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 		MaxTokens:   2048,
 		Temperature: params.LLMConfig.Temperature,
 		TopP:        params.LLMConfig.TopP,
+		TopK:        params.LLMConfig.TopK,
 		Tools:       nil, // No tools for final call
 	}
 
@@ -1477,6 +1390,7 @@ CRITICAL INSTRUCTIONS:
 	}
 
 	finalReq.Messages = messages
+	c.applyPromptCache(&finalReq, params)
 
 	c.logger.Debug(ctx, "Making final request without tools", map[string]interface{}{
 		"messages": len(finalReq.Messages),
@@ -1492,7 +1406,7 @@ CRITICAL INSTRUCTIONS:
 	finalHTTPResp, err := c.HTTPClient.Do(finalHTTPReq)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to send final request: %w", err)
+		return "", fmt.Errorf("failed to send final request: %w", llm.ClassifyError(c.Name(), err))
 	}
 	defer func() {
 		if closeErr := finalHTTPResp.Body.Close(); closeErr != nil {
@@ -1514,7 +1428,7 @@ CRITICAL INSTRUCTIONS:
 			"status_code": finalHTTPResp.StatusCode,
 			"response":    string(finalRespBody),
 		})
-		return "", fmt.Errorf("error from Anthropic API in final call: %s", string(finalRespBody))
+		return "", fmt.Errorf("error from Anthropic API in final call: %w", llm.NewHTTPError(c.Name(), finalHTTPResp.StatusCode, finalHTTPResp.Header, finalRespBody))
 	}
 
 	// Log raw final response before unmarshaling for debugging
@@ -1551,6 +1465,8 @@ CRITICAL INSTRUCTIONS:
 		return "", fmt.Errorf("failed to unmarshal final response: %w", err)
 	}
 
+	c.recordUsage(ctx, finalResp.Model, finalResp.Usage, maxIterations+1)
+
 	// Extract text content from final response
 	if finalResp.Content == nil {
 		return "", fmt.Errorf("no content in final response")
@@ -1576,7 +1492,7 @@ CRITICAL INSTRUCTIONS:
 
 	// If we have a ResponseFormat, extract JSON from the response
 	if params.ResponseFormat != nil {
-		extractedJSON := extractJSONFromResponse(response)
+		extractedJSON := structuredoutput.ExtractJSON(response)
 		if extractedJSON != response {
 			c.logger.Debug(ctx, "Extracted JSON from final response", map[string]interface{}{
 				"original_length":  len(response),
@@ -1625,6 +1541,9 @@ func (c *AnthropicClient) createHTTPRequest(ctx context.Context, req *Completion
 		httpReq.Header.Set("Content-Type", "application/json")
 		httpReq.Header.Set("X-API-Key", c.APIKey)
 		httpReq.Header.Set("Anthropic-Version", "2023-06-01")
+		if usesExtendedCacheTTL(req) {
+			httpReq.Header.Set("Anthropic-Beta", extendedCacheTTLBeta)
+		}
 
 		return httpReq, nil
 	}
@@ -1661,6 +1580,9 @@ func (c *AnthropicClient) createStreamingHTTPRequest(ctx context.Context, req *C
 		httpReq.Header.Set("Content-Type", "application/json")
 		httpReq.Header.Set("X-API-Key", c.APIKey)
 		httpReq.Header.Set("Anthropic-Version", "2023-06-01")
+		if usesExtendedCacheTTL(req) {
+			httpReq.Header.Set("Anthropic-Beta", extendedCacheTTLBeta)
+		}
 		httpReq.Header.Set("Accept", "text/event-stream")
 		httpReq.Header.Set("Cache-Control", "no-cache")
 
@@ -1668,11 +1590,37 @@ func (c *AnthropicClient) createStreamingHTTPRequest(ctx context.Context, req *C
 	}
 }
 
+// recordUsage reports the token usage of a response to the usage collector in the context
+func (c *AnthropicClient) recordUsage(ctx context.Context, model string, usage Usage, iteration int) {
+	if model == "" {
+		model = c.Model
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{
+		Provider:  c.Name(),
+		Model:     model,
+		Iteration: iteration,
+		Usage:     usage.ToTokenUsage(),
+	})
+	if usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
+		c.logger.Debug(ctx, "Prompt cache usage", map[string]interface{}{
+			"model":                       model,
+			"iteration":                   iteration,
+			"cache_read_input_tokens":     usage.CacheReadInputTokens,
+			"cache_creation_input_tokens": usage.CacheCreationInputTokens,
+		})
+	}
+}
+
 // Name implements interfaces.LLM.Name
 func (c *AnthropicClient) Name() string {
 	return "anthropic"
 }
 
+// GetModel returns the model name being used
+func (c *AnthropicClient) GetModel() string {
+	return c.Model
+}
+
 // SupportsStreaming implements interfaces.LLM.SupportsStreaming
 func (c *AnthropicClient) SupportsStreaming() bool {
 	return true
@@ -1793,89 +1741,6 @@ func getExampleValue(prop map[string]interface{}) interface{} {
 	}
 }
 
-// extractJSONFromResponse extracts JSON content from a response that may contain markdown or explanatory text
-func extractJSONFromResponse(response string) string {
-	// First, try to find JSON within markdown code blocks
-	jsonStart := strings.Index(response, "```json")
-	if jsonStart >= 0 {
-		jsonStart += len("```json")
-		jsonEnd := strings.Index(response[jsonStart:], "```")
-		if jsonEnd > 0 {
-			return strings.TrimSpace(response[jsonStart : jsonStart+jsonEnd])
-		}
-	}
-
-	// Try generic code blocks
-	jsonStart = strings.Index(response, "```")
-	if jsonStart >= 0 {
-		jsonStart += len("```")
-		contentAfterMarker := response[jsonStart:]
-		newlineIdx := strings.Index(contentAfterMarker, "\n")
-		if newlineIdx >= 0 {
-			contentAfterMarker = contentAfterMarker[newlineIdx+1:]
-		}
-		jsonEnd := strings.Index(contentAfterMarker, "```")
-		if jsonEnd > 0 {
-			extracted := strings.TrimSpace(contentAfterMarker[:jsonEnd])
-			if isValidJSONStart(extracted) {
-				return extracted
-			}
-		}
-	}
-
-	// Try to find JSON object by looking for { and matching }
-	jsonStart = strings.Index(response, "{")
-	if jsonStart >= 0 {
-		// Find the matching closing brace
-		braceCount := 0
-		inString := false
-		escapeNext := false
-
-		for i := jsonStart; i < len(response); i++ {
-			char := response[i]
-
-			if escapeNext {
-				escapeNext = false
-				continue
-			}
-
-			if char == '\\' {
-				escapeNext = true
-				continue
-			}
-
-			if char == '"' {
-				inString = !inString
-				continue
-			}
-
-			if !inString {
-				if char == '{' {
-					braceCount++
-				} else if char == '}' {
-					braceCount--
-					if braceCount == 0 {
-						extracted := strings.TrimSpace(response[jsonStart : i+1])
-						if isValidJSONStart(extracted) {
-							return extracted
-						}
-						break
-					}
-				}
-			}
-		}
-	}
-
-	// If no JSON found, return original response
-	return response
-}
-
-// isValidJSONStart checks if a string starts with valid JSON
-func isValidJSONStart(s string) bool {
-	s = strings.TrimSpace(s)
-	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
-}
-
 // buildMessagesWithMemory builds Anthropic messages from memory and current prompt
 func (c *AnthropicClient) buildMessagesWithMemory(ctx context.Context, prompt string, params *interfaces.GenerateOptions) []Message {
 	builder := newMessageHistoryBuilder(c.logger)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 )
 
@@ -47,6 +48,7 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 		messages = append(messages, Message{
 			Role:    "user",
 			Content: prompt,
+			Blocks:  b.convertContentParts(ctx, llm.PromptParts(prompt, params)),
 		})
 	}
 
@@ -60,6 +62,7 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *Me
 		return &Message{
 			Role:    "user",
 			Content: msg.Content,
+			Blocks:  b.convertContentParts(context.Background(), msg.Parts),
 		}
 
 	case interfaces.MessageRoleAssistant:
@@ -137,3 +140,36 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *Me
 
 	return nil
 }
+
+// convertContentParts converts multimodal content parts to Anthropic content
+// blocks. Images and PDFs are sent inline or by URL; audio is not supported
+// by Anthropic and is skipped.
+func (b *messageHistoryBuilder) convertContentParts(ctx context.Context, parts []interfaces.ContentPart) []InputBlock {
+	var blocks []InputBlock
+	for _, part := range parts {
+		switch part.Type {
+		case interfaces.ContentPartText:
+			if part.Text != "" {
+				blocks = append(blocks, InputBlock{Type: "text", Text: part.Text})
+			}
+		case interfaces.ContentPartImage:
+			blocks = append(blocks, InputBlock{Type: "image", Source: blockSource(part)})
+		case interfaces.ContentPartFile:
+			blocks = append(blocks, InputBlock{Type: "document", Source: blockSource(part)})
+		default:
+			b.logger.Warn(ctx, "Skipping content part not supported by Anthropic", map[string]interface{}{
+				"type":      part.Type,
+				"mime_type": part.MediaType(),
+			})
+		}
+	}
+	return blocks
+}
+
+// blockSource returns the source of an image or document block
+func blockSource(part interfaces.ContentPart) *BlockSource {
+	if len(part.Data) == 0 {
+		return &BlockSource{Type: "url", URL: part.URL}
+	}
+	return &BlockSource{Type: "base64", MediaType: part.MediaType(), Data: part.Base64()}
+}
@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
@@ -90,6 +91,52 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	}
 }
 
+func TestMessageHistoryBuilder_ContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New())
+	params := &interfaces.GenerateOptions{
+		ContentParts: []interfaces.ContentPart{
+			interfaces.ImagePart([]byte("png"), "image/png"),
+			interfaces.FileURLPart("https://example.com/invoice.pdf", "application/pdf"),
+			interfaces.AudioPart([]byte("wav"), "audio/wav"),
+		},
+	}
+
+	messages := builder.buildMessages(context.Background(), "Summarize", params)
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+
+	data, err := json.Marshal(messages[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	// Audio is not supported and is skipped
+	expected := `{"role":"user","content":[{"type":"text","text":"Summarize"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"cG5n"}},` +
+		`{"type":"document","source":{"type":"url","url":"https://example.com/invoice.pdf"}}]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected message JSON:\n got %s\nwant %s", data, expected)
+	}
+
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	if len(decoded.Blocks) != 3 || decoded.Blocks[1].Source.MediaType != "image/png" {
+		t.Errorf("Unexpected decoded blocks: %+v", decoded.Blocks)
+	}
+}
+
+func TestMessageMarshalJSONWithoutBlocks(t *testing.T) {
+	data, err := json.Marshal(Message{Role: "user", Content: "Hello"})
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	if string(data) != `{"role":"user","content":"Hello"}` {
+		t.Errorf("Unexpected message JSON: %s", data)
+	}
+}
+
 // mockMemory is a simple mock implementation for testing
 type mockMemory struct {
 	messages []interfaces.Message
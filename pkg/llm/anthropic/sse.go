@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 )
 
 // AnthropicSSEEvent represents the structure of Anthropic's SSE events
@@ -266,7 +267,7 @@ func (c *AnthropicClient) convertAnthropicEventToStreamEvent(event *AnthropicSSE
 		}
 
 		streamEvent.Type = interfaces.StreamEventError
-		streamEvent.Error = fmt.Errorf("anthropic api error: %v", errorData)
+		streamEvent.Error = fmt.Errorf("anthropic api error: %w", llm.NewHTTPError(c.Name(), 0, nil, event.Data))
 		streamEvent.Metadata["error_data"] = errorData
 
 	case "input_json_delta":
@@ -318,6 +319,9 @@ func (c *AnthropicClient) parseSSEStreamAndCapture(ctx context.Context, scanner
 		InputJSON strings.Builder
 	})
 
+	// Usage is split across message_start (input) and message_delta (output)
+	var usage Usage
+
 	lineCount := 0
 
 	for scanner.Scan() {
@@ -327,6 +331,8 @@ func (c *AnthropicClient) parseSSEStreamAndCapture(ctx context.Context, scanner
 		// Empty line indicates end of current event
 		if line == "" {
 			if currentEvent != nil && len(currentEvent.Data) > 0 {
+				mergeStreamUsage(&usage, currentEvent)
+
 				// Process complete event and capture content
 				if err := c.processCompleteSSEEventAndCapture(ctx, currentEvent, eventChan, thinkingBlocks, toolBlocks, &accumulatedContent); err != nil {
 					c.logger.Error(ctx, "Failed to process SSE event", map[string]interface{}{
@@ -390,6 +396,7 @@ func (c *AnthropicClient) parseSSEStreamAndCapture(ctx context.Context, scanner
 
 	// Process any remaining event
 	if currentEvent != nil && len(currentEvent.Data) > 0 {
+		mergeStreamUsage(&usage, currentEvent)
 		_ = c.processCompleteSSEEventAndCapture(ctx, currentEvent, eventChan, thinkingBlocks, toolBlocks, &accumulatedContent)
 	}
 
@@ -418,6 +425,8 @@ func (c *AnthropicClient) parseSSEStreamAndCapture(ctx context.Context, scanner
 		}
 	}
 
+	c.recordUsage(ctx, req.Model, usage, 0)
+
 	// Store messages in memory if provided
 	if params != nil && params.Memory != nil {
 		// Store user message
@@ -438,6 +447,46 @@ func (c *AnthropicClient) parseSSEStreamAndCapture(ctx context.Context, scanner
 	return accumulatedContent.String()
 }
 
+// mergeStreamUsage folds the usage reported by message_start and message_delta
+// events into usage. message_delta carries cumulative counts, so non-zero
+// values replace the ones seen so far.
+func mergeStreamUsage(usage *Usage, event *AnthropicSSEEvent) {
+	var data struct {
+		Usage   *Usage `json:"usage"`
+		Message struct {
+			Usage *Usage `json:"usage"`
+		} `json:"message"`
+	}
+	switch event.Type {
+	case "message_start", "message_delta":
+	default:
+		return
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return
+	}
+
+	update := data.Usage
+	if update == nil {
+		update = data.Message.Usage
+	}
+	if update == nil {
+		return
+	}
+	if update.InputTokens > 0 {
+		usage.InputTokens = update.InputTokens
+	}
+	if update.OutputTokens > 0 {
+		usage.OutputTokens = update.OutputTokens
+	}
+	if update.CacheCreationInputTokens > 0 {
+		usage.CacheCreationInputTokens = update.CacheCreationInputTokens
+	}
+	if update.CacheReadInputTokens > 0 {
+		usage.CacheReadInputTokens = update.CacheReadInputTokens
+	}
+}
+
 func (c *AnthropicClient) processCompleteSSEEventAndCapture(ctx context.Context, event *AnthropicSSEEvent, eventChan chan<- interfaces.StreamEvent, thinkingBlocks map[int]bool, toolBlocks map[int]struct {
 	ID        string
 	Name      string
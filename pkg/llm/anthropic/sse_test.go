@@ -235,6 +235,32 @@ func TestMessageStartData(t *testing.T) {
 	}
 }
 
+func TestMergeStreamUsage(t *testing.T) {
+	var usage Usage
+	mergeStreamUsage(&usage, &AnthropicSSEEvent{
+		Type: "message_start",
+		Data: json.RawMessage(`{"type": "message_start", "message": {"id": "msg_123", "usage": {"input_tokens": 25, "output_tokens": 1, "cache_read_input_tokens": 100}}}`),
+	})
+	mergeStreamUsage(&usage, &AnthropicSSEEvent{
+		Type: "content_block_delta",
+		Data: json.RawMessage(`{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hi"}}`),
+	})
+	mergeStreamUsage(&usage, &AnthropicSSEEvent{
+		Type: "message_delta",
+		Data: json.RawMessage(`{"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 42}}`),
+	})
+
+	expected := Usage{InputTokens: 25, OutputTokens: 42, CacheReadInputTokens: 100}
+	if usage != expected {
+		t.Fatalf("Expected usage %+v, got %+v", expected, usage)
+	}
+
+	tokens := usage.ToTokenUsage()
+	if tokens.InputTokens != 125 || tokens.CachedInputTokens != 100 || tokens.TotalTokens != 167 {
+		t.Errorf("Unexpected token usage: %+v", tokens)
+	}
+}
+
 func TestContentBlockDeltaData(t *testing.T) {
 	var blockDelta ContentBlockDeltaData
 	err := json.Unmarshal([]byte(`{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hello world"}}`), &blockDelta)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 )
 
@@ -68,6 +69,7 @@ func (c *AnthropicClient) GenerateStream(
 		MaxTokens:   maxTokens,
 		Temperature: params.LLMConfig.Temperature,
 		TopP:        params.LLMConfig.TopP,
+		TopK:        params.LLMConfig.TopK,
 		Stream:      true, // Enable streaming
 	}
 
@@ -107,6 +109,8 @@ func (c *AnthropicClient) GenerateStream(
 		}
 	}
 
+	c.applyPromptCache(&req, params)
+
 	// Get buffer size from stream config
 	bufferSize := 100 // default
 	if params.StreamConfig != nil {
@@ -197,7 +201,7 @@ func (c *AnthropicClient) executeStreamingRequestWithMemory(
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to send request: %w", err)
+			return fmt.Errorf("failed to send request: %w", llm.ClassifyError(c.Name(), err))
 		}
 		defer func() {
 			if closeErr := httpResp.Body.Close(); closeErr != nil {
@@ -223,10 +227,7 @@ func (c *AnthropicClient) executeStreamingRequestWithMemory(
 				"content_type": httpResp.Header.Get("Content-Type"),
 			})
 
-			if len(errorBody) > 0 {
-				return fmt.Errorf("error from Anthropic API: HTTP %d - %s", httpResp.StatusCode, string(errorBody))
-			}
-			return fmt.Errorf("error from Anthropic API: HTTP %d", httpResp.StatusCode)
+			return fmt.Errorf("error from Anthropic API: %w", llm.NewHTTPError(c.Name(), httpResp.StatusCode, httpResp.Header, errorBody))
 		}
 
 		// Verify content type
@@ -456,12 +457,10 @@ func (c *AnthropicClient) executeStreamingWithTools(
 			MaxTokens:   maxTokens,
 			Temperature: params.LLMConfig.Temperature,
 			TopP:        params.LLMConfig.TopP,
+			TopK:        params.LLMConfig.TopK,
 			Tools:       anthropicTools,
-			// Auto use tools when needed
-			ToolChoice: map[string]string{
-				"type": "auto",
-			},
-			Stream: true, // Enable streaming
+			ToolChoice:  toolChoiceParam(params.ToolChoice.ForTurn(iteration + 1)),
+			Stream:      true, // Enable streaming
 		}
 
 		// Add system message if available
@@ -491,6 +490,8 @@ func (c *AnthropicClient) executeStreamingWithTools(
 			}
 		}
 
+		c.applyPromptCache(&req, params)
+
 		// Execute streaming request and collect tool calls
 		c.logger.Debug(ctx, "[LLM RESPONSE DEBUG] Calling LLM for iteration", map[string]interface{}{
 			"iteration":     iteration + 1,
@@ -598,16 +599,19 @@ func (c *AnthropicClient) executeStreamingWithTools(
 			return ctx.Err()
 		}
 
-		// Execute each tool and add results
-		for _, toolCall := range toolCalls {
-			// Find the requested tool
-			var selectedTool interfaces.Tool
-			for _, tool := range originalTools {
-				if tool.Name() == toolCall.Name {
-					selectedTool = tool
-					break
-				}
+		// Execute the requested tools, concurrently if enabled
+		executions := make([]*llm.ToolExecution, len(toolCalls))
+		for i, toolCall := range toolCalls {
+			executions[i] = &llm.ToolExecution{
+				Tool:      llm.FindTool(originalTools, toolCall.Name),
+				Arguments: toolCall.Arguments,
 			}
+		}
+		llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+		// Process each tool call in the order the model requested them
+		for i, toolCall := range toolCalls {
+			selectedTool := executions[i].Tool
 
 			if selectedTool == nil {
 				c.logger.Error(ctx, "Tool not found in streaming", map[string]interface{}{
@@ -642,14 +646,13 @@ func (c *AnthropicClient) executeStreamingWithTools(
 				continue // Continue processing other tool calls
 			}
 
-			// Execute tool
-			c.logger.Info(ctx, "[TOOL EXECUTION DEBUG] Executing tool in streaming", map[string]interface{}{
+			c.logger.Info(ctx, "[TOOL EXECUTION DEBUG] Executed tool in streaming", map[string]interface{}{
 				"toolName":  toolCall.Name,
 				"arguments": toolCall.Arguments,
 				"iteration": iteration + 1,
 			})
 
-			toolResult, err := selectedTool.Execute(ctx, toolCall.Arguments)
+			toolResult, err := executions[i].Result, executions[i].Err
 			if err != nil {
 				toolResult = fmt.Sprintf("Error: %v", err)
 			}
@@ -770,6 +773,7 @@ func (c *AnthropicClient) executeStreamingWithTools(
 		MaxTokens:   maxTokens,
 		Temperature: params.LLMConfig.Temperature,
 		TopP:        params.LLMConfig.TopP,
+		TopK:        params.LLMConfig.TopK,
 		// No tools in final request - we want a final answer
 		Stream: true, // Enable streaming
 	}
@@ -799,6 +803,8 @@ func (c *AnthropicClient) executeStreamingWithTools(
 		}
 	}
 
+	c.applyPromptCache(&finalReq, params)
+
 	// Execute final request to get synthesized answer with memory support
 	c.logger.Debug(ctx, "[LLM RESPONSE DEBUG] Executing final synthesis LLM call", map[string]interface{}{
 		"finalCallNumber": finalIterationCount + 1,
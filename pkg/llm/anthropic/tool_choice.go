@@ -0,0 +1,21 @@
+package anthropic
+
+import "github.com/andmang/agent-sdk-go/pkg/interfaces"
+
+// toolChoiceParam converts a tool choice to the tool_choice parameter. A nil
+// choice is auto.
+func toolChoiceParam(choice *interfaces.ToolChoice) map[string]string {
+	if choice == nil {
+		return map[string]string{"type": "auto"}
+	}
+	switch choice.Mode {
+	case interfaces.ToolChoiceModeNone:
+		return map[string]string{"type": "none"}
+	case interfaces.ToolChoiceModeRequired:
+		return map[string]string{"type": "any"}
+	case interfaces.ToolChoiceModeTool:
+		return map[string]string{"type": "tool", "name": choice.Name}
+	default:
+		return map[string]string{"type": "auto"}
+	}
+}
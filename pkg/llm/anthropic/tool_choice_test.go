@@ -0,0 +1,54 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestToolChoiceParam(t *testing.T) {
+	function := interfaces.ToolChoiceFunction("extract_invoice")
+	assert.Equal(t, map[string]string{"type": "auto"}, toolChoiceParam(nil))
+	assert.Equal(t, map[string]string{"type": "none"}, toolChoiceParam(&interfaces.ToolChoiceNone))
+	assert.Equal(t, map[string]string{"type": "any"}, toolChoiceParam(&interfaces.ToolChoiceRequired))
+	assert.Equal(t, map[string]string{"type": "tool", "name": "extract_invoice"}, toolChoiceParam(&function))
+}
+
+func TestGenerateWithToolsToolChoice(t *testing.T) {
+	var choices []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ToolChoice map[string]string `json:"tool_choice"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		choices = append(choices, req.ToolChoice)
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(choices) == 1 {
+			_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[` +
+				`{"type":"tool_use","id":"toolu_1","name":"calculator","input":{"expression":"1+1"}}],` +
+				`"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"msg_2","type":"message","role":"assistant","content":[{"type":"text","text":"done"}],` +
+			`"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithModel("claude-3-5-haiku-latest"))
+	response, err := client.GenerateWithTools(context.Background(), "Find x", []interfaces.Tool{&cacheTestTool{}},
+		interfaces.WithToolChoice(interfaces.ToolChoiceFunction("calculator")))
+	require.NoError(t, err)
+	assert.Equal(t, "done", response)
+
+	require.Len(t, choices, 2)
+	assert.Equal(t, map[string]string{"type": "tool", "name": "calculator"}, choices[0])
+	assert.Equal(t, map[string]string{"type": "auto"}, choices[1], "the forced choice only applies to the first turn")
+}
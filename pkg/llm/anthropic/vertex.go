@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/logging"
+	"github.com/andmang/agent-sdk-go/pkg/retry"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
@@ -434,6 +435,15 @@ func (e *VertexRetryExecutor) Execute(ctx context.Context, operation func() erro
 			lastErr = err
 			attempt++
 
+			if !retry.IsRetryable(err) {
+				e.logger.Debug(ctx, "Error is not retryable", map[string]interface{}{
+					"attempt": attempt,
+					"error":   err.Error(),
+					"region":  currentRegion,
+				})
+				break
+			}
+
 			if attempt >= e.policy.MaximumAttempts {
 				e.logger.Debug(ctx, "Maximum attempts reached", map[string]interface{}{
 					"attempt": attempt,
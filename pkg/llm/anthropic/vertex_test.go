@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 )
 
 func TestVertexConfig_ParseRegions(t *testing.T) {
@@ -262,7 +264,7 @@ func TestVertexRetryExecutor_Execute(t *testing.T) {
 			attempts++
 			regionsUsed = append(regionsUsed, vc.GetCurrentRegion())
 			if attempts < 3 {
-				return context.DeadlineExceeded
+				return &interfaces.ProviderError{Kind: interfaces.ErrTransient, StatusCode: 503}
 			}
 			return nil
 		}
@@ -305,7 +307,7 @@ func TestVertexRetryExecutor_Execute(t *testing.T) {
 		attempts := 0
 		operation := func() error {
 			attempts++
-			return context.DeadlineExceeded
+			return &interfaces.ProviderError{Kind: interfaces.ErrTransient, StatusCode: 503}
 		}
 
 		ctx := context.Background()
@@ -342,7 +344,7 @@ func TestVertexRetryExecutor_Execute(t *testing.T) {
 			attempts++
 			regionsUsed = append(regionsUsed, vc.GetCurrentRegion())
 			if attempts < 2 {
-				return context.DeadlineExceeded
+				return &interfaces.ProviderError{Kind: interfaces.ErrTransient, StatusCode: 503}
 			}
 			return nil
 		}
@@ -0,0 +1,224 @@
+package azureopenai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+	"github.com/openai/openai-go/v2"
+)
+
+// batchInputLine is one request of a batch input file
+type batchInputLine struct {
+	CustomID string                         `json:"custom_id"`
+	Method   string                         `json:"method"`
+	URL      string                         `json:"url"`
+	Body     openai.ChatCompletionNewParams `json:"body"`
+}
+
+// batchOutputLine is one result of a batch output or error file
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// batchEndpoint is the endpoint of Azure OpenAI batch requests, which are
+// sent to the deployment named as their model
+const batchEndpoint = "/chat/completions"
+
+// SubmitBatch uploads the requests as a batch input file and creates a batch
+// for the chat completions endpoint. Each request is built the way Generate
+// builds it, including its response format. The deployment must be a batch
+// deployment, such as a Global Batch deployment. Results are available
+// within 24 hours.
+func (c *AzureOpenAIClient) SubmitBatch(ctx context.Context, requests []interfaces.BatchRequest) (*interfaces.Batch, error) {
+	if err := llm.ValidateBatchRequests(requests); err != nil {
+		return nil, err
+	}
+
+	if orgID, _ := multitenancy.GetOrgID(ctx); orgID != "" {
+		ctx = context.WithValue(ctx, organizationKey, orgID)
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, request := range requests {
+		params := &interfaces.GenerateOptions{
+			LLMConfig: &interfaces.LLMConfig{
+				Temperature: 0.7,
+			},
+		}
+		for _, option := range request.Options {
+			option(params)
+		}
+
+		line := batchInputLine{
+			CustomID: request.CustomID,
+			Method:   "POST",
+			URL:      batchEndpoint,
+			Body:     c.newChatCompletionParams(ctx, request.Prompt, params),
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to encode request %s: %w", request.CustomID, err)
+		}
+	}
+
+	file, err := c.batchClient.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(&input, "batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload batch input: %w", c.classifyError(err))
+	}
+
+	batch, err := c.batchClient.Batches.New(ctx, openai.BatchNewParams{
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+		Endpoint:         openai.BatchNewParamsEndpoint(batchEndpoint),
+		InputFileID:      file.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", c.classifyError(err))
+	}
+
+	c.logger.Info(ctx, "Submitted Azure OpenAI batch", map[string]interface{}{
+		"batch_id":   batch.ID,
+		"requests":   len(requests),
+		"deployment": c.deployment,
+	})
+	return convertBatch(batch), nil
+}
+
+// GetBatch returns the current state of a batch
+func (c *AzureOpenAIClient) GetBatch(ctx context.Context, batchID string) (*interfaces.Batch, error) {
+	batch, err := c.batchClient.Batches.Get(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", c.classifyError(err))
+	}
+	return convertBatch(batch), nil
+}
+
+// CancelBatch cancels a batch. Requests that already finished keep their results.
+func (c *AzureOpenAIClient) CancelBatch(ctx context.Context, batchID string) (*interfaces.Batch, error) {
+	batch, err := c.batchClient.Batches.Cancel(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel batch: %w", c.classifyError(err))
+	}
+	return convertBatch(batch), nil
+}
+
+// GetBatchResults downloads the output and error files of a finished batch.
+// The token usage of each successful request is recorded in the usage
+// collector of the context.
+func (c *AzureOpenAIClient) GetBatchResults(ctx context.Context, batchID string) (map[string]interfaces.BatchResult, error) {
+	batch, err := c.batchClient.Batches.Get(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", c.classifyError(err))
+	}
+	if !convertBatch(batch).Status.Done() {
+		return nil, fmt.Errorf("batch %s is %s: %w", batchID, batch.Status, interfaces.ErrBatchInProgress)
+	}
+
+	results := make(map[string]interfaces.BatchResult)
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := c.readBatchResults(ctx, fileID, results); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// readBatchResults adds the results of a batch output or error file
+func (c *AzureOpenAIClient) readBatchResults(ctx context.Context, fileID string, results map[string]interfaces.BatchResult) error {
+	resp, err := c.batchClient.Files.Content(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to download batch results: %w", c.classifyError(err))
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var line batchOutputLine
+		if err := decoder.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode batch results: %w", err)
+		}
+		results[line.CustomID] = c.batchResult(ctx, line)
+	}
+}
+
+// batchResult converts one line of a batch output or error file
+func (c *AzureOpenAIClient) batchResult(ctx context.Context, line batchOutputLine) interfaces.BatchResult {
+	result := interfaces.BatchResult{CustomID: line.CustomID}
+	switch {
+	case line.Error != nil:
+		result.Err = llm.NewProviderError(c.Name(), 0, line.Error.Code, line.Error.Message, nil, nil)
+	case line.Response == nil:
+		result.Err = errors.New("batch result has no response")
+	case line.Response.StatusCode != 200:
+		result.Err = llm.NewHTTPError(c.Name(), line.Response.StatusCode, nil, line.Response.Body)
+	default:
+		var completion openai.ChatCompletion
+		if err := json.Unmarshal(line.Response.Body, &completion); err != nil {
+			result.Err = fmt.Errorf("failed to unmarshal response: %w", err)
+			break
+		}
+		c.recordUsage(ctx, completion.Model, completion.Usage, 0)
+		if len(completion.Choices) == 0 {
+			result.Err = errors.New("no response from Azure OpenAI API")
+			break
+		}
+		result.Content = completion.Choices[0].Message.Content
+	}
+	return result
+}
+
+// convertBatch converts an Azure OpenAI batch to the provider-neutral description
+func convertBatch(batch *openai.Batch) *interfaces.Batch {
+	converted := &interfaces.Batch{
+		ID:             batch.ID,
+		ProviderStatus: string(batch.Status),
+		Total:          int(batch.RequestCounts.Total),
+		Succeeded:      int(batch.RequestCounts.Completed),
+		Failed:         int(batch.RequestCounts.Failed),
+		CreatedAt:      time.Unix(batch.CreatedAt, 0),
+	}
+	if batch.ExpiresAt > 0 {
+		converted.ExpiresAt = time.Unix(batch.ExpiresAt, 0)
+	}
+
+	switch batch.Status {
+	case openai.BatchStatusCompleted:
+		converted.Status = interfaces.BatchStatusCompleted
+	case openai.BatchStatusFailed:
+		converted.Status = interfaces.BatchStatusFailed
+	case openai.BatchStatusCancelled:
+		converted.Status = interfaces.BatchStatusCanceled
+	case openai.BatchStatusExpired:
+		converted.Status = interfaces.BatchStatusExpired
+	default:
+		// validating, in_progress, finalizing and cancelling
+		converted.Status = interfaces.BatchStatusInProgress
+	}
+	return converted
+}
+
+var _ interfaces.BatchLLM = (*AzureOpenAIClient)(nil)
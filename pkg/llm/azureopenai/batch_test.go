@@ -0,0 +1,61 @@
+package azureopenai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestSubmitBatch(t *testing.T) {
+	var paths []string
+	var line batchInputLine
+	var endpoint string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/openai/files":
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("Failed to read upload: %v", err)
+			}
+			scanner := bufio.NewScanner(file)
+			scanner.Scan()
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Failed to decode input line: %v", err)
+			}
+			_, _ = io.WriteString(w, `{"id":"file-in","object":"file","purpose":"batch"}`)
+		case "/openai/batches":
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			endpoint, _ = body["endpoint"].(string)
+			_, _ = io.WriteString(w, `{"id":"batch_1","object":"batch","status":"validating","created_at":1700000000,"request_counts":{"total":1}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", server.URL, "gpt-4o-batch", WithAPIVersion("2024-10-21"))
+	batch, err := client.SubmitBatch(context.Background(), []interfaces.BatchRequest{{CustomID: "doc-1", Prompt: "Classify"}})
+	if err != nil {
+		t.Fatalf("SubmitBatch failed: %v", err)
+	}
+	if batch.ID != "batch_1" || batch.Status != interfaces.BatchStatusInProgress || batch.Total != 1 {
+		t.Errorf("Unexpected batch: %+v", batch)
+	}
+
+	expectedPaths := []string{"POST /openai/files?api-version=2024-10-21", "POST /openai/batches?api-version=2024-10-21"}
+	if len(paths) != 2 || paths[0] != expectedPaths[0] || paths[1] != expectedPaths[1] {
+		t.Errorf("Expected requests %v, got %v", expectedPaths, paths)
+	}
+	if line.CustomID != "doc-1" || line.URL != "/chat/completions" || endpoint != "/chat/completions" {
+		t.Errorf("Unexpected input line %+v for endpoint %s", line, endpoint)
+	}
+}
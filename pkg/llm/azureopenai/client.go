@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -37,8 +38,12 @@ type AzureOpenAIClient struct {
 	deployment      string
 	region          string
 	resourceName    string
+	httpClient      *http.Client
 	logger          logging.Logger
 	retryExecutor   *retry.Executor
+	// batchClient calls the resource-level files and batches APIs, which
+	// are not under the deployment URL
+	batchClient openai.Client
 }
 
 // Option represents an option for configuring the Azure OpenAI client
@@ -79,21 +84,11 @@ func WithResourceName(resourceName string) Option {
 	}
 }
 
-// isReasoningModel returns true if the model is a reasoning model that requires temperature = 1
+// isReasoningModel returns true if the model is a reasoning model that requires
+// temperature = 1, according to llm.LookupModel
 func isReasoningModel(model string) bool {
-	reasoningModels := []string{
-		"o1-", "o1-mini", "o1-preview",
-		"o3-", "o3-mini",
-		"o4-", "o4-mini",
-		"gpt-5", "gpt-5-mini", "gpt-5-nano",
-	}
-
-	for _, prefix := range reasoningModels {
-		if strings.HasPrefix(model, prefix) {
-			return true
-		}
-	}
-	return false
+	info, ok := llm.LookupModel(model)
+	return ok && info.FixedTemperature
 }
 
 // getTemperatureForModel returns the appropriate temperature for a model
@@ -135,6 +130,13 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithHTTPClient sets the HTTP client used for API requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *AzureOpenAIClient) {
+		c.httpClient = httpClient
+	}
+}
+
 // recreateClients recreates the OpenAI clients with current configuration
 func (c *AzureOpenAIClient) recreateClients() {
 	// Build the Azure OpenAI endpoint URL
@@ -161,10 +163,18 @@ func (c *AzureOpenAIClient) recreateClients() {
 	if c.apiVersion != "" {
 		options = append(options, option.WithQuery("api-version", c.apiVersion))
 	}
+	if c.httpClient != nil {
+		options = append(options, option.WithHTTPClient(c.httpClient))
+	}
 
 	c.Client = openai.NewClient(options...)
 	c.ChatService = openai.NewChatService(options...)
 	c.ResponseService = openai.NewClient(options...)
+
+	// Files and batches are resource-level APIs
+	batchOptions := append([]option.RequestOption(nil), options...)
+	batchOptions[1] = option.WithBaseURL(strings.TrimSuffix(c.baseURL, "/") + "/openai")
+	c.batchClient = openai.NewClient(batchOptions...)
 }
 
 // NewClient creates a new Azure OpenAI client
@@ -237,64 +247,7 @@ func (c *AzureOpenAIClient) Generate(ctx context.Context, prompt string, options
 		ctx = context.WithValue(ctx, organizationKey, orgID)
 	}
 
-	// Build messages with memory and current prompt
-	messages := []openai.ChatCompletionMessageParamUnion{}
-
-	// Add system message if available
-	if params.SystemMessage != "" {
-		messages = append(messages, openai.SystemMessage(params.SystemMessage))
-		c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": params.SystemMessage})
-	}
-
-	// Add memory messages and current prompt
-	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
-
-	// Create request - use deployment name as model for Azure OpenAI
-	req := openai.ChatCompletionNewParams{
-		Model:    openai.ChatModel(c.deployment),
-		Messages: messages,
-	}
-
-	if params.LLMConfig != nil {
-		req.Temperature = openai.Float(c.getTemperatureForModel(params.LLMConfig.Temperature))
-		// Reasoning models don't support top_p parameter
-		if !isReasoningModel(c.Model) {
-			req.TopP = openai.Float(params.LLMConfig.TopP)
-		}
-		req.FrequencyPenalty = openai.Float(params.LLMConfig.FrequencyPenalty)
-		req.PresencePenalty = openai.Float(params.LLMConfig.PresencePenalty)
-		if len(params.LLMConfig.StopSequences) > 0 {
-			req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.LLMConfig.StopSequences}
-		}
-		// Set reasoning effort for reasoning models
-		if isReasoningModel(c.Model) && params.LLMConfig.Reasoning != "" {
-			req.ReasoningEffort = shared.ReasoningEffort(params.LLMConfig.Reasoning)
-			c.logger.Debug(ctx, "Setting reasoning effort", map[string]interface{}{"reasoning_effort": params.LLMConfig.Reasoning})
-		}
-	}
-
-	// Set response format if provided
-	if params.ResponseFormat != nil {
-		// Convert to the new API's response format structure
-		jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
-			Name:   params.ResponseFormat.Name,
-			Schema: params.ResponseFormat.Schema,
-		}
-
-		req.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
-				Type:       "json_schema",
-				JSONSchema: jsonSchema,
-			},
-		}
-		c.logger.Debug(ctx, "Using response format", map[string]interface{}{"format": *params.ResponseFormat})
-	}
-
-	// Set organization ID if available
-	if orgID, ok := ctx.Value(organizationKey).(string); ok && orgID != "" {
-		req.User = openai.String(orgID)
-	}
+	req := c.newChatCompletionParams(ctx, prompt, params)
 
 	var resp *openai.ChatCompletion
 	var err error
@@ -327,7 +280,7 @@ func (c *AzureOpenAIClient) Generate(ctx context.Context, prompt string, options
 				"model":      c.Model,
 				"deployment": c.deployment,
 			})
-			return fmt.Errorf("failed to generate text: %w", err)
+			return fmt.Errorf("failed to generate text: %w", c.classifyError(err))
 		}
 		return nil
 	}
@@ -346,6 +299,8 @@ func (c *AzureOpenAIClient) Generate(ctx context.Context, prompt string, options
 		return "", err
 	}
 
+	c.recordUsage(ctx, resp.Model, resp.Usage, 0)
+
 	// Return response
 	if len(resp.Choices) > 0 {
 		c.logger.Debug(ctx, "Successfully received response from Azure OpenAI", map[string]interface{}{
@@ -358,108 +313,90 @@ func (c *AzureOpenAIClient) Generate(ctx context.Context, prompt string, options
 	return "", fmt.Errorf("no response from Azure OpenAI API")
 }
 
-// Chat uses the ChatCompletion API to have a conversation (messages) with a model
-func (c *AzureOpenAIClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
-	if params == nil {
-		params = llm.DefaultGenerateParams()
-	}
-
-	// Convert messages to the OpenAI Chat format
-	chatMessages := make([]openai.ChatCompletionMessageParamUnion, len(messages))
-	for i, msg := range messages {
-		switch msg.Role {
-		case "system":
-			chatMessages[i] = openai.SystemMessage(msg.Content)
-		case "user":
-			chatMessages[i] = openai.UserMessage(msg.Content)
-		case "assistant":
-			chatMessages[i] = openai.AssistantMessage(msg.Content)
-		case "tool":
-			// For tool messages, we need to handle tool call ID
-			// Use the ToolCallID from the Message struct
-			chatMessages[i] = openai.ToolMessage(msg.Content, msg.ToolCallID)
-		default:
-			// Default to user message for unknown roles
-			chatMessages[i] = openai.UserMessage(msg.Content)
-		}
-	}
+// newChatCompletionParams builds the chat completion request of a Generate
+// call, which is also used for batch requests
+func (c *AzureOpenAIClient) newChatCompletionParams(ctx context.Context, prompt string, params *interfaces.GenerateOptions) openai.ChatCompletionNewParams {
+	// Build messages with memory and current prompt
+	messages := []openai.ChatCompletionMessageParamUnion{}
 
-	// Create chat request - use deployment name as model for Azure OpenAI
-	req := openai.ChatCompletionNewParams{
-		Model:            openai.ChatModel(c.deployment),
-		Messages:         chatMessages,
-		Temperature:      openai.Float(c.getTemperatureForModel(params.Temperature)),
-		FrequencyPenalty: openai.Float(params.FrequencyPenalty),
-		PresencePenalty:  openai.Float(params.PresencePenalty),
+	// Add system message if available
+	if params.SystemMessage != "" {
+		messages = append(messages, openai.SystemMessage(params.SystemMessage))
+		c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": params.SystemMessage})
 	}
 
-	// Reasoning models don't support top_p parameter
-	if !isReasoningModel(c.Model) {
-		req.TopP = openai.Float(params.TopP)
-	}
+	// Add memory messages and current prompt
+	builder := newMessageHistoryBuilder(c.logger)
+	messages = append(messages, builder.buildMessages(ctx, prompt, params)...)
 
-	if len(params.StopSequences) > 0 {
-		req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.StopSequences}
+	// Create request - use deployment name as model for Azure OpenAI
+	req := openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(c.deployment),
+		Messages: messages,
 	}
 
-	// Set reasoning effort for reasoning models
-	if isReasoningModel(c.Model) && params.Reasoning != "" {
-		req.ReasoningEffort = shared.ReasoningEffort(params.Reasoning)
-		c.logger.Debug(ctx, "Setting reasoning effort", map[string]interface{}{"reasoning_effort": params.Reasoning})
+	if params.LLMConfig != nil {
+		req.Temperature = openai.Float(c.getTemperatureForModel(params.LLMConfig.Temperature))
+		// Reasoning models don't support top_p parameter
+		if !isReasoningModel(c.Model) {
+			req.TopP = openai.Float(params.LLMConfig.TopP)
+		}
+		req.FrequencyPenalty = openai.Float(params.LLMConfig.FrequencyPenalty)
+		req.PresencePenalty = openai.Float(params.LLMConfig.PresencePenalty)
+		if len(params.LLMConfig.StopSequences) > 0 {
+			req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.LLMConfig.StopSequences}
+		}
+		// Set reasoning effort for reasoning models
+		if isReasoningModel(c.Model) && params.LLMConfig.Reasoning != "" {
+			req.ReasoningEffort = shared.ReasoningEffort(params.LLMConfig.Reasoning)
+			c.logger.Debug(ctx, "Setting reasoning effort", map[string]interface{}{"reasoning_effort": params.LLMConfig.Reasoning})
+		}
 	}
 
-	var resp *openai.ChatCompletion
-	var err error
-
-	operation := func() error {
-		c.logger.Debug(ctx, "Executing Azure OpenAI Chat API request", map[string]interface{}{
-			"model":             c.Model,
-			"deployment":        c.deployment,
-			"temperature":       req.Temperature,
-			"top_p":             req.TopP,
-			"frequency_penalty": req.FrequencyPenalty,
-			"presence_penalty":  req.PresencePenalty,
-			"stop_sequences":    req.Stop,
-			"messages":          len(req.Messages),
-			"reasoning_effort":  params.Reasoning,
-		})
+	// Set response format if provided
+	if params.ResponseFormat != nil {
+		// Convert to the new API's response format structure
+		jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   params.ResponseFormat.Name,
+			Schema: params.ResponseFormat.Schema,
+		}
 
-		resp, err = c.ChatService.Completions.New(ctx, req)
-		if err != nil {
-			c.logger.Error(ctx, "Error from Azure OpenAI Chat API", map[string]interface{}{
-				"error":      err.Error(),
-				"model":      c.Model,
-				"deployment": c.deployment,
-			})
-			return fmt.Errorf("failed to create chat completion: %w", err)
+		req.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				Type:       "json_schema",
+				JSONSchema: jsonSchema,
+			},
 		}
-		return nil
+		c.logger.Debug(ctx, "Using response format", map[string]interface{}{"format": *params.ResponseFormat})
 	}
 
-	if c.retryExecutor != nil {
-		c.logger.Debug(ctx, "Using retry mechanism for Azure OpenAI Chat request", map[string]interface{}{
-			"model":      c.Model,
-			"deployment": c.deployment,
-		})
-		err = c.retryExecutor.Execute(ctx, operation)
-	} else {
-		err = operation()
+	// Set organization ID if available
+	if orgID, ok := ctx.Value(organizationKey).(string); ok && orgID != "" {
+		req.User = openai.String(orgID)
 	}
+	return req
+}
 
-	if err != nil {
-		return "", err
+// Chat uses the ChatCompletion API to have a conversation (messages) with a model.
+// It sends the messages with ChatMessages; DefaultGenerateParams are used if
+// params is nil.
+func (c *AzureOpenAIClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
+	if params == nil {
+		params = llm.DefaultGenerateParams()
 	}
+	return c.ChatMessages(ctx, llm.ConvertMessages(messages), params.Options()...)
+}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no completions returned")
+// ChatMessages generates the next assistant message of a conversation. The
+// messages are sent the way messages from memory are, so assistant tool calls
+// and tool results are kept; leading system messages are used as the system
+// message.
+func (c *AzureOpenAIClient) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to chat: %w", err)
 	}
-
-	c.logger.Debug(ctx, "Successfully received chat response from Azure OpenAI", map[string]interface{}{
-		"model":      c.Model,
-		"deployment": c.deployment,
-	})
-
-	return resp.Choices[0].Message.Content, nil
+	return c.Generate(ctx, prompt, options...)
 }
 
 // GenerateWithTools implements interfaces.LLM.GenerateWithTools
@@ -552,7 +489,7 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 
 	// Add memory messages and current prompt
 	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+	messages = append(messages, builder.buildMessages(ctx, prompt, params)...)
 
 	// Create request - use deployment name as model for Azure OpenAI
 	req := openai.ChatCompletionNewParams{
@@ -605,6 +542,9 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 	for iteration := 0; iteration < maxIterations; iteration++ {
 		// Update request with current messages
 		req.Messages = messages
+		if choice := params.ToolChoice.ForTurn(iteration + 1); choice != nil {
+			req.ToolChoice = toolChoiceParam(choice)
+		}
 
 		// Send request
 		var reasoningEffort string
@@ -636,8 +576,9 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 				"error":      err.Error(),
 				"deployment": c.deployment,
 			})
-			return "", fmt.Errorf("failed to create chat completion: %w", err)
+			return "", fmt.Errorf("failed to create chat completion: %w", c.classifyError(err))
 		}
+		c.recordUsage(ctx, resp.Model, resp.Usage, iteration+1)
 
 		if len(resp.Choices) == 0 {
 			return "", fmt.Errorf("no completions returned")
@@ -660,8 +601,19 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 		// Add the assistant's message with tool calls to the conversation
 		messages = append(messages, resp.Choices[0].Message.ToParam())
 
-		// Process each tool call
-		for _, toolCall := range toolCalls {
+		// Execute the requested tools, concurrently if enabled. Wrapped parallel
+		// tool uses are expanded and executed while processing the calls below.
+		executions := make([]*llm.ToolExecution, len(toolCalls))
+		for i, toolCall := range toolCalls {
+			executions[i] = &llm.ToolExecution{Arguments: toolCall.Function.Arguments}
+			if !isParallelToolUse(toolCall.Function.Name) {
+				executions[i].Tool = llm.FindTool(tools, toolCall.Function.Name)
+			}
+		}
+		llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+		// Process each tool call in the order the model requested them
+		for i, toolCall := range toolCalls {
 			// Replace multi_tool_use.parallel name if present
 			if toolCall.Function.Name == "multi_tool_use.parallel" {
 				c.logger.Info(ctx, "Replacing multi_tool_use.parallel with parallel_tool_use", nil)
@@ -710,14 +662,7 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 						}
 
 						// Find the correct tool for this operation
-						var tool interfaces.Tool
-						for _, t := range tools {
-							if t.Name() == toolName {
-								tool = t
-								break
-							}
-						}
-
+						tool := llm.FindTool(tools, toolName)
 						if tool == nil {
 							err := fmt.Errorf("tool not found: %s", toolName)
 							c.logger.Error(ctx, "Tool not found in parallel execution", map[string]interface{}{"toolName": toolName})
@@ -803,12 +748,13 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 					close(resultCh)
 				}()
 
-				// Collect results and check for errors
+				// Collect results, reporting failed tools back to the model
 				toolsResults := make([]string, len(toolUsesWrapper.ToolUses))
 				for result := range resultCh {
 					if result.err != nil {
 						c.logger.Error(ctx, "Error executing tool", map[string]interface{}{"error": result.err.Error()})
-						return "", fmt.Errorf("error executing tool: %s", result.err.Error())
+						toolsResults[result.index] = fmt.Sprintf("Error: %v", result.err)
+						continue
 					}
 					toolsResults[result.index] = result.result
 				}
@@ -825,15 +771,7 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 				continue
 			}
 
-			// Find the requested tool
-			var selectedTool interfaces.Tool
-			for _, tool := range tools {
-				if tool.Name() == toolCall.Function.Name {
-					selectedTool = tool
-					break
-				}
-			}
-
+			selectedTool := executions[i].Tool
 			if selectedTool == nil || selectedTool.Name() == "" {
 				c.logger.Error(ctx, "Tool not found", map[string]interface{}{
 					"toolName": toolCall.Function.Name,
@@ -886,11 +824,10 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 				continue // Continue processing other tool calls
 			}
 
-			// Execute the tool
-			c.logger.Info(ctx, "Executing tool", map[string]interface{}{"toolName": selectedTool.Name()})
-			toolStartTime := time.Now()
-			toolResult, err := selectedTool.Execute(ctx, toolCall.Function.Arguments)
-			toolEndTime := time.Now()
+			c.logger.Info(ctx, "Executed tool", map[string]interface{}{"toolName": selectedTool.Name()})
+			toolStartTime := executions[i].StartTime
+			toolResult, err := executions[i].Result, executions[i].Err
+			toolEndTime := toolStartTime.Add(executions[i].Duration)
 
 			// Check for repetitive calls and add warning if needed
 			cacheKey := toolCall.Function.Name + ":" + toolCall.Function.Arguments
@@ -1038,8 +975,9 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 	finalResp, err := c.ChatService.Completions.New(ctx, finalReq)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to create final chat completion: %w", err)
+		return "", fmt.Errorf("failed to create final chat completion: %w", c.classifyError(err))
 	}
+	c.recordUsage(ctx, finalResp.Model, finalResp.Usage, maxIterations+1)
 
 	if len(finalResp.Choices) == 0 {
 		return "", fmt.Errorf("no completions returned in final call")
@@ -1050,6 +988,35 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 	return content, nil
 }
 
+// isParallelToolUse reports whether a tool call is a wrapper around several parallel tool uses
+func isParallelToolUse(name string) bool {
+	return name == "multi_tool_use.parallel" || name == "parallel_tool_use"
+}
+
+// recordUsage reports the token usage of a completion to the usage collector in the context
+func (c *AzureOpenAIClient) recordUsage(ctx context.Context, model string, usage openai.CompletionUsage, iteration int) {
+	if model == "" {
+		model = c.Model
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{
+		Provider:  c.Name(),
+		Model:     model,
+		Iteration: iteration,
+		Usage:     convertUsage(usage),
+	})
+}
+
+// convertUsage converts Azure OpenAI completion usage to the provider-neutral format
+func convertUsage(usage openai.CompletionUsage) llm.TokenUsage {
+	return llm.TokenUsage{
+		InputTokens:       int(usage.PromptTokens),
+		OutputTokens:      int(usage.CompletionTokens),
+		CachedInputTokens: int(usage.PromptTokensDetails.CachedTokens),
+		ReasoningTokens:   int(usage.CompletionTokensDetails.ReasoningTokens),
+		TotalTokens:       int(usage.TotalTokens),
+	}
+}
+
 // Name implements interfaces.LLM.Name
 func (c *AzureOpenAIClient) Name() string {
 	return "azure-openai"
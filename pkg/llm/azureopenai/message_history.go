@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/openai/openai-go/v2"
 )
@@ -22,12 +23,12 @@ func newMessageHistoryBuilder(logger logging.Logger) *messageHistoryBuilder {
 
 // buildMessages constructs Azure OpenAI messages from memory and current prompt
 // Returns messages ready for Azure OpenAI API calls, preserving chronological order
-func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, memory interfaces.Memory) []openai.ChatCompletionMessageParamUnion {
+func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, params *interfaces.GenerateOptions) []openai.ChatCompletionMessageParamUnion {
 	messages := []openai.ChatCompletionMessageParamUnion{}
 
 	// Add memory messages
-	if memory != nil {
-		memoryMessages, err := memory.GetMessages(ctx)
+	if params.Memory != nil {
+		memoryMessages, err := params.Memory.GetMessages(ctx)
 		if err != nil {
 			b.logger.Error(ctx, "Failed to retrieve memory messages", map[string]interface{}{
 				"error": err.Error(),
@@ -43,7 +44,11 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 		}
 	} else {
 		// Only append current user message when memory is nil
-		messages = append(messages, openai.UserMessage(prompt))
+		if parts := llm.PromptParts(prompt, params); len(parts) > 0 {
+			messages = append(messages, openai.UserMessage(b.convertContentParts(ctx, parts)))
+		} else {
+			messages = append(messages, openai.UserMessage(prompt))
+		}
 	}
 
 	return messages
@@ -53,6 +58,10 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *openai.ChatCompletionMessageParamUnion {
 	switch msg.Role {
 	case interfaces.MessageRoleUser:
+		if len(msg.Parts) > 0 {
+			userMsg := openai.UserMessage(b.convertContentParts(context.Background(), msg.Parts))
+			return &userMsg
+		}
 		userMsg := openai.UserMessage(msg.Content)
 		return &userMsg
 
@@ -78,3 +87,56 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *op
 
 	return nil
 }
+
+// convertContentParts converts multimodal content parts to Azure OpenAI content parts.
+// Parts the API cannot take, such as files given by URL, are skipped.
+func (b *messageHistoryBuilder) convertContentParts(ctx context.Context, parts []interfaces.ContentPart) []openai.ChatCompletionContentPartUnionParam {
+	var contentParts []openai.ChatCompletionContentPartUnionParam
+	for _, part := range parts {
+		switch {
+		case part.Type == interfaces.ContentPartText:
+			contentParts = append(contentParts, openai.TextContentPart(part.Text))
+
+		case part.Type == interfaces.ContentPartImage:
+			contentParts = append(contentParts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+				URL:    part.DataURL(),
+				Detail: part.Detail,
+			}))
+
+		case part.Type == interfaces.ContentPartFile && len(part.Data) > 0:
+			file := openai.ChatCompletionContentPartFileFileParam{
+				FileData: openai.String(part.DataURL()),
+			}
+			if part.Filename != "" {
+				file.Filename = openai.String(part.Filename)
+			}
+			contentParts = append(contentParts, openai.FileContentPart(file))
+
+		case part.Type == interfaces.ContentPartAudio && len(part.Data) > 0 && audioFormat(part.MediaType()) != "":
+			contentParts = append(contentParts, openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+				Data:   part.Base64(),
+				Format: audioFormat(part.MediaType()),
+			}))
+
+		default:
+			b.logger.Warn(ctx, "Skipping content part not supported by Azure OpenAI", map[string]interface{}{
+				"type":      part.Type,
+				"mime_type": part.MediaType(),
+				"url":       part.URL,
+			})
+		}
+	}
+	return contentParts
+}
+
+// audioFormat returns the input audio format for a MIME type, or "" if the
+// format is not supported
+func audioFormat(mimeType string) string {
+	switch mimeType {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "wav"
+	case "audio/mpeg", "audio/mp3":
+		return "mp3"
+	}
+	return ""
+}
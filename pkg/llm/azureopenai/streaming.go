@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/shared"
@@ -52,7 +53,7 @@ func (c *AzureOpenAIClient) GenerateStream(
 
 		// Build messages using unified builder
 		builder := newMessageHistoryBuilder(c.logger)
-		messages := builder.buildMessages(ctx, prompt, params.Memory)
+		messages := builder.buildMessages(ctx, prompt, params)
 
 		// Create stream request - use deployment name as model for Azure OpenAI
 		streamParams := openai.ChatCompletionNewParams{
@@ -80,13 +81,13 @@ func (c *AzureOpenAIClient) GenerateStream(
 			}
 		}
 
+		// Request a final usage chunk so token usage can be reported
+		streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		}
+
 		// Handle reasoning models and reasoning config
 		if isReasoningModel(c.Model) || (params.LLMConfig != nil && params.LLMConfig.EnableReasoning) {
-			// o1 models or reasoning enabled - ensure we get usage info
-			streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
-				IncludeUsage: openai.Bool(true),
-			}
-
 			// Log reasoning support
 			if isReasoningModel(c.Model) {
 				c.logger.Debug(ctx, "Using reasoning model with built-in reasoning", map[string]interface{}{
@@ -208,6 +209,7 @@ func (c *AzureOpenAIClient) GenerateStream(
 
 			// Handle usage information (especially for o1 models)
 			if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 || chunk.Usage.TotalTokens > 0 {
+				c.recordUsage(ctx, chunk.Model, chunk.Usage, 0)
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventContentDelta,
 					Timestamp: time.Now(),
@@ -216,6 +218,7 @@ func (c *AzureOpenAIClient) GenerateStream(
 							"prompt_tokens":     chunk.Usage.PromptTokens,
 							"completion_tokens": chunk.Usage.CompletionTokens,
 							"total_tokens":      chunk.Usage.TotalTokens,
+							"cached_tokens":     chunk.Usage.PromptTokensDetails.CachedTokens,
 						},
 					},
 				}
@@ -231,7 +234,7 @@ func (c *AzureOpenAIClient) GenerateStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("azure openai streaming error: %w", err),
+				Error:     fmt.Errorf("azure openai streaming error: %w", c.classifyError(err)),
 				Timestamp: time.Now(),
 			}
 			return
@@ -351,7 +354,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 
 		// Build messages using unified builder
 		builder := newMessageHistoryBuilder(c.logger)
-		messages := builder.buildMessages(ctx, prompt, params.Memory)
+		messages := builder.buildMessages(ctx, prompt, params)
 
 		// Send initial message start event
 		eventChan <- interfaces.StreamEvent{
@@ -379,7 +382,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				Model:      openai.ChatModel(c.deployment),
 				Messages:   messages,
 				Tools:      openaiTools,
-				ToolChoice: openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("auto")},
+				ToolChoice: toolChoiceParam(params.ToolChoice.ForTurn(iteration + 1)),
 			}
 
 			// Reasoning models only support temperature=1 (default), so don't set it
@@ -387,12 +390,13 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				streamParams.Temperature = openai.Float(params.LLMConfig.Temperature)
 			}
 
+			// Request a final usage chunk so token usage can be reported
+			streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+				IncludeUsage: openai.Bool(true),
+			}
+
 			// Handle reasoning models
 			if isReasoningModel(c.Model) || (params.LLMConfig != nil && params.LLMConfig.EnableReasoning) {
-				streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
-					IncludeUsage: openai.Bool(true),
-				}
-
 				if isReasoningModel(c.Model) {
 					c.logger.Debug(ctx, "Using reasoning model with built-in reasoning for tools", map[string]interface{}{
 						"model":      c.Model,
@@ -459,7 +463,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				})
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("azure openai streaming error: %w", stream.Err()),
+					Error:     fmt.Errorf("azure openai streaming error: %w", c.classifyError(stream.Err())),
 					Timestamp: time.Now(),
 				}
 				return
@@ -474,6 +478,9 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			// Process stream chunks
 			for stream.Next() {
 				chunk := stream.Current()
+				if chunk.Usage.TotalTokens > 0 {
+					c.recordUsage(ctx, chunk.Model, chunk.Usage, iteration+1)
+				}
 
 				for _, choice := range chunk.Choices {
 					// Handle content
@@ -586,7 +593,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				})
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("azure openai streaming error: %w", err),
+					Error:     fmt.Errorf("azure openai streaming error: %w", c.classifyError(err)),
 					Timestamp: time.Now(),
 				}
 				return
@@ -628,26 +635,26 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			assistantResponse.Role = "assistant"
 			messages = append(messages, assistantResponse.ToParam())
 
-			// Process each tool call
-			for _, toolCall := range assistantResponse.ToolCalls {
-				// Find the matching tool
-				var foundTool interfaces.Tool
-				for _, tool := range tools {
-					if tool.Name() == toolCall.Function.Name {
-						foundTool = tool
-						break
-					}
+			// Execute the requested tools, concurrently if enabled
+			executions := make([]*llm.ToolExecution, len(assistantResponse.ToolCalls))
+			for i, toolCall := range assistantResponse.ToolCalls {
+				executions[i] = &llm.ToolExecution{
+					Tool:      llm.FindTool(tools, toolCall.Function.Name),
+					Arguments: toolCall.Function.Arguments,
 				}
+			}
+			llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
 
-				if foundTool == nil {
+			// Process each tool call in the order the model requested them
+			for i, toolCall := range assistantResponse.ToolCalls {
+				if executions[i].Tool == nil {
 					c.logger.Error(ctx, "Tool not found", map[string]interface{}{
 						"tool_name": toolCall.Function.Name,
 					})
 					continue
 				}
 
-				// Execute the tool
-				result, err := foundTool.Execute(ctx, toolCall.Function.Arguments)
+				result, err := executions[i].Result, executions[i].Err
 				if err != nil {
 					c.logger.Error(ctx, "Tool execution error", map[string]interface{}{
 						"tool_name": toolCall.Function.Name,
@@ -767,6 +774,9 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 		finalStreamParams := openai.ChatCompletionNewParams{
 			Model:    openai.ChatModel(c.deployment),
 			Messages: finalMessages,
+			StreamOptions: openai.ChatCompletionStreamOptionsParam{
+				IncludeUsage: openai.Bool(true),
+			},
 		}
 
 		// Reasoning models only support temperature=1 (default), so don't set it
@@ -806,7 +816,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("azure openai final streaming error: %w", finalStream.Err()),
+				Error:     fmt.Errorf("azure openai final streaming error: %w", c.classifyError(finalStream.Err())),
 				Timestamp: time.Now(),
 			}
 			return
@@ -818,6 +828,9 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 		// Process final stream
 		for finalStream.Next() {
 			chunk := finalStream.Current()
+			if chunk.Usage.TotalTokens > 0 {
+				c.recordUsage(ctx, chunk.Model, chunk.Usage, maxIterations+1)
+			}
 
 			for _, choice := range chunk.Choices {
 				// Handle final content
@@ -858,7 +871,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("azure openai final streaming error: %w", err),
+				Error:     fmt.Errorf("azure openai final streaming error: %w", c.classifyError(err)),
 				Timestamp: time.Now(),
 			}
 			return
@@ -0,0 +1,27 @@
+package azureopenai
+
+import (
+	"github.com/openai/openai-go/v2"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// toolChoiceParam converts a tool choice to the tool_choice parameter. A nil
+// choice is auto.
+func toolChoiceParam(choice *interfaces.ToolChoice) openai.ChatCompletionToolChoiceOptionUnionParam {
+	if choice == nil {
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("auto")}
+	}
+	switch choice.Mode {
+	case interfaces.ToolChoiceModeTool:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfFunctionToolChoice: &openai.ChatCompletionNamedToolChoiceParam{
+				Function: openai.ChatCompletionNamedToolChoiceFunctionParam{Name: choice.Name},
+			},
+		}
+	case interfaces.ToolChoiceModeNone, interfaces.ToolChoiceModeRequired:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String(string(choice.Mode))}
+	default:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("auto")}
+	}
+}
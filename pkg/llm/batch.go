@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
+)
+
+// DefaultBatchPollInterval is how often WaitForBatch polls when no interval is given
+const DefaultBatchPollInterval = 30 * time.Second
+
+// ValidateBatchRequests checks that a batch has requests and that their
+// custom IDs are set and unique
+func ValidateBatchRequests(requests []interfaces.BatchRequest) error {
+	if len(requests) == 0 {
+		return errors.New("batch has no requests")
+	}
+	seen := make(map[string]bool, len(requests))
+	for i, request := range requests {
+		if request.CustomID == "" {
+			return fmt.Errorf("request %d has no custom ID", i)
+		}
+		if seen[request.CustomID] {
+			return fmt.Errorf("duplicate custom ID %q", request.CustomID)
+		}
+		seen[request.CustomID] = true
+	}
+	return nil
+}
+
+// WaitForBatch polls a batch until it has stopped processing or the context
+// is done. A pollInterval of zero uses DefaultBatchPollInterval.
+func WaitForBatch(ctx context.Context, model interfaces.BatchLLM, batchID string, pollInterval time.Duration) (*interfaces.Batch, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultBatchPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		batch, err := model.GetBatch(ctx, batchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get batch %s: %w", batchID, err)
+		}
+		if batch.Status.Done() {
+			return batch, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return batch, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunBatch submits requests as a batch, waits for it to finish and returns
+// the results keyed by custom ID. Requests of a canceled or expired batch
+// that did not finish have no result.
+func RunBatch(ctx context.Context, model interfaces.BatchLLM, requests []interfaces.BatchRequest, pollInterval time.Duration) (map[string]interfaces.BatchResult, error) {
+	batch, err := model.SubmitBatch(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit batch: %w", err)
+	}
+
+	batch, err = WaitForBatch(ctx, model, batch.ID, pollInterval)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status == interfaces.BatchStatusFailed {
+		return nil, fmt.Errorf("batch %s failed", batch.ID)
+	}
+	return model.GetBatchResults(ctx, batch.ID)
+}
+
+// UnmarshalBatchResult decodes the JSON content of a batch result generated
+// with a response format into v. Text around the JSON object is ignored.
+func UnmarshalBatchResult(result interfaces.BatchResult, v interface{}) error {
+	if result.Err != nil {
+		return result.Err
+	}
+	content := structuredoutput.ExtractJSON(result.Content)
+	if content == "" {
+		return fmt.Errorf("result %s has no JSON content", result.CustomID)
+	}
+	if err := json.Unmarshal([]byte(content), v); err != nil {
+		return fmt.Errorf("failed to unmarshal result %s: %w", result.CustomID, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// stubBatchLLM reports each batch as in progress for a number of polls
+type stubBatchLLM struct {
+	polls    int
+	status   interfaces.BatchStatus
+	requests []interfaces.BatchRequest
+}
+
+func (s *stubBatchLLM) SubmitBatch(ctx context.Context, requests []interfaces.BatchRequest) (*interfaces.Batch, error) {
+	s.requests = requests
+	return &interfaces.Batch{ID: "batch_1", Status: interfaces.BatchStatusInProgress}, nil
+}
+
+func (s *stubBatchLLM) GetBatch(ctx context.Context, batchID string) (*interfaces.Batch, error) {
+	if s.polls > 0 {
+		s.polls--
+		return &interfaces.Batch{ID: batchID, Status: interfaces.BatchStatusInProgress}, nil
+	}
+	return &interfaces.Batch{ID: batchID, Status: s.status}, nil
+}
+
+func (s *stubBatchLLM) GetBatchResults(ctx context.Context, batchID string) (map[string]interfaces.BatchResult, error) {
+	results := make(map[string]interfaces.BatchResult)
+	for _, request := range s.requests {
+		results[request.CustomID] = interfaces.BatchResult{CustomID: request.CustomID, Content: "Result: {\"echo\": \"" + request.Prompt + "\"}"}
+	}
+	return results, nil
+}
+
+func (s *stubBatchLLM) CancelBatch(ctx context.Context, batchID string) (*interfaces.Batch, error) {
+	return &interfaces.Batch{ID: batchID, Status: interfaces.BatchStatusCanceled}, nil
+}
+
+func TestValidateBatchRequests(t *testing.T) {
+	assert.Error(t, ValidateBatchRequests(nil))
+	assert.ErrorContains(t, ValidateBatchRequests([]interfaces.BatchRequest{{Prompt: "a"}}), "no custom ID")
+	assert.ErrorContains(t, ValidateBatchRequests([]interfaces.BatchRequest{{CustomID: "a"}, {CustomID: "a"}}), "duplicate")
+	assert.NoError(t, ValidateBatchRequests([]interfaces.BatchRequest{{CustomID: "a"}, {CustomID: "b"}}))
+}
+
+func TestRunBatch(t *testing.T) {
+	model := &stubBatchLLM{polls: 2, status: interfaces.BatchStatusCompleted}
+	results, err := RunBatch(context.Background(), model, []interfaces.BatchRequest{{CustomID: "a", Prompt: "hello"}}, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 0, model.polls)
+
+	var decoded struct {
+		Echo string `json:"echo"`
+	}
+	require.NoError(t, UnmarshalBatchResult(results["a"], &decoded))
+	assert.Equal(t, "hello", decoded.Echo)
+
+	failed := errors.New("request failed")
+	assert.Equal(t, failed, UnmarshalBatchResult(interfaces.BatchResult{CustomID: "b", Err: failed}, &decoded))
+
+	_, err = RunBatch(context.Background(), &stubBatchLLM{status: interfaces.BatchStatusFailed}, []interfaces.BatchRequest{{CustomID: "a"}}, time.Millisecond)
+	assert.ErrorContains(t, err, "failed")
+}
+
+func TestWaitForBatchContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	batch, err := WaitForBatch(ctx, &stubBatchLLM{polls: 1000}, "batch_1", time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, interfaces.BatchStatusInProgress, batch.Status)
+}
@@ -0,0 +1,370 @@
+// Package cassette records the HTTP exchanges of LLM provider clients and
+// replays them in tests, so agent flows can run offline and deterministically.
+//
+// A cassette is an http.RoundTripper. Pass its HTTP client to any provider
+// client with WithHTTPClient:
+//
+//	c := cassette.Open(t, "testdata/weather_agent.json")
+//	client := anthropic.NewClient(apiKey, anthropic.WithHTTPClient(c.Client()))
+//
+// Tests replay the cassette by default. Set AGENT_SDK_CASSETTE=record to send
+// the requests to the real API and record the responses instead.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// ModeEnvVar is the environment variable that selects the mode of cassettes
+// opened without WithMode: "record" records, anything else replays
+const ModeEnvVar = "AGENT_SDK_CASSETTE"
+
+// Mode is whether a cassette records or replays
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails on requests it has no recording for
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real API and records the exchanges
+	ModeRecord
+)
+
+// ErrUnexpectedRequest is returned when a replayed cassette has no recording for a request
+var ErrUnexpectedRequest = errors.New("cassette: unexpected request")
+
+// Request is a recorded HTTP request
+type Request struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" if the body is not UTF-8
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" if the body is not UTF-8
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+
+	played bool
+}
+
+// file is the format of a cassette on disk
+type file struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Cassette records or replays HTTP exchanges
+type Cassette struct {
+	path          string
+	mode          Mode
+	transport     http.RoundTripper
+	matcher       Matcher
+	ignoredFields []string
+	redacted      []string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	unexpected   []string
+}
+
+// Option configures a cassette
+type Option func(*Cassette)
+
+// WithMode sets whether the cassette records or replays, overriding ModeEnvVar
+func WithMode(mode Mode) Option {
+	return func(c *Cassette) {
+		c.mode = mode
+	}
+}
+
+// WithTransport sets the transport that recorded requests are sent with.
+// The default is http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Cassette) {
+		c.transport = transport
+	}
+}
+
+// WithMatcher sets how requests are matched to recordings. The default is DefaultMatcher.
+func WithMatcher(matcher Matcher) Option {
+	return func(c *Cassette) {
+		c.matcher = matcher
+	}
+}
+
+// WithIgnoredFields leaves top-level JSON body fields out of request matching,
+// e.g. fields holding timestamps or random IDs
+func WithIgnoredFields(fields ...string) Option {
+	return func(c *Cassette) {
+		c.ignoredFields = append(c.ignoredFields, fields...)
+	}
+}
+
+// WithRedactedHeaders adds request headers that are not written to the
+// cassette. Authorization, API key and cookie headers are always redacted.
+func WithRedactedHeaders(headers ...string) Option {
+	return func(c *Cassette) {
+		c.redacted = append(c.redacted, headers...)
+	}
+}
+
+// New creates a cassette stored at path. In replay mode the cassette is
+// loaded from the file, which must exist.
+func New(path string, options ...Option) (*Cassette, error) {
+	c := &Cassette{
+		path:      path,
+		mode:      modeFromEnv(),
+		transport: http.DefaultTransport,
+		matcher:   DefaultMatcher,
+		redacted:  []string{"Authorization", "Api-Key", "X-Api-Key", "X-Goog-Api-Key", "Cookie", "Set-Cookie"},
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette (set %s=record to record it): %w", ModeEnvVar, err)
+		}
+		var f file
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		c.interactions = f.Interactions
+	}
+	return c, nil
+}
+
+// Mode returns whether the cassette records or replays
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Client returns an HTTP client that uses the cassette as its transport
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// RoundTrip implements http.RoundTripper
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.mode == ModeRecord {
+		return c.record(req, body)
+	}
+	return c.replay(req, body)
+}
+
+// replay returns the first unplayed recording that matches the request
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, interaction := range c.interactions {
+		if interaction.played || !c.matcher(req, body, interaction.Request, c.ignoredFields) {
+			continue
+		}
+		interaction.played = true
+
+		responseBody, err := decodeBody(interaction.Response.Body, interaction.Response.BodyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(responseBody)),
+			ContentLength: int64(len(responseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	description := fmt.Sprintf("%s %s %s", req.Method, req.URL.String(), truncate(string(body), 500))
+	c.unexpected = append(c.unexpected, description)
+	return nil, fmt.Errorf("%w: no unplayed recording in %s matches %s", ErrUnexpectedRequest, c.path, description)
+}
+
+// record sends the request and records the exchange. Response bodies are
+// recorded as the client reads them, so streaming responses still stream.
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	requestBody, requestEncoding := encodeBody(body)
+	interaction := &Interaction{
+		Request: Request{
+			Method:       req.Method,
+			URL:          req.URL.String(),
+			Headers:      c.redact(req.Header),
+			Body:         requestBody,
+			BodyEncoding: requestEncoding,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    c.redact(resp.Header),
+		},
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mu.Unlock()
+
+	resp.Body = &recordingBody{ReadCloser: resp.Body, cassette: c, interaction: interaction}
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It does
+// nothing in replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	data, err := json.MarshalIndent(file{Version: 1, Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Unplayed returns the recordings that were not replayed
+func (c *Cassette) Unplayed() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var unplayed []Interaction
+	if c.mode != ModeReplay {
+		return unplayed
+	}
+	for _, interaction := range c.interactions {
+		if !interaction.played {
+			unplayed = append(unplayed, *interaction)
+		}
+	}
+	return unplayed
+}
+
+// Unexpected returns the requests that had no matching recording
+func (c *Cassette) Unexpected() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.unexpected...)
+}
+
+// redact returns a copy of the headers without the redacted ones
+func (c *Cassette) redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range c.redacted {
+		redacted.Del(name)
+	}
+	return redacted
+}
+
+// recordingBody copies a response body into its interaction as it is read
+type recordingBody struct {
+	io.ReadCloser
+	cassette    *Cassette
+	interaction *Interaction
+	buf         bytes.Buffer
+}
+
+func (r *recordingBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF {
+		r.finish()
+	}
+	return n, err
+}
+
+func (r *recordingBody) Close() error {
+	r.finish()
+	return r.ReadCloser.Close()
+}
+
+// finish stores the body read so far in the interaction
+func (r *recordingBody) finish() {
+	r.cassette.mu.Lock()
+	defer r.cassette.mu.Unlock()
+	r.interaction.Response.Body, r.interaction.Response.BodyEncoding = encodeBody(r.buf.Bytes())
+}
+
+func modeFromEnv() Mode {
+	if os.Getenv(ModeEnvVar) == "record" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// readBody reads the request body and restores it for the transport
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,183 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/anthropic"
+	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
+)
+
+const anthropicStream = "event: message_start\n" +
+	`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-haiku-latest","content":[],"usage":{"input_tokens":5,"output_tokens":0}}}` + "\n\n" +
+	"event: content_block_start\n" +
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}` + "\n\n" +
+	"event: content_block_stop\n" +
+	`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+	"event: message_delta\n" +
+	`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}` + "\n\n" +
+	"event: message_stop\n" +
+	`data: {"type":"message_stop"}` + "\n\n"
+
+func post(t *testing.T, client *http.Client, url, body string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	return client.Do(req)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req_1")
+		_, _ = w.Write([]byte(`{"answer": 42}`))
+	}))
+	path := filepath.Join(t.TempDir(), "cassettes", "answer.json")
+
+	recorder, err := New(path, WithMode(ModeRecord))
+	require.NoError(t, err)
+	resp, err := post(t, recorder.Client(), server.URL+"/v1/ask?b=2&a=1", `{"question": "meaning", "n": 1}`)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, `{"answer": 42}`, string(body))
+	require.NoError(t, recorder.Save())
+	assert.Equal(t, 1, calls)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret", "credentials are redacted")
+
+	server.Close()
+
+	player, err := New(path, WithMode(ModeReplay))
+	require.NoError(t, err)
+
+	// Key order, whitespace and query order do not matter
+	resp, err = post(t, player.Client(), server.URL+"/v1/ask?a=1&b=2", `{"n":1,"question":"meaning"}`)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"answer": 42}`, string(body))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "req_1", resp.Header.Get("X-Request-Id"))
+	assert.Empty(t, player.Unplayed())
+
+	// Each recording is played once
+	_, err = post(t, player.Client(), server.URL+"/v1/ask?a=1&b=2", `{"n":1,"question":"meaning"}`)
+	assert.True(t, errors.Is(err, ErrUnexpectedRequest), "got %v", err)
+	assert.Len(t, player.Unexpected(), 1)
+}
+
+func TestReplayUnexpectedRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "interactions": [
+		{"request": {"method": "POST", "url": "https://api.example.com/v1/ask", "body": "{\"question\": \"meaning\"}"},
+		 "response": {"status_code": 200, "body": "{}"}}
+	]}`), 0o644))
+
+	player, err := New(path)
+	require.NoError(t, err)
+
+	_, err = post(t, player.Client(), "https://api.example.com/v1/ask", `{"question": "other"}`)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUnexpectedRequest))
+	assert.Contains(t, err.Error(), `{"question": "other"}`)
+	assert.Len(t, player.Unplayed(), 1)
+
+	_, err = New(filepath.Join(t.TempDir(), "missing.json"), WithMode(ModeReplay))
+	assert.Error(t, err)
+}
+
+func TestIgnoredFields(t *testing.T) {
+	recorded := Request{Method: http.MethodPost, URL: "https://api.example.com/v1", Body: `{"q": "a", "request_id": "1"}`}
+	req := httptest.NewRequest(http.MethodPost, "https://api.example.com/v1", nil)
+
+	assert.False(t, DefaultMatcher(req, []byte(`{"q": "a", "request_id": "2"}`), recorded, nil))
+	assert.True(t, DefaultMatcher(req, []byte(`{"q": "a", "request_id": "2"}`), recorded, []string{"request_id"}))
+	assert.True(t, DefaultMatcher(httptest.NewRequest(http.MethodPost, "https://api.example.com/v1?key=abc", nil),
+		[]byte(`{"q":"a","request_id":"1"}`), recorded, nil), "credential query parameters are ignored")
+}
+
+func TestStreamingReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(anthropicStream))
+	}))
+	path := filepath.Join(t.TempDir(), "stream.json")
+
+	collect := func(c *Cassette) string {
+		client := anthropic.NewClient("test-key",
+			anthropic.WithModel("claude-3-5-haiku-latest"),
+			anthropic.WithBaseURL(server.URL),
+			anthropic.WithHTTPClient(c.Client()),
+		)
+		events, err := client.GenerateStream(context.Background(), "Say hello")
+		require.NoError(t, err)
+		var sb strings.Builder
+		for event := range events {
+			require.NotEqual(t, interfaces.StreamEventError, event.Type, "stream error: %v", event.Error)
+			if event.Type == interfaces.StreamEventContentDelta {
+				sb.WriteString(event.Content)
+			}
+		}
+		return sb.String()
+	}
+
+	recorder := Open(t, path, WithMode(ModeRecord))
+	assert.Equal(t, "Hello world", collect(recorder))
+	require.NoError(t, recorder.Save())
+	server.Close()
+
+	player := Open(t, path, WithMode(ModeReplay))
+	assert.Equal(t, "Hello world", collect(player))
+
+	recorded := player.interactions[0].Response
+	assert.Equal(t, anthropicStream, recorded.Body, "the stream is recorded byte for byte")
+}
+
+func TestProviderClientReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o-mini",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Paris"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":1,"total_tokens":11}}`))
+	}))
+	path := filepath.Join(t.TempDir(), "openai.json")
+
+	generate := func(c *Cassette) (string, error) {
+		client := openai.NewClient("test-key", openai.WithBaseURL(server.URL), openai.WithHTTPClient(c.Client()))
+		return client.Generate(context.Background(), "What is the capital of France?")
+	}
+
+	recorder := Open(t, path, WithMode(ModeRecord))
+	response, err := generate(recorder)
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", response)
+	require.NoError(t, recorder.Save())
+	server.Close()
+
+	response, err = generate(Open(t, path, WithMode(ModeReplay)))
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", response)
+}
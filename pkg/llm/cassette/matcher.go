@@ -0,0 +1,66 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Matcher reports whether a request matches a recorded request. The request
+// body has already been read into body. ignoredFields are top-level JSON body
+// fields that should not be compared.
+type Matcher func(req *http.Request, body []byte, recorded Request, ignoredFields []string) bool
+
+// credentialParams are query parameters that hold credentials and are not compared
+var credentialParams = []string{"key", "api_key", "api-key"}
+
+// DefaultMatcher matches requests with the same method, URL and body. Query
+// parameters may be in any order and credential parameters are ignored. JSON
+// bodies are compared as JSON, so key order and whitespace do not matter.
+func DefaultMatcher(req *http.Request, body []byte, recorded Request, ignoredFields []string) bool {
+	if req.Method != recorded.Method {
+		return false
+	}
+
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil || req.URL.Scheme != recordedURL.Scheme || req.URL.Host != recordedURL.Host ||
+		req.URL.Path != recordedURL.Path || normalizeQuery(req.URL.Query()) != normalizeQuery(recordedURL.Query()) {
+		return false
+	}
+
+	recordedBody, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(NormalizeBody(body, ignoredFields), NormalizeBody(recordedBody, ignoredFields))
+}
+
+// NormalizeBody returns JSON bodies re-encoded with sorted keys and without
+// the ignored top-level fields. Other bodies are returned unchanged.
+func NormalizeBody(body []byte, ignoredFields []string) []byte {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, field := range ignoredFields {
+			delete(object, field)
+		}
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return normalized
+}
+
+func normalizeQuery(query url.Values) string {
+	for _, param := range credentialParams {
+		query.Del(param)
+	}
+	// Encode sorts by key
+	return query.Encode()
+}
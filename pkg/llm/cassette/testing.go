@@ -0,0 +1,31 @@
+package cassette
+
+import "testing"
+
+// Open opens a cassette for a test. When the test ends, a recorded cassette
+// is saved, and a replayed cassette fails the test if a request had no
+// recording or a recording was not played.
+func Open(t testing.TB, path string, options ...Option) *Cassette {
+	t.Helper()
+
+	c, err := New(path, options...)
+	if err != nil {
+		t.Fatalf("failed to open cassette: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if c.Mode() == ModeRecord {
+			if err := c.Save(); err != nil {
+				t.Errorf("failed to save cassette: %v", err)
+			}
+			return
+		}
+		for _, request := range c.Unexpected() {
+			t.Errorf("cassette %s has no recording for request %s", path, request)
+		}
+		for _, interaction := range c.Unplayed() {
+			t.Errorf("cassette %s recording was not played: %s %s", path, interaction.Request.Method, interaction.Request.URL)
+		}
+	})
+	return c
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// ErrEmptyConversation is returned when a chat is started without messages
+var ErrEmptyConversation = errors.New("conversation has no messages")
+
+// Chat generates the next assistant message of a conversation. LLMs that
+// implement interfaces.ChatLLM are given the messages as they are; any other
+// LLM is called with Generate and the messages as its memory.
+func Chat(ctx context.Context, model interfaces.LLM, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	if chatModel, ok := model.(interfaces.ChatLLM); ok {
+		return chatModel.ChatMessages(ctx, messages, options...)
+	}
+	prompt, options, err := ChatOptions(messages, options...)
+	if err != nil {
+		return "", err
+	}
+	return model.Generate(ctx, prompt, options...)
+}
+
+// ChatOptions converts a conversation to the prompt and options of a Generate
+// call, for clients that build their requests from memory. Leading system
+// messages are appended to the system message, the other messages are given
+// as a Memory that replaces any set in the options, and the prompt is the text
+// of the last user message.
+func ChatOptions(messages []interfaces.Message, options ...interfaces.GenerateOption) (string, []interfaces.GenerateOption, error) {
+	if len(messages) == 0 {
+		return "", nil, ErrEmptyConversation
+	}
+
+	var system []string
+	for len(messages) > 0 && messages[0].Role == interfaces.MessageRoleSystem {
+		system = append(system, messages[0].Content)
+		messages = messages[1:]
+	}
+	if len(messages) == 0 {
+		return "", nil, ErrEmptyConversation
+	}
+
+	var prompt string
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == interfaces.MessageRoleUser {
+			prompt = messages[i].Content
+			break
+		}
+	}
+
+	transcript := NewTranscript(messages)
+	options = append(options[:len(options):len(options)], func(params *interfaces.GenerateOptions) {
+		if len(system) > 0 {
+			if params.SystemMessage != "" {
+				system = append([]string{params.SystemMessage}, system...)
+			}
+			params.SystemMessage = strings.Join(system, "\n\n")
+		}
+		params.Memory = transcript
+		params.ContentParts = nil
+	})
+	return prompt, options, nil
+}
+
+// NewTranscript returns a memory that holds the given messages, so that a
+// conversation can be sent through the memory-based message builders of the
+// clients. Messages added to it are kept in the transcript only.
+func NewTranscript(messages []interfaces.Message) interfaces.Memory {
+	return &transcript{messages: append([]interfaces.Message(nil), messages...)}
+}
+
+// transcript is an in-memory conversation that is not tied to a conversation
+// ID, unlike the memory package's buffers
+type transcript struct {
+	mu       sync.RWMutex
+	messages []interfaces.Message
+}
+
+// AddMessage adds a message to the transcript
+func (t *transcript) AddMessage(ctx context.Context, message interfaces.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, message)
+	return nil
+}
+
+// GetMessages returns the messages of the transcript
+func (t *transcript) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	opts := &interfaces.GetMessagesOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var messages []interfaces.Message
+	for _, message := range t.messages {
+		if len(opts.Roles) > 0 && !containsRole(opts.Roles, message.Role) {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	if opts.Limit > 0 && len(messages) > opts.Limit {
+		messages = messages[len(messages)-opts.Limit:]
+	}
+	return messages, nil
+}
+
+// Clear removes all messages from the transcript
+func (t *transcript) Clear(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+	return nil
+}
+
+func containsRole(roles []string, role interfaces.MessageRole) bool {
+	for _, r := range roles {
+		if r == string(role) {
+			return true
+		}
+	}
+	return false
+}
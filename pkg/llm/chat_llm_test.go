@@ -0,0 +1,40 @@
+package llm_test
+
+import (
+	"context"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/llm/anthropic"
+	"github.com/andmang/agent-sdk-go/pkg/llm/azureopenai"
+	"github.com/andmang/agent-sdk-go/pkg/llm/gemini"
+	"github.com/andmang/agent-sdk-go/pkg/llm/ollama"
+	"github.com/andmang/agent-sdk-go/pkg/llm/openai"
+	"github.com/andmang/agent-sdk-go/pkg/llm/router"
+	"github.com/andmang/agent-sdk-go/pkg/llm/vllm"
+)
+
+// Check that every client takes whole conversations
+var (
+	_ interfaces.ChatLLM = (*anthropic.AnthropicClient)(nil)
+	_ interfaces.ChatLLM = (*azureopenai.AzureOpenAIClient)(nil)
+	_ interfaces.ChatLLM = (*gemini.GeminiClient)(nil)
+	_ interfaces.ChatLLM = (*ollama.OllamaClient)(nil)
+	_ interfaces.ChatLLM = (*openai.OpenAIClient)(nil)
+	_ interfaces.ChatLLM = (*router.Client)(nil)
+	_ interfaces.ChatLLM = (*vllm.VLLMClient)(nil)
+)
+
+// messagesChat is the Chat method of the clients that take llm.Message
+type messagesChat interface {
+	Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error)
+}
+
+// Check that the clients keep their Chat method
+var (
+	_ messagesChat = (*anthropic.AnthropicClient)(nil)
+	_ messagesChat = (*azureopenai.AzureOpenAIClient)(nil)
+	_ messagesChat = (*ollama.OllamaClient)(nil)
+	_ messagesChat = (*openai.OpenAIClient)(nil)
+	_ messagesChat = (*vllm.VLLMClient)(nil)
+)
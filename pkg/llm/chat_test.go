@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestChatOptions(t *testing.T) {
+	messages := []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: "Answer in French"},
+		{Role: interfaces.MessageRoleUser, Content: "What time is it?"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "call_1", Name: "get_time", Arguments: "{}"}}},
+		{Role: interfaces.MessageRoleTool, Content: "12:00", ToolCallID: "call_1"},
+	}
+
+	prompt, options, err := ChatOptions(messages,
+		interfaces.WithSystemMessage("You are a clock"),
+		interfaces.WithMemory(NewTranscript(nil)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "What time is it?", prompt)
+
+	params := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(params)
+	}
+	assert.Equal(t, "You are a clock\n\nAnswer in French", params.SystemMessage)
+
+	history, err := params.Memory.GetMessages(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, messages[1:], history, "the transcript replaces the memory given in the options")
+
+	_, _, err = ChatOptions(nil)
+	assert.ErrorIs(t, err, ErrEmptyConversation)
+	_, _, err = ChatOptions(messages[:1])
+	assert.ErrorIs(t, err, ErrEmptyConversation)
+}
+
+func TestTranscript(t *testing.T) {
+	ctx := context.Background()
+	messages := []interfaces.Message{
+		{Role: interfaces.MessageRoleUser, Content: "one"},
+		{Role: interfaces.MessageRoleAssistant, Content: "two"},
+	}
+	transcript := NewTranscript(messages)
+	require.NoError(t, transcript.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "three"}))
+	assert.Len(t, messages, 2, "adding to the transcript does not change the caller's slice")
+
+	all, err := transcript.GetMessages(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	users, err := transcript.GetMessages(ctx, interfaces.WithRoles("user"), interfaces.WithLimit(1))
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "three", users[0].Content)
+
+	require.NoError(t, transcript.Clear(ctx))
+	all, err = transcript.GetMessages(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestConvertMessages(t *testing.T) {
+	messages := ConvertMessages([]Message{
+		{Role: "system", Content: "Be brief"},
+		{Role: "tool", Content: "12:00", ToolCallID: "call_1"},
+		{Role: "function", Content: "Hi"},
+	})
+
+	assert.Equal(t, []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: "Be brief"},
+		{Role: interfaces.MessageRoleTool, Content: "12:00", ToolCallID: "call_1"},
+		{Role: interfaces.MessageRoleUser, Content: "Hi"},
+	}, messages)
+}
+
+func TestGenerateParamsOptions(t *testing.T) {
+	params := &interfaces.GenerateOptions{}
+	for _, option := range DefaultGenerateParams().Options() {
+		option(params)
+	}
+
+	require.NotNil(t, params.LLMConfig)
+	assert.Equal(t, 0.7, params.LLMConfig.Temperature)
+	assert.Equal(t, 1.0, params.LLMConfig.TopP)
+	assert.Equal(t, 50, params.LLMConfig.TopK)
+	assert.Equal(t, 1.1, params.LLMConfig.RepeatPenalty)
+}
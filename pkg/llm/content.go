@@ -0,0 +1,18 @@
+package llm
+
+import "github.com/andmang/agent-sdk-go/pkg/interfaces"
+
+// PromptParts returns the content of the user message for a prompt sent
+// without memory: the prompt text followed by the parts given with
+// interfaces.WithContentParts. It returns nil if there are no parts, in which
+// case the prompt is sent as plain text.
+func PromptParts(prompt string, params *interfaces.GenerateOptions) []interfaces.ContentPart {
+	if params == nil || len(params.ContentParts) == 0 {
+		return nil
+	}
+	parts := make([]interfaces.ContentPart, 0, len(params.ContentParts)+1)
+	if prompt != "" {
+		parts = append(parts, interfaces.TextPart(prompt))
+	}
+	return append(parts, params.ContentParts...)
+}
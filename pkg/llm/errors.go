@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// Fragments of provider error codes and messages that identify a class of
+// failure. The context length and content policy fragments are checked for
+// any status that does not identify the failure; the others only for errors
+// without a status.
+var (
+	contextLengthFragments = []string{
+		"context_length_exceeded",
+		"maximum context length",
+		"context length",
+		"context window",
+		"prompt is too long",
+		"input is too long",
+		"too many tokens",
+		"reduce the length",
+	}
+	contentPolicyFragments = []string{
+		"content_policy",
+		"content policy",
+		"content_filter",
+		"content management policy",
+		"responsibleaipolicyviolation",
+		"safety system",
+	}
+	rateLimitFragments = []string{
+		"rate limit",
+		"rate_limit",
+		"resource_exhausted",
+		"too many requests",
+	}
+	authenticationFragments = []string{
+		"authentication",
+		"permission",
+		"unauthorized",
+		"invalid api key",
+		"invalid_api_key",
+	}
+	transientFragments = []string{
+		"overloaded",
+		"server_error",
+		"server error",
+		"api_error",
+		"internal",
+		"unavailable",
+		"timeout",
+		"timed out",
+		"try again",
+	}
+)
+
+// NewHTTPError classifies a failed HTTP response from a provider API. The
+// error code and message are read from the common JSON error bodies
+// (OpenAI, Anthropic, Google, vLLM and Ollama); other bodies are used as the
+// message as they are.
+func NewHTTPError(provider string, statusCode int, header http.Header, body []byte) *interfaces.ProviderError {
+	code, message := parseErrorBody(body)
+	return NewProviderError(provider, statusCode, code, message, header, nil)
+}
+
+// NewProviderError classifies a provider failure from its HTTP status, error
+// code and message. Retry-After headers, if given, set the requested backoff.
+func NewProviderError(provider string, statusCode int, code, message string, header http.Header, err error) *interfaces.ProviderError {
+	return &interfaces.ProviderError{
+		Provider:   provider,
+		Kind:       classify(statusCode, code, message),
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		RetryAfter: ParseRetryAfter(header, time.Now()),
+		Err:        err,
+	}
+}
+
+// ClassifyError marks network failures such as timeouts and reset connections
+// as transient. Errors that are already classified, context errors and any
+// other errors are returned unchanged.
+func ClassifyError(provider string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var providerErr *interfaces.ProviderError
+	if errors.As(err, &providerErr) {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return &interfaces.ProviderError{Provider: provider, Kind: interfaces.ErrTransient, Err: err}
+	}
+	return err
+}
+
+// IsContextLengthError reports whether the error says the request does not fit
+// in the model's context window. Besides classified provider errors, it
+// recognizes the messages of errors from LLMs that do not classify them.
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, interfaces.ErrContextLength) || containsAny(strings.ToLower(err.Error()), contextLengthFragments)
+}
+
+// ParseRetryAfter returns the backoff requested by the retry-after-ms or
+// Retry-After response headers, or 0 if there is none
+func ParseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if ms := header.Get("retry-after-ms"); ms != "" {
+		if value, err := strconv.ParseFloat(ms, 64); err == nil && value > 0 {
+			return time.Duration(value * float64(time.Millisecond))
+		}
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// classify maps an HTTP status, error code and message to an error class.
+// Statuses that identify the failure are checked first. Errors without a
+// status, such as error events of a stream or failed items of a batch, are
+// classified by their code and message, and are transient only if these say
+// the provider is overloaded or failed.
+func classify(statusCode int, code, message string) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return interfaces.ErrAuthentication
+	case http.StatusTooManyRequests:
+		return interfaces.ErrRateLimit
+	case http.StatusRequestEntityTooLarge:
+		return interfaces.ErrContextLength
+	case http.StatusRequestTimeout:
+		return interfaces.ErrTransient
+	}
+	if statusCode >= 500 {
+		return interfaces.ErrTransient
+	}
+
+	text := strings.ToLower(code + " " + message)
+	switch {
+	case containsAny(text, contextLengthFragments):
+		return interfaces.ErrContextLength
+	case containsAny(text, contentPolicyFragments):
+		return interfaces.ErrContentPolicy
+	}
+	if statusCode == 0 {
+		switch {
+		case containsAny(text, rateLimitFragments):
+			return interfaces.ErrRateLimit
+		case containsAny(text, authenticationFragments):
+			return interfaces.ErrAuthentication
+		case containsAny(text, transientFragments):
+			return interfaces.ErrTransient
+		}
+	}
+	return interfaces.ErrInvalidRequest
+}
+
+// parseErrorBody extracts the error code and message from a provider error body
+func parseErrorBody(body []byte) (code, message string) {
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", strings.TrimSpace(string(body))
+	}
+
+	// {"error": "message"} (Ollama)
+	var errorString string
+	if json.Unmarshal(parsed.Error, &errorString) == nil && errorString != "" {
+		return "", errorString
+	}
+
+	// {"error": {"type"/"code"/"status": ..., "message": ...}} (OpenAI, Anthropic, Google)
+	var errorObject struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+		Status  string          `json:"status"`
+	}
+	if json.Unmarshal(parsed.Error, &errorObject) == nil && errorObject.Message != "" {
+		return firstNonEmpty(rawString(errorObject.Code), errorObject.Status, errorObject.Type), errorObject.Message
+	}
+
+	// {"object": "error", "message": ..., "type": ...} (vLLM)
+	if parsed.Message != "" {
+		return firstNonEmpty(rawString(parsed.Code), parsed.Type), parsed.Message
+	}
+	return "", strings.TrimSpace(string(body))
+}
+
+// rawString returns a JSON string value, or "" for numbers, null and other values
+func rawString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func containsAny(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+	return false
+}
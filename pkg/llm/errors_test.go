@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestNewHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		kind       error
+		code       string
+		message    string
+	}{
+		{
+			name:       "openai rate limit",
+			statusCode: 429,
+			body:       `{"error":{"message":"Rate limit reached for requests","type":"requests","code":"rate_limit_exceeded"}}`,
+			kind:       interfaces.ErrRateLimit,
+			code:       "rate_limit_exceeded",
+			message:    "Rate limit reached for requests",
+		},
+		{
+			name:       "rate limit on tokens",
+			statusCode: 429,
+			body:       `{"error":{"message":"Too many tokens per minute, please slow down","code":"rate_limit_exceeded"}}`,
+			kind:       interfaces.ErrRateLimit,
+			code:       "rate_limit_exceeded",
+		},
+		{
+			name:       "openai context length",
+			statusCode: 400,
+			body:       `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			kind:       interfaces.ErrContextLength,
+			code:       "context_length_exceeded",
+		},
+		{
+			name:       "openai content policy",
+			statusCode: 400,
+			body:       `{"error":{"message":"Your request was rejected by our safety system","type":"invalid_request_error","code":"content_policy_violation"}}`,
+			kind:       interfaces.ErrContentPolicy,
+			code:       "content_policy_violation",
+		},
+		{
+			name:       "anthropic overloaded",
+			statusCode: 529,
+			body:       `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			kind:       interfaces.ErrTransient,
+			code:       "overloaded_error",
+			message:    "Overloaded",
+		},
+		{
+			name:       "anthropic prompt too long",
+			statusCode: 400,
+			body:       `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+			kind:       interfaces.ErrContextLength,
+			code:       "invalid_request_error",
+		},
+		{
+			name:       "google permission denied",
+			statusCode: 403,
+			body:       `{"error":{"code":403,"message":"API key not valid","status":"PERMISSION_DENIED"}}`,
+			kind:       interfaces.ErrAuthentication,
+			code:       "PERMISSION_DENIED",
+			message:    "API key not valid",
+		},
+		{
+			name:       "vllm context length",
+			statusCode: 400,
+			body:       `{"object":"error","message":"This model's maximum context length is 4096 tokens","type":"BadRequestError","code":400}`,
+			kind:       interfaces.ErrContextLength,
+			code:       "BadRequestError",
+		},
+		{
+			name:       "ollama model not found",
+			statusCode: 404,
+			body:       `{"error":"model \"llama9\" not found"}`,
+			kind:       interfaces.ErrInvalidRequest,
+			message:    `model "llama9" not found`,
+		},
+		{
+			name:       "openai batch conflict",
+			statusCode: 409,
+			body:       `{"error":{"message":"Cannot cancel a batch with status completed.","type":"invalid_request_error"}}`,
+			kind:       interfaces.ErrInvalidRequest,
+			code:       "invalid_request_error",
+		},
+		{
+			name:       "anthropic stream overloaded event",
+			statusCode: 0,
+			body:       `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			kind:       interfaces.ErrTransient,
+			code:       "overloaded_error",
+		},
+		{
+			name:       "anthropic stream invalid request event",
+			statusCode: 0,
+			body:       `{"type":"error","error":{"type":"invalid_request_error","message":"messages: roles must alternate"}}`,
+			kind:       interfaces.ErrInvalidRequest,
+			code:       "invalid_request_error",
+		},
+		{
+			name:       "unknown error without status",
+			statusCode: 0,
+			body:       `{"error":"invalid tool arguments"}`,
+			kind:       interfaces.ErrInvalidRequest,
+		},
+		{
+			name:       "plain text body",
+			statusCode: 502,
+			body:       "Bad Gateway\n",
+			kind:       interfaces.ErrTransient,
+			message:    "Bad Gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewHTTPError("test", tt.statusCode, nil, []byte(tt.body))
+			assert.ErrorIs(t, err, tt.kind)
+			assert.Equal(t, tt.statusCode, err.StatusCode)
+			assert.Equal(t, tt.code, err.Code)
+			if tt.message != "" {
+				assert.Equal(t, tt.message, err.Message)
+			}
+		})
+	}
+}
+
+func TestNewHTTPErrorRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "20")
+	err := NewHTTPError("openai", http.StatusTooManyRequests, header, []byte(`{"error":{"message":"slow down"}}`))
+
+	assert.True(t, err.Retryable())
+	retryAfter, ok := interfaces.RetryAfterFromError(fmt.Errorf("failed to generate text: %w", err))
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, retryAfter)
+	assert.Equal(t, "openai: rate limit exceeded (status 429): slow down", err.Error())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "none", header: http.Header{}, want: 0},
+		{name: "nil", header: nil, want: 0},
+		{name: "milliseconds", header: http.Header{"Retry-After-Ms": {"1500"}, "Retry-After": {"2"}}, want: 1500 * time.Millisecond},
+		{name: "seconds", header: http.Header{"Retry-After": {"3"}}, want: 3 * time.Second},
+		{name: "date", header: http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, want: time.Minute},
+		{name: "past date", header: http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, want: 0},
+		{name: "invalid", header: http.Header{"Retry-After": {"soon"}}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseRetryAfter(tt.header, now))
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	err := ClassifyError("ollama", fmt.Errorf("failed to send request: %w", netErr))
+	assert.ErrorIs(t, err, interfaces.ErrTransient)
+	assert.True(t, interfaces.IsRetryableError(err))
+
+	var opErr *net.OpError
+	require.ErrorAs(t, err, &opErr)
+
+	other := errors.New("failed to marshal request")
+	assert.Equal(t, other, ClassifyError("ollama", other))
+
+	classified := NewProviderError("openai", 401, "invalid_api_key", "Incorrect API key", nil, nil)
+	assert.Same(t, classified, ClassifyError("openai", classified))
+	assert.False(t, interfaces.IsRetryableError(classified))
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	assert.True(t, IsContextLengthError(fmt.Errorf("wrapped: %w", &interfaces.ProviderError{Kind: interfaces.ErrContextLength})))
+	assert.True(t, IsContextLengthError(errors.New("input is too long for requested model")))
+	assert.False(t, IsContextLengthError(errors.New("connection reset")))
+	assert.False(t, IsContextLengthError(nil))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"google.golang.org/genai"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 	"github.com/andmang/agent-sdk-go/pkg/retry"
@@ -53,6 +55,8 @@ type GeminiClient struct {
 	location        string
 	credentialsFile string
 	credentialsJSON []byte
+	baseURL         string
+	httpClient      *http.Client
 	logger          logging.Logger
 	retryExecutor   *retry.Executor
 	thinkingConfig  *ThinkingConfig
@@ -89,11 +93,17 @@ func WithAPIKey(apiKey string) Option {
 	}
 }
 
-// WithBaseURL sets the base URL for the Gemini client (not used with genai package)
+// WithBaseURL sets the base URL for the Gemini client, e.g. for a proxy
 func WithBaseURL(baseURL string) Option {
 	return func(c *GeminiClient) {
-		// Note: baseURL is not used with the genai package as it manages the endpoint internally
-		c.logger.Warn(context.Background(), "BaseURL option is not supported with Gemini API client", nil)
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *GeminiClient) {
+		c.httpClient = httpClient
 	}
 }
 
@@ -181,7 +191,11 @@ func NewClient(ctx context.Context, options ...Option) (*GeminiClient, error) {
 	// Create the genai client if not already provided
 	if client.genaiClient == nil {
 		config := &genai.ClientConfig{
-			Backend: client.backend,
+			Backend:    client.backend,
+			HTTPClient: client.httpClient,
+		}
+		if client.baseURL != "" {
+			config.HTTPOptions.BaseURL = client.baseURL
 		}
 
 		// Configure based on backend type
@@ -308,6 +322,10 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string, options ...i
 			topP := float32(params.LLMConfig.TopP)
 			genConfig.TopP = &topP
 		}
+		if params.LLMConfig.TopK > 0 {
+			topK := float32(params.LLMConfig.TopK)
+			genConfig.TopK = &topK
+		}
 		if len(params.LLMConfig.StopSequences) > 0 {
 			genConfig.StopSequences = params.LLMConfig.StopSequences
 		}
@@ -390,7 +408,7 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string, options ...i
 				"error": err.Error(),
 				"model": c.model,
 			})
-			return fmt.Errorf("failed to generate text: %w", err)
+			return fmt.Errorf("failed to generate text: %w", c.classifyError(err))
 		}
 		return nil
 	}
@@ -408,6 +426,8 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string, options ...i
 		return "", err
 	}
 
+	c.recordUsage(ctx, result, 0)
+
 	// Extract response and separate thinking from final content
 	if len(result.Candidates) > 0 && len(result.Candidates[0].Content.Parts) > 0 {
 		c.logger.Debug(ctx, "Successfully received response from Gemini", map[string]interface{}{
@@ -448,6 +468,18 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string, options ...i
 	return "", fmt.Errorf("no response from Gemini API")
 }
 
+// ChatMessages generates the next assistant message of a conversation. The
+// messages are sent the way messages from memory are, so assistant tool calls
+// and tool results are kept; leading system messages are used as the system
+// instruction.
+func (c *GeminiClient) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to chat: %w", err)
+	}
+	return c.Generate(ctx, prompt, options...)
+}
+
 // GenerateWithTools implements interfaces.LLM.GenerateWithTools
 func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
 	// Convert options to params
@@ -619,6 +651,10 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 				topP := float32(params.LLMConfig.TopP)
 				genConfig.TopP = &topP
 			}
+			if params.LLMConfig.TopK > 0 {
+				topK := float32(params.LLMConfig.TopK)
+				genConfig.TopK = &topK
+			}
 			if len(params.LLMConfig.StopSequences) > 0 {
 				genConfig.StopSequences = params.LLMConfig.StopSequences
 			}
@@ -673,6 +709,7 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 				},
 			},
 			SystemInstruction: systemInstruction,
+			ToolConfig:        toolConfig(params.ToolChoice.ForTurn(iteration + 1)),
 		}
 
 		// Apply generation config parameters directly to config
@@ -697,8 +734,9 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 		result, err := c.genaiClient.Models.GenerateContent(ctx, c.model, contents, config)
 		if err != nil {
 			c.logger.Error(ctx, "Error from Gemini API", map[string]interface{}{"error": err.Error()})
-			return "", fmt.Errorf("failed to create content: %w", err)
+			return "", fmt.Errorf("failed to create content: %w", c.classifyError(err))
 		}
+		c.recordUsage(ctx, result, iteration+1)
 
 		if len(result.Candidates) == 0 {
 			return "", fmt.Errorf("no candidates returned")
@@ -745,23 +783,37 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 		// Collect all function responses to add them in a single content message
 		var functionResponses []*genai.Part
 
-		// Process each function call
+		// Resolve the requested tools and their arguments
+		var functionCalls []*genai.FunctionCall
+		var executions []*llm.ToolExecution
 		for _, part := range candidate.Content.Parts {
 			if part.FunctionCall == nil {
 				continue
 			}
 
-			functionCall := part.FunctionCall
-
-			// Find the requested tool
-			var selectedTool interfaces.Tool
-			for _, tool := range tools {
-				if tool.Name() == functionCall.Name {
-					selectedTool = tool
-					break
+			execution := &llm.ToolExecution{Tool: llm.FindTool(tools, part.FunctionCall.Name)}
+			if execution.Tool != nil {
+				// Convert function call arguments to JSON string
+				argsBytes, err := json.Marshal(part.FunctionCall.Args)
+				if err != nil {
+					c.logger.Error(ctx, "Failed to marshal function call arguments", map[string]interface{}{
+						"error": err.Error(),
+					})
+					return "", fmt.Errorf("failed to marshal function call arguments: %w", err)
 				}
+				execution.Arguments = string(argsBytes)
 			}
 
+			functionCalls = append(functionCalls, part.FunctionCall)
+			executions = append(executions, execution)
+		}
+
+		// Execute the tools, concurrently if enabled
+		llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+		// Process each function call in the order the model requested them
+		for i, functionCall := range functionCalls {
+			selectedTool := executions[i].Tool
 			if selectedTool == nil {
 				c.logger.Error(ctx, "Tool not found", map[string]interface{}{
 					"toolName": functionCall.Name,
@@ -813,23 +865,14 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 				continue // Continue processing other function calls
 			}
 
-			// Convert function call arguments to JSON string
-			argsBytes, err := json.Marshal(functionCall.Args)
-			if err != nil {
-				c.logger.Error(ctx, "Failed to marshal function call arguments", map[string]interface{}{
-					"error": err.Error(),
-				})
-				return "", fmt.Errorf("failed to marshal function call arguments: %w", err)
-			}
-
-			// Execute the tool
-			c.logger.Info(ctx, "Executing tool", map[string]interface{}{"toolName": selectedTool.Name()})
-			toolStartTime := time.Now()
-			toolResult, err := selectedTool.Execute(ctx, string(argsBytes))
-			toolEndTime := time.Now()
+			argsJSON := executions[i].Arguments
+			c.logger.Info(ctx, "Executed tool", map[string]interface{}{"toolName": selectedTool.Name()})
+			toolStartTime := executions[i].StartTime
+			toolEndTime := toolStartTime.Add(executions[i].Duration)
+			toolResult, err := executions[i].Result, executions[i].Err
 
 			// Check for repetitive calls and add warning if needed
-			cacheKey := functionCall.Name + ":" + string(argsBytes)
+			cacheKey := functionCall.Name + ":" + argsJSON
 
 			toolCallHistoryMu.Lock()
 			toolCallHistory[cacheKey]++
@@ -853,7 +896,7 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 			executionDuration := toolEndTime.Sub(toolStartTime)
 			toolCallTrace := tracing.ToolCall{
 				Name:       functionCall.Name,
-				Arguments:  string(argsBytes),
+				Arguments:  argsJSON,
 				Timestamp:  toolStartTime.Format(time.RFC3339),
 				StartTime:  toolStartTime,
 				Duration:   executionDuration,
@@ -869,7 +912,7 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 						Content: "",
 						ToolCalls: []interfaces.ToolCall{{
 							Name:      functionCall.Name,
-							Arguments: string(argsBytes),
+							Arguments: argsJSON,
 						}},
 					})
 					_ = params.Memory.AddMessage(ctx, interfaces.Message{
@@ -886,7 +929,7 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 						Content: "",
 						ToolCalls: []interfaces.ToolCall{{
 							Name:      functionCall.Name,
-							Arguments: string(argsBytes),
+							Arguments: argsJSON,
 						}},
 					})
 					_ = params.Memory.AddMessage(ctx, interfaces.Message{
@@ -902,7 +945,7 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 			if err != nil {
 				c.logger.Error(ctx, "Tool execution failed", map[string]interface{}{
 					"toolName": selectedTool.Name(),
-					"toolArgs": string(argsBytes),
+					"toolArgs": argsJSON,
 					"error":    err.Error(),
 					"duration": toolEndTime.Sub(toolStartTime).String(),
 				})
@@ -969,6 +1012,10 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 			topP := float32(params.LLMConfig.TopP)
 			genConfig.TopP = &topP
 		}
+		if params.LLMConfig.TopK > 0 {
+			topK := float32(params.LLMConfig.TopK)
+			genConfig.TopK = &topK
+		}
 		if len(params.LLMConfig.StopSequences) > 0 {
 			genConfig.StopSequences = params.LLMConfig.StopSequences
 		}
@@ -1030,8 +1077,9 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 	finalResult, err := c.genaiClient.Models.GenerateContent(ctx, c.model, contents, config)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to create final content: %w", err)
+		return "", fmt.Errorf("failed to create final content: %w", c.classifyError(err))
 	}
+	c.recordUsage(ctx, finalResult, maxIterations+1)
 
 	if len(finalResult.Candidates) == 0 {
 		return "", fmt.Errorf("no candidates returned in final call")
@@ -1055,6 +1103,38 @@ func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, too
 	return content, nil
 }
 
+// recordUsage reports the token usage of a response to the usage collector in the context
+func (c *GeminiClient) recordUsage(ctx context.Context, result *genai.GenerateContentResponse, iteration int) {
+	if result == nil || result.UsageMetadata == nil {
+		return
+	}
+	model := result.ModelVersion
+	if model == "" {
+		model = c.model
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{
+		Provider:  c.Name(),
+		Model:     model,
+		Iteration: iteration,
+		Usage:     convertUsage(result.UsageMetadata),
+	})
+}
+
+// convertUsage converts Gemini usage metadata to the provider-neutral format.
+// Gemini reports thinking tokens separately from candidate tokens, so they are
+// added to the output count.
+func convertUsage(usage *genai.GenerateContentResponseUsageMetadata) llm.TokenUsage {
+	input := int(usage.PromptTokenCount + usage.ToolUsePromptTokenCount)
+	output := int(usage.CandidatesTokenCount + usage.ThoughtsTokenCount)
+	return llm.TokenUsage{
+		InputTokens:       input,
+		OutputTokens:      output,
+		CachedInputTokens: int(usage.CachedContentTokenCount),
+		ReasoningTokens:   int(usage.ThoughtsTokenCount),
+		TotalTokens:       int(usage.TotalTokenCount),
+	}
+}
+
 // Name implements interfaces.LLM.Name
 func (c *GeminiClient) Name() string {
 	return "gemini"
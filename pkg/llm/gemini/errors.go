@@ -0,0 +1,32 @@
+package gemini
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/genai"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// classifyError converts Gemini API errors to an *interfaces.ProviderError.
+// The backoff Google asks for in RetryInfo details is used as RetryAfter.
+func (c *GeminiClient) classifyError(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return llm.ClassifyError(c.Name(), err)
+	}
+
+	providerErr := llm.NewProviderError(c.Name(), apiErr.Code, apiErr.Status, apiErr.Message, nil, err)
+	for _, detail := range apiErr.Details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		if delay, ok := detail["retryDelay"].(string); ok {
+			if retryAfter, parseErr := time.ParseDuration(delay); parseErr == nil {
+				providerErr.RetryAfter = retryAfter
+			}
+		}
+	}
+	return providerErr
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"google.golang.org/genai"
 )
@@ -45,9 +46,13 @@ func (b *messageHistoryBuilder) buildContents(ctx context.Context, prompt string
 		}
 	} else {
 		// Only append current user message when memory is nil
+		parts := []*genai.Part{{Text: prompt}}
+		if contentParts := llm.PromptParts(prompt, params); len(contentParts) > 0 {
+			parts = b.convertContentParts(ctx, contentParts)
+		}
 		contents = append(contents, &genai.Content{
 			Role:  "user",
-			Parts: []*genai.Part{{Text: prompt}},
+			Parts: parts,
 		})
 	}
 
@@ -58,6 +63,12 @@ func (b *messageHistoryBuilder) buildContents(ctx context.Context, prompt string
 func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *genai.Content {
 	switch msg.Role {
 	case interfaces.MessageRoleUser:
+		if len(msg.Parts) > 0 {
+			return &genai.Content{
+				Role:  "user",
+				Parts: b.convertContentParts(context.Background(), msg.Parts),
+			}
+		}
 		return &genai.Content{
 			Role:  "user",
 			Parts: []*genai.Part{{Text: msg.Content}},
@@ -136,3 +147,29 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *ge
 
 	return nil
 }
+
+// convertContentParts converts multimodal content parts to Gemini parts.
+// Inline media is sent as a blob and URLs (including gs:// and File API
+// URIs) as file data.
+func (b *messageHistoryBuilder) convertContentParts(ctx context.Context, parts []interfaces.ContentPart) []*genai.Part {
+	var geminiParts []*genai.Part
+	for _, part := range parts {
+		switch {
+		case part.Type == interfaces.ContentPartText:
+			geminiParts = append(geminiParts, &genai.Part{Text: part.Text})
+		case len(part.Data) > 0:
+			geminiParts = append(geminiParts, &genai.Part{
+				InlineData: &genai.Blob{Data: part.Data, MIMEType: part.MediaType()},
+			})
+		case part.URL != "":
+			geminiParts = append(geminiParts, &genai.Part{
+				FileData: &genai.FileData{FileURI: part.URL, MIMEType: part.MediaType()},
+			})
+		default:
+			b.logger.Warn(ctx, "Skipping empty content part", map[string]interface{}{
+				"type": part.Type,
+			})
+		}
+	}
+	return geminiParts
+}
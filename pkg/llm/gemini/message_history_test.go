@@ -91,3 +91,36 @@ func TestMessageHistoryBuilder_BuildContents(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageHistoryBuilder_ContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New())
+	params := &interfaces.GenerateOptions{
+		ContentParts: []interfaces.ContentPart{
+			interfaces.ImagePart([]byte("png"), "image/png"),
+			interfaces.FileURLPart("gs://bucket/scan.pdf", ""),
+			interfaces.AudioPart([]byte("mp3"), "audio/mpeg"),
+		},
+	}
+
+	contents := builder.buildContents(context.Background(), "Transcribe", params)
+	if len(contents) != 1 {
+		t.Fatalf("Expected 1 content, got %d", len(contents))
+	}
+	parts := contents[0].Parts
+	if len(parts) != 4 {
+		t.Fatalf("Expected 4 parts, got %d", len(parts))
+	}
+	if parts[0].Text != "Transcribe" {
+		t.Errorf("Expected the prompt first, got %q", parts[0].Text)
+	}
+	if parts[1].InlineData == nil || parts[1].InlineData.MIMEType != "image/png" || string(parts[1].InlineData.Data) != "png" {
+		t.Errorf("Unexpected image part: %+v", parts[1].InlineData)
+	}
+	// The MIME type is guessed from the file extension
+	if parts[2].FileData == nil || parts[2].FileData.FileURI != "gs://bucket/scan.pdf" || parts[2].FileData.MIMEType != "application/pdf" {
+		t.Errorf("Unexpected file part: %+v", parts[2].FileData)
+	}
+	if parts[3].InlineData == nil || parts[3].InlineData.MIMEType != "audio/mpeg" {
+		t.Errorf("Unexpected audio part: %+v", parts[3].InlineData)
+	}
+}
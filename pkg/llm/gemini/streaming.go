@@ -10,6 +10,7 @@ import (
 	"google.golang.org/genai"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 )
 
@@ -82,6 +83,10 @@ func (c *GeminiClient) GenerateStream(ctx context.Context, prompt string, option
 			topP := float32(params.LLMConfig.TopP)
 			genConfig.TopP = &topP
 		}
+		if params.LLMConfig.TopK > 0 {
+			topK := float32(params.LLMConfig.TopK)
+			genConfig.TopK = &topK
+		}
 		if len(params.LLMConfig.StopSequences) > 0 {
 			genConfig.StopSequences = params.LLMConfig.StopSequences
 		}
@@ -170,6 +175,9 @@ func (c *GeminiClient) GenerateStream(ctx context.Context, prompt string, option
 		// Track accumulated content for memory storage
 		var accumulatedContent strings.Builder
 
+		// Usage metadata is cumulative, so only the last chunk that carries it is recorded
+		var usageResponse *genai.GenerateContentResponse
+
 		// Start streaming
 		streamIter := c.genaiClient.Models.GenerateContentStream(ctx, c.model, contents, config)
 
@@ -179,13 +187,16 @@ func (c *GeminiClient) GenerateStream(ctx context.Context, prompt string, option
 				select {
 				case eventCh <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     err,
+					Error:     c.classifyError(err),
 					Timestamp: time.Now(),
 				}:
 				case <-ctx.Done():
 				}
 				return
 			}
+			if response.UsageMetadata != nil {
+				usageResponse = response
+			}
 
 			// Process each candidate in the response
 			for _, candidate := range response.Candidates {
@@ -230,6 +241,7 @@ func (c *GeminiClient) GenerateStream(ctx context.Context, prompt string, option
 				}
 			}
 		}
+		c.recordUsage(ctx, usageResponse, 0)
 
 		// Store messages in memory if provided
 		if params.Memory != nil {
@@ -486,6 +498,10 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 				topP := float32(params.LLMConfig.TopP)
 				genConfig.TopP = &topP
 			}
+			if params.LLMConfig.TopK > 0 {
+				topK := float32(params.LLMConfig.TopK)
+				genConfig.TopK = &topK
+			}
 			if len(params.LLMConfig.StopSequences) > 0 {
 				genConfig.StopSequences = params.LLMConfig.StopSequences
 			}
@@ -495,6 +511,7 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 		config := &genai.GenerateContentConfig{
 			SystemInstruction: systemInstruction,
 			Tools:             geminiTools,
+			ToolConfig:        toolConfig(params.ToolChoice.ForTurn(iteration + 1)),
 		}
 
 		// Apply generation config parameters
@@ -521,7 +538,7 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 		// Execute streaming request and collect tool calls
 		shouldFilter := filterIntermediateContent && len(tools) > 0 && iteration < maxIterations-1
 		var iterationContentEvents []interfaces.StreamEvent
-		toolCalls, hasContent, err := c.executeStreamingRequestWithToolCapture(ctx, contents, config, eventCh, shouldFilter, &iterationContentEvents)
+		toolCalls, hasContent, err := c.executeStreamingRequestWithToolCapture(ctx, contents, config, eventCh, shouldFilter, &iterationContentEvents, iteration+1)
 		if err != nil {
 			return "", err
 		}
@@ -569,16 +586,19 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 		// Collect all tool results to add them in a single content message
 		var functionResponses []*genai.Part
 
-		// Execute each tool and collect results
-		for _, toolCall := range toolCalls {
-			// Find the requested tool
-			var selectedTool interfaces.Tool
-			for _, tool := range tools {
-				if tool.Name() == toolCall.Name {
-					selectedTool = tool
-					break
-				}
+		// Execute the requested tools, concurrently if enabled
+		executions := make([]*llm.ToolExecution, len(toolCalls))
+		for i, toolCall := range toolCalls {
+			executions[i] = &llm.ToolExecution{
+				Tool:      llm.FindTool(tools, toolCall.Name),
+				Arguments: toolCall.Arguments,
 			}
+		}
+		llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+		// Process each tool call in the order the model requested them
+		for i, toolCall := range toolCalls {
+			selectedTool := executions[i].Tool
 
 			if selectedTool == nil {
 				c.logger.Error(ctx, "Tool not found in streaming", map[string]interface{}{
@@ -615,14 +635,13 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 				continue // Continue processing other tool calls
 			}
 
-			// Execute tool
-			c.logger.Info(ctx, "Executing tool in streaming", map[string]interface{}{
+			c.logger.Info(ctx, "Executed tool in streaming", map[string]interface{}{
 				"toolName":  toolCall.Name,
 				"arguments": toolCall.Arguments,
 				"iteration": iteration + 1,
 			})
 
-			toolResult, err := selectedTool.Execute(ctx, toolCall.Arguments)
+			toolResult, err := executions[i].Result, executions[i].Err
 			if err != nil {
 				toolResult = fmt.Sprintf("Error: %v", err)
 			}
@@ -749,6 +768,10 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 			topP := float32(params.LLMConfig.TopP)
 			genConfig.TopP = &topP
 		}
+		if params.LLMConfig.TopK > 0 {
+			topK := float32(params.LLMConfig.TopK)
+			genConfig.TopK = &topK
+		}
 		if len(params.LLMConfig.StopSequences) > 0 {
 			genConfig.StopSequences = params.LLMConfig.StopSequences
 		}
@@ -773,7 +796,7 @@ func (c *GeminiClient) generateWithToolsAndStream(ctx context.Context, prompt st
 	}
 
 	// Execute final request to get synthesized answer using streaming (no filtering for final call)
-	_, _, err := c.executeStreamingRequestWithToolCapture(ctx, contents, config, eventCh, false, nil)
+	_, _, err := c.executeStreamingRequestWithToolCapture(ctx, contents, config, eventCh, false, nil, maxIterations+1)
 	if err != nil {
 		return "", fmt.Errorf("failed to create final content: %w", err)
 	}
@@ -815,6 +838,7 @@ func (c *GeminiClient) executeStreamingRequestWithToolCapture(
 	eventCh chan<- interfaces.StreamEvent,
 	filterContent bool,
 	capturedEvents *[]interfaces.StreamEvent,
+	iteration int,
 ) ([]interfaces.ToolCall, bool, error) {
 
 	var toolCalls []interfaces.ToolCall
@@ -840,12 +864,18 @@ func (c *GeminiClient) executeStreamingRequestWithToolCapture(
 		}
 	}
 
+	// Usage metadata is cumulative, so only the last chunk that carries it is recorded
+	var usageResponse *genai.GenerateContentResponse
+
 	// Generate content with tools using streaming
 	streamIter := c.genaiClient.Models.GenerateContentStream(ctx, c.model, contents, config)
 
 	for response, err := range streamIter {
 		if err != nil {
-			return nil, false, fmt.Errorf("failed to generate content stream: %w", err)
+			return nil, false, fmt.Errorf("failed to generate content stream: %w", c.classifyError(err))
+		}
+		if response.UsageMetadata != nil {
+			usageResponse = response
 		}
 
 		// Process each candidate in the response
@@ -917,6 +947,7 @@ func (c *GeminiClient) executeStreamingRequestWithToolCapture(
 			}
 		}
 	}
+	c.recordUsage(ctx, usageResponse, iteration)
 
 	return toolCalls, hasContent, nil
 }
@@ -0,0 +1,27 @@
+package gemini
+
+import (
+	"google.golang.org/genai"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// toolConfig converts a tool choice to a function calling config. A nil
+// choice is left to the API default (auto).
+func toolConfig(choice *interfaces.ToolChoice) *genai.ToolConfig {
+	if choice == nil {
+		return nil
+	}
+
+	config := &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto}
+	switch choice.Mode {
+	case interfaces.ToolChoiceModeNone:
+		config.Mode = genai.FunctionCallingConfigModeNone
+	case interfaces.ToolChoiceModeRequired:
+		config.Mode = genai.FunctionCallingConfigModeAny
+	case interfaces.ToolChoiceModeTool:
+		config.Mode = genai.FunctionCallingConfigModeAny
+		config.AllowedFunctionNames = []string{choice.Name}
+	}
+	return &genai.ToolConfig{FunctionCallingConfig: config}
+}
@@ -0,0 +1,33 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestToolConfig(t *testing.T) {
+	assert.Nil(t, toolConfig(nil))
+
+	tests := []struct {
+		choice  interfaces.ToolChoice
+		mode    genai.FunctionCallingConfigMode
+		allowed []string
+	}{
+		{interfaces.ToolChoiceAuto, genai.FunctionCallingConfigModeAuto, nil},
+		{interfaces.ToolChoiceNone, genai.FunctionCallingConfigModeNone, nil},
+		{interfaces.ToolChoiceRequired, genai.FunctionCallingConfigModeAny, nil},
+		{interfaces.ToolChoiceFunction("get_weather"), genai.FunctionCallingConfigModeAny, []string{"get_weather"}},
+	}
+	for _, tt := range tests {
+		config := toolConfig(&tt.choice)
+		require.NotNil(t, config)
+		require.NotNil(t, config.FunctionCallingConfig)
+		assert.Equal(t, tt.mode, config.FunctionCallingConfig.Mode)
+		assert.Equal(t, tt.allowed, config.FunctionCallingConfig.AllowedFunctionNames)
+	}
+}
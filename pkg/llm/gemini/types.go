@@ -1,6 +1,10 @@
 package gemini
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
 
 // ReasoningMode defines the reasoning approach for the model
 type ReasoningMode string
@@ -267,6 +271,18 @@ func GetModelCapabilities(model string) ModelCapabilities {
 			},
 		}
 	default:
+		// Models registered with llm.RegisterModel, such as tuned models
+		if info, ok := llm.LookupModel(model); ok {
+			return ModelCapabilities{
+				SupportsStreaming:   info.SupportsStreaming,
+				SupportsToolCalling: info.SupportsTools,
+				SupportsVision:      info.SupportsVision,
+				SupportsThinking:    info.SupportsReasoning,
+				MaxInputTokens:      info.ContextWindow,
+				MaxOutputTokens:     info.MaxOutputTokens,
+				SupportedMimeTypes:  []string{"text/plain"},
+			}
+		}
 		// Return default capabilities for unknown models
 		return ModelCapabilities{
 			SupportsStreaming:   true,
@@ -0,0 +1,482 @@
+// Package mock provides a scriptable interfaces.StreamingLLM for tests of
+// agents, orchestrators, memory and anything else that calls an LLM.
+//
+// Responses are scripted turn by turn. Tool calls in a turn are executed
+// against the tools passed to GenerateWithTools, the same way the provider
+// clients do, and every call the LLM receives is recorded for assertions:
+//
+//	model := mock.New(mock.WithTurns(
+//		mock.ToolCall("get_weather", `{"city": "Paris"}`),
+//		mock.Text("It is sunny in Paris."),
+//	))
+//	a, _ := agent.NewAgent(agent.WithLLM(model), agent.WithTools(weatherTool))
+//	response, _ := a.Run(ctx, "What is the weather in Paris?")
+//	// model.Calls()[1].ToolResults[0].Content holds the output of weatherTool
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// Check that LLM implements the streaming interface
+var (
+	_ interfaces.StreamingLLM = (*LLM)(nil)
+	_ interfaces.ChatLLM      = (*LLM)(nil)
+)
+
+// ErrScriptExhausted is returned when the LLM is called after all scripted turns were used
+var ErrScriptExhausted = errors.New("mock: no scripted turns left")
+
+// Turn is one scripted model response
+type Turn struct {
+	Content   string                // Text of the response
+	Thinking  string                // Thinking emitted before the response when streaming
+	ToolCalls []interfaces.ToolCall // Tools the model calls; IDs are generated if empty
+	Chunks    []string              // Streamed deltas; by default Content is streamed word by word
+	Err       error                 // Error returned instead of the response, or after the chunks when streaming
+	Usage     llm.TokenUsage        // Token usage reported to usage collection, if not zero
+}
+
+// Text returns a turn that responds with content
+func Text(content string) Turn {
+	return Turn{Content: content}
+}
+
+// ToolCall returns a turn that calls one tool with JSON arguments
+func ToolCall(name, arguments string) Turn {
+	return Turn{ToolCalls: []interfaces.ToolCall{{Name: name, Arguments: arguments}}}
+}
+
+// ToolCalls returns a turn that calls several tools at once
+func ToolCalls(calls ...interfaces.ToolCall) Turn {
+	return Turn{ToolCalls: calls}
+}
+
+// Error returns a turn that fails with err
+func Error(err error) Turn {
+	return Turn{Err: err}
+}
+
+// WithThinking returns the turn with thinking emitted before it when streaming
+func (t Turn) WithThinking(thinking string) Turn {
+	t.Thinking = thinking
+	return t
+}
+
+// WithChunks returns the turn streamed as the given deltas
+func (t Turn) WithChunks(chunks ...string) Turn {
+	t.Chunks = chunks
+	if t.Content == "" {
+		t.Content = strings.Join(chunks, "")
+	}
+	return t
+}
+
+// WithUsage returns the turn reporting the given token usage
+func (t Turn) WithUsage(usage llm.TokenUsage) Turn {
+	t.Usage = usage
+	return t
+}
+
+// ToolResult is the outcome of a tool call that was sent back to the model
+type ToolResult struct {
+	Call    interfaces.ToolCall
+	Content string // Tool output, or "Error: ..." if the tool failed or was not found
+}
+
+// Call is a request received by the LLM
+type Call struct {
+	Method      string                     // "Generate", "ChatMessages", "GenerateWithTools", "GenerateStream" or "GenerateWithToolsStream"
+	Prompt      string                     // Prompt passed by the caller; for ChatMessages, the text of the last user message
+	Messages    []interfaces.Message       // Conversation passed to ChatMessages
+	Options     interfaces.GenerateOptions // Options after applying the caller's GenerateOptions
+	Tools       []interfaces.Tool          // Tools offered to the model; nil for the final call of a tool loop
+	Iteration   int                        // 1-based iteration of the tool loop, 0 outside of one
+	ToolResults []ToolResult               // Results of the previous turn's tool calls sent with this request
+}
+
+// Responder computes the response to a call, for responses that depend on the input
+type Responder func(call Call) Turn
+
+// LLM is a scriptable interfaces.StreamingLLM. It is safe for concurrent use.
+type LLM struct {
+	name      string
+	responder Responder
+	fallback  *Turn
+
+	mu     sync.Mutex
+	turns  []Turn
+	calls  []Call
+	nextID int
+}
+
+// Option configures the mock LLM
+type Option func(*LLM)
+
+// WithTurns scripts the responses, in order
+func WithTurns(turns ...Turn) Option {
+	return func(m *LLM) {
+		m.turns = append(m.turns, turns...)
+	}
+}
+
+// WithResponder computes responses with fn once the scripted turns are used up
+func WithResponder(fn Responder) Option {
+	return func(m *LLM) {
+		m.responder = fn
+	}
+}
+
+// WithFallback responds with turn once the scripted turns are used up,
+// instead of failing with ErrScriptExhausted
+func WithFallback(turn Turn) Option {
+	return func(m *LLM) {
+		m.fallback = &turn
+	}
+}
+
+// WithName sets the name returned by Name. The default is "mock".
+func WithName(name string) Option {
+	return func(m *LLM) {
+		m.name = name
+	}
+}
+
+// New creates a mock LLM
+func New(options ...Option) *LLM {
+	m := &LLM{name: "mock"}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Script appends scripted turns
+func (m *LLM) Script(turns ...Turn) *LLM {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns = append(m.turns, turns...)
+	return m
+}
+
+// Calls returns the calls received so far, one per model turn
+func (m *LLM) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// LastCall returns the most recent call. It panics if there was none.
+func (m *LLM) LastCall() Call {
+	calls := m.Calls()
+	if len(calls) == 0 {
+		panic("mock: LLM was not called")
+	}
+	return calls[len(calls)-1]
+}
+
+// Prompts returns the prompts of the calls received so far
+func (m *LLM) Prompts() []string {
+	var prompts []string
+	for _, call := range m.Calls() {
+		prompts = append(prompts, call.Prompt)
+	}
+	return prompts
+}
+
+// Remaining returns the number of scripted turns not used yet
+func (m *LLM) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.turns)
+}
+
+// Name implements interfaces.LLM
+func (m *LLM) Name() string {
+	return m.name
+}
+
+// SupportsStreaming implements interfaces.LLM
+func (m *LLM) SupportsStreaming() bool {
+	return true
+}
+
+// Generate implements interfaces.LLM
+func (m *LLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	turn := m.respond(ctx, Call{Method: "Generate", Prompt: prompt, Options: applyOptions(options)})
+	if turn.Err != nil {
+		return "", turn.Err
+	}
+	if len(turn.ToolCalls) > 0 {
+		return "", fmt.Errorf("mock: turn calls tools, but Generate was called without tools")
+	}
+	return turn.Content, nil
+}
+
+// ChatMessages implements interfaces.ChatLLM. Leading system messages are
+// moved to the call's Options.SystemMessage, as the provider clients do.
+func (m *LLM) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
+	if err != nil {
+		return "", err
+	}
+	turn := m.respond(ctx, Call{Method: "ChatMessages", Prompt: prompt, Messages: messages, Options: applyOptions(options)})
+	if turn.Err != nil {
+		return "", turn.Err
+	}
+	if len(turn.ToolCalls) > 0 {
+		return "", fmt.Errorf("mock: turn calls tools, but ChatMessages does not take tools")
+	}
+	return turn.Content, nil
+}
+
+// GenerateWithTools implements interfaces.LLM. Tool calls are executed and
+// their results sent back until a turn has no tool calls. After
+// MaxIterations turns (2 by default) a final call is made without tools.
+func (m *LLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	params := applyOptions(options)
+	var results []ToolResult
+
+	for iteration := 1; ; iteration++ {
+		call := Call{Method: "GenerateWithTools", Prompt: prompt, Options: params, Tools: tools, Iteration: iteration, ToolResults: results}
+		if iteration > maxIterations(params) {
+			call.Tools = nil
+		}
+
+		turn := m.respond(ctx, call)
+		if turn.Err != nil {
+			return "", turn.Err
+		}
+		if len(turn.ToolCalls) == 0 {
+			return turn.Content, nil
+		}
+		if call.Tools == nil {
+			return "", fmt.Errorf("mock: turn calls tools after the maximum of %d iterations", maxIterations(params))
+		}
+		results = executeTools(ctx, turn.ToolCalls, tools, params)
+	}
+}
+
+// GenerateStream implements interfaces.StreamingLLM
+func (m *LLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	params := applyOptions(options)
+	events := make(chan interfaces.StreamEvent, 10)
+
+	go func() {
+		defer close(events)
+		if !send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart}) {
+			return
+		}
+		turn := m.respond(ctx, Call{Method: "GenerateStream", Prompt: prompt, Options: params})
+		if len(turn.ToolCalls) > 0 && turn.Err == nil {
+			turn.Err = fmt.Errorf("mock: turn calls tools, but GenerateStream was called without tools")
+		}
+		if streamTurn(ctx, events, turn, 0) {
+			send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventMessageStop})
+		}
+	}()
+	return events, nil
+}
+
+// GenerateWithToolsStream implements interfaces.StreamingLLM. Tool calls are
+// streamed as tool_use events and their results as tool_result events.
+func (m *LLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	params := applyOptions(options)
+	events := make(chan interfaces.StreamEvent, 10)
+
+	go func() {
+		defer close(events)
+		if !send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart}) {
+			return
+		}
+
+		var results []ToolResult
+		for iteration := 1; ; iteration++ {
+			call := Call{Method: "GenerateWithToolsStream", Prompt: prompt, Options: params, Tools: tools, Iteration: iteration, ToolResults: results}
+			if iteration > maxIterations(params) {
+				call.Tools = nil
+			}
+
+			turn := m.respond(ctx, call)
+			if len(turn.ToolCalls) > 0 && call.Tools == nil && turn.Err == nil {
+				turn.Err = fmt.Errorf("mock: turn calls tools after the maximum of %d iterations", maxIterations(params))
+			}
+			if !streamTurn(ctx, events, turn, iteration) {
+				return
+			}
+			if len(turn.ToolCalls) == 0 {
+				break
+			}
+
+			results = executeTools(ctx, turn.ToolCalls, tools, params)
+			for i := range results {
+				if !send(ctx, events, interfaces.StreamEvent{
+					Type:     interfaces.StreamEventToolResult,
+					ToolCall: &results[i].Call,
+					Content:  results[i].Content,
+					Metadata: map[string]interface{}{"iteration": iteration},
+				}) {
+					return
+				}
+			}
+		}
+		send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventMessageStop})
+	}()
+	return events, nil
+}
+
+// respond records the call and returns the next turn. Like a provider
+// request, a call with a cancelled context fails without being recorded.
+func (m *LLM) respond(ctx context.Context, call Call) Turn {
+	if err := ctx.Err(); err != nil {
+		return Turn{Err: err}
+	}
+
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+
+	var turn Turn
+	switch {
+	case len(m.turns) > 0:
+		turn = m.turns[0]
+		m.turns = m.turns[1:]
+	case m.responder != nil:
+		responder := m.responder
+		m.mu.Unlock()
+		turn = responder(call)
+		m.mu.Lock()
+	case m.fallback != nil:
+		turn = *m.fallback
+	default:
+		turn = Error(fmt.Errorf("%w (call %d, prompt %q)", ErrScriptExhausted, len(m.calls), call.Prompt))
+	}
+
+	if len(turn.ToolCalls) > 0 {
+		calls := make([]interfaces.ToolCall, len(turn.ToolCalls))
+		copy(calls, turn.ToolCalls)
+		for i := range calls {
+			if calls[i].ID == "" {
+				m.nextID++
+				calls[i].ID = fmt.Sprintf("call_%d", m.nextID)
+			}
+		}
+		turn.ToolCalls = calls
+	}
+	m.mu.Unlock()
+
+	if !turn.Usage.IsZero() {
+		llm.AddUsageToContext(ctx, llm.UsageRecord{
+			Provider:  m.name,
+			Model:     m.name,
+			Iteration: call.Iteration,
+			Usage:     turn.Usage,
+			Timestamp: time.Now(),
+		})
+	}
+	return turn
+}
+
+// executeTools runs the tool calls of a turn like the provider clients do,
+// recording them in tracing and memory
+func executeTools(ctx context.Context, calls []interfaces.ToolCall, tools []interfaces.Tool, params interfaces.GenerateOptions) []ToolResult {
+	executions := make([]*llm.ToolExecution, len(calls))
+	for i, call := range calls {
+		executions[i] = &llm.ToolExecution{Tool: llm.FindTool(tools, call.Name), Arguments: call.Arguments}
+	}
+	llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+	results := make([]ToolResult, len(calls))
+	for i, call := range calls {
+		results[i] = ToolResult{Call: call, Content: llm.RecordToolExecution(ctx, call, executions[i], params.Memory)}
+	}
+	return results
+}
+
+// streamTurn sends the events of a turn. It returns false if the stream ended.
+func streamTurn(ctx context.Context, events chan<- interfaces.StreamEvent, turn Turn, iteration int) bool {
+	metadata := map[string]interface{}{}
+	if iteration > 0 {
+		metadata["iteration"] = iteration
+	}
+
+	if turn.Thinking != "" {
+		if !send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventThinking, Content: turn.Thinking, Metadata: metadata}) {
+			return false
+		}
+	}
+
+	chunks := turn.Chunks
+	if chunks == nil && turn.Content != "" {
+		chunks = splitWords(turn.Content)
+	}
+	for _, chunk := range chunks {
+		if !send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: chunk, Metadata: metadata}) {
+			return false
+		}
+	}
+
+	if turn.Err != nil {
+		send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: turn.Err, Metadata: metadata})
+		return false
+	}
+
+	for i := range turn.ToolCalls {
+		if !send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventToolUse, ToolCall: &turn.ToolCalls[i], Metadata: metadata}) {
+			return false
+		}
+	}
+	if len(turn.ToolCalls) == 0 {
+		return send(ctx, events, interfaces.StreamEvent{Type: interfaces.StreamEventContentComplete, Metadata: metadata})
+	}
+	return true
+}
+
+func send(ctx context.Context, events chan<- interfaces.StreamEvent, event interfaces.StreamEvent) bool {
+	event.Timestamp = time.Now()
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// splitWords splits text into deltas that concatenate back to it
+func splitWords(text string) []string {
+	var chunks []string
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if text[i] == ' ' {
+			chunks = append(chunks, text[start:i])
+			start = i
+		}
+	}
+	return append(chunks, text[start:])
+}
+
+// applyOptions applies the options to the defaults the provider clients use,
+// so options that modify LLMConfig work as they do with real clients
+func applyOptions(options []interfaces.GenerateOption) interfaces.GenerateOptions {
+	params := interfaces.GenerateOptions{LLMConfig: &interfaces.LLMConfig{Temperature: 0.7}}
+	for _, option := range options {
+		if option != nil {
+			option(&params)
+		}
+	}
+	return params
+}
+
+func maxIterations(params interfaces.GenerateOptions) int {
+	if params.MaxIterations > 0 {
+		return params.MaxIterations
+	}
+	return 2
+}
@@ -0,0 +1,250 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/agent"
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
+	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
+	"github.com/andmang/agent-sdk-go/pkg/orchestration"
+)
+
+type weatherTool struct {
+	args []string
+}
+
+func (w *weatherTool) Name() string        { return "get_weather" }
+func (w *weatherTool) Description() string { return "Get the weather for a city" }
+func (w *weatherTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{"city": {Type: "string", Required: true}}
+}
+func (w *weatherTool) Run(ctx context.Context, input string) (string, error) {
+	return w.Execute(ctx, input)
+}
+func (w *weatherTool) Execute(ctx context.Context, args string) (string, error) {
+	w.args = append(w.args, args)
+	return "sunny, 24C", nil
+}
+
+func collect(t *testing.T, events <-chan interfaces.StreamEvent) []interfaces.StreamEvent {
+	t.Helper()
+	var all []interfaces.StreamEvent
+	for event := range events {
+		all = append(all, event)
+	}
+	return all
+}
+
+func TestGenerate(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Text("first"), mock.Error(errors.New("boom"))))
+
+	response, err := model.Generate(context.Background(), "hello", interfaces.WithSystemMessage("Be brief"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", response)
+
+	_, err = model.Generate(context.Background(), "again")
+	assert.EqualError(t, err, "boom")
+
+	_, err = model.Generate(context.Background(), "one more")
+	assert.True(t, errors.Is(err, mock.ErrScriptExhausted))
+
+	calls := model.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "Generate", calls[0].Method)
+	assert.Equal(t, "Be brief", calls[0].Options.SystemMessage)
+	assert.Equal(t, []string{"hello", "again", "one more"}, model.Prompts())
+}
+
+func TestGenerateWithTools(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+	mem := memory.NewConversationBuffer()
+
+	tool := &weatherTool{}
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("get_weather", `{"city": "Paris"}`),
+		mock.Text("It is sunny in Paris."),
+	))
+
+	response, err := model.GenerateWithTools(ctx, "Weather in Paris?", []interfaces.Tool{tool}, interfaces.WithMemory(mem))
+	require.NoError(t, err)
+	assert.Equal(t, "It is sunny in Paris.", response)
+	assert.Equal(t, []string{`{"city": "Paris"}`}, tool.args)
+
+	calls := model.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, 1, calls[0].Iteration)
+	require.Len(t, calls[1].ToolResults, 1)
+	assert.Equal(t, "sunny, 24C", calls[1].ToolResults[0].Content)
+	assert.Equal(t, "call_1", calls[1].ToolResults[0].Call.ID)
+
+	messages, err := mem.GetMessages(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2, "the tool call and its result are stored in memory")
+	assert.Equal(t, interfaces.MessageRoleTool, messages[1].Role)
+}
+
+func TestGenerateWithToolsMaxIterations(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("get_weather", `{"city": "Paris"}`),
+		mock.ToolCall("unknown_tool", `{}`),
+		mock.Text("final"),
+	))
+
+	response, err := model.GenerateWithTools(context.Background(), "Weather?", []interfaces.Tool{&weatherTool{}}, interfaces.WithMaxIterations(2))
+	require.NoError(t, err)
+	assert.Equal(t, "final", response)
+
+	calls := model.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "Error: tool not found: unknown_tool", calls[2].ToolResults[0].Content)
+	assert.Nil(t, calls[2].Tools, "the final call is made without tools")
+}
+
+func TestGenerateWithToolsStream(t *testing.T) {
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("get_weather", `{"city": "Paris"}`).WithThinking("I should check the weather"),
+		mock.Text("It is sunny").WithChunks("It is", " sunny"),
+	))
+
+	events, err := model.GenerateWithToolsStream(context.Background(), "Weather?", []interfaces.Tool{&weatherTool{}})
+	require.NoError(t, err)
+
+	var types []interfaces.StreamEventType
+	var content strings.Builder
+	for _, event := range collect(t, events) {
+		types = append(types, event.Type)
+		if event.Type == interfaces.StreamEventContentDelta {
+			content.WriteString(event.Content)
+		}
+	}
+	assert.Equal(t, []interfaces.StreamEventType{
+		interfaces.StreamEventMessageStart,
+		interfaces.StreamEventThinking,
+		interfaces.StreamEventToolUse,
+		interfaces.StreamEventToolResult,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentComplete,
+		interfaces.StreamEventMessageStop,
+	}, types)
+	assert.Equal(t, "It is sunny", content.String())
+}
+
+func TestGenerateStreamError(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Turn{Chunks: []string{"partial"}, Err: errors.New("connection reset")}))
+
+	events, err := model.GenerateStream(context.Background(), "hello")
+	require.NoError(t, err)
+
+	all := collect(t, events)
+	require.Len(t, all, 3)
+	assert.Equal(t, interfaces.StreamEventContentDelta, all[1].Type)
+	assert.Equal(t, interfaces.StreamEventError, all[2].Type)
+	assert.EqualError(t, all[2].Error, "connection reset")
+}
+
+func TestResponderAndUsage(t *testing.T) {
+	model := mock.New(mock.WithResponder(func(call mock.Call) mock.Turn {
+		return mock.Text(strings.ToUpper(call.Prompt)).WithUsage(llm.TokenUsage{InputTokens: 3, OutputTokens: 1, TotalTokens: 4})
+	}))
+
+	ctx := llm.WithUsageCollection(context.Background())
+	response, err := model.Generate(ctx, "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "HI", response)
+	assert.Equal(t, 4, llm.Summarize(llm.GetUsageFromContext(ctx), nil).Total.TotalTokens)
+}
+
+func TestAgentWithMock(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	tool := &weatherTool{}
+	model := mock.New(mock.WithTurns(
+		mock.ToolCall("get_weather", `{"city": "Paris"}`),
+		mock.Text("It is sunny in Paris."),
+	))
+	a, err := agent.NewAgent(
+		agent.WithLLM(model),
+		agent.WithMemory(memory.NewConversationBuffer()),
+		agent.WithTools(tool),
+		agent.WithSystemPrompt("You are a weather assistant."),
+		agent.WithRequirePlanApproval(false),
+	)
+	require.NoError(t, err)
+
+	response, err := a.Run(ctx, "What is the weather in Paris?")
+	require.NoError(t, err)
+	assert.Equal(t, "It is sunny in Paris.", response)
+	assert.Len(t, tool.args, 1)
+	assert.Equal(t, "You are a weather assistant.", model.LastCall().Options.SystemMessage)
+	assert.Equal(t, 0, model.Remaining())
+}
+
+func TestChat(t *testing.T) {
+	messages := []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: "Be brief"},
+		{Role: interfaces.MessageRoleUser, Content: "Weather in Paris?"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Paris"}`}}},
+		{Role: interfaces.MessageRoleTool, Content: "sunny, 24C", ToolCallID: "call_1"},
+	}
+
+	model := mock.New(mock.WithTurns(mock.Text("Sunny."), mock.Text("Still sunny.")))
+	response, err := llm.Chat(context.Background(), model, messages)
+	require.NoError(t, err)
+	assert.Equal(t, "Sunny.", response)
+
+	call := model.LastCall()
+	assert.Equal(t, "ChatMessages", call.Method)
+	assert.Equal(t, "Weather in Paris?", call.Prompt)
+	assert.Equal(t, messages, call.Messages)
+	assert.Equal(t, "Be brief", call.Options.SystemMessage)
+
+	// LLMs that only implement interfaces.LLM get the conversation as memory
+	plain := struct{ interfaces.LLM }{model}
+	response, err = llm.Chat(context.Background(), plain, messages)
+	require.NoError(t, err)
+	assert.Equal(t, "Still sunny.", response)
+
+	call = model.LastCall()
+	assert.Equal(t, "Generate", call.Method)
+	require.NotNil(t, call.Options.Memory)
+	history, err := call.Options.Memory.GetMessages(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, messages[1:], history)
+}
+
+func TestLLMRouterWithMock(t *testing.T) {
+	model := mock.New(mock.WithTurns(mock.Text(" billing ")))
+	router := orchestration.NewLLMRouter(model)
+
+	agentID, err := router.Route(context.Background(), "Why was I charged twice?", map[string]interface{}{
+		"agents": map[string]string{"billing": "Handles payments", "support": "Handles technical issues"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "billing", agentID)
+	assert.Contains(t, model.LastCall().Prompt, "Why was I charged twice?")
+}
+
+func TestConversationSummaryWithMock(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	model := mock.New(mock.WithFallback(mock.Text("The user talked about the weather.")))
+	mem := memory.NewConversationSummary(model, memory.WithMaxBufferSize(2))
+	for _, content := range []string{"Hi", "Hello!", "Is it sunny?", "Yes."} {
+		require.NoError(t, mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: content}))
+	}
+
+	assert.NotEmpty(t, model.Calls(), "the memory asked the LLM for a summary")
+}
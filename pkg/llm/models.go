@@ -0,0 +1,274 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// ErrUnsupportedFeature is returned when a configuration needs a feature the
+// model does not have
+var ErrUnsupportedFeature = errors.New("model does not support the requested feature")
+
+// ModelInfo describes a model's limits, the features it supports and its
+// price. Name is a model name or a prefix of model names, so an entry for
+// "gpt-4o" also describes "gpt-4o-2024-08-06".
+type ModelInfo struct {
+	Name                     string      `json:"name" yaml:"name"`
+	Provider                 string      `json:"provider,omitempty" yaml:"provider,omitempty"` // e.g. "openai", or "local" for open models
+	ContextWindow            int         `json:"context_window,omitempty" yaml:"context_window,omitempty"`
+	MaxOutputTokens          int         `json:"max_output_tokens,omitempty" yaml:"max_output_tokens,omitempty"`
+	SupportsTools            bool        `json:"supports_tools" yaml:"supports_tools"`
+	SupportsVision           bool        `json:"supports_vision" yaml:"supports_vision"`
+	SupportsReasoning        bool        `json:"supports_reasoning" yaml:"supports_reasoning"`
+	SupportsStructuredOutput bool        `json:"supports_structured_output" yaml:"supports_structured_output"`
+	SupportsStreaming        bool        `json:"supports_streaming" yaml:"supports_streaming"`
+	FixedTemperature         bool        `json:"fixed_temperature,omitempty" yaml:"fixed_temperature,omitempty"` // Only the default temperature of 1 is accepted
+	Price                    *ModelPrice `json:"price,omitempty" yaml:"price,omitempty"`                         // nil if unknown
+}
+
+// ModelRequirements are the features a configuration uses
+type ModelRequirements struct {
+	Tools            bool
+	Vision           bool
+	Reasoning        bool
+	StructuredOutput bool
+	Streaming        bool
+	MaxOutputTokens  int
+}
+
+// Check returns an error wrapping ErrUnsupportedFeature that lists the
+// requirements the model does not meet, or nil if it meets them all
+func (m ModelInfo) Check(req ModelRequirements) error {
+	var missing []string
+	if req.Tools && !m.SupportsTools {
+		missing = append(missing, "tool calling")
+	}
+	if req.Vision && !m.SupportsVision {
+		missing = append(missing, "image input")
+	}
+	if req.Reasoning && !m.SupportsReasoning {
+		missing = append(missing, "reasoning")
+	}
+	if req.StructuredOutput && !m.SupportsStructuredOutput {
+		missing = append(missing, "structured output")
+	}
+	if req.Streaming && !m.SupportsStreaming {
+		missing = append(missing, "streaming")
+	}
+	if req.MaxOutputTokens > 0 && m.MaxOutputTokens > 0 && req.MaxOutputTokens > m.MaxOutputTokens {
+		missing = append(missing, fmt.Sprintf("%d output tokens (maximum %d)", req.MaxOutputTokens, m.MaxOutputTokens))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s does not support %s", ErrUnsupportedFeature, m.Name, strings.Join(missing, ", "))
+}
+
+// ModelRegistry maps model names to model descriptions. Like PriceTable,
+// lookups match the exact model name first and then the longest registered
+// prefix. It is safe for concurrent use.
+type ModelRegistry struct {
+	mu     sync.RWMutex
+	models map[string]ModelInfo
+}
+
+// NewModelRegistry creates a registry holding the given models
+func NewModelRegistry(models ...ModelInfo) *ModelRegistry {
+	registry := &ModelRegistry{models: make(map[string]ModelInfo, len(models))}
+	for _, model := range models {
+		registry.Register(model)
+	}
+	return registry
+}
+
+// Register adds or replaces the description of a model or model prefix, for
+// example to describe a fine-tuned or self-hosted model
+func (r *ModelRegistry) Register(model ModelInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.models == nil {
+		r.models = make(map[string]ModelInfo)
+	}
+	model.Name = strings.ToLower(model.Name)
+	r.models[model.Name] = model
+}
+
+// Lookup returns the description of a model. Besides the name as given, it
+// tries the name without a path such as "models/" or "meta-llama/", without
+// the "ft:" prefix of OpenAI fine-tuned models and without a dotted provider
+// prefix such as "us.anthropic.", so a registered override wins over the
+// entry of the base model.
+func (r *ModelRegistry) Lookup(model string) (ModelInfo, bool) {
+	name := strings.ToLower(model)
+	candidates := []string{name}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+		candidates = append(candidates, name)
+	}
+	if trimmed, ok := strings.CutPrefix(name, "ft:"); ok {
+		name = trimmed
+		candidates = append(candidates, name)
+	}
+	// Bedrock and Vertex names such as "anthropic.claude-3-5-sonnet"
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		candidates = append(candidates, name[i+1:])
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, candidate := range candidates {
+		if info, ok := r.lookup(candidate); ok {
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// lookup matches a name exactly or by its longest registered prefix
+func (r *ModelRegistry) lookup(name string) (ModelInfo, bool) {
+	if info, ok := r.models[name]; ok {
+		return info, true
+	}
+	best := ""
+	for prefix := range r.models {
+		if len(prefix) > len(best) && strings.HasPrefix(name, prefix) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelInfo{}, false
+	}
+	return r.models[best], true
+}
+
+// Models returns the registered models sorted by provider and name
+func (r *ModelRegistry) Models() []ModelInfo {
+	r.mu.RLock()
+	models := make([]ModelInfo, 0, len(r.models))
+	for _, model := range r.models {
+		models = append(models, model)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].Provider != models[j].Provider {
+			return models[i].Provider < models[j].Provider
+		}
+		return models[i].Name < models[j].Name
+	})
+	return models
+}
+
+// Prices returns a price table holding the prices of the registered models
+func (r *ModelRegistry) Prices() *PriceTable {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	prices := make(map[string]ModelPrice)
+	for name, model := range r.models {
+		if model.Price != nil {
+			prices[name] = *model.Price
+		}
+	}
+	return NewPriceTable(prices)
+}
+
+// defaultModels is the registry used by the package-level functions and by
+// the provider clients
+var defaultModels = NewModelRegistry(builtinModels...)
+
+// DefaultModelRegistry returns the registry of well-known models, including
+// any registered with RegisterModel
+func DefaultModelRegistry() *ModelRegistry {
+	return defaultModels
+}
+
+// RegisterModel adds or replaces a model in the default registry
+func RegisterModel(model ModelInfo) {
+	defaultModels.Register(model)
+}
+
+// LookupModel returns the description of a model from the default registry
+func LookupModel(model string) (ModelInfo, bool) {
+	return defaultModels.Lookup(model)
+}
+
+// ModelName returns the model used by an LLM client, or "" if the client does
+// not report it
+func ModelName(model interfaces.LLM) string {
+	if m, ok := model.(interface{ GetModel() string }); ok {
+		return m.GetModel()
+	}
+	return ""
+}
+
+func price(input, output, cachedInput, cacheWrite float64) *ModelPrice {
+	return &ModelPrice{
+		InputPerMillion:       input,
+		OutputPerMillion:      output,
+		CachedInputPerMillion: cachedInput,
+		CacheWritePerMillion:  cacheWrite,
+	}
+}
+
+// builtinModels describes well-known models. Prices are list prices in USD
+// per million tokens; register overrides for negotiated prices.
+var builtinModels = []ModelInfo{
+	// OpenAI
+	{Name: "gpt-5", Provider: "openai", ContextWindow: 400000, MaxOutputTokens: 128000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(1.25, 10, 0.125, 0)},
+	{Name: "gpt-5-mini", Provider: "openai", ContextWindow: 400000, MaxOutputTokens: 128000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(0.25, 2, 0.025, 0)},
+	{Name: "gpt-5-nano", Provider: "openai", ContextWindow: 400000, MaxOutputTokens: 128000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(0.05, 0.4, 0.005, 0)},
+	{Name: "gpt-4.1", Provider: "openai", ContextWindow: 1047576, MaxOutputTokens: 32768, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(2, 8, 0.5, 0)},
+	{Name: "gpt-4.1-mini", Provider: "openai", ContextWindow: 1047576, MaxOutputTokens: 32768, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.4, 1.6, 0.1, 0)},
+	{Name: "gpt-4.1-nano", Provider: "openai", ContextWindow: 1047576, MaxOutputTokens: 32768, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.1, 0.4, 0.025, 0)},
+	{Name: "gpt-4o", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(2.5, 10, 1.25, 0)},
+	{Name: "gpt-4o-mini", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.15, 0.6, 0.075, 0)},
+	{Name: "gpt-4-turbo", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsStreaming: true, Price: price(10, 30, 0, 0)},
+	{Name: "gpt-4", Provider: "openai", ContextWindow: 8192, MaxOutputTokens: 8192, SupportsTools: true, SupportsStreaming: true, Price: price(30, 60, 0, 0)},
+	{Name: "gpt-3.5-turbo", Provider: "openai", ContextWindow: 16385, MaxOutputTokens: 4096, SupportsTools: true, SupportsStreaming: true, Price: price(0.5, 1.5, 0, 0)},
+	{Name: "o1", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(15, 60, 7.5, 0)},
+	{Name: "o1-mini", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 65536, SupportsReasoning: true, SupportsStreaming: true, FixedTemperature: true, Price: price(1.1, 4.4, 0.55, 0)},
+	{Name: "o3", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(2, 8, 0.5, 0)},
+	{Name: "o3-mini", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, SupportsTools: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(1.1, 4.4, 0.55, 0)},
+	{Name: "o4-mini", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, FixedTemperature: true, Price: price(1.1, 4.4, 0.275, 0)},
+
+	// Anthropic. Structured output is emulated by the client with a JSON instruction.
+	{Name: "claude-opus-4", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 32000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(15, 75, 1.5, 18.75)},
+	{Name: "claude-sonnet-4", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(3, 15, 0.3, 3.75)},
+	{Name: "claude-haiku-4-5", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(1, 5, 0.1, 1.25)},
+	{Name: "claude-3-7-sonnet", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(3, 15, 0.3, 3.75)},
+	{Name: "claude-3-5-sonnet", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(3, 15, 0.3, 3.75)},
+	{Name: "claude-3-5-haiku", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.8, 4, 0.08, 1)},
+	{Name: "claude-3-opus", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(15, 75, 1.5, 18.75)},
+	{Name: "claude-3-haiku", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.25, 1.25, 0.03, 0.3)},
+	{Name: "claude", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+
+	// Google Gemini
+	{Name: "gemini-2.5-pro", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 65536, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(1.25, 10, 0.31, 0)},
+	{Name: "gemini-2.5-flash", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 65536, SupportsTools: true, SupportsVision: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.3, 2.5, 0.075, 0)},
+	{Name: "gemini-2.5-flash-lite", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 65536, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.1, 0.4, 0.025, 0)},
+	{Name: "gemini-2.0-flash", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.1, 0.4, 0.025, 0)},
+	{Name: "gemini-2.0-flash-lite", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.075, 0.3, 0, 0)},
+	{Name: "gemini-1.5-pro", Provider: "gemini", ContextWindow: 2097152, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(1.25, 5, 0.3125, 0)},
+	{Name: "gemini-1.5-flash", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true, Price: price(0.075, 0.3, 0.01875, 0)},
+	{Name: "gemini", Provider: "gemini", ContextWindow: 1048576, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+
+	// Open models served by Ollama or vLLM. Structured output uses the
+	// server's JSON mode.
+	{Name: "llama3.1", Provider: "local", ContextWindow: 131072, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "llama3.2", Provider: "local", ContextWindow: 131072, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "llama3.3", Provider: "local", ContextWindow: 131072, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "llama-3.1", Provider: "local", ContextWindow: 131072, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "llama-3.2", Provider: "local", ContextWindow: 131072, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "llama-3.3", Provider: "local", ContextWindow: 131072, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "mistral", Provider: "local", ContextWindow: 32768, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "mixtral", Provider: "local", ContextWindow: 32768, MaxOutputTokens: 4096, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "qwen2.5", Provider: "local", ContextWindow: 32768, MaxOutputTokens: 8192, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "qwen3", Provider: "local", ContextWindow: 40960, MaxOutputTokens: 8192, SupportsTools: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "deepseek", Provider: "local", ContextWindow: 65536, MaxOutputTokens: 8192, SupportsTools: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+	{Name: "deepseek-r1", Provider: "local", ContextWindow: 65536, MaxOutputTokens: 8192, SupportsTools: true, SupportsReasoning: true, SupportsStructuredOutput: true, SupportsStreaming: true},
+}
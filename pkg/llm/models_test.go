@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected string
+	}{
+		{"gpt-4o-mini-2024-07-18", "gpt-4o-mini"},
+		{"gpt-4o-2024-08-06", "gpt-4o"},
+		{"GPT-4.1-nano", "gpt-4.1-nano"},
+		{"o1-preview", "o1"},
+		{"ft:gpt-4o-mini-2024-07-18:acme::abc123", "gpt-4o-mini"},
+		{"claude-sonnet-4-5-20250929", "claude-sonnet-4"},
+		{"claude-opus-4@20250514", "claude-opus-4"},
+		{"us.anthropic.claude-3-7-sonnet-20250219-v1:0", "claude-3-7-sonnet"},
+		{"models/gemini-2.5-flash-lite", "gemini-2.5-flash-lite"},
+		{"meta-llama/Llama-3.1-8B-Instruct", "llama-3.1"},
+		{"qwen3:8b", "qwen3"},
+	}
+	for _, tt := range tests {
+		info, ok := LookupModel(tt.model)
+		require.True(t, ok, tt.model)
+		assert.Equal(t, tt.expected, info.Name, tt.model)
+	}
+
+	_, ok := LookupModel("my-custom-model")
+	assert.False(t, ok)
+}
+
+func TestModelRegistryOverride(t *testing.T) {
+	registry := NewModelRegistry(builtinModels...)
+	registry.Register(ModelInfo{Name: "ft:gpt-4o-mini-2024-07-18:acme", Provider: "openai", ContextWindow: 128000, SupportsStreaming: true})
+
+	info, ok := registry.Lookup("ft:gpt-4o-mini-2024-07-18:acme::abc123")
+	require.True(t, ok)
+	assert.Equal(t, "ft:gpt-4o-mini-2024-07-18:acme", info.Name)
+	assert.False(t, info.SupportsTools)
+
+	// Other fine-tuned models inherit the base model
+	info, ok = registry.Lookup("ft:gpt-4o-mini-2024-07-18:other::def456")
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o-mini", info.Name)
+
+	info, ok = LookupModel("ft:gpt-4o-mini-2024-07-18:acme::abc123")
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o-mini", info.Name, "the default registry is unchanged")
+}
+
+func TestModelInfoCheck(t *testing.T) {
+	info, ok := LookupModel("gpt-3.5-turbo")
+	require.True(t, ok)
+
+	assert.NoError(t, info.Check(ModelRequirements{Tools: true, Streaming: true}))
+
+	err := info.Check(ModelRequirements{Tools: true, StructuredOutput: true, Reasoning: true, MaxOutputTokens: 8192})
+	require.ErrorIs(t, err, ErrUnsupportedFeature)
+	assert.Contains(t, err.Error(), "reasoning, structured output, 8192 output tokens (maximum 4096)")
+	assert.NotContains(t, err.Error(), "tool calling")
+}
+
+func TestModelRegistryPrices(t *testing.T) {
+	prices := DefaultModelRegistry().Prices()
+
+	price, ok := prices.Lookup("gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, 0.15, price.InputPerMillion)
+
+	_, ok = prices.Lookup("llama3.1:8b")
+	assert.False(t, ok, "local models have no price")
+}
+
+func TestResolveToolCallingMode(t *testing.T) {
+	registry := defaultModels
+	defaultModels = NewModelRegistry(ModelInfo{Name: "tiny-llm", SupportsStreaming: true})
+	defer func() { defaultModels = registry }()
+
+	assert.Equal(t, ToolCallingReAct, ResolveToolCallingMode(ToolCallingAuto, "tiny-llm:1b"))
+	assert.Equal(t, ToolCallingNative, ResolveToolCallingMode(ToolCallingNative, "tiny-llm:1b"))
+	assert.Equal(t, ToolCallingAuto, ResolveToolCallingMode(ToolCallingAuto, "unknown"))
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
@@ -24,6 +23,7 @@ type OllamaClient struct {
 	Model         string
 	logger        logging.Logger
 	retryExecutor *retry.Executor
+	toolMode      llm.ToolCallingMode
 }
 
 // Option represents an option for configuring the Ollama client
@@ -64,6 +64,15 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithToolCallingMode sets how the model calls tools. The default,
+// llm.ToolCallingAuto, uses Ollama's native tool calling and falls back to
+// ReAct prompting for models that do not support tools.
+func WithToolCallingMode(mode llm.ToolCallingMode) Option {
+	return func(c *OllamaClient) {
+		c.toolMode = mode
+	}
+}
+
 // NewClient creates a new Ollama client
 func NewClient(options ...Option) *OllamaClient {
 	// Create client with default options
@@ -72,6 +81,7 @@ func NewClient(options ...Option) *OllamaClient {
 		HTTPClient: &http.Client{Timeout: 60 * time.Second},
 		Model:      "qwen3:0.6b",
 		logger:     logging.New(),
+		toolMode:   llm.ToolCallingAuto,
 	}
 
 	// Apply options
@@ -124,6 +134,7 @@ type GenerateResponse struct {
 type ChatRequest struct {
 	Model     string        `json:"model"`
 	Messages  []ChatMessage `json:"messages"`
+	Tools     []Tool        `json:"tools,omitempty"`
 	Stream    bool          `json:"stream"`
 	Options   *Options      `json:"options,omitempty"`
 	Format    string        `json:"format,omitempty"`
@@ -131,8 +142,34 @@ type ChatRequest struct {
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"` // Reasoning returned separately by thinking models
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"` // Name of the tool that produced a tool message
+	Images    []string   `json:"images,omitempty"`    // Base64-encoded images for vision models
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
 }
 
 type ChatResponse struct {
@@ -140,6 +177,7 @@ type ChatResponse struct {
 	CreatedAt          string      `json:"created_at"`
 	Message            ChatMessage `json:"message"`
 	Done               bool        `json:"done"`
+	DoneReason         string      `json:"done_reason,omitempty"`
 	TotalDuration      int64       `json:"total_duration,omitempty"`
 	LoadDuration       int64       `json:"load_duration,omitempty"`
 	PromptEvalCount    int         `json:"prompt_eval_count,omitempty"`
@@ -166,15 +204,12 @@ func (c *OllamaClient) Generate(ctx context.Context, prompt string, options ...i
 
 	// Create request
 	req := GenerateRequest{
-		Model:  c.Model,
-		Prompt: finalPrompt,
-		Stream: false,
-		Options: &Options{
-			Temperature: params.LLMConfig.Temperature,
-			TopP:        params.LLMConfig.TopP,
-			Stop:        params.LLMConfig.StopSequences,
-		},
-		System: params.SystemMessage,
+		Model:   c.Model,
+		Prompt:  finalPrompt,
+		Stream:  false,
+		Options: requestOptions(params.LLMConfig),
+		System:  params.SystemMessage,
+		Images:  c.promptImages(ctx, params),
 	}
 
 	// Handle structured output if provided
@@ -211,80 +246,78 @@ Ensure your response is a valid JSON object that strictly follows the schema abo
 	if err := json.Unmarshal(resp, &generateResp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	c.recordUsage(ctx, generateResp.Model, generateResp.PromptEvalCount, generateResp.EvalCount, 0)
 
 	return generateResp.Response, nil
 }
 
-// GenerateWithTools generates text and can use tools
-func (c *OllamaClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
-	// For now, Ollama doesn't support tool calling in the same way as OpenAI/Anthropic
-	// We'll implement a basic version that includes tool descriptions in the prompt
-	if len(tools) == 0 {
-		return c.Generate(ctx, prompt, options...)
-	}
-
-	// Build tool descriptions
-	var toolDescriptions []string
-	for _, tool := range tools {
-		toolDescriptions = append(toolDescriptions, fmt.Sprintf("- %s: %s", tool.Name(), tool.Description()))
+// Chat performs a chat completion with messages.
+// It sends the messages with ChatMessages; DefaultGenerateParams are used if
+// params is nil.
+func (c *OllamaClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
+	if params == nil {
+		params = llm.DefaultGenerateParams()
 	}
-
-	// Create enhanced prompt with tool information
-	enhancedPrompt := fmt.Sprintf(`%s
-
-Available tools:
-%s
-
-Please respond to the user's request. If you need to use any tools, describe what you would do.`, prompt, strings.Join(toolDescriptions, "\n"))
-
-	return c.Generate(ctx, enhancedPrompt, options...)
+	return c.ChatMessages(ctx, llm.ConvertMessages(messages), params.Options()...)
 }
 
-// Chat performs a chat completion with messages
-func (c *OllamaClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
-	// Convert messages to Ollama format
-	var chatMessages []ChatMessage
-	for _, msg := range messages {
-		chatMessages = append(chatMessages, ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+// ChatMessages generates the next assistant message of a conversation.
+// Assistant tool calls and tool results in the messages are sent as they are.
+func (c *OllamaClient) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to chat: %w", err)
 	}
+	params := newGenerateOptions(options)
 
-	// Create request
-	req := ChatRequest{
+	chatResp, err := c.chat(ctx, ChatRequest{
 		Model:    c.Model,
-		Messages: chatMessages,
-		Stream:   false,
-		Options: &Options{
-			Temperature: params.Temperature,
-			TopP:        params.TopP,
-			Stop:        params.StopSequences,
-		},
-	}
-
-	// Make request
-	resp, err := c.makeRequest(ctx, "/api/chat", req)
+		Messages: c.buildChatMessages(ctx, prompt, params),
+		Options:  requestOptions(params.LLMConfig),
+	}, 0)
 	if err != nil {
 		return "", fmt.Errorf("failed to chat: %w", err)
 	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(resp, &chatResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal chat response: %w", err)
-	}
-
 	return chatResp.Message.Content, nil
 }
 
+// recordUsage reports the token usage of a response to the usage collector in the context.
+// Ollama reports prompt tokens as prompt_eval_count and generated tokens as eval_count.
+func (c *OllamaClient) recordUsage(ctx context.Context, model string, promptTokens, outputTokens int, iteration int) {
+	if model == "" {
+		model = c.Model
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{
+		Provider:  c.Name(),
+		Model:     model,
+		Iteration: iteration,
+		Usage: llm.TokenUsage{
+			InputTokens:  promptTokens,
+			OutputTokens: outputTokens,
+			TotalTokens:  promptTokens + outputTokens,
+		},
+	})
+}
+
 // Name returns the name of the LLM provider
 func (c *OllamaClient) Name() string {
 	return "ollama"
 }
 
-// SupportsStreaming returns false as streaming is not yet implemented for Ollama
+// GetModel returns the model name being used
+func (c *OllamaClient) GetModel() string {
+	return c.Model
+}
+
+// ToolCallingMode returns how the model calls tools. In auto mode, models
+// that llm.LookupModel knows to lack native tool calling use ReAct directly.
+func (c *OllamaClient) ToolCallingMode() llm.ToolCallingMode {
+	return llm.ResolveToolCallingMode(c.toolMode, c.Model)
+}
+
+// SupportsStreaming returns true as Ollama streams chat responses
 func (c *OllamaClient) SupportsStreaming() bool {
-	return false
+	return true
 }
 
 // makeRequest makes an HTTP request to the Ollama API
@@ -295,46 +328,56 @@ func (c *OllamaClient) makeRequest(ctx context.Context, endpoint string, payload
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+	// Execute request with retry if configured. Only rate limits and
+	// transient failures are retried.
+	return c.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
 
-	// Execute request with retry if configured
-	var resp *http.Response
-	if c.retryExecutor != nil {
-		err = c.retryExecutor.Execute(ctx, func() error {
-			var execErr error
-			resp, execErr = c.HTTPClient.Do(req)
-			return execErr
-		})
-	} else {
-		resp, err = c.HTTPClient.Do(req)
-	}
+// doRequest sends the request built by newRequest, with retry if configured,
+// and returns the response body. Failed requests return an
+// *interfaces.ProviderError.
+func (c *OllamaClient) doRequest(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	var body []byte
+	operation := func() error {
+		req, err := newRequest()
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() {
-		if resp != nil {
-			_ = resp.Body.Close()
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to execute request: %w", llm.ClassifyError(c.Name(), err))
 		}
-	}()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("API request failed: %w", llm.NewHTTPError(c.Name(), resp.StatusCode, resp.Header, body))
+		}
+		return nil
 	}
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	var err error
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, operation)
+	} else {
+		err = operation()
+	}
+	if err != nil {
+		return nil, err
 	}
-
 	return body, nil
 }
 
@@ -423,3 +466,45 @@ func WithResponseFormat(format interfaces.ResponseFormat) interfaces.GenerateOpt
 func (c *OllamaClient) buildPromptWithMemory(ctx context.Context, prompt string, params *interfaces.GenerateOptions) string {
 	return memory.BuildInlineHistoryPrompt(ctx, prompt, params.Memory, c.logger)
 }
+
+// promptImages returns the images sent with a prompt: those given with
+// interfaces.WithContentParts, or with memory those of the user messages the
+// prompt's history is built from
+func (c *OllamaClient) promptImages(ctx context.Context, params *interfaces.GenerateOptions) []string {
+	if params.Memory == nil {
+		return c.convertImages(ctx, params.ContentParts)
+	}
+
+	memoryMessages, err := params.Memory.GetMessages(ctx)
+	if err != nil {
+		return nil
+	}
+	var images []string
+	for _, msg := range memoryMessages {
+		if msg.Role == interfaces.MessageRoleUser {
+			images = append(images, c.convertImages(ctx, msg.Parts)...)
+		}
+	}
+	return images
+}
+
+// convertImages returns the base64-encoded inline images of the content
+// parts. Ollama only takes inline images, so image URLs, files and audio are
+// skipped.
+func (c *OllamaClient) convertImages(ctx context.Context, parts []interfaces.ContentPart) []string {
+	var images []string
+	for _, part := range parts {
+		switch {
+		case part.Type == interfaces.ContentPartText:
+		case part.Type == interfaces.ContentPartImage && len(part.Data) > 0:
+			images = append(images, part.Base64())
+		default:
+			c.logger.Warn(ctx, "Skipping content part not supported by Ollama", map[string]interface{}{
+				"type":      part.Type,
+				"mime_type": part.MediaType(),
+				"url":       part.URL,
+			})
+		}
+	}
+	return images
+}
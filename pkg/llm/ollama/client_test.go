@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
+	"github.com/andmang/agent-sdk-go/pkg/retry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -118,6 +120,48 @@ func TestGenerateWithSystemMessage(t *testing.T) {
 	assert.Equal(t, "System message received", response)
 }
 
+func TestGenerateWithImages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		// The image URL cannot be sent to Ollama and is skipped
+		assert.Equal(t, []string{"cG5n"}, req.Images)
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(GenerateResponse{Response: "A cat", Done: true}))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithModel("llava"), WithBaseURL(server.URL))
+	response, err := client.Generate(context.Background(), "What is in the picture?",
+		interfaces.WithContentParts(
+			interfaces.ImagePart([]byte("png"), "image/png"),
+			interfaces.ImageURLPart("https://example.com/cat.jpg"),
+		),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "A cat", response)
+}
+
+func TestBuildChatMessagesWithImages(t *testing.T) {
+	client := NewClient()
+	memory := &mockMemory{messages: []interfaces.Message{{
+		Role:    interfaces.MessageRoleUser,
+		Content: "Read the scan",
+		Parts: []interfaces.ContentPart{
+			interfaces.TextPart("Read the scan"),
+			interfaces.ImagePart([]byte("jpg"), "image/jpeg"),
+		},
+	}}}
+
+	messages := client.buildChatMessages(context.Background(), "Read the scan", &interfaces.GenerateOptions{Memory: memory})
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Read the scan", messages[0].Content)
+	assert.Equal(t, []string{"anBn"}, messages[0].Images)
+}
+
 func TestChat(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "POST", r.Method)
@@ -128,9 +172,17 @@ func TestChat(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "test-model", req.Model)
-		assert.Len(t, req.Messages, 2)
+		require.Len(t, req.Messages, 4)
 		assert.Equal(t, "system", req.Messages[0].Role)
 		assert.Equal(t, "user", req.Messages[1].Role)
+		assert.Equal(t, "assistant", req.Messages[2].Role)
+		require.Len(t, req.Messages[2].ToolCalls, 1)
+		assert.Equal(t, "get_time", req.Messages[2].ToolCalls[0].Function.Name)
+		assert.Equal(t, "tool", req.Messages[3].Role)
+		assert.Equal(t, "get_time", req.Messages[3].ToolName)
+		require.NotNil(t, req.Options)
+		assert.Equal(t, 40, req.Options.TopK)
+		assert.Equal(t, 1.1, req.Options.RepeatPenalty)
 
 		response := ChatResponse{
 			Model: "test-model",
@@ -152,39 +204,42 @@ func TestChat(t *testing.T) {
 		WithBaseURL(server.URL),
 	)
 
-	messages := []llm.Message{
-		{
-			Role:    "system",
-			Content: "You are a helpful assistant",
-		},
-		{
-			Role:    "user",
-			Content: "Hello",
-		},
+	messages := []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: "You are a helpful assistant"},
+		{Role: interfaces.MessageRoleUser, Content: "Hello, what time is it?"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "call_1", Name: "get_time", Arguments: "{}"}}},
+		{Role: interfaces.MessageRoleTool, Content: "12:00", ToolCallID: "call_1", Metadata: map[string]interface{}{"tool_name": "get_time"}},
 	}
 
-	response, err := client.Chat(context.Background(), messages, &llm.GenerateParams{
-		Temperature: 0.7,
-	})
+	response, err := client.ChatMessages(context.Background(), messages,
+		interfaces.WithTopK(40),
+		interfaces.WithRepeatPenalty(1.1),
+	)
 
 	require.NoError(t, err)
 	assert.Equal(t, "Hello! How can I help you?", response)
 }
 
 func TestGenerateWithTools(t *testing.T) {
+	var requests []ChatRequest
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req GenerateRequest
+		assert.Equal(t, "/api/chat", r.URL.Path)
+
+		var req ChatRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		require.NoError(t, err)
-
-		// Check that the prompt includes tool descriptions
-		assert.Contains(t, req.Prompt, "Available tools:")
-		assert.Contains(t, req.Prompt, "- test-tool: A test tool")
-
-		response := GenerateResponse{
-			Model:    "test-model",
-			Response: "I can help you with that using the available tools",
-			Done:     true,
+		requests = append(requests, req)
+
+		response := ChatResponse{Model: "test-model", Done: true}
+		if len(requests) == 1 {
+			response.Message = ChatMessage{
+				Role: "assistant",
+				ToolCalls: []ToolCall{{
+					Function: ToolCallFunction{Name: "test-tool", Arguments: map[string]interface{}{"input": "hello"}},
+				}},
+			}
+		} else {
+			response.Message = ChatMessage{Role: "assistant", Content: "The tool said: mock result"}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -205,15 +260,128 @@ func TestGenerateWithTools(t *testing.T) {
 	}
 
 	tools := []interfaces.Tool{mockTool}
+	memory := &mockMemory{}
 
 	response, err := client.GenerateWithTools(
 		context.Background(),
 		"Help me with something",
 		tools,
+		interfaces.WithMemory(memory),
 	)
 
 	require.NoError(t, err)
-	assert.Equal(t, "I can help you with that using the available tools", response)
+	assert.Equal(t, "The tool said: mock result", response)
+
+	// The tools are sent natively
+	require.Len(t, requests, 2)
+	require.Len(t, requests[0].Tools, 1)
+	assert.Equal(t, "test-tool", requests[0].Tools[0].Function.Name)
+	assert.Equal(t, "A test tool", requests[0].Tools[0].Function.Description)
+	assert.Equal(t, []interface{}{"input"}, requests[0].Tools[0].Function.Parameters["required"])
+
+	// The prompt is sent even though memory starts empty
+	assert.Equal(t, "Help me with something", requests[0].Messages[0].Content)
+
+	// The second request carries the tool call and its result
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, "tool", last.Role)
+	assert.Equal(t, "test-tool", last.ToolName)
+	assert.Equal(t, "mock result", last.Content)
+
+	// The tool call and result are stored in memory
+	require.Len(t, memory.messages, 2)
+	assert.Equal(t, interfaces.MessageRoleAssistant, memory.messages[0].Role)
+	assert.Equal(t, `{"input":"hello"}`, memory.messages[0].ToolCalls[0].Arguments)
+	assert.Equal(t, interfaces.MessageRoleTool, memory.messages[1].Role)
+	assert.Equal(t, "mock result", memory.messages[1].Content)
+}
+
+func TestGenerateWithToolsMaxIterations(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		require.NoError(t, err)
+		requests = append(requests, req)
+
+		// Keep calling the tool while tools are offered
+		response := ChatResponse{Model: "test-model", Done: true}
+		if len(req.Tools) > 0 {
+			response.Message = ChatMessage{
+				Role:      "assistant",
+				ToolCalls: []ToolCall{{Function: ToolCallFunction{Name: "test-tool", Arguments: map[string]interface{}{}}}},
+			}
+		} else {
+			response.Message = ChatMessage{Role: "assistant", Content: "final answer"}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(response)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithModel("test-model"), WithBaseURL(server.URL))
+	tools := []interfaces.Tool{&mockTool{name: "test-tool", description: "A test tool"}}
+
+	response, err := client.GenerateWithTools(context.Background(), "loop", tools, interfaces.WithMaxIterations(3))
+	require.NoError(t, err)
+	assert.Equal(t, "final answer", response)
+
+	// Three iterations with tools and a final call without them
+	require.Len(t, requests, 4)
+	assert.Empty(t, requests[3].Tools)
+}
+
+func TestGenerateWithToolsReActFallback(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		require.NoError(t, err)
+		requests = append(requests, req)
+
+		if len(req.Tools) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"registry.ollama.ai/library/tiny:latest does not support tools"}`))
+			return
+		}
+
+		content := "Thought: I need the tool\nAction: test-tool\nAction Input: {\"input\": \"hi\"}"
+		if len(requests) > 2 {
+			content = "Thought: I know the answer\nFinal Answer: it returned mock result"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(ChatResponse{
+			Model:   "tiny",
+			Message: ChatMessage{Role: "assistant", Content: content},
+			Done:    true,
+		})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithModel("tiny"), WithBaseURL(server.URL))
+	tools := []interfaces.Tool{&mockTool{name: "test-tool", description: "A test tool"}}
+
+	response, err := client.GenerateWithTools(context.Background(), "Help me", tools)
+	require.NoError(t, err)
+	assert.Equal(t, "it returned mock result", response)
+
+	// One rejected native request followed by two ReAct turns
+	require.Len(t, requests, 3)
+	assert.Contains(t, requests[1].Messages[0].Content, "test-tool: A test tool")
+	assert.Contains(t, requests[1].Options.Stop, "\nObservation:")
+	last := requests[2].Messages[len(requests[2].Messages)-1]
+	assert.Equal(t, "Observation: mock result", last.Content)
+
+	// Native mode reports the error instead of falling back
+	requests = nil
+	client = NewClient(WithModel("tiny"), WithBaseURL(server.URL), WithToolCallingMode(llm.ToolCallingNative))
+	_, err = client.GenerateWithTools(context.Background(), "Help me", tools)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support tools")
+	assert.Len(t, requests, 1)
 }
 
 func TestListModels(t *testing.T) {
@@ -298,7 +466,50 @@ func TestMakeRequestError(t *testing.T) {
 	_, err := client.Generate(context.Background(), "test prompt")
 
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "API request failed with status 500")
+	assert.Contains(t, err.Error(), "status 500")
+	assert.ErrorIs(t, err, interfaces.ErrTransient)
+}
+
+func TestMakeRequestRetriesTransientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"server busy"}`))
+		default:
+			_, _ = w.Write([]byte(`{"model":"llama2","response":"ok","done":true}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithRetry(retry.WithMaxAttempts(3), retry.WithInitialInterval(time.Millisecond)))
+	response, err := client.Generate(context.Background(), "test prompt")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response)
+	assert.Equal(t, 2, requests)
+}
+
+func TestMakeRequestDoesNotRetryAuthErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithRetry(retry.WithMaxAttempts(3), retry.WithInitialInterval(time.Millisecond)))
+	_, err := client.Generate(context.Background(), "test prompt")
+
+	var providerErr *interfaces.ProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, interfaces.ErrAuthentication, providerErr.Kind)
+	assert.Equal(t, http.StatusUnauthorized, providerErr.StatusCode)
+	assert.Equal(t, "unauthorized", providerErr.Message)
+	assert.Equal(t, 1, requests)
 }
 
 func TestName(t *testing.T) {
@@ -378,3 +589,38 @@ func TestWithSystemMessage(t *testing.T) {
 
 	assert.Equal(t, "You are helpful", options.SystemMessage)
 }
+
+// mockMemory records the messages added to it
+type mockMemory struct {
+	messages []interfaces.Message
+}
+
+func (m *mockMemory) AddMessage(ctx context.Context, message interfaces.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *mockMemory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	return m.messages, nil
+}
+
+func (m *mockMemory) Clear(ctx context.Context) error {
+	m.messages = nil
+	return nil
+}
+
+func TestToolsForChoice(t *testing.T) {
+	tools := []Tool{
+		{Type: "function", Function: ToolFunction{Name: "get_weather"}},
+		{Type: "function", Function: ToolFunction{Name: "get_time"}},
+	}
+
+	assert.Equal(t, tools, toolsForChoice(tools, nil))
+	assert.Equal(t, tools, toolsForChoice(tools, &interfaces.ToolChoiceRequired))
+	assert.Empty(t, toolsForChoice(tools, &interfaces.ToolChoiceNone))
+
+	choice := interfaces.ToolChoiceFunction("get_time")
+	narrowed := toolsForChoice(tools, &choice)
+	require.Len(t, narrowed, 1)
+	assert.Equal(t, "get_time", narrowed[0].Function.Name)
+}
@@ -0,0 +1,380 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// chatStreamChunk is one line of Ollama's newline-delimited JSON stream
+type chatStreamChunk struct {
+	ChatResponse
+	Error string `json:"error,omitempty"`
+}
+
+// GenerateStream implements interfaces.StreamingLLM.GenerateStream
+func (c *OllamaClient) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	params := newGenerateOptions(options)
+	eventChan := make(chan interfaces.StreamEvent, streamBufferSize(params))
+
+	go func() {
+		defer close(eventChan)
+
+		if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStart,
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"model": c.Model},
+		}) {
+			return
+		}
+
+		req := ChatRequest{
+			Model:    c.Model,
+			Messages: c.buildChatMessages(ctx, prompt, params),
+			Stream:   true,
+			Options:  requestOptions(params.LLMConfig),
+		}
+		if params.ResponseFormat != nil && params.ResponseFormat.Type == interfaces.ResponseFormatJSON {
+			req.Format = "json"
+		}
+
+		if _, err := c.streamChat(ctx, req, 0, eventChan); err != nil {
+			sendError(ctx, eventChan, err)
+			return
+		}
+
+		sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStop,
+			Timestamp: time.Now(),
+		})
+	}()
+
+	return eventChan, nil
+}
+
+// GenerateWithToolsStream implements interfaces.StreamingLLM.GenerateWithToolsStream.
+// Tool calls are executed by the client in a loop, emitting tool_use and
+// tool_result events, until the model answers or MaxIterations is reached.
+// When the model does not support native tools, the ReAct fallback runs
+// without streaming and its final answer is emitted as a single delta.
+func (c *OllamaClient) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	if len(tools) == 0 {
+		return c.GenerateStream(ctx, prompt, options...)
+	}
+
+	params := newGenerateOptions(options)
+	eventChan := make(chan interfaces.StreamEvent, streamBufferSize(params))
+
+	go func() {
+		defer close(eventChan)
+
+		if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStart,
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"model": c.Model},
+		}) {
+			return
+		}
+
+		var err error
+		if c.ToolCallingMode() == llm.ToolCallingReAct {
+			err = c.streamReAct(ctx, prompt, tools, params, eventChan)
+		} else {
+			err = c.streamWithNativeTools(ctx, prompt, tools, params, eventChan)
+			if err != nil && c.toolMode != llm.ToolCallingNative && isToolsUnsupportedError(err) {
+				c.logger.Warn(ctx, "Model does not support native tool calling, falling back to ReAct", map[string]interface{}{
+					"model": c.Model,
+				})
+				err = c.streamReAct(ctx, prompt, tools, params, eventChan)
+			}
+		}
+		if err != nil {
+			sendError(ctx, eventChan, err)
+			return
+		}
+
+		sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStop,
+			Timestamp: time.Now(),
+		})
+	}()
+
+	return eventChan, nil
+}
+
+// streamWithNativeTools runs the streaming tool loop using Ollama's native tool calling
+func (c *OllamaClient) streamWithNativeTools(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions, eventChan chan<- interfaces.StreamEvent) error {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2 // Default to current behavior
+	}
+
+	ollamaTools := convertTools(tools)
+	messages := c.buildChatMessages(ctx, prompt, params)
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		message, err := c.streamChat(ctx, ChatRequest{
+			Model:    c.Model,
+			Messages: messages,
+			Tools:    toolsForChoice(ollamaTools, params.ToolChoice.ForTurn(iteration+1)),
+			Stream:   true,
+			Options:  requestOptions(params.LLMConfig),
+		}, iteration+1, eventChan)
+		if err != nil {
+			return fmt.Errorf("failed to stream with tools (iteration %d): %w", iteration+1, err)
+		}
+
+		if len(message.ToolCalls) == 0 {
+			return nil
+		}
+		messages = append(messages, *message)
+
+		calls, err := convertToolCalls(message.ToolCalls, iteration+1)
+		if err != nil {
+			return err
+		}
+		results := executeToolCalls(ctx, calls, tools, params)
+		for i, call := range calls {
+			if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+				Type:      interfaces.StreamEventToolResult,
+				ToolCall:  &calls[i],
+				Content:   results[i],
+				Timestamp: time.Now(),
+				Metadata:  map[string]interface{}{"iteration": iteration + 1},
+			}) {
+				return ctx.Err()
+			}
+			messages = append(messages, ChatMessage{
+				Role:     "tool",
+				Content:  results[i],
+				ToolName: call.Name,
+			})
+		}
+	}
+
+	// Ask for a final answer without tools
+	c.logger.Info(ctx, "Maximum iterations reached, making final call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+	messages = append(messages, ChatMessage{
+		Role:    "user",
+		Content: "Please provide your final response based on the information available. Do not request any additional tools.",
+	})
+	if _, err := c.streamChat(ctx, ChatRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Stream:   true,
+		Options:  requestOptions(params.LLMConfig),
+	}, maxIterations+1, eventChan); err != nil {
+		return fmt.Errorf("failed to stream final response: %w", err)
+	}
+	return nil
+}
+
+// streamReAct runs the ReAct fallback and emits its final answer
+func (c *OllamaClient) streamReAct(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions, eventChan chan<- interfaces.StreamEvent) error {
+	response, err := c.generateWithReAct(ctx, prompt, tools, params)
+	if err != nil {
+		return err
+	}
+	if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+		Type:      interfaces.StreamEventContentDelta,
+		Content:   response,
+		Timestamp: time.Now(),
+	}) {
+		return ctx.Err()
+	}
+	if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+		Type:      interfaces.StreamEventContentComplete,
+		Timestamp: time.Now(),
+	}) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// streamChat sends a streaming chat request and emits content, thinking and
+// tool_use events as chunks arrive. It returns the complete assistant message,
+// without thinking, once the stream is done.
+func (c *OllamaClient) streamChat(ctx context.Context, req ChatRequest, iteration int, eventChan chan<- interfaces.StreamEvent) (*ChatMessage, error) {
+	resp, err := c.makeStreamRequest(ctx, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	message := &ChatMessage{Role: "assistant"}
+	var content strings.Builder
+	var thinkParser llm.ThinkTagParser
+
+	emitSegments := func(segments []llm.ThinkSegment) bool {
+		for _, segment := range segments {
+			event := interfaces.StreamEvent{
+				Type:      interfaces.StreamEventContentDelta,
+				Content:   segment.Text,
+				Timestamp: time.Now(),
+			}
+			if segment.Thinking {
+				event.Type = interfaces.StreamEventThinking
+			} else {
+				content.WriteString(segment.Text)
+			}
+			if !sendEvent(ctx, eventChan, event) {
+				return false
+			}
+		}
+		return true
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama streaming error: %w", llm.NewProviderError(c.Name(), 0, "", chunk.Error, nil, nil))
+		}
+
+		if chunk.Message.Thinking != "" {
+			if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+				Type:      interfaces.StreamEventThinking,
+				Content:   chunk.Message.Thinking,
+				Timestamp: time.Now(),
+			}) {
+				return nil, ctx.Err()
+			}
+		}
+		if chunk.Message.Content != "" && !emitSegments(thinkParser.Feed(chunk.Message.Content)) {
+			return nil, ctx.Err()
+		}
+
+		for _, toolCall := range chunk.Message.ToolCalls {
+			call, err := convertToolCall(toolCall, iteration, len(message.ToolCalls))
+			if err != nil {
+				return nil, err
+			}
+			message.ToolCalls = append(message.ToolCalls, toolCall)
+			if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+				Type:      interfaces.StreamEventToolUse,
+				ToolCall:  &call,
+				Timestamp: time.Now(),
+				Metadata:  map[string]interface{}{"iteration": iteration},
+			}) {
+				return nil, ctx.Err()
+			}
+		}
+
+		if chunk.Done {
+			if !emitSegments(thinkParser.Flush()) {
+				return nil, ctx.Err()
+			}
+			c.recordUsage(ctx, chunk.Model, chunk.PromptEvalCount, chunk.EvalCount, iteration)
+			if len(message.ToolCalls) == 0 {
+				sendEvent(ctx, eventChan, interfaces.StreamEvent{
+					Type:      interfaces.StreamEventContentComplete,
+					Timestamp: time.Now(),
+					Metadata: map[string]interface{}{
+						"finish_reason": chunk.DoneReason,
+						"usage": map[string]interface{}{
+							"prompt_tokens":     chunk.PromptEvalCount,
+							"completion_tokens": chunk.EvalCount,
+							"total_tokens":      chunk.PromptEvalCount + chunk.EvalCount,
+						},
+					},
+				})
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	message.Content = content.String()
+	return message, nil
+}
+
+// makeStreamRequest starts a streaming HTTP request to the Ollama API. The
+// caller must close the response body.
+func (c *OllamaClient) makeStreamRequest(ctx context.Context, endpoint string, payload interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp *http.Response
+	do := func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/x-ndjson")
+
+		resp, err = c.HTTPClient.Do(req)
+		if err != nil {
+			return llm.ClassifyError(c.Name(), err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return fmt.Errorf("API request failed: %w", llm.NewHTTPError(c.Name(), resp.StatusCode, resp.Header, body))
+		}
+		return nil
+	}
+
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, do)
+	} else {
+		err = do()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return resp, nil
+}
+
+// streamBufferSize returns the event channel buffer size from the stream config
+func streamBufferSize(params *interfaces.GenerateOptions) int {
+	if params.StreamConfig != nil && params.StreamConfig.BufferSize > 0 {
+		return params.StreamConfig.BufferSize
+	}
+	return 100
+}
+
+// sendEvent sends an event unless the context is done and reports whether it was sent
+func sendEvent(ctx context.Context, eventChan chan<- interfaces.StreamEvent, event interfaces.StreamEvent) bool {
+	select {
+	case eventChan <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendError sends an error event
+func sendError(ctx context.Context, eventChan chan<- interfaces.StreamEvent, err error) {
+	sendEvent(ctx, eventChan, interfaces.StreamEvent{
+		Type:      interfaces.StreamEventError,
+		Error:     err,
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,159 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeNDJSON writes each chunk as one line of a streamed response
+func writeNDJSON(t *testing.T, w http.ResponseWriter, chunks ...ChatResponse) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	for _, chunk := range chunks {
+		line, err := json.Marshal(chunk)
+		require.NoError(t, err)
+		_, _ = w.Write(append(line, '\n'))
+		w.(http.Flusher).Flush()
+	}
+}
+
+func collectEvents(t *testing.T, eventChan <-chan interfaces.StreamEvent) []interfaces.StreamEvent {
+	var events []interfaces.StreamEvent
+	for event := range eventChan {
+		require.NoError(t, event.Error)
+		events = append(events, event)
+	}
+	return events
+}
+
+func eventText(events []interfaces.StreamEvent, eventType interfaces.StreamEventType) string {
+	var sb strings.Builder
+	for _, event := range events {
+		if event.Type == eventType {
+			sb.WriteString(event.Content)
+		}
+	}
+	return sb.String()
+}
+
+func TestGenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		var req ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+
+		writeNDJSON(t, w,
+			ChatResponse{Message: ChatMessage{Role: "assistant", Content: "<think>The user"}},
+			ChatResponse{Message: ChatMessage{Role: "assistant", Content: " says hi</thi"}},
+			ChatResponse{Message: ChatMessage{Role: "assistant", Content: "nk>Hello"}},
+			ChatResponse{Message: ChatMessage{Role: "assistant", Content: " there!"}},
+			ChatResponse{Model: "qwen3", Done: true, DoneReason: "stop", PromptEvalCount: 10, EvalCount: 5},
+		)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("qwen3"))
+	assert.True(t, client.SupportsStreaming())
+
+	ctx := llm.WithUsageCollection(context.Background())
+	eventChan, err := client.GenerateStream(ctx, "hi")
+	require.NoError(t, err)
+	events := collectEvents(t, eventChan)
+
+	assert.Equal(t, interfaces.StreamEventMessageStart, events[0].Type)
+	assert.Equal(t, interfaces.StreamEventMessageStop, events[len(events)-1].Type)
+	assert.Equal(t, "The user says hi", eventText(events, interfaces.StreamEventThinking))
+	assert.Equal(t, "Hello there!", eventText(events, interfaces.StreamEventContentDelta))
+	assert.Equal(t, interfaces.StreamEventContentComplete, events[len(events)-2].Type)
+	assert.Equal(t, "stop", events[len(events)-2].Metadata["finish_reason"])
+
+	records := llm.GetUsageFromContext(ctx)
+	require.Len(t, records, 1)
+	assert.Equal(t, 15, records[0].Usage.TotalTokens)
+}
+
+func TestGenerateWithToolsStream(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		if len(requests) == 1 {
+			writeNDJSON(t, w,
+				ChatResponse{Message: ChatMessage{Role: "assistant", ToolCalls: []ToolCall{{
+					Function: ToolCallFunction{Name: "test-tool", Arguments: map[string]interface{}{"input": "x"}},
+				}}}},
+				ChatResponse{Done: true, DoneReason: "stop"},
+			)
+			return
+		}
+		writeNDJSON(t, w,
+			ChatResponse{Message: ChatMessage{Role: "assistant", Thinking: "Got the result."}},
+			ChatResponse{Message: ChatMessage{Role: "assistant", Content: "The result is mock result"}},
+			ChatResponse{Done: true, DoneReason: "stop"},
+		)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("qwen3"))
+	tools := []interfaces.Tool{&mockTool{name: "test-tool", description: "A test tool"}}
+
+	eventChan, err := client.GenerateWithToolsStream(context.Background(), "use the tool", tools)
+	require.NoError(t, err)
+	events := collectEvents(t, eventChan)
+
+	var toolUse, toolResult *interfaces.StreamEvent
+	for i := range events {
+		switch events[i].Type {
+		case interfaces.StreamEventToolUse:
+			toolUse = &events[i]
+		case interfaces.StreamEventToolResult:
+			toolResult = &events[i]
+		}
+	}
+	require.NotNil(t, toolUse)
+	assert.Equal(t, "test-tool", toolUse.ToolCall.Name)
+	assert.Equal(t, `{"input":"x"}`, toolUse.ToolCall.Arguments)
+	require.NotNil(t, toolResult)
+	assert.Equal(t, toolUse.ToolCall.ID, toolResult.ToolCall.ID)
+	assert.Equal(t, "mock result", toolResult.Content)
+
+	assert.Equal(t, "Got the result.", eventText(events, interfaces.StreamEventThinking))
+	assert.Equal(t, "The result is mock result", eventText(events, interfaces.StreamEventContentDelta))
+	assert.Equal(t, interfaces.StreamEventMessageStop, events[len(events)-1].Type)
+
+	require.Len(t, requests, 2)
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, ChatMessage{Role: "tool", Content: "mock result", ToolName: "test-tool"}, last)
+}
+
+func TestGenerateStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"partial"}}` + "\n" + `{"error":"model crashed"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	eventChan, err := client.GenerateStream(context.Background(), "hi")
+	require.NoError(t, err)
+
+	var streamErr error
+	for event := range eventChan {
+		if event.Type == interfaces.StreamEventError {
+			streamErr = event.Error
+		}
+	}
+	require.Error(t, streamErr)
+	assert.Contains(t, streamErr.Error(), "model crashed")
+}
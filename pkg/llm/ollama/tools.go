@@ -0,0 +1,305 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// GenerateWithTools generates text and can use tools. Tools are passed to
+// Ollama's /api/chat endpoint and executed in a loop until the model answers
+// without calling a tool or MaxIterations is reached. Models that do not
+// support tools fall back to a ReAct prompt, see WithToolCallingMode.
+func (c *OllamaClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	if len(tools) == 0 {
+		return c.Generate(ctx, prompt, options...)
+	}
+
+	params := newGenerateOptions(options)
+	if c.ToolCallingMode() == llm.ToolCallingReAct {
+		return c.generateWithReAct(ctx, prompt, tools, params)
+	}
+
+	response, err := c.generateWithNativeTools(ctx, prompt, tools, params)
+	if err != nil && c.toolMode != llm.ToolCallingNative && isToolsUnsupportedError(err) {
+		c.logger.Warn(ctx, "Model does not support native tool calling, falling back to ReAct", map[string]interface{}{
+			"model": c.Model,
+		})
+		return c.generateWithReAct(ctx, prompt, tools, params)
+	}
+	return response, err
+}
+
+// newGenerateOptions applies the options on top of the client defaults
+func newGenerateOptions(options []interfaces.GenerateOption) *interfaces.GenerateOptions {
+	params := &interfaces.GenerateOptions{
+		LLMConfig: &interfaces.LLMConfig{
+			Temperature: 0.7,
+		},
+	}
+	for _, option := range options {
+		option(params)
+	}
+	if params.LLMConfig == nil {
+		params.LLMConfig = &interfaces.LLMConfig{}
+	}
+	return params
+}
+
+// generateWithNativeTools runs the tool loop using Ollama's native tool calling
+func (c *OllamaClient) generateWithNativeTools(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions) (string, error) {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2 // Default to current behavior
+	}
+
+	ollamaTools := convertTools(tools)
+	messages := c.buildChatMessages(ctx, prompt, params)
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		chatResp, err := c.chat(ctx, ChatRequest{
+			Model:    c.Model,
+			Messages: messages,
+			Tools:    toolsForChoice(ollamaTools, params.ToolChoice.ForTurn(iteration+1)),
+			Options:  requestOptions(params.LLMConfig),
+		}, iteration+1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate with tools (iteration %d): %w", iteration+1, err)
+		}
+
+		if len(chatResp.Message.ToolCalls) == 0 {
+			return chatResp.Message.Content, nil
+		}
+
+		c.logger.Info(ctx, "Processing tool calls", map[string]interface{}{
+			"count":     len(chatResp.Message.ToolCalls),
+			"iteration": iteration + 1,
+		})
+		messages = append(messages, chatResp.Message)
+
+		calls, err := convertToolCalls(chatResp.Message.ToolCalls, iteration+1)
+		if err != nil {
+			return "", err
+		}
+		results := executeToolCalls(ctx, calls, tools, params)
+		for i, call := range calls {
+			messages = append(messages, ChatMessage{
+				Role:     "tool",
+				Content:  results[i],
+				ToolName: call.Name,
+			})
+		}
+	}
+
+	// Ask for a final answer without tools
+	c.logger.Info(ctx, "Maximum iterations reached, making final call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+	messages = append(messages, ChatMessage{
+		Role:    "user",
+		Content: "Please provide your final response based on the information available. Do not request any additional tools.",
+	})
+	chatResp, err := c.chat(ctx, ChatRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Options:  requestOptions(params.LLMConfig),
+	}, maxIterations+1)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate final response: %w", err)
+	}
+	return chatResp.Message.Content, nil
+}
+
+// convertToolCalls converts Ollama tool calls to the common format
+func convertToolCalls(toolCalls []ToolCall, iteration int) ([]interfaces.ToolCall, error) {
+	calls := make([]interfaces.ToolCall, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		call, err := convertToolCall(toolCall, iteration, i)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = call
+	}
+	return calls, nil
+}
+
+// convertToolCall converts an Ollama tool call to the common format. Ollama
+// does not assign IDs to tool calls, so the ID is derived from the iteration
+// and the position of the call in the turn.
+func convertToolCall(toolCall ToolCall, iteration, index int) (interfaces.ToolCall, error) {
+	arguments, err := json.Marshal(toolCall.Function.Arguments)
+	if err != nil {
+		return interfaces.ToolCall{}, fmt.Errorf("failed to marshal tool arguments: %w", err)
+	}
+	return interfaces.ToolCall{
+		ID:        fmt.Sprintf("call_%d_%d", iteration, index),
+		Name:      toolCall.Function.Name,
+		Arguments: string(arguments),
+	}, nil
+}
+
+// executeToolCalls runs the tool calls of one turn, concurrently if enabled,
+// and returns the content for each result in the order the model requested them
+func executeToolCalls(ctx context.Context, calls []interfaces.ToolCall, tools []interfaces.Tool, params *interfaces.GenerateOptions) []string {
+	executions := make([]*llm.ToolExecution, len(calls))
+	for i, call := range calls {
+		executions[i] = &llm.ToolExecution{
+			Tool:      llm.FindTool(tools, call.Name),
+			Arguments: call.Arguments,
+		}
+	}
+	llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+	results := make([]string, len(calls))
+	for i, call := range calls {
+		results[i] = llm.RecordToolExecution(ctx, call, executions[i], params.Memory)
+	}
+	return results
+}
+
+// generateWithReAct runs the tool loop with a text-based ReAct prompt
+func (c *OllamaClient) generateWithReAct(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions) (string, error) {
+	return llm.RunReActLoop(ctx, func(ctx context.Context, req llm.ReActRequest) (string, error) {
+		messages := make([]ChatMessage, len(req.Messages))
+		for i, msg := range req.Messages {
+			messages[i] = ChatMessage{Role: msg.Role, Content: msg.Content}
+		}
+
+		options := requestOptions(params.LLMConfig)
+		options.Stop = req.StopSequences
+
+		chatResp, err := c.chat(ctx, ChatRequest{
+			Model:    c.Model,
+			Messages: messages,
+			Options:  options,
+		}, req.Iteration)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate with tools (iteration %d): %w", req.Iteration, err)
+		}
+		return chatResp.Message.Content, nil
+	}, prompt, tools, params, c.logger)
+}
+
+// chat sends a non-streaming chat request and records its token usage
+func (c *OllamaClient) chat(ctx context.Context, req ChatRequest, iteration int) (*ChatResponse, error) {
+	resp, err := c.makeRequest(ctx, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(resp, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chat response: %w", err)
+	}
+	c.recordUsage(ctx, chatResp.Model, chatResp.PromptEvalCount, chatResp.EvalCount, iteration)
+
+	return &chatResp, nil
+}
+
+// buildChatMessages builds the chat history from the system message, memory
+// and prompt. As with the other providers, the prompt is taken from memory
+// when it has any messages because the agent has already stored it there.
+func (c *OllamaClient) buildChatMessages(ctx context.Context, prompt string, params *interfaces.GenerateOptions) []ChatMessage {
+	var messages []ChatMessage
+	if params.SystemMessage != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: params.SystemMessage})
+	}
+
+	if params.Memory == nil {
+		return append(messages, ChatMessage{Role: "user", Content: prompt, Images: c.convertImages(ctx, params.ContentParts)})
+	}
+
+	memoryMessages, err := params.Memory.GetMessages(ctx)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to retrieve memory messages", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return append(messages, ChatMessage{Role: "user", Content: prompt})
+	}
+	if len(memoryMessages) == 0 {
+		return append(messages, ChatMessage{Role: "user", Content: prompt})
+	}
+
+	for _, msg := range memoryMessages {
+		chatMsg := ChatMessage{Role: string(msg.Role), Content: msg.Content}
+		switch msg.Role {
+		case interfaces.MessageRoleUser:
+			chatMsg.Images = c.convertImages(ctx, msg.Parts)
+		case interfaces.MessageRoleAssistant:
+			for _, toolCall := range msg.ToolCalls {
+				var arguments map[string]interface{}
+				_ = json.Unmarshal([]byte(toolCall.Arguments), &arguments)
+				chatMsg.ToolCalls = append(chatMsg.ToolCalls, ToolCall{
+					Function: ToolCallFunction{Name: toolCall.Name, Arguments: arguments},
+				})
+			}
+			if chatMsg.Content == "" && len(chatMsg.ToolCalls) == 0 {
+				continue
+			}
+		case interfaces.MessageRoleTool:
+			if name, ok := msg.Metadata["tool_name"].(string); ok {
+				chatMsg.ToolName = name
+			}
+		}
+		messages = append(messages, chatMsg)
+	}
+
+	return messages
+}
+
+// convertTools converts tools to Ollama's function definitions
+func convertTools(tools []interfaces.Tool) []Tool {
+	ollamaTools := make([]Tool, len(tools))
+	for i, tool := range tools {
+		ollamaTools[i] = Tool{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  llm.ToolParametersSchema(tool),
+			},
+		}
+	}
+	return ollamaTools
+}
+
+// toolsForChoice applies a tool choice by narrowing the tools offered, since
+// Ollama has no tool_choice parameter: no tools for none, only the named tool
+// for a specific tool. Requiring a tool call cannot be enforced.
+func toolsForChoice(tools []Tool, choice *interfaces.ToolChoice) []Tool {
+	if choice == nil {
+		return tools
+	}
+	switch choice.Mode {
+	case interfaces.ToolChoiceModeNone:
+		return nil
+	case interfaces.ToolChoiceModeTool:
+		for _, tool := range tools {
+			if tool.Function.Name == choice.Name {
+				return []Tool{tool}
+			}
+		}
+	}
+	return tools
+}
+
+// requestOptions converts the LLM config to Ollama request options
+func requestOptions(config *interfaces.LLMConfig) *Options {
+	return &Options{
+		Temperature:   config.Temperature,
+		TopP:          config.TopP,
+		TopK:          config.TopK,
+		Stop:          config.StopSequences,
+		RepeatPenalty: config.RepeatPenalty,
+	}
+}
+
+// isToolsUnsupportedError reports whether Ollama rejected a request because
+// the model does not support tools
+func isToolsUnsupportedError(err error) bool {
+	return strings.Contains(err.Error(), "does not support tools")
+}
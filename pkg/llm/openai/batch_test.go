@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/structuredoutput"
+	"github.com/openai/openai-go/v2"
+)
+
+// batchStandIn emulates the files and batches APIs. Batches complete
+// immediately, with one output line per input line; requests with the custom
+// ID "bad" fail.
+type batchStandIn struct {
+	mu     sync.Mutex
+	input  []map[string]interface{}
+	status string
+}
+
+func (s *batchStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	batch := func() string {
+		return fmt.Sprintf(`{"id":"batch_1","object":"batch","endpoint":"/v1/chat/completions","input_file_id":"file-in",
+			"completion_window":"24h","status":%q,"created_at":1700000000,"expires_at":1700086400,
+			"output_file_id":"file-out","error_file_id":"file-err",
+			"request_counts":{"total":%d,"completed":%d,"failed":1}}`, s.status, len(s.input), len(s.input)-1)
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/files":
+		file, _, err := r.FormFile("file")
+		if err != nil || r.FormValue("purpose") != "batch" {
+			http.Error(w, `{"error":{"message":"bad upload"}}`, http.StatusBadRequest)
+			return
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			_ = json.Unmarshal(scanner.Bytes(), &line)
+			s.input = append(s.input, line)
+		}
+		_, _ = io.WriteString(w, `{"id":"file-in","object":"file","bytes":1,"created_at":1700000000,"filename":"batch.jsonl","purpose":"batch"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/batches":
+		s.status = "validating"
+		_, _ = io.WriteString(w, batch())
+	case r.Method == http.MethodGet && r.URL.Path == "/batches/batch_1":
+		_, _ = io.WriteString(w, batch())
+		s.status = "completed"
+	case r.Method == http.MethodGet && r.URL.Path == "/files/file-out/content":
+		for _, line := range s.input {
+			if line["custom_id"] == "bad" {
+				continue
+			}
+			content := `{"label":"invoice"}`
+			_, _ = fmt.Fprintf(w, `{"id":"r1","custom_id":%q,"response":{"status_code":200,"body":{"id":"c1","object":"chat.completion","created":1,"model":"gpt-4o-mini","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":%q}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}}}`+"\n", line["custom_id"], content)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/files/file-err/content":
+		_, _ = io.WriteString(w, `{"id":"r2","custom_id":"bad","response":{"status_code":400,"body":{"error":{"message":"Invalid schema","type":"invalid_request_error"}}}}`+"\n")
+	default:
+		http.Error(w, `{"error":{"message":"not found"}}`, http.StatusNotFound)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	standIn := &batchStandIn{}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithModel("gpt-4o-mini"))
+
+	type label struct {
+		Label string `json:"label"`
+	}
+	requests := []interfaces.BatchRequest{
+		{CustomID: "doc-1", Prompt: "Classify document 1", Options: []interfaces.GenerateOption{
+			WithResponseFormat(*structuredoutput.NewResponseFormat(label{})),
+		}},
+		{CustomID: "doc-2", Prompt: "Classify document 2", Options: []interfaces.GenerateOption{WithSystemMessage("You classify documents")}},
+		{CustomID: "bad", Prompt: "Classify document 3"},
+	}
+
+	ctx := llm.WithUsageCollection(context.Background())
+	results, err := llm.RunBatch(ctx, client, requests, 1)
+	if err != nil {
+		t.Fatalf("RunBatch failed: %v", err)
+	}
+
+	if len(standIn.input) != 3 {
+		t.Fatalf("Expected 3 input lines, got %d", len(standIn.input))
+	}
+	first := standIn.input[0]
+	if first["custom_id"] != "doc-1" || first["method"] != "POST" || first["url"] != "/v1/chat/completions" {
+		t.Errorf("Unexpected input line: %v", first)
+	}
+	body := first["body"].(map[string]interface{})
+	format, _ := body["response_format"].(map[string]interface{})
+	if body["model"] != "gpt-4o-mini" || format["type"] != "json_schema" {
+		t.Errorf("Expected the request to use the model and a JSON schema, got %v", body)
+	}
+	messages := standIn.input[1]["body"].(map[string]interface{})["messages"].([]interface{})
+	if len(messages) != 2 || messages[0].(map[string]interface{})["role"] != "system" {
+		t.Errorf("Expected a system and a user message, got %v", messages)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	var decoded label
+	if err := llm.UnmarshalBatchResult(results["doc-1"], &decoded); err != nil || decoded.Label != "invoice" {
+		t.Errorf("Expected label invoice, got %+v (%v)", decoded, err)
+	}
+	if !errors.Is(results["bad"].Err, interfaces.ErrInvalidRequest) || !strings.Contains(results["bad"].Err.Error(), "Invalid schema") {
+		t.Errorf("Expected an invalid request error, got %v", results["bad"].Err)
+	}
+
+	if usage := llm.GetUsageFromContext(ctx); len(usage) != 2 || usage[0].Usage.TotalTokens != 15 {
+		t.Errorf("Expected the usage of 2 requests, got %+v", usage)
+	}
+}
+
+func TestConvertBatch(t *testing.T) {
+	client := NewClient("test-key")
+	if _, err := client.SubmitBatch(context.Background(), []interfaces.BatchRequest{{CustomID: "a"}, {CustomID: "a"}}); err == nil {
+		t.Error("Expected duplicate custom IDs to be rejected")
+	}
+
+	tests := map[string]interfaces.BatchStatus{
+		"validating": interfaces.BatchStatusInProgress,
+		"finalizing": interfaces.BatchStatusInProgress,
+		"cancelling": interfaces.BatchStatusInProgress,
+		"completed":  interfaces.BatchStatusCompleted,
+		"cancelled":  interfaces.BatchStatusCanceled,
+		"expired":    interfaces.BatchStatusExpired,
+		"failed":     interfaces.BatchStatusFailed,
+	}
+	for status, expected := range tests {
+		var batch openai.Batch
+		if err := json.Unmarshal([]byte(fmt.Sprintf(`{"id":"batch_1","status":%q}`, status)), &batch); err != nil {
+			t.Fatalf("Failed to unmarshal batch: %v", err)
+		}
+		converted := convertBatch(&batch)
+		if converted.Status != expected {
+			t.Errorf("For status %s, expected %s, got %s", status, expected, converted.Status)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package openai
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
@@ -29,6 +30,7 @@ type OpenAIClient struct {
 	Model           string
 	apiKey          string
 	baseURL         string
+	httpClient      *http.Client
 	logger          logging.Logger
 	retryExecutor   *retry.Executor
 }
@@ -43,21 +45,11 @@ func WithModel(model string) Option {
 	}
 }
 
-// isReasoningModel returns true if the model is a reasoning model that requires temperature = 1
+// isReasoningModel returns true if the model is a reasoning model that requires
+// temperature = 1, according to llm.LookupModel
 func isReasoningModel(model string) bool {
-	reasoningModels := []string{
-		"o1-", "o1-mini", "o1-preview",
-		"o3-", "o3-mini",
-		"o4-", "o4-mini",
-		"gpt-5", "gpt-5-mini", "gpt-5-nano",
-	}
-
-	for _, prefix := range reasoningModels {
-		if strings.HasPrefix(model, prefix) {
-			return true
-		}
-	}
-	return false
+	info, ok := llm.LookupModel(model)
+	return ok && info.FixedTemperature
 }
 
 // getTemperatureForModel returns the appropriate temperature for a model
@@ -95,10 +87,27 @@ func WithBaseURL(baseURL string) Option {
 	return func(c *OpenAIClient) {
 		c.baseURL = baseURL
 		// Recreate the client and services with the new base URL
-		c.Client = openai.NewClient(option.WithAPIKey(c.apiKey), option.WithBaseURL(baseURL))
-		c.ChatService = openai.NewChatService(option.WithAPIKey(c.apiKey), option.WithBaseURL(baseURL))
-		c.ResponseService = openai.NewClient(option.WithAPIKey(c.apiKey), option.WithBaseURL(baseURL))
+		c.recreateClients()
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *OpenAIClient) {
+		c.httpClient = httpClient
+		c.recreateClients()
+	}
+}
+
+// recreateClients recreates the OpenAI clients with the current configuration
+func (c *OpenAIClient) recreateClients() {
+	options := []option.RequestOption{option.WithAPIKey(c.apiKey), option.WithBaseURL(c.baseURL)}
+	if c.httpClient != nil {
+		options = append(options, option.WithHTTPClient(c.httpClient))
 	}
+	c.Client = openai.NewClient(options...)
+	c.ChatService = openai.NewChatService(options...)
+	c.ResponseService = openai.NewClient(options...)
 }
 
 // NewClient creates a new OpenAI client
@@ -141,64 +150,7 @@ func (c *OpenAIClient) Generate(ctx context.Context, prompt string, options ...i
 		ctx = context.WithValue(ctx, organizationKey, orgID)
 	}
 
-	// Build messages starting with memory context
-	messages := []openai.ChatCompletionMessageParamUnion{}
-
-	// Add system message if available
-	if params.SystemMessage != "" {
-		messages = append(messages, openai.SystemMessage(params.SystemMessage))
-		c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": params.SystemMessage})
-	}
-
-	// Build messages using unified builder
-	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
-
-	// Create request
-	req := openai.ChatCompletionNewParams{
-		Model:    openai.ChatModel(c.Model),
-		Messages: messages,
-	}
-
-	if params.LLMConfig != nil {
-		req.Temperature = openai.Float(c.getTemperatureForModel(params.LLMConfig.Temperature))
-		// Reasoning models don't support top_p parameter
-		if !isReasoningModel(c.Model) {
-			req.TopP = openai.Float(params.LLMConfig.TopP)
-		}
-		req.FrequencyPenalty = openai.Float(params.LLMConfig.FrequencyPenalty)
-		req.PresencePenalty = openai.Float(params.LLMConfig.PresencePenalty)
-		if len(params.LLMConfig.StopSequences) > 0 {
-			req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.LLMConfig.StopSequences}
-		}
-		// Set reasoning effort for reasoning models
-		if isReasoningModel(c.Model) && params.LLMConfig.Reasoning != "" {
-			req.ReasoningEffort = shared.ReasoningEffort(params.LLMConfig.Reasoning)
-			c.logger.Debug(ctx, "Setting reasoning effort", map[string]interface{}{"reasoning_effort": params.LLMConfig.Reasoning})
-		}
-	}
-
-	// Set response format if provided
-	if params.ResponseFormat != nil {
-		// Convert to the new API's response format structure
-		jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
-			Name:   params.ResponseFormat.Name,
-			Schema: params.ResponseFormat.Schema,
-		}
-
-		req.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
-				Type:       "json_schema",
-				JSONSchema: jsonSchema,
-			},
-		}
-		c.logger.Debug(ctx, "Using response format", map[string]interface{}{"format": *params.ResponseFormat})
-	}
-
-	// Set organization ID if available
-	if orgID, ok := ctx.Value(organizationKey).(string); ok && orgID != "" {
-		req.User = openai.String(orgID)
-	}
+	req := c.newChatCompletionParams(ctx, prompt, params)
 
 	var resp *openai.ChatCompletion
 	var err error
@@ -229,7 +181,7 @@ func (c *OpenAIClient) Generate(ctx context.Context, prompt string, options ...i
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to generate text: %w", err)
+			return fmt.Errorf("failed to generate text: %w", c.classifyError(err))
 		}
 		return nil
 	}
@@ -247,6 +199,8 @@ func (c *OpenAIClient) Generate(ctx context.Context, prompt string, options ...i
 		return "", err
 	}
 
+	c.recordUsage(ctx, resp.Model, resp.Usage, 0)
+
 	// Return response
 	if len(resp.Choices) > 0 {
 		c.logger.Debug(ctx, "Successfully received response from OpenAI", map[string]interface{}{
@@ -258,104 +212,90 @@ func (c *OpenAIClient) Generate(ctx context.Context, prompt string, options ...i
 	return "", fmt.Errorf("no response from OpenAI API")
 }
 
-// Chat uses the ChatCompletion API to have a conversation (messages) with a model
-func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
-	if params == nil {
-		params = llm.DefaultGenerateParams()
-	}
-
-	// Convert messages to the OpenAI Chat format
-	chatMessages := make([]openai.ChatCompletionMessageParamUnion, len(messages))
-	for i, msg := range messages {
-		switch msg.Role {
-		case "system":
-			chatMessages[i] = openai.SystemMessage(msg.Content)
-		case "user":
-			chatMessages[i] = openai.UserMessage(msg.Content)
-		case "assistant":
-			chatMessages[i] = openai.AssistantMessage(msg.Content)
-		case "tool":
-			// For tool messages, we need to handle tool call ID
-			// Use the ToolCallID from the Message struct
-			chatMessages[i] = openai.ToolMessage(msg.Content, msg.ToolCallID)
-		default:
-			// Default to user message for unknown roles
-			chatMessages[i] = openai.UserMessage(msg.Content)
-		}
-	}
+// newChatCompletionParams builds the chat completion request of a Generate
+// call, which is also used for batch requests
+func (c *OpenAIClient) newChatCompletionParams(ctx context.Context, prompt string, params *interfaces.GenerateOptions) openai.ChatCompletionNewParams {
+	// Build messages starting with memory context
+	messages := []openai.ChatCompletionMessageParamUnion{}
 
-	// Create chat request
-	req := openai.ChatCompletionNewParams{
-		Model:            openai.ChatModel(c.Model),
-		Messages:         chatMessages,
-		Temperature:      openai.Float(c.getTemperatureForModel(params.Temperature)),
-		FrequencyPenalty: openai.Float(params.FrequencyPenalty),
-		PresencePenalty:  openai.Float(params.PresencePenalty),
+	// Add system message if available
+	if params.SystemMessage != "" {
+		messages = append(messages, openai.SystemMessage(params.SystemMessage))
+		c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": params.SystemMessage})
 	}
 
-	// Reasoning models don't support top_p parameter
-	if !isReasoningModel(c.Model) {
-		req.TopP = openai.Float(params.TopP)
-	}
+	// Build messages using unified builder
+	builder := newMessageHistoryBuilder(c.logger)
+	messages = append(messages, builder.buildMessages(ctx, prompt, params)...)
 
-	if len(params.StopSequences) > 0 {
-		req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.StopSequences}
+	// Create request
+	req := openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(c.Model),
+		Messages: messages,
 	}
 
-	// Set reasoning effort for reasoning models
-	if isReasoningModel(c.Model) && params.Reasoning != "" {
-		req.ReasoningEffort = shared.ReasoningEffort(params.Reasoning)
-		c.logger.Debug(ctx, "Setting reasoning effort", map[string]interface{}{"reasoning_effort": params.Reasoning})
+	if params.LLMConfig != nil {
+		req.Temperature = openai.Float(c.getTemperatureForModel(params.LLMConfig.Temperature))
+		// Reasoning models don't support top_p parameter
+		if !isReasoningModel(c.Model) {
+			req.TopP = openai.Float(params.LLMConfig.TopP)
+		}
+		req.FrequencyPenalty = openai.Float(params.LLMConfig.FrequencyPenalty)
+		req.PresencePenalty = openai.Float(params.LLMConfig.PresencePenalty)
+		if len(params.LLMConfig.StopSequences) > 0 {
+			req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.LLMConfig.StopSequences}
+		}
+		// Set reasoning effort for reasoning models
+		if isReasoningModel(c.Model) && params.LLMConfig.Reasoning != "" {
+			req.ReasoningEffort = shared.ReasoningEffort(params.LLMConfig.Reasoning)
+			c.logger.Debug(ctx, "Setting reasoning effort", map[string]interface{}{"reasoning_effort": params.LLMConfig.Reasoning})
+		}
 	}
 
-	var resp *openai.ChatCompletion
-	var err error
-
-	operation := func() error {
-		c.logger.Debug(ctx, "Executing OpenAI Chat API request", map[string]interface{}{
-			"model":             c.Model,
-			"temperature":       req.Temperature,
-			"top_p":             req.TopP,
-			"frequency_penalty": req.FrequencyPenalty,
-			"presence_penalty":  req.PresencePenalty,
-			"stop_sequences":    req.Stop,
-			"messages":          len(req.Messages),
-			"reasoning_effort":  params.Reasoning,
-		})
+	// Set response format if provided
+	if params.ResponseFormat != nil {
+		// Convert to the new API's response format structure
+		jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   params.ResponseFormat.Name,
+			Schema: params.ResponseFormat.Schema,
+		}
 
-		resp, err = c.ChatService.Completions.New(ctx, req)
-		if err != nil {
-			c.logger.Error(ctx, "Error from OpenAI Chat API", map[string]interface{}{
-				"error": err.Error(),
-				"model": c.Model,
-			})
-			return fmt.Errorf("failed to create chat completion: %w", err)
+		req.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				Type:       "json_schema",
+				JSONSchema: jsonSchema,
+			},
 		}
-		return nil
+		c.logger.Debug(ctx, "Using response format", map[string]interface{}{"format": *params.ResponseFormat})
 	}
 
-	if c.retryExecutor != nil {
-		c.logger.Debug(ctx, "Using retry mechanism for OpenAI Chat request", map[string]interface{}{
-			"model": c.Model,
-		})
-		err = c.retryExecutor.Execute(ctx, operation)
-	} else {
-		err = operation()
+	// Set organization ID if available
+	if orgID, ok := ctx.Value(organizationKey).(string); ok && orgID != "" {
+		req.User = openai.String(orgID)
 	}
+	return req
+}
 
-	if err != nil {
-		return "", err
+// Chat uses the ChatCompletion API to have a conversation (messages) with a model.
+// It sends the messages with ChatMessages; DefaultGenerateParams are used if
+// params is nil.
+func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
+	if params == nil {
+		params = llm.DefaultGenerateParams()
 	}
+	return c.ChatMessages(ctx, llm.ConvertMessages(messages), params.Options()...)
+}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no completions returned")
+// ChatMessages generates the next assistant message of a conversation. The
+// messages are sent the way messages from memory are, so assistant tool calls
+// and tool results are kept; leading system messages are used as the system
+// message.
+func (c *OpenAIClient) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to chat: %w", err)
 	}
-
-	c.logger.Debug(ctx, "Successfully received chat response from OpenAI", map[string]interface{}{
-		"model": c.Model,
-	})
-
-	return resp.Choices[0].Message.Content, nil
+	return c.Generate(ctx, prompt, options...)
 }
 
 func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
@@ -432,7 +372,7 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 
 	// Build message history
 	builder := newMessageHistoryBuilder(c.logger)
-	messages := builder.buildMessages(ctx, prompt, params.Memory)
+	messages := builder.buildMessages(ctx, prompt, params)
 
 	if params.SystemMessage != "" {
 		// Ensure system message is at the start
@@ -453,6 +393,9 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 			req.TopP = openai.Float(params.LLMConfig.TopP)
 			req.ParallelToolCalls = openai.Bool(true)
 		}
+		if choice := params.ToolChoice.ForTurn(iteration + 1); choice != nil {
+			req.ToolChoice = toolChoiceParam(choice)
+		}
 		if len(params.LLMConfig.StopSequences) > 0 {
 			req.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: params.LLMConfig.StopSequences}
 		}
@@ -460,8 +403,9 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 		resp, err := c.ChatService.Completions.New(ctx, req)
 		if err != nil {
 			c.logger.Error(ctx, "Error from OpenAI API", map[string]interface{}{"error": err.Error()})
-			return "", fmt.Errorf("failed to create chat completion: %w", err)
+			return "", fmt.Errorf("failed to create chat completion: %w", c.classifyError(err))
 		}
+		c.recordUsage(ctx, resp.Model, resp.Usage, iteration+1)
 
 		if len(resp.Choices) == 0 {
 			return "", fmt.Errorf("no completions returned")
@@ -474,27 +418,24 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 			return strings.TrimSpace(responseMessage.Content), nil
 		}
 
-		// Process tool calls and append results to messages for the next loop iteration
-		for _, toolCall := range responseMessage.ToolCalls {
-			var selectedTool interfaces.Tool
-			for _, t := range tools {
-				if t.Name() == toolCall.Function.Name {
-					selectedTool = t
-					break
-				}
-			}
-
-			var toolResultContent string
-			if selectedTool == nil {
-				toolResultContent = fmt.Sprintf("Error: tool not found: %s", toolCall.Function.Name)
-			} else {
-				result, err := selectedTool.Execute(ctx, toolCall.Function.Arguments)
-				if err != nil {
-					toolResultContent = fmt.Sprintf("Error: %v", err)
-				} else {
-					toolResultContent = result
-				}
+		// Execute the requested tools, concurrently if enabled
+		executions := make([]*llm.ToolExecution, len(responseMessage.ToolCalls))
+		for i, toolCall := range responseMessage.ToolCalls {
+			executions[i] = &llm.ToolExecution{
+				Tool:      llm.FindTool(tools, toolCall.Function.Name),
+				Arguments: toolCall.Function.Arguments,
 			}
+		}
+		llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+		// Append results to messages in the order the model requested them,
+		// and store the calls and results in memory if provided
+		for i, toolCall := range responseMessage.ToolCalls {
+			toolResultContent := llm.RecordToolExecution(ctx, interfaces.ToolCall{
+				ID:        toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			}, executions[i], params.Memory)
 			messages = append(messages, openai.ToolMessage(toolResultContent, toolCall.ID))
 		}
 	}
@@ -502,6 +443,30 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 	return "", fmt.Errorf("max iterations reached without a final answer")
 }
 
+// recordUsage reports the token usage of a completion to the usage collector in the context
+func (c *OpenAIClient) recordUsage(ctx context.Context, model string, usage openai.CompletionUsage, iteration int) {
+	if model == "" {
+		model = c.Model
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{
+		Provider:  c.Name(),
+		Model:     model,
+		Iteration: iteration,
+		Usage:     convertUsage(usage),
+	})
+}
+
+// convertUsage converts OpenAI completion usage to the provider-neutral format
+func convertUsage(usage openai.CompletionUsage) llm.TokenUsage {
+	return llm.TokenUsage{
+		InputTokens:       int(usage.PromptTokens),
+		OutputTokens:      int(usage.CompletionTokens),
+		CachedInputTokens: int(usage.PromptTokensDetails.CachedTokens),
+		ReasoningTokens:   int(usage.CompletionTokensDetails.ReasoningTokens),
+		TotalTokens:       int(usage.TotalTokens),
+	}
+}
+
 // Name implements interfaces.LLM.Name
 func (c *OpenAIClient) Name() string {
 	return "openai"
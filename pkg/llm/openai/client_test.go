@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/llm"
@@ -261,6 +264,21 @@ func TestChatWithToolMessages(t *testing.T) {
 			t.Error("Expected tool message in request")
 		}
 
+		// Verify that the system message and the assistant's tool call are kept
+		if role := messages[0].(map[string]interface{})["role"]; role != "system" {
+			t.Errorf("Expected the system message first, got role '%v'", role)
+		}
+		foundToolCall := false
+		for _, msg := range messages {
+			msgMap := msg.(map[string]interface{})
+			if toolCalls, ok := msgMap["tool_calls"].([]interface{}); ok && msgMap["role"] == "assistant" && len(toolCalls) == 1 {
+				foundToolCall = true
+			}
+		}
+		if !foundToolCall {
+			t.Error("Expected assistant message with tool call in request")
+		}
+
 		// Send response
 		w.Header().Set("Content-Type", "application/json")
 		response := openai.ChatCompletion{
@@ -299,19 +317,29 @@ func TestChatWithToolMessages(t *testing.T) {
 	)
 
 	// Test chat with tool messages
-	messages := []llm.Message{
+	messages := []interfaces.Message{
 		{
-			Role:    "user",
+			Role:    interfaces.MessageRoleSystem,
+			Content: "You are a test assistant",
+		},
+		{
+			Role:    interfaces.MessageRoleUser,
 			Content: "test message",
 		},
 		{
-			Role:       "tool",
+			Role: interfaces.MessageRoleAssistant,
+			ToolCalls: []interfaces.ToolCall{
+				{ID: "test-tool-call-id", Name: "test_tool", Arguments: `{"query":"test"}`},
+			},
+		},
+		{
+			Role:       interfaces.MessageRoleTool,
 			Content:    "tool result",
 			ToolCallID: "test-tool-call-id",
 		},
 	}
 
-	resp, err := client.Chat(context.Background(), messages, nil)
+	resp, err := client.ChatMessages(context.Background(), messages)
 	if err != nil {
 		t.Fatalf("Failed to chat: %v", err)
 	}
@@ -659,3 +687,146 @@ func TestGenerateWithMemory(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateRecordsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"model": "gpt-4o-2024-08-06",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}],
+			"usage": {
+				"prompt_tokens": 120,
+				"completion_tokens": 30,
+				"total_tokens": 150,
+				"prompt_tokens_details": {"cached_tokens": 100},
+				"completion_tokens_details": {"reasoning_tokens": 12}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	client := openai_client.NewClient("test-key",
+		openai_client.WithModel("gpt-4o"),
+		openai_client.WithLogger(logging.New()),
+	)
+	client.ChatService = openai.NewChatService(
+		option.WithAPIKey("test-key"),
+		option.WithBaseURL(server.URL),
+	)
+
+	ctx := llm.WithUsageCollection(context.Background())
+	if _, err := client.Generate(ctx, "test prompt"); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	records := llm.GetUsageFromContext(ctx)
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 usage record, got %d", len(records))
+	}
+	record := records[0]
+	if record.Provider != "openai" || record.Model != "gpt-4o-2024-08-06" {
+		t.Errorf("Unexpected provider/model: %s/%s", record.Provider, record.Model)
+	}
+	expected := llm.TokenUsage{
+		InputTokens:       120,
+		OutputTokens:      30,
+		CachedInputTokens: 100,
+		ReasoningTokens:   12,
+		TotalTokens:       150,
+	}
+	if record.Usage != expected {
+		t.Errorf("Expected usage %+v, got %+v", expected, record.Usage)
+	}
+}
+
+// barrierTool blocks until all of the tools sharing its barrier have started
+type barrierTool struct {
+	mockTool
+	started *sync.WaitGroup
+}
+
+func (b *barrierTool) Execute(ctx context.Context, args string) (string, error) {
+	b.started.Done()
+	done := make(chan struct{})
+	go func() {
+		b.started.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		return "", fmt.Errorf("%s ran alone", b.name)
+	}
+	return b.mockTool.Execute(ctx, args)
+}
+
+func TestGenerateWithToolsRunsToolCallsConcurrently(t *testing.T) {
+	var toolMessages []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody struct {
+			Messages []map[string]interface{} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		for _, msg := range reqBody.Messages {
+			if msg["role"] == "tool" {
+				toolMessages = append(toolMessages, msg)
+			}
+		}
+		if len(toolMessages) > 0 {
+			_, _ = w.Write([]byte(`{"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "all done"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant", "content": "", "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "tool_a", "arguments": "{\"n\": 1}"}},
+			{"id": "call_2", "type": "function", "function": {"name": "tool_b", "arguments": "{\"n\": 2}"}},
+			{"id": "call_3", "type": "function", "function": {"name": "tool_c", "arguments": "{\"n\": 3}"}}
+		]}}]}`))
+	}))
+	defer server.Close()
+
+	client := openai_client.NewClient("test-key",
+		openai_client.WithModel("gpt-4o"),
+		openai_client.WithLogger(logging.New()),
+	)
+	client.ChatService = openai.NewChatService(
+		option.WithAPIKey("test-key"),
+		option.WithBaseURL(server.URL),
+	)
+
+	started := &sync.WaitGroup{}
+	started.Add(3)
+	tools := []interfaces.Tool{
+		&barrierTool{mockTool: mockTool{name: "tool_a"}, started: started},
+		&barrierTool{mockTool: mockTool{name: "tool_b"}, started: started},
+		&barrierTool{mockTool: mockTool{name: "tool_c"}, started: started},
+	}
+
+	resp, err := client.GenerateWithTools(context.Background(), "run all tools", tools,
+		interfaces.WithParallelToolCalls(3),
+	)
+	if err != nil {
+		t.Fatalf("Failed to generate with tools: %v", err)
+	}
+	if resp != "all done" {
+		t.Errorf("Unexpected response: %q", resp)
+	}
+
+	if len(toolMessages) != 3 {
+		t.Fatalf("Expected 3 tool messages, got %d", len(toolMessages))
+	}
+	for i, msg := range toolMessages {
+		expectedID := fmt.Sprintf("call_%d", i+1)
+		if msg["tool_call_id"] != expectedID {
+			t.Errorf("Tool message %d: expected tool_call_id %s, got %v", i, expectedID, msg["tool_call_id"])
+		}
+		if content, _ := msg["content"].(string); strings.HasPrefix(content, "Error") {
+			t.Errorf("Tool message %d reported an error: %s", i, content)
+		}
+	}
+}
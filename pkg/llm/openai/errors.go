@@ -0,0 +1,28 @@
+package openai
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/openai/openai-go/v2"
+)
+
+// classifyError converts OpenAI API errors to an *interfaces.ProviderError
+// carrying the status, error code and Retry-After of the response
+func (c *OpenAIClient) classifyError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return llm.ClassifyError(c.Name(), err)
+	}
+
+	var header http.Header
+	if apiErr.Response != nil {
+		header = apiErr.Response.Header
+	}
+	code := apiErr.Code
+	if code == "" {
+		code = apiErr.Type
+	}
+	return llm.NewProviderError(c.Name(), apiErr.StatusCode, code, apiErr.Message, header, err)
+}
@@ -2,6 +2,7 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
@@ -65,7 +66,7 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			messages := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			messages := builder.buildMessages(context.Background(), tt.prompt, &interfaces.GenerateOptions{Memory: tt.memory})
 			if len(messages) != tt.expected {
 				t.Errorf("Expected %d messages, got %d", tt.expected, len(messages))
 			}
@@ -91,3 +92,66 @@ func (m *mockMemory) Clear(ctx context.Context) error {
 	m.messages = []interfaces.Message{}
 	return nil
 }
+
+func TestMessageHistoryBuilder_ContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New())
+	params := &interfaces.GenerateOptions{
+		ContentParts: []interfaces.ContentPart{
+			interfaces.ImagePart([]byte("png"), "image/png"),
+			interfaces.FilePart([]byte("pdf"), "application/pdf", "scan.pdf"),
+			interfaces.AudioPart([]byte("wav"), "audio/wav"),
+		},
+	}
+
+	messages := builder.buildMessages(context.Background(), "Describe these", params)
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	data, err := json.Marshal(messages[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+
+	var message struct {
+		Content []map[string]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	if len(message.Content) != 4 {
+		t.Fatalf("Expected 4 content parts, got %d: %s", len(message.Content), data)
+	}
+	for i, expected := range []string{"text", "image_url", "file", "input_audio"} {
+		if message.Content[i]["type"] != expected {
+			t.Errorf("Expected part %d to be %s, got %v", i, expected, message.Content[i]["type"])
+		}
+	}
+	imageURL := message.Content[1]["image_url"].(map[string]interface{})["url"]
+	if imageURL != "data:image/png;base64,cG5n" {
+		t.Errorf("Unexpected image URL: %v", imageURL)
+	}
+	if filename := message.Content[2]["file"].(map[string]interface{})["filename"]; filename != "scan.pdf" {
+		t.Errorf("Unexpected file name: %v", filename)
+	}
+}
+
+func TestMessageHistoryBuilder_MemoryContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New())
+	memory := &mockMemory{messages: []interfaces.Message{{
+		Role:    interfaces.MessageRoleUser,
+		Content: "What is in the picture?",
+		Parts: []interfaces.ContentPart{
+			interfaces.TextPart("What is in the picture?"),
+			interfaces.ImageURLPart("https://example.com/cat.jpg"),
+		},
+	}}}
+
+	messages := builder.buildMessages(context.Background(), "ignored", &interfaces.GenerateOptions{Memory: memory})
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	parts := messages[0].OfUser.Content.OfArrayOfContentParts
+	if len(parts) != 2 || parts[1].OfImageURL == nil || parts[1].OfImageURL.ImageURL.URL != "https://example.com/cat.jpg" {
+		t.Errorf("Unexpected content parts: %+v", parts)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/shared"
@@ -52,7 +53,7 @@ func (c *OpenAIClient) GenerateStream(
 
 		// Build messages using unified builder
 		builder := newMessageHistoryBuilder(c.logger)
-		messages := builder.buildMessages(ctx, prompt, params.Memory)
+		messages := builder.buildMessages(ctx, prompt, params)
 
 		// Create stream request
 		streamParams := openai.ChatCompletionNewParams{
@@ -80,13 +81,13 @@ func (c *OpenAIClient) GenerateStream(
 			}
 		}
 
+		// Request a final usage chunk so token usage can be reported
+		streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		}
+
 		// Handle reasoning models and reasoning config
 		if isReasoningModel(c.Model) || (params.LLMConfig != nil && params.LLMConfig.EnableReasoning) {
-			// o1 models or reasoning enabled - ensure we get usage info
-			streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
-				IncludeUsage: openai.Bool(true),
-			}
-
 			// Log reasoning support
 			if isReasoningModel(c.Model) {
 				c.logger.Debug(ctx, "Using reasoning model with built-in reasoning", map[string]interface{}{
@@ -204,6 +205,7 @@ func (c *OpenAIClient) GenerateStream(
 
 			// Handle usage information (especially for o1 models)
 			if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 || chunk.Usage.TotalTokens > 0 {
+				c.recordUsage(ctx, chunk.Model, chunk.Usage, 0)
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventContentDelta,
 					Timestamp: time.Now(),
@@ -212,6 +214,7 @@ func (c *OpenAIClient) GenerateStream(
 							"prompt_tokens":     chunk.Usage.PromptTokens,
 							"completion_tokens": chunk.Usage.CompletionTokens,
 							"total_tokens":      chunk.Usage.TotalTokens,
+							"cached_tokens":     chunk.Usage.PromptTokensDetails.CachedTokens,
 						},
 					},
 				}
@@ -226,7 +229,7 @@ func (c *OpenAIClient) GenerateStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("openai streaming error: %w", err),
+				Error:     fmt.Errorf("openai streaming error: %w", c.classifyError(err)),
 				Timestamp: time.Now(),
 			}
 			return
@@ -330,7 +333,7 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 
 		// Build messages using unified builder
 		builder := newMessageHistoryBuilder(c.logger)
-		messages := builder.buildMessages(ctx, prompt, params.Memory)
+		messages := builder.buildMessages(ctx, prompt, params)
 
 		// Store initial messages in memory
 		if params.Memory != nil {
@@ -371,7 +374,7 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 				Model:      openai.ChatModel(c.Model),
 				Messages:   messages,
 				Tools:      openaiTools,
-				ToolChoice: openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("auto")},
+				ToolChoice: toolChoiceParam(params.ToolChoice.ForTurn(iteration + 1)),
 			}
 
 			// Reasoning models only support temperature=1 (default), so don't set it
@@ -379,12 +382,13 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 				streamParams.Temperature = openai.Float(params.LLMConfig.Temperature)
 			}
 
+			// Request a final usage chunk so token usage can be reported
+			streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+				IncludeUsage: openai.Bool(true),
+			}
+
 			// Handle reasoning models
 			if isReasoningModel(c.Model) || (params.LLMConfig != nil && params.LLMConfig.EnableReasoning) {
-				streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
-					IncludeUsage: openai.Bool(true),
-				}
-
 				if isReasoningModel(c.Model) {
 					c.logger.Debug(ctx, "Using reasoning model with built-in reasoning for tools", map[string]interface{}{
 						"model": c.Model,
@@ -448,7 +452,7 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 				})
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("openai streaming error: %w", stream.Err()),
+					Error:     fmt.Errorf("openai streaming error: %w", c.classifyError(stream.Err())),
 					Timestamp: time.Now(),
 				}
 				return
@@ -463,6 +467,9 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 			// Process stream chunks
 			for stream.Next() {
 				chunk := stream.Current()
+				if chunk.Usage.TotalTokens > 0 {
+					c.recordUsage(ctx, chunk.Model, chunk.Usage, iteration+1)
+				}
 
 				for _, choice := range chunk.Choices {
 					// Handle content
@@ -574,7 +581,7 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 				})
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("openai streaming error: %w", err),
+					Error:     fmt.Errorf("openai streaming error: %w", c.classifyError(err)),
 					Timestamp: time.Now(),
 				}
 				return
@@ -616,26 +623,26 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 			assistantResponse.Role = "assistant"
 			messages = append(messages, assistantResponse.ToParam())
 
-			// Process each tool call
-			for _, toolCall := range assistantResponse.ToolCalls {
-				// Find the matching tool
-				var foundTool interfaces.Tool
-				for _, tool := range tools {
-					if tool.Name() == toolCall.Function.Name {
-						foundTool = tool
-						break
-					}
+			// Execute the requested tools, concurrently if enabled
+			executions := make([]*llm.ToolExecution, len(assistantResponse.ToolCalls))
+			for i, toolCall := range assistantResponse.ToolCalls {
+				executions[i] = &llm.ToolExecution{
+					Tool:      llm.FindTool(tools, toolCall.Function.Name),
+					Arguments: toolCall.Function.Arguments,
 				}
+			}
+			llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
 
-				if foundTool == nil {
+			// Process each tool call in the order the model requested them
+			for i, toolCall := range assistantResponse.ToolCalls {
+				if executions[i].Tool == nil {
 					c.logger.Error(ctx, "Tool not found", map[string]interface{}{
 						"tool_name": toolCall.Function.Name,
 					})
 					continue
 				}
 
-				// Execute the tool
-				result, err := foundTool.Execute(ctx, toolCall.Function.Arguments)
+				result, err := executions[i].Result, executions[i].Err
 				if err != nil {
 					c.logger.Error(ctx, "Tool execution error", map[string]interface{}{
 						"tool_name": toolCall.Function.Name,
@@ -755,6 +762,9 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 		finalStreamParams := openai.ChatCompletionNewParams{
 			Model:    openai.ChatModel(c.Model),
 			Messages: finalMessages,
+			StreamOptions: openai.ChatCompletionStreamOptionsParam{
+				IncludeUsage: openai.Bool(true),
+			},
 		}
 
 		// Reasoning models only support temperature=1 (default), so don't set it
@@ -793,7 +803,7 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("openai final streaming error: %w", finalStream.Err()),
+				Error:     fmt.Errorf("openai final streaming error: %w", c.classifyError(finalStream.Err())),
 				Timestamp: time.Now(),
 			}
 			return
@@ -805,6 +815,9 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 		// Process final stream
 		for finalStream.Next() {
 			chunk := finalStream.Current()
+			if chunk.Usage.TotalTokens > 0 {
+				c.recordUsage(ctx, chunk.Model, chunk.Usage, maxIterations+1)
+			}
 
 			for _, choice := range chunk.Choices {
 				// Handle final content
@@ -844,7 +857,7 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("openai final streaming error: %w", err),
+				Error:     fmt.Errorf("openai final streaming error: %w", c.classifyError(err)),
 				Timestamp: time.Now(),
 			}
 			return
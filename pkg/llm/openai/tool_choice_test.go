@@ -0,0 +1,30 @@
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestToolChoiceParam(t *testing.T) {
+	function := interfaces.ToolChoiceFunction("extract_invoice")
+	tests := []struct {
+		choice   *interfaces.ToolChoice
+		expected string
+	}{
+		{nil, `"auto"`},
+		{&interfaces.ToolChoiceNone, `"none"`},
+		{&interfaces.ToolChoiceRequired, `"required"`},
+		{&function, `{"function":{"name":"extract_invoice"},"type":"function"}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(toolChoiceParam(tt.choice))
+		if err != nil {
+			t.Fatalf("Failed to marshal tool choice: %v", err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, data)
+		}
+	}
+}
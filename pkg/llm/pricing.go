@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelPrice holds the price of a model in USD per million tokens
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million" yaml:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million" yaml:"output_per_million"`
+	// CachedInputPerMillion is charged for prompt tokens read from cache.
+	// When zero, cached tokens are charged at the input price.
+	CachedInputPerMillion float64 `json:"cached_input_per_million,omitempty" yaml:"cached_input_per_million,omitempty"`
+	// CacheWritePerMillion is charged for prompt tokens written to cache.
+	// When zero, cache writes are charged at the input price.
+	CacheWritePerMillion float64 `json:"cache_write_per_million,omitempty" yaml:"cache_write_per_million,omitempty"`
+}
+
+// Cost returns the cost in USD of the given usage
+func (p ModelPrice) Cost(usage TokenUsage) float64 {
+	cachedPrice := p.CachedInputPerMillion
+	if cachedPrice == 0 {
+		cachedPrice = p.InputPerMillion
+	}
+	writePrice := p.CacheWritePerMillion
+	if writePrice == 0 {
+		writePrice = p.InputPerMillion
+	}
+
+	uncached := usage.InputTokens - usage.CachedInputTokens - usage.CacheCreationTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+
+	cost := float64(uncached) * p.InputPerMillion
+	cost += float64(usage.CachedInputTokens) * cachedPrice
+	cost += float64(usage.CacheCreationTokens) * writePrice
+	cost += float64(usage.OutputTokens) * p.OutputPerMillion
+	return cost / 1_000_000
+}
+
+// PriceTable maps model names to prices. Lookups match the exact model name
+// first and then the longest registered prefix, so an entry for "gpt-4o" also
+// prices "gpt-4o-2024-08-06". It is safe for concurrent use.
+type PriceTable struct {
+	mu     sync.RWMutex
+	prices map[string]ModelPrice
+}
+
+// NewPriceTable creates a price table from the given prices
+func NewPriceTable(prices map[string]ModelPrice) *PriceTable {
+	table := &PriceTable{prices: make(map[string]ModelPrice, len(prices))}
+	for model, price := range prices {
+		table.prices[model] = price
+	}
+	return table
+}
+
+// Set adds or replaces the price of a model
+func (t *PriceTable) Set(model string, price ModelPrice) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.prices == nil {
+		t.prices = make(map[string]ModelPrice)
+	}
+	t.prices[model] = price
+}
+
+// Lookup returns the price for a model
+func (t *PriceTable) Lookup(model string) (ModelPrice, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if price, ok := t.prices[model]; ok {
+		return price, true
+	}
+
+	bestLen := 0
+	var best ModelPrice
+	for prefix, price := range t.prices {
+		if len(prefix) > bestLen && strings.HasPrefix(model, prefix) {
+			best = price
+			bestLen = len(prefix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Cost returns the cost in USD of the given usage for a model, and false if
+// the model has no price
+func (t *PriceTable) Cost(model string, usage TokenUsage) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(usage), true
+}
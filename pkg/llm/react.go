@@ -0,0 +1,301 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/logging"
+)
+
+// ToolCallingMode selects how a client lets the model call tools
+type ToolCallingMode string
+
+const (
+	// ToolCallingAuto uses the server's native tool calling and falls back to
+	// ReAct when the server or model rejects tools
+	ToolCallingAuto ToolCallingMode = "auto"
+	// ToolCallingNative only uses the server's native tool calling
+	ToolCallingNative ToolCallingMode = "native"
+	// ToolCallingReAct describes the tools in the prompt and parses tool calls
+	// from the model's text, for models without native function calling
+	ToolCallingReAct ToolCallingMode = "react"
+)
+
+// ResolveToolCallingMode returns ToolCallingReAct for ToolCallingAuto when
+// LookupModel knows that the model has no native tool calling, and the mode
+// as it is otherwise
+func ResolveToolCallingMode(mode ToolCallingMode, model string) ToolCallingMode {
+	if mode == ToolCallingAuto {
+		if info, ok := LookupModel(model); ok && !info.SupportsTools {
+			return ToolCallingReAct
+		}
+	}
+	return mode
+}
+
+// ReActStep is one parsed reply of a model following the ReAct format
+type ReActStep struct {
+	Thought     string
+	Action      string
+	ActionInput string
+	FinalAnswer string
+}
+
+// IsAction reports whether the model asked to call a tool
+func (s ReActStep) IsAction() bool {
+	return s.Action != ""
+}
+
+// ReActRequest is one model call made by the ReAct loop
+type ReActRequest struct {
+	Messages      []Message
+	StopSequences []string
+	// Iteration is the 1-based iteration of the loop
+	Iteration int
+}
+
+// ReActChatFunc sends a ReAct request to the model and returns its reply
+type ReActChatFunc func(ctx context.Context, req ReActRequest) (string, error)
+
+const (
+	reactActionPrefix      = "Action:"
+	reactActionInputPrefix = "Action Input:"
+	reactFinalAnswerPrefix = "Final Answer:"
+	reactThoughtPrefix     = "Thought:"
+	reactObservationPrefix = "Observation:"
+)
+
+// BuildReActPrompt returns the system prompt that teaches a model the ReAct
+// format for the given tools
+func BuildReActPrompt(tools []interfaces.Tool) string {
+	var sb strings.Builder
+	sb.WriteString("You have access to the following tools:\n\n")
+
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name())
+		schema, err := json.Marshal(ToolParametersSchema(tool))
+		if err != nil {
+			schema = []byte("{}")
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s\nParameters: %s\n\n", tool.Name(), tool.Description(), schema))
+	}
+
+	sb.WriteString("To use a tool, respond with exactly this format and nothing after it:\n")
+	sb.WriteString(reactThoughtPrefix + " <your reasoning>\n")
+	sb.WriteString(fmt.Sprintf("%s <one of [%s]>\n", reactActionPrefix, strings.Join(names, ", ")))
+	sb.WriteString(reactActionInputPrefix + " <the tool arguments as a JSON object>\n\n")
+	sb.WriteString("You will then receive the result of the tool as:\n")
+	sb.WriteString(reactObservationPrefix + " <tool result>\n\n")
+	sb.WriteString("When you have enough information to answer, respond with:\n")
+	sb.WriteString(reactThoughtPrefix + " <your reasoning>\n")
+	sb.WriteString(reactFinalAnswerPrefix + " <your answer to the user>")
+
+	return sb.String()
+}
+
+// ParseReActResponse parses a model reply in the ReAct format. A reply that
+// contains neither an action nor a final answer is treated as the final answer.
+func ParseReActResponse(text string) ReActStep {
+	text = trimObservation(text)
+
+	step := ReActStep{}
+	actionIdx := strings.Index(text, reactActionPrefix)
+	finalIdx := strings.Index(text, reactFinalAnswerPrefix)
+
+	if thoughtIdx := strings.Index(text, reactThoughtPrefix); thoughtIdx >= 0 {
+		end := len(text)
+		for _, idx := range []int{actionIdx, finalIdx} {
+			if idx > thoughtIdx && idx < end {
+				end = idx
+			}
+		}
+		step.Thought = strings.TrimSpace(text[thoughtIdx+len(reactThoughtPrefix) : end])
+	}
+
+	if actionIdx >= 0 && (finalIdx < 0 || actionIdx < finalIdx) {
+		rest := text[actionIdx+len(reactActionPrefix):]
+		inputIdx := strings.Index(rest, reactActionInputPrefix)
+		if inputIdx < 0 {
+			step.Action = strings.TrimSpace(firstLine(rest))
+			step.ActionInput = "{}"
+			return step
+		}
+		step.Action = strings.TrimSpace(rest[:inputIdx])
+		step.ActionInput = trimCodeFence(rest[inputIdx+len(reactActionInputPrefix):])
+		if step.ActionInput == "" {
+			step.ActionInput = "{}"
+		}
+		return step
+	}
+
+	if finalIdx >= 0 {
+		step.FinalAnswer = strings.TrimSpace(text[finalIdx+len(reactFinalAnswerPrefix):])
+		return step
+	}
+
+	step.FinalAnswer = strings.TrimSpace(text)
+	return step
+}
+
+// RunReActLoop runs a text-based tool loop for models without native function
+// calling. Each iteration asks the model for a thought and either a tool call
+// or a final answer; tool results are fed back as observations. When
+// params.MaxIterations is reached the model is asked for a final answer.
+// Tool calls and results are stored in params.Memory if one is set.
+func RunReActLoop(ctx context.Context, chat ReActChatFunc, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions, logger logging.Logger) (string, error) {
+	maxIterations := params.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = 2
+	}
+
+	systemPrompt := BuildReActPrompt(tools)
+	if params.SystemMessage != "" {
+		systemPrompt = params.SystemMessage + "\n\n" + systemPrompt
+	}
+	messages := []Message{{Role: "system", Content: systemPrompt}}
+	messages = append(messages, reactHistory(ctx, prompt, params.Memory, logger)...)
+
+	stop := append([]string{"\n" + reactObservationPrefix}, stopSequences(params)...)
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		reply, err := chat(ctx, ReActRequest{Messages: messages, StopSequences: stop, Iteration: iteration + 1})
+		if err != nil {
+			return "", err
+		}
+
+		step := ParseReActResponse(reply)
+		if !step.IsAction() {
+			return step.FinalAnswer, nil
+		}
+
+		logger.Info(ctx, "Executing tool from ReAct response", map[string]interface{}{
+			"toolName":  step.Action,
+			"iteration": iteration + 1,
+		})
+
+		callID := fmt.Sprintf("react_%d", iteration+1)
+		observation := executeReActStep(ctx, step, tools, callID, params.Memory)
+
+		messages = append(messages,
+			Message{Role: "assistant", Content: strings.TrimSpace(trimObservation(reply))},
+			Message{Role: "user", Content: reactObservationPrefix + " " + observation},
+		)
+	}
+
+	logger.Info(ctx, "Maximum iterations reached, asking for a final answer", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+	messages = append(messages, Message{
+		Role:    "user",
+		Content: "You have used the maximum number of tool calls. Respond now with your " + reactFinalAnswerPrefix + " based on the observations so far.",
+	})
+	reply, err := chat(ctx, ReActRequest{Messages: messages, StopSequences: stop, Iteration: maxIterations + 1})
+	if err != nil {
+		return "", err
+	}
+
+	step := ParseReActResponse(reply)
+	if step.FinalAnswer == "" {
+		return strings.TrimSpace(reply), nil
+	}
+	return step.FinalAnswer, nil
+}
+
+// executeReActStep runs the tool requested in a ReAct step and returns the
+// observation to feed back to the model
+func executeReActStep(ctx context.Context, step ReActStep, tools []interfaces.Tool, callID string, memory interfaces.Memory) string {
+	execution := &ToolExecution{Tool: FindTool(tools, step.Action), Arguments: step.ActionInput}
+	ExecuteTools(ctx, []*ToolExecution{execution}, 1)
+
+	call := interfaces.ToolCall{ID: callID, Name: step.Action, Arguments: step.ActionInput}
+	return RecordToolExecution(ctx, call, execution, memory)
+}
+
+// reactHistory converts the conversation in memory to plain chat messages. As
+// with the native clients, the prompt is only appended when there is no memory
+// because the agent has already stored it.
+func reactHistory(ctx context.Context, prompt string, memory interfaces.Memory, logger logging.Logger) []Message {
+	if memory == nil {
+		return []Message{{Role: "user", Content: prompt}}
+	}
+
+	memoryMessages, err := memory.GetMessages(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve memory messages", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return []Message{{Role: "user", Content: prompt}}
+	}
+
+	var messages []Message
+	for _, msg := range memoryMessages {
+		switch msg.Role {
+		case interfaces.MessageRoleUser, interfaces.MessageRoleSystem:
+			messages = append(messages, Message{Role: string(msg.Role), Content: msg.Content})
+		case interfaces.MessageRoleAssistant:
+			if len(msg.ToolCalls) > 0 {
+				var content string
+				if msg.Content != "" {
+					content = reactThoughtPrefix + " " + msg.Content + "\n"
+				}
+				call := msg.ToolCalls[0]
+				content += fmt.Sprintf("%s %s\n%s %s", reactActionPrefix, call.Name, reactActionInputPrefix, call.Arguments)
+				messages = append(messages, Message{Role: "assistant", Content: content})
+			} else if msg.Content != "" {
+				messages = append(messages, Message{Role: "assistant", Content: msg.Content})
+			}
+		case interfaces.MessageRoleTool:
+			messages = append(messages, Message{Role: "user", Content: reactObservationPrefix + " " + msg.Content})
+		}
+	}
+	if len(messages) == 0 {
+		messages = append(messages, Message{Role: "user", Content: prompt})
+	}
+	return messages
+}
+
+// stopSequences returns the stop sequences configured in the options
+func stopSequences(params *interfaces.GenerateOptions) []string {
+	if params.LLMConfig == nil {
+		return nil
+	}
+	return params.LLMConfig.StopSequences
+}
+
+// trimObservation drops any observation the model made up after its tool call
+func trimObservation(text string) string {
+	if idx := strings.Index(text, "\n"+reactObservationPrefix); idx >= 0 {
+		return text[:idx]
+	}
+	return text
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// trimCodeFence strips whitespace and a surrounding markdown code fence
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if idx := strings.Index(s, "\n"); idx >= 0 {
+		s = s[idx+1:]
+	}
+	if idx := strings.LastIndex(s, "```"); idx >= 0 {
+		s = s[:idx]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReActResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected ReActStep
+	}{
+		{
+			name: "action with input",
+			text: "Thought: I need the weather\nAction: weather\nAction Input: {\"city\": \"Paris\"}",
+			expected: ReActStep{
+				Thought:     "I need the weather",
+				Action:      "weather",
+				ActionInput: `{"city": "Paris"}`,
+			},
+		},
+		{
+			name: "input in a code fence and an invented observation",
+			text: "Action: search\nAction Input: ```json\n{\"q\": \"go\"}\n```\nObservation: lots of results",
+			expected: ReActStep{
+				Action:      "search",
+				ActionInput: `{"q": "go"}`,
+			},
+		},
+		{
+			name:     "action without input",
+			text:     "Action: now\nsomething else",
+			expected: ReActStep{Action: "now", ActionInput: "{}"},
+		},
+		{
+			name: "final answer",
+			text: "Thought: I know this\nFinal Answer: 42",
+			expected: ReActStep{
+				Thought:     "I know this",
+				FinalAnswer: "42",
+			},
+		},
+		{
+			name:     "plain text is the final answer",
+			text:     "  Just an answer.  ",
+			expected: ReActStep{FinalAnswer: "Just an answer."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := ParseReActResponse(tt.text)
+			assert.Equal(t, tt.expected, step)
+			assert.Equal(t, tt.expected.Action != "", step.IsAction())
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// CircuitState is the state of a provider's circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed means the provider receives requests
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen means the provider is skipped until its cooldown has passed
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen means a single probe request is let through
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// provider is a Provider with its health
+type provider struct {
+	name    string
+	llm     interfaces.LLM
+	weight  int
+	standby bool
+	breaker breaker
+}
+
+// breaker is a consecutive-failure circuit breaker
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether a request may be sent. Once the cooldown has passed,
+// it lets a single probe request through until its outcome is recorded.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success closes the circuit
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+}
+
+// failure records a failed request and reports whether it opened the circuit
+func (b *breaker) failure(now time.Time, threshold int, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if threshold <= 0 || (b.failures < threshold && !b.probing) {
+		return false
+	}
+	b.openUntil = now.Add(cooldown)
+	b.probing = false
+	return true
+}
+
+// release gives up a probe without an outcome, e.g. when the caller cancelled
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// snapshot returns the breaker's state at the given time
+func (b *breaker) snapshot(name string, now time.Time) ProviderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := ProviderHealth{
+		Name:                name,
+		State:               CircuitClosed,
+		ConsecutiveFailures: b.failures,
+		OpenUntil:           b.openUntil,
+	}
+	switch {
+	case b.openUntil.IsZero():
+	case now.Before(b.openUntil):
+		health.State = CircuitOpen
+	default:
+		health.State = CircuitHalfOpen
+	}
+	return health
+}
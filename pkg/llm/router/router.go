@@ -0,0 +1,365 @@
+// Package router provides an LLM that spreads requests over several providers
+// with ordered fallback, weighted load balancing, per-provider circuit breakers
+// and error-based routing rules. A Client implements interfaces.StreamingLLM
+// and can be passed to agent.WithLLM like any single provider.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/andmang/agent-sdk-go/pkg/logging"
+)
+
+// ErrNoProviderAvailable is returned when every provider's circuit is open
+var ErrNoProviderAvailable = errors.New("no LLM provider available")
+
+// Strategy decides the order in which providers are tried
+type Strategy string
+
+const (
+	// StrategyFailover tries providers in the order they were given
+	StrategyFailover Strategy = "failover"
+	// StrategyWeighted spreads requests over providers in proportion to their
+	// weights, falling back to the others in weighted random order
+	StrategyWeighted Strategy = "weighted"
+)
+
+// Provider is one LLM the router can send requests to
+type Provider struct {
+	// Name identifies the provider in rules, logs and Health. Defaults to the
+	// LLM's name, which must then be unique.
+	Name string
+	LLM  interfaces.LLM
+	// Weight is the share of requests sent to the provider with StrategyWeighted (default 1)
+	Weight int
+	// Standby providers are only used when a rule routes a request to them,
+	// for example a larger-context model that is too expensive for every request
+	Standby bool
+}
+
+// Rule routes a failed request to a specific provider. Errors matched by a
+// rule describe the request rather than the provider, so they do not count
+// against the failing provider's health.
+type Rule struct {
+	// When reports whether the rule applies to the error
+	When func(err error) bool
+	// Use is the name of the provider to try next
+	Use string
+}
+
+// OnContextLengthExceeded returns a rule that retries requests that do not
+// fit in a model's context window on the named provider
+func OnContextLengthExceeded(provider string) Rule {
+	return Rule{When: IsContextLengthError, Use: provider}
+}
+
+// Client routes LLM requests over several providers
+type Client struct {
+	providers        []*provider
+	byName           map[string]*provider
+	strategy         Strategy
+	rules            []Rule
+	failureThreshold int
+	cooldown         time.Duration
+	shouldFailover   func(err error) bool
+	logger           logging.Logger
+
+	// Overridable in tests
+	now    func() time.Time
+	random func() float64
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithStrategy sets how providers are ordered (default StrategyFailover)
+func WithStrategy(strategy Strategy) Option {
+	return func(c *Client) {
+		c.strategy = strategy
+	}
+}
+
+// WithRule adds a routing rule. Rules are evaluated in the order they were added.
+func WithRule(rule Rule) Option {
+	return func(c *Client) {
+		c.rules = append(c.rules, rule)
+	}
+}
+
+// WithCircuitBreaker opens a provider's circuit after failureThreshold
+// consecutive rate limits, server errors, timeouts or network failures. The provider is skipped until the cooldown has passed,
+// after which a single request is let through to probe it.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.failureThreshold = failureThreshold
+		c.cooldown = cooldown
+	}
+}
+
+// WithFailoverCondition sets which errors make the router try the next
+// provider. By default only rate limits, server errors, timeouts and network
+// failures do (see interfaces.IsRetryableError). Errors never fail over once
+// the caller's context is done.
+func WithFailoverCondition(shouldFailover func(err error) bool) Option {
+	return func(c *Client) {
+		c.shouldFailover = shouldFailover
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger logging.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient creates a router over the given providers
+func NewClient(providers []Provider, options ...Option) (*Client, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one provider is required")
+	}
+
+	c := &Client{
+		byName:           make(map[string]*provider, len(providers)),
+		strategy:         StrategyFailover,
+		failureThreshold: 5,
+		cooldown:         30 * time.Second,
+		shouldFailover:   interfaces.IsRetryableError,
+		logger:           logging.New(),
+		now:              time.Now,
+		random:           rand.Float64,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	for i, p := range providers {
+		if p.LLM == nil {
+			return nil, fmt.Errorf("provider %d has no LLM", i)
+		}
+		name := p.Name
+		if name == "" {
+			name = p.LLM.Name()
+		}
+		if _, exists := c.byName[name]; exists {
+			return nil, fmt.Errorf("duplicate provider name %q", name)
+		}
+		weight := p.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		entry := &provider{name: name, llm: p.LLM, weight: weight, standby: p.Standby}
+		c.providers = append(c.providers, entry)
+		c.byName[name] = entry
+	}
+
+	for _, rule := range c.rules {
+		if rule.When == nil {
+			return nil, fmt.Errorf("rule for provider %q has no condition", rule.Use)
+		}
+		if _, ok := c.byName[rule.Use]; !ok {
+			return nil, fmt.Errorf("rule refers to unknown provider %q", rule.Use)
+		}
+	}
+
+	return c, nil
+}
+
+// Generate implements interfaces.LLM.Generate
+func (c *Client) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	var response string
+	err := c.route(ctx, func(ctx context.Context, p *provider) error {
+		var err error
+		response, err = p.llm.Generate(ctx, prompt, options...)
+		return err
+	})
+	return response, err
+}
+
+// GenerateWithTools implements interfaces.LLM.GenerateWithTools. A provider
+// that fails after executing tools is not rolled back, so the next provider
+// may run the same tools again.
+func (c *Client) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	var response string
+	err := c.route(ctx, func(ctx context.Context, p *provider) error {
+		var err error
+		response, err = p.llm.GenerateWithTools(ctx, prompt, tools, options...)
+		return err
+	})
+	return response, err
+}
+
+// ChatMessages implements interfaces.ChatLLM.ChatMessages. Providers that do
+// not implement interfaces.ChatLLM are sent the conversation with llm.Chat.
+func (c *Client) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	var response string
+	err := c.route(ctx, func(ctx context.Context, p *provider) error {
+		var err error
+		response, err = llm.Chat(ctx, p.llm, messages, options...)
+		return err
+	})
+	return response, err
+}
+
+// Name implements interfaces.LLM.Name
+func (c *Client) Name() string {
+	return "router"
+}
+
+// SupportsStreaming implements interfaces.LLM.SupportsStreaming. Providers
+// that cannot stream are adapted, so the router always supports it.
+func (c *Client) SupportsStreaming() bool {
+	return true
+}
+
+// ProviderHealth is a snapshot of a provider's circuit breaker
+type ProviderHealth struct {
+	Name                string
+	State               CircuitState
+	ConsecutiveFailures int
+	// OpenUntil is when an open circuit lets the next probe request through
+	OpenUntil time.Time
+}
+
+// Health returns the health of each provider in the order they were given
+func (c *Client) Health() []ProviderHealth {
+	health := make([]ProviderHealth, len(c.providers))
+	for i, p := range c.providers {
+		health[i] = p.breaker.snapshot(p.name, c.now())
+	}
+	return health
+}
+
+// route calls the providers in order until one succeeds
+func (c *Client) route(ctx context.Context, call func(ctx context.Context, p *provider) error) error {
+	order := c.order()
+	tried := make(map[*provider]bool, len(c.providers))
+	var errs []error
+	var next *provider
+
+	for {
+		p := next
+		next = nil
+		if p == nil || !p.breaker.allow(c.now()) {
+			p = c.nextAvailable(order, tried)
+		}
+		if p == nil {
+			break
+		}
+		tried[p] = true
+
+		err := call(ctx, p)
+		if err == nil {
+			p.breaker.success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			p.breaker.release()
+			return err
+		}
+		var committed *committedError
+		if errors.As(err, &committed) {
+			// The provider already streamed part of the response
+			c.recordError(ctx, p, err)
+			return committed.err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+
+		// A rule match means the provider answered but could not handle this
+		// request, so it counts as healthy
+		next = c.matchRule(err, tried)
+		if next != nil {
+			p.breaker.success()
+		} else {
+			c.recordError(ctx, p, err)
+			if !c.shouldFailover(err) {
+				return err
+			}
+		}
+
+		c.logger.Warn(ctx, "LLM provider failed, trying next provider", map[string]interface{}{
+			"provider": p.name,
+			"error":    err.Error(),
+		})
+	}
+
+	if len(errs) == 0 {
+		return ErrNoProviderAvailable
+	}
+	return fmt.Errorf("all LLM providers failed: %w", errors.Join(errs...))
+}
+
+// order returns the non-standby providers in the order they should be tried
+func (c *Client) order() []*provider {
+	var order []*provider
+	for _, p := range c.providers {
+		if !p.standby {
+			order = append(order, p)
+		}
+	}
+	if c.strategy != StrategyWeighted {
+		return order
+	}
+
+	// Weighted random permutation: sorting by u^(1/w) picks each provider
+	// first with probability proportional to its weight
+	keys := make(map[*provider]float64, len(order))
+	for _, p := range order {
+		keys[p] = math.Pow(c.random(), 1/float64(p.weight))
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]] > keys[order[j]]
+	})
+	return order
+}
+
+// nextAvailable returns the first untried provider whose circuit allows a request
+func (c *Client) nextAvailable(order []*provider, tried map[*provider]bool) *provider {
+	for _, p := range order {
+		if !tried[p] && p.breaker.allow(c.now()) {
+			return p
+		}
+	}
+	return nil
+}
+
+// matchRule returns the untried provider of the first rule matching the error
+func (c *Client) matchRule(err error, tried map[*provider]bool) *provider {
+	for _, rule := range c.rules {
+		if target := c.byName[rule.Use]; !tried[target] && rule.When(err) {
+			return target
+		}
+	}
+	return nil
+}
+
+// recordError counts an error against the provider's health if it shows the
+// provider is unhealthy: a rate limit, server error, timeout or network
+// failure. Other errors, such as invalid requests, leave its health unchanged.
+func (c *Client) recordError(ctx context.Context, p *provider, err error) {
+	if !interfaces.IsRetryableError(err) {
+		p.breaker.release()
+		return
+	}
+	if p.breaker.failure(c.now(), c.failureThreshold, c.cooldown) {
+		c.logger.Warn(ctx, "LLM provider circuit opened", map[string]interface{}{
+			"provider": p.name,
+			"cooldown": c.cooldown.String(),
+			"error":    err.Error(),
+		})
+	}
+}
+
+// IsContextLengthError reports whether the error says the request does not
+// fit in the model's context window, see llm.IsContextLengthError
+func IsContextLengthError(err error) bool {
+	return llm.IsContextLengthError(err)
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM returns its errors in turn and then its response
+type fakeLLM struct {
+	name     string
+	response string
+	errs     []error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return "", err
+	}
+	return f.response, nil
+}
+
+func (f *fakeLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return f.Generate(ctx, prompt, options...)
+}
+
+func (f *fakeLLM) Name() string            { return f.name }
+func (f *fakeLLM) SupportsStreaming() bool { return false }
+
+func (f *fakeLLM) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+var (
+	errUnavailable = &interfaces.ProviderError{Provider: "test", Kind: interfaces.ErrTransient, StatusCode: 503, Message: "overloaded"}
+	errTimeout     = &interfaces.ProviderError{Provider: "test", Kind: interfaces.ErrTransient, Err: errors.New("timeout")}
+)
+
+func TestNewClientValidation(t *testing.T) {
+	_, err := NewClient(nil)
+	assert.Error(t, err)
+
+	_, err = NewClient([]Provider{{LLM: &fakeLLM{name: "a"}}, {LLM: &fakeLLM{name: "a"}}})
+	assert.ErrorContains(t, err, "duplicate provider name")
+
+	_, err = NewClient([]Provider{{LLM: &fakeLLM{name: "a"}}}, WithRule(OnContextLengthExceeded("b")))
+	assert.ErrorContains(t, err, "unknown provider")
+}
+
+func TestFailover(t *testing.T) {
+	primary := &fakeLLM{name: "openai", errs: []error{errUnavailable}}
+	secondary := &fakeLLM{name: "anthropic", response: "from anthropic"}
+
+	client, err := NewClient([]Provider{{LLM: primary}, {LLM: secondary}})
+	require.NoError(t, err)
+
+	response, err := client.Generate(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "from anthropic", response)
+	assert.Equal(t, 1, primary.callCount())
+	assert.Equal(t, 1, client.Health()[0].ConsecutiveFailures)
+
+	// The primary is tried first again and succeeds
+	primary.response = "from openai"
+	response, err = client.GenerateWithTools(context.Background(), "hi", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "from openai", response)
+	assert.Equal(t, 0, client.Health()[0].ConsecutiveFailures)
+}
+
+func TestAllProvidersFail(t *testing.T) {
+	client, err := NewClient([]Provider{
+		{LLM: &fakeLLM{name: "a", errs: []error{errUnavailable}}},
+		{LLM: &fakeLLM{name: "b", errs: []error{errTimeout}}},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Generate(context.Background(), "hi")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.ErrorIs(t, err, errTimeout)
+}
+
+func TestFailoverOnlyOnProviderFailures(t *testing.T) {
+	invalidErr := &interfaces.ProviderError{Provider: "a", Kind: interfaces.ErrInvalidRequest, StatusCode: 400, Message: "invalid tool schema"}
+	secondary := &fakeLLM{name: "b", response: "ok"}
+	client, err := NewClient(
+		[]Provider{{LLM: &fakeLLM{name: "a", errs: []error{invalidErr, errors.New("failed to marshal request")}}}, {LLM: secondary}},
+		WithCircuitBreaker(1, time.Minute),
+	)
+	require.NoError(t, err)
+
+	// Errors about the request neither fail over nor count against the provider
+	_, err = client.Generate(context.Background(), "hi")
+	assert.Equal(t, invalidErr, err)
+	_, err = client.Generate(context.Background(), "hi")
+	assert.EqualError(t, err, "failed to marshal request")
+	assert.Equal(t, 0, secondary.callCount())
+	assert.Equal(t, CircuitClosed, client.Health()[0].State)
+	assert.Equal(t, 0, client.Health()[0].ConsecutiveFailures)
+}
+
+func TestFailoverCondition(t *testing.T) {
+	authErr := errors.New("status 401: invalid api key")
+	secondary := &fakeLLM{name: "b", response: "ok"}
+	client, err := NewClient(
+		[]Provider{{LLM: &fakeLLM{name: "a", errs: []error{authErr}}}, {LLM: secondary}},
+		WithFailoverCondition(func(err error) bool { return err != authErr }),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Generate(context.Background(), "hi")
+	assert.Equal(t, authErr, err)
+	assert.Equal(t, 0, secondary.callCount())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	primary := &fakeLLM{name: "primary", errs: []error{errUnavailable, errUnavailable, errUnavailable}, response: "primary"}
+	secondary := &fakeLLM{name: "secondary", response: "secondary"}
+
+	client, err := NewClient([]Provider{{LLM: primary}, {LLM: secondary}}, WithCircuitBreaker(2, time.Minute))
+	require.NoError(t, err)
+	client.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		response, err := client.Generate(context.Background(), "hi")
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", response)
+	}
+	// The circuit opened after two failures, so the third request skipped the primary
+	assert.Equal(t, 2, primary.callCount())
+	health := client.Health()[0]
+	assert.Equal(t, CircuitOpen, health.State)
+	assert.Equal(t, now.Add(time.Minute), health.OpenUntil)
+
+	// After the cooldown a probe is let through; it fails and reopens the circuit
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, client.Health()[0].State)
+	_, err = client.Generate(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, 3, primary.callCount())
+	assert.Equal(t, CircuitOpen, client.Health()[0].State)
+
+	// The next probe succeeds and closes the circuit
+	now = now.Add(time.Minute)
+	response, err := client.Generate(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", response)
+	assert.Equal(t, CircuitClosed, client.Health()[0].State)
+}
+
+func TestNoProviderAvailable(t *testing.T) {
+	client, err := NewClient([]Provider{{LLM: &fakeLLM{name: "a", errs: []error{errUnavailable}}}}, WithCircuitBreaker(1, time.Minute))
+	require.NoError(t, err)
+
+	_, err = client.Generate(context.Background(), "hi")
+	assert.ErrorIs(t, err, errUnavailable)
+	_, err = client.Generate(context.Background(), "hi")
+	assert.ErrorIs(t, err, ErrNoProviderAvailable)
+}
+
+func TestContextLengthRule(t *testing.T) {
+	contextErr := errors.New("This model's maximum context length is 8192 tokens")
+	small := &fakeLLM{name: "small", errs: []error{contextErr}}
+	other := &fakeLLM{name: "other", response: "other"}
+	large := &fakeLLM{name: "large", response: "large"}
+
+	client, err := NewClient(
+		[]Provider{{LLM: small}, {LLM: other}, {LLM: large, Standby: true}},
+		WithRule(OnContextLengthExceeded("large")),
+	)
+	require.NoError(t, err)
+
+	response, err := client.Generate(context.Background(), "a very long prompt")
+	require.NoError(t, err)
+	assert.Equal(t, "large", response)
+	assert.Equal(t, 0, other.callCount())
+	assert.Equal(t, 0, client.Health()[0].ConsecutiveFailures)
+
+	// Standby providers are not used without a rule
+	small.errs = []error{errUnavailable}
+	other.errs = []error{errUnavailable}
+	_, err = client.Generate(context.Background(), "hi")
+	assert.Error(t, err)
+	assert.Equal(t, 1, large.callCount())
+}
+
+func TestWeightedStrategy(t *testing.T) {
+	a := &fakeLLM{name: "a", response: "a"}
+	b := &fakeLLM{name: "b", response: "b"}
+	client, err := NewClient([]Provider{{LLM: a, Weight: 1}, {LLM: b, Weight: 3}}, WithStrategy(StrategyWeighted))
+	require.NoError(t, err)
+
+	// With equal draws the heavier provider wins
+	client.random = func() float64 { return 0.5 }
+	response, err := client.Generate(context.Background(), "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "b", response)
+
+	// Over many requests the share follows the weights
+	client.random = rand01()
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		response, err := client.Generate(context.Background(), "hi")
+		require.NoError(t, err)
+		counts[response]++
+	}
+	assert.InDelta(t, 3000, counts["b"], 200)
+}
+
+// rand01 returns a deterministic pseudo-random source in [0, 1)
+func rand01() func() float64 {
+	state := uint64(42)
+	return func() float64 {
+		state = state*6364136223846793005 + 1442695040888963407
+		return float64(state>>11) / float64(1<<53)
+	}
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	assert.True(t, IsContextLengthError(errors.New(`{"error":{"code":"context_length_exceeded"}}`)))
+	assert.True(t, IsContextLengthError(errors.New("prompt is too long: 250000 tokens > 200000 maximum")))
+	assert.False(t, IsContextLengthError(errUnavailable))
+	assert.False(t, IsContextLengthError(nil))
+}
@@ -0,0 +1,187 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// startStream starts a stream on one provider
+type startStream func(ctx context.Context, p *provider) (<-chan interfaces.StreamEvent, error)
+
+// committedError is a stream error that occurred after events were forwarded
+// to the caller. The request cannot be moved to another provider anymore.
+type committedError struct {
+	err error
+}
+
+func (e *committedError) Error() string { return e.err.Error() }
+func (e *committedError) Unwrap() error { return e.err }
+
+// GenerateStream implements interfaces.StreamingLLM.GenerateStream. The
+// request fails over to the next provider as long as the failing provider
+// has not streamed any content yet.
+func (c *Client) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return c.routeStream(ctx, options, func(ctx context.Context, p *provider) (<-chan interfaces.StreamEvent, error) {
+		if streamingLLM, ok := p.llm.(interfaces.StreamingLLM); ok && p.llm.SupportsStreaming() {
+			return streamingLLM.GenerateStream(ctx, prompt, options...)
+		}
+		response, err := p.llm.Generate(ctx, prompt, options...)
+		if err != nil {
+			return nil, err
+		}
+		return responseEvents(response), nil
+	})
+}
+
+// GenerateWithToolsStream implements interfaces.StreamingLLM.GenerateWithToolsStream.
+// As with GenerateStream, the request only fails over before any content or
+// tool events have been streamed.
+func (c *Client) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return c.routeStream(ctx, options, func(ctx context.Context, p *provider) (<-chan interfaces.StreamEvent, error) {
+		if streamingLLM, ok := p.llm.(interfaces.StreamingLLM); ok && p.llm.SupportsStreaming() {
+			return streamingLLM.GenerateWithToolsStream(ctx, prompt, tools, options...)
+		}
+		response, err := p.llm.GenerateWithTools(ctx, prompt, tools, options...)
+		if err != nil {
+			return nil, err
+		}
+		return responseEvents(response), nil
+	})
+}
+
+// routeStream routes a streaming request and forwards the events of the
+// provider that handles it
+func (c *Client) routeStream(ctx context.Context, options []interfaces.GenerateOption, start startStream) (<-chan interfaces.StreamEvent, error) {
+	params := &interfaces.GenerateOptions{LLMConfig: &interfaces.LLMConfig{}}
+	for _, option := range options {
+		if option != nil {
+			option(params)
+		}
+	}
+	streamConfig := interfaces.DefaultStreamConfig()
+	if params.StreamConfig != nil && params.StreamConfig.BufferSize > 0 {
+		streamConfig = *params.StreamConfig
+	}
+	eventChan := make(chan interfaces.StreamEvent, streamConfig.BufferSize)
+
+	go func() {
+		defer close(eventChan)
+
+		err := c.route(ctx, func(ctx context.Context, p *provider) error {
+			return forward(ctx, p, start, eventChan)
+		})
+		if err != nil {
+			sendEvent(ctx, eventChan, interfaces.StreamEvent{
+				Type:      interfaces.StreamEventError,
+				Error:     err,
+				Timestamp: time.Now(),
+			})
+		}
+	}()
+
+	return eventChan, nil
+}
+
+// forward streams one provider's events to the caller. message_start events
+// are held back until the provider streams something else, so that a
+// provider failing right away can be replaced without the caller noticing.
+func forward(ctx context.Context, p *provider, start startStream, eventChan chan<- interfaces.StreamEvent) error {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := start(attemptCtx, p)
+	if err != nil {
+		return err
+	}
+
+	var pending []interfaces.StreamEvent
+	committed := false
+	for event := range events {
+		if event.Type == interfaces.StreamEventError {
+			err := event.Error
+			if err == nil {
+				err = errors.New(event.Content)
+			}
+			// Unblock and drain the abandoned stream
+			cancel()
+			go drain(events)
+			if committed {
+				return &committedError{err: err}
+			}
+			return err
+		}
+
+		if !committed && event.Type == interfaces.StreamEventMessageStart {
+			pending = append(pending, withProvider(event, p.name))
+			continue
+		}
+		if !committed {
+			committed = true
+			for _, pendingEvent := range pending {
+				if !sendEvent(ctx, eventChan, pendingEvent) {
+					go drain(events)
+					return ctx.Err()
+				}
+			}
+			pending = nil
+		}
+		if !sendEvent(ctx, eventChan, event) {
+			go drain(events)
+			return ctx.Err()
+		}
+	}
+
+	for _, pendingEvent := range pending {
+		if !sendEvent(ctx, eventChan, pendingEvent) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// withProvider returns a copy of the event with the provider name in its metadata
+func withProvider(event interfaces.StreamEvent, name string) interfaces.StreamEvent {
+	metadata := make(map[string]interface{}, len(event.Metadata)+1)
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+	metadata["provider"] = name
+	event.Metadata = metadata
+	return event
+}
+
+// responseEvents returns the events of a complete, non-streamed response
+func responseEvents(response string) <-chan interfaces.StreamEvent {
+	now := time.Now()
+	events := []interfaces.StreamEvent{
+		{Type: interfaces.StreamEventMessageStart, Timestamp: now},
+		{Type: interfaces.StreamEventContentDelta, Content: response, Timestamp: now},
+		{Type: interfaces.StreamEventContentComplete, Timestamp: now},
+		{Type: interfaces.StreamEventMessageStop, Timestamp: now},
+	}
+	eventChan := make(chan interfaces.StreamEvent, len(events))
+	for _, event := range events {
+		eventChan <- event
+	}
+	close(eventChan)
+	return eventChan
+}
+
+// drain discards the remaining events of an abandoned stream
+func drain(events <-chan interfaces.StreamEvent) {
+	for range events {
+	}
+}
+
+// sendEvent sends an event unless the context is done and reports whether it was sent
+func sendEvent(ctx context.Context, eventChan chan<- interfaces.StreamEvent, event interfaces.StreamEvent) bool {
+	select {
+	case eventChan <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,139 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStreamingLLM streams its events
+type fakeStreamingLLM struct {
+	fakeLLM
+	events []interfaces.StreamEvent
+}
+
+func (f *fakeStreamingLLM) SupportsStreaming() bool { return true }
+
+func (f *fakeStreamingLLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	eventChan := make(chan interfaces.StreamEvent)
+	go func() {
+		defer close(eventChan)
+		for _, event := range f.events {
+			select {
+			case eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return eventChan, nil
+}
+
+func (f *fakeStreamingLLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return f.GenerateStream(ctx, prompt, options...)
+}
+
+func collect(eventChan <-chan interfaces.StreamEvent) (string, []interfaces.StreamEvent, error) {
+	var content string
+	var events []interfaces.StreamEvent
+	var err error
+	for event := range eventChan {
+		events = append(events, event)
+		switch event.Type {
+		case interfaces.StreamEventContentDelta:
+			content += event.Content
+		case interfaces.StreamEventError:
+			err = event.Error
+		}
+	}
+	return content, events, err
+}
+
+func TestGenerateStreamFailover(t *testing.T) {
+	failing := &fakeStreamingLLM{
+		fakeLLM: fakeLLM{name: "failing"},
+		events: []interfaces.StreamEvent{
+			{Type: interfaces.StreamEventMessageStart},
+			{Type: interfaces.StreamEventError, Error: errUnavailable},
+		},
+	}
+	working := &fakeStreamingLLM{
+		fakeLLM: fakeLLM{name: "working"},
+		events: []interfaces.StreamEvent{
+			{Type: interfaces.StreamEventMessageStart, Metadata: map[string]interface{}{"model": "m"}},
+			{Type: interfaces.StreamEventContentDelta, Content: "Hello"},
+			{Type: interfaces.StreamEventContentDelta, Content: " world"},
+			{Type: interfaces.StreamEventMessageStop},
+		},
+	}
+
+	client, err := NewClient([]Provider{{LLM: failing}, {LLM: working}})
+	require.NoError(t, err)
+
+	eventChan, err := client.GenerateStream(context.Background(), "hi")
+	require.NoError(t, err)
+	content, events, err := collect(eventChan)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello world", content)
+	require.Len(t, events, 4)
+	assert.Equal(t, interfaces.StreamEventMessageStart, events[0].Type)
+	assert.Equal(t, "working", events[0].Metadata["provider"])
+	assert.Equal(t, "m", events[0].Metadata["model"])
+	assert.Equal(t, 1, client.Health()[0].ConsecutiveFailures)
+}
+
+func TestGenerateStreamNoFailoverAfterContent(t *testing.T) {
+	failing := &fakeStreamingLLM{
+		fakeLLM: fakeLLM{name: "failing"},
+		events: []interfaces.StreamEvent{
+			{Type: interfaces.StreamEventContentDelta, Content: "Hel"},
+			{Type: interfaces.StreamEventError, Error: errUnavailable},
+		},
+	}
+	other := &fakeStreamingLLM{fakeLLM: fakeLLM{name: "other"}}
+
+	client, err := NewClient([]Provider{{LLM: failing}, {LLM: other}})
+	require.NoError(t, err)
+
+	eventChan, err := client.GenerateWithToolsStream(context.Background(), "hi", nil)
+	require.NoError(t, err)
+	content, _, err := collect(eventChan)
+
+	assert.Equal(t, "Hel", content)
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, 0, other.callCount())
+	assert.Equal(t, 1, client.Health()[0].ConsecutiveFailures)
+}
+
+func TestGenerateStreamAdaptsNonStreamingProvider(t *testing.T) {
+	client, err := NewClient([]Provider{
+		{LLM: &fakeLLM{name: "a", errs: []error{errTimeout}}},
+		{LLM: &fakeLLM{name: "b", response: "complete answer"}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	eventChan, err := client.GenerateStream(ctx, "hi")
+	require.NoError(t, err)
+	content, events, err := collect(eventChan)
+	require.NoError(t, err)
+
+	assert.Equal(t, "complete answer", content)
+	assert.Equal(t, interfaces.StreamEventMessageStart, events[0].Type)
+	assert.Equal(t, "b", events[0].Metadata["provider"])
+	assert.Equal(t, interfaces.StreamEventMessageStop, events[len(events)-1].Type)
+}
+
+func TestClientImplementsStreamingLLM(t *testing.T) {
+	var _ interfaces.StreamingLLM = (*Client)(nil)
+}
@@ -0,0 +1,80 @@
+package llm
+
+import "strings"
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// ThinkSegment is a piece of streamed model output
+type ThinkSegment struct {
+	Text     string
+	Thinking bool // Whether the text was inside <think> tags
+}
+
+// ThinkTagParser splits streamed model output into regular content and the
+// reasoning that models such as Qwen3 or DeepSeek-R1 wrap in <think></think>
+// tags. Tags may be split across deltas; the parser holds back a partial tag
+// until the next delta arrives. It is not safe for concurrent use.
+type ThinkTagParser struct {
+	inThink bool
+	pending string
+}
+
+// Feed parses the next delta and returns the segments that are complete
+func (p *ThinkTagParser) Feed(delta string) []ThinkSegment {
+	buf := p.pending + delta
+	p.pending = ""
+
+	var segments []ThinkSegment
+	for buf != "" {
+		tag := thinkOpenTag
+		if p.inThink {
+			tag = thinkCloseTag
+		}
+
+		if idx := strings.Index(buf, tag); idx >= 0 {
+			if idx > 0 {
+				segments = append(segments, ThinkSegment{Text: buf[:idx], Thinking: p.inThink})
+			}
+			p.inThink = !p.inThink
+			buf = buf[idx+len(tag):]
+			continue
+		}
+
+		// Hold back a trailing partial tag
+		keep := partialTagSuffix(buf, tag)
+		if keep < len(buf) {
+			segments = append(segments, ThinkSegment{Text: buf[:len(buf)-keep], Thinking: p.inThink})
+		}
+		p.pending = buf[len(buf)-keep:]
+		break
+	}
+	return segments
+}
+
+// Flush returns any text held back at the end of the stream
+func (p *ThinkTagParser) Flush() []ThinkSegment {
+	if p.pending == "" {
+		return nil
+	}
+	segment := ThinkSegment{Text: p.pending, Thinking: p.inThink}
+	p.pending = ""
+	return []ThinkSegment{segment}
+}
+
+// partialTagSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag
+func partialTagSuffix(s, tag string) int {
+	maxLen := len(tag) - 1
+	if len(s) < maxLen {
+		maxLen = len(s)
+	}
+	for n := maxLen; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
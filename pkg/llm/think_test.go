@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThinkTagParser(t *testing.T) {
+	var parser ThinkTagParser
+	var segments []ThinkSegment
+	for _, delta := range []string{"<thi", "nk>let me ", "think</th", "ink>The answer", " is 4<", "/p>"} {
+		segments = append(segments, parser.Feed(delta)...)
+	}
+	segments = append(segments, parser.Flush()...)
+
+	var thinking, content string
+	for _, segment := range segments {
+		if segment.Thinking {
+			thinking += segment.Text
+		} else {
+			content += segment.Text
+		}
+	}
+	assert.Equal(t, "let me think", thinking)
+	assert.Equal(t, "The answer is 4</p>", content)
+}
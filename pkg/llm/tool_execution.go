@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/tracing"
+)
+
+// ToolExecution is one tool call requested by the model in a single turn
+type ToolExecution struct {
+	Tool      interfaces.Tool // Tool to run; executions with a nil tool are skipped
+	Arguments string          // JSON arguments passed to the tool
+
+	Result    string        // Output of the tool
+	Err       error         // Error returned by the tool, or the context error if it never ran
+	StartTime time.Time     // When the tool started running
+	Duration  time.Duration // How long the tool ran
+}
+
+// FindTool returns the tool with the given name, or nil if there is none. A
+// tool that is not in the list may be provided by one of the tools that
+// implement interfaces.ToolLookup.
+func FindTool(tools []interfaces.Tool, name string) interfaces.Tool {
+	for _, tool := range tools {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	for _, tool := range tools {
+		if lookup, ok := tool.(interfaces.ToolLookup); ok {
+			if found := lookup.LookupTool(name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// ExecuteTools runs the tool calls of one LLM turn and stores each outcome on
+// its execution, so results keep the order in which the model requested them.
+//
+// With maxParallel greater than one, up to maxParallel tools run concurrently;
+// otherwise they run one after another. A failing tool does not stop the
+// others. Once ctx is cancelled, tools that have not started are not run and
+// get the context error instead.
+//
+// Each call of ExecuteTools is recorded as a turn when the context carries a
+// recorder added with WithToolTurnRecording, and ends with the function added
+// with WithToolTurnEnd, if there is one.
+func ExecuteTools(ctx context.Context, executions []*ToolExecution, maxParallel int) {
+	onEnd, _ := ctx.Value(toolTurnEndKey{}).(func())
+	ctx = context.WithValue(ctx, toolTurnEndKey{}, (func())(nil))
+	ctx, endTurn := startToolTurn(ctx, len(executions))
+	defer func() {
+		endTurn()
+		if onEnd != nil {
+			onEnd()
+		}
+	}()
+
+	if maxParallel <= 1 {
+		for _, execution := range executions {
+			execution.run(ctx)
+		}
+		return
+	}
+
+	semaphore := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for _, execution := range executions {
+		if execution.Tool == nil {
+			continue
+		}
+
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			execution.Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(execution *ToolExecution) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			execution.run(ctx)
+		}(execution)
+	}
+
+	wg.Wait()
+}
+
+// ToolTurn describes a turn of a tool loop in which the LLM requested tools
+type ToolTurn struct {
+	Calls     int       // Number of tool calls requested in the turn
+	StartTime time.Time // When the tools of the turn started running
+	EndTime   time.Time // When the last of them finished
+}
+
+// toolTurnRecorder accumulates the tool turns of a scope such as an agent run
+type toolTurnRecorder struct {
+	mu    sync.Mutex
+	turns []ToolTurn
+}
+
+// toolTurnRecorderKey is the context key for recording tool turns
+type toolTurnRecorderKey struct{}
+
+// toolTurnKey is the context key for the number of the turn whose tools run
+type toolTurnKey struct{}
+
+// toolTurnEndKey is the context key for the function called at the end of
+// each tool turn
+type toolTurnEndKey struct{}
+
+// WithToolTurnEnd adds a function to the context that ExecuteTools calls once
+// all tools of a turn have finished, before their results are recorded and
+// sent back to the LLM. The tools run without it, so that the turns of a
+// nested run do not call it.
+func WithToolTurnEnd(ctx context.Context, onEnd func()) context.Context {
+	return context.WithValue(ctx, toolTurnEndKey{}, onEnd)
+}
+
+// WithToolTurnRecording adds a tool turn recorder to the context. It replaces
+// any recorder already in the context, so that the turns of a nested run are
+// not counted in the outer one.
+func WithToolTurnRecording(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, toolTurnRecorderKey{}, &toolTurnRecorder{})
+	return context.WithValue(ctx, toolTurnKey{}, 0)
+}
+
+// GetToolTurnsFromContext returns the tool turns recorded in the context
+func GetToolTurnsFromContext(ctx context.Context) []ToolTurn {
+	recorder, ok := ctx.Value(toolTurnRecorderKey{}).(*toolTurnRecorder)
+	if !ok {
+		return nil
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	turns := make([]ToolTurn, len(recorder.turns))
+	copy(turns, recorder.turns)
+	return turns
+}
+
+// ToolTurnFromContext returns the 1-based number of the recorded turn whose
+// tools run with the context, or 0 if turns are not recorded
+func ToolTurnFromContext(ctx context.Context) int {
+	turn, _ := ctx.Value(toolTurnKey{}).(int)
+	return turn
+}
+
+// startToolTurn records the start of a tool turn, if there is a recorder in
+// the context, and returns the context of its tools with a function that
+// records its end
+func startToolTurn(ctx context.Context, calls int) (context.Context, func()) {
+	recorder, ok := ctx.Value(toolTurnRecorderKey{}).(*toolTurnRecorder)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	recorder.mu.Lock()
+	recorder.turns = append(recorder.turns, ToolTurn{Calls: calls, StartTime: time.Now()})
+	turn := len(recorder.turns)
+	recorder.mu.Unlock()
+
+	return context.WithValue(ctx, toolTurnKey{}, turn), func() {
+		recorder.mu.Lock()
+		recorder.turns[turn-1].EndTime = time.Now()
+		recorder.mu.Unlock()
+	}
+}
+
+// run executes the tool unless it is missing or the context is already done
+func (e *ToolExecution) run(ctx context.Context) {
+	if e.Tool == nil {
+		return
+	}
+	if err := ctx.Err(); err != nil {
+		e.Err = err
+		return
+	}
+
+	e.StartTime = time.Now()
+	e.Result, e.Err = e.Tool.Execute(ctx, e.Arguments)
+	e.Duration = time.Since(e.StartTime)
+}
+
+// RecordToolExecution reports a finished tool call to the tracing context and
+// stores the call and its result in memory, if one is given. It returns the
+// content to send back to the model: the tool output, or an "Error: ..." text
+// when the tool was not found or failed.
+func RecordToolExecution(ctx context.Context, call interfaces.ToolCall, execution *ToolExecution, memory interfaces.Memory) string {
+	var content, errMsg string
+	switch {
+	case execution.Tool == nil:
+		errMsg = fmt.Sprintf("tool not found: %s", call.Name)
+		content = "Error: " + errMsg
+	case execution.Err != nil:
+		errMsg = execution.Err.Error()
+		content = "Error: " + errMsg
+	default:
+		content = execution.Result
+	}
+
+	startTime := execution.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	tracing.AddToolCallToContext(ctx, tracing.ToolCall{
+		Name:       call.Name,
+		Arguments:  call.Arguments,
+		ID:         call.ID,
+		Timestamp:  startTime.Format(time.RFC3339),
+		StartTime:  startTime,
+		Duration:   execution.Duration,
+		DurationMs: execution.Duration.Milliseconds(),
+		Result:     content,
+		Error:      errMsg,
+	})
+
+	if memory != nil {
+		// The call ran, so it is stored even if the LLM call is being
+		// cancelled
+		ctx := context.WithoutCancel(ctx)
+		_ = memory.AddMessage(ctx, interfaces.Message{
+			Role:      interfaces.MessageRoleAssistant,
+			ToolCalls: []interfaces.ToolCall{call},
+		})
+		_ = memory.AddMessage(ctx, interfaces.Message{
+			Role:       interfaces.MessageRoleTool,
+			Content:    content,
+			ToolCallID: call.ID,
+			Metadata: map[string]interface{}{
+				"tool_name": call.Name,
+			},
+		})
+	}
+
+	return content
+}
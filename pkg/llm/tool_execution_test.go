@@ -0,0 +1,223 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+// sleepTool sleeps before echoing its arguments and tracks how many calls run at once
+type sleepTool struct {
+	delay   time.Duration
+	err     error
+	running *int32
+	peak    *int32
+}
+
+func (t *sleepTool) Name() string        { return "sleep" }
+func (t *sleepTool) Description() string { return "Sleeps and echoes its arguments" }
+func (t *sleepTool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+func (t *sleepTool) Parameters() map[string]interfaces.ParameterSpec { return nil }
+
+func (t *sleepTool) Execute(ctx context.Context, args string) (string, error) {
+	if t.running != nil {
+		current := atomic.AddInt32(t.running, 1)
+		defer atomic.AddInt32(t.running, -1)
+		for {
+			peak := atomic.LoadInt32(t.peak)
+			if current <= peak || atomic.CompareAndSwapInt32(t.peak, peak, current) {
+				break
+			}
+		}
+	}
+
+	select {
+	case <-time.After(t.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if t.err != nil {
+		return "", t.err
+	}
+	return "result " + args, nil
+}
+
+func TestExecuteTools(t *testing.T) {
+	t.Run("sequential keeps order", func(t *testing.T) {
+		var running, peak int32
+		tool := &sleepTool{delay: time.Millisecond, running: &running, peak: &peak}
+		executions := []*ToolExecution{
+			{Tool: tool, Arguments: "a"},
+			{Tool: nil, Arguments: "missing"},
+			{Tool: tool, Arguments: "b"},
+		}
+
+		ExecuteTools(context.Background(), executions, 0)
+
+		assert.Equal(t, "result a", executions[0].Result)
+		assert.Empty(t, executions[1].Result)
+		assert.NoError(t, executions[1].Err)
+		assert.Equal(t, "result b", executions[2].Result)
+		assert.Equal(t, int32(1), peak)
+	})
+
+	t.Run("parallel is bounded and keeps order", func(t *testing.T) {
+		var running, peak int32
+		tool := &sleepTool{delay: 20 * time.Millisecond, running: &running, peak: &peak}
+		executions := make([]*ToolExecution, 6)
+		for i := range executions {
+			executions[i] = &ToolExecution{Tool: tool, Arguments: string(rune('a' + i))}
+		}
+
+		ExecuteTools(context.Background(), executions, 3)
+
+		for i, execution := range executions {
+			assert.NoError(t, execution.Err)
+			assert.Equal(t, "result "+string(rune('a'+i)), execution.Result)
+			assert.False(t, execution.StartTime.IsZero())
+			assert.GreaterOrEqual(t, execution.Duration, 20*time.Millisecond)
+		}
+		assert.Equal(t, int32(3), peak)
+	})
+
+	t.Run("errors do not stop other tools", func(t *testing.T) {
+		failing := &sleepTool{delay: time.Millisecond, err: errors.New("boom")}
+		ok := &sleepTool{delay: 5 * time.Millisecond}
+		executions := []*ToolExecution{
+			{Tool: failing, Arguments: "a"},
+			{Tool: ok, Arguments: "b"},
+		}
+
+		ExecuteTools(context.Background(), executions, 2)
+
+		assert.EqualError(t, executions[0].Err, "boom")
+		assert.NoError(t, executions[1].Err)
+		assert.Equal(t, "result b", executions[1].Result)
+	})
+
+	t.Run("cancellation skips calls that have not started", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		tool := &sleepTool{delay: time.Second}
+		executions := []*ToolExecution{
+			{Tool: tool, Arguments: "a"},
+			{Tool: tool, Arguments: "b"},
+			{Tool: tool, Arguments: "c"},
+		}
+
+		start := time.Now()
+		ExecuteTools(ctx, executions, 2)
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		for _, execution := range executions {
+			assert.ErrorIs(t, execution.Err, context.DeadlineExceeded)
+		}
+		assert.True(t, executions[2].StartTime.IsZero(), "third call should never start")
+	})
+	t.Run("turns are recorded", func(t *testing.T) {
+		var turns []int
+		tool := &turnTool{turns: &turns}
+		ctx := WithToolTurnRecording(context.Background())
+
+		ExecuteTools(ctx, []*ToolExecution{{Tool: tool}, {Tool: tool}}, 2)
+		ExecuteTools(ctx, []*ToolExecution{{Tool: tool}}, 0)
+
+		assert.ElementsMatch(t, []int{1, 1, 2}, turns)
+		recorded := GetToolTurnsFromContext(ctx)
+		if assert.Len(t, recorded, 2) {
+			assert.Equal(t, 2, recorded[0].Calls)
+			assert.Equal(t, 1, recorded[1].Calls)
+			assert.False(t, recorded[1].StartTime.Before(recorded[0].EndTime))
+		}
+		assert.Zero(t, ToolTurnFromContext(ctx))
+	})
+
+	t.Run("turn end is called after the tools finished", func(t *testing.T) {
+		var finished int32
+		ended := make(chan int32, 2)
+		tool := &sleepTool{delay: 10 * time.Millisecond}
+		counting := &turnEndTool{sleepTool: tool, finished: &finished}
+		ctx := WithToolTurnEnd(context.Background(), func() {
+			ended <- atomic.LoadInt32(&finished)
+		})
+
+		ExecuteTools(ctx, []*ToolExecution{{Tool: counting}, {Tool: counting}}, 2)
+
+		// The tools run without the function, so nested turns do not call it
+		assert.Len(t, ended, 1)
+		assert.Equal(t, int32(2), <-ended)
+	})
+}
+
+// turnEndTool counts the calls that finished and runs a nested turn
+type turnEndTool struct {
+	*sleepTool
+	finished *int32
+}
+
+func (t *turnEndTool) Execute(ctx context.Context, args string) (string, error) {
+	ExecuteTools(ctx, []*ToolExecution{{Tool: t.sleepTool}}, 0)
+	defer atomic.AddInt32(t.finished, 1)
+	return t.sleepTool.Execute(ctx, args)
+}
+
+// turnTool records the tool turn it runs in
+type turnTool struct {
+	sleepTool
+	mu    sync.Mutex
+	turns *[]int
+}
+
+func (t *turnTool) Execute(ctx context.Context, args string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*t.turns = append(*t.turns, ToolTurnFromContext(ctx))
+	return "", nil
+}
+
+func TestFindTool(t *testing.T) {
+	tool := &sleepTool{}
+	tools := []interfaces.Tool{tool}
+
+	assert.Same(t, tool, FindTool(tools, "sleep"))
+	assert.Nil(t, FindTool(tools, "search"))
+	assert.Nil(t, FindTool(nil, "sleep"))
+
+	// Tools not in the list may be provided by a tool lookup
+	search := &namedTool{sleepTool: tool, name: "search"}
+	lookup := &lookupTool{namedTool: namedTool{sleepTool: tool, name: "lookup"}, tools: []interfaces.Tool{search}}
+	assert.Same(t, search, FindTool([]interfaces.Tool{tool, lookup}, "search"))
+	assert.Nil(t, FindTool([]interfaces.Tool{tool, lookup}, "delete"))
+}
+
+// namedTool is a sleep tool with another name
+type namedTool struct {
+	*sleepTool
+	name string
+}
+
+func (t *namedTool) Name() string { return t.name }
+
+// lookupTool provides other tools by name
+type lookupTool struct {
+	namedTool
+	tools []interfaces.Tool
+}
+
+func (t *lookupTool) LookupTool(name string) interfaces.Tool {
+	for _, tool := range t.tools {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package llm
+
+import "github.com/andmang/agent-sdk-go/pkg/interfaces"
+
+// ToolParametersSchema returns the JSON schema of a tool's parameters as used
+// by OpenAI-compatible function calling APIs
+func ToolParametersSchema(tool interfaces.Tool) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for name, param := range tool.Parameters() {
+		properties[name] = parameterSchema(param)
+		if param.Required {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// parameterSchema converts a single parameter spec to JSON schema
+func parameterSchema(param interfaces.ParameterSpec) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type": param.Type,
+	}
+	if param.Description != "" {
+		schema["description"] = param.Description
+	}
+	if param.Enum != nil {
+		schema["enum"] = param.Enum
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+	if param.Type == "array" {
+		if param.Items != nil && param.Items.Type != "" {
+			schema["items"] = parameterSchema(*param.Items)
+		} else {
+			schema["items"] = map[string]interface{}{"type": "string"}
+		}
+	}
+	return schema
+}
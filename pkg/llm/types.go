@@ -1,5 +1,7 @@
 package llm
 
+import "github.com/andmang/agent-sdk-go/pkg/interfaces"
+
 // Message represents a message in a chat conversation
 type Message struct {
 	Role       string // "system", "user", "assistant", "tool"
@@ -30,3 +32,36 @@ func DefaultGenerateParams() *GenerateParams {
 		RepeatPenalty:    1.1,
 	}
 }
+
+// Options returns the generate options that set the parameters
+func (p *GenerateParams) Options() []interfaces.GenerateOption {
+	return []interfaces.GenerateOption{func(options *interfaces.GenerateOptions) {
+		if options.LLMConfig == nil {
+			options.LLMConfig = &interfaces.LLMConfig{}
+		}
+		options.LLMConfig.Temperature = p.Temperature
+		options.LLMConfig.TopP = p.TopP
+		options.LLMConfig.FrequencyPenalty = p.FrequencyPenalty
+		options.LLMConfig.PresencePenalty = p.PresencePenalty
+		options.LLMConfig.StopSequences = p.StopSequences
+		options.LLMConfig.TopK = p.TopK
+		options.LLMConfig.RepeatPenalty = p.RepeatPenalty
+		options.LLMConfig.Reasoning = p.Reasoning
+	}}
+}
+
+// ConvertMessages converts messages to the messages of interfaces.ChatLLM.
+// Messages with an unknown role are sent as user messages.
+func ConvertMessages(messages []Message) []interfaces.Message {
+	converted := make([]interfaces.Message, len(messages))
+	for i, msg := range messages {
+		role := interfaces.MessageRole(msg.Role)
+		switch role {
+		case interfaces.MessageRoleSystem, interfaces.MessageRoleUser, interfaces.MessageRoleAssistant, interfaces.MessageRoleTool:
+		default:
+			role = interfaces.MessageRoleUser
+		}
+		converted[i] = interfaces.Message{Role: role, Content: msg.Content, ToolCallID: msg.ToolCallID}
+	}
+	return converted
+}
@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenUsage is a provider-neutral record of the tokens consumed by a single LLM call.
+//
+// InputTokens counts every prompt token the provider processed, including tokens
+// served from or written to a prompt cache. OutputTokens counts every generated
+// token, including reasoning/thinking tokens.
+type TokenUsage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CachedInputTokens   int `json:"cached_input_tokens,omitempty"`   // Prompt tokens read from the provider cache
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"` // Prompt tokens written to the provider cache
+	ReasoningTokens     int `json:"reasoning_tokens,omitempty"`      // Output tokens spent on reasoning/thinking
+	TotalTokens         int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usage values
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:         u.InputTokens + other.InputTokens,
+		OutputTokens:        u.OutputTokens + other.OutputTokens,
+		CachedInputTokens:   u.CachedInputTokens + other.CachedInputTokens,
+		CacheCreationTokens: u.CacheCreationTokens + other.CacheCreationTokens,
+		ReasoningTokens:     u.ReasoningTokens + other.ReasoningTokens,
+		TotalTokens:         u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// IsZero reports whether no tokens were recorded
+func (u TokenUsage) IsZero() bool {
+	return u == TokenUsage{}
+}
+
+// UsageRecord describes the usage of one request made to a provider
+type UsageRecord struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Iteration is the 1-based iteration of the tool-calling loop that produced this
+	// record, or 0 for calls made outside of a tool loop.
+	Iteration int        `json:"iteration,omitempty"`
+	Usage     TokenUsage `json:"usage"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// UsageSummary aggregates usage records, optionally priced through a PriceTable
+type UsageSummary struct {
+	Records []UsageRecord `json:"records"`
+	Total   TokenUsage    `json:"total"`
+	// Cost is the total cost in USD of the records that could be priced
+	Cost float64 `json:"cost"`
+	// UnpricedModels lists models that were used but missing from the price table
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
+}
+
+// Summarize totals the given records and prices them using the table. A nil
+// table leaves Cost at zero.
+func Summarize(records []UsageRecord, prices *PriceTable) UsageSummary {
+	summary := UsageSummary{Records: records}
+	unpriced := make(map[string]bool)
+	for _, record := range records {
+		summary.Total = summary.Total.Add(record.Usage)
+		if prices == nil {
+			continue
+		}
+		cost, ok := prices.Cost(record.Model, record.Usage)
+		if !ok {
+			if !unpriced[record.Model] {
+				unpriced[record.Model] = true
+				summary.UnpricedModels = append(summary.UnpricedModels, record.Model)
+			}
+			continue
+		}
+		summary.Cost += cost
+	}
+	return summary
+}
+
+// usageCollector accumulates usage records for a scope such as an agent run.
+// Records added to a collector are also added to its parent, so usage from
+// nested runs (e.g. sub-agents) rolls up into the outer run.
+type usageCollector struct {
+	mu      sync.Mutex
+	records []UsageRecord
+	parent  *usageCollector
+}
+
+func (c *usageCollector) add(record UsageRecord) {
+	for current := c; current != nil; current = current.parent {
+		current.mu.Lock()
+		current.records = append(current.records, record)
+		current.mu.Unlock()
+	}
+}
+
+func (c *usageCollector) snapshot() []UsageRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	records := make([]UsageRecord, len(c.records))
+	copy(records, c.records)
+	return records
+}
+
+// usageCollectorKey is the context key for collecting token usage
+type usageCollectorKey struct{}
+
+// WithUsageCollection adds a usage collector to the context. If the context
+// already carries a collector, the new one is nested inside it so that usage
+// is reported to both.
+func WithUsageCollection(ctx context.Context) context.Context {
+	parent, _ := ctx.Value(usageCollectorKey{}).(*usageCollector)
+	return context.WithValue(ctx, usageCollectorKey{}, &usageCollector{parent: parent})
+}
+
+// HasUsageCollection reports whether the context carries a usage collector
+func HasUsageCollection(ctx context.Context) bool {
+	_, ok := ctx.Value(usageCollectorKey{}).(*usageCollector)
+	return ok
+}
+
+// AddUsageToContext records usage in the context's collector, if any. Records
+// with no tokens are ignored.
+func AddUsageToContext(ctx context.Context, record UsageRecord) {
+	if record.Usage.IsZero() {
+		return
+	}
+	collector, ok := ctx.Value(usageCollectorKey{}).(*usageCollector)
+	if !ok {
+		return
+	}
+	if record.Usage.TotalTokens == 0 {
+		record.Usage.TotalTokens = record.Usage.InputTokens + record.Usage.OutputTokens
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	collector.add(record)
+}
+
+// GetUsageFromContext returns the usage records collected in the context
+func GetUsageFromContext(ctx context.Context) []UsageRecord {
+	if collector, ok := ctx.Value(usageCollectorKey{}).(*usageCollector); ok {
+		return collector.snapshot()
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenUsageAdd(t *testing.T) {
+	a := TokenUsage{InputTokens: 10, OutputTokens: 5, CachedInputTokens: 2, ReasoningTokens: 1, TotalTokens: 15}
+	b := TokenUsage{InputTokens: 3, OutputTokens: 4, CacheCreationTokens: 7, TotalTokens: 7}
+
+	sum := a.Add(b)
+	assert.Equal(t, TokenUsage{
+		InputTokens:         13,
+		OutputTokens:        9,
+		CachedInputTokens:   2,
+		CacheCreationTokens: 7,
+		ReasoningTokens:     1,
+		TotalTokens:         22,
+	}, sum)
+	assert.False(t, sum.IsZero())
+	assert.True(t, TokenUsage{}.IsZero())
+}
+
+func TestAddUsageToContext(t *testing.T) {
+	t.Run("ignored without a collector", func(t *testing.T) {
+		ctx := context.Background()
+		AddUsageToContext(ctx, UsageRecord{Model: "m", Usage: TokenUsage{InputTokens: 1}})
+		assert.False(t, HasUsageCollection(ctx))
+		assert.Nil(t, GetUsageFromContext(ctx))
+	})
+
+	t.Run("fills total and timestamp", func(t *testing.T) {
+		ctx := WithUsageCollection(context.Background())
+		AddUsageToContext(ctx, UsageRecord{Model: "m", Usage: TokenUsage{InputTokens: 3, OutputTokens: 2}})
+		AddUsageToContext(ctx, UsageRecord{Model: "m"}) // zero usage is dropped
+
+		records := GetUsageFromContext(ctx)
+		require.Len(t, records, 1)
+		assert.Equal(t, 5, records[0].Usage.TotalTokens)
+		assert.False(t, records[0].Timestamp.IsZero())
+	})
+
+	t.Run("nested collectors roll up", func(t *testing.T) {
+		outer := WithUsageCollection(context.Background())
+		AddUsageToContext(outer, UsageRecord{Model: "outer", Usage: TokenUsage{InputTokens: 1, OutputTokens: 1}})
+
+		inner := WithUsageCollection(outer)
+		AddUsageToContext(inner, UsageRecord{Model: "inner", Usage: TokenUsage{InputTokens: 2, OutputTokens: 2}})
+
+		assert.Len(t, GetUsageFromContext(inner), 1)
+		assert.Len(t, GetUsageFromContext(outer), 2)
+	})
+
+	t.Run("concurrent writers", func(t *testing.T) {
+		ctx := WithUsageCollection(context.Background())
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				AddUsageToContext(ctx, UsageRecord{Model: "m", Usage: TokenUsage{OutputTokens: 1}})
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, GetUsageFromContext(ctx), 50)
+	})
+}
+
+func TestPriceTable(t *testing.T) {
+	table := NewPriceTable(map[string]ModelPrice{
+		"gpt-4o":      {InputPerMillion: 2.5, OutputPerMillion: 10, CachedInputPerMillion: 1.25},
+		"gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+	})
+
+	price, ok := table.Lookup("gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, 0.15, price.InputPerMillion, "longest prefix should win")
+
+	_, ok = table.Lookup("claude-sonnet-4")
+	assert.False(t, ok)
+
+	cost, ok := table.Cost("gpt-4o", TokenUsage{
+		InputTokens:       1_000_000,
+		CachedInputTokens: 400_000,
+		OutputTokens:      100_000,
+	})
+	require.True(t, ok)
+	// 600k uncached at 2.5 + 400k cached at 1.25 + 100k output at 10
+	assert.InDelta(t, 1.5+0.5+1.0, cost, 1e-9)
+
+	table.Set("claude-sonnet-4", ModelPrice{InputPerMillion: 3, OutputPerMillion: 15, CacheWritePerMillion: 3.75})
+	cost, ok = table.Cost("claude-sonnet-4", TokenUsage{InputTokens: 2_000_000, CacheCreationTokens: 1_000_000})
+	require.True(t, ok)
+	assert.InDelta(t, 3+3.75, cost, 1e-9)
+}
+
+func TestSummarize(t *testing.T) {
+	records := []UsageRecord{
+		{Model: "gpt-4o", Iteration: 1, Usage: TokenUsage{InputTokens: 1_000_000, TotalTokens: 1_000_000}},
+		{Model: "gpt-4o", Iteration: 2, Usage: TokenUsage{OutputTokens: 1_000_000, TotalTokens: 1_000_000}},
+		{Model: "local-model", Usage: TokenUsage{InputTokens: 10, TotalTokens: 10}},
+	}
+
+	summary := Summarize(records, nil)
+	assert.Equal(t, 2_000_010, summary.Total.TotalTokens)
+	assert.Zero(t, summary.Cost)
+	assert.Empty(t, summary.UnpricedModels)
+
+	table := NewPriceTable(map[string]ModelPrice{"gpt-4o": {InputPerMillion: 2.5, OutputPerMillion: 10}})
+	summary = Summarize(records, table)
+	assert.InDelta(t, 12.5, summary.Cost, 1e-9)
+	assert.Equal(t, []string{"local-model"}, summary.UnpricedModels)
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
@@ -24,6 +23,7 @@ type VLLMClient struct {
 	Model         string
 	logger        logging.Logger
 	retryExecutor *retry.Executor
+	toolMode      llm.ToolCallingMode
 }
 
 // Option represents an option for configuring the vLLM client
@@ -64,6 +64,15 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithToolCallingMode sets how the model calls tools. The default,
+// llm.ToolCallingAuto, uses vLLM's native tool calling and falls back to
+// ReAct prompting when the server was started without tool support.
+func WithToolCallingMode(mode llm.ToolCallingMode) Option {
+	return func(c *VLLMClient) {
+		c.toolMode = mode
+	}
+}
+
 // NewClient creates a new vLLM client
 func NewClient(options ...Option) *VLLMClient {
 	// Create client with default options
@@ -72,6 +81,7 @@ func NewClient(options ...Option) *VLLMClient {
 		HTTPClient: &http.Client{Timeout: 60 * time.Second},
 		Model:      "llama-2-7b",
 		logger:     logging.New(),
+		toolMode:   llm.ToolCallingAuto,
 	}
 
 	// Apply options
@@ -84,17 +94,18 @@ func NewClient(options ...Option) *VLLMClient {
 
 // vLLM API request/response structures
 type GenerateRequest struct {
-	Model         string   `json:"model"`
-	Prompt        string   `json:"prompt"`
-	Stream        bool     `json:"stream"`
-	Temperature   float64  `json:"temperature,omitempty"`
-	TopP          float64  `json:"top_p,omitempty"`
-	TopK          int      `json:"top_k,omitempty"`
-	MaxTokens     int      `json:"max_tokens,omitempty"`
-	Stop          []string `json:"stop,omitempty"`
-	UseBeamSearch bool     `json:"use_beam_search,omitempty"`
-	BestOf        int      `json:"best_of,omitempty"`
-	N             int      `json:"n,omitempty"`
+	Model             string   `json:"model"`
+	Prompt            string   `json:"prompt"`
+	Stream            bool     `json:"stream"`
+	Temperature       float64  `json:"temperature,omitempty"`
+	TopP              float64  `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	MaxTokens         int      `json:"max_tokens,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
+	RepetitionPenalty float64  `json:"repetition_penalty,omitempty"`
+	UseBeamSearch     bool     `json:"use_beam_search,omitempty"`
+	BestOf            int      `json:"best_of,omitempty"`
+	N                 int      `json:"n,omitempty"`
 }
 
 type GenerateResponse struct {
@@ -108,30 +119,72 @@ type GenerateResponse struct {
 		LogProbs     interface{} `json:"logprobs,omitempty"`
 		FinishReason string      `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage Usage `json:"usage"`
+}
+
+// Usage represents token usage information
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ToTokenUsage converts vLLM usage to the provider-neutral format
+func (u Usage) ToTokenUsage() llm.TokenUsage {
+	return llm.TokenUsage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+	}
 }
 
 type ChatRequest struct {
-	Model         string        `json:"model"`
-	Messages      []ChatMessage `json:"messages"`
-	Stream        bool          `json:"stream"`
-	Temperature   float64       `json:"temperature,omitempty"`
-	TopP          float64       `json:"top_p,omitempty"`
-	TopK          int           `json:"top_k,omitempty"`
-	MaxTokens     int           `json:"max_tokens,omitempty"`
-	Stop          []string      `json:"stop,omitempty"`
-	UseBeamSearch bool          `json:"use_beam_search,omitempty"`
-	BestOf        int           `json:"best_of,omitempty"`
-	N             int           `json:"n,omitempty"`
+	Model             string         `json:"model"`
+	Messages          []ChatMessage  `json:"messages"`
+	Tools             []Tool         `json:"tools,omitempty"`
+	ToolChoice        interface{}    `json:"tool_choice,omitempty"`
+	Stream            bool           `json:"stream"`
+	StreamOptions     *StreamOptions `json:"stream_options,omitempty"`
+	Temperature       float64        `json:"temperature,omitempty"`
+	TopP              float64        `json:"top_p,omitempty"`
+	TopK              int            `json:"top_k,omitempty"`
+	MaxTokens         int            `json:"max_tokens,omitempty"`
+	Stop              []string       `json:"stop,omitempty"`
+	RepetitionPenalty float64        `json:"repetition_penalty,omitempty"`
+	UseBeamSearch     bool           `json:"use_beam_search,omitempty"`
+	BestOf            int            `json:"best_of,omitempty"`
+	N                 int            `json:"n,omitempty"`
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON encoded arguments
 }
 
 type ChatResponse struct {
@@ -144,11 +197,7 @@ type ChatResponse struct {
 		Message      ChatMessage `json:"message"`
 		FinishReason string      `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage Usage `json:"usage"`
 }
 
 type ModelInfo struct {
@@ -181,12 +230,14 @@ func (c *VLLMClient) Generate(ctx context.Context, prompt string, options ...int
 
 	// Create request
 	req := GenerateRequest{
-		Model:       c.Model,
-		Prompt:      finalPrompt,
-		Stream:      false,
-		Temperature: params.LLMConfig.Temperature,
-		TopP:        params.LLMConfig.TopP,
-		Stop:        params.LLMConfig.StopSequences,
+		Model:             c.Model,
+		Prompt:            finalPrompt,
+		Stream:            false,
+		Temperature:       params.LLMConfig.Temperature,
+		TopP:              params.LLMConfig.TopP,
+		TopK:              params.LLMConfig.TopK,
+		Stop:              params.LLMConfig.StopSequences,
+		RepetitionPenalty: params.LLMConfig.RepeatPenalty,
 	}
 
 	// Handle structured output if provided
@@ -223,6 +274,7 @@ Ensure your response is a valid JSON object that strictly follows the schema abo
 	if err := json.Unmarshal(resp, &generateResp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	c.recordUsage(ctx, generateResp.Model, generateResp.Usage, 0)
 
 	if len(generateResp.Choices) == 0 {
 		return "", fmt.Errorf("no choices in response")
@@ -231,72 +283,43 @@ Ensure your response is a valid JSON object that strictly follows the schema abo
 	return generateResp.Choices[0].Text, nil
 }
 
-// GenerateWithTools generates text and can use tools
-func (c *VLLMClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
-	// For now, vLLM doesn't support tool calling in the same way as OpenAI/Anthropic
-	// We'll implement a basic version that includes tool descriptions in the prompt
-	if len(tools) == 0 {
-		return c.Generate(ctx, prompt, options...)
-	}
-
-	// Build tool descriptions
-	var toolDescriptions []string
-	for _, tool := range tools {
-		toolDescriptions = append(toolDescriptions, fmt.Sprintf("- %s: %s", tool.Name(), tool.Description()))
-	}
-
-	// Create enhanced prompt with tool information
-	enhancedPrompt := fmt.Sprintf(`%s
-
-Available tools:
-%s
-
-Please respond to the user's request. If you need to use any tools, describe what you would do.`, prompt, strings.Join(toolDescriptions, "\n"))
-
-	return c.Generate(ctx, enhancedPrompt, options...)
-}
-
-// Chat performs a chat completion with messages
+// Chat performs a chat completion with messages.
+// It sends the messages with ChatMessages; DefaultGenerateParams are used if
+// params is nil.
 func (c *VLLMClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
 	if params == nil {
 		params = llm.DefaultGenerateParams()
 	}
+	return c.ChatMessages(ctx, llm.ConvertMessages(messages), params.Options()...)
+}
 
-	// Convert messages to vLLM format
-	var chatMessages []ChatMessage
-	for _, msg := range messages {
-		chatMessages = append(chatMessages, ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
-
-	// Create request
-	req := ChatRequest{
-		Model:       c.Model,
-		Messages:    chatMessages,
-		Stream:      false,
-		Temperature: params.Temperature,
-		TopP:        params.TopP,
-		Stop:        params.StopSequences,
-	}
-
-	// Make request
-	resp, err := c.makeRequest(ctx, "/v1/chat/completions", req)
+// ChatMessages generates the next assistant message of a conversation.
+// Assistant tool calls and tool results in the messages are sent as they are.
+func (c *VLLMClient) ChatMessages(ctx context.Context, messages []interfaces.Message, options ...interfaces.GenerateOption) (string, error) {
+	prompt, options, err := llm.ChatOptions(messages, options...)
 	if err != nil {
 		return "", fmt.Errorf("failed to chat: %w", err)
 	}
+	params := newGenerateOptions(options)
 
-	var chatResp ChatResponse
-	if err := json.Unmarshal(resp, &chatResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal chat response: %w", err)
-	}
-
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in chat response")
+	message, err := c.chat(ctx, c.newChatRequest(c.buildChatMessages(ctx, prompt, params), params.LLMConfig), 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to chat: %w", err)
 	}
+	return message.Content, nil
+}
 
-	return chatResp.Choices[0].Message.Content, nil
+// recordUsage reports the token usage of a response to the usage collector in the context
+func (c *VLLMClient) recordUsage(ctx context.Context, model string, usage Usage, iteration int) {
+	if model == "" {
+		model = c.Model
+	}
+	llm.AddUsageToContext(ctx, llm.UsageRecord{
+		Provider:  c.Name(),
+		Model:     model,
+		Iteration: iteration,
+		Usage:     usage.ToTokenUsage(),
+	})
 }
 
 // Name returns the name of the LLM provider
@@ -304,9 +327,20 @@ func (c *VLLMClient) Name() string {
 	return "vllm"
 }
 
-// SupportsStreaming returns false as streaming is not yet implemented for VLLM
+// GetModel returns the model name being used
+func (c *VLLMClient) GetModel() string {
+	return c.Model
+}
+
+// ToolCallingMode returns how the model calls tools. In auto mode, models
+// that llm.LookupModel knows to lack native tool calling use ReAct directly.
+func (c *VLLMClient) ToolCallingMode() llm.ToolCallingMode {
+	return llm.ResolveToolCallingMode(c.toolMode, c.Model)
+}
+
+// SupportsStreaming returns true as vLLM streams chat completions
 func (c *VLLMClient) SupportsStreaming() bool {
-	return false
+	return true
 }
 
 // makeRequest makes an HTTP request to the vLLM API
@@ -317,89 +351,63 @@ func (c *VLLMClient) makeRequest(ctx context.Context, endpoint string, payload i
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute request with retry if configured
-	var resp *http.Response
-	if c.retryExecutor != nil {
-		err = c.retryExecutor.Execute(ctx, func() error {
-			var execErr error
-			resp, execErr = c.HTTPClient.Do(req)
-			return execErr
-		})
-	} else {
-		resp, err = c.HTTPClient.Do(req)
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() {
-		if resp != nil {
-			_ = resp.Body.Close()
+	// Execute request with retry if configured. Only rate limits and
+	// transient failures are retried.
+	return c.doRequest(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
 		}
-	}()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return body, nil
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 }
 
 // makeGETRequest makes a GET request to the vLLM API
 func (c *VLLMClient) makeGETRequest(ctx context.Context, endpoint string) ([]byte, error) {
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	return c.doRequest(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", c.BaseURL+endpoint, nil)
+	})
+}
 
-	// Execute request with retry if configured
-	var resp *http.Response
-	if c.retryExecutor != nil {
-		err = c.retryExecutor.Execute(ctx, func() error {
-			var execErr error
-			resp, execErr = c.HTTPClient.Do(req)
-			return execErr
-		})
-	} else {
-		resp, err = c.HTTPClient.Do(req)
-	}
+// doRequest sends the request built by newRequest, with retry if configured,
+// and returns the response body. Failed requests return an
+// *interfaces.ProviderError.
+func (c *VLLMClient) doRequest(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	var body []byte
+	operation := func() error {
+		req, err := newRequest()
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer func() {
-		if resp != nil {
-			_ = resp.Body.Close()
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to execute request: %w", llm.ClassifyError(c.Name(), err))
 		}
-	}()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("API request failed: %w", llm.NewHTTPError(c.Name(), resp.StatusCode, resp.Header, body))
+		}
+		return nil
 	}
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	var err error
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, operation)
+	} else {
+		err = operation()
+	}
+	if err != nil {
+		return nil, err
 	}
-
 	return body, nil
 }
 
@@ -2,9 +2,13 @@ package vllm
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 	"github.com/stretchr/testify/assert"
 )
@@ -382,3 +386,127 @@ func TestClientWithAllOptions(t *testing.T) {
 	assert.NotNil(t, client.retryExecutor)
 	assert.NotNil(t, client.HTTPClient)
 }
+
+func TestChatRecordsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chat-1",
+			"model": "mistral-7b",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "hi"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 3, "total_tokens": 15}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("mistral-7b"))
+	ctx := llm.WithUsageCollection(context.Background())
+
+	resp, err := client.Chat(ctx, []llm.Message{{Role: "user", Content: "hello"}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", resp)
+
+	records := llm.GetUsageFromContext(ctx)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "vllm", records[0].Provider)
+		assert.Equal(t, "mistral-7b", records[0].Model)
+		assert.Equal(t, llm.TokenUsage{InputTokens: 12, OutputTokens: 3, TotalTokens: 15}, records[0].Usage)
+	}
+}
+
+// echoTool returns its arguments prefixed with its name
+type echoTool struct{}
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Echoes its input" }
+func (t *echoTool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+func (t *echoTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{
+		"text": {Type: "string", Description: "Text to echo", Required: true},
+	}
+}
+func (t *echoTool) Execute(ctx context.Context, args string) (string, error) {
+	return "echo: " + args, nil
+}
+
+func TestGenerateWithTools(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		var req ChatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(requests) == 1 {
+			_, _ = w.Write([]byte(`{"model": "qwen", "choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+				"role": "assistant", "content": null,
+				"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "echo", "arguments": "{\"text\": \"hi\"}"}}]
+			}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"model": "qwen", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "done"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("qwen"))
+	ctx := llm.WithUsageCollection(context.Background())
+
+	resp, err := client.GenerateWithTools(ctx, "say hi", []interfaces.Tool{&echoTool{}}, interfaces.WithSystemMessage("be brief"))
+	assert.NoError(t, err)
+	assert.Equal(t, "done", resp)
+
+	if assert.Len(t, requests, 2) {
+		if assert.Len(t, requests[0].Tools, 1) {
+			assert.Equal(t, "echo", requests[0].Tools[0].Function.Name)
+		}
+		assert.Equal(t, "system", requests[0].Messages[0].Role)
+		assert.Equal(t, "say hi", requests[0].Messages[1].Content)
+
+		second := requests[1].Messages
+		assert.Equal(t, "call_1", second[2].ToolCalls[0].ID)
+		assert.Equal(t, ChatMessage{Role: "tool", Content: `echo: {"text": "hi"}`, ToolCallID: "call_1"}, second[3])
+	}
+}
+
+func TestGenerateWithToolsReActFallback(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		if len(req.Tools) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"object": "error", "message": "\"auto\" tool choice requires --enable-auto-tool-choice and --tool-call-parser to be set"}`))
+			return
+		}
+
+		content := "Thought: I should echo\nAction: echo\nAction Input: {\"text\": \"hi\"}\nObservation: made up"
+		if len(requests) > 2 {
+			content = "Final Answer: it echoed hi"
+		}
+		resp, _ := json.Marshal(map[string]interface{}{
+			"model":   "qwen",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": content}}},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("qwen"))
+
+	resp, err := client.GenerateWithTools(context.Background(), "say hi", []interfaces.Tool{&echoTool{}})
+	assert.NoError(t, err)
+	assert.Equal(t, "it echoed hi", resp)
+
+	if assert.Len(t, requests, 3) {
+		observation := requests[2].Messages[len(requests[2].Messages)-1]
+		assert.Equal(t, `Observation: echo: {"text": "hi"}`, observation.Content)
+		assert.NotContains(t, requests[2].Messages[len(requests[2].Messages)-2].Content, "made up")
+	}
+}
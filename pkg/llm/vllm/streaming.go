@@ -0,0 +1,457 @@
+package vllm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// StreamOptions controls what vLLM includes in a streamed response
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatStreamResponse is one server-sent event of a streamed chat completion
+type ChatStreamResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index        int       `json:"index"`
+		Delta        ChatDelta `json:"delta"`
+		FinishReason string    `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+
+	// Set when the server reports an error mid-stream
+	Message string `json:"message,omitempty"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// ChatDelta is the incremental part of a streamed chat message
+type ChatDelta struct {
+	Role             string          `json:"role,omitempty"`
+	Content          string          `json:"content,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+	Reasoning        string          `json:"reasoning,omitempty"`
+	ToolCalls        []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// ToolCallDelta is a fragment of a streamed tool call. The ID and name arrive
+// with the first fragment of each call, the arguments across all of them.
+type ToolCallDelta struct {
+	Index    int              `json:"index"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// GenerateStream implements interfaces.StreamingLLM.GenerateStream
+func (c *VLLMClient) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	params := newGenerateOptions(options)
+	eventChan := make(chan interfaces.StreamEvent, streamBufferSize(params))
+
+	go func() {
+		defer close(eventChan)
+
+		if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStart,
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"model": c.Model},
+		}) {
+			return
+		}
+
+		req := c.newChatRequest(c.buildChatMessages(ctx, prompt, params), params.LLMConfig)
+		if _, err := c.streamChat(ctx, req, 0, eventChan); err != nil {
+			sendError(ctx, eventChan, err)
+			return
+		}
+
+		sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStop,
+			Timestamp: time.Now(),
+		})
+	}()
+
+	return eventChan, nil
+}
+
+// GenerateWithToolsStream implements interfaces.StreamingLLM.GenerateWithToolsStream.
+// Tool calls are executed by the client in a loop, emitting tool_use and
+// tool_result events, until the model answers or MaxIterations is reached.
+// When the server does not support native tools, the ReAct fallback runs
+// without streaming and its final answer is emitted as a single delta.
+func (c *VLLMClient) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	if len(tools) == 0 {
+		return c.GenerateStream(ctx, prompt, options...)
+	}
+
+	params := newGenerateOptions(options)
+	eventChan := make(chan interfaces.StreamEvent, streamBufferSize(params))
+
+	go func() {
+		defer close(eventChan)
+
+		if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStart,
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"model": c.Model},
+		}) {
+			return
+		}
+
+		var err error
+		if c.ToolCallingMode() == llm.ToolCallingReAct {
+			err = c.streamReAct(ctx, prompt, tools, params, eventChan)
+		} else {
+			err = c.streamWithNativeTools(ctx, prompt, tools, params, eventChan)
+			if err != nil && c.toolMode != llm.ToolCallingNative && isToolsUnsupportedError(err) {
+				c.logger.Warn(ctx, "Server does not support native tool calling, falling back to ReAct", map[string]interface{}{
+					"model": c.Model,
+				})
+				err = c.streamReAct(ctx, prompt, tools, params, eventChan)
+			}
+		}
+		if err != nil {
+			sendError(ctx, eventChan, err)
+			return
+		}
+
+		sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventMessageStop,
+			Timestamp: time.Now(),
+		})
+	}()
+
+	return eventChan, nil
+}
+
+// streamWithNativeTools runs the streaming tool loop using vLLM's native tool calling
+func (c *VLLMClient) streamWithNativeTools(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions, eventChan chan<- interfaces.StreamEvent) error {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2 // Default to current behavior
+	}
+
+	vllmTools := convertTools(tools)
+	messages := c.buildChatMessages(ctx, prompt, params)
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		req := c.newChatRequest(messages, params.LLMConfig)
+		req.Tools = vllmTools
+		req.ToolChoice = toolChoiceParam(params.ToolChoice.ForTurn(iteration + 1))
+
+		message, err := c.streamChat(ctx, req, iteration+1, eventChan)
+		if err != nil {
+			return fmt.Errorf("failed to stream with tools (iteration %d): %w", iteration+1, err)
+		}
+
+		if len(message.ToolCalls) == 0 {
+			return nil
+		}
+		messages = append(messages, *message)
+
+		calls := convertToolCalls(message.ToolCalls)
+		results := executeToolCalls(ctx, calls, tools, params)
+		for i, call := range calls {
+			if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+				Type:      interfaces.StreamEventToolResult,
+				ToolCall:  &calls[i],
+				Content:   results[i],
+				Timestamp: time.Now(),
+				Metadata:  map[string]interface{}{"iteration": iteration + 1},
+			}) {
+				return ctx.Err()
+			}
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    results[i],
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	// Ask for a final answer without tools
+	c.logger.Info(ctx, "Maximum iterations reached, making final call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+	messages = append(messages, ChatMessage{
+		Role:    "user",
+		Content: "Please provide your final response based on the information available. Do not request any additional tools.",
+	})
+	if _, err := c.streamChat(ctx, c.newChatRequest(messages, params.LLMConfig), maxIterations+1, eventChan); err != nil {
+		return fmt.Errorf("failed to stream final response: %w", err)
+	}
+	return nil
+}
+
+// streamReAct runs the ReAct fallback and emits its final answer
+func (c *VLLMClient) streamReAct(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions, eventChan chan<- interfaces.StreamEvent) error {
+	response, err := c.generateWithReAct(ctx, prompt, tools, params)
+	if err != nil {
+		return err
+	}
+	if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+		Type:      interfaces.StreamEventContentDelta,
+		Content:   response,
+		Timestamp: time.Now(),
+	}) {
+		return ctx.Err()
+	}
+	if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+		Type:      interfaces.StreamEventContentComplete,
+		Timestamp: time.Now(),
+	}) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// streamChat sends a streaming chat request and emits content and thinking
+// events as chunks arrive. Tool call fragments are accumulated and emitted as
+// tool_use events once the stream is done. It returns the complete assistant
+// message, without thinking.
+func (c *VLLMClient) streamChat(ctx context.Context, req ChatRequest, iteration int, eventChan chan<- interfaces.StreamEvent) (*ChatMessage, error) {
+	req.Stream = true
+	req.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	resp, err := c.makeStreamRequest(ctx, "/v1/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var content strings.Builder
+	var thinkParser llm.ThinkTagParser
+	toolCalls := make(map[int]*ToolCall)
+	var model, finishReason string
+	var usage *Usage
+
+	emitSegments := func(segments []llm.ThinkSegment) bool {
+		for _, segment := range segments {
+			event := interfaces.StreamEvent{
+				Type:      interfaces.StreamEventContentDelta,
+				Content:   segment.Text,
+				Timestamp: time.Now(),
+			}
+			if segment.Thinking {
+				event.Type = interfaces.StreamEventThinking
+			} else {
+				content.WriteString(segment.Text)
+			}
+			if !sendEvent(ctx, eventChan, event) {
+				return false
+			}
+		}
+		return true
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk ChatStreamResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil || chunk.Object == "error" {
+			return nil, fmt.Errorf("vllm streaming error: %w", llm.NewHTTPError(c.Name(), 0, nil, []byte(data)))
+		}
+
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+
+			delta := choice.Delta
+			reasoning := delta.ReasoningContent
+			if reasoning == "" {
+				reasoning = delta.Reasoning
+			}
+			if reasoning != "" {
+				if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+					Type:      interfaces.StreamEventThinking,
+					Content:   reasoning,
+					Timestamp: time.Now(),
+				}) {
+					return nil, ctx.Err()
+				}
+			}
+			if delta.Content != "" && !emitSegments(thinkParser.Feed(delta.Content)) {
+				return nil, ctx.Err()
+			}
+
+			for _, fragment := range delta.ToolCalls {
+				toolCall, ok := toolCalls[fragment.Index]
+				if !ok {
+					toolCall = &ToolCall{Type: "function"}
+					toolCalls[fragment.Index] = toolCall
+				}
+				if fragment.ID != "" {
+					toolCall.ID = fragment.ID
+				}
+				if fragment.Function.Name != "" {
+					toolCall.Function.Name = fragment.Function.Name
+				}
+				toolCall.Function.Arguments += fragment.Function.Arguments
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	if !emitSegments(thinkParser.Flush()) {
+		return nil, ctx.Err()
+	}
+	if usage != nil {
+		c.recordUsage(ctx, model, *usage, iteration)
+	}
+
+	message := &ChatMessage{Role: "assistant", Content: content.String()}
+
+	indexes := make([]int, 0, len(toolCalls))
+	for index := range toolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		toolCall := toolCalls[index]
+		if toolCall.ID == "" {
+			toolCall.ID = fmt.Sprintf("call_%d_%d", iteration, index)
+		}
+		if toolCall.Function.Arguments == "" {
+			toolCall.Function.Arguments = "{}"
+		}
+		message.ToolCalls = append(message.ToolCalls, *toolCall)
+
+		if !sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type: interfaces.StreamEventToolUse,
+			ToolCall: &interfaces.ToolCall{
+				ID:        toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			},
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"iteration": iteration},
+		}) {
+			return nil, ctx.Err()
+		}
+	}
+
+	if len(message.ToolCalls) == 0 {
+		metadata := map[string]interface{}{"finish_reason": finishReason}
+		if usage != nil {
+			metadata["usage"] = map[string]interface{}{
+				"prompt_tokens":     usage.PromptTokens,
+				"completion_tokens": usage.CompletionTokens,
+				"total_tokens":      usage.TotalTokens,
+			}
+		}
+		sendEvent(ctx, eventChan, interfaces.StreamEvent{
+			Type:      interfaces.StreamEventContentComplete,
+			Timestamp: time.Now(),
+			Metadata:  metadata,
+		})
+	}
+
+	return message, nil
+}
+
+// makeStreamRequest starts a streaming HTTP request to the vLLM API. The
+// caller must close the response body.
+func (c *VLLMClient) makeStreamRequest(ctx context.Context, endpoint string, payload interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp *http.Response
+	do := func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err = c.HTTPClient.Do(req)
+		if err != nil {
+			return llm.ClassifyError(c.Name(), err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return fmt.Errorf("API request failed: %w", llm.NewHTTPError(c.Name(), resp.StatusCode, resp.Header, body))
+		}
+		return nil
+	}
+
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, do)
+	} else {
+		err = do()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return resp, nil
+}
+
+// streamBufferSize returns the event channel buffer size from the stream config
+func streamBufferSize(params *interfaces.GenerateOptions) int {
+	if params.StreamConfig != nil && params.StreamConfig.BufferSize > 0 {
+		return params.StreamConfig.BufferSize
+	}
+	return 100
+}
+
+// sendEvent sends an event unless the context is done and reports whether it was sent
+func sendEvent(ctx context.Context, eventChan chan<- interfaces.StreamEvent, event interfaces.StreamEvent) bool {
+	select {
+	case eventChan <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendError sends an error event
+func sendError(ctx context.Context, eventChan chan<- interfaces.StreamEvent, err error) {
+	sendEvent(ctx, eventChan, interfaces.StreamEvent{
+		Type:      interfaces.StreamEventError,
+		Error:     err,
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,160 @@
+package vllm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSSE writes each chunk as a server-sent event followed by [DONE]
+func writeSSE(w http.ResponseWriter, chunks ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, chunk := range chunks {
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		w.(http.Flusher).Flush()
+	}
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func collectEvents(t *testing.T, eventChan <-chan interfaces.StreamEvent) []interfaces.StreamEvent {
+	var events []interfaces.StreamEvent
+	for event := range eventChan {
+		require.NoError(t, event.Error)
+		events = append(events, event)
+	}
+	return events
+}
+
+func eventText(events []interfaces.StreamEvent, eventType interfaces.StreamEventType) string {
+	var sb strings.Builder
+	for _, event := range events {
+		if event.Type == eventType {
+			sb.WriteString(event.Content)
+		}
+	}
+	return sb.String()
+}
+
+func TestGenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		var req ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+		require.NotNil(t, req.StreamOptions)
+		assert.True(t, req.StreamOptions.IncludeUsage)
+
+		writeSSE(w,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"role":"assistant","content":"<think>The user"}}]}`,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"content":" says hi</thi"}}]}`,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"content":"nk>Hello"}}]}`,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"content":" there!"},"finish_reason":"stop"}]}`,
+			`{"model":"qwen","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+		)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("qwen"))
+	assert.True(t, client.SupportsStreaming())
+
+	ctx := llm.WithUsageCollection(context.Background())
+	eventChan, err := client.GenerateStream(ctx, "hi")
+	require.NoError(t, err)
+	events := collectEvents(t, eventChan)
+
+	assert.Equal(t, interfaces.StreamEventMessageStart, events[0].Type)
+	assert.Equal(t, interfaces.StreamEventMessageStop, events[len(events)-1].Type)
+	assert.Equal(t, "The user says hi", eventText(events, interfaces.StreamEventThinking))
+	assert.Equal(t, "Hello there!", eventText(events, interfaces.StreamEventContentDelta))
+	assert.Equal(t, interfaces.StreamEventContentComplete, events[len(events)-2].Type)
+	assert.Equal(t, "stop", events[len(events)-2].Metadata["finish_reason"])
+
+	records := llm.GetUsageFromContext(ctx)
+	require.Len(t, records, 1)
+	assert.Equal(t, 15, records[0].Usage.TotalTokens)
+}
+
+func TestGenerateWithToolsStream(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		if len(requests) == 1 {
+			writeSSE(w,
+				`{"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"echo","arguments":""}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"text\": "}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"hi\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			)
+			return
+		}
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"reasoning_content":"Got the result."}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"It echoed hi"},"finish_reason":"stop"}]}`,
+		)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithModel("qwen"))
+
+	eventChan, err := client.GenerateWithToolsStream(context.Background(), "say hi", []interfaces.Tool{&echoTool{}})
+	require.NoError(t, err)
+	events := collectEvents(t, eventChan)
+
+	var toolUse, toolResult *interfaces.StreamEvent
+	for i := range events {
+		switch events[i].Type {
+		case interfaces.StreamEventToolUse:
+			toolUse = &events[i]
+		case interfaces.StreamEventToolResult:
+			toolResult = &events[i]
+		}
+	}
+	require.NotNil(t, toolUse)
+	assert.Equal(t, "call_1", toolUse.ToolCall.ID)
+	assert.Equal(t, "echo", toolUse.ToolCall.Name)
+	assert.Equal(t, `{"text": "hi"}`, toolUse.ToolCall.Arguments)
+	require.NotNil(t, toolResult)
+	assert.Equal(t, `echo: {"text": "hi"}`, toolResult.Content)
+
+	assert.Equal(t, "Got the result.", eventText(events, interfaces.StreamEventThinking))
+	assert.Equal(t, "It echoed hi", eventText(events, interfaces.StreamEventContentDelta))
+	assert.Equal(t, interfaces.StreamEventMessageStop, events[len(events)-1].Type)
+
+	require.Len(t, requests, 2)
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, ChatMessage{Role: "tool", Content: `echo: {"text": "hi"}`, ToolCallID: "call_1"}, last)
+}
+
+func TestGenerateStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"content":"partial"}}]}`,
+			`{"object":"error","message":"engine dead"}`,
+		)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	eventChan, err := client.GenerateStream(context.Background(), "hi")
+	require.NoError(t, err)
+
+	var streamErr error
+	for event := range eventChan {
+		if event.Type == interfaces.StreamEventError {
+			streamErr = event.Error
+		}
+	}
+	require.Error(t, streamErr)
+	assert.Contains(t, streamErr.Error(), "engine dead")
+}
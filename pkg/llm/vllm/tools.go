@@ -0,0 +1,280 @@
+package vllm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm"
+)
+
+// GenerateWithTools generates text and can use tools. Tools are passed to the
+// OpenAI-compatible /v1/chat/completions endpoint and executed in a loop until
+// the model answers without calling a tool or MaxIterations is reached. When
+// the server was not started with tool support, the client falls back to a
+// ReAct prompt, see WithToolCallingMode.
+func (c *VLLMClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	if len(tools) == 0 {
+		return c.Generate(ctx, prompt, options...)
+	}
+
+	params := newGenerateOptions(options)
+	if c.ToolCallingMode() == llm.ToolCallingReAct {
+		return c.generateWithReAct(ctx, prompt, tools, params)
+	}
+
+	response, err := c.generateWithNativeTools(ctx, prompt, tools, params)
+	if err != nil && c.toolMode != llm.ToolCallingNative && isToolsUnsupportedError(err) {
+		c.logger.Warn(ctx, "Server does not support native tool calling, falling back to ReAct", map[string]interface{}{
+			"model": c.Model,
+		})
+		return c.generateWithReAct(ctx, prompt, tools, params)
+	}
+	return response, err
+}
+
+// newGenerateOptions applies the options on top of the client defaults
+func newGenerateOptions(options []interfaces.GenerateOption) *interfaces.GenerateOptions {
+	params := &interfaces.GenerateOptions{
+		LLMConfig: &interfaces.LLMConfig{
+			Temperature: 0.7,
+		},
+	}
+	for _, option := range options {
+		option(params)
+	}
+	if params.LLMConfig == nil {
+		params.LLMConfig = &interfaces.LLMConfig{}
+	}
+	return params
+}
+
+// generateWithNativeTools runs the tool loop using vLLM's native tool calling
+func (c *VLLMClient) generateWithNativeTools(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions) (string, error) {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2 // Default to current behavior
+	}
+
+	vllmTools := convertTools(tools)
+	messages := c.buildChatMessages(ctx, prompt, params)
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		req := c.newChatRequest(messages, params.LLMConfig)
+		req.Tools = vllmTools
+		req.ToolChoice = toolChoiceParam(params.ToolChoice.ForTurn(iteration + 1))
+
+		message, err := c.chat(ctx, req, iteration+1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate with tools (iteration %d): %w", iteration+1, err)
+		}
+
+		if len(message.ToolCalls) == 0 {
+			return message.Content, nil
+		}
+
+		c.logger.Info(ctx, "Processing tool calls", map[string]interface{}{
+			"count":     len(message.ToolCalls),
+			"iteration": iteration + 1,
+		})
+		messages = append(messages, *message)
+
+		calls := convertToolCalls(message.ToolCalls)
+		results := executeToolCalls(ctx, calls, tools, params)
+		for i, call := range calls {
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    results[i],
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	// Ask for a final answer without tools
+	c.logger.Info(ctx, "Maximum iterations reached, making final call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+	messages = append(messages, ChatMessage{
+		Role:    "user",
+		Content: "Please provide your final response based on the information available. Do not request any additional tools.",
+	})
+	message, err := c.chat(ctx, c.newChatRequest(messages, params.LLMConfig), maxIterations+1)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate final response: %w", err)
+	}
+	return message.Content, nil
+}
+
+// convertToolCalls converts vLLM tool calls to the common format
+func convertToolCalls(toolCalls []ToolCall) []interfaces.ToolCall {
+	calls := make([]interfaces.ToolCall, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		calls[i] = interfaces.ToolCall{
+			ID:        toolCall.ID,
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		}
+	}
+	return calls
+}
+
+// executeToolCalls runs the tool calls of one turn, concurrently if enabled,
+// and returns the content for each result in the order the model requested them
+func executeToolCalls(ctx context.Context, calls []interfaces.ToolCall, tools []interfaces.Tool, params *interfaces.GenerateOptions) []string {
+	executions := make([]*llm.ToolExecution, len(calls))
+	for i, call := range calls {
+		executions[i] = &llm.ToolExecution{
+			Tool:      llm.FindTool(tools, call.Name),
+			Arguments: call.Arguments,
+		}
+	}
+	llm.ExecuteTools(ctx, executions, params.MaxParallelToolCalls)
+
+	results := make([]string, len(calls))
+	for i, call := range calls {
+		results[i] = llm.RecordToolExecution(ctx, call, executions[i], params.Memory)
+	}
+	return results
+}
+
+// generateWithReAct runs the tool loop with a text-based ReAct prompt
+func (c *VLLMClient) generateWithReAct(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions) (string, error) {
+	return llm.RunReActLoop(ctx, func(ctx context.Context, req llm.ReActRequest) (string, error) {
+		messages := make([]ChatMessage, len(req.Messages))
+		for i, msg := range req.Messages {
+			messages[i] = ChatMessage{Role: msg.Role, Content: msg.Content}
+		}
+
+		chatReq := c.newChatRequest(messages, params.LLMConfig)
+		chatReq.Stop = req.StopSequences
+
+		message, err := c.chat(ctx, chatReq, req.Iteration)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate with tools (iteration %d): %w", req.Iteration, err)
+		}
+		return message.Content, nil
+	}, prompt, tools, params, c.logger)
+}
+
+// newChatRequest creates a non-streaming chat request with the given sampling config
+func (c *VLLMClient) newChatRequest(messages []ChatMessage, config *interfaces.LLMConfig) ChatRequest {
+	return ChatRequest{
+		Model:             c.Model,
+		Messages:          messages,
+		Stream:            false,
+		Temperature:       config.Temperature,
+		TopP:              config.TopP,
+		TopK:              config.TopK,
+		Stop:              config.StopSequences,
+		RepetitionPenalty: config.RepeatPenalty,
+	}
+}
+
+// chat sends a chat request, records its token usage and returns the first choice
+func (c *VLLMClient) chat(ctx context.Context, req ChatRequest, iteration int) (*ChatMessage, error) {
+	resp, err := c.makeRequest(ctx, "/v1/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(resp, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chat response: %w", err)
+	}
+	c.recordUsage(ctx, chatResp.Model, chatResp.Usage, iteration)
+
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in chat response")
+	}
+	return &chatResp.Choices[0].Message, nil
+}
+
+// buildChatMessages builds the chat history from the system message, memory
+// and prompt. As with the other providers, the prompt is taken from memory
+// when it has any messages because the agent has already stored it there.
+func (c *VLLMClient) buildChatMessages(ctx context.Context, prompt string, params *interfaces.GenerateOptions) []ChatMessage {
+	var messages []ChatMessage
+	if params.SystemMessage != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: params.SystemMessage})
+	}
+
+	if params.Memory == nil {
+		return append(messages, ChatMessage{Role: "user", Content: prompt})
+	}
+
+	memoryMessages, err := params.Memory.GetMessages(ctx)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to retrieve memory messages", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return append(messages, ChatMessage{Role: "user", Content: prompt})
+	}
+	if len(memoryMessages) == 0 {
+		return append(messages, ChatMessage{Role: "user", Content: prompt})
+	}
+
+	for _, msg := range memoryMessages {
+		chatMsg := ChatMessage{Role: string(msg.Role), Content: msg.Content}
+		switch msg.Role {
+		case interfaces.MessageRoleAssistant:
+			for _, toolCall := range msg.ToolCalls {
+				chatMsg.ToolCalls = append(chatMsg.ToolCalls, ToolCall{
+					ID:       toolCall.ID,
+					Type:     "function",
+					Function: ToolCallFunction{Name: toolCall.Name, Arguments: toolCall.Arguments},
+				})
+			}
+			if chatMsg.Content == "" && len(chatMsg.ToolCalls) == 0 {
+				continue
+			}
+		case interfaces.MessageRoleTool:
+			if msg.ToolCallID == "" {
+				continue
+			}
+			chatMsg.ToolCallID = msg.ToolCallID
+		}
+		messages = append(messages, chatMsg)
+	}
+
+	return messages
+}
+
+// convertTools converts tools to OpenAI-compatible function definitions
+func convertTools(tools []interfaces.Tool) []Tool {
+	vllmTools := make([]Tool, len(tools))
+	for i, tool := range tools {
+		vllmTools[i] = Tool{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  llm.ToolParametersSchema(tool),
+			},
+		}
+	}
+	return vllmTools
+}
+
+// toolChoiceParam converts a tool choice to the OpenAI-compatible tool_choice
+// parameter. A nil choice is left to the server default.
+func toolChoiceParam(choice *interfaces.ToolChoice) interface{} {
+	if choice == nil {
+		return nil
+	}
+	if choice.Mode == interfaces.ToolChoiceModeTool {
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": choice.Name},
+		}
+	}
+	return string(choice.Mode)
+}
+
+// isToolsUnsupportedError reports whether vLLM rejected a request because the
+// server was started without automatic tool choice
+func isToolsUnsupportedError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "enable-auto-tool-choice") || strings.Contains(msg, "tool-call-parser")
+}
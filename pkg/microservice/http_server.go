@@ -3,6 +3,7 @@ package microservice
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -30,6 +31,22 @@ type StreamRequest struct {
 	MaxIterations  int               `json:"max_iterations,omitempty"`
 }
 
+// RunResponse represents the JSON response of a run: its output and trace
+type RunResponse struct {
+	*agent.RunResult
+	Agent string `json:"agent"`
+	Error string `json:"error,omitempty"`
+}
+
+// ResolveApprovalRequest represents the JSON request for deciding on a tool
+// call waiting for approval
+type ResolveApprovalRequest struct {
+	Approved  bool   `json:"approved"`
+	Arguments string `json:"arguments,omitempty"` // Replaces the arguments of an approved call when set
+	Reason    string `json:"reason,omitempty"`
+	OrgID     string `json:"org_id,omitempty"`
+}
+
 // SSEEvent represents a Server-Sent Event
 type SSEEvent struct {
 	Event     string      `json:"event"`
@@ -41,14 +58,15 @@ type SSEEvent struct {
 
 // StreamEventData represents the data structure for streaming events
 type StreamEventData struct {
-	Type         string                 `json:"type"`
-	Content      string                 `json:"content,omitempty"`
-	ThinkingStep string                 `json:"thinking_step,omitempty"`
-	ToolCall     *ToolCallData          `json:"tool_call,omitempty"`
-	Error        string                 `json:"error,omitempty"`
-	Metadata     map[string]interface{} `json:"metadata,omitempty"`
-	IsFinal      bool                   `json:"is_final"`
-	Timestamp    int64                  `json:"timestamp"`
+	Type         string                   `json:"type"`
+	Content      string                   `json:"content,omitempty"`
+	ThinkingStep string                   `json:"thinking_step,omitempty"`
+	ToolCall     *ToolCallData            `json:"tool_call,omitempty"`
+	Approval     *interfaces.ToolApproval `json:"approval,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+	Metadata     map[string]interface{}   `json:"metadata,omitempty"`
+	IsFinal      bool                     `json:"is_final"`
+	Timestamp    int64                    `json:"timestamp"`
 }
 
 // ToolCallData represents tool call information for HTTP/SSE
@@ -80,6 +98,8 @@ func (h *HTTPServer) Start() error {
 	mux.HandleFunc("/api/v1/agent/run", h.handleRun)
 	mux.HandleFunc("/api/v1/agent/stream", h.handleStream)
 	mux.HandleFunc("/api/v1/agent/metadata", h.handleMetadata)
+	mux.HandleFunc("/api/v1/agent/approvals", h.handleListApprovals)
+	mux.HandleFunc("/api/v1/agent/approvals/{id}", h.handleResolveApproval)
 
 	// Serve static files for browser example (if they exist)
 	mux.Handle("/", http.FileServer(http.Dir("./web/")))
@@ -97,6 +117,8 @@ func (h *HTTPServer) Start() error {
 	fmt.Printf("  - POST /api/v1/agent/run (non-streaming)\n")
 	fmt.Printf("  - POST /api/v1/agent/stream (SSE streaming)\n")
 	fmt.Printf("  - GET /api/v1/agent/metadata\n")
+	fmt.Printf("  - GET /api/v1/agent/approvals\n")
+	fmt.Printf("  - POST /api/v1/agent/approvals/{id}\n")
 	fmt.Printf("  - GET /health\n")
 
 	return h.server.ListenAndServe()
@@ -174,21 +196,23 @@ func (h *HTTPServer) handleRun(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Execute agent
-	result, err := h.agent.Run(ctx, req.Input)
+	result, err := h.agent.RunDetailed(ctx, req.Input)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"error": err.Error(),
+		_ = json.NewEncoder(w).Encode(RunResponse{
+			RunResult: result,
+			Agent:     h.agent.GetName(),
+			Error:     err.Error(),
 		})
 		return
 	}
 
-	// Return result
+	// Return the result with the trace of the run
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"output": result,
-		"agent":  h.agent.GetName(),
+	if err := json.NewEncoder(w).Encode(RunResponse{
+		RunResult: result,
+		Agent:     h.agent.GetName(),
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
@@ -297,6 +321,8 @@ func (h *HTTPServer) handleStream(w http.ResponseWriter, r *http.Request) {
 			sseEventType = "tool_result"
 		case interfaces.AgentEventError:
 			sseEventType = "error"
+		case interfaces.AgentEventApprovalRequest:
+			sseEventType = "approval_request"
 		case interfaces.AgentEventComplete:
 			sseEventType = "complete"
 			eventData.IsFinal = true
@@ -342,18 +368,119 @@ func (h *HTTPServer) handleMetadata(w http.ResponseWriter, r *http.Request) {
 			"run",
 			"stream",
 			"metadata",
+			"approvals",
 		},
 		"endpoints": map[string]string{
-			"run":      "/api/v1/agent/run",
-			"stream":   "/api/v1/agent/stream",
-			"metadata": "/api/v1/agent/metadata",
-			"health":   "/health",
+			"run":       "/api/v1/agent/run",
+			"stream":    "/api/v1/agent/stream",
+			"metadata":  "/api/v1/agent/metadata",
+			"approvals": "/api/v1/agent/approvals",
+			"health":    "/health",
 		},
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
 
+// handleListApprovals lists the tool calls waiting for approval
+func (h *HTTPServer) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Approvals are only listed for the organization that asks for them
+	orgID := r.URL.Query().Get("org_id")
+	if orgID == "" {
+		writeOrgRequired(w)
+		return
+	}
+	ctx := multitenancy.WithOrgID(r.Context(), orgID)
+
+	approvals, err := h.agent.PendingApprovals(ctx)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if approvals == nil {
+		approvals = []interfaces.ToolApproval{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"approvals": approvals,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleResolveApproval approves, edits or rejects a tool call waiting for
+// approval, resuming the run that made it
+func (h *HTTPServer) handleResolveApproval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	approvalID := r.PathValue("id")
+	if approvalID == "" {
+		http.Error(w, "Approval ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req ResolveApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Approvals are only decided by the organization of the run that made them
+	if req.OrgID == "" {
+		writeOrgRequired(w)
+		return
+	}
+	ctx := multitenancy.WithOrgID(r.Context(), req.OrgID)
+
+	err := h.agent.ResolveApproval(ctx, approvalID, interfaces.ApprovalDecision{
+		Approved:  req.Approved,
+		Arguments: req.Arguments,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, interfaces.ErrApprovalNotFound) {
+			status = http.StatusNotFound
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":       approvalID,
+		"approved": req.Approved,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// writeOrgRequired rejects an approval request that names no organization
+func writeOrgRequired(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": "org_id is required",
+	})
+}
+
 // convertAgentEventToHTTPEvent converts agent stream events to HTTP event format
 func (h *HTTPServer) convertAgentEventToHTTPEvent(event interfaces.AgentStreamEvent) StreamEventData {
 	eventData := StreamEventData{
@@ -377,6 +504,10 @@ func (h *HTTPServer) convertAgentEventToHTTPEvent(event interfaces.AgentStreamEv
 		}
 	}
 
+	if event.Approval != nil {
+		eventData.Approval = event.Approval
+	}
+
 	if event.Error != nil {
 		eventData.Error = event.Error.Error()
 	}
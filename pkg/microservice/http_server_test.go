@@ -12,7 +12,9 @@ import (
 
 	"github.com/andmang/agent-sdk-go/pkg/agent"
 	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+	"github.com/andmang/agent-sdk-go/pkg/llm/mock"
 	"github.com/andmang/agent-sdk-go/pkg/memory"
+	"github.com/andmang/agent-sdk-go/pkg/multitenancy"
 )
 
 // MockLLM implements a simple mock LLM for testing
@@ -266,6 +268,12 @@ func TestHTTPServer_Run(t *testing.T) {
 	if response["output"] != "Hello, world!" {
 		t.Errorf("Expected output 'Hello, world!', got %v", response["output"])
 	}
+	if response["iterations"] != float64(1) {
+		t.Errorf("Expected 1 iteration, got %v", response["iterations"])
+	}
+	if steps, ok := response["steps"].([]interface{}); !ok || len(steps) != 1 {
+		t.Errorf("Expected the LLM turn in the steps, got %v", response["steps"])
+	}
 }
 
 func TestHTTPServer_Stream(t *testing.T) {
@@ -446,6 +454,143 @@ func TestStreamEventData_Conversion(t *testing.T) {
 	}
 }
 
+// transferTool is a tool whose calls require approval in tests
+type transferTool struct{}
+
+func (t *transferTool) Name() string        { return "transfer" }
+func (t *transferTool) Description() string { return "Transfers money" }
+func (t *transferTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{}
+}
+func (t *transferTool) Run(ctx context.Context, input string) (string, error) {
+	return "transferred " + input, nil
+}
+func (t *transferTool) Execute(ctx context.Context, args string) (string, error) {
+	return t.Run(ctx, args)
+}
+
+func TestHTTPServer_Approvals(t *testing.T) {
+	requests := make(chan interfaces.ToolApproval, 1)
+	agentInstance, err := agent.NewAgent(
+		agent.WithLLM(mock.New(mock.WithTurns(mock.ToolCall("transfer", `{"amount":100}`), mock.Text("done")))),
+		agent.WithTools(&transferTool{}),
+		agent.WithRequirePlanApproval(false),
+		agent.WithApprovalPolicy(agent.RequireApprovalFor("transfer")),
+		agent.WithApprovalHandler(func(ctx context.Context, approval interfaces.ToolApproval) {
+			requests <- approval
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	server := NewHTTPServer(agentInstance, 8080)
+
+	done := make(chan error, 1)
+	go func() {
+		ctx := memory.WithConversationID(multitenancy.WithOrgID(context.Background(), "test-org"), "test-conversation")
+		_, err := agentInstance.Run(ctx, "Transfer 100")
+		done <- err
+	}()
+	approval := <-requests
+
+	// List the pending approvals
+	req := httptest.NewRequest("GET", "/api/v1/agent/approvals?org_id=test-org", nil)
+	w := httptest.NewRecorder()
+	server.handleListApprovals(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var listed struct {
+		Approvals []interfaces.ToolApproval `json:"approvals"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(listed.Approvals) != 1 || listed.Approvals[0].ID != approval.ID || listed.Approvals[0].ToolName != "transfer" {
+		t.Errorf("Expected the pending transfer, got %+v", listed.Approvals)
+	}
+
+	// Approvals are not listed or decided without an organization
+	req = httptest.NewRequest("GET", "/api/v1/agent/approvals", nil)
+	w = httptest.NewRecorder()
+	server.handleListApprovals(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+
+	requestBody, _ := json.Marshal(ResolveApprovalRequest{Approved: true})
+	req = httptest.NewRequest("POST", "/api/v1/agent/approvals/"+approval.ID, bytes.NewBuffer(requestBody))
+	req.SetPathValue("id", approval.ID)
+	w = httptest.NewRecorder()
+	server.handleResolveApproval(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+
+	// Or by another organization
+	requestBody, _ = json.Marshal(ResolveApprovalRequest{Approved: true, OrgID: "other-org"})
+	req = httptest.NewRequest("POST", "/api/v1/agent/approvals/"+approval.ID, bytes.NewBuffer(requestBody))
+	req.SetPathValue("id", approval.ID)
+	w = httptest.NewRecorder()
+	server.handleResolveApproval(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	// Deciding on an unknown approval fails
+	requestBody, _ = json.Marshal(ResolveApprovalRequest{Approved: true, OrgID: "test-org"})
+	req = httptest.NewRequest("POST", "/api/v1/agent/approvals/unknown", bytes.NewBuffer(requestBody))
+	req.SetPathValue("id", "unknown")
+	w = httptest.NewRecorder()
+	server.handleResolveApproval(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	// Approve with edited arguments
+	requestBody, _ = json.Marshal(ResolveApprovalRequest{Approved: true, Arguments: `{"amount":50}`, OrgID: "test-org"})
+	req = httptest.NewRequest("POST", "/api/v1/agent/approvals/"+approval.ID, bytes.NewBuffer(requestBody))
+	req.SetPathValue("id", approval.ID)
+	w = httptest.NewRecorder()
+	server.handleResolveApproval(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The edited call still requires approval
+	edited := <-requests
+	if edited.ID == approval.ID || edited.Arguments != `{"amount":50}` {
+		t.Fatalf("Expected a new approval of the edited call, got %+v", edited)
+	}
+	requestBody, _ = json.Marshal(ResolveApprovalRequest{Approved: true, OrgID: "test-org"})
+	req = httptest.NewRequest("POST", "/api/v1/agent/approvals/"+edited.ID, bytes.NewBuffer(requestBody))
+	req.SetPathValue("id", edited.ID)
+	w = httptest.NewRecorder()
+	server.handleResolveApproval(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// Nothing is pending anymore
+	req = httptest.NewRequest("GET", "/api/v1/agent/approvals?org_id=test-org", nil)
+	w = httptest.NewRecorder()
+	server.handleListApprovals(w, req)
+
+	if !strings.Contains(w.Body.String(), `"approvals":[]`) {
+		t.Errorf("Expected no pending approvals, got %s", w.Body.String())
+	}
+}
+
 // LLMError implements a simple error type for testing
 type LLMError struct {
 	Message string
@@ -2,8 +2,10 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
 	"github.com/andmang/agent-sdk-go/pkg/logging"
 )
 
@@ -21,7 +23,11 @@ func NewExecutor(policy *Policy) *Executor {
 	}
 }
 
-// Execute executes the given operation with retries based on the policy
+// Execute executes the given operation with retries based on the policy.
+// Errors classified as non-retryable LLM provider failures (see
+// interfaces.ProviderError) are returned without retrying, and a backoff
+// requested by the provider through Retry-After is waited out if it is longer
+// than the policy's interval, up to the policy's maximum interval.
 func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 	var lastErr error
 	attempt := int32(0)
@@ -50,6 +56,14 @@ func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 				lastErr = err
 				attempt++
 
+				if !IsRetryable(err) {
+					e.logger.Debug(ctx, "Error is not retryable", map[string]interface{}{
+						"attempt": attempt,
+						"error":   err.Error(),
+					})
+					return err
+				}
+
 				if attempt >= e.policy.MaximumAttempts {
 					e.logger.Debug(ctx, "Maximum attempts reached", map[string]interface{}{
 						"attempt": attempt,
@@ -64,10 +78,13 @@ func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 					nextInterval = e.policy.MaximumInterval
 				}
 
+				delay := Delay(err, currentInterval, e.policy.MaximumInterval)
+
 				e.logger.Debug(ctx, "Operation failed, scheduling retry", map[string]interface{}{
 					"attempt":          attempt,
 					"error":            err.Error(),
 					"current_interval": currentInterval,
+					"delay":            delay,
 					"next_interval":    nextInterval,
 				})
 
@@ -78,7 +95,7 @@ func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 						"error":   ctx.Err(),
 					})
 					return ctx.Err()
-				case <-time.After(currentInterval):
+				case <-time.After(delay):
 					currentInterval = nextInterval
 				}
 			}
@@ -87,3 +104,27 @@ func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 
 	return lastErr
 }
+
+// IsRetryable reports whether an operation that failed with err should be
+// retried. Only rate limits and transient errors are retried; cancelled or
+// timed out contexts and errors that are not classified are returned as is.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return interfaces.IsRetryableError(err)
+}
+
+// Delay returns how long to wait before retrying after err: the backoff
+// requested by the provider if it is longer than interval, otherwise interval.
+// The delay is capped at maximum, if it is positive.
+func Delay(err error, interval, maximum time.Duration) time.Duration {
+	delay := interval
+	if retryAfter, ok := interfaces.RetryAfterFromError(err); ok && retryAfter > interval {
+		delay = retryAfter
+	}
+	if maximum > 0 && delay > maximum {
+		delay = maximum
+	}
+	return delay
+}
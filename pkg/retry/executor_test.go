@@ -0,0 +1,80 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+func TestExecuteRetriesRetryableErrors(t *testing.T) {
+	executor := NewExecutor(NewPolicy(WithInitialInterval(time.Millisecond), WithMaxAttempts(3)))
+
+	calls := 0
+	err := executor.Execute(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return &interfaces.ProviderError{Kind: interfaces.ErrTransient}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestExecuteStopsOnNonRetryableErrors(t *testing.T) {
+	executor := NewExecutor(NewPolicy(WithInitialInterval(time.Millisecond), WithMaxAttempts(3)))
+	authErr := &interfaces.ProviderError{Kind: interfaces.ErrAuthentication, StatusCode: 401}
+
+	calls := 0
+	err := executor.Execute(context.Background(), func() error {
+		calls++
+		return authErr
+	})
+
+	assert.ErrorIs(t, err, interfaces.ErrAuthentication)
+	assert.Equal(t, 1, calls)
+}
+
+func TestExecuteStopsOnUnclassifiedErrors(t *testing.T) {
+	executor := NewExecutor(NewPolicy(WithInitialInterval(time.Millisecond), WithMaxAttempts(2)))
+
+	calls := 0
+	err := executor.Execute(context.Background(), func() error {
+		calls++
+		return errors.New("boom")
+	})
+
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 1, calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&interfaces.ProviderError{Kind: interfaces.ErrRateLimit}))
+	assert.True(t, IsRetryable(fmt.Errorf("request failed: %w", interfaces.ErrTransient)))
+	assert.False(t, IsRetryable(&interfaces.ProviderError{Kind: interfaces.ErrInvalidRequest, StatusCode: 409}))
+	assert.False(t, IsRetryable(errors.New("boom")))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+
+	// A transient error caused by a timed out context is not retried either
+	assert.False(t, IsRetryable(&interfaces.ProviderError{Kind: interfaces.ErrTransient, Err: context.DeadlineExceeded}))
+}
+
+func TestDelay(t *testing.T) {
+	rateLimited := &interfaces.ProviderError{Kind: interfaces.ErrRateLimit, RetryAfter: 5 * time.Second}
+
+	assert.Equal(t, 5*time.Second, Delay(rateLimited, time.Second, time.Minute))
+	assert.Equal(t, 10*time.Second, Delay(rateLimited, 10*time.Second, time.Minute))
+	assert.Equal(t, time.Second, Delay(errors.New("boom"), time.Second, time.Minute))
+
+	// A Retry-After longer than the maximum interval is capped
+	assert.Equal(t, 2*time.Second, Delay(rateLimited, time.Second, 2*time.Second))
+	assert.Equal(t, 5*time.Second, Delay(rateLimited, time.Second, 0))
+}
@@ -0,0 +1,89 @@
+package structuredoutput
+
+import "strings"
+
+// ExtractJSON extracts the JSON value from a model response that may wrap it
+// in a markdown code block or surround it with explanatory text. The response
+// is returned unchanged if it holds no JSON object or array.
+func ExtractJSON(response string) string {
+	trimmed := strings.TrimSpace(response)
+	if isJSONStart(trimmed) {
+		if value, ok := matchingJSON(trimmed, 0); ok && len(value) == len(trimmed) {
+			return trimmed
+		}
+	}
+
+	// First, try to find JSON within markdown code blocks
+	if start := strings.Index(response, "```json"); start >= 0 {
+		start += len("```json")
+		if end := strings.Index(response[start:], "```"); end > 0 {
+			return strings.TrimSpace(response[start : start+end])
+		}
+	}
+
+	// Try generic code blocks
+	if start := strings.Index(response, "```"); start >= 0 {
+		content := response[start+len("```"):]
+		if newline := strings.Index(content, "\n"); newline >= 0 {
+			content = content[newline+1:]
+		}
+		if end := strings.Index(content, "```"); end > 0 {
+			if extracted := strings.TrimSpace(content[:end]); isJSONStart(extracted) {
+				return extracted
+			}
+		}
+	}
+
+	// Look for the first object or array and its matching closing bracket
+	if start := strings.IndexAny(response, "{["); start >= 0 {
+		if value, ok := matchingJSON(response, start); ok {
+			return value
+		}
+	}
+
+	// If no JSON found, return original response
+	return response
+}
+
+// matchingJSON returns the object or array starting at start, up to its
+// matching closing bracket
+func matchingJSON(s string, start int) (string, bool) {
+	depth := 0
+	inString := false
+	escapeNext := false
+
+	for i := start; i < len(s); i++ {
+		char := s[i]
+		if escapeNext {
+			escapeNext = false
+			continue
+		}
+		if char == '\\' {
+			escapeNext = true
+			continue
+		}
+		if char == '"' {
+			inString = !inString
+			continue
+		}
+		if inString {
+			continue
+		}
+
+		switch char {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return s[start : i+1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// isJSONStart checks if a string starts like a JSON object or array
+func isJSONStart(s string) bool {
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
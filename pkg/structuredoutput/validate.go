@@ -0,0 +1,183 @@
+package structuredoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// ValidationError lists the ways a JSON value does not match a schema
+type ValidationError struct {
+	Issues []string // One issue per line, e.g. "$.items[0].price: expected number, got string"
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "response does not match the schema: " + strings.Join(e.Issues, "; ")
+}
+
+// Validate checks a JSON document against a schema such as the one built by
+// NewResponseFormat. It supports the keywords used there: type, properties,
+// required, items, additionalProperties and enum. Invalid JSON is returned as
+// a JSON syntax error, and a document that does not match the schema as a
+// *ValidationError listing every issue found.
+func Validate(schema interfaces.JSONSchema, data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	// Round-trip the schema so nested schemas are all map[string]interface{},
+	// whatever map types it was built with
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return fmt.Errorf("failed to decode schema: %w", err)
+	}
+
+	var issues []string
+	validateValue(normalized, value, "$", &issues)
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string, issues *[]string) {
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 && !containsValue(enum, value) {
+		*issues = append(*issues, fmt.Sprintf("%s: %s is not one of the allowed values", path, describe(value)))
+		return
+	}
+
+	if !matchesType(schema["type"], value) {
+		*issues = append(*issues, fmt.Sprintf("%s: expected %s, got %s", path, typeNames(schema["type"]), jsonType(value)))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, present := v[key]; !present {
+						*issues = append(*issues, fmt.Sprintf("%s: missing required property %q", path, key))
+					}
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childPath := path + "." + key
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				validateValue(propertySchema, v[key], childPath, issues)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*issues = append(*issues, fmt.Sprintf("%s: unexpected property", childPath))
+				}
+			case map[string]interface{}:
+				validateValue(additional, v[key], childPath, issues)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), issues)
+			}
+		}
+	}
+}
+
+// matchesType reports whether a value has the schema type, or one of the
+// schema types if it lists several. An unset type matches any value.
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return len(t) == 0
+	default:
+		return true
+	}
+}
+
+func matchesTypeName(name string, value interface{}) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "":
+		return true
+	default:
+		return jsonType(value) == name
+	}
+}
+
+// jsonType returns the JSON type name of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func typeNames(schemaType interface{}) string {
+	if names, ok := schemaType.([]interface{}); ok {
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprint(name))
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(schemaType)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if fmt.Sprint(v) == fmt.Sprint(value) && jsonType(v) == jsonType(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,108 @@
+package structuredoutput
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateAddress struct {
+	City string `json:"city"`
+}
+
+type validatePerson struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Tags    []string          `json:"tags,omitempty"`
+	Address validateAddress   `json:"address"`
+	Extra   map[string]string `json:"extra,omitempty"`
+}
+
+func TestValidate(t *testing.T) {
+	schema := NewResponseFormat(validatePerson{}).Schema
+
+	tests := []struct {
+		name   string
+		data   string
+		issues []string
+	}{
+		{
+			name: "valid",
+			data: `{"name": "Ada", "age": 36, "tags": ["math"], "address": {"city": "London"}, "extra": {"a": "b"}}`,
+		},
+		{
+			name:   "missing required",
+			data:   `{"name": "Ada", "address": {}}`,
+			issues: []string{`$: missing required property "age"`, `$.address: missing required property "city"`},
+		},
+		{
+			name:   "wrong types",
+			data:   `{"name": 1, "age": 36.5, "tags": ["a", 2], "address": {"city": "London"}, "extra": {"a": true}}`,
+			issues: []string{"$.age: expected integer, got number", "$.extra.a: expected string, got boolean", "$.name: expected string, got number", "$.tags[1]: expected string, got number"},
+		},
+		{
+			name:   "not an object",
+			data:   `[]`,
+			issues: []string{"$: expected object, got array"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(schema, []byte(tt.data))
+			if tt.issues == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr), "expected a ValidationError, got %v", err)
+			assert.Equal(t, tt.issues, validationErr.Issues)
+		})
+	}
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	err := Validate(NewResponseFormat(validatePerson{}).Schema, []byte(`{"name": `))
+	require.Error(t, err)
+	var validationErr *ValidationError
+	assert.False(t, errors.As(err, &validationErr))
+}
+
+func TestValidateEnumAndAdditionalProperties(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status": map[string]interface{}{"type": "string", "enum": []string{"open", "closed"}},
+		},
+		"additionalProperties": false,
+	}
+
+	assert.NoError(t, Validate(schema, []byte(`{"status": "open"}`)))
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(Validate(schema, []byte(`{"status": "pending", "id": 1}`)), &validationErr))
+	assert.Equal(t, []string{"$.id: unexpected property", `$.status: "pending" is not one of the allowed values`}, validationErr.Issues)
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{"plain object", `{"a": 1}`, `{"a": 1}`},
+		{"json code block", "Sure:\n```json\n{\"a\": 1}\n```\nDone", `{"a": 1}`},
+		{"generic code block", "```\n[1, 2]\n```", `[1, 2]`},
+		{"surrounding text", `The result is {"a": "}"} as requested`, `{"a": "}"}`},
+		{"array in text", `Items: [{"a": 1}] end`, `[{"a": 1}]`},
+		{"no JSON", "no json here", "no json here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractJSON(tt.response))
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package toolselection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultTopK is the number of tools selected by default, besides the pinned
+// tools
+const DefaultTopK = 10
+
+// Selector selects the tools relevant to a request, so that an agent with
+// many tools, e.g. from several MCP servers, sends only those to the model.
+// Tools are ranked by the similarity of the embeddings of their name and
+// description to the embedding of the request. The embeddings of the tools
+// are cached.
+type Selector struct {
+	embedder interfaces.Embedder
+	topK     int
+	pinned   map[string]bool
+
+	mu         sync.Mutex
+	embeddings map[string][]float32 // Embeddings of the tools by their text
+}
+
+// Option configures a Selector
+type Option func(*Selector)
+
+// WithTopK sets the number of tools selected besides the pinned tools
+func WithTopK(k int) Option {
+	return func(s *Selector) {
+		s.topK = k
+	}
+}
+
+// WithPinnedTools sets the tools that are always selected
+func WithPinnedTools(names ...string) Option {
+	return func(s *Selector) {
+		for _, name := range names {
+			s.pinned[name] = true
+		}
+	}
+}
+
+// NewSelector creates a tool selector embedding tools and requests with the
+// given embedder
+func NewSelector(embedder interfaces.Embedder, options ...Option) *Selector {
+	s := &Selector{
+		embedder:   embedder,
+		topK:       DefaultTopK,
+		pinned:     make(map[string]bool),
+		embeddings: make(map[string][]float32),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	if s.topK <= 0 {
+		s.topK = DefaultTopK
+	}
+	return s
+}
+
+// TopK returns the number of tools selected besides the pinned tools
+func (s *Selector) TopK() int {
+	return s.topK
+}
+
+// IsPinned reports whether the named tool is always selected
+func (s *Selector) IsPinned(name string) bool {
+	return s.pinned[name]
+}
+
+// Select returns the pinned tools and the TopK other tools most relevant to
+// the query, in the order of tools. All tools are returned if there are no
+// more than TopK besides the pinned tools.
+func (s *Selector) Select(ctx context.Context, query string, tools []interfaces.Tool) ([]interfaces.Tool, error) {
+	var candidates []interfaces.Tool
+	for _, tool := range tools {
+		if !s.pinned[tool.Name()] {
+			candidates = append(candidates, tool)
+		}
+	}
+	if len(candidates) <= s.topK {
+		return tools, nil
+	}
+
+	relevant, err := s.Search(ctx, query, candidates, s.topK)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(relevant))
+	for _, tool := range relevant {
+		selected[tool.Name()] = true
+	}
+
+	var result []interfaces.Tool
+	for _, tool := range tools {
+		if s.pinned[tool.Name()] || selected[tool.Name()] {
+			result = append(result, tool)
+		}
+	}
+	return result, nil
+}
+
+// Search returns up to k of the tools most relevant to the query, the most
+// relevant first. Tools named in the query come before the others.
+func (s *Selector) Search(ctx context.Context, query string, tools []interfaces.Tool, k int) ([]interfaces.Tool, error) {
+	if k <= 0 || len(tools) == 0 {
+		return nil, nil
+	}
+
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.'
+	}) {
+		words[word] = true
+	}
+	var named, others []interfaces.Tool
+	for _, tool := range tools {
+		if words[tool.Name()] {
+			named = append(named, tool)
+		} else {
+			others = append(others, tool)
+		}
+	}
+	if len(named) >= k || len(others) == 0 {
+		return append(named, others...)[:min(k, len(tools))], nil
+	}
+
+	queryEmbedding, err := s.embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the query: %w", err)
+	}
+	embeddings, err := s.embedTools(ctx, others)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float32, len(others))
+	for i, tool := range others {
+		score, err := s.embedder.CalculateSimilarity(queryEmbedding, embeddings[i], "cosine")
+		if err != nil {
+			return nil, fmt.Errorf("failed to score tool %s: %w", tool.Name(), err)
+		}
+		scores[tool.Name()] = score
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return scores[others[i].Name()] > scores[others[j].Name()]
+	})
+	return append(named, others[:min(k-len(named), len(others))]...), nil
+}
+
+// embedTools returns the embeddings of the tools, embedding those that are
+// not cached in a single batch
+func (s *Selector) embedTools(ctx context.Context, tools []interfaces.Tool) ([][]float32, error) {
+	texts := make([]string, len(tools))
+	embeddings := make([][]float32, len(tools))
+	var missing []string
+
+	s.mu.Lock()
+	for i, tool := range tools {
+		texts[i] = toolText(tool)
+		if embedding, ok := s.embeddings[texts[i]]; ok {
+			embeddings[i] = embedding
+		} else {
+			missing = append(missing, texts[i])
+		}
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	embedded, err := s.embedder.EmbedBatch(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed tools: %w", err)
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("failed to embed tools: got %d embeddings for %d tools", len(embedded), len(missing))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, text := range missing {
+		s.embeddings[text] = embedded[i]
+	}
+	for i, text := range texts {
+		embeddings[i] = s.embeddings[text]
+	}
+	return embeddings, nil
+}
+
+// toolText returns the text embedded for a tool
+func toolText(tool interfaces.Tool) string {
+	return tool.Name() + ": " + tool.Description()
+}
@@ -0,0 +1,140 @@
+package toolselection
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andmang/agent-sdk-go/pkg/interfaces"
+)
+
+// keywordEmbedder embeds texts by counting the keywords they contain
+type keywordEmbedder struct {
+	keywords []string
+	batches  [][]string
+	err      error
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	embedding := make([]float32, len(e.keywords))
+	for i, keyword := range e.keywords {
+		embedding[i] = float32(strings.Count(strings.ToLower(text), keyword))
+	}
+	return embedding, nil
+}
+
+func (e *keywordEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, texts)
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+func (e *keywordEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	var dot, norm1, norm2 float64
+	for i := range vec1 {
+		dot += float64(vec1[i] * vec2[i])
+		norm1 += float64(vec1[i] * vec1[i])
+		norm2 += float64(vec2[i] * vec2[i])
+	}
+	if norm1 == 0 || norm2 == 0 {
+		return 0, nil
+	}
+	return float32(dot / math.Sqrt(norm1*norm2)), nil
+}
+
+type testTool struct {
+	name, description string
+}
+
+func (t testTool) Name() string                                             { return t.name }
+func (t testTool) Description() string                                      { return t.description }
+func (t testTool) Parameters() map[string]interfaces.ParameterSpec          { return nil }
+func (t testTool) Run(ctx context.Context, input string) (string, error)    { return "", nil }
+func (t testTool) Execute(ctx context.Context, args string) (string, error) { return "", nil }
+
+func testTools() []interfaces.Tool {
+	return []interfaces.Tool{
+		testTool{"get_weather", "Get the weather forecast for a city"},
+		testTool{"send_email", "Send an email to a recipient"},
+		testTool{"create_event", "Create a calendar event"},
+		testTool{"query_db", "Run a query against the database"},
+		testTool{"read_file", "Read a file from disk"},
+	}
+}
+
+func names(tools []interfaces.Tool) []string {
+	var result []string
+	for _, tool := range tools {
+		result = append(result, tool.Name())
+	}
+	return result
+}
+
+func newEmbedder() *keywordEmbedder {
+	return &keywordEmbedder{keywords: []string{"weather", "email", "calendar", "database", "file"}}
+}
+
+func TestSelect(t *testing.T) {
+	embedder := newEmbedder()
+	selector := NewSelector(embedder, WithTopK(2), WithPinnedTools("read_file"))
+
+	selected, err := selector.Select(context.Background(), "Email me the weather", testTools())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"get_weather", "send_email", "read_file"}, names(selected))
+
+	// The tools keep their order
+	selected, err = selector.Select(context.Background(), "Look up the database and the weather", testTools())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"get_weather", "query_db", "read_file"}, names(selected))
+
+	// The embeddings of the tools are cached
+	assert.Len(t, embedder.batches, 1)
+	assert.Len(t, embedder.batches[0], 4)
+}
+
+func TestSelectFewTools(t *testing.T) {
+	embedder := newEmbedder()
+	selector := NewSelector(embedder, WithTopK(4), WithPinnedTools("read_file"))
+
+	selected, err := selector.Select(context.Background(), "Anything", testTools())
+	require.NoError(t, err)
+	assert.Len(t, selected, 5)
+	assert.Empty(t, embedder.batches)
+}
+
+func TestSearch(t *testing.T) {
+	selector := NewSelector(newEmbedder())
+
+	found, err := selector.Search(context.Background(), "I need read_file and something for email", testTools(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read_file", "send_email"}, names(found))
+
+	found, err = selector.Search(context.Background(), "database", testTools(), 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 5)
+	assert.Equal(t, "query_db", found[0].Name())
+}
+
+func TestSelectEmbeddingError(t *testing.T) {
+	embedder := newEmbedder()
+	embedder.err = errors.New("unavailable")
+	selector := NewSelector(embedder, WithTopK(1))
+
+	_, err := selector.Select(context.Background(), "weather", testTools())
+	assert.ErrorContains(t, err, "unavailable")
+}
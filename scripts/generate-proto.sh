@@ -12,17 +12,16 @@ if ! command -v protoc &> /dev/null; then
     exit 1
 fi
 
-# Check if protoc-gen-go is installed
-if ! command -v protoc-gen-go &> /dev/null; then
-    echo "Installing protoc-gen-go..."
-    go install google.golang.org/protobuf/cmd/protoc-gen-go@latest
-fi
+# Plugin versions the checked-in code was generated with
+PROTOC_GEN_GO_VERSION=v1.36.7
+PROTOC_GEN_GO_GRPC_VERSION=v1.5.1
 
-# Check if protoc-gen-go-grpc is installed
-if ! command -v protoc-gen-go-grpc &> /dev/null; then
-    echo "Installing protoc-gen-go-grpc..."
-    go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest
-fi
+# Install the pinned plugins into a temporary directory
+BIN_DIR="$(mktemp -d)"
+trap 'rm -rf "$BIN_DIR"' EXIT
+GOBIN="$BIN_DIR" go install google.golang.org/protobuf/cmd/protoc-gen-go@${PROTOC_GEN_GO_VERSION}
+GOBIN="$BIN_DIR" go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@${PROTOC_GEN_GO_GRPC_VERSION}
+export PATH="$BIN_DIR:$PATH"
 
 # Create output directory
 mkdir -p pkg/grpc/pb
@@ -30,10 +29,11 @@ mkdir -p pkg/grpc/pb
 # Generate Go code from proto files
 echo "Generating gRPC Go code..."
 protoc \
-    --go_out=. \
+    -I pkg/grpc/proto \
+    --go_out=pkg/grpc/pb \
     --go_opt=paths=source_relative \
-    --go-grpc_out=. \
+    --go-grpc_out=pkg/grpc/pb \
     --go-grpc_opt=paths=source_relative \
-    pkg/grpc/proto/agent.proto
+    agent.proto
 
 echo "gRPC Go code generated successfully!"